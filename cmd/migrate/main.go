@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/config"
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/migrator"
+)
+
+const configPath = "configs/config.yaml"
+
+const usage = `Usage: migrate [flags] <command> [args]
+
+Commands:
+  up             apply all pending migrations
+  down N         roll back N migrations
+  goto V         migrate up or down to version V
+  status         print the current and the latest versions
+  force V        set version V without running migrations (use -1 for none)
+  create NAME    create empty up and down migration files
+
+The storage (postgres or sqlite) is taken from the config.
+
+Flags:
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	dir := flag.String("dir", "", "directory for new migration files (create), defaults to the storage migrations")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := run(flag.Arg(0), flag.Args()[1:], *dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(command string, args []string, dir string) error {
+	config := config.New(configPath)
+
+	//Creating files does not need the database
+	if command == "create" {
+		if dir == "" {
+			dir = migrationsDir(config.DB.Storage)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("create requires a migration name")
+		}
+		up, down, err := migrator.Create(dir, args[0], time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s\nCreated %s\n", up, down)
+		return nil
+	}
+
+	m, err := migrator.New(config.DB.Storage, config.DB.Dsn(), logger.GetLogger(config.App.Env))
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch command {
+	case "up":
+		if err := m.Up(); err != nil {
+			return err
+		}
+	case "down":
+		n, err := intArg(args, "down requires the number of migrations")
+		if err != nil {
+			return err
+		}
+		if err := m.Down(n); err != nil {
+			return err
+		}
+	case "goto":
+		v, err := intArg(args, "goto requires a version")
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return fmt.Errorf("the version must not be negative")
+		}
+		if err := m.Goto(uint(v)); err != nil {
+			return err
+		}
+	case "force":
+		v, err := intArg(args, "force requires a version")
+		if err != nil {
+			return err
+		}
+		if err := m.Force(v); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	return printStatus(m)
+}
+
+func printStatus(m *migrator.Migrator) error {
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Version: %d\nDirty:   %t\nLatest:  %d\n", status.Version, status.Dirty, status.Latest)
+	if len(status.Pending) == 0 {
+		fmt.Println("Pending: none")
+		return nil
+	}
+	fmt.Println("Pending:")
+	for _, v := range status.Pending {
+		fmt.Printf("  %d\n", v)
+	}
+	return nil
+}
+
+func intArg(args []string, msg string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%s", msg)
+	}
+	n, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an integer", args[0])
+	}
+	return int(n), nil
+}
+
+func migrationsDir(storage string) string {
+	if storage == config.StorageSQLite {
+		return "migrations/sqlite"
+	}
+	return "migrations"
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/google/uuid"
+)
+
+// backend performs operations either through the REST API or directly in the database
+type backend interface {
+	//An empty status lists subscriptions with any status
+	List(ctx context.Context, userId *uuid.UUID, status string, page, limit int) ([]dto.Subscription, error)
+	Get(ctx context.Context, id int) (*dto.Subscription, error)
+	Create(ctx context.Context, req *dto.SubscriptionCreateRequest) (int, error)
+	Update(ctx context.Context, id int, req *dto.SubscriptionUpdateRequest) (*dto.Subscription, error)
+	Delete(ctx context.Context, id int) (*dto.Subscription, error)
+	Pause(ctx context.Context, id int, req *dto.PauseRequest) (*dto.Subscription, error)
+	Resume(ctx context.Context, id int, req *dto.ResumeRequest) (*dto.Subscription, error)
+	Cancel(ctx context.Context, id int, req *dto.CancelRequest) (*dto.Subscription, error)
+	Price(ctx context.Context, userId *uuid.UUID, serviceName *string, startDate, endDate, proration string) (int, error)
+	EndingTrials(ctx context.Context, userId *uuid.UUID, days int) ([]dto.Subscription, error)
+	Close()
+}
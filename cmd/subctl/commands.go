@@ -0,0 +1,469 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/google/uuid"
+)
+
+// optionalString is a string flag that tells whether it was set
+type optionalString struct {
+	value *string
+}
+
+func (o *optionalString) String() string {
+	if o.value == nil {
+		return ""
+	}
+	return *o.value
+}
+
+func (o *optionalString) Set(s string) error {
+	o.value = &s
+	return nil
+}
+
+// optionalInt is an int flag that tells whether it was set
+type optionalInt struct {
+	value *int
+}
+
+func (o *optionalInt) String() string {
+	if o.value == nil {
+		return ""
+	}
+	return strconv.Itoa(*o.value)
+}
+
+func (o *optionalInt) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("not an integer")
+	}
+	o.value = &n
+	return nil
+}
+
+func newFlagSet(name, args string) *flag.FlagSet {
+	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	set.Usage = func() {
+		fmt.Fprintf(set.Output(), "Usage: subctl %s [flags] %s\n", name, args)
+		set.PrintDefaults()
+	}
+	return set
+}
+
+func parseUserId(s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect user ID %q", s)
+	}
+	return &id, nil
+}
+
+func parseId(set *flag.FlagSet) (int, error) {
+	if set.NArg() != 1 {
+		set.Usage()
+		return 0, errors.New("exactly one subscription ID is required")
+	}
+	id, err := strconv.Atoi(set.Arg(0))
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("incorrect subscription ID %q", set.Arg(0))
+	}
+	return id, nil
+}
+
+func runList(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("list", "")
+	user := set.String("user", "", "show only subscriptions of this user")
+	status := set.String("status", "", "show only subscriptions with this status: active, trialing, paused, cancelled or expired")
+	page := set.Int("page", 1, "page number, starting from 1")
+	limit := set.Int("limit", 20, "number of subscriptions on a page")
+	all := set.Bool("all", false, "show all pages")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	userId, err := parseUserId(*user)
+	if err != nil {
+		return err
+	}
+
+	var subscriptions []dto.Subscription
+	if *all {
+		subscriptions, err = fetchAll(ctx, env.backend, userId, *status)
+	} else {
+		subscriptions, err = env.backend.List(ctx, userId, *status, *page, *limit)
+	}
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions(subscriptions)
+}
+
+func runGet(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("get", "ID")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseId(set)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := env.backend.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions([]dto.Subscription{*subscription})
+}
+
+func runCreate(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("create", "")
+	var req dto.SubscriptionCreateRequest
+	var endDate optionalString
+	var price, billingDay, trialMonths, trialPrice, serviceId, planId optionalInt
+	set.StringVar(&req.ServiceName, "service", "", "service name, the catalog name by default")
+	set.Var(&price, "price", "monthly price, the price of the plan by default")
+	set.StringVar(&req.UserId, "user", "", "user UUID")
+	set.StringVar(&req.StartDate, "start", "", "start date, YYYY-MM-DD or MM-YYYY")
+	set.Var(&endDate, "end", "end date, YYYY-MM-DD or MM-YYYY")
+	set.Var(&billingDay, "billing-day", "day of the month the subscription is charged on, the start day by default")
+	set.Var(&trialMonths, "trial-months", "number of the first billing cycles charged the trial price")
+	set.Var(&trialPrice, "trial-price", "price of a trial cycle, 0 by default")
+	set.Var(&serviceId, "service-id", "catalog service ID, found by the service name by default")
+	set.Var(&planId, "plan-id", "catalog plan ID")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	req.Price = price.value
+	req.ServiceId = serviceId.value
+	req.PlanId = planId.value
+	req.EndDate = endDate.value
+	req.BillingDay = billingDay.value
+	req.TrialMonths = trialMonths.value
+	req.TrialPrice = trialPrice.value
+
+	id, err := env.backend.Create(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := env.backend.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions([]dto.Subscription{*subscription})
+}
+
+func runUpdate(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("update", "ID")
+	var serviceName, startDate, endDate optionalString
+	var price, billingDay, trialMonths, trialPrice, serviceId, planId optionalInt
+	set.Var(&serviceName, "service", "new service name")
+	set.Var(&price, "price", "new monthly price")
+	set.Var(&startDate, "start", "new start date, YYYY-MM-DD or MM-YYYY")
+	set.Var(&endDate, "end", "new end date, YYYY-MM-DD or MM-YYYY")
+	set.Var(&billingDay, "billing-day", "new billing day")
+	set.Var(&trialMonths, "trial-months", "new number of trial cycles")
+	set.Var(&trialPrice, "trial-price", "new price of a trial cycle")
+	set.Var(&serviceId, "service-id", "new catalog service ID")
+	set.Var(&planId, "plan-id", "new catalog plan ID")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseId(set)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := env.backend.Update(ctx, id, &dto.SubscriptionUpdateRequest{
+		ServiceName: serviceName.value,
+		Price:       price.value,
+		StartDate:   startDate.value,
+		EndDate:     endDate.value,
+		BillingDay:  billingDay.value,
+		TrialMonths: trialMonths.value,
+		TrialPrice:  trialPrice.value,
+		ServiceId:   serviceId.value,
+		PlanId:      planId.value,
+	})
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions([]dto.Subscription{*subscription})
+}
+
+func runPause(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("pause", "ID")
+	var req dto.PauseRequest
+	var startDate, endDate optionalString
+	set.Var(&startDate, "start", "first paused day, YYYY-MM-DD or MM-YYYY, today by default")
+	set.Var(&endDate, "end", "last paused day, YYYY-MM-DD or MM-YYYY, paused until resumed by default")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseId(set)
+	if err != nil {
+		return err
+	}
+	req.StartDate = startDate.value
+	req.EndDate = endDate.value
+
+	subscription, err := env.backend.Pause(ctx, id, &req)
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions([]dto.Subscription{*subscription})
+}
+
+func runResume(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("resume", "ID")
+	var date optionalString
+	set.Var(&date, "date", "first billed day after the pause, YYYY-MM-DD, today by default")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseId(set)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := env.backend.Resume(ctx, id, &dto.ResumeRequest{Date: date.value})
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions([]dto.Subscription{*subscription})
+}
+
+func runCancel(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("cancel", "ID")
+	var date, reason optionalString
+	atPeriodEnd := set.Bool("at-period-end", false, "end the subscription on the last day of the current billing cycle")
+	set.Var(&date, "date", "last day of the subscription, YYYY-MM-DD or MM-YYYY, today by default")
+	set.Var(&reason, "reason", "why the subscription is cancelled")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseId(set)
+	if err != nil {
+		return err
+	}
+	if *atPeriodEnd && date.value != nil {
+		return errors.New("set either -at-period-end or -date, not both")
+	}
+
+	subscription, err := env.backend.Cancel(ctx, id, &dto.CancelRequest{AtPeriodEnd: *atPeriodEnd, Date: date.value, Reason: reason.value})
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions([]dto.Subscription{*subscription})
+}
+
+func runDelete(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("delete", "ID")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseId(set)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := env.backend.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions([]dto.Subscription{*subscription})
+}
+
+func runImport(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("import", "FILE")
+	format := set.String("format", "", "json or csv (default by the file extension, json for stdin)")
+	keepGoing := set.Bool("continue", false, "continue after a subscription fails to import")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() != 1 {
+		set.Usage()
+		return errors.New("a file to import is required, use - for stdin")
+	}
+
+	path := set.Arg(0)
+	f := env.stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		f = file
+	}
+	if *format == "" {
+		*format = formatJSON
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			*format = formatCSV
+		}
+	}
+
+	requests, err := readSubscriptions(f, *format)
+	if err != nil {
+		return err
+	}
+
+	var created []dto.Subscription
+	failed := 0
+	for i := range requests {
+		id, err := env.backend.Create(ctx, &requests[i])
+		if err != nil {
+			failed++
+			fmt.Fprintf(env.stderr, "subscription #%d (%s): %v\n", i+1, requests[i].ServiceName, err)
+			if !*keepGoing {
+				break
+			}
+			continue
+		}
+
+		subscription, err := env.backend.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		created = append(created, *subscription)
+	}
+
+	if err := env.out.subscriptions(created); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("imported %d of %d subscriptions", len(created), len(requests))
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("export", "")
+	user := set.String("user", "", "export only subscriptions of this user")
+	file := set.String("file", "", "write to the file instead of stdout")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	userId, err := parseUserId(*user)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := fetchAll(ctx, env.backend, userId, "")
+	if err != nil {
+		return err
+	}
+
+	out := env.out
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = &printer{w: f, format: out.format}
+	}
+	return out.subscriptions(subscriptions)
+}
+
+func runPrice(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("price", "")
+	var serviceName optionalString
+	user := set.String("user", "", "count only subscriptions of this user")
+	set.Var(&serviceName, "service", "count only subscriptions of this service")
+	startDate := set.String("start", "", "start of the period, YYYY-MM-DD or MM-YYYY")
+	endDate := set.String("end", "", "end of the period, YYYY-MM-DD or MM-YYYY")
+	proration := set.String("proration", "", "billing of partial months: monthly (default) or daily")
+	byService := set.Bool("by-service", false, "break the total down by the user's services (requires -user)")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	userId, err := parseUserId(*user)
+	if err != nil {
+		return err
+	}
+	if *byService && userId == nil {
+		return errors.New("-by-service requires -user")
+	}
+
+	row := func(serviceName *string, price int) reportRow {
+		r := reportRow{StartDate: *startDate, EndDate: *endDate, Price: price}
+		if userId != nil {
+			r.UserId = userId.String()
+		}
+		if serviceName != nil {
+			r.ServiceName = *serviceName
+		}
+		return r
+	}
+
+	var report []reportRow
+	if *byService {
+		subscriptions, err := fetchAll(ctx, env.backend, userId, "")
+		if err != nil {
+			return err
+		}
+		services := make(map[string]struct{})
+		for _, s := range subscriptions {
+			services[s.ServiceName] = struct{}{}
+		}
+		names := make([]string, 0, len(services))
+		for name := range services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			price, err := env.backend.Price(ctx, userId, &name, *startDate, *endDate, *proration)
+			if err != nil {
+				return err
+			}
+			report = append(report, row(&name, price))
+		}
+	}
+
+	total, err := env.backend.Price(ctx, userId, serviceName.value, *startDate, *endDate, *proration)
+	if err != nil {
+		return err
+	}
+	totalRow := row(serviceName.value, total)
+	if *byService {
+		totalRow.ServiceName = "TOTAL"
+	}
+	report = append(report, totalRow)
+
+	return env.out.report(report)
+}
+
+func runTrials(ctx context.Context, env *cmdEnv, args []string) error {
+	set := newFlagSet("trials", "")
+	user := set.String("user", "", "show only subscriptions of this user")
+	days := set.Int("days", 7, "number of days from today")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	userId, err := parseUserId(*user)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := env.backend.EndingTrials(ctx, userId, *days)
+	if err != nil {
+		return err
+	}
+	return env.out.subscriptions(subscriptions)
+}
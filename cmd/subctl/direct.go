@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/Estriper0/subscription_service/internal/handlers"
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/repository/db"
+	"github.com/Estriper0/subscription_service/internal/service"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/Estriper0/subscription_service/pkg/postgres"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const directPoolSize = 2
+
+// directBackend works with the database through the service layer, bypassing the API
+type directBackend struct {
+	pool     *pgxpool.Pool
+	service  *service.SubscriptionService
+	validate *validator.Validate
+}
+
+// nopMetrics discards the service metrics, they only make sense in the long-running API
+type nopMetrics struct{}
+
+func (nopMetrics) IncServiceError(method, reason string) {}
+
+// directContext scopes the queries to the organization.
+// Like the administrative server, without one the subscriptions of all the organizations are visible,
+// the commands that change them are rejected before.
+func directContext(ctx context.Context, organizationId int) context.Context {
+	if organizationId == 0 {
+		return tenant.Bypass(ctx)
+	}
+	return tenant.WithOrganization(ctx, organizationId)
+}
+
+func newDirectBackend(dbURL string) (*directBackend, error) {
+	pool, err := postgres.New(dbURL, directPoolSize, db.TenantSetting, db.BypassSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	validate, err := handlers.NewValidator()
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := db.NewSubscriptionRepo(pool)
+	return &directBackend{
+		pool:     pool,
+		service:  service.NewSubscriptionService(repo, repo, repo, repo, repo, repo, repo, nopMetrics{}, logger),
+		validate: validate,
+	}, nil
+}
+
+func (b *directBackend) List(ctx context.Context, userId *uuid.UUID, status string, page, limit int) ([]dto.Subscription, error) {
+	if err := b.validate.Var(status, "omitempty,oneof=active trialing paused cancelled expired"); err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * limit
+
+	var (
+		subscriptions []*domain.Subscription
+		err           error
+	)
+	if userId != nil {
+		subscriptions, err = b.service.GetByUser(ctx, *userId, offset, limit, domain.ListFilter{Status: domain.Status(status)})
+	} else {
+		subscriptions, err = b.service.GetAll(ctx, offset, limit, domain.ListFilter{Status: domain.Status(status)})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]dto.Subscription, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		res = append(res, toDTO(s))
+	}
+	return res, nil
+}
+
+func (b *directBackend) Get(ctx context.Context, id int) (*dto.Subscription, error) {
+	subscription, err := b.service.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := toDTO(subscription)
+	return &res, nil
+}
+
+func (b *directBackend) Create(ctx context.Context, req *dto.SubscriptionCreateRequest) (int, error) {
+	if err := b.validate.Struct(req); err != nil {
+		return 0, err
+	}
+
+	//The user ID is validated as uuid4 above
+	userId, _ := uuid.Parse(req.UserId)
+	return b.service.Create(ctx, &domain.SubscriptionCreate{
+		ServiceName: req.ServiceName,
+		Price:       req.Price,
+		UserId:      userId,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		BillingDay:  req.BillingDay,
+		TrialMonths: req.TrialMonths,
+		TrialPrice:  req.TrialPrice,
+		ServiceId:   req.ServiceId,
+		PlanId:      req.PlanId,
+	})
+}
+
+func (b *directBackend) Update(ctx context.Context, id int, req *dto.SubscriptionUpdateRequest) (*dto.Subscription, error) {
+	if err := b.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	subscription, err := b.service.Update(ctx, &domain.SubscriptionUpdate{
+		Id:          id,
+		ServiceName: req.ServiceName,
+		Price:       req.Price,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		BillingDay:  req.BillingDay,
+		TrialMonths: req.TrialMonths,
+		TrialPrice:  req.TrialPrice,
+		ServiceId:   req.ServiceId,
+		PlanId:      req.PlanId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := toDTO(subscription)
+	return &res, nil
+}
+
+func (b *directBackend) Delete(ctx context.Context, id int) (*dto.Subscription, error) {
+	subscription, err := b.service.DeleteById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := toDTO(subscription)
+	return &res, nil
+}
+
+func (b *directBackend) Pause(ctx context.Context, id int, req *dto.PauseRequest) (*dto.Subscription, error) {
+	if err := b.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	subscription, err := b.service.Pause(ctx, &domain.PauseCreate{
+		SubscriptionId: id,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := toDTO(subscription)
+	return &res, nil
+}
+
+func (b *directBackend) Resume(ctx context.Context, id int, req *dto.ResumeRequest) (*dto.Subscription, error) {
+	if err := b.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	subscription, err := b.service.Resume(ctx, &domain.Resume{
+		SubscriptionId: id,
+		Date:           req.Date,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := toDTO(subscription)
+	return &res, nil
+}
+
+func (b *directBackend) Cancel(ctx context.Context, id int, req *dto.CancelRequest) (*dto.Subscription, error) {
+	if err := b.validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	subscription, err := b.service.Cancel(ctx, &domain.Cancel{
+		SubscriptionId: id,
+		AtPeriodEnd:    req.AtPeriodEnd,
+		Date:           req.Date,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := toDTO(subscription)
+	return &res, nil
+}
+
+func (b *directBackend) Price(ctx context.Context, userId *uuid.UUID, serviceName *string, startDate, endDate, proration string) (int, error) {
+	for _, date := range []string{startDate, endDate} {
+		if err := b.validate.Var(date, "required,date"); err != nil {
+			return 0, err
+		}
+	}
+	if err := b.validate.Var(proration, "omitempty,oneof=monthly daily"); err != nil {
+		return 0, err
+	}
+	filter := &domain.PriceFilter{
+		UserId:      userId,
+		ServiceName: serviceName,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Proration:   domain.ProrationMonthly,
+	}
+	if proration != "" {
+		filter.Proration = domain.Proration(proration)
+	}
+
+	report, err := b.service.GetPriceByFilter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return report.Total, nil
+}
+
+func (b *directBackend) EndingTrials(ctx context.Context, userId *uuid.UUID, days int) ([]dto.Subscription, error) {
+	if err := b.validate.Var(days, "gte=0,lte=366"); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := b.service.GetEndingTrials(ctx, userId, days)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]dto.Subscription, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		res = append(res, toDTO(s))
+	}
+	return res, nil
+}
+
+func (b *directBackend) Close() {
+	b.pool.Close()
+}
+
+func toDTO(s *domain.Subscription) dto.Subscription {
+	res := dto.Subscription{
+		Id:              s.Id,
+		ServiceName:     s.ServiceName,
+		Price:           s.Price,
+		UserId:          s.UserId,
+		StartDate:       s.StartDate,
+		EndDate:         s.EndDate,
+		BillingDay:      s.BillingDay,
+		NextBillingDate: s.NextBillingDate,
+		TrialMonths:     s.TrialMonths,
+		TrialPrice:      s.TrialPrice,
+		TrialEndsAt:     s.TrialEndsAt,
+		Status:          string(s.Status),
+		CancelledAt:     s.CancelledAt,
+		CancelReason:    s.CancelReason,
+		ServiceId:       s.ServiceId,
+		PlanId:          s.PlanId,
+	}
+	for _, p := range s.Pauses {
+		res.Pauses = append(res.Pauses, dto.Pause{
+			Id:        p.Id,
+			StartDate: p.StartDate,
+			EndDate:   p.EndDate,
+		})
+	}
+	return res
+}
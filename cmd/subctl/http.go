@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/handlers"
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/google/uuid"
+)
+
+type httpBackend struct {
+	baseURL string
+	//Sent in the X-Organization-Id header, the API requires it
+	organizationId int
+	client         *http.Client
+}
+
+func newHTTPBackend(baseURL string, organizationId int) *httpBackend {
+	return &httpBackend{
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		organizationId: organizationId,
+		client:         &http.Client{},
+	}
+}
+
+// apiError is a problem document returned by the API
+type apiError handlers.ErrorResponse
+
+func (e *apiError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d)", e.Title, e.Status)
+	if e.Detail != "" {
+		fmt.Fprintf(&b, ": %s", e.Detail)
+	}
+	for _, fe := range e.Errors {
+		fmt.Fprintf(&b, "\n  %s: %s", fe.Field, fe.Message)
+	}
+	if e.RequestId != "" {
+		fmt.Fprintf(&b, "\n  request id: %s", e.RequestId)
+	}
+	return b.String()
+}
+
+func (b *httpBackend) List(ctx context.Context, userId *uuid.UUID, status string, page, limit int) ([]dto.Subscription, error) {
+	path := "/subscription/"
+	if userId != nil {
+		path = "/subscription/user/" + userId.String()
+	}
+	query := url.Values{
+		"page":  {strconv.Itoa(page)},
+		"limit": {strconv.Itoa(limit)},
+	}
+	if status != "" {
+		query.Set("status", status)
+	}
+
+	var res struct {
+		Subscriptions []dto.Subscription `json:"subscriptions"`
+	}
+	err := b.do(ctx, http.MethodGet, path, query, nil, &res)
+	return res.Subscriptions, err
+}
+
+func (b *httpBackend) Get(ctx context.Context, id int) (*dto.Subscription, error) {
+	var res struct {
+		Subscription dto.Subscription `json:"subscription"`
+	}
+	err := b.do(ctx, http.MethodGet, "/subscription/"+strconv.Itoa(id), nil, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Subscription, nil
+}
+
+func (b *httpBackend) Create(ctx context.Context, req *dto.SubscriptionCreateRequest) (int, error) {
+	var res struct {
+		Id int `json:"id"`
+	}
+	err := b.do(ctx, http.MethodPost, "/subscription/", nil, req, &res)
+	return res.Id, err
+}
+
+func (b *httpBackend) Update(ctx context.Context, id int, req *dto.SubscriptionUpdateRequest) (*dto.Subscription, error) {
+	var res struct {
+		Subscription dto.Subscription `json:"subscription"`
+	}
+	err := b.do(ctx, http.MethodPatch, "/subscription/"+strconv.Itoa(id), nil, req, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Subscription, nil
+}
+
+func (b *httpBackend) Delete(ctx context.Context, id int) (*dto.Subscription, error) {
+	var res struct {
+		Subscription dto.Subscription `json:"subscription"`
+	}
+	err := b.do(ctx, http.MethodDelete, "/subscription/"+strconv.Itoa(id), nil, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Subscription, nil
+}
+
+func (b *httpBackend) Pause(ctx context.Context, id int, req *dto.PauseRequest) (*dto.Subscription, error) {
+	var res struct {
+		Subscription dto.Subscription `json:"subscription"`
+	}
+	err := b.do(ctx, http.MethodPost, "/subscription/"+strconv.Itoa(id)+"/pause", nil, req, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Subscription, nil
+}
+
+func (b *httpBackend) Resume(ctx context.Context, id int, req *dto.ResumeRequest) (*dto.Subscription, error) {
+	var res struct {
+		Subscription dto.Subscription `json:"subscription"`
+	}
+	err := b.do(ctx, http.MethodPost, "/subscription/"+strconv.Itoa(id)+"/resume", nil, req, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Subscription, nil
+}
+
+func (b *httpBackend) Cancel(ctx context.Context, id int, req *dto.CancelRequest) (*dto.Subscription, error) {
+	var res struct {
+		Subscription dto.Subscription `json:"subscription"`
+	}
+	err := b.do(ctx, http.MethodPost, "/subscription/"+strconv.Itoa(id)+"/cancel", nil, req, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res.Subscription, nil
+}
+
+func (b *httpBackend) Price(ctx context.Context, userId *uuid.UUID, serviceName *string, startDate, endDate, proration string) (int, error) {
+	query := url.Values{
+		"start_date": {startDate},
+		"end_date":   {endDate},
+	}
+	if userId != nil {
+		query.Set("user_id", userId.String())
+	}
+	if serviceName != nil {
+		query.Set("service_name", *serviceName)
+	}
+	if proration != "" {
+		query.Set("proration", proration)
+	}
+
+	var res struct {
+		Price int `json:"price"`
+	}
+	err := b.do(ctx, http.MethodGet, "/subscription/price", query, nil, &res)
+	return res.Price, err
+}
+
+func (b *httpBackend) EndingTrials(ctx context.Context, userId *uuid.UUID, days int) ([]dto.Subscription, error) {
+	query := url.Values{
+		"days": {strconv.Itoa(days)},
+	}
+	if userId != nil {
+		query.Set("user_id", userId.String())
+	}
+
+	var res struct {
+		Subscriptions []dto.Subscription `json:"subscriptions"`
+	}
+	err := b.do(ctx, http.MethodGet, "/subscription/trials", query, nil, &res)
+	return res.Subscriptions, err
+}
+
+func (b *httpBackend) Close() {}
+
+func (b *httpBackend) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	target := b.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding the request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("creating the request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if b.organizationId != 0 {
+		req.Header.Set("X-Organization-Id", strconv.Itoa(b.organizationId))
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var problem apiError
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil || problem.Status == 0 {
+			return fmt.Errorf("%s %s: unexpected status %s", method, path, resp.Status)
+		}
+		return &problem
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("decoding the response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `subctl manages subscriptions through the API or directly in the database.
+
+Usage: subctl [global flags] <command> [flags]
+
+Commands:
+  list      list subscriptions, optionally of one user
+  get       show a subscription
+  create    create a subscription
+  update    change fields of a subscription
+  delete    delete a subscription
+  pause     pause billing of a subscription
+  resume    resume a paused subscription
+  cancel    cancel a subscription now, on a date or at the end of the billing cycle
+  import    create subscriptions from a JSON or CSV file
+  export    write all subscriptions as JSON or CSV
+  price     report the total cost of subscriptions for a period
+  trials    list subscriptions whose trial ends soon
+
+Run "subctl <command> -h" for the command flags.
+
+Global flags:
+`
+
+type command struct {
+	name string
+	run  func(ctx context.Context, env *cmdEnv, args []string) error
+	//The command changes subscriptions, with --direct it needs --organization
+	writes bool
+}
+
+var commands = []command{
+	{"list", runList, false},
+	{"get", runGet, false},
+	{"create", runCreate, true},
+	{"update", runUpdate, true},
+	{"delete", runDelete, true},
+	{"import", runImport, true},
+	{"export", runExport, false},
+	{"pause", runPause, true},
+	{"resume", runResume, true},
+	{"cancel", runCancel, true},
+	{"price", runPrice, false},
+	{"trials", runTrials, false},
+}
+
+// cmdEnv is what every command gets to work with
+type cmdEnv struct {
+	backend backend
+	out     *printer
+	stdin   io.Reader
+	stderr  io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	global := newGlobalFlags()
+	global.set.Usage = func() {
+		fmt.Fprint(global.set.Output(), usage)
+		global.set.PrintDefaults()
+	}
+	if err := global.set.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if global.set.NArg() == 0 {
+		global.set.Usage()
+		return 2
+	}
+
+	name := global.set.Arg(0)
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		global.set.Usage()
+		return 2
+	}
+
+	settings, err := global.resolve()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	//Without an organization the direct queries see all the organizations, the changes belong to one
+	if settings.Direct && settings.Organization == 0 && cmd.writes {
+		fmt.Fprintf(os.Stderr, "--direct %s requires --organization\n", cmd.name)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), settings.Timeout)
+	defer cancel()
+
+	var b backend
+	if settings.Direct {
+		b, err = newDirectBackend(settings.DBUrl)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		ctx = directContext(ctx, settings.Organization)
+	} else {
+		b = newHTTPBackend(settings.URL, settings.Organization)
+	}
+	defer b.Close()
+
+	env := &cmdEnv{
+		backend: b,
+		out:     &printer{w: os.Stdout, format: settings.Output},
+		stdin:   os.Stdin,
+		stderr:  os.Stderr,
+	}
+	err = cmd.run(ctx, env, global.set.Args()[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
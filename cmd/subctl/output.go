@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+var subscriptionColumns = []string{"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_day", "trial_months", "trial_price", "trial_ends_at", "status", "cancelled_at"}
+
+func validFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatCSV
+}
+
+// reportRow is one line of the price report
+type reportRow struct {
+	UserId      string `json:"user_id,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Price       int    `json:"price"`
+}
+
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p *printer) subscriptions(subscriptions []dto.Subscription) error {
+	if p.format == formatJSON {
+		return p.json(subscriptions)
+	}
+
+	rows := make([][]string, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		rows = append(rows, subscriptionRow(s))
+	}
+	return p.rows(subscriptionColumns, rows)
+}
+
+func (p *printer) report(report []reportRow) error {
+	if p.format == formatJSON {
+		return p.json(report)
+	}
+
+	rows := make([][]string, 0, len(report))
+	for _, r := range report {
+		rows = append(rows, []string{r.UserId, r.ServiceName, r.StartDate, r.EndDate, strconv.Itoa(r.Price)})
+	}
+	return p.rows([]string{"user_id", "service_name", "start_date", "end_date", "price"}, rows)
+}
+
+func (p *printer) json(v any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (p *printer) rows(header []string, rows [][]string) error {
+	if p.format == formatCSV {
+		w := csv.NewWriter(p.w)
+		if err := w.Write(header); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+func subscriptionRow(s dto.Subscription) []string {
+	endDate, trialEndsAt, cancelledAt := "", "", ""
+	if s.EndDate != nil {
+		endDate = *s.EndDate
+	}
+	if s.CancelledAt != nil {
+		cancelledAt = *s.CancelledAt
+	}
+	if s.TrialEndsAt != nil {
+		trialEndsAt = *s.TrialEndsAt
+	}
+	return []string{
+		strconv.Itoa(s.Id),
+		s.ServiceName,
+		strconv.Itoa(s.Price),
+		s.UserId.String(),
+		s.StartDate,
+		endDate,
+		strconv.Itoa(s.BillingDay),
+		strconv.Itoa(s.TrialMonths),
+		strconv.Itoa(s.TrialPrice),
+		trialEndsAt,
+		s.Status,
+		cancelledAt,
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/google/uuid"
+)
+
+const exportPageSize = 100
+
+// readSubscriptions reads subscriptions to import from JSON (an array) or CSV with a header row.
+// CSV columns are matched by name, so files produced by export can be imported back.
+func readSubscriptions(r io.Reader, format string) ([]dto.SubscriptionCreateRequest, error) {
+	switch format {
+	case formatJSON:
+		var res []dto.SubscriptionCreateRequest
+		if err := json.NewDecoder(r).Decode(&res); err != nil {
+			return nil, fmt.Errorf("decoding JSON: %w", err)
+		}
+		return res, nil
+	case formatCSV:
+		return readCSV(r)
+	}
+	return nil, fmt.Errorf("cannot import the %q format", format)
+}
+
+func readCSV(r io.Reader) ([]dto.SubscriptionCreateRequest, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	for _, name := range []string{"service_name", "price", "user_id", "start_date"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the CSV header has no %q column", name)
+		}
+	}
+	get := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var res []dto.SubscriptionCreateRequest
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV line %d: %w", line, err)
+		}
+
+		price, err := strconv.Atoi(get(record, "price"))
+		if err != nil {
+			return nil, fmt.Errorf("CSV line %d: the price is not an integer", line)
+		}
+		req := dto.SubscriptionCreateRequest{
+			ServiceName: get(record, "service_name"),
+			Price:       &price,
+			UserId:      get(record, "user_id"),
+			StartDate:   get(record, "start_date"),
+		}
+		if endDate := get(record, "end_date"); endDate != "" {
+			req.EndDate = &endDate
+		}
+		for _, field := range []struct {
+			column, name string
+			value        **int
+		}{
+			{"billing_day", "billing day", &req.BillingDay},
+			{"trial_months", "number of trial months", &req.TrialMonths},
+			{"trial_price", "trial price", &req.TrialPrice},
+		} {
+			value := get(record, field.column)
+			if value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("CSV line %d: the %s is not an integer", line, field.name)
+			}
+			*field.value = &n
+		}
+		res = append(res, req)
+	}
+
+	return res, nil
+}
+
+// fetchAll walks all pages of the subscription list, an empty status lists subscriptions with any status
+func fetchAll(ctx context.Context, b backend, userId *uuid.UUID, status string) ([]dto.Subscription, error) {
+	var res []dto.Subscription
+	for page := 1; ; page++ {
+		subscriptions, err := b.List(ctx, userId, status, page, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, subscriptions...)
+		if len(subscriptions) < exportPageSize {
+			return res, nil
+		}
+	}
+}
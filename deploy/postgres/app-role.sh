@@ -0,0 +1,15 @@
+#!/bin/sh
+# Creates the role the application and the migrations connect with.
+# Row-level security doesn't apply to superusers, so they must not connect as POSTGRES_USER.
+# The script runs once, when the volume of the database is created.
+set -e
+
+psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" \
+    -v app_user="$DB_USER" -v app_password="$DB_PASSWORD" -v app_db="$POSTGRES_DB" <<'EOSQL'
+CREATE ROLE :"app_user" LOGIN PASSWORD :'app_password' NOSUPERUSER NOBYPASSRLS NOCREATEROLE;
+-- The owner of the database creates the tables and the trusted extensions in the public schema
+ALTER DATABASE :"app_db" OWNER TO :"app_user";
+-- The migrations grant the role to the user running them, which needs the admin option without CREATEROLE
+CREATE ROLE tenant_bypass NOLOGIN;
+GRANT tenant_bypass TO :"app_user" WITH ADMIN OPTION;
+EOSQL
@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscription": {
+        "/budget": {
             "post": {
-                "description": "Создаёт новую подписку для пользователя",
+                "description": "Создаёт месячный бюджет пользователя: общий, на категорию или на сервис. У пользователя не больше одного бюджета на каждую категорию, сервис и общий.\nЕсли расходы текущего месяца уже достигли 80% или 100% бюджета, сразу создаётся уведомление.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,23 +25,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription"
+                    "budget"
                 ],
-                "summary": "Создать новую подписку",
+                "summary": "Создать бюджет",
                 "parameters": [
                     {
-                        "description": "Данные для создания подписки",
+                        "description": "Данные бюджета",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.SubscriptionCreateRequest"
+                            "$ref": "#/definitions/dto.BudgetCreateRequest"
                         }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "400": {
-                        "description": "Неверные входные данные",
+                        "description": "Неверные входные данные, неизвестная категория или сервис",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Бюджет для этой категории или сервиса уже есть",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -55,9 +68,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscription/": {
+        "/budget/": {
             "get": {
-                "description": "Возвращает список всех подписок",
+                "description": "Возвращает бюджеты пользователя",
                 "consumes": [
                     "application/json"
                 ],
@@ -65,30 +78,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription"
+                    "budget"
                 ],
-                "summary": "Получить все подписки",
+                "summary": "Получить бюджеты пользователя",
                 "parameters": [
                     {
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "Номер страницы",
-                        "name": "page",
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "minimum": 0,
                         "type": "integer",
-                        "description": "Количество записей на странице",
-                        "name": "limit",
-                        "in": "query",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Бюджеты",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.Budget"
+                                }
+                            }
+                        }
+                    },
                     "400": {
-                        "description": "Неверные входные данные",
+                        "description": "Неверный формат UUID",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -102,9 +126,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscription/price": {
+        "/budget/alerts": {
             "get": {
-                "description": "Рассчитывает общую стоимость подписок по заданным фильтрам (пользователь, сервис, период)",
+                "description": "Возвращает уведомления о достижении 80% и 100% бюджетов пользователя. Уведомление создаётся при изменении бюджета или подписки не больше одного раза за месяц на каждый порог.",
                 "consumes": [
                     "application/json"
                 ],
@@ -112,41 +136,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription"
+                    "budget"
                 ],
-                "summary": "Получить сумму подписок по фильтрам",
+                "summary": "Получить уведомления о бюджетах",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID пользователя для фильтрации",
+                        "description": "UUID пользователя",
                         "name": "user_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Название сервиса для фильтрации",
-                        "name": "service_name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Начальная дата для подсчета суммы",
-                        "name": "start_date",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Конечная дата для подсчета суммы",
-                        "name": "end_date",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Уведомления",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.BudgetAlert"
+                                }
+                            }
+                        }
+                    },
                     "400": {
-                        "description": "Неверные входные данные",
+                        "description": "Неверный формат UUID",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -160,9 +184,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscription/user/{user_id}": {
+        "/budget/status": {
             "get": {
-                "description": "Возвращает список всех подписок пользователя по его ID",
+                "description": "Возвращает расходы месяца по каждому бюджету пользователя, их процент от бюджета и уровень: ok, warning от 80%, exceeded от 100%.\nРасходы считаются как в /subscription/price с помесячным пропорциональным расчётом.",
                 "consumes": [
                     "application/json"
                 ],
@@ -170,38 +194,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription"
+                    "budget"
                 ],
-                "summary": "Получить подписки пользователя",
+                "summary": "Получить состояние бюджетов",
                 "parameters": [
                     {
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "Номер страницы",
-                        "name": "page",
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "Количество записей на странице",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "example": "10-2026",
+                        "description": "Месяц в формате MM-YYYY, по умолчанию текущий",
+                        "name": "month",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "UUID пользователя",
-                        "name": "user_id",
-                        "in": "path",
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "Состояние бюджетов",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.BudgetStatus"
+                                }
+                            }
+                        }
+                    },
                     "400": {
-                        "description": "Неверный формат UUID или параметры запроса",
+                        "description": "Неверные входные данные",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -215,9 +249,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscription/{id}": {
+        "/budget/{id}": {
             "get": {
-                "description": "Возвращает информацию о конкретной подписке по её ID",
+                "description": "Возвращает бюджет по ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -225,17 +259,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription"
+                    "budget"
                 ],
-                "summary": "Получить подписку по ID",
+                "summary": "Получить бюджет",
                 "parameters": [
                     {
                         "minimum": 0,
                         "type": "integer",
-                        "description": "ID подписки",
+                        "description": "ID бюджета",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -246,7 +287,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Подписка не найдена",
+                        "description": "Бюджет не найден",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -260,7 +301,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Удаляет подписку по её ID и возвращает информацию об удалённой подписке",
+                "description": "Удаляет бюджет вместе с его уведомлениями",
                 "consumes": [
                     "application/json"
                 ],
@@ -268,17 +309,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription"
+                    "budget"
                 ],
-                "summary": "Удалить подписку по ID",
+                "summary": "Удалить бюджет",
                 "parameters": [
                     {
                         "minimum": 0,
                         "type": "integer",
-                        "description": "ID подписки для удаления",
+                        "description": "ID бюджета",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -289,7 +337,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Подписка не найдена",
+                        "description": "Бюджет не найден",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -303,7 +351,7 @@ const docTemplate = `{
                 }
             },
             "patch": {
-                "description": "Обновляет информацию о существующей подписке",
+                "description": "Изменяет сумму бюджета. Если расходы текущего месяца достигли 80% или 100% новой суммы, создаётся уведомление.",
                 "consumes": [
                     "application/json"
                 ],
@@ -311,26 +359,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscription"
+                    "budget"
                 ],
-                "summary": "Обновить подписку",
+                "summary": "Изменить сумму бюджета",
                 "parameters": [
                     {
                         "minimum": 0,
                         "type": "integer",
-                        "description": "ID подписки для обновления",
+                        "description": "ID бюджета",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные для обновления подписки",
+                        "description": "Новая сумма бюджета",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.SubscriptionUpdateRequest"
+                            "$ref": "#/definitions/dto.BudgetUpdateRequest"
                         }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -341,7 +396,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Подписка не найдена",
+                        "description": "Бюджет не найден",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -354,81 +409,4601 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.SubscriptionCreateRequest": {
-            "type": "object",
-            "required": [
-                "price",
-                "service_name",
-                "start_date",
-                "user_id"
-            ],
-            "properties": {
-                "end_date": {
-                    "type": "string",
-                    "example": "05-2026"
-                },
-                "price": {
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 599
-                },
-                "service_name": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "example": "Netflix"
-                },
-                "start_date": {
-                    "type": "string",
-                    "example": "01-2026"
-                },
-                "user_id": {
-                    "type": "string",
-                    "example": "550e8400-e29b-41d4-a716-446655440000"
-                }
-            }
         },
-        "dto.SubscriptionUpdateRequest": {
-            "type": "object",
-            "properties": {
-                "end_date": {
-                    "type": "string",
-                    "example": "05-2026"
-                },
-                "price": {
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 699
-                },
-                "service_name": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "example": "Netflix Premium"
-                },
-                "start_date": {
-                    "type": "string",
-                    "example": "01-2026"
+        "/category": {
+            "post": {
+                "description": "Создаёт категорию пользователя. Родительской может быть встроенная категория или категория того же пользователя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "category"
+                ],
+                "summary": "Создать категорию пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные категории",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CategoryCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Категория с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handlers.Error": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
+        "/category/": {
+            "get": {
+                "description": "Возвращает встроенные категории и категории пользователя по ID, без user_id — категории всех пользователей",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "category"
+                ],
+                "summary": "Получить категории",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Категории",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.Category"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handlers.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "err": {
-                    "$ref": "#/definitions/handlers.Error"
+        "/category/{id}": {
+            "get": {
+                "description": "Возвращает категорию по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "category"
+                ],
+                "summary": "Получить категорию",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет категорию пользователя вместе с подкатегориями. Подписки и сервисы сохраняются без категории.\nВстроенные категории удалять нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "category"
+                ],
+                "summary": "Удалить категорию пользователя",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Встроенная категория",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Переименовывает категорию пользователя или переносит её в другую родительскую категорию.\nВстроенные категории изменять нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "category"
+                ],
+                "summary": "Обновить категорию пользователя",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления категории",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CategoryUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Встроенная категория",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Категория не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Категория с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Отвечает 200, пока процесс запущен и обрабатывает запросы",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка живости",
+                "responses": {
+                    "200": {
+                        "description": "Процесс работает",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization": {
+            "post": {
+                "description": "Создаёт организацию (тенант): компанию или семью. Подписки, созданные с заголовком X-Organization-Id, видны только в этой организации.\nС user_id пользователь становится первым участником.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Создать организацию",
+                "parameters": [
+                    {
+                        "description": "Данные организации",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrganizationCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization/": {
+            "get": {
+                "description": "Возвращает организации, в которых состоит пользователь",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Получить организации пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Организации",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.Organization"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization/{id}": {
+            "get": {
+                "description": "Возвращает организацию по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Получить организацию",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Организация не совпадает с заголовком X-Organization-Id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет организацию вместе с участниками. Организацию с подписками удалить нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Удалить организацию",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Организация не совпадает с заголовком X-Organization-Id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "У организации есть подписки",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Изменяет название организации",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Переименовать организацию",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrganizationUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Организация не совпадает с заголовком X-Organization-Id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization/{id}/members": {
+            "get": {
+                "description": "Возвращает участников организации",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Получить участников организации",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Организация не совпадает с заголовком X-Organization-Id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет пользователя в организацию, повторное добавление ничего не меняет. Возвращает участников организации.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Добавить участника организации",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Участник",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrganizationMemberRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Организация не совпадает с заголовком X-Organization-Id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization/{id}/members/{user_id}": {
+            "delete": {
+                "description": "Удаляет пользователя из организации, его подписки остаются в организации. Возвращает оставшихся участников.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Удалить участника организации",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Организация не совпадает с заголовком X-Organization-Id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена или пользователь не участник",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organization/{id}/price": {
+            "get": {
+                "description": "Возвращает стоимость подписок организации за период с помесячным пропорциональным расчётом\nи сколько из неё платит каждый пользователь с учётом разделения стоимости общих подписок.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organization"
+                ],
+                "summary": "Стоимость подписок организации",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первый день периода (YYYY-MM-DD) или первый месяц (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний день периода (YYYY-MM-DD) или последний месяц (MM-YYYY)",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrganizationCostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Организация не совпадает с заголовком X-Organization-Id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/plan/{id}": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Получить тариф по ID",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID тарифа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Тариф не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет тариф, подписки на нём сохраняют свою цену",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Удалить тариф",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID тарифа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Тариф не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Обновляет тариф; цены уже созданных подписок не меняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Обновить тариф",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID тарифа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления тарифа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlanUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Тариф не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Тариф с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет доступность базы данных и версию миграций. Во время остановки приложения отвечает 503",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "Приложение готово принимать запросы",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "Приложение не готово",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/service": {
+            "post": {
+                "description": "Добавляет сервис с псевдонимами. Подписки без сервиса, название которых совпадает с названием\nили псевдонимом без учёта регистра, связываются с ним.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Добавить сервис в каталог",
+                "parameters": [
+                    {
+                        "description": "Данные сервиса",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ServiceCreateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Название или псевдоним уже занято",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/": {
+            "get": {
+                "description": "Возвращает сервисы каталога по ID вместе с тарифами",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Получить сервисы каталога",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/{id}": {
+            "get": {
+                "description": "Возвращает сервис каталога вместе с тарифами",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Получить сервис по ID",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет сервис вместе с тарифами. Подписки сохраняются, но перестают быть связанными с каталогом.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Удалить сервис",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Обновляет сервис каталога; подписки без сервиса с новым названием или псевдонимом связываются с ним",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Обновить сервис",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления сервиса",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ServiceUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Название или псевдоним уже занято",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/service/{id}/plan": {
+            "post": {
+                "description": "Добавляет тариф с ценой по умолчанию за месяц или год",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalog"
+                ],
+                "summary": "Добавить тариф сервиса",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID сервиса",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные тарифа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlanCreateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сервис не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Тариф с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription": {
+            "post": {
+                "description": "Создаёт новую подписку для пользователя. С plan_id цена и сервис по умолчанию берутся из тарифа каталога,\nбез service_id подписка связывается с сервисом каталога, у которого такое название или псевдоним.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Создать новую подписку",
+                "parameters": [
+                    {
+                        "description": "Данные для создания подписки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SubscriptionCreateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации, в которой создаётся подписка",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные или пользователь не зарегистрирован",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Пользователь не состоит в организации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/": {
+            "get": {
+                "description": "Возвращает список всех подписок",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получить все подписки",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "trialing",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Только подписки с этим статусом",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Только подписки этой категории и её подкатегорий",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Только подписки со всеми этими метками, с префиксом ! — без метки",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/debts": {
+            "get": {
+                "description": "Возвращает, сколько участники общих подписок должны их владельцам за период (помесячный расчёт).\nВстречные долги двух пользователей взаимозачитываются. С user_id — только долги пользователя и долги ему.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Долги за общие подписки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Первый день периода (YYYY-MM-DD) или первый месяц (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний день периода (YYYY-MM-DD) или последний месяц (MM-YYYY)",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DebtsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/forecast": {
+            "get": {
+                "description": "Прогнозирует списания подписок пользователя по месяцам, начиная с текущего, по тем же правилам, что и /subscription/price\nс proration=monthly: учитываются день списания, дата окончания, пробный период и паузы. Списания текущего месяца\nдо сегодняшнего дня не учитываются. Для каждого месяца возвращаются подписки со списаниями в нём и расчёт их стоимости.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получить прогноз расходов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 60,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 12,
+                        "description": "Число месяцев",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ForecastResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/names/clusters": {
+            "get": {
+                "description": "Группирует названия сервисов в подписках, совпадающие без учёта регистра, пробелов и диакритики\nили похожие по триграммам. Для каждой группы предлагается название: сервис из каталога или самое частое написание.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "names"
+                ],
+                "summary": "Найти похожие названия сервисов",
+                "parameters": [
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "Минимальная триграммная похожесть, по умолчанию 0.5",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Группы названий, начиная с самых больших",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.NameCluster"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/names/merge": {
+            "post": {
+                "description": "Переименовывает подписки с указанными названиями в одной транзакции и связывает их с сервисом каталога\nс новым названием, если он есть. Объединение записывается в журнал.\nДоступно только на порту администрирования (server.admin_port).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "names"
+                ],
+                "summary": "Объединить названия сервиса",
+                "parameters": [
+                    {
+                        "description": "Названия",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.NameMergeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации, без него объединяются названия во всех организациях",
+                        "name": "X-Organization-Id",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Запись журнала",
+                        "schema": {
+                            "$ref": "#/definitions/dto.NameMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/names/merges": {
+            "get": {
+                "description": "Возвращает выполненные объединения названий по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "names"
+                ],
+                "summary": "Получить журнал объединений названий",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/price": {
+            "get": {
+                "description": "Рассчитывает общую стоимость подписок по заданным фильтрам (пользователь, сервис, период).\nПериод включает начальную и конечную даты, месяц MM-YYYY означает все его дни. Подписка списывается\nежемесячно в день списания (billing_day), стоимость — сумма списаний в периоде, подписка без даты окончания\nсчитается активной до конца периода. При proration=daily каждый платёжный период оплачивается\nпропорционально числу активных дней. При explain=true возвращается расчёт по каждой подписке.\nПри by_category=true возвращается стоимость по категориям: стоимость подкатегорий входит в стоимость\nродительской категории, подписка без категории относится к категории своего сервиса.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получить сумму подписок по фильтрам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя для фильтрации",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название сервиса для фильтрации, также учитываются подписки сервиса каталога с таким названием или псевдонимом",
+                        "name": "service_name",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID сервиса каталога для фильтрации, имеет приоритет над service_name",
+                        "name": "service_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первый день периода (YYYY-MM-DD) или первый месяц (MM-YYYY)",
+                        "name": "start_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний день периода (YYYY-MM-DD) или последний месяц (MM-YYYY)",
+                        "name": "end_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "monthly",
+                            "daily"
+                        ],
+                        "type": "string",
+                        "default": "monthly",
+                        "description": "Режим расчёта неполных месяцев",
+                        "name": "proration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть расчёт по каждой подписке",
+                        "name": "explain",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Только подписки этой категории и её подкатегорий",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Только подписки со всеми этими метками, с префиксом ! — без метки",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть стоимость по категориям",
+                        "name": "by_category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PriceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/trials": {
+            "get": {
+                "description": "Возвращает подписки, пробный период которых заканчивается в ближайшие days дней (включая сегодня),\nчтобы пользователь успел отменить подписку до списания полной цены.\nПодписки, которые заканчиваются вместе с пробным периодом, не возвращаются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получить подписки с заканчивающимся пробным периодом",
+                "parameters": [
+                    {
+                        "maximum": 366,
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 7,
+                        "description": "Число дней",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя для фильтрации",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/user/{user_id}": {
+            "get": {
+                "description": "Возвращает список всех подписок пользователя по его ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получить подписки пользователя",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "trialing",
+                            "paused",
+                            "cancelled",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Только подписки с этим статусом",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Только подписки этой категории и её подкатегорий",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Только подписки со всеми этими метками, с префиксом ! — без метки",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверный формат UUID или параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/user/{user_id}/insights": {
+            "get": {
+                "description": "Находит возможные проблемы с подписками пользователя:\nduplicate — две подписки на один сервис (сервис каталога или одинаковое название без учёта регистра, пробелов и диакритики), активные одновременно;\nprice_jump — подписка на сервис дороже предыдущей на 30% и больше;\nlong_open_ended — подписка без даты окончания, активная 24 месяца и дольше.\nID подсказки не меняется, пока не меняются подписки; скрытые подсказки возвращаются только с dismissed=true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получить подсказки по подпискам пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть и скрытые подсказки",
+                        "name": "dismissed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подсказки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.Insight"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/user/{user_id}/insights/{insight_id}/dismiss": {
+            "post": {
+                "description": "Скрывает подсказку по подпискам пользователя. Повторное скрытие ничего не меняет.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Скрыть подсказку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID подсказки",
+                        "name": "insight_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Скрытая подсказка",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/dto.Insight"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подсказка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}": {
+            "get": {
+                "description": "Возвращает информацию о конкретной подписке по её ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Получить подписку по ID",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет подписку по её ID и возвращает информацию об удалённой подписке",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Удалить подписку по ID",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки для удаления",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Обновляет информацию о существующей подписке",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Обновить подписку",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки для обновления",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления подписки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SubscriptionUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/cancel": {
+            "post": {
+                "description": "Устанавливает дату окончания подписки и сохраняет отмену с причиной. При at_period_end=true подписка\nзаканчивается в последний день текущего платёжного периода, иначе — в день date (по умолчанию сегодня).\nОтмена не продлевает подписку с более ранней датой окончания. Тело запроса необязательно.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Отменить подписку",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры отмены",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CancelRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка уже закончилась",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/pause": {
+            "post": {
+                "description": "Добавляет паузу: дни паузы, включая начальный и конечный, не оплачиваются.\nПауза должна начинаться и заканчиваться в пределах срока подписки и не пересекаться с другими паузами.\nБез end_date пауза длится до возобновления. Тело запроса необязательно.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Приостановить подписку",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Период паузы",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PauseRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Пауза пересекается с другой паузой",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/price-changes": {
+            "get": {
+                "description": "Возвращает запланированные и прошедшие изменения цены подписки по дате.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Изменения цены подписки",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Подписка оплачивается по новой цене с даты изменения: прогноз считает каждое списание по цене, действующей в его день.\nДата должна быть не раньше сегодняшней и в пределах срока подписки, на одну дату — одно изменение.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Запланировать изменение цены",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Дата и новая цена",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PriceChangeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные или дата вне срока подписки",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "На эту дату уже есть изменение цены",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/resume": {
+            "post": {
+                "description": "Завершает паузу, в которую попадает дата возобновления: пауза заканчивается накануне этой даты.\nТело запроса необязательно.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Возобновить подписку",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Дата возобновления",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResumeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка не приостановлена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{id}/split": {
+            "put": {
+                "description": "Делает подписку общей с другими пользователями. При equal стоимость делится поровну между владельцем и участниками,\nпри percentage участник платит процент стоимости, при fixed — сумму из цены в месяц. Владелец платит остаток,\nдоли участников округляются вниз. Стоимость подписок пользователя, прогноз и бюджеты учитывают только его долю.\nПустой список участников отменяет разделение. Участниками могут быть только зарегистрированные пользователи организации.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscription"
+                ],
+                "summary": "Разделить стоимость подписки",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Правило и участники",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SplitRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Участник не состоит в организации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag": {
+            "post": {
+                "description": "Создаёт метку пользователя. Метки также создаются при указании их в подписке.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Создать метку пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные метки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TagCreateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Метка с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag/": {
+            "get": {
+                "description": "Возвращает метки пользователя по названию с числом подписок у каждой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Получить метки пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Метки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.Tag"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tag/{id}": {
+            "get": {
+                "description": "Возвращает метку по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Получить метку",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID метки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Метка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет метку и снимает её со всех подписок, сами подписки сохраняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Удалить метку",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID метки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Метка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Переименовывает метку у всех подписок с ней",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tag"
+                ],
+                "summary": "Переименовать метку",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "ID метки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название метки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TagUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID организации",
+                        "name": "X-Organization-Id",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Метка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Метка с таким названием уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "post": {
+                "description": "Регистрирует пользователя. Подписки можно создавать только для зарегистрированных пользователей.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Зарегистрировать пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные пользователя",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/dto.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь с таким ID или email уже есть",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/": {
+            "get": {
+                "description": "Возвращает страницу зарегистрированных пользователей по UUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Получить пользователей",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Количество записей на странице",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователи",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/dto.User"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "get": {
+                "description": "Возвращает пользователя по UUID, в том числе обезличенного",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Получить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/dto.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет персональные данные пользователя (GDPR): участие в организациях и общих подписках, бюджеты, метки, категории и скрытые рекомендации.\nВ режиме USER_DELETION=cascade пользователь удаляется вместе с подписками.\nВ режиме anonymize (по умолчанию) пользователь обезличивается: имя и email стираются, заметки и причины отмены подписок очищаются, а сами подписки остаются для статистики.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Удалить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Удалённый или обезличенный пользователь",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/dto.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден или уже обезличен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Изменяет заданные поля пользователя, email со значением null или пустой строкой удаляется.\nОбезличенного пользователя изменить нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Изменить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/dto.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден или обезличен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email занят другим пользователем",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.Budget": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Сумма в месяц",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "category_id": {
+                    "description": "Бюджет на категорию вместе с её подкатегориями",
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "service_id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.BudgetAlert": {
+            "type": "object",
+            "properties": {
+                "alerted_at": {
+                    "type": "string",
+                    "example": "2026-10-19T10:00:00Z"
+                },
+                "amount": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "budget_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2026-10-01"
+                },
+                "spent": {
+                    "description": "Расходы и сумма бюджета в момент уведомления",
+                    "type": "integer",
+                    "example": 850
+                },
+                "threshold": {
+                    "description": "Порог в процентах: 80 или 100",
+                    "type": "integer",
+                    "example": 80
+                }
+            }
+        },
+        "dto.BudgetCreateRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "user_id"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1000
+                },
+                "category_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "service_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 3
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.BudgetStatus": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "$ref": "#/definitions/dto.Budget"
+                },
+                "level": {
+                    "description": "ok — меньше 80%, warning — от 80%, exceeded — от 100%",
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "warning",
+                        "exceeded"
+                    ],
+                    "example": "warning"
+                },
+                "month": {
+                    "description": "Первый день месяца",
+                    "type": "string",
+                    "example": "2026-10-01"
+                },
+                "percent": {
+                    "type": "integer",
+                    "example": 85
+                },
+                "spent": {
+                    "description": "Расходы за месяц с помесячным пропорциональным расчётом",
+                    "type": "integer",
+                    "example": 850
+                }
+            }
+        },
+        "dto.BudgetUpdateRequest": {
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1500
+                }
+            }
+        },
+        "dto.CancelRequest": {
+            "type": "object",
+            "properties": {
+                "at_period_end": {
+                    "description": "Отменить в конце текущего платёжного периода",
+                    "type": "boolean",
+                    "example": true
+                },
+                "date": {
+                    "description": "Последний день подписки, по умолчанию — сегодня; нельзя указывать вместе с at_period_end",
+                    "type": "string",
+                    "example": "2026-04-30"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Слишком дорого"
+                }
+            }
+        },
+        "dto.Category": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 11
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Podcasts"
+                },
+                "parent_id": {
+                    "description": "Родительская категория, null для категории верхнего уровня",
+                    "type": "integer",
+                    "example": 8
+                },
+                "user_id": {
+                    "description": "Владелец категории, null для встроенной категории",
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.CategoryCost": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "description": "null для подписок без категории",
+                    "type": "integer",
+                    "example": 8
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Music"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 599
+                }
+            }
+        },
+        "dto.CategoryCreateRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "user_id"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Podcasts"
+                },
+                "parent_id": {
+                    "description": "Встроенная категория или категория того же пользователя",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 8
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.CategoryUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Audiobooks"
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 5
+                }
+            }
+        },
+        "dto.Debt": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer",
+                    "example": 360
+                },
+                "from": {
+                    "type": "string",
+                    "example": "6ba7b810-9dad-41d1-80b4-00c04fd430c8"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "dto.DebtsResponse": {
+            "type": "object",
+            "properties": {
+                "debts": {
+                    "description": "По должнику и получателю; встречные долги взаимозачтены",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Debt"
+                    }
+                }
+            }
+        },
+        "dto.ForecastMonth": {
+            "type": "object",
+            "properties": {
+                "month": {
+                    "description": "Первый день месяца",
+                    "type": "string",
+                    "example": "2026-11-01"
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "subscriptions": {
+                    "description": "Подписки со списаниями в этом месяце",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PriceItem"
+                    }
+                }
+            }
+        },
+        "dto.ForecastResponse": {
+            "type": "object",
+            "properties": {
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ForecastMonth"
+                    }
+                },
+                "price": {
+                    "description": "Сумма за все месяцы прогноза",
+                    "type": "integer",
+                    "example": 7188
+                }
+            }
+        },
+        "dto.Insight": {
+            "type": "object",
+            "properties": {
+                "dismissed_at": {
+                    "type": "string",
+                    "example": "2026-10-19T10:00:00Z"
+                },
+                "from": {
+                    "description": "Для duplicate: дни, когда активны обе подписки; без to — обе активны до сих пор",
+                    "type": "string",
+                    "example": "2026-03-01"
+                },
+                "id": {
+                    "description": "Не меняется, пока не меняются подписки",
+                    "type": "string",
+                    "example": "duplicate-3-7"
+                },
+                "increase": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "duplicate",
+                        "price_jump",
+                        "long_open_ended"
+                    ],
+                    "example": "duplicate"
+                },
+                "months": {
+                    "description": "Для long_open_ended: полных месяцев без даты окончания",
+                    "type": "integer",
+                    "example": 26
+                },
+                "new_price": {
+                    "type": "integer",
+                    "example": 700
+                },
+                "old_price": {
+                    "description": "Для price_jump: цены предыдущей и следующей подписки и рост цены в процентах",
+                    "type": "integer",
+                    "example": 500
+                },
+                "service_name": {
+                    "description": "Название сервиса самой ранней подписки",
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "subscription_ids": {
+                    "description": "Подписки в порядке начала",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        7
+                    ]
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2026-03-31"
+                }
+            }
+        },
+        "dto.MemberRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "share": {
+                    "description": "Для percentage — от 1 до 100 в сумме по участникам, для fixed — не больше цены в сумме, для equal не указывается",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 40
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "6ba7b810-9dad-41d1-80b4-00c04fd430c8"
+                }
+            }
+        },
+        "dto.MembersResponse": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "description": "По UUID пользователя",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.OrganizationMember"
+                    }
+                }
+            }
+        },
+        "dto.NameCluster": {
+            "type": "object",
+            "properties": {
+                "canonical": {
+                    "description": "Название сервиса из каталога или самое частое написание",
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "names": {
+                    "description": "Написания по убыванию числа подписок",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ServiceName"
+                    }
+                },
+                "service_id": {
+                    "description": "Сервис каталога с предложенным названием",
+                    "type": "integer",
+                    "example": 1
+                },
+                "subscriptions": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "dto.NameMerge": {
+            "type": "object",
+            "properties": {
+                "canonical": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "merged_at": {
+                    "type": "string",
+                    "example": "2026-10-18T18:00:00Z"
+                },
+                "names": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "netflix",
+                        "Netflx"
+                    ]
+                },
+                "subscriptions": {
+                    "description": "Количество переименованных подписок",
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "dto.NameMergeRequest": {
+            "type": "object",
+            "required": [
+                "canonical",
+                "names"
+            ],
+            "properties": {
+                "canonical": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Netflix"
+                },
+                "names": {
+                    "description": "Подписки с этими названиями (с точным совпадением) получают название canonical",
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "netflix",
+                        "Netflx"
+                    ]
+                }
+            }
+        },
+        "dto.Organization": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-10-19T10:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Семья Ивановых"
+                }
+            }
+        },
+        "dto.OrganizationCostResponse": {
+            "type": "object",
+            "properties": {
+                "organization_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "users": {
+                    "description": "Сколько платит каждый пользователь с учётом разделения стоимости, по UUID пользователя",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UserCost"
+                    }
+                }
+            }
+        },
+        "dto.OrganizationCreateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Семья Ивановых"
+                },
+                "user_id": {
+                    "description": "Первый участник организации",
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.OrganizationMember": {
+            "type": "object",
+            "properties": {
+                "joined_at": {
+                    "type": "string",
+                    "example": "2026-10-19T10:00:00Z"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.OrganizationMemberRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.OrganizationUpdateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Ивановы"
+                }
+            }
+        },
+        "dto.PauseRequest": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "description": "Последний день паузы, без него пауза длится до возобновления",
+                    "type": "string",
+                    "example": "2026-04-30"
+                },
+                "start_date": {
+                    "description": "По умолчанию — сегодня",
+                    "type": "string",
+                    "example": "2026-03-01"
+                }
+            }
+        },
+        "dto.PlanCreateRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "price"
+            ],
+            "properties": {
+                "billing_period": {
+                    "description": "По умолчанию — month",
+                    "type": "string",
+                    "enum": [
+                        "month",
+                        "year"
+                    ],
+                    "example": "month"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Premium"
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 999
+                }
+            }
+        },
+        "dto.PlanUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "billing_period": {
+                    "type": "string",
+                    "enum": [
+                        "month",
+                        "year"
+                    ],
+                    "example": "year"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Premium"
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1099
+                }
+            }
+        },
+        "dto.PriceChangeRequest": {
+            "type": "object",
+            "required": [
+                "effective_date",
+                "price"
+            ],
+            "properties": {
+                "effective_date": {
+                    "description": "Первый день новой цены, не раньше сегодняшнего",
+                    "type": "string",
+                    "example": "2026-12-01"
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 499
+                }
+            }
+        },
+        "dto.PriceItem": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "integer",
+                    "example": 1797
+                },
+                "days": {
+                    "type": "integer",
+                    "example": 90
+                },
+                "explanation": {
+                    "type": "string",
+                    "example": "599 × 3 months (2026-01-01 – 2026-03-31) = 1797"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2026-01-01"
+                },
+                "months": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 599
+                },
+                "service_name": {
+                    "type": "string",
+                    "example": "Netflix"
+                },
+                "share": {
+                    "description": "Часть стоимости, которую платит пользователь запроса; без пользователя — вся стоимость",
+                    "type": "integer",
+                    "example": 1797
+                },
+                "subscription_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2026-03-31"
+                },
+                "trial_months": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "trial_price": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "dto.PriceResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "description": "Стоимость по категориям (при by_category=true)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CategoryCost"
+                    }
+                },
+                "price": {
+                    "type": "integer",
+                    "example": 1797
+                },
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PriceItem"
+                    }
+                }
+            }
+        },
+        "dto.ResumeRequest": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Первый оплачиваемый день после паузы, по умолчанию — сегодня",
+                    "type": "string",
+                    "example": "2026-05-01"
+                }
+            }
+        },
+        "dto.ServiceCreateRequest": {
+            "type": "object",
+            "required": [
+                "aliases",
+                "name"
+            ],
+            "properties": {
+                "aliases": {
+                    "description": "Названия и псевдонимы сервисов не должны повторяться без учёта регистра",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "NFLX"
+                    ]
+                },
+                "category_id": {
+                    "description": "Только встроенная категория, сервисы общие для всех пользователей",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 7
+                },
+                "logo_url": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://www.netflix.com/favicon.ico"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Netflix"
+                },
+                "website": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://www.netflix.com"
+                }
+            }
+        },
+        "dto.ServiceName": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "netflix"
+                },
+                "subscriptions": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "dto.ServiceUpdateRequest": {
+            "type": "object",
+            "required": [
+                "aliases"
+            ],
+            "properties": {
+                "aliases": {
+                    "description": "Заменяет все псевдонимы, пустой список удаляет их",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "NFLX"
+                    ]
+                },
+                "category_id": {
+                    "description": "Только встроенная категория, сервисы общие для всех пользователей",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 7
+                },
+                "logo_url": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://www.netflix.com/favicon.ico"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Netflix"
+                },
+                "website": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://www.netflix.com"
+                }
+            }
+        },
+        "dto.SplitRequest": {
+            "type": "object",
+            "required": [
+                "rule"
+            ],
+            "properties": {
+                "members": {
+                    "description": "Заменяет участников, пустой список отменяет разделение",
+                    "type": "array",
+                    "maxItems": 50,
+                    "uniqueItems": true,
+                    "items": {
+                        "$ref": "#/definitions/dto.MemberRequest"
+                    }
+                },
+                "rule": {
+                    "description": "equal — поровну с владельцем, percentage — процент стоимости, fixed — фиксированная сумма из цены в месяц",
+                    "type": "string",
+                    "enum": [
+                        "equal",
+                        "percentage",
+                        "fixed"
+                    ],
+                    "example": "percentage"
+                }
+            }
+        },
+        "dto.SubscriptionCreateRequest": {
+            "type": "object",
+            "required": [
+                "start_date",
+                "tags",
+                "user_id"
+            ],
+            "properties": {
+                "billing_day": {
+                    "description": "По умолчанию — день даты начала",
+                    "type": "integer",
+                    "maximum": 31,
+                    "minimum": 1,
+                    "example": 17
+                },
+                "category_id": {
+                    "description": "Встроенная категория или категория пользователя, по умолчанию — категория сервиса",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 8
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "05-2026"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Оплачивается с рабочей карты"
+                },
+                "plan_id": {
+                    "description": "Тариф из каталога, должен принадлежать сервису",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 2
+                },
+                "price": {
+                    "description": "По умолчанию — цена тарифа в месяц (годовая цена делится на 12)",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 599
+                },
+                "service_id": {
+                    "description": "Сервис из каталога; без него подписка связывается с сервисом, у которого такое название или псевдоним",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "service_name": {
+                    "description": "По умолчанию — название сервиса из каталога",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Netflix"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2026-01-17"
+                },
+                "tags": {
+                    "description": "Метки пользователя, недостающие создаются",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work",
+                        "family"
+                    ]
+                },
+                "trial_months": {
+                    "description": "Пробный или промо-период: число первых платёжных периодов и цена за каждый из них (0 — бесплатно)",
+                    "type": "integer",
+                    "maximum": 120,
+                    "minimum": 0,
+                    "example": 1
+                },
+                "trial_price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 0
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                }
+            }
+        },
+        "dto.SubscriptionUpdateRequest": {
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "billing_day": {
+                    "type": "integer",
+                    "maximum": 31,
+                    "minimum": 1,
+                    "example": 17
+                },
+                "category_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 8
+                },
+                "end_date": {
+                    "type": "string",
+                    "example": "05-2026"
+                },
+                "notes": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Оплачивается с личной карты"
+                },
+                "plan_id": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 2
+                },
+                "price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 699
+                },
+                "service_id": {
+                    "description": "Тариф должен принадлежать сервису; с новым тарифом сервис берётся из него",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 1
+                },
+                "service_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Netflix Premium"
+                },
+                "start_date": {
+                    "type": "string",
+                    "example": "2026-01-17"
+                },
+                "tags": {
+                    "description": "Заменяет метки подписки, пустой список удаляет их",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "work"
+                    ]
+                },
+                "trial_months": {
+                    "type": "integer",
+                    "maximum": 120,
+                    "minimum": 0,
+                    "example": 1
+                },
+                "trial_price": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 299
+                }
+            }
+        },
+        "dto.Tag": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "name": {
+                    "type": "string",
+                    "example": "work"
+                },
+                "subscriptions": {
+                    "description": "Число подписок с меткой",
+                    "type": "integer",
+                    "example": 2
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.TagCreateRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "user_id"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Уникально для пользователя без учёта регистра, не начинается с «!»",
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "work"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.TagUpdateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "business"
+                }
+            }
+        },
+        "dto.User": {
+            "type": "object",
+            "properties": {
+                "anonymized_at": {
+                    "description": "Время удаления персональных данных, у обезличенного пользователя имя пустое, а email не задан",
+                    "type": "string",
+                    "example": "2026-10-20T10:00:00Z"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2026-10-19T10:00:00Z"
+                },
+                "currency": {
+                    "description": "Код валюты ISO 4217",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "display_name": {
+                    "type": "string",
+                    "example": "Иван Иванов"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "ivan@example.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "locale": {
+                    "description": "Языковой тег BCP 47",
+                    "type": "string",
+                    "example": "ru-RU"
+                },
+                "timezone": {
+                    "description": "Часовой пояс IANA",
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                }
+            }
+        },
+        "dto.UserCost": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "integer",
+                    "example": 800
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                }
+            }
+        },
+        "dto.UserCreateRequest": {
+            "type": "object",
+            "required": [
+                "display_name"
+            ],
+            "properties": {
+                "currency": {
+                    "description": "По умолчанию RUB",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "display_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Иван Иванов"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 254,
+                    "example": "ivan@example.com"
+                },
+                "id": {
+                    "description": "Если не задан, генерируется новый",
+                    "type": "string",
+                    "example": "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+                },
+                "locale": {
+                    "description": "По умолчанию en",
+                    "type": "string",
+                    "example": "ru-RU"
+                },
+                "timezone": {
+                    "description": "По умолчанию UTC",
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                }
+            }
+        },
+        "dto.UserUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "display_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1,
+                    "example": "Иван Иванов"
+                },
+                "email": {
+                    "description": "null или пустая строка удаляет email",
+                    "type": "string",
+                    "maxLength": 254,
+                    "example": "ivan@example.com"
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "ru-RU"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                }
+            }
+        },
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "The request contains invalid fields"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/subscription/"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f1c8b7e-2a4d-4e9b-9c61-0d7a5b2e8f10"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Validation failed"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:subscription-service:problem:validation-error"
+                }
+            }
+        },
+        "handlers.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "gte"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "price"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must be greater than or equal to 0"
+                }
+            }
+        },
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
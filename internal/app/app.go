@@ -1,32 +1,35 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
-	"regexp"
 	"syscall"
 
 	_ "github.com/Estriper0/subscription_service/docs"
 	"github.com/Estriper0/subscription_service/internal/config"
 	"github.com/Estriper0/subscription_service/internal/handlers"
-	"github.com/Estriper0/subscription_service/internal/repository/db"
+	"github.com/Estriper0/subscription_service/internal/health"
+	"github.com/Estriper0/subscription_service/internal/metrics"
+	"github.com/Estriper0/subscription_service/internal/middleware"
 	"github.com/Estriper0/subscription_service/internal/server"
 	"github.com/Estriper0/subscription_service/internal/service"
-	"github.com/Estriper0/subscription_service/pkg/postgres"
+	"github.com/Estriper0/subscription_service/internal/tracing"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"github.com/jackc/pgx/v5/pgxpool"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type App struct {
-	logger *slog.Logger
-	config *config.Config
-	db     *pgxpool.Pool
-	server *server.Server
+	logger      *slog.Logger
+	config      *config.Config
+	storage     *storage
+	server      *server.Server
+	adminServer *server.Server
+	stopTracing func(context.Context) error
 }
 
 func New(logger *slog.Logger, config *config.Config) *App {
@@ -35,45 +38,123 @@ func New(logger *slog.Logger, config *config.Config) *App {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	//Tracing is set up first so that the database pool picks up the tracer provider
+	stopTracing, err := tracing.New(context.Background(), config.Tracing)
+	if err != nil {
+		panic(err)
+	}
+
+	metrics := metrics.New()
+
+	//The admin server is traced, logged and measured like the public one
+	chain := []gin.HandlerFunc{
+		otelgin.Middleware(config.Tracing.ServiceName),
+		middleware.RequestId(),
+		middleware.Logging(logger),
+		metrics.Middleware(),
+		middleware.Recovery(logger, handlers.InternalError),
+	}
 	router := gin.New()
+	router.Use(chain...)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	dbPool, err := postgres.New(config.DB.Url(), config.DB.PoolSize)
+	storage, err := newStorage(logger, config, metrics)
 	if err != nil {
 		panic(err)
 	}
 
-	validate := validator.New()
-	err = registerCustomValidations(validate)
+	validate, err := handlers.NewValidator()
 	if err != nil {
 		panic(err)
 	}
 
-	subscriptionRepo := db.NewSubscriptionRepo(dbPool)
-	subscriptionService := service.NewSubscriptionService(subscriptionRepo, logger)
-	subscriptionGroup := router.Group("/subscription")
+	subscriptionService := service.NewSubscriptionService(storage.subscriptionRepo, storage.catalogRepo, storage.categoryRepo, storage.tagRepo, storage.splitRepo, storage.organizationRepo, storage.userRepo, metrics, logger)
+	//The requests are scoped to the organization in the header
+	tenant := middleware.Tenant(false, handlers.TenantError)
+	subscriptionGroup := router.Group("/subscription", tenant)
 
 	handlers.NewSubscriptionHandler(subscriptionGroup, subscriptionService, validate)
 
-	server := server.New(router, config)
+	//Administrative endpoints are served on the admin port only, there is no authentication to guard them on the public one
+	adminRouter := gin.New()
+	adminRouter.Use(chain...)
+	//Without the header the administrative requests see the rows of all the organizations
+	adminSubscriptionGroup := adminRouter.Group("/subscription", middleware.Tenant(true, handlers.TenantError))
+
+	nameService := service.NewNameService(storage.nameRepo, storage.catalogRepo, metrics, logger)
+	handlers.NewNameHandler(subscriptionGroup, adminSubscriptionGroup, nameService, validate)
+
+	insightService := service.NewInsightService(storage.subscriptionRepo, storage.insightRepo, metrics, logger)
+	handlers.NewInsightHandler(subscriptionGroup, insightService)
+
+	catalogService := service.NewCatalogService(storage.catalogRepo, storage.categoryRepo, metrics, logger)
+	handlers.NewCatalogHandler(router.Group("/service", tenant), router.Group("/plan", tenant), catalogService, validate)
+
+	categoryService := service.NewCategoryService(storage.categoryRepo, metrics, logger)
+	handlers.NewCategoryHandler(router.Group("/category"), categoryService, validate)
+
+	tagService := service.NewTagService(storage.tagRepo, metrics, logger)
+	handlers.NewTagHandler(router.Group("/tag", tenant), tagService, validate)
+
+	budgetService := service.NewBudgetService(storage.budgetRepo, storage.catalogRepo, storage.categoryRepo, subscriptionService, metrics, logger)
+	handlers.NewBudgetHandler(router.Group("/budget", tenant), budgetService, validate)
+	//The alerts are raised as soon as a subscription change pushes the user over a threshold
+	subscriptionService.OnChange(budgetService.CheckBudgets)
+
+	organizationService := service.NewOrganizationService(storage.organizationRepo, subscriptionService, metrics, logger)
+	organizationGroup := router.Group("/organization")
+	//Only the organization in the header is changed
+	organizationPath := organizationGroup.Group("/:id", tenant, middleware.TenantPath("id", handlers.TenantError))
+	handlers.NewOrganizationHandler(organizationGroup, organizationPath, organizationService, validate)
+
+	userService := service.NewUserService(storage.userRepo, config.App.CascadeUserDeletion(), metrics, logger)
+	handlers.NewUserHandler(router.Group("/user"), userService, validate)
+
+	health := health.New(config.Server.ReadinessTimeout, storage.checks...)
+	handlers.NewHealthHandler(router, health)
+
+	metrics.RegisterActiveSubscriptions(subscriptionService.CountActive)
+
+	//Metrics are served on a separate port if it is configured
+	var adminServer *server.Server
+	if config.Server.AdminPort != 0 {
+		adminRouter.GET("/metrics", gin.WrapH(metrics.Handler()))
+		adminServer = server.New(adminRouter, config.Server.AdminPort, config)
+	} else {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
+	server := server.New(router, config.Server.Port, config)
+	server.BeforeStop(health.SetShuttingDown)
 
 	return &App{
-		logger: logger,
-		config: config,
-		db:     dbPool,
-		server: server,
+		logger:      logger,
+		config:      config,
+		storage:     storage,
+		server:      server,
+		adminServer: adminServer,
+		stopTracing: stopTracing,
 	}
 }
 
 func (a *App) Run() {
 	//Closing the connection to the database
-	defer a.db.Close()
+	defer a.storage.close()
 
 	a.logger.Info("Start application")
 
 	a.logger.Info(fmt.Sprintf("Starting server on :%d", a.config.Server.Port))
 	go a.server.Run()
 
+	var adminErr <-chan error
+	if a.adminServer != nil {
+		a.logger.Info(fmt.Sprintf("Starting admin server on :%d", a.config.Server.AdminPort))
+		go a.adminServer.Run()
+		adminErr = a.adminServer.Err()
+	} else {
+		a.logger.Warn("The admin port is not configured, the administrative endpoints such as POST /subscription/names/merge are not served")
+	}
+
 	quit := make(chan os.Signal, 1)
 	defer close(quit)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -84,6 +165,8 @@ func (a *App) Run() {
 		a.logger.Info(fmt.Sprintf("Received signal: %s", q.String()))
 	case err := <-a.server.Err():
 		a.logger.Error(fmt.Sprintf("Server error: %s", err.Error()))
+	case err := <-adminErr:
+		a.logger.Error(fmt.Sprintf("Admin server error: %s", err.Error()))
 	}
 	a.logger.Info("Initiating graceful shutdown...")
 
@@ -94,16 +177,19 @@ func (a *App) Run() {
 	} else {
 		a.logger.Info("Server shutdown gracefully")
 	}
-	a.logger.Info("Stop application")
-}
-
-func registerCustomValidations(v *validator.Validate) error {
-	err := v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
-		date := fl.Field().String()
-		pattern := `^(0[1-9]|1[0-2])-(19\d{2}|20\d{2})$`
-		matched, err := regexp.MatchString(pattern, date)
-		return err == nil && matched
-	})
+	if a.adminServer != nil {
+		err = a.adminServer.Stop()
+		if err != nil {
+			a.logger.Error("Incorrect admin server shutdown", slog.String("error", err.Error()))
+		}
+	}
 
-	return err
+	//Flushing the remaining spans
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
+	defer cancel()
+	err = a.stopTracing(ctx)
+	if err != nil {
+		a.logger.Error("Incorrect tracing shutdown", slog.String("error", err.Error()))
+	}
+	a.logger.Info("Stop application")
 }
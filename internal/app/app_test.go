@@ -0,0 +1,1511 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/config"
+	"github.com/Estriper0/subscription_service/internal/handlers"
+	"github.com/Estriper0/subscription_service/internal/pgtest"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	userA = "550e8400-e29b-41d4-a716-446655440000"
+	userB = "6ba7b810-9dad-41d1-80b4-00c04fd430c8"
+	userC = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	userD = "16fd2706-8baf-433b-82eb-8c7fada847da"
+	userE = "9b2f6a3e-4c1d-4e8a-b7f0-2d5c8e1a3f64"
+	userF = "3f8e2b1c-7d4a-4e6f-9a0b-5c2d8e7f1a93"
+	userG = "c4a1e7d2-5b3f-4a8e-9c6d-1f2e3a4b5c6d"
+	userH = "e8d3c2b1-6a5f-4e7d-8c9b-0a1f2e3d4c5b"
+	userI = "2a7f9c4e-1b8d-4f3a-a6e5-7d0c9b8a1e2f"
+	userJ = "5d6e7f80-9a1b-4c2d-8e3f-4a5b6c7d8e9f"
+	userK = "8b1c2d3e-4f5a-4b6c-9d7e-0f1a2b3c4d5e"
+	userL = "4e5f6a7b-8c9d-4e0f-a1b2-c3d4e5f6a7b8"
+	userM = "d1e2f3a4-b5c6-4d7e-8f9a-0b1c2d3e4f5a"
+	userN = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	userO = "f0e1d2c3-b4a5-4968-8776-a5b4c3d2e1f0"
+
+	//The organization the requests are sent in unless they check the tenancy
+	defaultOrganization = 1
+)
+
+// The same end-to-end scenario runs against every storage
+func TestApp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("memory", func(t *testing.T) {
+		testRoutes(t, config.DBConfig{Storage: config.StorageMemory})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testRoutes(t, config.DBConfig{
+			Storage:     config.StorageSQLite,
+			Path:        filepath.Join(t.TempDir(), "subscriptions.db"),
+			AutoMigrate: true,
+		})
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testRoutes(t, pgtest.Start(t))
+	})
+}
+
+// newTestApp returns the handlers of the public and the admin servers
+func newTestApp(t *testing.T, db config.DBConfig) (http.Handler, http.Handler) {
+	t.Helper()
+
+	cfg := &config.Config{
+		App: config.AppConfig{Env: "test"},
+		Server: config.ServerConfig{
+			ReadTimeout:      5 * time.Second,
+			WriteTimeout:     5 * time.Second,
+			ShutdownTimeout:  5 * time.Second,
+			ReadinessTimeout: 2 * time.Second,
+			AdminPort:        9090,
+		},
+		DB: db,
+		Tracing: config.TracingConfig{
+			Exporter:    "none",
+			ServiceName: "subscription_service",
+		},
+	}
+	app := New(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg)
+	t.Cleanup(app.storage.close)
+
+	return app.server.Handler(), app.adminServer.Handler()
+}
+
+func testRoutes(t *testing.T, db config.DBConfig) {
+	h, admin := newTestApp(t, db)
+
+	//Subscriptions are created only for the registered users who are members of the organization
+	t.Run("RegisterUsers", func(t *testing.T) {
+		for i, id := range []string{userA, userB, userC, userD, userE, userF, userG, userH, userI, userJ, userK, userL, userM, userN, userO} {
+			do(t, h, http.MethodPost, "/user/", fmt.Sprintf(`{"id":"%s","display_name":"User %c"}`, id, 'A'+i), http.StatusCreated, nil)
+			do(t, h, http.MethodPost, "/organization/"+strconv.Itoa(defaultOrganization)+"/members", `{"user_id":"`+id+`"}`, http.StatusOK, nil)
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		var res struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":100,"user_id":"`+userA+`","start_date":"01-2026"}`, http.StatusCreated, &res)
+		if res.Id != 1 {
+			t.Fatalf("id = %d, want 1", res.Id)
+		}
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Spotify","price":10,"user_id":"`+userA+`","start_date":"12-2025","end_date":"02-2026"}`, http.StatusCreated, &res)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":1000,"user_id":"`+userB+`","start_date":"11-2025","end_date":"12-2025"}`, http.StatusCreated, &res)
+		if res.Id != 3 {
+			t.Fatalf("id = %d, want 3", res.Id)
+		}
+	})
+
+	t.Run("CreateInvalid", func(t *testing.T) {
+		problem := doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":-1,"user_id":"`+userA+`","start_date":"13-2026"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeValidation || len(problem.Errors) != 2 {
+			t.Fatalf("problem = %+v, want validation errors of price and start_date", problem)
+		}
+		problem = doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":1,"user_id":"`+userA+`","start_date":"05-2026","end_date":"04-2026"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeIncorrectTime {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeIncorrectTime)
+		}
+		problem = doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeMalformedBody {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeMalformedBody)
+		}
+	})
+
+	t.Run("GetById", func(t *testing.T) {
+		var res struct{ Subscription subscription }
+		do(t, h, http.MethodGet, "/subscription/2", "", http.StatusOK, &res)
+		want := subscription{Id: 2, ServiceName: "Spotify", Price: 10, UserId: userA, StartDate: "2025-12-01", EndDate: ptr("2026-02-28"), BillingDay: 1}
+		if !res.Subscription.equal(want) {
+			t.Fatalf("subscription = %+v, want %+v", res.Subscription, want)
+		}
+
+		doProblem(t, h, http.MethodGet, "/subscription/999", "", http.StatusNotFound)
+		doProblem(t, h, http.MethodGet, "/subscription/abc", "", http.StatusBadRequest)
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		var res struct{ Subscriptions []subscription }
+		do(t, h, http.MethodGet, "/subscription/?page=1&limit=2", "", http.StatusOK, &res)
+		if ids(res.Subscriptions) != "1,2" {
+			t.Fatalf("ids = %s, want 1,2", ids(res.Subscriptions))
+		}
+		do(t, h, http.MethodGet, "/subscription/?page=2&limit=2", "", http.StatusOK, &res)
+		if ids(res.Subscriptions) != "3" {
+			t.Fatalf("ids = %s, want 3", ids(res.Subscriptions))
+		}
+
+		doProblem(t, h, http.MethodGet, "/subscription/?limit=2", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/?page=1&limit=x", "", http.StatusBadRequest)
+	})
+
+	t.Run("GetByUser", func(t *testing.T) {
+		var res struct{ Subscriptions []subscription }
+		do(t, h, http.MethodGet, "/subscription/user/"+userA+"?page=1&limit=10", "", http.StatusOK, &res)
+		if ids(res.Subscriptions) != "1,2" {
+			t.Fatalf("ids = %s, want 1,2", ids(res.Subscriptions))
+		}
+
+		doProblem(t, h, http.MethodGet, "/subscription/user/not-a-uuid?page=1&limit=10", "", http.StatusBadRequest)
+	})
+
+	t.Run("GetPriceByFilter", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			query string
+			want  int
+		}{
+			{"all", "start_date=01-2026&end_date=06-2026", 100*6 + 10*2},
+			{"user", "start_date=01-2026&end_date=06-2026&user_id=" + userA, 100*6 + 10*2},
+			{"service", "start_date=10-2025&end_date=06-2026&service_name=Netflix", 100*6 + 1000*2},
+			{"user and service", "start_date=10-2025&end_date=06-2026&service_name=Netflix&user_id=" + userB, 1000 * 2},
+			{"same month", "start_date=03-2026&end_date=03-2026", 100},
+			{"across a year boundary", "start_date=12-2025&end_date=01-2026", 100*1 + 10*2 + 1000*1},
+			{"daily proration of whole months", "start_date=01-2026&end_date=06-2026&proration=daily", 100*6 + 10*2},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var res struct{ Price int }
+				do(t, h, http.MethodGet, "/subscription/price?"+tt.query, "", http.StatusOK, &res)
+				if res.Price != tt.want {
+					t.Fatalf("price = %d, want %d", res.Price, tt.want)
+				}
+			})
+		}
+
+		problem := doProblem(t, h, http.MethodGet, "/subscription/price?start_date=06-2026&end_date=01-2026", "", http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeIncorrectTime {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeIncorrectTime)
+		}
+		doProblem(t, h, http.MethodGet, "/subscription/price?end_date=01-2026", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&proration=weekly", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&explain=maybe", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&user_id=x", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/price?start_date=2026-13-01&end_date=01-2026", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=2026-01", "", http.StatusBadRequest)
+	})
+
+	t.Run("GetPriceByFilterExplain", func(t *testing.T) {
+		var res struct {
+			Price         int
+			Subscriptions []struct {
+				SubscriptionId int    `json:"subscription_id"`
+				From           string `json:"from"`
+				To             string `json:"to"`
+				Months         int    `json:"months"`
+				Cost           int    `json:"cost"`
+				Explanation    string `json:"explanation"`
+			}
+		}
+		do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=06-2026&user_id="+userA+"&explain=true", "", http.StatusOK, &res)
+		if res.Price != 620 || len(res.Subscriptions) != 2 {
+			t.Fatalf("price = %d with %d subscriptions, want 620 with 2", res.Price, len(res.Subscriptions))
+		}
+		spotify := res.Subscriptions[1]
+		if spotify.SubscriptionId != 2 || spotify.From != "2026-01-01" || spotify.To != "2026-02-28" || spotify.Months != 2 || spotify.Cost != 20 {
+			t.Fatalf("explanation = %+v, want Spotify billed for 2 months from 2026-01-01 to 2026-02-28", spotify)
+		}
+		if spotify.Explanation != "10 × 2 months (2026-01-01 – 2026-02-28) = 20" {
+			t.Fatalf("explanation = %q", spotify.Explanation)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		var res struct{ Subscription subscription }
+		do(t, h, http.MethodPatch, "/subscription/1", `{"price":700,"end_date":"03-2026"}`, http.StatusOK, &res)
+		want := subscription{Id: 1, ServiceName: "Netflix", Price: 700, UserId: userA, StartDate: "2026-01-01", EndDate: ptr("2026-03-31"), BillingDay: 1}
+		if !res.Subscription.equal(want) {
+			t.Fatalf("subscription = %+v, want %+v", res.Subscription, want)
+		}
+
+		problem := doProblem(t, h, http.MethodPatch, "/subscription/1", `{"start_date":"04-2026"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeIncorrectTime {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeIncorrectTime)
+		}
+		doProblem(t, h, http.MethodPatch, "/subscription/999", `{"price":1}`, http.StatusNotFound)
+	})
+
+	t.Run("DeleteById", func(t *testing.T) {
+		var res struct{ Subscription subscription }
+		do(t, h, http.MethodDelete, "/subscription/3", "", http.StatusOK, &res)
+		if res.Subscription.Id != 3 {
+			t.Fatalf("id = %d, want 3", res.Subscription.Id)
+		}
+		doProblem(t, h, http.MethodDelete, "/subscription/3", "", http.StatusNotFound)
+		doProblem(t, h, http.MethodGet, "/subscription/3", "", http.StatusNotFound)
+	})
+
+	t.Run("BillingDay", func(t *testing.T) {
+		var created struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Spotify","price":100,"user_id":"`+userB+`","start_date":"2026-01-17","end_date":"2026-03-16"}`, http.StatusCreated, &created)
+
+		var res struct{ Subscription subscription }
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(created.Id), "", http.StatusOK, &res)
+		want := subscription{Id: created.Id, ServiceName: "Spotify", Price: 100, UserId: userB, StartDate: "2026-01-17", EndDate: ptr("2026-03-16"), BillingDay: 17}
+		if !res.Subscription.equal(want) {
+			t.Fatalf("subscription = %+v, want %+v", res.Subscription, want)
+		}
+
+		//Charged on January 17 and February 17
+		var price struct{ Price int }
+		do(t, h, http.MethodGet, "/subscription/price?start_date=2026-01-01&end_date=2026-06-30&user_id="+userB, "", http.StatusOK, &price)
+		if price.Price != 200 {
+			t.Fatalf("price = %d, want 200", price.Price)
+		}
+		do(t, h, http.MethodGet, "/subscription/price?start_date=2026-01-18&end_date=2026-02-16&user_id="+userB, "", http.StatusOK, &price)
+		if price.Price != 0 {
+			t.Fatalf("price between charges = %d, want 0", price.Price)
+		}
+
+		do(t, h, http.MethodPatch, "/subscription/"+strconv.Itoa(created.Id), `{"billing_day":31}`, http.StatusOK, &res)
+		if res.Subscription.BillingDay != 31 {
+			t.Fatalf("billing day = %d, want 31", res.Subscription.BillingDay)
+		}
+		problem := doProblem(t, h, http.MethodPatch, "/subscription/"+strconv.Itoa(created.Id), `{"billing_day":32}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeValidation {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeValidation)
+		}
+	})
+
+	t.Run("Trial", func(t *testing.T) {
+		var created struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Kinopoisk","price":300,"user_id":"`+userC+`","start_date":"2026-01-01","end_date":"2026-03-31","trial_months":1}`, http.StatusCreated, &created)
+
+		var res struct{ Subscription subscription }
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(created.Id), "", http.StatusOK, &res)
+		if res.Subscription.TrialMonths != 1 || res.Subscription.TrialEndsAt == nil || *res.Subscription.TrialEndsAt != "2026-01-31" {
+			t.Fatalf("subscription = %+v, want a one month trial ending on 2026-01-31", res.Subscription)
+		}
+
+		var price struct {
+			Price         int
+			Subscriptions []struct {
+				TrialMonths int    `json:"trial_months"`
+				TrialPrice  *int   `json:"trial_price"`
+				Explanation string `json:"explanation"`
+			}
+		}
+		do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=06-2026&explain=true&user_id="+userC, "", http.StatusOK, &price)
+		if price.Price != 600 || len(price.Subscriptions) != 1 || price.Subscriptions[0].TrialMonths != 1 || price.Subscriptions[0].TrialPrice == nil {
+			t.Fatalf("price = %+v, want 600 with one free trial month", price)
+		}
+		if price.Subscriptions[0].Explanation != "0 × 1 trial months + 300 × 2 months (2026-01-01 – 2026-03-31) = 600" {
+			t.Fatalf("explanation = %q", price.Subscriptions[0].Explanation)
+		}
+
+		//The trial of a subscription started today ends in 27 to 30 days
+		today := time.Now().UTC().Format("2006-01-02")
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Okko","price":200,"user_id":"`+userC+`","start_date":"`+today+`","trial_months":1,"trial_price":1}`, http.StatusCreated, &created)
+
+		var trials struct{ Subscriptions []subscription }
+		do(t, h, http.MethodGet, "/subscription/trials?days=31&user_id="+userC, "", http.StatusOK, &trials)
+		if ids(trials.Subscriptions) != strconv.Itoa(created.Id) {
+			t.Fatalf("ids = %s, want %d", ids(trials.Subscriptions), created.Id)
+		}
+		do(t, h, http.MethodGet, "/subscription/trials?user_id="+userC, "", http.StatusOK, &trials)
+		if len(trials.Subscriptions) != 0 {
+			t.Fatalf("ids = %s, want none within the default 7 days", ids(trials.Subscriptions))
+		}
+
+		doProblem(t, h, http.MethodGet, "/subscription/trials?days=x", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/trials?days=-1", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Okko","price":200,"user_id":"`+userC+`","start_date":"01-2026","trial_months":-1}`, http.StatusBadRequest)
+	})
+
+	t.Run("PauseAndResume", func(t *testing.T) {
+		var created struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"YouTube","price":100,"user_id":"`+userB+`","start_date":"2026-01-01","end_date":"2026-12-31"}`, http.StatusCreated, &created)
+		path := "/subscription/" + strconv.Itoa(created.Id)
+
+		var res struct{ Subscription subscription }
+		do(t, h, http.MethodPost, path+"/pause", `{"start_date":"2026-03-01","end_date":"04-2026"}`, http.StatusOK, &res)
+		if len(res.Subscription.Pauses) != 1 || res.Subscription.Pauses[0].StartDate != "2026-03-01" || !equalDates(res.Subscription.Pauses[0].EndDate, ptr("2026-04-30")) {
+			t.Fatalf("pauses = %+v, want one from 2026-03-01 to 2026-04-30", res.Subscription.Pauses)
+		}
+
+		problem := doProblem(t, h, http.MethodPost, path+"/pause", `{"start_date":"2026-04-15"}`, http.StatusConflict)
+		if problem.Type != handlers.ProblemTypeConflict {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeConflict)
+		}
+		problem = doProblem(t, h, http.MethodPost, path+"/pause", `{"start_date":"2025-12-01","end_date":"2026-01-15"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeIncorrectTime {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeIncorrectTime)
+		}
+		doProblem(t, h, http.MethodPost, path+"/pause", `{"start_date":"2026-06-10","end_date":"2026-06-01"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, path+"/pause", `{"start_date":"x"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/subscription/999/pause", `{"start_date":"2026-03-01"}`, http.StatusNotFound)
+
+		//Paused until resumed
+		do(t, h, http.MethodPost, path+"/pause", `{"start_date":"2026-08-01"}`, http.StatusOK, &res)
+		do(t, h, http.MethodPost, path+"/resume", `{"date":"2026-10-01"}`, http.StatusOK, &res)
+		if len(res.Subscription.Pauses) != 2 || !equalDates(res.Subscription.Pauses[1].EndDate, ptr("2026-09-30")) {
+			t.Fatalf("pauses = %+v, want the second one ending on 2026-09-30", res.Subscription.Pauses)
+		}
+		problem = doProblem(t, h, http.MethodPost, path+"/resume", `{"date":"2026-10-01"}`, http.StatusConflict)
+		if problem.Type != handlers.ProblemTypeConflict {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeConflict)
+		}
+
+		do(t, h, http.MethodGet, path, "", http.StatusOK, &res)
+		if len(res.Subscription.Pauses) != 2 {
+			t.Fatalf("pauses = %+v, want 2", res.Subscription.Pauses)
+		}
+
+		//March, April, August and September are not charged
+		var price struct{ Price int }
+		do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=12-2026&service_name=YouTube", "", http.StatusOK, &price)
+		if price.Price != 800 {
+			t.Fatalf("price = %d, want 800", price.Price)
+		}
+
+		problem = doProblem(t, h, http.MethodPatch, path, `{"end_date":"2026-06-30"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeIncorrectTime {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeIncorrectTime)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		now := time.Now().UTC()
+		today := now.Format(time.DateOnly)
+		//Billed on the 15th, the current cycle ends on the 14th
+		periodEnd := time.Date(now.Year(), now.Month(), 15, 0, 0, 0, 0, time.UTC)
+		if !now.Before(periodEnd) {
+			periodEnd = periodEnd.AddDate(0, 1, 0)
+		}
+		periodEnd = periodEnd.AddDate(0, 0, -1)
+
+		var active, expired, trialing struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":100,"user_id":"`+userD+`","start_date":"2020-01-15"}`, http.StatusCreated, &active)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Spotify","price":100,"user_id":"`+userD+`","start_date":"01-2020","end_date":"12-2020"}`, http.StatusCreated, &expired)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Okko","price":100,"user_id":"`+userD+`","start_date":"`+today+`","trial_months":1}`, http.StatusCreated, &trialing)
+		list := "/subscription/user/" + userD + "?page=1&limit=10&status="
+
+		var page struct{ Subscriptions []statusSubscription }
+		for status, want := range map[string]int{"active": active.Id, "expired": expired.Id, "trialing": trialing.Id} {
+			do(t, h, http.MethodGet, list+status, "", http.StatusOK, &page)
+			if len(page.Subscriptions) != 1 || page.Subscriptions[0].Id != want || page.Subscriptions[0].Status != status {
+				t.Fatalf("%s subscriptions = %+v, want %d", status, page.Subscriptions, want)
+			}
+		}
+
+		var res struct{ Subscription statusSubscription }
+		path := "/subscription/" + strconv.Itoa(active.Id) + "/cancel"
+		do(t, h, http.MethodPost, path, `{"at_period_end":true,"reason":"too expensive"}`, http.StatusOK, &res)
+		got := res.Subscription
+		if got.Status != "cancelled" || !equalDates(got.EndDate, ptr(periodEnd.Format(time.DateOnly))) || !equalDates(got.CancelledAt, &today) || !equalDates(got.CancelReason, ptr("too expensive")) {
+			t.Fatalf("subscription = %+v, want cancelled today ending on %s", got, periodEnd.Format(time.DateOnly))
+		}
+
+		do(t, h, http.MethodGet, list+"cancelled", "", http.StatusOK, &page)
+		if len(page.Subscriptions) != 1 || page.Subscriptions[0].Id != active.Id {
+			t.Fatalf("cancelled subscriptions = %+v, want %d", page.Subscriptions, active.Id)
+		}
+		page.Subscriptions = nil
+		do(t, h, http.MethodGet, "/subscription/?page=1&limit=100&status=active", "", http.StatusOK, &page)
+		for _, s := range page.Subscriptions {
+			if s.Status != "active" || s.UserId == userD {
+				t.Fatalf("active subscriptions = %+v, want none of the user", page.Subscriptions)
+			}
+		}
+
+		problem := doProblem(t, h, http.MethodPost, "/subscription/"+strconv.Itoa(expired.Id)+"/cancel", "", http.StatusConflict)
+		if problem.Type != handlers.ProblemTypeConflict {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeConflict)
+		}
+		problem = doProblem(t, h, http.MethodPost, "/subscription/"+strconv.Itoa(trialing.Id)+"/cancel", `{"date":"2019-12-31"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeIncorrectTime {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeIncorrectTime)
+		}
+		doProblem(t, h, http.MethodPost, path, `{"at_period_end":true,"date":"2026-01-01"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, path, `{"reason":"`+strings.Repeat("x", 501)+`"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/subscription/999/cancel", "", http.StatusNotFound)
+		doProblem(t, h, http.MethodGet, list+"unknown", "", http.StatusBadRequest)
+
+		//Cancelled today without a body
+		do(t, h, http.MethodPost, "/subscription/"+strconv.Itoa(trialing.Id)+"/cancel", "", http.StatusOK, &res)
+		if res.Subscription.Status != "cancelled" || !equalDates(res.Subscription.EndDate, &today) || res.Subscription.CancelReason != nil {
+			t.Fatalf("subscription = %+v, want cancelled and ending today", res.Subscription)
+		}
+	})
+
+	t.Run("Catalog", func(t *testing.T) {
+		var unlinked, service, music, plan struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"YT","price":50,"user_id":"`+userE+`","start_date":"01-2026"}`, http.StatusCreated, &unlinked)
+		do(t, h, http.MethodPost, "/service/", `{"name":"YouTube Premium","aliases":["yt","youtube"],"category_id":7,"website":"https://www.youtube.com"}`, http.StatusCreated, &service)
+		do(t, h, http.MethodPost, "/service/", `{"name":"YouTube Music"}`, http.StatusCreated, &music)
+		serviceId := strconv.Itoa(service.Id)
+
+		problem := doProblem(t, h, http.MethodPost, "/service/", `{"name":"YOUTUBE"}`, http.StatusConflict)
+		if problem.Type != handlers.ProblemTypeConflict {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeConflict)
+		}
+		doProblem(t, h, http.MethodPatch, "/service/"+strconv.Itoa(music.Id), `{"aliases":["Yt"]}`, http.StatusConflict)
+		doProblem(t, h, http.MethodPost, "/service/", `{"name":"Okko","website":"not a url"}`, http.StatusBadRequest)
+
+		do(t, h, http.MethodPost, "/service/"+serviceId+"/plan", `{"name":"Family","price":2400,"billing_period":"year"}`, http.StatusCreated, &plan)
+		doProblem(t, h, http.MethodPost, "/service/"+serviceId+"/plan", `{"name":"Family","price":100}`, http.StatusConflict)
+		doProblem(t, h, http.MethodPost, "/service/"+serviceId+"/plan", `{"name":"Weekly","price":100,"billing_period":"week"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/service/999/plan", `{"name":"Family","price":100}`, http.StatusNotFound)
+		planId := strconv.Itoa(plan.Id)
+
+		var got struct{ Service catalogService }
+		do(t, h, http.MethodGet, "/service/"+serviceId, "", http.StatusOK, &got)
+		if got.Service.Name != "YouTube Premium" || !reflect.DeepEqual(got.Service.Aliases, []string{"youtube", "yt"}) ||
+			len(got.Service.Plans) != 1 || got.Service.Plans[0].Price != 2400 || got.Service.Plans[0].BillingPeriod != "year" {
+			t.Fatalf("service = %+v", got.Service)
+		}
+
+		//The price and the name come from the plan, a yearly price is spread over 12 months
+		var fromPlan, byAlias struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"plan_id":`+planId+`,"user_id":"`+userE+`","start_date":"01-2026"}`, http.StatusCreated, &fromPlan)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Youtube","price":100,"user_id":"`+userE+`","start_date":"01-2026"}`, http.StatusCreated, &byAlias)
+		problem = doProblem(t, h, http.MethodPost, "/subscription/", `{"plan_id":999,"user_id":"`+userE+`","start_date":"01-2026"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeBadRequest {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeBadRequest)
+		}
+		doProblem(t, h, http.MethodPost, "/subscription/", `{"service_id":`+strconv.Itoa(music.Id)+`,"plan_id":`+planId+`,"user_id":"`+userE+`","start_date":"01-2026"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Okko","user_id":"`+userE+`","start_date":"01-2026"}`, http.StatusBadRequest)
+
+		var res struct{ Subscription catalogSubscription }
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(fromPlan.Id), "", http.StatusOK, &res)
+		if res.Subscription.ServiceName != "YouTube Premium" || res.Subscription.Price != 200 || !equalIds(res.Subscription.ServiceId, service.Id) || !equalIds(res.Subscription.PlanId, plan.Id) {
+			t.Fatalf("subscription = %+v, want the plan %d of the service %d for 200", res.Subscription, plan.Id, service.Id)
+		}
+		for _, id := range []int{unlinked.Id, byAlias.Id} {
+			do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(id), "", http.StatusOK, &res)
+			if !equalIds(res.Subscription.ServiceId, service.Id) || res.Subscription.PlanId != nil {
+				t.Fatalf("subscription = %+v, want linked to the service %d", res.Subscription, service.Id)
+			}
+		}
+
+		//All names of the service are counted
+		for _, query := range []string{"service_name=yt", "service_id=" + serviceId} {
+			var price struct{ Price int }
+			do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&user_id="+userE+"&"+query, "", http.StatusOK, &price)
+			if price.Price != 350 {
+				t.Fatalf("price with %s = %d, want 350", query, price.Price)
+			}
+		}
+		doProblem(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&service_id=x", "", http.StatusBadRequest)
+
+		var updated struct{ Plan catalogPlan }
+		do(t, h, http.MethodPatch, "/plan/"+planId, `{"price":1200}`, http.StatusOK, &updated)
+		if updated.Plan.Price != 1200 || updated.Plan.BillingPeriod != "year" {
+			t.Fatalf("plan = %+v, want 1200 a year", updated.Plan)
+		}
+
+		do(t, h, http.MethodDelete, "/service/"+serviceId, "", http.StatusOK, &got)
+		if len(got.Service.Plans) != 1 {
+			t.Fatalf("deleted service = %+v, want it with the plan", got.Service)
+		}
+		doProblem(t, h, http.MethodGet, "/plan/"+planId, "", http.StatusNotFound)
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(fromPlan.Id), "", http.StatusOK, &res)
+		if res.Subscription.ServiceId != nil || res.Subscription.PlanId != nil || res.Subscription.Price != 200 {
+			t.Fatalf("subscription = %+v, want unlinked with the same price", res.Subscription)
+		}
+	})
+
+	t.Run("NameMerge", func(t *testing.T) {
+		var kept struct{ Id int }
+		for _, name := range []string{"Wink Plus", "Wink Plus", "wink  plus", "Wínk Plus", "Wink"} {
+			do(t, h, http.MethodPost, "/subscription/", `{"service_name":"`+name+`","price":100,"user_id":"`+userF+`","start_date":"01-2026"}`, http.StatusCreated, &kept)
+		}
+
+		findCluster := func(threshold string) *nameCluster {
+			var res struct{ Clusters []nameCluster }
+			do(t, h, http.MethodGet, "/subscription/names/clusters?threshold="+threshold, "", http.StatusOK, &res)
+			for _, cluster := range res.Clusters {
+				for _, name := range cluster.Names {
+					if name.Name == "Wink Plus" {
+						return &cluster
+					}
+				}
+			}
+			return nil
+		}
+
+		//The spelling variants are together at any threshold, a different word only at a low one
+		cluster := findCluster("1")
+		if cluster == nil || cluster.Canonical != "Wink Plus" || len(cluster.Names) != 3 || cluster.Subscriptions != 4 || cluster.Names[0].Subscriptions != 2 {
+			t.Fatalf("cluster = %+v, want 3 spellings of Wink Plus", cluster)
+		}
+		cluster = findCluster("0.5")
+		if cluster == nil || len(cluster.Names) != 4 || cluster.Subscriptions != 5 {
+			t.Fatalf("cluster = %+v, want Wink too", cluster)
+		}
+		for _, threshold := range []string{"0", "1.5", "x"} {
+			doProblem(t, h, http.MethodGet, "/subscription/names/clusters?threshold="+threshold, "", http.StatusBadRequest)
+		}
+
+		var merge nameMerge
+		do(t, admin, http.MethodPost, "/subscription/names/merge", `{"canonical":"Wink Plus","names":["wink  plus","Wínk Plus","Wink"]}`, http.StatusOK, &merge)
+		if merge.Id == 0 || merge.Canonical != "Wink Plus" || merge.Subscriptions != 3 {
+			t.Fatalf("merge = %+v, want 3 subscriptions renamed", merge)
+		}
+		doProblem(t, admin, http.MethodPost, "/subscription/names/merge", `{"canonical":"Wink Plus","names":[]}`, http.StatusBadRequest)
+		//The merge is not served on the public port
+		do(t, h, http.MethodPost, "/subscription/names/merge", `{"canonical":"Wink Plus","names":["Wink"]}`, http.StatusNotFound, nil)
+
+		if cluster = findCluster("0.3"); cluster != nil {
+			t.Fatalf("cluster = %+v after the merge, want none", cluster)
+		}
+		var res struct{ Subscription subscription }
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(kept.Id), "", http.StatusOK, &res)
+		if res.Subscription.ServiceName != "Wink Plus" {
+			t.Fatalf("service name = %s, want Wink Plus", res.Subscription.ServiceName)
+		}
+
+		var merges struct{ Merges []nameMerge }
+		do(t, h, http.MethodGet, "/subscription/names/merges?page=1&limit=10", "", http.StatusOK, &merges)
+		if len(merges.Merges) != 1 || !reflect.DeepEqual(merges.Merges[0], merge) {
+			t.Fatalf("merges = %+v, want %+v", merges.Merges, merge)
+		}
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		//Built-in: 1 Streaming, 7 Video and 8 Music under it
+		var podcasts, audio, other, service struct{ Id int }
+		do(t, h, http.MethodPost, "/category/", `{"name":"Podcasts","parent_id":8,"user_id":"`+userG+`"}`, http.StatusCreated, &podcasts)
+		do(t, h, http.MethodPost, "/category/", `{"name":"Audio","parent_id":`+strconv.Itoa(podcasts.Id)+`,"user_id":"`+userG+`"}`, http.StatusCreated, &audio)
+		do(t, h, http.MethodPost, "/category/", `{"name":"Podcasts","user_id":"`+userF+`"}`, http.StatusCreated, &other)
+		podcastsId := strconv.Itoa(podcasts.Id)
+
+		doProblem(t, h, http.MethodPost, "/category/", `{"name":"podcasts","parent_id":8,"user_id":"`+userG+`"}`, http.StatusConflict)
+		doProblem(t, h, http.MethodPost, "/category/", `{"name":"Shows","parent_id":`+strconv.Itoa(other.Id)+`,"user_id":"`+userG+`"}`, http.StatusBadRequest)
+		problem := doProblem(t, h, http.MethodPatch, "/category/1", `{"name":"Streams"}`, http.StatusForbidden)
+		if problem.Type != handlers.ProblemTypeForbidden {
+			t.Fatalf("type = %s, want %s", problem.Type, handlers.ProblemTypeForbidden)
+		}
+		doProblem(t, h, http.MethodPatch, "/category/"+podcastsId, `{"parent_id":`+strconv.Itoa(audio.Id)+`}`, http.StatusBadRequest)
+
+		//A service category must be built-in
+		do(t, h, http.MethodPost, "/service/", `{"name":"Kion","category_id":7}`, http.StatusCreated, &service)
+		doProblem(t, h, http.MethodPost, "/service/", `{"name":"Castbox","category_id":`+podcastsId+`}`, http.StatusBadRequest)
+
+		var byService, zvuk, castbox, gym struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Kion","price":300,"user_id":"`+userG+`","start_date":"01-2026"}`, http.StatusCreated, &byService)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Zvuk","price":200,"user_id":"`+userG+`","start_date":"01-2026","category_id":8}`, http.StatusCreated, &zvuk)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Castbox","price":100,"user_id":"`+userG+`","start_date":"01-2026","category_id":`+podcastsId+`}`, http.StatusCreated, &castbox)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Gym","price":1000,"user_id":"`+userG+`","start_date":"01-2026"}`, http.StatusCreated, &gym)
+		doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Castbox","price":100,"user_id":"`+userG+`","start_date":"01-2026","category_id":`+strconv.Itoa(other.Id)+`}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPatch, "/subscription/"+strconv.Itoa(gym.Id), `{"category_id":999}`, http.StatusBadRequest)
+
+		//A category includes its subcategories and the subscriptions of its services
+		for query, want := range map[string]string{
+			"1":        fmt.Sprintf("%d,%d,%d", byService.Id, zvuk.Id, castbox.Id),
+			"8":        fmt.Sprintf("%d,%d", zvuk.Id, castbox.Id),
+			podcastsId: strconv.Itoa(castbox.Id),
+			"6":        "",
+		} {
+			var res struct{ Subscriptions []subscription }
+			do(t, h, http.MethodGet, "/subscription/user/"+userG+"?page=1&limit=10&category="+query, "", http.StatusOK, &res)
+			if got := ids(res.Subscriptions); got != want {
+				t.Fatalf("subscriptions in the category %s = %s, want %s", query, got, want)
+			}
+		}
+		doProblem(t, h, http.MethodGet, "/subscription/?page=1&limit=10&category=x", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/?page=1&limit=10&category=999", "", http.StatusBadRequest)
+
+		var price struct {
+			Price      int
+			Categories []categoryCost
+		}
+		do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&user_id="+userG+"&category=8", "", http.StatusOK, &price)
+		if price.Price != 300 || price.Categories != nil {
+			t.Fatalf("price = %+v, want 300 without a breakdown", price)
+		}
+		do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&user_id="+userG+"&by_category=true", "", http.StatusOK, &price)
+		streaming, video, music := 1, 7, 8
+		want := []categoryCost{
+			{CategoryId: &streaming, Name: "Streaming", Price: 600},
+			{CategoryId: &video, Name: "Video", ParentId: &streaming, Price: 300},
+			{CategoryId: &music, Name: "Music", ParentId: &streaming, Price: 300},
+			{CategoryId: &podcasts.Id, Name: "Podcasts", ParentId: &music, Price: 100},
+			{Price: 1000},
+		}
+		if price.Price != 1600 || !reflect.DeepEqual(price.Categories, want) {
+			t.Fatalf("price = %+v, want 1600 with %+v", price, want)
+		}
+		doProblem(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&by_category=x", "", http.StatusBadRequest)
+
+		var deleted struct{ Category category }
+		do(t, h, http.MethodDelete, "/category/"+podcastsId, "", http.StatusOK, &deleted)
+		if deleted.Category.Name != "Podcasts" || deleted.Category.UserId == nil || *deleted.Category.UserId != userG {
+			t.Fatalf("deleted category = %+v", deleted.Category)
+		}
+		doProblem(t, h, http.MethodGet, "/category/"+strconv.Itoa(audio.Id), "", http.StatusNotFound)
+		var res struct{ Subscription catalogSubscription }
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(castbox.Id), "", http.StatusOK, &res)
+		if res.Subscription.CategoryId != nil {
+			t.Fatalf("subscription = %+v, want it without the deleted category", res.Subscription)
+		}
+		var categories struct{ Categories []category }
+		do(t, h, http.MethodGet, "/category/?user_id="+userG, "", http.StatusOK, &categories)
+		if len(categories.Categories) != 10 {
+			t.Fatalf("got %d categories, want the 10 built-in ones", len(categories.Categories))
+		}
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		var slack, jira, gym struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Slack","price":700,"user_id":"`+userH+`","start_date":"01-2026","tags":["work","Family"],"notes":"Paid by the employer"}`, http.StatusCreated, &slack)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Jira","price":900,"user_id":"`+userH+`","start_date":"01-2026","tags":["Work"]}`, http.StatusCreated, &jira)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Gym","price":2000,"user_id":"`+userH+`","start_date":"01-2026"}`, http.StatusCreated, &gym)
+		doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Gym","price":2000,"user_id":"`+userH+`","start_date":"01-2026","tags":["!work"]}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Gym","price":2000,"user_id":"`+userH+`","start_date":"01-2026","tags":[" "]}`, http.StatusBadRequest)
+
+		var res struct{ Subscription taggedSubscription }
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(slack.Id), "", http.StatusOK, &res)
+		if !reflect.DeepEqual(res.Subscription.Tags, []string{"Family", "work"}) || res.Subscription.Notes == nil || *res.Subscription.Notes != "Paid by the employer" {
+			t.Fatalf("subscription = %+v, want the tags Family and work with the notes", res.Subscription)
+		}
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(gym.Id), "", http.StatusOK, &res)
+		if res.Subscription.Tags == nil || len(res.Subscription.Tags) != 0 || res.Subscription.Notes != nil {
+			t.Fatalf("subscription = %+v, want an empty list of tags without notes", res.Subscription)
+		}
+
+		//Tags are matched regardless of case, "!" excludes a tag
+		for query, want := range map[string]string{
+			"tag=WORK":              fmt.Sprintf("%d,%d", slack.Id, jira.Id),
+			"tag=work&tag=family":   strconv.Itoa(slack.Id),
+			"tag=!family":           fmt.Sprintf("%d,%d", jira.Id, gym.Id),
+			"tag=work&tag=!Family":  strconv.Itoa(jira.Id),
+			"tag=travel":            "",
+			"tag=!work&tag=!family": strconv.Itoa(gym.Id),
+		} {
+			var list struct{ Subscriptions []subscription }
+			do(t, h, http.MethodGet, "/subscription/user/"+userH+"?page=1&limit=10&"+query, "", http.StatusOK, &list)
+			if got := ids(list.Subscriptions); got != want {
+				t.Fatalf("subscriptions with %s = %s, want %s", query, got, want)
+			}
+		}
+		doProblem(t, h, http.MethodGet, "/subscription/?page=1&limit=10&tag=!", "", http.StatusBadRequest)
+
+		var price struct{ Price int }
+		do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&user_id="+userH+"&tag=work", "", http.StatusOK, &price)
+		if price.Price != 1600 {
+			t.Fatalf("price = %d, want 1600", price.Price)
+		}
+		do(t, h, http.MethodGet, "/subscription/price?start_date=01-2026&end_date=01-2026&user_id="+userH+"&tag=!work", "", http.StatusOK, &price)
+		if price.Price != 2000 {
+			t.Fatalf("price = %d, want 2000", price.Price)
+		}
+
+		var tags struct{ Tags []tag }
+		do(t, h, http.MethodGet, "/tag/?user_id="+userH, "", http.StatusOK, &tags)
+		want := []tag{{Name: "Family", Subscriptions: 1}, {Name: "work", Subscriptions: 2}}
+		for i := range tags.Tags {
+			tags.Tags[i].Id = 0
+		}
+		if !reflect.DeepEqual(tags.Tags, want) {
+			t.Fatalf("tags = %+v, want %+v", tags.Tags, want)
+		}
+		doProblem(t, h, http.MethodGet, "/tag/", "", http.StatusBadRequest)
+
+		var health struct{ Id int }
+		do(t, h, http.MethodPost, "/tag/", `{"name":"Health","user_id":"`+userH+`"}`, http.StatusCreated, &health)
+		doProblem(t, h, http.MethodPost, "/tag/", `{"name":"health","user_id":"`+userH+`"}`, http.StatusConflict)
+		do(t, h, http.MethodPatch, "/subscription/"+strconv.Itoa(gym.Id), `{"tags":["health"],"notes":"Annual card"}`, http.StatusOK, &res)
+		if !reflect.DeepEqual(res.Subscription.Tags, []string{"Health"}) || *res.Subscription.Notes != "Annual card" {
+			t.Fatalf("subscription = %+v, want the tag Health with the new notes", res.Subscription)
+		}
+
+		//Updating other fields keeps the tags, an empty list removes them
+		do(t, h, http.MethodPatch, "/subscription/"+strconv.Itoa(slack.Id), `{"price":800}`, http.StatusOK, &res)
+		if len(res.Subscription.Tags) != 2 {
+			t.Fatalf("subscription = %+v, want its 2 tags kept", res.Subscription)
+		}
+		do(t, h, http.MethodPatch, "/subscription/"+strconv.Itoa(slack.Id), `{"tags":[]}`, http.StatusOK, &res)
+		if len(res.Subscription.Tags) != 0 {
+			t.Fatalf("subscription = %+v, want no tags", res.Subscription)
+		}
+
+		var renamed struct{ Tag tag }
+		do(t, h, http.MethodPatch, "/tag/"+strconv.Itoa(health.Id), `{"name":"Sport"}`, http.StatusOK, &renamed)
+		if renamed.Tag.Name != "Sport" || renamed.Tag.Subscriptions != 1 {
+			t.Fatalf("renamed tag = %+v", renamed.Tag)
+		}
+		do(t, h, http.MethodDelete, "/tag/"+strconv.Itoa(health.Id), "", http.StatusOK, nil)
+		doProblem(t, h, http.MethodGet, "/tag/"+strconv.Itoa(health.Id), "", http.StatusNotFound)
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(gym.Id), "", http.StatusOK, &res)
+		if len(res.Subscription.Tags) != 0 {
+			t.Fatalf("subscription = %+v, want it without the deleted tag", res.Subscription)
+		}
+	})
+
+	t.Run("Budgets", func(t *testing.T) {
+		//The subscriptions started long ago, so the current month is billed in full
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":800,"user_id":"`+userI+`","start_date":"01-2020"}`, http.StatusCreated, nil)
+
+		var overall struct{ Id int }
+		do(t, h, http.MethodPost, "/budget/", `{"amount":1000,"user_id":"`+userI+`"}`, http.StatusCreated, &overall)
+		doProblem(t, h, http.MethodPost, "/budget/", `{"amount":500,"user_id":"`+userI+`"}`, http.StatusConflict)
+		doProblem(t, h, http.MethodPost, "/budget/", `{"amount":500,"user_id":"`+userI+`","category_id":2,"service_id":1}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/budget/", `{"amount":500,"user_id":"`+userI+`","category_id":1000}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/budget/", `{"amount":0,"user_id":"`+userI+`"}`, http.StatusBadRequest)
+		do(t, h, http.MethodPost, "/budget/", `{"amount":500,"user_id":"`+userI+`","category_id":2}`, http.StatusCreated, nil)
+
+		//Creating the budget already pushed the spending to 80%
+		var alerts struct{ Alerts []budgetAlert }
+		do(t, h, http.MethodGet, "/budget/alerts?user_id="+userI, "", http.StatusOK, &alerts)
+		if len(alerts.Alerts) != 1 || alerts.Alerts[0].BudgetId != overall.Id || alerts.Alerts[0].Threshold != 80 || alerts.Alerts[0].Spent != 800 {
+			t.Fatalf("alerts = %+v, want the 80%% alert of the overall budget", alerts.Alerts)
+		}
+
+		var status struct{ Budgets []budgetStatus }
+		do(t, h, http.MethodGet, "/budget/status?user_id="+userI, "", http.StatusOK, &status)
+		if len(status.Budgets) != 2 {
+			t.Fatalf("got %d budget statuses, want 2", len(status.Budgets))
+		}
+		if got := status.Budgets[0]; got.Budget.Id != overall.Id || got.Spent != 800 || got.Percent != 80 || got.Level != "warning" {
+			t.Fatalf("overall budget status = %+v", got)
+		}
+		if got := status.Budgets[1]; got.Spent != 0 || got.Level != "ok" {
+			t.Fatalf("software budget status = %+v", got)
+		}
+
+		//A new subscription pushes the spending over the budget, the alerts are not repeated
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Spotify","price":300,"user_id":"`+userI+`","start_date":"01-2020"}`, http.StatusCreated, nil)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Kinopoisk","price":100,"user_id":"`+userI+`","start_date":"01-2020"}`, http.StatusCreated, nil)
+		do(t, h, http.MethodGet, "/budget/alerts?user_id="+userI, "", http.StatusOK, &alerts)
+		if len(alerts.Alerts) != 2 || alerts.Alerts[1].Threshold != 100 || alerts.Alerts[1].Spent != 1100 {
+			t.Fatalf("alerts = %+v, want the 80%% and 100%% alerts", alerts.Alerts)
+		}
+
+		var updated struct{ Budget budget }
+		do(t, h, http.MethodPatch, "/budget/"+strconv.Itoa(overall.Id), `{"amount":2000}`, http.StatusOK, &updated)
+		if updated.Budget.Amount != 2000 || updated.Budget.CategoryId != nil {
+			t.Fatalf("budget = %+v", updated.Budget)
+		}
+		do(t, h, http.MethodGet, "/budget/status?user_id="+userI, "", http.StatusOK, &status)
+		if got := status.Budgets[0]; got.Spent != 1200 || got.Percent != 60 || got.Level != "ok" {
+			t.Fatalf("overall budget status = %+v", got)
+		}
+		do(t, h, http.MethodGet, "/budget/status?user_id="+userI+"&month=01-2019", "", http.StatusOK, &status)
+		if got := status.Budgets[0]; got.Month != "2019-01-01" || got.Spent != 0 {
+			t.Fatalf("overall budget status = %+v, want no spending before the subscriptions", got)
+		}
+		doProblem(t, h, http.MethodGet, "/budget/status?user_id="+userI+"&month=2019-01", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/budget/status", "", http.StatusBadRequest)
+
+		var budgets struct{ Budgets []budget }
+		do(t, h, http.MethodGet, "/budget/?user_id="+userI, "", http.StatusOK, &budgets)
+		if len(budgets.Budgets) != 2 || *budgets.Budgets[1].CategoryId != 2 {
+			t.Fatalf("budgets = %+v", budgets.Budgets)
+		}
+
+		do(t, h, http.MethodDelete, "/budget/"+strconv.Itoa(overall.Id), "", http.StatusOK, nil)
+		doProblem(t, h, http.MethodGet, "/budget/"+strconv.Itoa(overall.Id), "", http.StatusNotFound)
+		do(t, h, http.MethodGet, "/budget/alerts?user_id="+userI, "", http.StatusOK, &alerts)
+		if len(alerts.Alerts) != 0 {
+			t.Fatalf("alerts = %+v, want them deleted with the budget", alerts.Alerts)
+		}
+
+		//Resuming a subscription counts it again
+		var paused struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"JetBrains","price":1,"user_id":"`+userI+`","start_date":"01-2020","category_id":2}`, http.StatusCreated, &paused)
+		path := "/subscription/" + strconv.Itoa(paused.Id)
+		do(t, h, http.MethodPost, path+"/pause", `{"start_date":"2020-01-01"}`, http.StatusOK, nil)
+		do(t, h, http.MethodPatch, path, `{"price":450}`, http.StatusOK, nil)
+		do(t, h, http.MethodGet, "/budget/alerts?user_id="+userI, "", http.StatusOK, &alerts)
+		if len(alerts.Alerts) != 0 {
+			t.Fatalf("alerts = %+v, want none while paused", alerts.Alerts)
+		}
+		do(t, h, http.MethodPost, path+"/resume", `{"date":"2020-02-01"}`, http.StatusOK, nil)
+		do(t, h, http.MethodGet, "/budget/alerts?user_id="+userI, "", http.StatusOK, &alerts)
+		if len(alerts.Alerts) != 1 || alerts.Alerts[0].Threshold != 80 || alerts.Alerts[0].Spent != 450 {
+			t.Fatalf("alerts = %+v, want the 80%% alert of the software budget", alerts.Alerts)
+		}
+	})
+
+	t.Run("Forecast", func(t *testing.T) {
+		month := time.Now().UTC().AddDate(0, 0, 1-time.Now().UTC().Day())
+		next := month.AddDate(0, 1, 0)
+		var netflix struct{ Id int }
+		//An open-ended subscription, one ending with the next month and one starting after it
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":500,"user_id":"`+userJ+`","start_date":"01-2020"}`, http.StatusCreated, &netflix)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Spotify","price":300,"user_id":"`+userJ+`","start_date":"01-2020","end_date":"`+next.Format("01-2006")+`"}`, http.StatusCreated, nil)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Gym","price":2000,"user_id":"`+userJ+`","start_date":"`+month.AddDate(0, 2, 0).Format("01-2006")+`","trial_months":1,"trial_price":1000}`, http.StatusCreated, nil)
+
+		var res struct {
+			Price  int
+			Months []struct {
+				Month         string
+				Price         int
+				Subscriptions []struct {
+					ServiceName string `json:"service_name"`
+					Cost        int
+					Explanation string
+				}
+			}
+		}
+		do(t, h, http.MethodGet, "/subscription/forecast?months=4&user_id="+userJ, "", http.StatusOK, &res)
+		if len(res.Months) != 4 {
+			t.Fatalf("got %d forecast months, want 4", len(res.Months))
+		}
+		for i, want := range []int{800, 1500, 2500} {
+			got := res.Months[i+1]
+			if got.Month != month.AddDate(0, i+1, 0).Format("2006-01-02") || got.Price != want {
+				t.Fatalf("forecast month %d = %s %d, want %d", i+1, got.Month, got.Price, want)
+			}
+		}
+		if gym := res.Months[2].Subscriptions[1]; gym.ServiceName != "Gym" || gym.Cost != 1000 || gym.Explanation == "" {
+			t.Fatalf("forecast of the trial = %+v", gym)
+		}
+		if res.Price != res.Months[0].Price+4800 {
+			t.Fatalf("forecast total = %d, want the sum of the months", res.Price)
+		}
+
+		//The price goes up from the charge two months ahead
+		changes := "/subscription/" + strconv.Itoa(netflix.Id) + "/price-changes"
+		effective := month.AddDate(0, 2, 0).Format(time.DateOnly)
+		do(t, h, http.MethodPost, changes, `{"effective_date":"`+effective+`","price":700}`, http.StatusCreated, nil)
+		doProblem(t, h, http.MethodPost, changes, `{"effective_date":"`+effective+`","price":800}`, http.StatusConflict)
+		doProblem(t, h, http.MethodPost, changes, `{"effective_date":"2020-01-01","price":800}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, changes, `{"effective_date":"`+effective+`"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodPost, "/subscription/999999/price-changes", `{"effective_date":"`+effective+`","price":800}`, http.StatusNotFound)
+		var listed struct {
+			PriceChanges []struct {
+				EffectiveDate string `json:"effective_date"`
+				Price         int
+			} `json:"price_changes"`
+		}
+		do(t, h, http.MethodGet, changes, "", http.StatusOK, &listed)
+		if len(listed.PriceChanges) != 1 || listed.PriceChanges[0].EffectiveDate != effective || listed.PriceChanges[0].Price != 700 {
+			t.Fatalf("price changes = %+v, want 700 from %s", listed.PriceChanges, effective)
+		}
+
+		do(t, h, http.MethodGet, "/subscription/forecast?months=4&user_id="+userJ, "", http.StatusOK, &res)
+		for i, want := range []int{800, 1700, 2700} {
+			if got := res.Months[i+1]; got.Price != want {
+				t.Fatalf("forecast month %d with the price change = %s %d, want %d", i+1, got.Month, got.Price, want)
+			}
+		}
+		//The price report bills the month of the change at the same price as the forecast
+		changed := month.AddDate(0, 2, 0).Format("01-2006")
+		var report struct{ Price int }
+		do(t, h, http.MethodGet, "/subscription/price?start_date="+changed+"&end_date="+changed+"&user_id="+userJ, "", http.StatusOK, &report)
+		if report.Price != res.Months[2].Price {
+			t.Fatalf("price of %s = %d, want %d as forecast", changed, report.Price, res.Months[2].Price)
+		}
+
+		do(t, h, http.MethodGet, "/subscription/forecast?user_id="+userJ, "", http.StatusOK, &res)
+		if len(res.Months) != 12 {
+			t.Fatalf("got %d forecast months by default, want 12", len(res.Months))
+		}
+		doProblem(t, h, http.MethodGet, "/subscription/forecast?months=0&user_id="+userJ, "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/forecast?months=61&user_id="+userJ, "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/forecast?months=12", "", http.StatusBadRequest)
+	})
+
+	t.Run("Insights", func(t *testing.T) {
+		//The same service bought twice in March 2026 at a higher price, and a gym membership open since 2020
+		var old, current, gym struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":500,"user_id":"`+userK+`","start_date":"01-2025","end_date":"03-2026"}`, http.StatusCreated, &old)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"NETFLIX","price":700,"user_id":"`+userK+`","start_date":"03-2026"}`, http.StatusCreated, &current)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Gym","price":2000,"user_id":"`+userK+`","start_date":"01-2020"}`, http.StatusCreated, &gym)
+
+		var res struct{ Insights []insight }
+		do(t, h, http.MethodGet, "/subscription/user/"+userK+"/insights", "", http.StatusOK, &res)
+		pair := strconv.Itoa(old.Id) + "-" + strconv.Itoa(current.Id)
+		want := []string{"duplicate-" + pair, "price_jump-" + pair, "long_open_ended-" + strconv.Itoa(gym.Id)}
+		if len(res.Insights) != len(want) {
+			t.Fatalf("insights = %+v, want %v", res.Insights, want)
+		}
+		for i, id := range want {
+			if res.Insights[i].Id != id {
+				t.Fatalf("insight %d = %+v, want %s", i, res.Insights[i], id)
+			}
+		}
+		if duplicate := res.Insights[0]; *duplicate.From != "2026-03-01" || *duplicate.To != "2026-03-31" {
+			t.Fatalf("duplicate = %+v, want it in March 2026", duplicate)
+		}
+		if jump := res.Insights[1]; *jump.Increase != 40 {
+			t.Fatalf("price jump = %+v, want 40%%", jump)
+		}
+
+		var dismissed struct{ Insight insight }
+		do(t, h, http.MethodPost, "/subscription/user/"+userK+"/insights/"+want[0]+"/dismiss", "", http.StatusOK, &dismissed)
+		if dismissed.Insight.Id != want[0] || dismissed.Insight.DismissedAt == nil {
+			t.Fatalf("dismissed insight = %+v", dismissed.Insight)
+		}
+		do(t, h, http.MethodPost, "/subscription/user/"+userK+"/insights/"+want[0]+"/dismiss", "", http.StatusOK, nil)
+		do(t, h, http.MethodGet, "/subscription/user/"+userK+"/insights", "", http.StatusOK, &res)
+		if len(res.Insights) != 2 || res.Insights[0].Id != want[1] {
+			t.Fatalf("insights = %+v, want them without the dismissed one", res.Insights)
+		}
+		do(t, h, http.MethodGet, "/subscription/user/"+userK+"/insights?dismissed=true", "", http.StatusOK, &res)
+		if len(res.Insights) != 3 || res.Insights[0].DismissedAt == nil {
+			t.Fatalf("insights = %+v, want all of them", res.Insights)
+		}
+
+		//Another user has no such insight
+		doProblem(t, h, http.MethodPost, "/subscription/user/"+userA+"/insights/"+want[0]+"/dismiss", "", http.StatusNotFound)
+		doProblem(t, h, http.MethodGet, "/subscription/user/"+userK+"/insights?dismissed=maybe", "", http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/subscription/user/not-a-uuid/insights", "", http.StatusBadRequest)
+	})
+
+	t.Run("Shared", func(t *testing.T) {
+		//Each user pays for one subscription and shares it with the other
+		var netflix, spotify struct{ Id int }
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":900,"user_id":"`+userL+`","start_date":"01-2026","end_date":"12-2026"}`, http.StatusCreated, &netflix)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Spotify","price":300,"user_id":"`+userM+`","start_date":"01-2026","end_date":"12-2026"}`, http.StatusCreated, &spotify)
+
+		var res struct{ Subscription sharedSubscription }
+		do(t, h, http.MethodPut, "/subscription/"+strconv.Itoa(netflix.Id)+"/split", `{"rule":"equal","members":[{"user_id":"`+userM+`"}]}`, http.StatusOK, &res)
+		if split := res.Subscription.Split; split == nil || split.Rule != "equal" || len(split.Members) != 1 || split.Members[0].UserId != userM || split.Members[0].Share != nil {
+			t.Fatalf("subscription = %+v, want it shared equally with the member", res.Subscription)
+		}
+		do(t, h, http.MethodPut, "/subscription/"+strconv.Itoa(spotify.Id)+"/split", `{"rule":"percentage","members":[{"user_id":"`+userL+`","share":50}]}`, http.StatusOK, nil)
+
+		//Only the member's share is counted
+		var price struct {
+			Price         int
+			Subscriptions []struct {
+				ServiceName string `json:"service_name"`
+				Cost        int
+				Share       int
+				Explanation string
+			}
+		}
+		do(t, h, http.MethodGet, "/subscription/price?start_date=03-2026&end_date=03-2026&explain=true&user_id="+userM, "", http.StatusOK, &price)
+		if price.Price != 600 || len(price.Subscriptions) != 2 {
+			t.Fatalf("price = %+v, want 450 for Netflix and 150 for Spotify", price)
+		}
+		if item := price.Subscriptions[0]; item.ServiceName != "Netflix" || item.Cost != 900 || item.Share != 450 || !strings.Contains(item.Explanation, "450") {
+			t.Fatalf("shared item = %+v", item)
+		}
+
+		var debts struct {
+			Debts []struct {
+				From   string
+				To     string
+				Amount int
+			}
+		}
+		do(t, h, http.MethodGet, "/subscription/debts?start_date=01-2026&end_date=03-2026&user_id="+userL, "", http.StatusOK, &debts)
+		if len(debts.Debts) != 1 || debts.Debts[0].From != userM || debts.Debts[0].To != userL || debts.Debts[0].Amount != 900 {
+			t.Fatalf("debts = %+v, want %s to owe %s 3 × (450 - 150)", debts.Debts, userM, userL)
+		}
+
+		for _, body := range []string{
+			`{"rule":"percentage","members":[{"user_id":"` + userM + `"}]}`,
+			`{"rule":"percentage","members":[{"user_id":"` + userM + `","share":101}]}`,
+			`{"rule":"fixed","members":[{"user_id":"` + userM + `","share":1000}]}`,
+			`{"rule":"equal","members":[{"user_id":"` + userL + `"}]}`,
+			`{"rule":"equal","members":[{"user_id":"` + userM + `"},{"user_id":"` + userM + `"}]}`,
+			`{"rule":"half","members":[]}`,
+		} {
+			doProblem(t, h, http.MethodPut, "/subscription/"+strconv.Itoa(netflix.Id)+"/split", body, http.StatusBadRequest)
+		}
+		doProblem(t, h, http.MethodPut, "/subscription/100000/split", `{"rule":"equal","members":[]}`, http.StatusNotFound)
+		//The members must be registered users of the organization, like the owners
+		problem := doProblem(t, h, http.MethodPut, "/subscription/"+strconv.Itoa(netflix.Id)+"/split", `{"rule":"equal","members":[{"user_id":"a3b4c5d6-e7f8-4a9b-8c0d-1e2f3a4b5c6d"}]}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeBadRequest {
+			t.Fatalf("problem = %+v, want bad request for an unknown member", problem)
+		}
+		outsider := "b1c2d3e4-f5a6-4b7c-8d9e-0f1a2b3c4d5e"
+		do(t, h, http.MethodPost, "/user/", `{"id":"`+outsider+`","display_name":"Outsider"}`, http.StatusCreated, nil)
+		problem = doProblem(t, h, http.MethodPut, "/subscription/"+strconv.Itoa(netflix.Id)+"/split", `{"rule":"equal","members":[{"user_id":"`+outsider+`"}]}`, http.StatusForbidden)
+		if problem.Type != handlers.ProblemTypeForbidden {
+			t.Fatalf("problem = %+v, want forbidden for a member outside of the organization", problem)
+		}
+		doProblem(t, h, http.MethodGet, "/subscription/debts?start_date=03-2026", "", http.StatusBadRequest)
+
+		//No members stop sharing
+		do(t, h, http.MethodPut, "/subscription/"+strconv.Itoa(netflix.Id)+"/split", `{"rule":"equal","members":[]}`, http.StatusOK, &res)
+		if res.Subscription.Split != nil {
+			t.Fatalf("subscription = %+v, want it not shared", res.Subscription)
+		}
+		do(t, h, http.MethodGet, "/subscription/debts?start_date=01-2026&end_date=03-2026&user_id="+userL, "", http.StatusOK, &debts)
+		if len(debts.Debts) != 1 || debts.Debts[0].From != userL || debts.Debts[0].Amount != 450 {
+			t.Fatalf("debts = %+v, want %s to owe %s 3 × 150", debts.Debts, userL, userM)
+		}
+
+		//A price change by the owner rechecks the budgets of the members
+		var budget, shared struct{ Id int }
+		do(t, h, http.MethodPost, "/budget/", `{"amount":1000,"user_id":"`+userM+`"}`, http.StatusCreated, &budget)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":600,"user_id":"`+userL+`","start_date":"01-2020"}`, http.StatusCreated, &shared)
+		do(t, h, http.MethodPut, "/subscription/"+strconv.Itoa(shared.Id)+"/split", `{"rule":"equal","members":[{"user_id":"`+userM+`"}]}`, http.StatusOK, nil)
+		var alerts struct{ Alerts []budgetAlert }
+		do(t, h, http.MethodGet, "/budget/alerts?user_id="+userM, "", http.StatusOK, &alerts)
+		if len(alerts.Alerts) != 0 {
+			t.Fatalf("alerts = %+v, want none below 80%%", alerts.Alerts)
+		}
+		do(t, h, http.MethodPatch, "/subscription/"+strconv.Itoa(shared.Id), `{"price":1640}`, http.StatusOK, nil)
+		do(t, h, http.MethodGet, "/budget/alerts?user_id="+userM, "", http.StatusOK, &alerts)
+		if len(alerts.Alerts) != 1 || alerts.Alerts[0].BudgetId != budget.Id || alerts.Alerts[0].Threshold != 80 {
+			t.Fatalf("alerts = %+v, want the 80%% alert of the member", alerts.Alerts)
+		}
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		var family, company struct{ Id int }
+		do(t, h, http.MethodPost, "/organization/", `{"name":"Family","user_id":"`+userN+`"}`, http.StatusCreated, &family)
+		do(t, h, http.MethodPost, "/organization/", `{"name":"Company"}`, http.StatusCreated, &company)
+		familyId := strconv.Itoa(family.Id)
+
+		var members struct {
+			Members []struct {
+				UserId string `json:"user_id"`
+			}
+		}
+		doIn(t, h, family.Id, http.MethodPost, "/organization/"+familyId+"/members", `{"user_id":"`+userO+`"}`, http.StatusOK, &members)
+		if len(members.Members) != 2 || members.Members[0].UserId != userN || members.Members[1].UserId != userO {
+			t.Fatalf("members = %+v, want %s and %s", members.Members, userN, userO)
+		}
+
+		//An organization is changed only with its own ID in the header
+		var other handlers.ErrorResponse
+		doIn(t, h, company.Id, http.MethodPatch, "/organization/"+familyId, `{"name":"Acme"}`, http.StatusForbidden, &other)
+		if other.Type != handlers.ProblemTypeForbidden {
+			t.Fatalf("problem = %+v, want forbidden", other)
+		}
+		doIn(t, h, company.Id, http.MethodPost, "/organization/"+familyId+"/members", `{"user_id":"`+userA+`"}`, http.StatusForbidden, nil)
+		doIn(t, h, company.Id, http.MethodDelete, "/organization/"+familyId+"/members/"+userO, "", http.StatusForbidden, nil)
+		doIn(t, h, company.Id, http.MethodDelete, "/organization/"+familyId, "", http.StatusForbidden, nil)
+		doIn(t, h, defaultOrganization, http.MethodGet, "/organization/"+familyId+"/price?start_date=03-2026&end_date=04-2026", "", http.StatusForbidden, nil)
+		doIn(t, h, 0, http.MethodGet, "/organization/"+familyId, "", http.StatusBadRequest, nil)
+
+		//Only the members create subscriptions in the organization
+		var netflix, zvuk, personal struct{ Id int }
+		doIn(t, h, family.Id, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":900,"user_id":"`+userN+`","start_date":"01-2026"}`, http.StatusCreated, &netflix)
+		doIn(t, h, family.Id, http.MethodPost, "/subscription/", `{"service_name":"Zvuk","price":300,"user_id":"`+userO+`","start_date":"01-2026"}`, http.StatusCreated, &zvuk)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Gym","price":2000,"user_id":"`+userN+`","start_date":"01-2026"}`, http.StatusCreated, &personal)
+		var problem handlers.ErrorResponse
+		doIn(t, h, company.Id, http.MethodPost, "/subscription/", `{"service_name":"Slack","price":700,"user_id":"`+userN+`","start_date":"01-2026"}`, http.StatusForbidden, &problem)
+		if problem.Type != handlers.ProblemTypeForbidden {
+			t.Fatalf("problem = %+v, want forbidden", problem)
+		}
+
+		var res struct {
+			Subscription struct {
+				OrganizationId int `json:"organization_id"`
+			}
+		}
+		doIn(t, h, family.Id, http.MethodGet, "/subscription/"+strconv.Itoa(netflix.Id), "", http.StatusOK, &res)
+		if res.Subscription.OrganizationId != family.Id {
+			t.Fatalf("organization_id = %v, want %d", res.Subscription.OrganizationId, family.Id)
+		}
+
+		//The organization sees only its subscriptions, the administrative requests without the header all of them
+		var subscriptions struct{ Subscriptions []subscription }
+		doIn(t, h, family.Id, http.MethodGet, "/subscription/user/"+userN+"?page=1&limit=10", "", http.StatusOK, &subscriptions)
+		if want := strconv.Itoa(netflix.Id); ids(subscriptions.Subscriptions) != want {
+			t.Fatalf("ids = %s, want %s", ids(subscriptions.Subscriptions), want)
+		}
+		do(t, h, http.MethodGet, "/subscription/user/"+userN+"?page=1&limit=10", "", http.StatusOK, &subscriptions)
+		if want := strconv.Itoa(personal.Id); ids(subscriptions.Subscriptions) != want {
+			t.Fatalf("ids = %s, want %s", ids(subscriptions.Subscriptions), want)
+		}
+		doIn(t, h, company.Id, http.MethodGet, "/subscription/"+strconv.Itoa(netflix.Id), "", http.StatusNotFound, nil)
+
+		//Without the header the administrative merge renames the subscriptions of all the organizations
+		var merge nameMerge
+		doIn(t, admin, 0, http.MethodPost, "/subscription/names/merge", `{"canonical":"Zvuk Premium","names":["Zvuk"]}`, http.StatusOK, &merge)
+		if merge.Subscriptions != 2 {
+			t.Fatalf("merge = %+v, want the subscriptions of both organizations renamed", merge)
+		}
+		var renamed struct{ Subscription subscription }
+		doIn(t, h, family.Id, http.MethodGet, "/subscription/"+strconv.Itoa(zvuk.Id), "", http.StatusOK, &renamed)
+		if renamed.Subscription.ServiceName != "Zvuk Premium" {
+			t.Fatalf("service name = %s, want Zvuk Premium", renamed.Subscription.ServiceName)
+		}
+		//Each organization lists only its own merges
+		var merges struct{ Merges []nameMerge }
+		doIn(t, h, family.Id, http.MethodGet, "/subscription/names/merges?page=1&limit=10", "", http.StatusOK, &merges)
+		if len(merges.Merges) != 0 {
+			t.Fatalf("merges of the organization = %+v, want none", merges.Merges)
+		}
+
+		var cost struct {
+			Total int
+			Users []struct {
+				UserId string `json:"user_id"`
+				Cost   int
+			}
+		}
+		doIn(t, h, family.Id, http.MethodGet, "/organization/"+familyId+"/price?start_date=03-2026&end_date=04-2026", "", http.StatusOK, &cost)
+		if cost.Total != 2400 || len(cost.Users) != 2 || cost.Users[0].UserId != userN || cost.Users[0].Cost != 1800 || cost.Users[1].Cost != 600 {
+			t.Fatalf("cost = %+v, want 2 × 900 for %s and 2 × 300 for %s", cost, userN, userO)
+		}
+
+		var organizations struct {
+			Organizations []struct{ Id int }
+		}
+		doIn(t, h, 0, http.MethodGet, "/organization/?user_id="+userN, "", http.StatusOK, &organizations)
+		if len(organizations.Organizations) != 2 || organizations.Organizations[0].Id != defaultOrganization || organizations.Organizations[1].Id != family.Id {
+			t.Fatalf("organizations = %+v, want %d and %d", organizations.Organizations, defaultOrganization, family.Id)
+		}
+
+		doIn(t, h, family.Id, http.MethodDelete, "/organization/"+familyId+"/members/"+userO, "", http.StatusOK, &members)
+		if len(members.Members) != 1 {
+			t.Fatalf("members = %+v, want only %s", members.Members, userN)
+		}
+		doIn(t, h, family.Id, http.MethodDelete, "/organization/"+familyId, "", http.StatusConflict, nil)
+		doIn(t, h, company.Id, http.MethodDelete, "/organization/"+strconv.Itoa(company.Id), "", http.StatusOK, nil)
+		doIn(t, h, company.Id, http.MethodGet, "/organization/"+strconv.Itoa(company.Id), "", http.StatusNotFound, nil)
+
+		//The header is required
+		for _, header := range []string{"", "abc", "0", "-1"} {
+			req := httptest.NewRequest(http.MethodGet, "/subscription/user/"+userN+"?page=1&limit=10", nil)
+			req.Header.Set("X-Organization-Id", header)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("X-Organization-Id %q: status = %d, want 400", header, rec.Code)
+			}
+		}
+	})
+
+	t.Run("Health", func(t *testing.T) {
+		do(t, h, http.MethodGet, "/healthz", "", http.StatusOK, nil)
+
+		var res struct{ Status string }
+		do(t, h, http.MethodGet, "/readyz", "", http.StatusOK, &res)
+		if res.Status != "ok" {
+			t.Fatalf("status = %s, want ok", res.Status)
+		}
+	})
+
+	t.Run("User", func(t *testing.T) {
+		type user struct {
+			Id           string     `json:"id"`
+			DisplayName  string     `json:"display_name"`
+			Email        *string    `json:"email"`
+			Locale       string     `json:"locale"`
+			Currency     string     `json:"currency"`
+			Timezone     string     `json:"timezone"`
+			AnonymizedAt *time.Time `json:"anonymized_at"`
+		}
+		var created struct{ User user }
+		do(t, h, http.MethodPost, "/user/", `{"display_name":"Pavel","email":"pavel@example.com","locale":"ru-RU","timezone":"Europe/Moscow"}`, http.StatusCreated, &created)
+		if created.User.Id == "" || created.User.Locale != "ru-RU" || created.User.Currency != "RUB" || created.User.Timezone != "Europe/Moscow" || created.User.AnonymizedAt != nil {
+			t.Fatalf("user = %+v, want Pavel in ru-RU with the default currency", created.User)
+		}
+		userP := created.User.Id
+
+		problem := doProblem(t, h, http.MethodPost, "/user/", `{"display_name":"Pavel","email":"PAVEL@example.com"}`, http.StatusConflict)
+		if problem.Type != handlers.ProblemTypeConflict {
+			t.Fatalf("problem = %+v, want conflict", problem)
+		}
+		doProblem(t, h, http.MethodPost, "/user/", `{"id":"`+userA+`","display_name":"Alice"}`, http.StatusConflict)
+		problem = doProblem(t, h, http.MethodPost, "/user/", `{"display_name":"","email":"pavel","locale":"!!","currency":"RUBL","timezone":"Mars/Olympus"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeValidation || len(problem.Errors) != 5 {
+			t.Fatalf("problem = %+v, want validation errors of all the fields", problem)
+		}
+
+		var users struct{ Users []user }
+		do(t, h, http.MethodGet, "/user/?page=1&limit=100", "", http.StatusOK, &users)
+		if len(users.Users) != 17 {
+			t.Fatalf("got %d users, want 17, the outsider of the split included", len(users.Users))
+		}
+
+		var updated struct{ User user }
+		do(t, h, http.MethodPatch, "/user/"+userP, `{"currency":"EUR"}`, http.StatusOK, &updated)
+		if updated.User.DisplayName != "Pavel" || updated.User.Currency != "EUR" {
+			t.Fatalf("user = %+v, want Pavel paying in EUR", updated.User)
+		}
+		//An explicit null or an empty string removes the email, a missing one keeps it
+		do(t, h, http.MethodPatch, "/user/"+userP, `{"email":null}`, http.StatusOK, &updated)
+		if updated.User.Email != nil || updated.User.Currency != "EUR" {
+			t.Fatalf("user = %+v, want no email", updated.User)
+		}
+		do(t, h, http.MethodPatch, "/user/"+userP, `{"email":"pavel@example.com"}`, http.StatusOK, &updated)
+		do(t, h, http.MethodPatch, "/user/"+userP, `{"email":""}`, http.StatusOK, &updated)
+		if updated.User.Email != nil {
+			t.Fatalf("user = %+v, want no email", updated.User)
+		}
+		do(t, h, http.MethodPatch, "/user/"+userP, `{"email":"pavel@example.com"}`, http.StatusOK, &updated)
+		do(t, h, http.MethodPatch, "/user/"+userP, `{"timezone":"UTC"}`, http.StatusOK, &updated)
+		if updated.User.Email == nil || *updated.User.Email != "pavel@example.com" {
+			t.Fatalf("user = %+v, want the email kept", updated.User)
+		}
+
+		//Subscriptions are rejected for unknown users
+		problem = doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":100,"user_id":"a3b4c5d6-e7f8-4a9b-8c0d-1e2f3a4b5c6d","start_date":"01-2026"}`, http.StatusBadRequest)
+		if problem.Type != handlers.ProblemTypeBadRequest {
+			t.Fatalf("problem = %+v, want bad request", problem)
+		}
+
+		//By default the deleted user is anonymized and the subscriptions are kept without the notes
+		var sub struct{ Id int }
+		do(t, h, http.MethodPost, "/organization/"+strconv.Itoa(defaultOrganization)+"/members", `{"user_id":"`+userP+`"}`, http.StatusOK, nil)
+		do(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":100,"user_id":"`+userP+`","start_date":"01-2026","notes":"Pavel's card"}`, http.StatusCreated, &sub)
+		var deleted struct{ User user }
+		do(t, h, http.MethodDelete, "/user/"+userP, "", http.StatusOK, &deleted)
+		if deleted.User.DisplayName != "" || deleted.User.Email != nil || deleted.User.AnonymizedAt == nil {
+			t.Fatalf("deleted user = %+v, want it anonymized", deleted.User)
+		}
+		var res struct {
+			Subscription struct {
+				UserId string  `json:"user_id"`
+				Notes  *string `json:"notes"`
+			}
+		}
+		do(t, h, http.MethodGet, "/subscription/"+strconv.Itoa(sub.Id), "", http.StatusOK, &res)
+		if res.Subscription.UserId != userP || res.Subscription.Notes != nil {
+			t.Fatalf("subscription = %+v, want it of %s without the notes", res.Subscription, userP)
+		}
+
+		doProblem(t, h, http.MethodPatch, "/user/"+userP, `{"display_name":"Pavel"}`, http.StatusNotFound)
+		doProblem(t, h, http.MethodDelete, "/user/"+userP, "", http.StatusNotFound)
+		doProblem(t, h, http.MethodPost, "/subscription/", `{"service_name":"Netflix","price":100,"user_id":"`+userP+`","start_date":"01-2026"}`, http.StatusBadRequest)
+		doProblem(t, h, http.MethodGet, "/user/abc", "", http.StatusBadRequest)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		body := do(t, admin, http.MethodGet, "/metrics", "", http.StatusOK, nil)
+		if !strings.Contains(body, "subscription_service_active_subscriptions ") {
+			t.Fatalf("metrics do not contain the active subscriptions:\n%s", body)
+		}
+		if !strings.Contains(body, `subscription_service_budget_alerts_total{threshold="100"} 1`) {
+			t.Fatalf("metrics do not contain the budget alert:\n%s", body)
+		}
+		//The admin server is measured like the public one
+		if !strings.Contains(body, `subscription_service_http_requests_total{method="POST",route="/subscription/names/merge",status="200"}`) {
+			t.Fatalf("metrics do not contain the administrative requests:\n%s", body)
+		}
+	})
+
+	t.Run("Swagger", func(t *testing.T) {
+		var doc struct {
+			Paths map[string]map[string]struct {
+				Parameters []struct{ Name, In string }
+			}
+		}
+		do(t, h, http.MethodGet, "/swagger/doc.json", "", http.StatusOK, &doc)
+		//Every tenant-scoped operation declares the organization header
+		for path, operations := range doc.Paths {
+			if !strings.HasPrefix(path, "/subscription") && !strings.HasPrefix(path, "/service") && !strings.HasPrefix(path, "/plan") &&
+				!strings.HasPrefix(path, "/tag") && !strings.HasPrefix(path, "/budget") && !strings.HasPrefix(path, "/organization/{id}") {
+				continue
+			}
+			for method, operation := range operations {
+				if !slices.ContainsFunc(operation.Parameters, func(p struct{ Name, In string }) bool { return p.Name == "X-Organization-Id" && p.In == "header" }) {
+					t.Errorf("%s %s does not declare the X-Organization-Id header", strings.ToUpper(method), path)
+				}
+			}
+		}
+	})
+}
+
+type subscription struct {
+	Id          int     `json:"id"`
+	ServiceName string  `json:"service_name"`
+	Price       int     `json:"price"`
+	UserId      string  `json:"user_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     *string `json:"end_date"`
+	BillingDay  int     `json:"billing_day"`
+	TrialMonths int     `json:"trial_months"`
+	TrialEndsAt *string `json:"trial_ends_at"`
+	Pauses      []pause `json:"pauses"`
+}
+
+// statusSubscription adds the fields that depend on the current date
+type statusSubscription struct {
+	subscription
+	Status       string  `json:"status"`
+	CancelledAt  *string `json:"cancelled_at"`
+	CancelReason *string `json:"cancel_reason"`
+}
+
+type catalogSubscription struct {
+	subscription
+	ServiceId  *int `json:"service_id"`
+	PlanId     *int `json:"plan_id"`
+	CategoryId *int `json:"category_id"`
+}
+
+type taggedSubscription struct {
+	subscription
+	Tags  []string `json:"tags"`
+	Notes *string  `json:"notes"`
+}
+
+type sharedSubscription struct {
+	subscription
+	Split *struct {
+		Rule    string `json:"rule"`
+		Members []struct {
+			UserId string `json:"user_id"`
+			Share  *int   `json:"share"`
+		} `json:"members"`
+	} `json:"split"`
+}
+
+type tag struct {
+	Id            int    `json:"id"`
+	Name          string `json:"name"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+type budget struct {
+	Id         int  `json:"id"`
+	Amount     int  `json:"amount"`
+	CategoryId *int `json:"category_id"`
+	ServiceId  *int `json:"service_id"`
+}
+
+type budgetStatus struct {
+	Budget  budget `json:"budget"`
+	Month   string `json:"month"`
+	Spent   int    `json:"spent"`
+	Percent int    `json:"percent"`
+	Level   string `json:"level"`
+}
+
+type budgetAlert struct {
+	BudgetId  int `json:"budget_id"`
+	Threshold int `json:"threshold"`
+	Spent     int `json:"spent"`
+}
+
+type insight struct {
+	Id          string  `json:"id"`
+	From        *string `json:"from"`
+	To          *string `json:"to"`
+	Increase    *int    `json:"increase"`
+	DismissedAt *string `json:"dismissed_at"`
+}
+
+type category struct {
+	Name   string  `json:"name"`
+	UserId *string `json:"user_id"`
+}
+
+type categoryCost struct {
+	CategoryId *int   `json:"category_id"`
+	Name       string `json:"name"`
+	ParentId   *int   `json:"parent_id"`
+	Price      int    `json:"price"`
+}
+
+type catalogService struct {
+	Name    string        `json:"name"`
+	Aliases []string      `json:"aliases"`
+	Plans   []catalogPlan `json:"plans"`
+}
+
+type catalogPlan struct {
+	Price         int    `json:"price"`
+	BillingPeriod string `json:"billing_period"`
+}
+
+type nameCluster struct {
+	Canonical string `json:"canonical"`
+	Names     []struct {
+		Name          string `json:"name"`
+		Subscriptions int    `json:"subscriptions"`
+	} `json:"names"`
+	Subscriptions int `json:"subscriptions"`
+}
+
+type nameMerge struct {
+	Id            int      `json:"id"`
+	Canonical     string   `json:"canonical"`
+	Names         []string `json:"names"`
+	Subscriptions int      `json:"subscriptions"`
+	MergedAt      string   `json:"merged_at"`
+}
+
+type pause struct {
+	StartDate string  `json:"start_date"`
+	EndDate   *string `json:"end_date"`
+}
+
+func (s subscription) equal(o subscription) bool {
+	if !equalDates(s.EndDate, o.EndDate) || !equalDates(s.TrialEndsAt, o.TrialEndsAt) {
+		return false
+	}
+	s.EndDate, o.EndDate = nil, nil
+	s.TrialEndsAt, o.TrialEndsAt = nil, nil
+	if len(s.Pauses) != len(o.Pauses) {
+		return false
+	}
+	for i := range s.Pauses {
+		if s.Pauses[i].StartDate != o.Pauses[i].StartDate || !equalDates(s.Pauses[i].EndDate, o.Pauses[i].EndDate) {
+			return false
+		}
+	}
+	s.Pauses, o.Pauses = nil, nil
+	return reflect.DeepEqual(s, o)
+}
+
+func equalDates(a, b *string) bool {
+	return (a == nil) == (b == nil) && (a == nil || *a == *b)
+}
+
+func ids(subscriptions []subscription) string {
+	var res []string
+	for _, s := range subscriptions {
+		res = append(res, strconv.Itoa(s.Id))
+	}
+	return strings.Join(res, ",")
+}
+
+func equalIds(got *int, want int) bool {
+	return got != nil && *got == want
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+// do sends the request in the default organization, checks the status and decodes the JSON body into res if it is not nil
+func do(t *testing.T, h http.Handler, method, target, body string, status int, res any) string {
+	t.Helper()
+
+	return doIn(t, h, defaultOrganization, method, target, body, status, res)
+}
+
+// doIn is do in the organization, without the header if organizationId is 0
+func doIn(t *testing.T, h http.Handler, organizationId int, method, target, body string, status int, res any) string {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if organizationId != 0 {
+		req.Header.Set("X-Organization-Id", strconv.Itoa(organizationId))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != status {
+		t.Fatalf("%s %s: status = %d, want %d, body: %s", method, target, rec.Code, status, rec.Body.String())
+	}
+	if res != nil {
+		err := json.Unmarshal(rec.Body.Bytes(), res)
+		if err != nil {
+			t.Fatalf("%s %s: decoding %s: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec.Body.String()
+}
+
+func doProblem(t *testing.T, h http.Handler, method, target, body string, status int) handlers.ErrorResponse {
+	t.Helper()
+
+	var problem handlers.ErrorResponse
+	do(t, h, method, target, body, status, &problem)
+	if problem.Status != status {
+		t.Fatalf("%s %s: problem status = %d, want %d", method, target, problem.Status, status)
+	}
+	return problem
+}
@@ -1,33 +0,0 @@
-package app
-
-import (
-	"errors"
-	"fmt"
-	"os"
-
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-)
-
-func init() {
-	dbURL := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
-
-	m, err := migrate.New("file://migrations", dbURL)
-	if err != nil {
-		panic(fmt.Sprintf("app:init:migrate.New - %s", err.Error()))
-	}
-	err = m.Up()
-	defer m.Close()
-
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		panic(fmt.Sprintf("app:init:m.Up - %s", err.Error()))
-	}
-}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Estriper0/subscription_service/internal/config"
+	"github.com/Estriper0/subscription_service/internal/health"
+	"github.com/Estriper0/subscription_service/internal/metrics"
+	"github.com/Estriper0/subscription_service/internal/migrator"
+	"github.com/Estriper0/subscription_service/internal/repository/db"
+	"github.com/Estriper0/subscription_service/internal/repository/memory"
+	sqliteRepo "github.com/Estriper0/subscription_service/internal/repository/sqlite"
+	"github.com/Estriper0/subscription_service/internal/service"
+	"github.com/Estriper0/subscription_service/pkg/postgres"
+	"github.com/Estriper0/subscription_service/pkg/sqlite"
+)
+
+// storage is the repository implementation selected by config.DBConfig.Storage
+type storage struct {
+	subscriptionRepo service.ISubscriptionRepo
+	//The same repository, the catalog, the categories, the tags, the name merges, the budgets and the insight dismissals share the database with the subscriptions
+	catalogRepo  service.ICatalogRepo
+	categoryRepo service.ICategoryRepo
+	tagRepo      service.ITagRepo
+	nameRepo     service.INameRepo
+	budgetRepo   service.IBudgetRepo
+	insightRepo  service.IInsightRepo
+	splitRepo    service.ISplitRepo
+	//The organizations scope the other repositories by the tenant of the request context
+	organizationRepo service.IOrganizationRepo
+	userRepo         service.IUserRepo
+	checks           []health.Check
+	close            func()
+}
+
+func newStorage(logger *slog.Logger, cfg *config.Config, metrics *metrics.Metrics) (*storage, error) {
+	switch cfg.DB.Storage {
+	case config.StorageMemory:
+		logger.Warn("Using the in-memory storage, data will be lost on restart")
+		repo := memory.NewSubscriptionRepo()
+		return &storage{
+			subscriptionRepo: repo,
+			catalogRepo:      repo,
+			categoryRepo:     repo,
+			tagRepo:          repo,
+			nameRepo:         repo,
+			budgetRepo:       repo,
+			insightRepo:      repo,
+			splitRepo:        repo,
+			organizationRepo: repo,
+			userRepo:         repo,
+			close:            func() {},
+		}, nil
+	case config.StoragePostgres:
+		return newPostgresStorage(logger, cfg, metrics)
+	case config.StorageSQLite:
+		return newSQLiteStorage(logger, cfg)
+	}
+
+	return nil, fmt.Errorf("app:newStorage - unknown storage %q", cfg.DB.Storage)
+}
+
+func newPostgresStorage(logger *slog.Logger, config *config.Config, metrics *metrics.Metrics) (*storage, error) {
+	if config.DB.AutoMigrate {
+		err := migrateUp(logger, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dbPool, err := postgres.New(config.DB.Url(), config.DB.PoolSize, db.TenantSetting, db.BypassSetting)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedMigration, err := migrator.LatestVersion(config.DB.Storage)
+	if err != nil {
+		dbPool.Close()
+		return nil, err
+	}
+
+	metrics.RegisterPool(dbPool)
+
+	repo := db.NewSubscriptionRepo(dbPool)
+	return &storage{
+		subscriptionRepo: repo,
+		catalogRepo:      repo,
+		categoryRepo:     repo,
+		tagRepo:          repo,
+		nameRepo:         repo,
+		budgetRepo:       repo,
+		insightRepo:      repo,
+		splitRepo:        repo,
+		organizationRepo: repo,
+		userRepo:         repo,
+		checks: []health.Check{
+			health.Postgres(dbPool),
+			health.Migrations(dbPool, expectedMigration),
+		},
+		close: dbPool.Close,
+	}, nil
+}
+
+func newSQLiteStorage(logger *slog.Logger, config *config.Config) (*storage, error) {
+	if config.DB.AutoMigrate {
+		err := migrateUp(logger, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sqliteDB, err := sqlite.New(config.DB.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedMigration, err := migrator.LatestVersion(config.DB.Storage)
+	if err != nil {
+		sqliteDB.Close()
+		return nil, err
+	}
+
+	repo := sqliteRepo.NewSubscriptionRepo(sqliteDB)
+	return &storage{
+		subscriptionRepo: repo,
+		catalogRepo:      repo,
+		categoryRepo:     repo,
+		tagRepo:          repo,
+		nameRepo:         repo,
+		budgetRepo:       repo,
+		insightRepo:      repo,
+		splitRepo:        repo,
+		organizationRepo: repo,
+		userRepo:         repo,
+		checks: []health.Check{
+			health.SQLite(sqliteDB),
+			health.SQLiteMigrations(sqliteDB, expectedMigration),
+		},
+		close: func() { sqliteDB.Close() },
+	}, nil
+}
+
+func migrateUp(logger *slog.Logger, config *config.Config) error {
+	m, err := migrator.New(config.DB.Storage, config.DB.Dsn(), logger)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	logger.Info("Applying database migrations")
+	return m.Up()
+}
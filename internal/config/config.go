@@ -9,29 +9,76 @@ import (
 )
 
 type Config struct {
-	App    AppConfig
-	Server ServerConfig `yaml:"server"`
-	DB     DBConfig     `yaml:"db"`
+	App     AppConfig
+	Server  ServerConfig  `yaml:"server"`
+	DB      DBConfig      `yaml:"db"`
+	Tracing TracingConfig `yaml:"tracing"`
 }
 
 type AppConfig struct {
 	Env string `env:"ENV" env-default:"local"`
+	//cascade or anonymize, what DELETE /user/{id} does with the user's data
+	UserDeletion string `env:"USER_DELETION" env-default:"anonymize"`
 }
 
+const (
+	UserDeletionCascade   = "cascade"
+	UserDeletionAnonymize = "anonymize"
+)
+
 type ServerConfig struct {
 	Port            int           `env-required:"true" yaml:"port" env:"APP_PORT"`
 	ReadTimeout     time.Duration `env-required:"true" yaml:"read_timeout" env:"READ_TIMEOUT"`
 	WriteTimeout    time.Duration `env-required:"true" yaml:"write_timeout" env:"WRITE_TIMEOUT"`
 	ShutdownTimeout time.Duration `env-required:"true" yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	//Time between reporting not ready and draining connections
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+	//Timeout of all readiness checks together
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env:"READINESS_TIMEOUT" env-default:"2s"`
+	//Port of the admin server with /metrics and the administrative endpoints,
+	//when zero the metrics are served on Port and the administrative endpoints are not served
+	AdminPort int `yaml:"admin_port" env:"ADMIN_PORT"`
 }
 
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
 type DBConfig struct {
-	Host     string `env-required:"true" env:"DB_HOST"`
-	Port     string `env-required:"true" env:"DB_PORT"`
-	User     string `env-required:"true" env:"DB_USER"`
-	Password string `env-required:"true" env:"DB_PASSWORD"`
-	Name     string `env-required:"true" env:"DB_NAME"`
+	//postgres, sqlite or memory, the connection settings are required only for postgres
+	Storage string `yaml:"storage" env:"DB_STORAGE" env-default:"postgres"`
+	//Database file of the sqlite storage
+	Path     string `yaml:"path" env:"DB_PATH" env-default:"data/subscriptions.db"`
+	Host     string `env:"DB_HOST"`
+	Port     string `env:"DB_PORT"`
+	User     string `env:"DB_USER"`
+	Password string `env:"DB_PASSWORD"`
+	Name     string `env:"DB_NAME"`
 	PoolSize int    `env-required:"true" yaml:"pool_size" env:"DB_POOL_SIZE"`
+	//Apply pending migrations on startup instead of running cmd/migrate
+	AutoMigrate bool `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+}
+
+type TracingConfig struct {
+	//none, stdout or otlp
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	//OTLP collector address, e.g. otel-collector:4317
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	//grpc or http
+	Protocol    string  `yaml:"protocol" env:"TRACING_PROTOCOL" env-default:"grpc"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"subscription_service"`
+}
+
+// Dsn returns the connection string of the configured storage for the migrator
+func (db *DBConfig) Dsn() string {
+	if db.Storage == StorageSQLite {
+		return db.Path
+	}
+	return db.Url()
 }
 
 func (db *DBConfig) Url() string {
@@ -53,5 +100,55 @@ func New(configPath string) *Config {
 		panic(fmt.Sprintf("config:New:ReadConfig - %s", err.Error()))
 	}
 
+	err = config.DB.validate()
+	if err != nil {
+		panic(fmt.Sprintf("config:New:validate - %s", err.Error()))
+	}
+	err = config.App.validate()
+	if err != nil {
+		panic(fmt.Sprintf("config:New:validate - %s", err.Error()))
+	}
+
 	return &config
 }
+
+func (db *DBConfig) validate() error {
+	switch db.Storage {
+	case StoragePostgres:
+		for name, value := range map[string]string{
+			"DB_HOST":     db.Host,
+			"DB_PORT":     db.Port,
+			"DB_USER":     db.User,
+			"DB_PASSWORD": db.Password,
+			"DB_NAME":     db.Name,
+		} {
+			if value == "" {
+				return fmt.Errorf("%s is required for the %s storage", name, db.Storage)
+			}
+		}
+	case StorageSQLite:
+		if db.Path == "" {
+			return fmt.Errorf("DB_PATH is required for the %s storage", db.Storage)
+		}
+	case StorageMemory:
+	default:
+		return fmt.Errorf("unknown storage %q", db.Storage)
+	}
+
+	return nil
+}
+
+// CascadeUserDeletion reports whether a deleted user is deleted with the subscriptions instead of being anonymized
+func (app *AppConfig) CascadeUserDeletion() bool {
+	return app.UserDeletion == UserDeletionCascade
+}
+
+func (app *AppConfig) validate() error {
+	switch app.UserDeletion {
+	case UserDeletionCascade, UserDeletionAnonymize:
+	default:
+		return fmt.Errorf("unknown user deletion %q", app.UserDeletion)
+	}
+
+	return nil
+}
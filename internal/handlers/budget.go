@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type BudgetHandler struct {
+	budgetService IBudgetService
+	validate      *validator.Validate
+}
+
+type IBudgetService interface {
+	CreateBudget(ctx context.Context, data *domain.BudgetCreate) (int, error)
+	GetBudget(ctx context.Context, id int) (*domain.Budget, error)
+	GetBudgets(ctx context.Context, userId uuid.UUID) ([]*domain.Budget, error)
+	UpdateBudget(ctx context.Context, data *domain.BudgetUpdate) (*domain.Budget, error)
+	DeleteBudget(ctx context.Context, id int) (*domain.Budget, error)
+	GetBudgetStatus(ctx context.Context, userId uuid.UUID, month *string) ([]*domain.BudgetStatus, error)
+	GetBudgetAlerts(ctx context.Context, userId uuid.UUID) ([]*domain.BudgetAlert, error)
+}
+
+func NewBudgetHandler(g *gin.RouterGroup, budgetService IBudgetService, validate *validator.Validate) {
+	r := &BudgetHandler{
+		budgetService: budgetService,
+		validate:      validate,
+	}
+
+	g.GET("/", r.GetBudgets)
+	g.POST("/", r.AddBudget)
+	g.GET("/status", r.GetBudgetStatus)
+	g.GET("/alerts", r.GetBudgetAlerts)
+	g.GET("/:id", r.GetBudget)
+	g.PATCH("/:id", r.UpdateBudget)
+	g.DELETE("/:id", r.DeleteBudget)
+}
+
+// AddBudget godoc
+// @Summary Создать бюджет
+// @Description Создаёт месячный бюджет пользователя: общий, на категорию или на сервис. У пользователя не больше одного бюджета на каждую категорию, сервис и общий.
+// @Description Если расходы текущего месяца уже достигли 80% или 100% бюджета, сразу создаётся уведомление.
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param request body dto.BudgetCreateRequest true "Данные бюджета"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные, неизвестная категория или сервис"
+// @Failure 409 {object} handlers.ErrorResponse "Бюджет для этой категории или сервиса уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /budget [post]
+func (h *BudgetHandler) AddBudget(c *gin.Context) {
+	var req dto.BudgetCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	UUID, _ := uuid.Parse(req.UserId)
+
+	id, err := h.budgetService.CreateBudget(c.Request.Context(), &domain.BudgetCreate{
+		UserId:     UUID,
+		Amount:     req.Amount,
+		CategoryId: req.CategoryId,
+		ServiceId:  req.ServiceId,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"id": id,
+		},
+	)
+}
+
+// GetBudgets godoc
+// @Summary Получить бюджеты пользователя
+// @Description Возвращает бюджеты пользователя
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param user_id query string true "UUID пользователя" format(uuid)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} map[string][]dto.Budget "Бюджеты"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /budget/ [get]
+func (h *BudgetHandler) GetBudgets(c *gin.Context) {
+	userId, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	budgets, err := h.budgetService.GetBudgets(c.Request.Context(), userId)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.Budget{}
+	for _, budget := range budgets {
+		res = append(res, toBudgetDTO(budget))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"budgets": res,
+		},
+	)
+}
+
+// GetBudgetStatus godoc
+// @Summary Получить состояние бюджетов
+// @Description Возвращает расходы месяца по каждому бюджету пользователя, их процент от бюджета и уровень: ok, warning от 80%, exceeded от 100%.
+// @Description Расходы считаются как в /subscription/price с помесячным пропорциональным расчётом.
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param user_id query string true "UUID пользователя" format(uuid)
+// @Param month query string false "Месяц в формате MM-YYYY, по умолчанию текущий" example(10-2026)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} map[string][]dto.BudgetStatus "Состояние бюджетов"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /budget/status [get]
+func (h *BudgetHandler) GetBudgetStatus(c *gin.Context) {
+	userId, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	var month *string
+	if value, ok := c.GetQuery("month"); ok {
+		if _, err := time.Parse("01-2006", value); err != nil {
+			respondWithError(c, errIncorrectMonth)
+			return
+		}
+		month = &value
+	}
+
+	statuses, err := h.budgetService.GetBudgetStatus(c.Request.Context(), userId, month)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.BudgetStatus{}
+	for _, status := range statuses {
+		res = append(res, dto.BudgetStatus{
+			Budget:  toBudgetDTO(&status.Budget),
+			Month:   status.Month,
+			Spent:   status.Spent,
+			Percent: status.Percent,
+			Level:   string(status.Level),
+		})
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"budgets": res,
+		},
+	)
+}
+
+// GetBudgetAlerts godoc
+// @Summary Получить уведомления о бюджетах
+// @Description Возвращает уведомления о достижении 80% и 100% бюджетов пользователя. Уведомление создаётся при изменении бюджета или подписки не больше одного раза за месяц на каждый порог.
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param user_id query string true "UUID пользователя" format(uuid)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} map[string][]dto.BudgetAlert "Уведомления"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /budget/alerts [get]
+func (h *BudgetHandler) GetBudgetAlerts(c *gin.Context) {
+	userId, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	alerts, err := h.budgetService.GetBudgetAlerts(c.Request.Context(), userId)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.BudgetAlert{}
+	for _, alert := range alerts {
+		res = append(res, dto.BudgetAlert{
+			Id:        alert.Id,
+			BudgetId:  alert.BudgetId,
+			Month:     alert.Month,
+			Threshold: alert.Threshold,
+			Spent:     alert.Spent,
+			Amount:    alert.Amount,
+			AlertedAt: alert.AlertedAt,
+		})
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"alerts": res,
+		},
+	)
+}
+
+// GetBudget godoc
+// @Summary Получить бюджет
+// @Description Возвращает бюджет по ID
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID бюджета" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Бюджет не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /budget/{id} [get]
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	budget, err := h.budgetService.GetBudget(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"budget": toBudgetDTO(budget),
+		},
+	)
+}
+
+// UpdateBudget godoc
+// @Summary Изменить сумму бюджета
+// @Description Изменяет сумму бюджета. Если расходы текущего месяца достигли 80% или 100% новой суммы, создаётся уведомление.
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID бюджета" minimum(0)
+// @Param request body dto.BudgetUpdateRequest true "Новая сумма бюджета"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Бюджет не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /budget/{id} [patch]
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.BudgetUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	budget, err := h.budgetService.UpdateBudget(c.Request.Context(), &domain.BudgetUpdate{
+		Id:     idInt,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"budget": toBudgetDTO(budget),
+		},
+	)
+}
+
+// DeleteBudget godoc
+// @Summary Удалить бюджет
+// @Description Удаляет бюджет вместе с его уведомлениями
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID бюджета" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Бюджет не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /budget/{id} [delete]
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	budget, err := h.budgetService.DeleteBudget(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"budget": toBudgetDTO(budget),
+		},
+	)
+}
+
+func toBudgetDTO(b *domain.Budget) dto.Budget {
+	return dto.Budget{
+		Id:         b.Id,
+		UserId:     b.UserId.String(),
+		Amount:     b.Amount,
+		CategoryId: b.CategoryId,
+		ServiceId:  b.ServiceId,
+	}
+}
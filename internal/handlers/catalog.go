@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CatalogHandler struct {
+	catalogService ICatalogService
+	validate       *validator.Validate
+}
+
+type ICatalogService interface {
+	CreateService(ctx context.Context, data *domain.ServiceCreate) (int, error)
+	GetService(ctx context.Context, id int) (*domain.Service, error)
+	GetServices(ctx context.Context, offset, limit int) ([]*domain.Service, error)
+	UpdateService(ctx context.Context, data *domain.ServiceUpdate) (*domain.Service, error)
+	DeleteService(ctx context.Context, id int) (*domain.Service, error)
+	CreatePlan(ctx context.Context, data *domain.PlanCreate) (int, error)
+	GetPlan(ctx context.Context, id int) (*domain.Plan, error)
+	UpdatePlan(ctx context.Context, data *domain.PlanUpdate) (*domain.Plan, error)
+	DeletePlan(ctx context.Context, id int) (*domain.Plan, error)
+}
+
+func NewCatalogHandler(services *gin.RouterGroup, plans *gin.RouterGroup, catalogService ICatalogService, validate *validator.Validate) {
+	r := &CatalogHandler{
+		catalogService: catalogService,
+		validate:       validate,
+	}
+
+	services.GET("/", r.GetServices)
+	services.POST("/", r.AddService)
+	services.GET("/:id", r.GetService)
+	services.PATCH("/:id", r.UpdateService)
+	services.DELETE("/:id", r.DeleteService)
+	services.POST("/:id/plan", r.AddPlan)
+
+	plans.GET("/:id", r.GetPlan)
+	plans.PATCH("/:id", r.UpdatePlan)
+	plans.DELETE("/:id", r.DeletePlan)
+}
+
+// AddService godoc
+// @Summary Добавить сервис в каталог
+// @Description Добавляет сервис с псевдонимами. Подписки без сервиса, название которых совпадает с названием
+// @Description или псевдонимом без учёта регистра, связываются с ним.
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param request body dto.ServiceCreateRequest true "Данные сервиса"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 409 {object} handlers.ErrorResponse "Название или псевдоним уже занято"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /service [post]
+func (h *CatalogHandler) AddService(c *gin.Context) {
+	var req dto.ServiceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	id, err := h.catalogService.CreateService(c.Request.Context(), &domain.ServiceCreate{
+		Name:       req.Name,
+		Aliases:    req.Aliases,
+		CategoryId: req.CategoryId,
+		Website:    req.Website,
+		LogoUrl:    req.LogoUrl,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"id": id,
+		},
+	)
+}
+
+// GetServices godoc
+// @Summary Получить сервисы каталога
+// @Description Возвращает сервисы каталога по ID вместе с тарифами
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param page query integer true "Номер страницы" minimum(0)
+// @Param limit query integer true "Количество записей на странице" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /service/ [get]
+func (h *CatalogHandler) GetServices(c *gin.Context) {
+	page, ok := c.GetQuery("page")
+	if !ok {
+		respondWithError(c, errNoPage)
+		return
+	}
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		respondWithError(c, errPageNotInteger)
+		return
+	}
+
+	limit, ok := c.GetQuery("limit")
+	if !ok {
+		respondWithError(c, errNoLimit)
+		return
+	}
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		respondWithError(c, errLimitNotInteger)
+		return
+	}
+	offset := (pageInt - 1) * limitInt
+
+	services, err := h.catalogService.GetServices(c.Request.Context(), offset, limitInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.Service{}
+	for _, s := range services {
+		res = append(res, toServiceDTO(s))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"page":     page,
+			"limit":    limit,
+			"services": res,
+		},
+	)
+}
+
+// GetService godoc
+// @Summary Получить сервис по ID
+// @Description Возвращает сервис каталога вместе с тарифами
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID сервиса" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Сервис не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /service/{id} [get]
+func (h *CatalogHandler) GetService(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	service, err := h.catalogService.GetService(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"service": toServiceDTO(service),
+		},
+	)
+}
+
+// UpdateService godoc
+// @Summary Обновить сервис
+// @Description Обновляет сервис каталога; подписки без сервиса с новым названием или псевдонимом связываются с ним
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID сервиса" minimum(0)
+// @Param request body dto.ServiceUpdateRequest true "Данные для обновления сервиса"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Сервис не найден"
+// @Failure 409 {object} handlers.ErrorResponse "Название или псевдоним уже занято"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /service/{id} [patch]
+func (h *CatalogHandler) UpdateService(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.ServiceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	service, err := h.catalogService.UpdateService(c.Request.Context(), &domain.ServiceUpdate{
+		Id:         idInt,
+		Name:       req.Name,
+		Aliases:    req.Aliases,
+		CategoryId: req.CategoryId,
+		Website:    req.Website,
+		LogoUrl:    req.LogoUrl,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"service": toServiceDTO(service),
+		},
+	)
+}
+
+// DeleteService godoc
+// @Summary Удалить сервис
+// @Description Удаляет сервис вместе с тарифами. Подписки сохраняются, но перестают быть связанными с каталогом.
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID сервиса" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Сервис не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /service/{id} [delete]
+func (h *CatalogHandler) DeleteService(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	service, err := h.catalogService.DeleteService(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"service": toServiceDTO(service),
+		},
+	)
+}
+
+// AddPlan godoc
+// @Summary Добавить тариф сервиса
+// @Description Добавляет тариф с ценой по умолчанию за месяц или год
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID сервиса" minimum(0)
+// @Param request body dto.PlanCreateRequest true "Данные тарифа"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Сервис не найден"
+// @Failure 409 {object} handlers.ErrorResponse "Тариф с таким названием уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /service/{id}/plan [post]
+func (h *CatalogHandler) AddPlan(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.PlanCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	plan := &domain.PlanCreate{
+		ServiceId:     idInt,
+		Name:          req.Name,
+		Price:         *req.Price,
+		BillingPeriod: domain.BillingMonth,
+	}
+	if req.BillingPeriod != nil {
+		plan.BillingPeriod = domain.BillingPeriod(*req.BillingPeriod)
+	}
+
+	planId, err := h.catalogService.CreatePlan(c.Request.Context(), plan)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"id": planId,
+		},
+	)
+}
+
+// GetPlan godoc
+// @Summary Получить тариф по ID
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID тарифа" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Тариф не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /plan/{id} [get]
+func (h *CatalogHandler) GetPlan(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	plan, err := h.catalogService.GetPlan(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"plan": toPlanDTO(plan),
+		},
+	)
+}
+
+// UpdatePlan godoc
+// @Summary Обновить тариф
+// @Description Обновляет тариф; цены уже созданных подписок не меняются
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID тарифа" minimum(0)
+// @Param request body dto.PlanUpdateRequest true "Данные для обновления тарифа"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Тариф не найден"
+// @Failure 409 {object} handlers.ErrorResponse "Тариф с таким названием уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /plan/{id} [patch]
+func (h *CatalogHandler) UpdatePlan(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.PlanUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	data := &domain.PlanUpdate{
+		Id:    idInt,
+		Name:  req.Name,
+		Price: req.Price,
+	}
+	if req.BillingPeriod != nil {
+		period := domain.BillingPeriod(*req.BillingPeriod)
+		data.BillingPeriod = &period
+	}
+
+	plan, err := h.catalogService.UpdatePlan(c.Request.Context(), data)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"plan": toPlanDTO(plan),
+		},
+	)
+}
+
+// DeletePlan godoc
+// @Summary Удалить тариф
+// @Description Удаляет тариф, подписки на нём сохраняют свою цену
+// @Tags catalog
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID тарифа" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Тариф не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /plan/{id} [delete]
+func (h *CatalogHandler) DeletePlan(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	plan, err := h.catalogService.DeletePlan(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"plan": toPlanDTO(plan),
+		},
+	)
+}
+
+func toServiceDTO(s *domain.Service) dto.Service {
+	res := dto.Service{
+		Id:         s.Id,
+		Name:       s.Name,
+		Aliases:    s.Aliases,
+		CategoryId: s.CategoryId,
+		Website:    s.Website,
+		LogoUrl:    s.LogoUrl,
+		Plans:      []dto.Plan{},
+	}
+	for _, p := range s.Plans {
+		res.Plans = append(res.Plans, toPlanDTO(&p))
+	}
+	return res
+}
+
+func toPlanDTO(p *domain.Plan) dto.Plan {
+	return dto.Plan{
+		Id:            p.Id,
+		ServiceId:     p.ServiceId,
+		Name:          p.Name,
+		Price:         p.Price,
+		BillingPeriod: string(p.BillingPeriod),
+	}
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type CategoryHandler struct {
+	categoryService ICategoryService
+	validate        *validator.Validate
+}
+
+type ICategoryService interface {
+	CreateCategory(ctx context.Context, data *domain.CategoryCreate) (int, error)
+	GetCategory(ctx context.Context, id int) (*domain.Category, error)
+	GetCategories(ctx context.Context, userId *uuid.UUID) ([]*domain.Category, error)
+	UpdateCategory(ctx context.Context, data *domain.CategoryUpdate) (*domain.Category, error)
+	DeleteCategory(ctx context.Context, id int) (*domain.Category, error)
+}
+
+func NewCategoryHandler(g *gin.RouterGroup, categoryService ICategoryService, validate *validator.Validate) {
+	r := &CategoryHandler{
+		categoryService: categoryService,
+		validate:        validate,
+	}
+
+	g.GET("/", r.GetCategories)
+	g.POST("/", r.AddCategory)
+	g.GET("/:id", r.GetCategory)
+	g.PATCH("/:id", r.UpdateCategory)
+	g.DELETE("/:id", r.DeleteCategory)
+}
+
+// AddCategory godoc
+// @Summary Создать категорию пользователя
+// @Description Создаёт категорию пользователя. Родительской может быть встроенная категория или категория того же пользователя.
+// @Tags category
+// @Accept json
+// @Produce json
+// @Param request body dto.CategoryCreateRequest true "Данные категории"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 409 {object} handlers.ErrorResponse "Категория с таким названием уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /category [post]
+func (h *CategoryHandler) AddCategory(c *gin.Context) {
+	var req dto.CategoryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	UUID, _ := uuid.Parse(req.UserId)
+
+	id, err := h.categoryService.CreateCategory(c.Request.Context(), &domain.CategoryCreate{
+		Name:     req.Name,
+		ParentId: req.ParentId,
+		UserId:   UUID,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"id": id,
+		},
+	)
+}
+
+// GetCategories godoc
+// @Summary Получить категории
+// @Description Возвращает встроенные категории и категории пользователя по ID, без user_id — категории всех пользователей
+// @Tags category
+// @Accept json
+// @Produce json
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Success 200 {object} map[string][]dto.Category "Категории"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /category/ [get]
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	var userId *uuid.UUID
+	if value, ok := c.GetQuery("user_id"); ok {
+		parseUUID, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(c, errIncorrectUUID)
+			return
+		}
+		userId = &parseUUID
+	}
+
+	categories, err := h.categoryService.GetCategories(c.Request.Context(), userId)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.Category{}
+	for _, category := range categories {
+		res = append(res, toCategoryDTO(category))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"categories": res,
+		},
+	)
+}
+
+// GetCategory godoc
+// @Summary Получить категорию
+// @Description Возвращает категорию по ID
+// @Tags category
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID категории" minimum(0)
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Категория не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /category/{id} [get]
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	category, err := h.categoryService.GetCategory(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"category": toCategoryDTO(category),
+		},
+	)
+}
+
+// UpdateCategory godoc
+// @Summary Обновить категорию пользователя
+// @Description Переименовывает категорию пользователя или переносит её в другую родительскую категорию.
+// @Description Встроенные категории изменять нельзя.
+// @Tags category
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID категории" minimum(0)
+// @Param request body dto.CategoryUpdateRequest true "Данные для обновления категории"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Встроенная категория"
+// @Failure 404 {object} handlers.ErrorResponse "Категория не найдена"
+// @Failure 409 {object} handlers.ErrorResponse "Категория с таким названием уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /category/{id} [patch]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.CategoryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(c.Request.Context(), &domain.CategoryUpdate{
+		Id:       idInt,
+		Name:     req.Name,
+		ParentId: req.ParentId,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"category": toCategoryDTO(category),
+		},
+	)
+}
+
+// DeleteCategory godoc
+// @Summary Удалить категорию пользователя
+// @Description Удаляет категорию пользователя вместе с подкатегориями. Подписки и сервисы сохраняются без категории.
+// @Description Встроенные категории удалять нельзя.
+// @Tags category
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID категории" minimum(0)
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Встроенная категория"
+// @Failure 404 {object} handlers.ErrorResponse "Категория не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /category/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	category, err := h.categoryService.DeleteCategory(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"category": toCategoryDTO(category),
+		},
+	)
+}
+
+func toCategoryDTO(c *domain.Category) dto.Category {
+	res := dto.Category{
+		Id:       c.Id,
+		Name:     c.Name,
+		ParentId: c.ParentId,
+	}
+	if c.UserId != nil {
+		userId := c.UserId.String()
+		res.UserId = &userId
+	}
+	return res
+}
@@ -0,0 +1,52 @@
+package dto
+
+import "time"
+
+// Budget месячный бюджет пользователя: общий, на категорию или на сервис
+type Budget struct {
+	Id     int    `json:"id" example:"1"`
+	UserId string `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	// Сумма в месяц
+	Amount int `json:"amount" example:"1000"`
+	// Бюджет на категорию вместе с её подкатегориями
+	CategoryId *int `json:"category_id" example:"1"`
+	ServiceId  *int `json:"service_id" example:"3"`
+}
+
+// BudgetCreateRequest запрос на создание бюджета. Без категории и сервиса бюджет общий.
+type BudgetCreateRequest struct {
+	UserId     string `json:"user_id" validate:"required,uuid4" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Amount     int    `json:"amount" validate:"required,gte=1" example:"1000"`
+	CategoryId *int   `json:"category_id" validate:"omitempty,gte=1,excluded_with=ServiceId" example:"1"`
+	ServiceId  *int   `json:"service_id" validate:"omitempty,gte=1" example:"3"`
+}
+
+// BudgetUpdateRequest запрос на изменение суммы бюджета
+type BudgetUpdateRequest struct {
+	Amount int `json:"amount" validate:"required,gte=1" example:"1500"`
+}
+
+// BudgetStatus расходы месяца по бюджету
+type BudgetStatus struct {
+	Budget Budget `json:"budget"`
+	// Первый день месяца
+	Month string `json:"month" example:"2026-10-01"`
+	// Расходы за месяц с помесячным пропорциональным расчётом
+	Spent   int `json:"spent" example:"850"`
+	Percent int `json:"percent" example:"85"`
+	// ok — меньше 80%, warning — от 80%, exceeded — от 100%
+	Level string `json:"level" example:"warning" enums:"ok,warning,exceeded"`
+}
+
+// BudgetAlert уведомление о достижении порога бюджета, отправляется один раз за месяц
+type BudgetAlert struct {
+	Id       int    `json:"id" example:"1"`
+	BudgetId int    `json:"budget_id" example:"1"`
+	Month    string `json:"month" example:"2026-10-01"`
+	// Порог в процентах: 80 или 100
+	Threshold int `json:"threshold" example:"80"`
+	// Расходы и сумма бюджета в момент уведомления
+	Spent     int       `json:"spent" example:"850"`
+	Amount    int       `json:"amount" example:"1000"`
+	AlertedAt time.Time `json:"alerted_at" example:"2026-10-19T10:00:00Z"`
+}
@@ -0,0 +1,62 @@
+package dto
+
+// Service сервис из каталога
+type Service struct {
+	Id   int    `json:"id" example:"1"`
+	Name string `json:"name" example:"Netflix"`
+	// Другие названия сервиса, по алфавиту
+	Aliases []string `json:"aliases" example:"NFLX"`
+	// Встроенная категория сервиса
+	CategoryId *int    `json:"category_id" example:"7"`
+	Website    *string `json:"website" example:"https://www.netflix.com"`
+	LogoUrl    *string `json:"logo_url" example:"https://www.netflix.com/favicon.ico"`
+	// Тарифы сервиса по ID
+	Plans []Plan `json:"plans"`
+}
+
+// Plan тариф сервиса с ценой по умолчанию
+type Plan struct {
+	Id        int    `json:"id" example:"2"`
+	ServiceId int    `json:"service_id" example:"1"`
+	Name      string `json:"name" example:"Premium"`
+	// Цена за платёжный период
+	Price         int    `json:"price" example:"999"`
+	BillingPeriod string `json:"billing_period" enums:"month,year" example:"month"`
+}
+
+// ServiceCreateRequest запрос на добавление сервиса в каталог
+type ServiceCreateRequest struct {
+	Name string `json:"name" validate:"required,lte=100" example:"Netflix"`
+	// Названия и псевдонимы сервисов не должны повторяться без учёта регистра
+	Aliases []string `json:"aliases" validate:"omitempty,lte=20,dive,required,lte=100" example:"NFLX"`
+	// Только встроенная категория, сервисы общие для всех пользователей
+	CategoryId *int    `json:"category_id" validate:"omitempty,gte=1" example:"7"`
+	Website    *string `json:"website" validate:"omitempty,url,lte=255" example:"https://www.netflix.com"`
+	LogoUrl    *string `json:"logo_url" validate:"omitempty,url,lte=255" example:"https://www.netflix.com/favicon.ico"`
+}
+
+// ServiceUpdateRequest запрос на обновление сервиса
+type ServiceUpdateRequest struct {
+	Name *string `json:"name" validate:"omitempty,gte=1,lte=100" example:"Netflix"`
+	// Заменяет все псевдонимы, пустой список удаляет их
+	Aliases []string `json:"aliases" validate:"omitempty,lte=20,dive,required,lte=100" example:"NFLX"`
+	// Только встроенная категория, сервисы общие для всех пользователей
+	CategoryId *int    `json:"category_id" validate:"omitempty,gte=1" example:"7"`
+	Website    *string `json:"website" validate:"omitempty,url,lte=255" example:"https://www.netflix.com"`
+	LogoUrl    *string `json:"logo_url" validate:"omitempty,url,lte=255" example:"https://www.netflix.com/favicon.ico"`
+}
+
+// PlanCreateRequest запрос на добавление тарифа
+type PlanCreateRequest struct {
+	Name  string `json:"name" validate:"required,lte=100" example:"Premium"`
+	Price *int   `json:"price" validate:"required,gte=0" example:"999"`
+	// По умолчанию — month
+	BillingPeriod *string `json:"billing_period" validate:"omitempty,oneof=month year" example:"month"`
+}
+
+// PlanUpdateRequest запрос на обновление тарифа
+type PlanUpdateRequest struct {
+	Name          *string `json:"name" validate:"omitempty,gte=1,lte=100" example:"Premium"`
+	Price         *int    `json:"price" validate:"omitempty,gte=0" example:"1099"`
+	BillingPeriod *string `json:"billing_period" validate:"omitempty,oneof=month year" example:"year"`
+}
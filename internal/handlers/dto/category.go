@@ -0,0 +1,34 @@
+package dto
+
+// Category категория подписок
+type Category struct {
+	Id   int    `json:"id" example:"11"`
+	Name string `json:"name" example:"Podcasts"`
+	// Родительская категория, null для категории верхнего уровня
+	ParentId *int `json:"parent_id" example:"8"`
+	// Владелец категории, null для встроенной категории
+	UserId *string `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+}
+
+// CategoryCreateRequest запрос на создание категории пользователя
+type CategoryCreateRequest struct {
+	Name string `json:"name" validate:"required,lte=100" example:"Podcasts"`
+	// Встроенная категория или категория того же пользователя
+	ParentId *int   `json:"parent_id" validate:"omitempty,gte=1" example:"8"`
+	UserId   string `json:"user_id" validate:"required,uuid4" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+}
+
+// CategoryUpdateRequest запрос на обновление категории пользователя
+type CategoryUpdateRequest struct {
+	Name     *string `json:"name" validate:"omitempty,gte=1,lte=100" example:"Audiobooks"`
+	ParentId *int    `json:"parent_id" validate:"omitempty,gte=1" example:"5"`
+}
+
+// CategoryCost стоимость подписок категории вместе с подкатегориями
+type CategoryCost struct {
+	// null для подписок без категории
+	CategoryId *int   `json:"category_id" example:"8"`
+	Name       string `json:"name" example:"Music"`
+	ParentId   *int   `json:"parent_id" example:"1"`
+	Price      int    `json:"price" example:"599"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// Insight возможная проблема с подписками пользователя
+type Insight struct {
+	// Не меняется, пока не меняются подписки
+	Id   string `json:"id" example:"duplicate-3-7"`
+	Kind string `json:"kind" example:"duplicate" enums:"duplicate,price_jump,long_open_ended"`
+	// Название сервиса самой ранней подписки
+	ServiceName string `json:"service_name" example:"Netflix"`
+	// Подписки в порядке начала
+	SubscriptionIds []int `json:"subscription_ids" example:"3,7"`
+	// Для duplicate: дни, когда активны обе подписки; без to — обе активны до сих пор
+	From *string `json:"from,omitempty" example:"2026-03-01"`
+	To   *string `json:"to,omitempty" example:"2026-03-31"`
+	// Для price_jump: цены предыдущей и следующей подписки и рост цены в процентах
+	OldPrice *int `json:"old_price,omitempty" example:"500"`
+	NewPrice *int `json:"new_price,omitempty" example:"700"`
+	Increase *int `json:"increase,omitempty" example:"40"`
+	// Для long_open_ended: полных месяцев без даты окончания
+	Months      *int       `json:"months,omitempty" example:"26"`
+	DismissedAt *time.Time `json:"dismissed_at,omitempty" example:"2026-10-19T10:00:00Z"`
+}
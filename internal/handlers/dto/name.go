@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// ServiceName написание названия сервиса в подписках
+type ServiceName struct {
+	Name          string `json:"name" example:"netflix"`
+	Subscriptions int    `json:"subscriptions" example:"3"`
+}
+
+// NameCluster группа написаний, вероятно обозначающих один сервис
+type NameCluster struct {
+	// Название сервиса из каталога или самое частое написание
+	Canonical string `json:"canonical" example:"Netflix"`
+	// Сервис каталога с предложенным названием
+	ServiceId *int `json:"service_id" example:"1"`
+	// Написания по убыванию числа подписок
+	Names         []ServiceName `json:"names"`
+	Subscriptions int           `json:"subscriptions" example:"5"`
+}
+
+// NameMergeRequest запрос на объединение названий сервиса
+type NameMergeRequest struct {
+	Canonical string `json:"canonical" validate:"required,lte=100" example:"Netflix"`
+	// Подписки с этими названиями (с точным совпадением) получают название canonical
+	Names []string `json:"names" validate:"required,gte=1,lte=100,dive,required,lte=100" example:"netflix,Netflx"`
+}
+
+// NameMerge запись журнала об объединении названий
+type NameMerge struct {
+	Id        int      `json:"id" example:"1"`
+	Canonical string   `json:"canonical" example:"Netflix"`
+	Names     []string `json:"names" example:"netflix,Netflx"`
+	// Количество переименованных подписок
+	Subscriptions int       `json:"subscriptions" example:"4"`
+	MergedAt      time.Time `json:"merged_at" example:"2026-10-18T18:00:00Z"`
+}
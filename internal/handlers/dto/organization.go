@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization организация (тенант): компания или семья, подписки организации видны только в ней
+type Organization struct {
+	Id        int       `json:"id" example:"1"`
+	Name      string    `json:"name" example:"Семья Ивановых"`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-19T10:00:00Z"`
+}
+
+// OrganizationCreateRequest запрос на создание организации
+type OrganizationCreateRequest struct {
+	Name string `json:"name" validate:"required,lte=100" example:"Семья Ивановых"`
+	// Первый участник организации
+	UserId *string `json:"user_id" validate:"omitempty,uuid4" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+}
+
+// OrganizationUpdateRequest запрос на переименование организации
+type OrganizationUpdateRequest struct {
+	Name string `json:"name" validate:"required,lte=100" example:"Ивановы"`
+}
+
+// OrganizationMember участник организации
+type OrganizationMember struct {
+	UserId   uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	JoinedAt time.Time `json:"joined_at" example:"2026-10-19T10:00:00Z"`
+}
+
+// MembersResponse участники организации
+type MembersResponse struct {
+	// По UUID пользователя
+	Members []OrganizationMember `json:"members"`
+}
+
+// OrganizationMemberRequest запрос на добавление участника организации
+type OrganizationMemberRequest struct {
+	UserId string `json:"user_id" validate:"required,uuid4" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+}
+
+// OrganizationCostResponse стоимость подписок организации за период с помесячным пропорциональным расчётом
+type OrganizationCostResponse struct {
+	OrganizationId int `json:"organization_id" example:"1"`
+	Total          int `json:"total" example:"1200"`
+	// Сколько платит каждый пользователь с учётом разделения стоимости, по UUID пользователя
+	Users []UserCost `json:"users"`
+}
+
+// UserCost доля пользователя в стоимости подписок организации
+type UserCost struct {
+	UserId uuid.UUID `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Cost   int       `json:"cost" example:"800"`
+}
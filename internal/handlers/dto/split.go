@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// Split разделение стоимости подписки, владелец платит то, что не платят участники
+type Split struct {
+	Rule string `json:"rule" enums:"equal,percentage,fixed" example:"percentage"`
+	// Участники по UUID пользователя
+	Members []Member `json:"members"`
+}
+
+// Member участник общей подписки
+type Member struct {
+	UserId uuid.UUID `json:"user_id" example:"6ba7b810-9dad-41d1-80b4-00c04fd430c8"`
+	// Процент стоимости или сумма в месяц, null при равном разделении
+	Share *int `json:"share" example:"40"`
+}
+
+// SplitRequest запрос на разделение стоимости подписки
+type SplitRequest struct {
+	// equal — поровну с владельцем, percentage — процент стоимости, fixed — фиксированная сумма из цены в месяц
+	Rule string `json:"rule" validate:"required,oneof=equal percentage fixed" example:"percentage"`
+	// Заменяет участников, пустой список отменяет разделение
+	Members []MemberRequest `json:"members" validate:"lte=50,unique=UserId,dive"`
+}
+
+// MemberRequest участник общей подписки
+type MemberRequest struct {
+	UserId string `json:"user_id" validate:"required,uuid4" example:"6ba7b810-9dad-41d1-80b4-00c04fd430c8"`
+	// Для percentage — от 1 до 100 в сумме по участникам, для fixed — не больше цены в сумме, для equal не указывается
+	Share *int `json:"share" validate:"omitempty,gte=0" example:"40"`
+}
+
+// DebtsResponse долги между пользователями за общие подписки за период
+type DebtsResponse struct {
+	// По должнику и получателю; встречные долги взаимозачтены
+	Debts []Debt `json:"debts"`
+}
+
+// Debt сумма, которую один пользователь должен другому
+type Debt struct {
+	From   uuid.UUID `json:"from" example:"6ba7b810-9dad-41d1-80b4-00c04fd430c8"`
+	To     uuid.UUID `json:"to" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Amount int       `json:"amount" example:"360"`
+}
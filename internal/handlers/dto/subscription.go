@@ -10,23 +10,163 @@ type Subscription struct {
 	ServiceName string    `json:"service_name" example:"Netflix"`
 	Price       int       `json:"price" example:"599"`
 	UserId      uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	StartDate   string    `json:"start_date" example:"01-2026"`
-	EndDate     *string   `json:"end_date" example:"05-2026"`
+	StartDate   string    `json:"start_date" example:"2026-01-17"`
+	EndDate     *string   `json:"end_date" example:"2026-05-31"`
+	// День месяца, в который списывается оплата; в коротких месяцах — последний день
+	BillingDay int `json:"billing_day" example:"17"`
+	// Ближайшее списание, null если подписка закончилась
+	NextBillingDate *string `json:"next_billing_date" example:"2026-02-17"`
+	// Число первых платёжных периодов по пробной цене
+	TrialMonths int `json:"trial_months" example:"1"`
+	TrialPrice  int `json:"trial_price" example:"0"`
+	// Последний день пробного периода, null без пробного периода
+	TrialEndsAt *string `json:"trial_ends_at" example:"2026-02-16"`
+	// Паузы по дате начала, дни пауз не оплачиваются
+	Pauses []Pause `json:"pauses,omitempty"`
+	// Статус на сегодня (для будущей подписки — на дату начала)
+	Status string `json:"status" enums:"active,trialing,paused,cancelled,expired" example:"active"`
+	// День отмены, null если подписка не отменена; подписка действует до end_date
+	CancelledAt  *string `json:"cancelled_at" example:"2026-04-10"`
+	CancelReason *string `json:"cancel_reason" example:"Слишком дорого"`
+	// Сервис и тариф из каталога, null если подписка не связана с каталогом
+	ServiceId *int `json:"service_id" example:"1"`
+	PlanId    *int `json:"plan_id" example:"2"`
+	// Категория подписки, null если подписка относится к категории своего сервиса
+	CategoryId *int `json:"category_id" example:"8"`
+	// Метки по названию без учёта регистра
+	Tags  []string `json:"tags" example:"work,family"`
+	Notes *string  `json:"notes" example:"Оплачивается с рабочей карты"`
+	// Разделение стоимости с другими пользователями, null если подписка не общая
+	Split *Split `json:"split"`
+	// Организация, в которой создана подписка
+	OrganizationId int `json:"organization_id" example:"1"`
+}
+
+// Pause пауза подписки, включая начальный и конечный дни
+type Pause struct {
+	Id        int    `json:"id" example:"1"`
+	StartDate string `json:"start_date" example:"2026-03-01"`
+	// null, пока подписка не возобновлена
+	EndDate *string `json:"end_date" example:"2026-04-30"`
+}
+
+// PauseRequest запрос на приостановку подписки
+type PauseRequest struct {
+	// По умолчанию — сегодня
+	StartDate *string `json:"start_date" validate:"omitempty,date" example:"2026-03-01"`
+	// Последний день паузы, без него пауза длится до возобновления
+	EndDate *string `json:"end_date" validate:"omitempty,date" example:"2026-04-30"`
+}
+
+// PriceChange новая цена подписки с даты изменения
+type PriceChange struct {
+	Id            int    `json:"id" example:"1"`
+	EffectiveDate string `json:"effective_date" example:"2026-12-01"`
+	Price         int    `json:"price" example:"499"`
+}
+
+// PriceChangeRequest запрос на изменение цены подписки
+type PriceChangeRequest struct {
+	// Первый день новой цены, не раньше сегодняшнего
+	EffectiveDate string `json:"effective_date" validate:"required,date" example:"2026-12-01"`
+	Price         *int   `json:"price" validate:"required,gte=0" example:"499"`
+}
+
+// ResumeRequest запрос на возобновление подписки
+type ResumeRequest struct {
+	// Первый оплачиваемый день после паузы, по умолчанию — сегодня
+	Date *string `json:"date" validate:"omitempty,date" example:"2026-05-01"`
+}
+
+// CancelRequest запрос на отмену подписки
+type CancelRequest struct {
+	// Отменить в конце текущего платёжного периода
+	AtPeriodEnd bool `json:"at_period_end" example:"true"`
+	// Последний день подписки, по умолчанию — сегодня; нельзя указывать вместе с at_period_end
+	Date   *string `json:"date" validate:"omitempty,date" example:"2026-04-30"`
+	Reason *string `json:"reason" validate:"omitempty,lte=500" example:"Слишком дорого"`
 }
 
 // SubscriptionCreateRequest запрос на создание подписки
 type SubscriptionCreateRequest struct {
-	ServiceName string  `json:"service_name" validate:"required,lte=100" example:"Netflix"`
-	Price       int     `json:"price" validate:"required,gte=0" example:"599"`
-	UserId      string  `json:"user_id" validate:"required,uuid4" example:"550e8400-e29b-41d4-a716-446655440000"`
-	StartDate   string  `json:"start_date" validate:"required,date" example:"01-2026"`
-	EndDate     *string `json:"end_date" validate:"omitempty,date" example:"05-2026"`
+	// По умолчанию — название сервиса из каталога
+	ServiceName string `json:"service_name" validate:"required_without_all=ServiceId PlanId,lte=100" example:"Netflix"`
+	// По умолчанию — цена тарифа в месяц (годовая цена делится на 12)
+	Price     *int    `json:"price" validate:"required_without=PlanId,omitempty,gte=0" example:"599"`
+	UserId    string  `json:"user_id" validate:"required,uuid4" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StartDate string  `json:"start_date" validate:"required,date" example:"2026-01-17"`
+	EndDate   *string `json:"end_date" validate:"omitempty,date" example:"05-2026"`
+	// По умолчанию — день даты начала
+	BillingDay *int `json:"billing_day" validate:"omitempty,gte=1,lte=31" example:"17"`
+	// Пробный или промо-период: число первых платёжных периодов и цена за каждый из них (0 — бесплатно)
+	TrialMonths *int `json:"trial_months" validate:"omitempty,gte=0,lte=120" example:"1"`
+	TrialPrice  *int `json:"trial_price" validate:"omitempty,gte=0" example:"0"`
+	// Сервис из каталога; без него подписка связывается с сервисом, у которого такое название или псевдоним
+	ServiceId *int `json:"service_id" validate:"omitempty,gte=1" example:"1"`
+	// Тариф из каталога, должен принадлежать сервису
+	PlanId *int `json:"plan_id" validate:"omitempty,gte=1" example:"2"`
+	// Встроенная категория или категория пользователя, по умолчанию — категория сервиса
+	CategoryId *int `json:"category_id" validate:"omitempty,gte=1" example:"8"`
+	// Метки пользователя, недостающие создаются
+	Tags  []string `json:"tags" validate:"omitempty,lte=20,dive,required,lte=50,tag_name" example:"work,family"`
+	Notes *string  `json:"notes" validate:"omitempty,lte=2000" example:"Оплачивается с рабочей карты"`
 }
 
 // SubscriptionUpdateRequest запрос на обновление подписки
 type SubscriptionUpdateRequest struct {
 	ServiceName *string `json:"service_name" validate:"omitempty,lte=100" example:"Netflix Premium"`
 	Price       *int    `json:"price" validate:"omitempty,gte=0" example:"699"`
-	StartDate   *string `json:"start_date" validate:"omitempty,date" example:"01-2026"`
+	StartDate   *string `json:"start_date" validate:"omitempty,date" example:"2026-01-17"`
 	EndDate     *string `json:"end_date" validate:"omitempty,date" example:"05-2026"`
+	BillingDay  *int    `json:"billing_day" validate:"omitempty,gte=1,lte=31" example:"17"`
+	TrialMonths *int    `json:"trial_months" validate:"omitempty,gte=0,lte=120" example:"1"`
+	TrialPrice  *int    `json:"trial_price" validate:"omitempty,gte=0" example:"299"`
+	// Тариф должен принадлежать сервису; с новым тарифом сервис берётся из него
+	ServiceId  *int `json:"service_id" validate:"omitempty,gte=1" example:"1"`
+	PlanId     *int `json:"plan_id" validate:"omitempty,gte=1" example:"2"`
+	CategoryId *int `json:"category_id" validate:"omitempty,gte=1" example:"8"`
+	// Заменяет метки подписки, пустой список удаляет их
+	Tags  []string `json:"tags" validate:"omitempty,lte=20,dive,required,lte=50,tag_name" example:"work"`
+	Notes *string  `json:"notes" validate:"omitempty,lte=2000" example:"Оплачивается с личной карты"`
+}
+
+// PriceResponse стоимость подписок за период
+type PriceResponse struct {
+	Price         int         `json:"price" example:"1797"`
+	Subscriptions []PriceItem `json:"subscriptions,omitempty"`
+	// Стоимость по категориям (при by_category=true)
+	Categories []CategoryCost `json:"categories,omitempty"`
+}
+
+// ForecastResponse прогноз расходов пользователя по месяцам
+type ForecastResponse struct {
+	// Сумма за все месяцы прогноза
+	Price  int             `json:"price" example:"7188"`
+	Months []ForecastMonth `json:"months"`
+}
+
+// ForecastMonth прогноз расходов за месяц
+type ForecastMonth struct {
+	// Первый день месяца
+	Month string `json:"month" example:"2026-11-01"`
+	Price int    `json:"price" example:"599"`
+	// Подписки со списаниями в этом месяце
+	Subscriptions []PriceItem `json:"subscriptions"`
+}
+
+// PriceItem расчёт стоимости одной подписки за период (при explain=true)
+type PriceItem struct {
+	SubscriptionId int    `json:"subscription_id" example:"1"`
+	ServiceName    string `json:"service_name" example:"Netflix"`
+	Price          int    `json:"price" example:"599"`
+	From           string `json:"from,omitempty" example:"2026-01-01"`
+	To             string `json:"to,omitempty" example:"2026-03-31"`
+	Months         int    `json:"months" example:"3"`
+	Days           int    `json:"days,omitempty" example:"90"`
+	TrialMonths    int    `json:"trial_months,omitempty" example:"1"`
+	TrialPrice     *int   `json:"trial_price,omitempty" example:"0"`
+	Cost           int    `json:"cost" example:"1797"`
+	// Часть стоимости, которую платит пользователь запроса; без пользователя — вся стоимость
+	Share       int    `json:"share" example:"1797"`
+	Explanation string `json:"explanation" example:"599 × 3 months (2026-01-01 – 2026-03-31) = 1797"`
 }
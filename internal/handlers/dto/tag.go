@@ -0,0 +1,22 @@
+package dto
+
+// Tag метка подписок пользователя
+type Tag struct {
+	Id     int    `json:"id" example:"3"`
+	UserId string `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Name   string `json:"name" example:"work"`
+	// Число подписок с меткой
+	Subscriptions int `json:"subscriptions" example:"2"`
+}
+
+// TagCreateRequest запрос на создание метки пользователя
+type TagCreateRequest struct {
+	// Уникально для пользователя без учёта регистра, не начинается с «!»
+	Name   string `json:"name" validate:"required,lte=50,tag_name" example:"work"`
+	UserId string `json:"user_id" validate:"required,uuid4" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+}
+
+// TagUpdateRequest запрос на переименование метки
+type TagUpdateRequest struct {
+	Name string `json:"name" validate:"required,lte=50,tag_name" example:"business"`
+}
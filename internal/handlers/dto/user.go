@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User зарегистрированный пользователь, владелец подписок
+type User struct {
+	Id          uuid.UUID `json:"id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	DisplayName string    `json:"display_name" example:"Иван Иванов"`
+	Email       *string   `json:"email" example:"ivan@example.com"`
+	// Языковой тег BCP 47
+	Locale string `json:"locale" example:"ru-RU"`
+	// Код валюты ISO 4217
+	Currency string `json:"currency" example:"RUB"`
+	// Часовой пояс IANA
+	Timezone  string    `json:"timezone" example:"Europe/Moscow"`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-19T10:00:00Z"`
+	// Время удаления персональных данных, у обезличенного пользователя имя пустое, а email не задан
+	AnonymizedAt *time.Time `json:"anonymized_at" example:"2026-10-20T10:00:00Z"`
+}
+
+// UserCreateRequest запрос на регистрацию пользователя
+type UserCreateRequest struct {
+	// Если не задан, генерируется новый
+	Id          *string `json:"id" validate:"omitempty,uuid4" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	DisplayName string  `json:"display_name" validate:"required,lte=100" example:"Иван Иванов"`
+	Email       *string `json:"email" validate:"omitempty,lte=254,email" example:"ivan@example.com"`
+	// По умолчанию en
+	Locale *string `json:"locale" validate:"omitempty,bcp47_language_tag" example:"ru-RU"`
+	// По умолчанию RUB
+	Currency *string `json:"currency" validate:"omitempty,iso4217" example:"RUB"`
+	// По умолчанию UTC
+	Timezone *string `json:"timezone" validate:"omitempty,timezone" example:"Europe/Moscow"`
+}
+
+// UserUpdateRequest запрос на изменение пользователя, незаданные поля не меняются
+type UserUpdateRequest struct {
+	DisplayName *string `json:"display_name" validate:"omitempty,gte=1,lte=100" example:"Иван Иванов"`
+	// null или пустая строка удаляет email
+	Email    *string `json:"email" validate:"omitempty,lte=254,email" example:"ivan@example.com"`
+	Locale   *string `json:"locale" validate:"omitempty,bcp47_language_tag" example:"ru-RU"`
+	Currency *string `json:"currency" validate:"omitempty,iso4217" example:"RUB"`
+	Timezone *string `json:"timezone" validate:"omitempty,timezone" example:"Europe/Moscow"`
+	// Email задан как null или пустая строка
+	ClearEmail bool `json:"-" swaggerignore:"true"`
+}
+
+// UnmarshalJSON tells an explicit null or empty email from a missing one
+func (r *UserUpdateRequest) UnmarshalJSON(data []byte) error {
+	type plain UserUpdateRequest
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	email, ok := fields["email"]
+	if ok && bytes.Equal(bytes.TrimSpace(email), []byte("null")) {
+		r.ClearEmail = true
+	}
+	if r.Email != nil && *r.Email == "" {
+		r.Email, r.ClearEmail = nil, true
+	}
+	return nil
+}
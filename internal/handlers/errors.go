@@ -1,31 +1,318 @@
 package handlers
 
-import "github.com/gin-gonic/gin"
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/middleware"
+	"github.com/Estriper0/subscription_service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/language"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Stable problem type URIs (RFC 7807)
+const (
+	ProblemTypeValidation    = "urn:subscription-service:problem:validation-error"
+	ProblemTypeMalformedBody = "urn:subscription-service:problem:malformed-body"
+	ProblemTypeBadRequest    = "urn:subscription-service:problem:bad-request"
+	ProblemTypeIncorrectTime = "urn:subscription-service:problem:incorrect-time"
+	ProblemTypeNotFound      = "urn:subscription-service:problem:not-found"
+	ProblemTypeConflict      = "urn:subscription-service:problem:conflict"
+	ProblemTypeForbidden     = "urn:subscription-service:problem:forbidden"
+	ProblemTypeInternal      = "urn:subscription-service:problem:internal-error"
+)
 
 const (
-	ErrStatusNotFound   = "NOT_FOUND"
-	ErrStatusInternal   = "INTERNAL"
-	ErrStatusBadRequest = "BAD_REQUEST"
+	contentLanguageHeader = "Content-Language"
+	acceptLanguageHeader  = "Accept-Language"
 )
 
-// ErrorResponse ответ ошибка
+// ErrorResponse ответ ошибка в формате application/problem+json (RFC 7807)
 type ErrorResponse struct {
-	Err Error
+	Type      string       `json:"type" example:"urn:subscription-service:problem:validation-error"`
+	Title     string       `json:"title" example:"Validation failed"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"The request contains invalid fields"`
+	Instance  string       `json:"instance,omitempty" example:"/subscription/"`
+	RequestId string       `json:"request_id,omitempty" example:"3f1c8b7e-2a4d-4e9b-9c61-0d7a5b2e8f10"`
+	Errors    []FieldError `json:"errors,omitempty"`
 }
 
-type Error struct {
-	Code    string
-	Message string
+// FieldError ошибка валидации конкретного поля запроса
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Code    string `json:"code" example:"gte"`
+	Message string `json:"message" example:"must be greater than or equal to 0"`
 }
 
-func respondWithError(c *gin.Context, code int, errStatus string, err error) {
-	c.JSON(
-		code,
-		gin.H{
-			"err": gin.H{
-				"code":    errStatus,
-				"message": err.Error(),
-			},
-		},
+// requestError is a client error whose text is taken from the message catalog
+type requestError string
+
+func (e requestError) Error() string {
+	return translate(language.English, string(e))
+}
+
+var (
+	errNoPage          = requestError(msgNoPage)
+	errPageNotInteger  = requestError(msgPageNotInteger)
+	errNoLimit         = requestError(msgNoLimit)
+	errLimitNotInteger = requestError(msgLimitNotInteger)
+	errIncorrectUUID   = requestError(msgIncorrectUUID)
+	errIncorrectId     = requestError(msgIncorrectId)
+	errNoStartDate     = requestError(msgNoStartDate)
+	errNoEndDate       = requestError(msgNoEndDate)
+
+	errIncorrectStartDate = requestError(msgIncorrectStartDate)
+	errIncorrectEndDate   = requestError(msgIncorrectEndDate)
+	errIncorrectDays      = requestError(msgIncorrectDays)
+	errIncorrectStatus    = requestError(msgIncorrectStatus)
+	errCancelDate         = requestError(msgCancelDate)
+	errIncorrectServiceId = requestError(msgIncorrectServiceId)
+	errIncorrectThreshold = requestError(msgIncorrectThreshold)
+	errIncorrectCategory  = requestError(msgIncorrectCategory)
+	errIncorrectTag       = requestError(msgIncorrectTag)
+	errIncorrectMonth     = requestError(msgIncorrectMonth)
+	errIncorrectMonths    = requestError(msgIncorrectMonths)
+
+	errIncorrectProration = requestError(msgIncorrectProration)
+	errExplainNotBoolean  = requestError(msgExplainNotBoolean)
+
+	errByCategoryNotBoolean = requestError(msgByCategoryNotBoolean)
+	errDismissedNotBoolean  = requestError(msgDismissedNotBoolean)
+)
+
+// respondWithError converts err into a problem document and writes it.
+// Errors unknown to the API are reported as internal without exposing their text.
+func respondWithError(c *gin.Context, err error) {
+	lang := requestLanguage(c)
+	problem := ErrorResponse{
+		Instance:  c.Request.URL.Path,
+		RequestId: middleware.GetRequestId(c.Request.Context()),
+	}
+
+	var (
+		validationErrs validator.ValidationErrors
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		reqErr         requestError
 	)
+	switch {
+	case errors.As(err, &validationErrs):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeValidation
+		problem.Title = translate(lang, msgTitleValidation)
+		problem.Detail = translate(lang, msgValidationDetail)
+		for _, fe := range validationErrs {
+			problem.Errors = append(problem.Errors, FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: translateFieldError(lang, fe),
+			})
+		}
+	case errors.As(err, &typeErr):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeValidation
+		problem.Title = translate(lang, msgTitleValidation)
+		problem.Detail = translate(lang, msgValidationDetail)
+		problem.Errors = []FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: translate(lang, msgFieldType, typeErr.Type.String()),
+		}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeMalformedBody
+		problem.Title = translate(lang, msgTitleMalformedBody)
+		problem.Detail = translate(lang, msgMalformedBody)
+	case errors.As(err, &reqErr):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, string(reqErr))
+	case errors.Is(err, service.ErrIncorrectTime):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeIncorrectTime
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgIncorrectTime)
+	case errors.Is(err, service.ErrPauseOutsidePeriod):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeIncorrectTime
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgPauseOutsidePeriod)
+	case errors.Is(err, service.ErrPauseOverlap):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgPauseOverlap)
+	case errors.Is(err, service.ErrNotPaused):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgNotPaused)
+	case errors.Is(err, service.ErrAlreadyEnded):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgAlreadyEnded)
+	case errors.Is(err, service.ErrPriceChangeOutsidePeriod):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeIncorrectTime
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgPriceChangeOutsidePeriod)
+	case errors.Is(err, service.ErrPriceChangeExists):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgPriceChangeExists)
+	case errors.Is(err, service.ErrAlreadyExists):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgAlreadyExists)
+	case errors.Is(err, service.ErrUnknownService):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgUnknownService)
+	case errors.Is(err, service.ErrUnknownPlan):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgUnknownPlan)
+	case errors.Is(err, service.ErrPlanMismatch):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgPlanMismatch)
+	case errors.Is(err, service.ErrUnknownCategory):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgUnknownCategory)
+	case errors.Is(err, service.ErrCategoryCycle):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgCategoryCycle)
+	case errors.Is(err, service.ErrBuiltInCategory):
+		problem.Status = http.StatusForbidden
+		problem.Type = ProblemTypeForbidden
+		problem.Title = translate(lang, msgTitleForbidden)
+		problem.Detail = translate(lang, msgBuiltInCategory)
+	case errors.Is(err, service.ErrBudgetExists):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgBudgetExists)
+	case errors.Is(err, service.ErrIncorrectSplit):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgIncorrectSplit)
+	case errors.Is(err, middleware.ErrNoOrganization):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgNoOrganization)
+	case errors.Is(err, middleware.ErrIncorrectOrganization):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgIncorrectOrganization)
+	case errors.Is(err, middleware.ErrOtherOrganization):
+		problem.Status = http.StatusForbidden
+		problem.Type = ProblemTypeForbidden
+		problem.Title = translate(lang, msgTitleForbidden)
+		problem.Detail = translate(lang, msgOtherOrganization)
+	case errors.Is(err, service.ErrNotMember):
+		problem.Status = http.StatusForbidden
+		problem.Type = ProblemTypeForbidden
+		problem.Title = translate(lang, msgTitleForbidden)
+		problem.Detail = translate(lang, msgNotMember)
+	case errors.Is(err, service.ErrOrganizationNotEmpty):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgOrganizationNotEmpty)
+	case errors.Is(err, service.ErrUnknownUser):
+		problem.Status = http.StatusBadRequest
+		problem.Type = ProblemTypeBadRequest
+		problem.Title = translate(lang, msgTitleBadRequest)
+		problem.Detail = translate(lang, msgUnknownUser)
+	case errors.Is(err, service.ErrUserExists):
+		problem.Status = http.StatusConflict
+		problem.Type = ProblemTypeConflict
+		problem.Title = translate(lang, msgTitleConflict)
+		problem.Detail = translate(lang, msgUserExists)
+	case errors.Is(err, service.ErrNotFound):
+		problem.Status = http.StatusNotFound
+		problem.Type = ProblemTypeNotFound
+		problem.Title = translate(lang, msgTitleNotFound)
+		problem.Detail = translate(lang, msgNotFound)
+	default:
+		problem.Status = http.StatusInternalServerError
+		problem.Type = ProblemTypeInternal
+		problem.Title = translate(lang, msgTitleInternal)
+		problem.Detail = translate(lang, msgInternal)
+	}
+
+	c.Header("Content-Type", ProblemContentType)
+	c.Header(contentLanguageHeader, lang.String())
+	c.JSON(problem.Status, problem)
+}
+
+// InternalError responds with an internal error problem, e.g. after a recovered panic
+func InternalError(c *gin.Context) {
+	respondWithError(c, service.ErrInternal)
+}
+
+// TenantError responds with the error of the X-Organization-Id header
+func TenantError(c *gin.Context, err error) {
+	respondWithError(c, err)
+}
+
+func translateFieldError(lang language.Tag, fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required", "required_without", "required_without_all":
+		return translate(lang, msgFieldRequired)
+	case "lte":
+		if isString {
+			return translate(lang, msgFieldMaxLength, fe.Param())
+		}
+		return translate(lang, msgFieldLte, fe.Param())
+	case "gte":
+		if isString {
+			return translate(lang, msgFieldMinLength, fe.Param())
+		}
+		return translate(lang, msgFieldGte, fe.Param())
+	case "uuid4":
+		return translate(lang, msgFieldUUID)
+	case "date":
+		return translate(lang, msgFieldDate)
+	case "tag_name":
+		return translate(lang, msgFieldTagName)
+	case "unique":
+		return translate(lang, msgFieldUnique)
+	case "oneof":
+		return translate(lang, msgFieldOneOf, strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "url":
+		return translate(lang, msgFieldURL)
+	case "email":
+		return translate(lang, msgFieldEmail)
+	case "bcp47_language_tag":
+		return translate(lang, msgFieldLocale)
+	case "iso4217":
+		return translate(lang, msgFieldCurrency)
+	case "timezone":
+		return translate(lang, msgFieldTimezone)
+	}
+	return translate(lang, msgFieldInvalid)
 }
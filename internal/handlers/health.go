@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Estriper0/subscription_service/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	health IHealth
+}
+
+type IHealth interface {
+	Ready(ctx context.Context) health.Report
+}
+
+func NewHealthHandler(r gin.IRoutes, health IHealth) {
+	h := &HealthHandler{
+		health: health,
+	}
+
+	r.GET("/healthz", h.Live)
+	r.GET("/readyz", h.Ready)
+}
+
+// Live godoc
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс запущен и обрабатывает запросы
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "Процесс работает"
+// @Router /healthz [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(
+		http.StatusOK,
+		health.Report{
+			Status: health.StatusOk,
+		},
+	)
+}
+
+// Ready godoc
+// @Summary Проверка готовности
+// @Description Проверяет доступность базы данных и версию миграций. Во время остановки приложения отвечает 503
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "Приложение готово принимать запросы"
+// @Failure 503 {object} health.Report "Приложение не готово"
+// @Router /readyz [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.health.Ready(c.Request.Context())
+
+	code := http.StatusOK
+	if report.Status != health.StatusOk {
+		code = http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, report)
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Message identifiers of the localized error texts
+const (
+	msgTitleValidation    = "title_validation"
+	msgTitleMalformedBody = "title_malformed_body"
+	msgTitleBadRequest    = "title_bad_request"
+	msgTitleNotFound      = "title_not_found"
+	msgTitleConflict      = "title_conflict"
+	msgTitleForbidden     = "title_forbidden"
+	msgTitleInternal      = "title_internal"
+
+	msgValidationDetail = "validation_detail"
+	msgMalformedBody    = "malformed_body"
+	msgIncorrectTime    = "incorrect_time"
+	msgNotFound         = "not_found"
+	msgInternal         = "internal"
+
+	msgPauseOutsidePeriod       = "pause_outside_period"
+	msgPauseOverlap             = "pause_overlap"
+	msgNotPaused                = "not_paused"
+	msgAlreadyEnded             = "already_ended"
+	msgPriceChangeOutsidePeriod = "price_change_outside_period"
+	msgPriceChangeExists        = "price_change_exists"
+	msgAlreadyExists            = "already_exists"
+	msgUnknownService           = "unknown_service"
+	msgUnknownPlan              = "unknown_plan"
+	msgPlanMismatch             = "plan_mismatch"
+	msgUnknownCategory          = "unknown_category"
+	msgBuiltInCategory          = "builtin_category"
+	msgCategoryCycle            = "category_cycle"
+	msgBudgetExists             = "budget_exists"
+	msgIncorrectSplit           = "incorrect_split"
+
+	msgNoOrganization        = "no_organization"
+	msgIncorrectOrganization = "incorrect_organization"
+	msgNotMember             = "not_member"
+	msgOtherOrganization     = "other_organization"
+	msgOrganizationNotEmpty  = "organization_not_empty"
+
+	msgUnknownUser = "unknown_user"
+	msgUserExists  = "user_exists"
+
+	msgNoPage          = "no_page"
+	msgPageNotInteger  = "page_not_integer"
+	msgNoLimit         = "no_limit"
+	msgLimitNotInteger = "limit_not_integer"
+	msgIncorrectUUID   = "incorrect_uuid"
+	msgIncorrectId     = "incorrect_id"
+	msgNoStartDate     = "no_start_date"
+	msgNoEndDate       = "no_end_date"
+
+	msgIncorrectStartDate = "incorrect_start_date"
+	msgIncorrectEndDate   = "incorrect_end_date"
+	msgIncorrectDays      = "incorrect_days"
+	msgIncorrectStatus    = "incorrect_status"
+	msgCancelDate         = "cancel_date"
+	msgIncorrectServiceId = "incorrect_service_id"
+	msgIncorrectThreshold = "incorrect_threshold"
+	msgIncorrectCategory  = "incorrect_category"
+	msgIncorrectTag       = "incorrect_tag"
+	msgIncorrectMonth     = "incorrect_month"
+	msgIncorrectMonths    = "incorrect_months"
+
+	msgIncorrectProration = "incorrect_proration"
+	msgExplainNotBoolean  = "explain_not_boolean"
+
+	msgByCategoryNotBoolean = "by_category_not_boolean"
+	msgDismissedNotBoolean  = "dismissed_not_boolean"
+
+	msgExplainMonthly = "explain_monthly"
+	msgExplainDaily   = "explain_daily"
+
+	msgExplainMonthlyTrial = "explain_monthly_trial"
+	msgExplainDailyTrial   = "explain_daily_trial"
+
+	msgExplainShare       = "explain_share"
+	msgExplainPriceChange = "explain_price_change"
+
+	msgFieldRequired  = "field_required"
+	msgFieldMaxLength = "field_max_length"
+	msgFieldMinLength = "field_min_length"
+	msgFieldLte       = "field_lte"
+	msgFieldGte       = "field_gte"
+	msgFieldUUID      = "field_uuid"
+	msgFieldDate      = "field_date"
+	msgFieldTagName   = "field_tag_name"
+	msgFieldUnique    = "field_unique"
+	msgFieldType      = "field_type"
+	msgFieldOneOf     = "field_one_of"
+	msgFieldURL       = "field_url"
+	msgFieldEmail     = "field_email"
+	msgFieldLocale    = "field_locale"
+	msgFieldCurrency  = "field_currency"
+	msgFieldTimezone  = "field_timezone"
+	msgFieldInvalid   = "field_invalid"
+)
+
+// The first supported language is used when Accept-Language matches nothing
+var supportedLanguages = []language.Tag{
+	language.English,
+	language.Russian,
+}
+
+var languageMatcher = language.NewMatcher(supportedLanguages)
+
+var messages = map[language.Tag]map[string]string{
+	language.English: {
+		msgTitleValidation:    "Validation failed",
+		msgTitleMalformedBody: "Malformed request body",
+		msgTitleBadRequest:    "Bad request",
+		msgTitleNotFound:      "Not found",
+		msgTitleConflict:      "Conflict",
+		msgTitleForbidden:     "Forbidden",
+		msgTitleInternal:      "Internal server error",
+
+		msgValidationDetail: "The request contains invalid fields",
+		msgMalformedBody:    "The request body is not a valid JSON document",
+		msgIncorrectTime:    "The end date must not be earlier than the start date",
+		msgNotFound:         "The requested resource was not found",
+		msgInternal:         "An unexpected error occurred, please try again later",
+
+		msgPauseOutsidePeriod:       "The pause must be within the subscription period",
+		msgPauseOverlap:             "The pause overlaps another pause of the subscription",
+		msgNotPaused:                "The subscription is not paused on the resume date",
+		msgAlreadyEnded:             "The subscription has already ended",
+		msgPriceChangeOutsidePeriod: "The price change must be within the subscription period and not in the past",
+		msgPriceChangeExists:        "The subscription already has a price change on this date",
+		msgAlreadyExists:            "The name or alias is already taken by another service, plan, category or tag",
+		msgUnknownService:           "The service is not in the catalog",
+		msgUnknownPlan:              "The plan is not in the catalog",
+		msgPlanMismatch:             "The plan belongs to another service",
+		msgUnknownCategory:          "The category is neither built-in nor the user's",
+		msgBuiltInCategory:          "Built-in categories can't be changed",
+		msgCategoryCycle:            "A category can't be moved under its own subcategory",
+		msgBudgetExists:             "The user already has a budget for this category or service",
+		msgIncorrectSplit:           "The members must be other users, without shares for the equal split, with percentages adding up to at most 100 or amounts adding up to at most the price",
+
+		msgNoOrganization:        "The X-Organization-Id header is required",
+		msgIncorrectOrganization: "The X-Organization-Id header must be a positive integer",
+		msgNotMember:             "The user is not a member of the organization",
+		msgOtherOrganization:     "The organization differs from the X-Organization-Id header",
+		msgOrganizationNotEmpty:  "The organization still has subscriptions",
+
+		msgUnknownUser: "The user is not registered",
+		msgUserExists:  "A user with this ID or email already exists",
+
+		msgNoPage:          "The page query parameter is required",
+		msgPageNotInteger:  "The page query parameter must be an integer",
+		msgNoLimit:         "The limit query parameter is required",
+		msgLimitNotInteger: "The limit query parameter must be an integer",
+		msgIncorrectUUID:   "The user ID must be a valid UUID",
+		msgIncorrectId:     "The ID must be a non-negative integer",
+		msgNoStartDate:     "The start_date query parameter is required",
+		msgNoEndDate:       "The end_date query parameter is required",
+
+		msgIncorrectStartDate: "The start_date query parameter must be a date in YYYY-MM-DD or MM-YYYY format",
+		msgIncorrectEndDate:   "The end_date query parameter must be a date in YYYY-MM-DD or MM-YYYY format",
+		msgIncorrectDays:      "The days query parameter must be an integer from 0 to 366",
+		msgIncorrectStatus:    "The status query parameter must be active, trialing, paused, cancelled or expired",
+		msgCancelDate:         "Set either at_period_end or date, not both",
+		msgIncorrectServiceId: "The service_id query parameter must be a positive integer",
+		msgIncorrectThreshold: "The threshold query parameter must be a number greater than 0 and at most 1",
+		msgIncorrectCategory:  "The category query parameter must be a positive integer",
+		msgIncorrectTag:       "The tag query parameter must be a tag name, prefixed with ! to exclude the tag",
+		msgIncorrectMonth:     "The month query parameter must be a month in MM-YYYY format",
+		msgIncorrectMonths:    "The months query parameter must be an integer from 1 to 60",
+
+		msgIncorrectProration: "The proration query parameter must be monthly or daily",
+		msgExplainNotBoolean:  "The explain query parameter must be a boolean",
+
+		msgByCategoryNotBoolean: "The by_category query parameter must be a boolean",
+		msgDismissedNotBoolean:  "The dismissed query parameter must be a boolean",
+
+		msgExplainMonthly: "%d × %d months (%s – %s) = %d",
+		msgExplainDaily:   "%d per month for %d days in %d months (%s – %s) = %d",
+
+		msgExplainMonthlyTrial: "%d × %d trial months + %d × %d months (%s – %s) = %d",
+		msgExplainDailyTrial:   "%d per month, %d during %d trial months, for %d days in %d months (%s – %s) = %d",
+
+		msgExplainShare:       "%s, the user's share is %d",
+		msgExplainPriceChange: "%d months at the price in effect on each charge, the last one %d (%s – %s) = %d",
+
+		msgFieldRequired:  "is required",
+		msgFieldMaxLength: "must be at most %s characters long",
+		msgFieldMinLength: "must be at least %s characters long",
+		msgFieldLte:       "must be less than or equal to %s",
+		msgFieldGte:       "must be greater than or equal to %s",
+		msgFieldUUID:      "must be a valid UUID v4",
+		msgFieldDate:      "must be a date in YYYY-MM-DD or MM-YYYY format",
+		msgFieldTagName:   "must not be blank or start with !",
+		msgFieldUnique:    "must not contain repeated values",
+		msgFieldType:      "must be of type %s",
+		msgFieldOneOf:     "must be one of: %s",
+		msgFieldURL:       "must be a valid URL",
+		msgFieldEmail:     "must be a valid email address",
+		msgFieldLocale:    "must be a BCP 47 language tag, e.g. en or ru-RU",
+		msgFieldCurrency:  "must be an ISO 4217 currency code, e.g. RUB",
+		msgFieldTimezone:  "must be an IANA time zone, e.g. Europe/Moscow",
+		msgFieldInvalid:   "is invalid",
+	},
+	language.Russian: {
+		msgTitleValidation:    "Ошибка валидации",
+		msgTitleMalformedBody: "Некорректное тело запроса",
+		msgTitleBadRequest:    "Некорректный запрос",
+		msgTitleNotFound:      "Не найдено",
+		msgTitleConflict:      "Конфликт",
+		msgTitleForbidden:     "Доступ запрещён",
+		msgTitleInternal:      "Внутренняя ошибка сервера",
+
+		msgValidationDetail: "Запрос содержит некорректные поля",
+		msgMalformedBody:    "Тело запроса не является корректным JSON-документом",
+		msgIncorrectTime:    "Дата окончания не может быть раньше даты начала",
+		msgNotFound:         "Запрошенный ресурс не найден",
+		msgInternal:         "Произошла непредвиденная ошибка, повторите попытку позже",
+
+		msgPauseOutsidePeriod:       "Пауза должна быть в пределах срока подписки",
+		msgPauseOverlap:             "Пауза пересекается с другой паузой подписки",
+		msgNotPaused:                "Подписка не приостановлена на дату возобновления",
+		msgAlreadyEnded:             "Подписка уже закончилась",
+		msgPriceChangeOutsidePeriod: "Изменение цены должно быть в пределах срока подписки и не в прошлом",
+		msgPriceChangeExists:        "У подписки уже есть изменение цены на эту дату",
+		msgAlreadyExists:            "Название или псевдоним уже занято другим сервисом, тарифом, категорией или меткой",
+		msgUnknownService:           "Сервиса нет в каталоге",
+		msgUnknownPlan:              "Тарифа нет в каталоге",
+		msgPlanMismatch:             "Тариф принадлежит другому сервису",
+		msgUnknownCategory:          "Категория не встроенная и не принадлежит пользователю",
+		msgBuiltInCategory:          "Встроенные категории нельзя изменять",
+		msgCategoryCycle:            "Категорию нельзя переместить в её собственную подкатегорию",
+		msgBudgetExists:             "У пользователя уже есть бюджет для этой категории или сервиса",
+		msgIncorrectSplit:           "Участниками должны быть другие пользователи: без долей при равном разделении, с процентами не больше 100 в сумме или суммами не больше цены",
+
+		msgNoOrganization:        "Заголовок X-Organization-Id обязателен",
+		msgIncorrectOrganization: "Заголовок X-Organization-Id должен быть положительным целым числом",
+		msgNotMember:             "Пользователь не состоит в организации",
+		msgOtherOrganization:     "Организация не совпадает с заголовком X-Organization-Id",
+		msgOrganizationNotEmpty:  "У организации ещё есть подписки",
+
+		msgUnknownUser: "Пользователь не зарегистрирован",
+		msgUserExists:  "Пользователь с таким ID или email уже существует",
+
+		msgNoPage:          "Параметр запроса page обязателен",
+		msgPageNotInteger:  "Параметр запроса page должен быть целым числом",
+		msgNoLimit:         "Параметр запроса limit обязателен",
+		msgLimitNotInteger: "Параметр запроса limit должен быть целым числом",
+		msgIncorrectUUID:   "ID пользователя должен быть корректным UUID",
+		msgIncorrectId:     "ID должен быть неотрицательным целым числом",
+		msgNoStartDate:     "Параметр запроса start_date обязателен",
+		msgNoEndDate:       "Параметр запроса end_date обязателен",
+
+		msgIncorrectStartDate: "Параметр запроса start_date должен быть датой в формате YYYY-MM-DD или MM-YYYY",
+		msgIncorrectEndDate:   "Параметр запроса end_date должен быть датой в формате YYYY-MM-DD или MM-YYYY",
+		msgIncorrectDays:      "Параметр запроса days должен быть целым числом от 0 до 366",
+		msgIncorrectStatus:    "Параметр запроса status должен быть active, trialing, paused, cancelled или expired",
+		msgCancelDate:         "Укажите либо at_period_end, либо date, но не оба",
+		msgIncorrectServiceId: "Параметр запроса service_id должен быть положительным целым числом",
+		msgIncorrectThreshold: "Параметр запроса threshold должен быть числом больше 0 и не больше 1",
+		msgIncorrectCategory:  "Параметр запроса category должен быть положительным целым числом",
+		msgIncorrectTag:       "Параметр запроса tag должен быть названием метки, с префиксом ! для исключения метки",
+		msgIncorrectMonth:     "Параметр запроса month должен быть месяцем в формате MM-YYYY",
+		msgIncorrectMonths:    "Параметр запроса months должен быть целым числом от 1 до 60",
+
+		msgIncorrectProration: "Параметр запроса proration должен быть monthly или daily",
+		msgExplainNotBoolean:  "Параметр запроса explain должен быть логическим значением",
+
+		msgByCategoryNotBoolean: "Параметр запроса by_category должен быть логическим значением",
+		msgDismissedNotBoolean:  "Параметр запроса dismissed должен быть логическим значением",
+
+		msgExplainMonthly: "%d × %d мес. (%s – %s) = %d",
+		msgExplainDaily:   "%d в месяц за %d дн. в %d мес. (%s – %s) = %d",
+
+		msgExplainMonthlyTrial: "%d × %d мес. пробного периода + %d × %d мес. (%s – %s) = %d",
+		msgExplainDailyTrial:   "%d в месяц, %d в %d мес. пробного периода, за %d дн. в %d мес. (%s – %s) = %d",
+
+		msgExplainShare:       "%s, доля пользователя — %d",
+		msgExplainPriceChange: "%d мес. по цене на дату каждого списания, последняя — %d (%s – %s) = %d",
+
+		msgFieldRequired:  "обязательное поле",
+		msgFieldMaxLength: "должно содержать не более %s символов",
+		msgFieldMinLength: "должно содержать не менее %s символов",
+		msgFieldLte:       "должно быть не больше %s",
+		msgFieldGte:       "должно быть не меньше %s",
+		msgFieldUUID:      "должно быть корректным UUID v4",
+		msgFieldDate:      "должно быть датой в формате YYYY-MM-DD или MM-YYYY",
+		msgFieldTagName:   "не должно быть пустым или начинаться с !",
+		msgFieldUnique:    "не должно содержать повторяющихся значений",
+		msgFieldType:      "должно иметь тип %s",
+		msgFieldOneOf:     "должно быть одним из значений: %s",
+		msgFieldURL:       "должно быть корректным URL",
+		msgFieldEmail:     "должно быть корректным адресом электронной почты",
+		msgFieldLocale:    "должно быть языковым тегом BCP 47, например en или ru-RU",
+		msgFieldCurrency:  "должно быть кодом валюты ISO 4217, например RUB",
+		msgFieldTimezone:  "должно быть часовым поясом IANA, например Europe/Moscow",
+		msgFieldInvalid:   "некорректное значение",
+	},
+}
+
+// requestLanguage picks the best supported language from the Accept-Language header
+func requestLanguage(c *gin.Context) language.Tag {
+	_, index := language.MatchStrings(languageMatcher, c.GetHeader(acceptLanguageHeader))
+	return supportedLanguages[index]
+}
+
+func translate(lang language.Tag, id string, args ...any) string {
+	text, ok := messages[lang][id]
+	if !ok {
+		text = messages[language.English][id]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// explainPrice describes how the cost of a subscription was calculated and the user's share of a shared one
+func explainPrice(lang language.Tag, proration domain.Proration, item domain.PriceItem) string {
+	explanation := explainCost(lang, proration, item)
+	if item.Share != item.Cost {
+		return translate(lang, msgExplainShare, explanation, item.Share)
+	}
+	return explanation
+}
+
+func explainCost(lang language.Tag, proration domain.Proration, item domain.PriceItem) string {
+	if item.PriceChanged {
+		return translate(lang, msgExplainPriceChange, item.Months, item.Price, item.From, item.To, item.Cost)
+	}
+	if item.TrialMonths > 0 {
+		if proration == domain.ProrationDaily {
+			return translate(lang, msgExplainDailyTrial, item.Price, item.TrialPrice, item.TrialMonths, item.Days, item.Months, item.From, item.To, item.Cost)
+		}
+		return translate(lang, msgExplainMonthlyTrial, item.TrialPrice, item.TrialMonths, item.Price, item.Months-item.TrialMonths, item.From, item.To, item.Cost)
+	}
+	if proration == domain.ProrationDaily {
+		return translate(lang, msgExplainDaily, item.Price, item.Days, item.Months, item.From, item.To, item.Cost)
+	}
+	return translate(lang, msgExplainMonthly, item.Price, item.Months, item.From, item.To, item.Cost)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InsightHandler struct {
+	insightService IInsightService
+}
+
+type IInsightService interface {
+	GetInsights(ctx context.Context, userId uuid.UUID, dismissed bool) ([]*domain.Insight, error)
+	DismissInsight(ctx context.Context, userId uuid.UUID, id string) (*domain.Insight, error)
+}
+
+func NewInsightHandler(g *gin.RouterGroup, insightService IInsightService) {
+	r := &InsightHandler{
+		insightService: insightService,
+	}
+
+	g.GET("/user/:user_id/insights", r.GetInsights)
+	g.POST("/user/:user_id/insights/:insight_id/dismiss", r.DismissInsight)
+}
+
+// GetInsights godoc
+// @Summary Получить подсказки по подпискам пользователя
+// @Description Находит возможные проблемы с подписками пользователя:
+// @Description duplicate — две подписки на один сервис (сервис каталога или одинаковое название без учёта регистра, пробелов и диакритики), активные одновременно;
+// @Description price_jump — подписка на сервис дороже предыдущей на 30% и больше;
+// @Description long_open_ended — подписка без даты окончания, активная 24 месяца и дольше.
+// @Description ID подсказки не меняется, пока не меняются подписки; скрытые подсказки возвращаются только с dismissed=true.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param user_id path string true "UUID пользователя" format(uuid)
+// @Param dismissed query boolean false "Вернуть и скрытые подсказки"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} map[string][]dto.Insight "Подсказки"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/user/{user_id}/insights [get]
+func (h *InsightHandler) GetInsights(c *gin.Context) {
+	userId, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	dismissed := false
+	if value, ok := c.GetQuery("dismissed"); ok {
+		dismissed, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(c, errDismissedNotBoolean)
+			return
+		}
+	}
+
+	insights, err := h.insightService.GetInsights(c.Request.Context(), userId, dismissed)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.Insight{}
+	for _, insight := range insights {
+		res = append(res, toInsightDTO(insight))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"insights": res,
+		},
+	)
+}
+
+// DismissInsight godoc
+// @Summary Скрыть подсказку
+// @Description Скрывает подсказку по подпискам пользователя. Повторное скрытие ничего не меняет.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param user_id path string true "UUID пользователя" format(uuid)
+// @Param insight_id path string true "ID подсказки"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} map[string]dto.Insight "Скрытая подсказка"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 404 {object} handlers.ErrorResponse "Подсказка не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/user/{user_id}/insights/{insight_id}/dismiss [post]
+func (h *InsightHandler) DismissInsight(c *gin.Context) {
+	userId, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	insight, err := h.insightService.DismissInsight(c.Request.Context(), userId, c.Param("insight_id"))
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"insight": toInsightDTO(insight),
+		},
+	)
+}
+
+func toInsightDTO(i *domain.Insight) dto.Insight {
+	res := dto.Insight{
+		Id:              i.Id,
+		Kind:            string(i.Kind),
+		ServiceName:     i.ServiceName,
+		SubscriptionIds: i.SubscriptionIds,
+		DismissedAt:     i.DismissedAt,
+	}
+	switch i.Kind {
+	case domain.InsightDuplicate:
+		res.From = &i.From
+		if i.To != "" {
+			res.To = &i.To
+		}
+	case domain.InsightPriceJump:
+		res.OldPrice = &i.OldPrice
+		res.NewPrice = &i.NewPrice
+		res.Increase = &i.Increase
+	case domain.InsightLongOpenEnded:
+		res.Months = &i.Months
+	}
+	return res
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// defaultNameThreshold is the trigram similarity the names are clustered with by default
+const defaultNameThreshold = 0.5
+
+type NameHandler struct {
+	nameService INameService
+	validate    *validator.Validate
+}
+
+type INameService interface {
+	GetClusters(ctx context.Context, threshold float64) ([]*domain.NameCluster, error)
+	Merge(ctx context.Context, data *domain.NameMerge) (*domain.NameMergeAudit, error)
+	GetMerges(ctx context.Context, offset, limit int) ([]*domain.NameMergeAudit, error)
+}
+
+// NewNameHandler registers the merge, which rewrites the subscriptions of every user, in the admin group
+func NewNameHandler(g *gin.RouterGroup, admin *gin.RouterGroup, nameService INameService, validate *validator.Validate) {
+	r := &NameHandler{
+		nameService: nameService,
+		validate:    validate,
+	}
+
+	g.GET("/names/clusters", r.GetClusters)
+	admin.POST("/names/merge", r.Merge)
+	g.GET("/names/merges", r.GetMerges)
+}
+
+// GetClusters godoc
+// @Summary Найти похожие названия сервисов
+// @Description Группирует названия сервисов в подписках, совпадающие без учёта регистра, пробелов и диакритики
+// @Description или похожие по триграммам. Для каждой группы предлагается название: сервис из каталога или самое частое написание.
+// @Tags names
+// @Accept json
+// @Produce json
+// @Param threshold query number false "Минимальная триграммная похожесть, по умолчанию 0.5" minimum(0) maximum(1)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} map[string][]dto.NameCluster "Группы названий, начиная с самых больших"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/names/clusters [get]
+func (h *NameHandler) GetClusters(c *gin.Context) {
+	threshold := defaultNameThreshold
+	if value, ok := c.GetQuery("threshold"); ok {
+		var err error
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			respondWithError(c, errIncorrectThreshold)
+			return
+		}
+	}
+
+	clusters, err := h.nameService.GetClusters(c.Request.Context(), threshold)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.NameCluster{}
+	for _, cluster := range clusters {
+		names := make([]dto.ServiceName, 0, len(cluster.Names))
+		for _, name := range cluster.Names {
+			names = append(names, dto.ServiceName{Name: name.Name, Subscriptions: name.Subscriptions})
+		}
+		res = append(res, dto.NameCluster{
+			Canonical:     cluster.Canonical,
+			ServiceId:     cluster.ServiceId,
+			Names:         names,
+			Subscriptions: cluster.Subscriptions,
+		})
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"clusters": res,
+		},
+	)
+}
+
+// Merge godoc
+// @Summary Объединить названия сервиса
+// @Description Переименовывает подписки с указанными названиями в одной транзакции и связывает их с сервисом каталога
+// @Description с новым названием, если он есть. Объединение записывается в журнал.
+// @Description Доступно только на порту администрирования (server.admin_port).
+// @Tags names
+// @Accept json
+// @Produce json
+// @Param request body dto.NameMergeRequest true "Названия"
+// @Param X-Organization-Id header integer false "ID организации, без него объединяются названия во всех организациях"
+// @Success 200 {object} dto.NameMerge "Запись журнала"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/names/merge [post]
+func (h *NameHandler) Merge(c *gin.Context) {
+	var req dto.NameMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	merge, err := h.nameService.Merge(c.Request.Context(), &domain.NameMerge{
+		Canonical: req.Canonical,
+		Names:     req.Names,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toNameMergeDTO(merge))
+}
+
+// GetMerges godoc
+// @Summary Получить журнал объединений названий
+// @Description Возвращает выполненные объединения названий по ID
+// @Tags names
+// @Accept json
+// @Produce json
+// @Param page query integer true "Номер страницы" minimum(0)
+// @Param limit query integer true "Количество записей на странице" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/names/merges [get]
+func (h *NameHandler) GetMerges(c *gin.Context) {
+	page, ok := c.GetQuery("page")
+	if !ok {
+		respondWithError(c, errNoPage)
+		return
+	}
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		respondWithError(c, errPageNotInteger)
+		return
+	}
+
+	limit, ok := c.GetQuery("limit")
+	if !ok {
+		respondWithError(c, errNoLimit)
+		return
+	}
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		respondWithError(c, errLimitNotInteger)
+		return
+	}
+	offset := (pageInt - 1) * limitInt
+
+	merges, err := h.nameService.GetMerges(c.Request.Context(), offset, limitInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.NameMerge{}
+	for _, m := range merges {
+		res = append(res, toNameMergeDTO(m))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"page":   page,
+			"limit":  limit,
+			"merges": res,
+		},
+	)
+}
+
+func toNameMergeDTO(m *domain.NameMergeAudit) dto.NameMerge {
+	return dto.NameMerge{
+		Id:            m.Id,
+		Canonical:     m.Canonical,
+		Names:         m.Names,
+		Subscriptions: m.Subscriptions,
+		MergedAt:      m.MergedAt,
+	}
+}
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type OrganizationHandler struct {
+	organizationService IOrganizationService
+	validate            *validator.Validate
+}
+
+type IOrganizationService interface {
+	CreateOrganization(ctx context.Context, data *domain.OrganizationCreate) (int, error)
+	GetOrganization(ctx context.Context, id int) (*domain.Organization, error)
+	GetOrganizations(ctx context.Context, userId uuid.UUID) ([]*domain.Organization, error)
+	UpdateOrganization(ctx context.Context, data *domain.OrganizationUpdate) (*domain.Organization, error)
+	DeleteOrganization(ctx context.Context, id int) (*domain.Organization, error)
+	AddMember(ctx context.Context, organizationId int, userId uuid.UUID) ([]domain.OrganizationMember, error)
+	RemoveMember(ctx context.Context, organizationId int, userId uuid.UUID) ([]domain.OrganizationMember, error)
+	GetMembers(ctx context.Context, organizationId int) ([]domain.OrganizationMember, error)
+	GetOrganizationCost(ctx context.Context, filter *domain.OrganizationCostFilter) (*domain.OrganizationCost, error)
+}
+
+// NewOrganizationHandler registers the organization list on g and the routes of an organization on organization,
+// the group of /:id scoped to the tenant
+func NewOrganizationHandler(g *gin.RouterGroup, organization *gin.RouterGroup, organizationService IOrganizationService, validate *validator.Validate) {
+	r := &OrganizationHandler{
+		organizationService: organizationService,
+		validate:            validate,
+	}
+
+	g.GET("/", r.GetOrganizations)
+	g.POST("/", r.AddOrganization)
+	organization.GET("", r.GetOrganization)
+	organization.PATCH("", r.UpdateOrganization)
+	organization.DELETE("", r.DeleteOrganization)
+	organization.GET("/members", r.GetMembers)
+	organization.POST("/members", r.AddMember)
+	organization.DELETE("/members/:user_id", r.RemoveMember)
+	organization.GET("/price", r.GetOrganizationCost)
+}
+
+// AddOrganization godoc
+// @Summary Создать организацию
+// @Description Создаёт организацию (тенант): компанию или семью. Подписки, созданные с заголовком X-Organization-Id, видны только в этой организации.
+// @Description С user_id пользователь становится первым участником.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param request body dto.OrganizationCreateRequest true "Данные организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization [post]
+func (h *OrganizationHandler) AddOrganization(c *gin.Context) {
+	var req dto.OrganizationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	data := &domain.OrganizationCreate{Name: req.Name}
+	if req.UserId != nil {
+		UUID, _ := uuid.Parse(*req.UserId)
+		data.UserId = &UUID
+	}
+
+	id, err := h.organizationService.CreateOrganization(c.Request.Context(), data)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"id": id,
+		},
+	)
+}
+
+// GetOrganizations godoc
+// @Summary Получить организации пользователя
+// @Description Возвращает организации, в которых состоит пользователь
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param user_id query string true "UUID пользователя" format(uuid)
+// @Success 200 {object} map[string][]dto.Organization "Организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/ [get]
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	userId, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	organizations, err := h.organizationService.GetOrganizations(c.Request.Context(), userId)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.Organization{}
+	for _, organization := range organizations {
+		res = append(res, toOrganizationDTO(organization))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"organizations": res,
+		},
+	)
+}
+
+// GetOrganization godoc
+// @Summary Получить организацию
+// @Description Возвращает организацию по ID
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID организации" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Организация не совпадает с заголовком X-Organization-Id"
+// @Failure 404 {object} handlers.ErrorResponse "Организация не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/{id} [get]
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	id, ok := organizationId(c)
+	if !ok {
+		return
+	}
+
+	organization, err := h.organizationService.GetOrganization(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"organization": toOrganizationDTO(organization),
+		},
+	)
+}
+
+// UpdateOrganization godoc
+// @Summary Переименовать организацию
+// @Description Изменяет название организации
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID организации" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Param request body dto.OrganizationUpdateRequest true "Новое название"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Организация не совпадает с заголовком X-Organization-Id"
+// @Failure 404 {object} handlers.ErrorResponse "Организация не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/{id} [patch]
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	id, ok := organizationId(c)
+	if !ok {
+		return
+	}
+
+	var req dto.OrganizationUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	organization, err := h.organizationService.UpdateOrganization(c.Request.Context(), &domain.OrganizationUpdate{
+		Id:   id,
+		Name: req.Name,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"organization": toOrganizationDTO(organization),
+		},
+	)
+}
+
+// DeleteOrganization godoc
+// @Summary Удалить организацию
+// @Description Удаляет организацию вместе с участниками. Организацию с подписками удалить нельзя.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID организации" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Организация не совпадает с заголовком X-Organization-Id"
+// @Failure 404 {object} handlers.ErrorResponse "Организация не найдена"
+// @Failure 409 {object} handlers.ErrorResponse "У организации есть подписки"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/{id} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	id, ok := organizationId(c)
+	if !ok {
+		return
+	}
+
+	organization, err := h.organizationService.DeleteOrganization(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"organization": toOrganizationDTO(organization),
+		},
+	)
+}
+
+// GetMembers godoc
+// @Summary Получить участников организации
+// @Description Возвращает участников организации
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID организации" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} dto.MembersResponse
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Организация не совпадает с заголовком X-Organization-Id"
+// @Failure 404 {object} handlers.ErrorResponse "Организация не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/{id}/members [get]
+func (h *OrganizationHandler) GetMembers(c *gin.Context) {
+	id, ok := organizationId(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.organizationService.GetMembers(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		toMembersResponse(members),
+	)
+}
+
+// AddMember godoc
+// @Summary Добавить участника организации
+// @Description Добавляет пользователя в организацию, повторное добавление ничего не меняет. Возвращает участников организации.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID организации" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Param request body dto.OrganizationMemberRequest true "Участник"
+// @Success 200 {object} dto.MembersResponse
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Организация не совпадает с заголовком X-Organization-Id"
+// @Failure 404 {object} handlers.ErrorResponse "Организация не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/{id}/members [post]
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	id, ok := organizationId(c)
+	if !ok {
+		return
+	}
+
+	var req dto.OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	UUID, _ := uuid.Parse(req.UserId)
+
+	members, err := h.organizationService.AddMember(c.Request.Context(), id, UUID)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		toMembersResponse(members),
+	)
+}
+
+// RemoveMember godoc
+// @Summary Удалить участника организации
+// @Description Удаляет пользователя из организации, его подписки остаются в организации. Возвращает оставшихся участников.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID организации" minimum(0)
+// @Param user_id path string true "UUID пользователя" format(uuid)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} dto.MembersResponse
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Организация не совпадает с заголовком X-Organization-Id"
+// @Failure 404 {object} handlers.ErrorResponse "Организация не найдена или пользователь не участник"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/{id}/members/{user_id} [delete]
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	id, ok := organizationId(c)
+	if !ok {
+		return
+	}
+
+	userId, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	members, err := h.organizationService.RemoveMember(c.Request.Context(), id, userId)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		toMembersResponse(members),
+	)
+}
+
+// GetOrganizationCost godoc
+// @Summary Стоимость подписок организации
+// @Description Возвращает стоимость подписок организации за период с помесячным пропорциональным расчётом
+// @Description и сколько из неё платит каждый пользователь с учётом разделения стоимости общих подписок.
+// @Tags organization
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID организации" minimum(0)
+// @Param start_date query string true "Первый день периода (YYYY-MM-DD) или первый месяц (MM-YYYY)"
+// @Param end_date query string true "Последний день периода (YYYY-MM-DD) или последний месяц (MM-YYYY)"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} dto.OrganizationCostResponse
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Организация не совпадает с заголовком X-Organization-Id"
+// @Failure 404 {object} handlers.ErrorResponse "Организация не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /organization/{id}/price [get]
+func (h *OrganizationHandler) GetOrganizationCost(c *gin.Context) {
+	id, ok := organizationId(c)
+	if !ok {
+		return
+	}
+
+	startDate, ok := c.GetQuery("start_date")
+	if !ok {
+		respondWithError(c, errNoStartDate)
+		return
+	}
+	if !isDate(startDate) {
+		respondWithError(c, errIncorrectStartDate)
+		return
+	}
+
+	endDate, ok := c.GetQuery("end_date")
+	if !ok {
+		respondWithError(c, errNoEndDate)
+		return
+	}
+	if !isDate(endDate) {
+		respondWithError(c, errIncorrectEndDate)
+		return
+	}
+
+	cost, err := h.organizationService.GetOrganizationCost(c.Request.Context(), &domain.OrganizationCostFilter{
+		OrganizationId: id,
+		StartDate:      startDate,
+		EndDate:        endDate,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := dto.OrganizationCostResponse{OrganizationId: cost.OrganizationId, Total: cost.Total, Users: []dto.UserCost{}}
+	for _, u := range cost.Users {
+		res.Users = append(res.Users, dto.UserCost{UserId: u.UserId, Cost: u.Cost})
+	}
+
+	c.JSON(
+		http.StatusOK,
+		res,
+	)
+}
+
+// organizationId parses the organization ID path parameter, responding with an error if it is incorrect
+func organizationId(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 0 {
+		respondWithError(c, errIncorrectId)
+		return 0, false
+	}
+	return id, true
+}
+
+func toOrganizationDTO(o *domain.Organization) dto.Organization {
+	return dto.Organization{
+		Id:        o.Id,
+		Name:      o.Name,
+		CreatedAt: o.CreatedAt,
+	}
+}
+
+func toMembersResponse(members []domain.OrganizationMember) dto.MembersResponse {
+	res := dto.MembersResponse{Members: []dto.OrganizationMember{}}
+	for _, m := range members {
+		res.Members = append(res.Members, dto.OrganizationMember{UserId: m.UserId, JoinedAt: m.JoinedAt})
+	}
+	return res
+}
@@ -3,17 +3,26 @@ package handlers
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Estriper0/subscription_service/internal/handlers/dto"
-	"github.com/Estriper0/subscription_service/internal/service"
 	"github.com/Estriper0/subscription_service/internal/service/domain"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultTrialDays = 7
+	maxTrialDays     = 366
+
+	defaultForecastMonths = 12
+	maxForecastMonths     = 60
+)
+
 type SubscriptionHandler struct {
 	subscriptionService ISubscriptionService
 	validate            *validator.Validate
@@ -21,12 +30,21 @@ type SubscriptionHandler struct {
 
 type ISubscriptionService interface {
 	Create(ctx context.Context, subscription *domain.SubscriptionCreate) (int, error)
-	GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int) ([]*domain.Subscription, error)
+	GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int, filter domain.ListFilter) ([]*domain.Subscription, error)
 	GetById(ctx context.Context, id int) (*domain.Subscription, error)
 	DeleteById(ctx context.Context, id int) (*domain.Subscription, error)
 	Update(ctx context.Context, data *domain.SubscriptionUpdate) (*domain.Subscription, error)
-	GetPriceByFilter(ctx context.Context, userId *uuid.UUID, serviceName *string, startDate, endDate string) (int, error)
-	GetAll(ctx context.Context, offset, limit int) ([]*domain.Subscription, error)
+	GetPriceByFilter(ctx context.Context, filter *domain.PriceFilter) (*domain.PriceReport, error)
+	GetAll(ctx context.Context, offset, limit int, filter domain.ListFilter) ([]*domain.Subscription, error)
+	GetEndingTrials(ctx context.Context, userId *uuid.UUID, days int) ([]*domain.Subscription, error)
+	GetForecast(ctx context.Context, userId uuid.UUID, months int) (*domain.Forecast, error)
+	Pause(ctx context.Context, data *domain.PauseCreate) (*domain.Subscription, error)
+	Resume(ctx context.Context, data *domain.Resume) (*domain.Subscription, error)
+	Cancel(ctx context.Context, data *domain.Cancel) (*domain.Subscription, error)
+	SchedulePriceChange(ctx context.Context, data *domain.PriceChangeCreate) (*domain.PriceChange, error)
+	GetPriceChanges(ctx context.Context, subscriptionId int) ([]*domain.PriceChange, error)
+	SetSplit(ctx context.Context, data *domain.SplitUpdate) (*domain.Subscription, error)
+	GetDebts(ctx context.Context, filter *domain.DebtFilter) ([]domain.Debt, error)
 }
 
 func NewSubscriptionHandler(g *gin.RouterGroup, subscriptionService ISubscriptionService, validate *validator.Validate) {
@@ -40,30 +58,42 @@ func NewSubscriptionHandler(g *gin.RouterGroup, subscriptionService ISubscriptio
 	g.GET("/:id", r.GetById)
 	g.DELETE("/:id", r.DeleteById)
 	g.PATCH("/:id", r.Update)
+	g.POST("/:id/pause", r.Pause)
+	g.POST("/:id/resume", r.Resume)
+	g.POST("/:id/cancel", r.Cancel)
+	g.POST("/:id/price-changes", r.SchedulePriceChange)
+	g.GET("/:id/price-changes", r.GetPriceChanges)
+	g.PUT("/:id/split", r.SetSplit)
 	g.GET("/price", r.GetPriceByFilter)
+	g.GET("/trials", r.GetEndingTrials)
+	g.GET("/forecast", r.GetForecast)
+	g.GET("/debts", r.GetDebts)
 	g.GET("/user/:user_id", r.GetByUser)
 }
 
 // Add godoc
 // @Summary Создать новую подписку
-// @Description Создаёт новую подписку для пользователя
+// @Description Создаёт новую подписку для пользователя. С plan_id цена и сервис по умолчанию берутся из тарифа каталога,
+// @Description без service_id подписка связывается с сервисом каталога, у которого такое название или псевдоним.
 // @Tags subscription
 // @Accept json
 // @Produce json
 // @Param request body dto.SubscriptionCreateRequest true "Данные для создания подписки"
-// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Param X-Organization-Id header integer true "ID организации, в которой создаётся подписка"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные или пользователь не зарегистрирован"
+// @Failure 403 {object} handlers.ErrorResponse "Пользователь не состоит в организации"
 // @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /subscription [post]
 func (h *SubscriptionHandler) Add(c *gin.Context) {
 	var req dto.SubscriptionCreateRequest
 
-	if err := c.Bind(&req); err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
 	if err := h.validate.Struct(req); err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		respondWithError(c, err)
 		return
 	}
 
@@ -75,13 +105,17 @@ func (h *SubscriptionHandler) Add(c *gin.Context) {
 		UserId:      UUID,
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
+		BillingDay:  req.BillingDay,
+		TrialMonths: req.TrialMonths,
+		TrialPrice:  req.TrialPrice,
+		ServiceId:   req.ServiceId,
+		PlanId:      req.PlanId,
+		CategoryId:  req.CategoryId,
+		Tags:        req.Tags,
+		Notes:       req.Notes,
 	})
 	if err != nil {
-		if errors.Is(err, service.ErrIncorrectTime) {
-			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
-			return
-		}
-		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		respondWithError(c, err)
 		return
 	}
 
@@ -102,29 +136,33 @@ func (h *SubscriptionHandler) Add(c *gin.Context) {
 // @Param page query integer true "Номер страницы" minimum(0)
 // @Param limit query integer true "Количество записей на странице" minimum(0)
 // @Param user_id path string true "UUID пользователя" format(uuid)
+// @Param status query string false "Только подписки с этим статусом" Enums(active, trialing, paused, cancelled, expired)
+// @Param category query integer false "Только подписки этой категории и её подкатегорий" minimum(1)
+// @Param tag query []string false "Только подписки со всеми этими метками, с префиксом ! — без метки" collectionFormat(multi)
+// @Param X-Organization-Id header integer true "ID организации"
 // @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID или параметры запроса"
 // @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /subscription/user/{user_id} [get]
 func (h *SubscriptionHandler) GetByUser(c *gin.Context) {
 	page, ok := c.GetQuery("page")
 	if !ok {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("no page"))
+		respondWithError(c, errNoPage)
 		return
 	}
 	pageInt, err := strconv.Atoi(page)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("page is not a integer"))
+		respondWithError(c, errPageNotInteger)
 		return
 	}
 
 	limit, ok := c.GetQuery("limit")
 	if !ok {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("no limit"))
+		respondWithError(c, errNoLimit)
 		return
 	}
 	limitInt, err := strconv.Atoi(limit)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("limit is not a integer"))
+		respondWithError(c, errLimitNotInteger)
 		return
 	}
 
@@ -133,27 +171,25 @@ func (h *SubscriptionHandler) GetByUser(c *gin.Context) {
 	userId := c.Param("user_id")
 	parseUUID, err := uuid.Parse(userId)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("incorrect uuid"))
+		respondWithError(c, errIncorrectUUID)
 		return
 	}
 
-	subscriptions, err := h.subscriptionService.GetByUser(c.Request.Context(), parseUUID, offset, limitInt)
+	filter, err := parseListFilter(c)
 	if err != nil {
-		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		respondWithError(c, err)
+		return
+	}
+
+	subscriptions, err := h.subscriptionService.GetByUser(c.Request.Context(), parseUUID, offset, limitInt, filter)
+	if err != nil {
+		respondWithError(c, err)
 		return
 	}
 
 	var res []dto.Subscription
 	for _, s := range subscriptions {
-		k := dto.Subscription{
-			Id:          s.Id,
-			ServiceName: s.ServiceName,
-			Price:       s.Price,
-			UserId:      s.UserId,
-			StartDate:   s.StartDate,
-			EndDate:     s.EndDate,
-		}
-		res = append(res, k)
+		res = append(res, toSubscriptionDTO(s))
 	}
 
 	c.JSON(
@@ -173,6 +209,7 @@ func (h *SubscriptionHandler) GetByUser(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path integer true "ID подписки" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
 // @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
 // @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
 // @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
@@ -181,28 +218,17 @@ func (h *SubscriptionHandler) GetById(c *gin.Context) {
 	id := c.Param("id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil || idInt < 0 {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("id must be a non-negative integer"))
+		respondWithError(c, errIncorrectId)
 		return
 	}
 
 	subscription, err := h.subscriptionService.GetById(c.Request.Context(), idInt)
 	if err != nil {
-		if errors.Is(err, service.ErrNotFound) {
-			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
-			return
-		}
-		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		respondWithError(c, err)
 		return
 	}
 
-	res := dto.Subscription{
-		Id:          subscription.Id,
-		ServiceName: subscription.ServiceName,
-		Price:       subscription.Price,
-		UserId:      subscription.UserId,
-		StartDate:   subscription.StartDate,
-		EndDate:     subscription.EndDate,
-	}
+	res := toSubscriptionDTO(subscription)
 
 	c.JSON(
 		http.StatusOK,
@@ -219,6 +245,7 @@ func (h *SubscriptionHandler) GetById(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path integer true "ID подписки для удаления" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
 // @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
 // @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
 // @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
@@ -227,28 +254,17 @@ func (h *SubscriptionHandler) DeleteById(c *gin.Context) {
 	id := c.Param("id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil || idInt < 0 {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("id must be a non-negative integer"))
+		respondWithError(c, errIncorrectId)
 		return
 	}
 
 	subscription, err := h.subscriptionService.DeleteById(c.Request.Context(), idInt)
 	if err != nil {
-		if errors.Is(err, service.ErrNotFound) {
-			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
-			return
-		}
-		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		respondWithError(c, err)
 		return
 	}
 
-	res := dto.Subscription{
-		Id:          subscription.Id,
-		ServiceName: subscription.ServiceName,
-		Price:       subscription.Price,
-		UserId:      subscription.UserId,
-		StartDate:   subscription.StartDate,
-		EndDate:     subscription.EndDate,
-	}
+	res := toSubscriptionDTO(subscription)
 
 	c.JSON(
 		http.StatusOK,
@@ -266,6 +282,7 @@ func (h *SubscriptionHandler) DeleteById(c *gin.Context) {
 // @Produce json
 // @Param id path integer true "ID подписки для обновления" minimum(0)
 // @Param request body dto.SubscriptionUpdateRequest true "Данные для обновления подписки"
+// @Param X-Organization-Id header integer true "ID организации"
 // @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
 // @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
 // @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
@@ -274,18 +291,18 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 	idInt, err := strconv.Atoi(id)
 	if err != nil || idInt < 0 {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("id must be a non-negative integer"))
+		respondWithError(c, errIncorrectId)
 		return
 	}
 
 	var req dto.SubscriptionUpdateRequest
-	if err := c.Bind(&req); err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
 	if err := h.validate.Struct(req); err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		respondWithError(c, err)
 		return
 	}
 
@@ -295,27 +312,21 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		Price:       req.Price,
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
+		BillingDay:  req.BillingDay,
+		TrialMonths: req.TrialMonths,
+		TrialPrice:  req.TrialPrice,
+		ServiceId:   req.ServiceId,
+		PlanId:      req.PlanId,
+		CategoryId:  req.CategoryId,
+		Tags:        req.Tags,
+		Notes:       req.Notes,
 	})
 	if err != nil {
-		if errors.Is(err, service.ErrNotFound) {
-			respondWithError(c, http.StatusNotFound, ErrStatusNotFound, err)
-			return
-		} else if errors.Is(err, service.ErrIncorrectTime) {
-			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
-			return
-		}
-		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		respondWithError(c, err)
 		return
 	}
 
-	res := dto.Subscription{
-		Id:          subscription.Id,
-		ServiceName: subscription.ServiceName,
-		Price:       subscription.Price,
-		UserId:      subscription.UserId,
-		StartDate:   subscription.StartDate,
-		EndDate:     subscription.EndDate,
-	}
+	res := toSubscriptionDTO(subscription)
 
 	c.JSON(
 		http.StatusOK,
@@ -327,62 +338,170 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 
 // GetPriceByFilter godoc
 // @Summary Получить сумму подписок по фильтрам
-// @Description Рассчитывает общую стоимость подписок по заданным фильтрам (пользователь, сервис, период)
+// @Description Рассчитывает общую стоимость подписок по заданным фильтрам (пользователь, сервис, период).
+// @Description Период включает начальную и конечную даты, месяц MM-YYYY означает все его дни. Подписка списывается
+// @Description ежемесячно в день списания (billing_day), стоимость — сумма списаний в периоде, подписка без даты окончания
+// @Description считается активной до конца периода. При proration=daily каждый платёжный период оплачивается
+// @Description пропорционально числу активных дней. При explain=true возвращается расчёт по каждой подписке.
+// @Description При by_category=true возвращается стоимость по категориям: стоимость подкатегорий входит в стоимость
+// @Description родительской категории, подписка без категории относится к категории своего сервиса.
 // @Tags subscription
 // @Accept json
 // @Produce json
 // @Param user_id query string false "UUID пользователя для фильтрации" format(uuid)
-// @Param service_name query string false "Название сервиса для фильтрации"
-// @Param start_date query string true "Начальная дата для подсчета суммы"
-// @Param end_date query string true "Конечная дата для подсчета суммы"
+// @Param service_name query string false "Название сервиса для фильтрации, также учитываются подписки сервиса каталога с таким названием или псевдонимом"
+// @Param service_id query integer false "ID сервиса каталога для фильтрации, имеет приоритет над service_name" minimum(1)
+// @Param start_date query string true "Первый день периода (YYYY-MM-DD) или первый месяц (MM-YYYY)"
+// @Param end_date query string true "Последний день периода (YYYY-MM-DD) или последний месяц (MM-YYYY)"
+// @Param proration query string false "Режим расчёта неполных месяцев" Enums(monthly, daily) default(monthly)
+// @Param explain query boolean false "Вернуть расчёт по каждой подписке"
+// @Param category query integer false "Только подписки этой категории и её подкатегорий" minimum(1)
+// @Param tag query []string false "Только подписки со всеми этими метками, с префиксом ! — без метки" collectionFormat(multi)
+// @Param by_category query boolean false "Вернуть стоимость по категориям"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} dto.PriceResponse
 // @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
 // @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /subscription/price [get]
 func (h *SubscriptionHandler) GetPriceByFilter(c *gin.Context) {
 	startDate, ok := c.GetQuery("start_date")
 	if !ok {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("no start date"))
+		respondWithError(c, errNoStartDate)
+		return
+	}
+	if !isDate(startDate) {
+		respondWithError(c, errIncorrectStartDate)
 		return
 	}
 
 	endDate, ok := c.GetQuery("end_date")
 	if !ok {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("no end date"))
+		respondWithError(c, errNoEndDate)
+		return
+	}
+	if !isDate(endDate) {
+		respondWithError(c, errIncorrectEndDate)
 		return
 	}
 
-	var n *string
+	filter := &domain.PriceFilter{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Proration: domain.ProrationMonthly,
+	}
+
 	serviceName, ok := c.GetQuery("service_name")
 	if ok {
-		n = &serviceName
+		filter.ServiceName = &serviceName
+	}
+
+	if value, ok := c.GetQuery("service_id"); ok {
+		serviceId, err := strconv.Atoi(value)
+		if err != nil || serviceId < 1 {
+			respondWithError(c, errIncorrectServiceId)
+			return
+		}
+		filter.ServiceId = &serviceId
 	}
 
-	var u *uuid.UUID
 	userId, ok := c.GetQuery("user_id")
 	if ok {
 		parseUUID, err := uuid.Parse(userId)
 		if err != nil {
-			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("incorrect uuid"))
+			respondWithError(c, errIncorrectUUID)
 			return
 		}
-		u = &parseUUID
+		filter.UserId = &parseUUID
 	}
 
-	price, err := h.subscriptionService.GetPriceByFilter(c.Request.Context(), u, n, startDate, endDate)
+	proration, ok := c.GetQuery("proration")
+	if ok {
+		switch domain.Proration(proration) {
+		case domain.ProrationMonthly, domain.ProrationDaily:
+			filter.Proration = domain.Proration(proration)
+		default:
+			respondWithError(c, errIncorrectProration)
+			return
+		}
+	}
+
+	var explain bool
+	if value, ok := c.GetQuery("explain"); ok {
+		var err error
+		explain, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(c, errExplainNotBoolean)
+			return
+		}
+	}
+
+	categoryId, err := parseCategory(c)
 	if err != nil {
-		if errors.Is(err, service.ErrIncorrectTime) {
-			respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, err)
+		respondWithError(c, err)
+		return
+	}
+	filter.CategoryId = categoryId
+
+	filter.Tags, filter.ExcludedTags, err = parseTags(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if value, ok := c.GetQuery("by_category"); ok {
+		filter.ByCategory, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(c, errByCategoryNotBoolean)
 			return
 		}
-		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+	}
+
+	report, err := h.subscriptionService.GetPriceByFilter(c.Request.Context(), filter)
+	if err != nil {
+		respondWithError(c, err)
 		return
 	}
 
+	res := dto.PriceResponse{Price: report.Total}
+	if explain {
+		lang := requestLanguage(c)
+		res.Subscriptions = []dto.PriceItem{}
+		for _, item := range report.Items {
+			explained := dto.PriceItem{
+				SubscriptionId: item.SubscriptionId,
+				ServiceName:    item.ServiceName,
+				Price:          item.Price,
+				From:           item.From,
+				To:             item.To,
+				Months:         item.Months,
+				Days:           item.Days,
+				TrialMonths:    item.TrialMonths,
+				Cost:           item.Cost,
+				Share:          item.Share,
+				Explanation:    explainPrice(lang, filter.Proration, item),
+			}
+			if item.TrialMonths > 0 {
+				explained.TrialPrice = &item.TrialPrice
+			}
+			res.Subscriptions = append(res.Subscriptions, explained)
+		}
+		c.Header(contentLanguageHeader, lang.String())
+	}
+	if filter.ByCategory {
+		res.Categories = []dto.CategoryCost{}
+		for _, category := range report.Categories {
+			res.Categories = append(res.Categories, dto.CategoryCost{
+				CategoryId: category.CategoryId,
+				Name:       category.Name,
+				ParentId:   category.ParentId,
+				Price:      category.Cost,
+			})
+		}
+	}
+
 	c.JSON(
 		http.StatusOK,
-		gin.H{
-			"price": price,
-		},
+		res,
 	)
 }
 
@@ -394,50 +513,52 @@ func (h *SubscriptionHandler) GetPriceByFilter(c *gin.Context) {
 // @Produce json
 // @Param page query integer true "Номер страницы" minimum(0)
 // @Param limit query integer true "Количество записей на странице" minimum(0)
+// @Param status query string false "Только подписки с этим статусом" Enums(active, trialing, paused, cancelled, expired)
+// @Param category query integer false "Только подписки этой категории и её подкатегорий" minimum(1)
+// @Param tag query []string false "Только подписки со всеми этими метками, с префиксом ! — без метки" collectionFormat(multi)
+// @Param X-Organization-Id header integer true "ID организации"
 // @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
 // @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /subscription/ [get]
 func (h *SubscriptionHandler) GetAll(c *gin.Context) {
 	page, ok := c.GetQuery("page")
 	if !ok {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("no page"))
+		respondWithError(c, errNoPage)
 		return
 	}
 	pageInt, err := strconv.Atoi(page)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("page is not a integer"))
+		respondWithError(c, errPageNotInteger)
 		return
 	}
 
 	limit, ok := c.GetQuery("limit")
 	if !ok {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("no limit"))
+		respondWithError(c, errNoLimit)
 		return
 	}
 	limitInt, err := strconv.Atoi(limit)
 	if err != nil {
-		respondWithError(c, http.StatusBadRequest, ErrStatusBadRequest, errors.New("limit is not a integer"))
+		respondWithError(c, errLimitNotInteger)
 		return
 	}
 	offset := (pageInt - 1) * limitInt
 
-	subscriptions, err := h.subscriptionService.GetAll(c.Request.Context(), offset, limitInt)
+	filter, err := parseListFilter(c)
 	if err != nil {
-		respondWithError(c, http.StatusInternalServerError, ErrStatusInternal, err)
+		respondWithError(c, err)
+		return
+	}
+
+	subscriptions, err := h.subscriptionService.GetAll(c.Request.Context(), offset, limitInt, filter)
+	if err != nil {
+		respondWithError(c, err)
 		return
 	}
 
 	var res []dto.Subscription
 	for _, s := range subscriptions {
-		k := dto.Subscription{
-			Id:          s.Id,
-			ServiceName: s.ServiceName,
-			Price:       s.Price,
-			UserId:      s.UserId,
-			StartDate:   s.StartDate,
-			EndDate:     s.EndDate,
-		}
-		res = append(res, k)
+		res = append(res, toSubscriptionDTO(s))
 	}
 
 	c.JSON(
@@ -449,3 +570,605 @@ func (h *SubscriptionHandler) GetAll(c *gin.Context) {
 		},
 	)
 }
+
+// Pause godoc
+// @Summary Приостановить подписку
+// @Description Добавляет паузу: дни паузы, включая начальный и конечный, не оплачиваются.
+// @Description Пауза должна начинаться и заканчиваться в пределах срока подписки и не пересекаться с другими паузами.
+// @Description Без end_date пауза длится до возобновления. Тело запроса необязательно.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID подписки" minimum(0)
+// @Param request body dto.PauseRequest false "Период паузы"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
+// @Failure 409 {object} handlers.ErrorResponse "Пауза пересекается с другой паузой"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.PauseRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	subscription, err := h.subscriptionService.Pause(c.Request.Context(), &domain.PauseCreate{
+		SubscriptionId: idInt,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"subscription": toSubscriptionDTO(subscription),
+		},
+	)
+}
+
+// Resume godoc
+// @Summary Возобновить подписку
+// @Description Завершает паузу, в которую попадает дата возобновления: пауза заканчивается накануне этой даты.
+// @Description Тело запроса необязательно.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID подписки" minimum(0)
+// @Param request body dto.ResumeRequest false "Дата возобновления"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
+// @Failure 409 {object} handlers.ErrorResponse "Подписка не приостановлена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.ResumeRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	subscription, err := h.subscriptionService.Resume(c.Request.Context(), &domain.Resume{
+		SubscriptionId: idInt,
+		Date:           req.Date,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"subscription": toSubscriptionDTO(subscription),
+		},
+	)
+}
+
+// Cancel godoc
+// @Summary Отменить подписку
+// @Description Устанавливает дату окончания подписки и сохраняет отмену с причиной. При at_period_end=true подписка
+// @Description заканчивается в последний день текущего платёжного периода, иначе — в день date (по умолчанию сегодня).
+// @Description Отмена не продлевает подписку с более ранней датой окончания. Тело запроса необязательно.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID подписки" minimum(0)
+// @Param request body dto.CancelRequest false "Параметры отмены"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
+// @Failure 409 {object} handlers.ErrorResponse "Подписка уже закончилась"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/{id}/cancel [post]
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.CancelRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if req.AtPeriodEnd && req.Date != nil {
+		respondWithError(c, errCancelDate)
+		return
+	}
+
+	subscription, err := h.subscriptionService.Cancel(c.Request.Context(), &domain.Cancel{
+		SubscriptionId: idInt,
+		AtPeriodEnd:    req.AtPeriodEnd,
+		Date:           req.Date,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"subscription": toSubscriptionDTO(subscription),
+		},
+	)
+}
+
+// SchedulePriceChange godoc
+// @Summary Запланировать изменение цены
+// @Description Подписка оплачивается по новой цене с даты изменения: прогноз считает каждое списание по цене, действующей в его день.
+// @Description Дата должна быть не раньше сегодняшней и в пределах срока подписки, на одну дату — одно изменение.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID подписки" minimum(0)
+// @Param request body dto.PriceChangeRequest true "Дата и новая цена"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные или дата вне срока подписки"
+// @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
+// @Failure 409 {object} handlers.ErrorResponse "На эту дату уже есть изменение цены"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/{id}/price-changes [post]
+func (h *SubscriptionHandler) SchedulePriceChange(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.PriceChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	change, err := h.subscriptionService.SchedulePriceChange(c.Request.Context(), &domain.PriceChangeCreate{
+		SubscriptionId: idInt,
+		EffectiveDate:  req.EffectiveDate,
+		Price:          *req.Price,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"price_change": toPriceChangeDTO(change),
+		},
+	)
+}
+
+// GetPriceChanges godoc
+// @Summary Изменения цены подписки
+// @Description Возвращает запланированные и прошедшие изменения цены подписки по дате.
+// @Tags subscription
+// @Produce json
+// @Param id path integer true "ID подписки" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный ID"
+// @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/{id}/price-changes [get]
+func (h *SubscriptionHandler) GetPriceChanges(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	changes, err := h.subscriptionService.GetPriceChanges(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := make([]dto.PriceChange, 0, len(changes))
+	for _, change := range changes {
+		res = append(res, toPriceChangeDTO(change))
+	}
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"price_changes": res,
+		},
+	)
+}
+
+// SetSplit godoc
+// @Summary Разделить стоимость подписки
+// @Description Делает подписку общей с другими пользователями. При equal стоимость делится поровну между владельцем и участниками,
+// @Description при percentage участник платит процент стоимости, при fixed — сумму из цены в месяц. Владелец платит остаток,
+// @Description доли участников округляются вниз. Стоимость подписок пользователя, прогноз и бюджеты учитывают только его долю.
+// @Description Пустой список участников отменяет разделение. Участниками могут быть только зарегистрированные пользователи организации.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID подписки" minimum(0)
+// @Param request body dto.SplitRequest true "Правило и участники"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 403 {object} handlers.ErrorResponse "Участник не состоит в организации"
+// @Failure 404 {object} handlers.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/{id}/split [put]
+func (h *SubscriptionHandler) SetSplit(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.SplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	data := &domain.SplitUpdate{SubscriptionId: idInt, Rule: domain.SplitRule(req.Rule)}
+	for _, m := range req.Members {
+		//Validated as UUID v4
+		userId, _ := uuid.Parse(m.UserId)
+		data.Members = append(data.Members, domain.Member{UserId: userId, Share: m.Share})
+	}
+
+	subscription, err := h.subscriptionService.SetSplit(c.Request.Context(), data)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"subscription": toSubscriptionDTO(subscription),
+		},
+	)
+}
+
+// GetDebts godoc
+// @Summary Долги за общие подписки
+// @Description Возвращает, сколько участники общих подписок должны их владельцам за период (помесячный расчёт).
+// @Description Встречные долги двух пользователей взаимозачитываются. С user_id — только долги пользователя и долги ему.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param start_date query string true "Первый день периода (YYYY-MM-DD) или первый месяц (MM-YYYY)"
+// @Param end_date query string true "Последний день периода (YYYY-MM-DD) или последний месяц (MM-YYYY)"
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} dto.DebtsResponse
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/debts [get]
+func (h *SubscriptionHandler) GetDebts(c *gin.Context) {
+	startDate, ok := c.GetQuery("start_date")
+	if !ok {
+		respondWithError(c, errNoStartDate)
+		return
+	}
+	if !isDate(startDate) {
+		respondWithError(c, errIncorrectStartDate)
+		return
+	}
+
+	endDate, ok := c.GetQuery("end_date")
+	if !ok {
+		respondWithError(c, errNoEndDate)
+		return
+	}
+	if !isDate(endDate) {
+		respondWithError(c, errIncorrectEndDate)
+		return
+	}
+
+	filter := &domain.DebtFilter{StartDate: startDate, EndDate: endDate}
+	if value, ok := c.GetQuery("user_id"); ok {
+		userId, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(c, errIncorrectUUID)
+			return
+		}
+		filter.UserId = &userId
+	}
+
+	debts, err := h.subscriptionService.GetDebts(c.Request.Context(), filter)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := dto.DebtsResponse{Debts: []dto.Debt{}}
+	for _, d := range debts {
+		res.Debts = append(res.Debts, dto.Debt{From: d.From, To: d.To, Amount: d.Amount})
+	}
+
+	c.JSON(
+		http.StatusOK,
+		res,
+	)
+}
+
+// parseListFilter returns the status, category and tag query parameters, empty if they are not set
+func parseListFilter(c *gin.Context) (domain.ListFilter, error) {
+	var filter domain.ListFilter
+	if value, ok := c.GetQuery("status"); ok {
+		switch status := domain.Status(value); status {
+		case domain.StatusActive, domain.StatusTrialing, domain.StatusPaused, domain.StatusCancelled, domain.StatusExpired:
+			filter.Status = status
+		default:
+			return filter, errIncorrectStatus
+		}
+	}
+	categoryId, err := parseCategory(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.CategoryId = categoryId
+	filter.Tags, filter.ExcludedTags, err = parseTags(c)
+	return filter, err
+}
+
+// parseCategory returns the category query parameter, nil if it is not set
+func parseCategory(c *gin.Context) (*int, error) {
+	value, ok := c.GetQuery("category")
+	if !ok {
+		return nil, nil
+	}
+	categoryId, err := strconv.Atoi(value)
+	if err != nil || categoryId < 1 {
+		return nil, errIncorrectCategory
+	}
+	return &categoryId, nil
+}
+
+// parseTags returns the tag query parameters split into the required and the excluded tags marked with "!"
+func parseTags(c *gin.Context) ([]string, []string, error) {
+	var tags, excluded []string
+	for _, value := range c.QueryArray("tag") {
+		name, exclude := strings.CutPrefix(value, "!")
+		if !isTagName(name) {
+			return nil, nil, errIncorrectTag
+		}
+		if exclude {
+			excluded = append(excluded, name)
+		} else {
+			tags = append(tags, name)
+		}
+	}
+	return tags, excluded, nil
+}
+
+// bindOptionalJSON decodes the request body into req if there is one
+func bindOptionalJSON(c *gin.Context, req any) error {
+	err := c.ShouldBindJSON(req)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// GetEndingTrials godoc
+// @Summary Получить подписки с заканчивающимся пробным периодом
+// @Description Возвращает подписки, пробный период которых заканчивается в ближайшие days дней (включая сегодня),
+// @Description чтобы пользователь успел отменить подписку до списания полной цены.
+// @Description Подписки, которые заканчиваются вместе с пробным периодом, не возвращаются.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param days query integer false "Число дней" minimum(0) maximum(366) default(7)
+// @Param user_id query string false "UUID пользователя для фильтрации" format(uuid)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/trials [get]
+func (h *SubscriptionHandler) GetEndingTrials(c *gin.Context) {
+	days := defaultTrialDays
+	if value, ok := c.GetQuery("days"); ok {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 0 || days > maxTrialDays {
+			respondWithError(c, errIncorrectDays)
+			return
+		}
+	}
+
+	var userId *uuid.UUID
+	if value, ok := c.GetQuery("user_id"); ok {
+		parseUUID, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(c, errIncorrectUUID)
+			return
+		}
+		userId = &parseUUID
+	}
+
+	subscriptions, err := h.subscriptionService.GetEndingTrials(c.Request.Context(), userId, days)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.Subscription{}
+	for _, s := range subscriptions {
+		res = append(res, toSubscriptionDTO(s))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"days":          days,
+			"subscriptions": res,
+		},
+	)
+}
+
+// GetForecast godoc
+// @Summary Получить прогноз расходов
+// @Description Прогнозирует списания подписок пользователя по месяцам, начиная с текущего, по тем же правилам, что и /subscription/price
+// @Description с proration=monthly: учитываются день списания, дата окончания, пробный период и паузы. Списания текущего месяца
+// @Description до сегодняшнего дня не учитываются. Для каждого месяца возвращаются подписки со списаниями в нём и расчёт их стоимости.
+// @Tags subscription
+// @Accept json
+// @Produce json
+// @Param user_id query string true "UUID пользователя" format(uuid)
+// @Param months query integer false "Число месяцев" minimum(1) maximum(60) default(12)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} dto.ForecastResponse
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /subscription/forecast [get]
+func (h *SubscriptionHandler) GetForecast(c *gin.Context) {
+	userId, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	months := defaultForecastMonths
+	if value, ok := c.GetQuery("months"); ok {
+		months, err = strconv.Atoi(value)
+		if err != nil || months < 1 || months > maxForecastMonths {
+			respondWithError(c, errIncorrectMonths)
+			return
+		}
+	}
+
+	forecast, err := h.subscriptionService.GetForecast(c.Request.Context(), userId, months)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	lang := requestLanguage(c)
+	res := dto.ForecastResponse{Price: forecast.Total, Months: []dto.ForecastMonth{}}
+	for _, month := range forecast.Months {
+		forecastMonth := dto.ForecastMonth{Month: month.Month, Price: month.Total, Subscriptions: []dto.PriceItem{}}
+		for _, item := range month.Items {
+			explained := dto.PriceItem{
+				SubscriptionId: item.SubscriptionId,
+				ServiceName:    item.ServiceName,
+				Price:          item.Price,
+				From:           item.From,
+				To:             item.To,
+				Months:         item.Months,
+				TrialMonths:    item.TrialMonths,
+				Cost:           item.Cost,
+				Share:          item.Share,
+				Explanation:    explainPrice(lang, domain.ProrationMonthly, item),
+			}
+			if item.TrialMonths > 0 {
+				explained.TrialPrice = &item.TrialPrice
+			}
+			forecastMonth.Subscriptions = append(forecastMonth.Subscriptions, explained)
+		}
+		res.Months = append(res.Months, forecastMonth)
+	}
+	c.Header(contentLanguageHeader, lang.String())
+
+	c.JSON(
+		http.StatusOK,
+		res,
+	)
+}
+
+func toSubscriptionDTO(s *domain.Subscription) dto.Subscription {
+	res := dto.Subscription{
+		Id:              s.Id,
+		ServiceName:     s.ServiceName,
+		Price:           s.Price,
+		UserId:          s.UserId,
+		StartDate:       s.StartDate,
+		EndDate:         s.EndDate,
+		BillingDay:      s.BillingDay,
+		NextBillingDate: s.NextBillingDate,
+		TrialMonths:     s.TrialMonths,
+		TrialPrice:      s.TrialPrice,
+		TrialEndsAt:     s.TrialEndsAt,
+		Status:          string(s.Status),
+		CancelledAt:     s.CancelledAt,
+		CancelReason:    s.CancelReason,
+		ServiceId:       s.ServiceId,
+		PlanId:          s.PlanId,
+		CategoryId:      s.CategoryId,
+		Tags:            []string{},
+		Notes:           s.Notes,
+		OrganizationId:  s.OrganizationId,
+	}
+	res.Tags = append(res.Tags, s.Tags...)
+	if s.Split != nil {
+		res.Split = &dto.Split{Rule: string(s.Split.Rule), Members: []dto.Member{}}
+		for _, m := range s.Split.Members {
+			res.Split.Members = append(res.Split.Members, dto.Member{UserId: m.UserId, Share: m.Share})
+		}
+	}
+	for _, p := range s.Pauses {
+		res.Pauses = append(res.Pauses, dto.Pause{
+			Id:        p.Id,
+			StartDate: p.StartDate,
+			EndDate:   p.EndDate,
+		})
+	}
+	return res
+}
+
+func toPriceChangeDTO(c *domain.PriceChange) dto.PriceChange {
+	return dto.PriceChange{
+		Id:            c.Id,
+		EffectiveDate: c.EffectiveDate,
+		Price:         c.Price,
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type TagHandler struct {
+	tagService ITagService
+	validate   *validator.Validate
+}
+
+type ITagService interface {
+	CreateTag(ctx context.Context, data *domain.TagCreate) (int, error)
+	GetTag(ctx context.Context, id int) (*domain.Tag, error)
+	GetTags(ctx context.Context, userId uuid.UUID) ([]*domain.Tag, error)
+	UpdateTag(ctx context.Context, data *domain.TagUpdate) (*domain.Tag, error)
+	DeleteTag(ctx context.Context, id int) (*domain.Tag, error)
+}
+
+func NewTagHandler(g *gin.RouterGroup, tagService ITagService, validate *validator.Validate) {
+	r := &TagHandler{
+		tagService: tagService,
+		validate:   validate,
+	}
+
+	g.GET("/", r.GetTags)
+	g.POST("/", r.AddTag)
+	g.GET("/:id", r.GetTag)
+	g.PATCH("/:id", r.UpdateTag)
+	g.DELETE("/:id", r.DeleteTag)
+}
+
+// AddTag godoc
+// @Summary Создать метку пользователя
+// @Description Создаёт метку пользователя. Метки также создаются при указании их в подписке.
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Param request body dto.TagCreateRequest true "Данные метки"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 409 {object} handlers.ErrorResponse "Метка с таким названием уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /tag [post]
+func (h *TagHandler) AddTag(c *gin.Context) {
+	var req dto.TagCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	UUID, _ := uuid.Parse(req.UserId)
+
+	id, err := h.tagService.CreateTag(c.Request.Context(), &domain.TagCreate{
+		UserId: UUID,
+		Name:   req.Name,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"id": id,
+		},
+	)
+}
+
+// GetTags godoc
+// @Summary Получить метки пользователя
+// @Description Возвращает метки пользователя по названию с числом подписок у каждой
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Param user_id query string true "UUID пользователя" format(uuid)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Success 200 {object} map[string][]dto.Tag "Метки"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /tag/ [get]
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userId, err := uuid.Parse(c.Query("user_id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	tags, err := h.tagService.GetTags(c.Request.Context(), userId)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.Tag{}
+	for _, tag := range tags {
+		res = append(res, toTagDTO(tag))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"tags": res,
+		},
+	)
+}
+
+// GetTag godoc
+// @Summary Получить метку
+// @Description Возвращает метку по ID
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID метки" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Метка не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /tag/{id} [get]
+func (h *TagHandler) GetTag(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	tag, err := h.tagService.GetTag(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"tag": toTagDTO(tag),
+		},
+	)
+}
+
+// UpdateTag godoc
+// @Summary Переименовать метку
+// @Description Переименовывает метку у всех подписок с ней
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID метки" minimum(0)
+// @Param request body dto.TagUpdateRequest true "Новое название метки"
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Метка не найдена"
+// @Failure 409 {object} handlers.ErrorResponse "Метка с таким названием уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /tag/{id} [patch]
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	var req dto.TagUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(c.Request.Context(), &domain.TagUpdate{
+		Id:   idInt,
+		Name: req.Name,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"tag": toTagDTO(tag),
+		},
+	)
+}
+
+// DeleteTag godoc
+// @Summary Удалить метку
+// @Description Удаляет метку и снимает её со всех подписок, сами подписки сохраняются
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Param id path integer true "ID метки" minimum(0)
+// @Param X-Organization-Id header integer true "ID организации"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Метка не найдена"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /tag/{id} [delete]
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id := c.Param("id")
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 0 {
+		respondWithError(c, errIncorrectId)
+		return
+	}
+
+	tag, err := h.tagService.DeleteTag(c.Request.Context(), idInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"tag": toTagDTO(tag),
+		},
+	)
+}
+
+func toTagDTO(t *domain.Tag) dto.Tag {
+	return dto.Tag{
+		Id:            t.Id,
+		UserId:        t.UserId.String(),
+		Name:          t.Name,
+		Subscriptions: t.Subscriptions,
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/handlers/dto"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	userService IUserService
+	validate    *validator.Validate
+}
+
+type IUserService interface {
+	CreateUser(ctx context.Context, data *domain.UserCreate) (*domain.User, error)
+	GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetUsers(ctx context.Context, offset, limit int) ([]*domain.User, error)
+	UpdateUser(ctx context.Context, data *domain.UserUpdate) (*domain.User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
+}
+
+func NewUserHandler(g *gin.RouterGroup, userService IUserService, validate *validator.Validate) {
+	r := &UserHandler{
+		userService: userService,
+		validate:    validate,
+	}
+
+	g.POST("/", r.AddUser)
+	g.GET("/", r.GetUsers)
+	g.GET("/:id", r.GetUser)
+	g.PATCH("/:id", r.UpdateUser)
+	g.DELETE("/:id", r.DeleteUser)
+}
+
+// AddUser godoc
+// @Summary Зарегистрировать пользователя
+// @Description Регистрирует пользователя. Подписки можно создавать только для зарегистрированных пользователей.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param request body dto.UserCreateRequest true "Данные пользователя"
+// @Success 201 {object} map[string]dto.User "Пользователь"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 409 {object} handlers.ErrorResponse "Пользователь с таким ID или email уже есть"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user [post]
+func (h *UserHandler) AddUser(c *gin.Context) {
+	var req dto.UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	data := &domain.UserCreate{
+		DisplayName: req.DisplayName,
+		Email:       req.Email,
+		Locale:      req.Locale,
+		Currency:    req.Currency,
+		Timezone:    req.Timezone,
+	}
+	if req.Id != nil {
+		UUID, _ := uuid.Parse(*req.Id)
+		data.Id = &UUID
+	}
+
+	user, err := h.userService.CreateUser(c.Request.Context(), data)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		gin.H{
+			"user": toUserDTO(user),
+		},
+	)
+}
+
+// GetUsers godoc
+// @Summary Получить пользователей
+// @Description Возвращает страницу зарегистрированных пользователей по UUID
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param page query integer true "Номер страницы" minimum(0)
+// @Param limit query integer true "Количество записей на странице" minimum(0)
+// @Success 200 {object} map[string][]dto.User "Пользователи"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/ [get]
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	page, ok := c.GetQuery("page")
+	if !ok {
+		respondWithError(c, errNoPage)
+		return
+	}
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		respondWithError(c, errPageNotInteger)
+		return
+	}
+
+	limit, ok := c.GetQuery("limit")
+	if !ok {
+		respondWithError(c, errNoLimit)
+		return
+	}
+	limitInt, err := strconv.Atoi(limit)
+	if err != nil {
+		respondWithError(c, errLimitNotInteger)
+		return
+	}
+	offset := (pageInt - 1) * limitInt
+
+	users, err := h.userService.GetUsers(c.Request.Context(), offset, limitInt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	res := []dto.User{}
+	for _, user := range users {
+		res = append(res, toUserDTO(user))
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"page":  page,
+			"limit": limit,
+			"users": res,
+		},
+	)
+}
+
+// GetUser godoc
+// @Summary Получить пользователя
+// @Description Возвращает пользователя по UUID, в том числе обезличенного
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Success 200 {object} map[string]dto.User "Пользователь"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 404 {object} handlers.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	user, err := h.userService.GetUser(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"user": toUserDTO(user),
+		},
+	)
+}
+
+// UpdateUser godoc
+// @Summary Изменить пользователя
+// @Description Изменяет заданные поля пользователя, email со значением null или пустой строкой удаляется.
+// @Description Обезличенного пользователя изменить нельзя.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Param request body dto.UserUpdateRequest true "Новые данные"
+// @Success 200 {object} map[string]dto.User "Пользователь"
+// @Failure 400 {object} handlers.ErrorResponse "Неверные входные данные"
+// @Failure 404 {object} handlers.ErrorResponse "Пользователь не найден или обезличен"
+// @Failure 409 {object} handlers.ErrorResponse "Email занят другим пользователем"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/{id} [patch]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	var req dto.UserUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	user, err := h.userService.UpdateUser(c.Request.Context(), &domain.UserUpdate{
+		Id:          id,
+		DisplayName: req.DisplayName,
+		Email:       req.Email,
+		ClearEmail:  req.ClearEmail,
+		Locale:      req.Locale,
+		Currency:    req.Currency,
+		Timezone:    req.Timezone,
+	})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"user": toUserDTO(user),
+		},
+	)
+}
+
+// DeleteUser godoc
+// @Summary Удалить пользователя
+// @Description Удаляет персональные данные пользователя (GDPR): участие в организациях и общих подписках, бюджеты, метки, категории и скрытые рекомендации.
+// @Description В режиме USER_DELETION=cascade пользователь удаляется вместе с подписками.
+// @Description В режиме anonymize (по умолчанию) пользователь обезличивается: имя и email стираются, заметки и причины отмены подписок очищаются, а сами подписки остаются для статистики.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Success 200 {object} map[string]dto.User "Удалённый или обезличенный пользователь"
+// @Failure 400 {object} handlers.ErrorResponse "Неверный формат UUID"
+// @Failure 404 {object} handlers.ErrorResponse "Пользователь не найден или уже обезличен"
+// @Failure 500 {object} handlers.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /user/{id} [delete]
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondWithError(c, errIncorrectUUID)
+		return
+	}
+
+	user, err := h.userService.DeleteUser(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"user": toUserDTO(user),
+		},
+	)
+}
+
+func toUserDTO(u *domain.User) dto.User {
+	return dto.User{
+		Id:           u.Id,
+		DisplayName:  u.DisplayName,
+		Email:        u.Email,
+		Locale:       u.Locale,
+		Currency:     u.Currency,
+		Timezone:     u.Timezone,
+		CreatedAt:    u.CreatedAt,
+		AnonymizedAt: u.AnonymizedAt,
+	}
+}
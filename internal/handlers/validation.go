@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	//The time zones are validated without the system database, the image doesn't have it
+	_ "time/tzdata"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator creates a validator with the custom validations used by the request DTOs
+func NewValidator() (*validator.Validate, error) {
+	v := validator.New()
+
+	//Report fields by their JSON names
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	err := v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		return isDate(fl.Field().String())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.RegisterValidation("tag_name", func(fl validator.FieldLevel) bool {
+		return isTagName(fl.Field().String())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+var monthPattern = regexp.MustCompile(`^(0[1-9]|1[0-2])-(19\d{2}|20\d{2})$`)
+
+// isDate accepts a day as YYYY-MM-DD and a whole month as MM-YYYY
+func isDate(value string) bool {
+	if monthPattern.MatchString(value) {
+		return true
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	return err == nil && date.Year() >= 1900 && date.Year() <= 2099
+}
+
+// isTagName rejects blank names and the ones starting with "!" that marks the excluded tags in the filters
+func isTagName(value string) bool {
+	return strings.TrimSpace(value) != "" && !strings.HasPrefix(value, "!")
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres checks that a connection to the database can be acquired and used
+func Postgres(pool *pgxpool.Pool) Check {
+	return Check{
+		Name: "database",
+		Fn: func(ctx context.Context) (map[string]any, error) {
+			stat := pool.Stat()
+			details := map[string]any{
+				"total_conns":    stat.TotalConns(),
+				"acquired_conns": stat.AcquiredConns(),
+			}
+			return details, pool.Ping(ctx)
+		},
+	}
+}
+
+// Migrations checks that the database schema is clean and at the expected migration version
+func Migrations(pool *pgxpool.Pool, expected uint) Check {
+	return migrations(expected, func(ctx context.Context) (version uint, dirty bool, err error) {
+		err = pool.QueryRow(ctx, migrationVersionQuery).Scan(&version, &dirty)
+		return version, dirty, err
+	})
+}
+
+// SQLite checks that the database file can be used
+func SQLite(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Fn: func(ctx context.Context) (map[string]any, error) {
+			stat := db.Stats()
+			details := map[string]any{
+				"open_conns":   stat.OpenConnections,
+				"in_use_conns": stat.InUse,
+			}
+			return details, db.PingContext(ctx)
+		},
+	}
+}
+
+// SQLiteMigrations is Migrations for the sqlite storage
+func SQLiteMigrations(db *sql.DB, expected uint) Check {
+	return migrations(expected, func(ctx context.Context) (version uint, dirty bool, err error) {
+		err = db.QueryRowContext(ctx, migrationVersionQuery).Scan(&version, &dirty)
+		return version, dirty, err
+	})
+}
+
+const migrationVersionQuery = "SELECT version, dirty FROM schema_migrations LIMIT 1"
+
+func migrations(expected uint, read func(ctx context.Context) (uint, bool, error)) Check {
+	return Check{
+		Name: "migrations",
+		Fn: func(ctx context.Context) (map[string]any, error) {
+			details := map[string]any{"expected_version": expected}
+
+			version, dirty, err := read(ctx)
+			if err != nil {
+				return details, fmt.Errorf("cannot read the migration version: %w", err)
+			}
+			details["version"] = version
+			details["dirty"] = dirty
+
+			if dirty {
+				return details, fmt.Errorf("migration %d is dirty", version)
+			}
+			if version != expected {
+				return details, fmt.Errorf("migration version %d, expected %d", version, expected)
+			}
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOk          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+var ErrShuttingDown = errors.New("the application is shutting down")
+
+// Check is a named dependency check, Fn returns details to report and an error if the dependency is not usable
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) (map[string]any, error)
+}
+
+type CheckResult struct {
+	Status  string         `json:"status"`
+	Latency string         `json:"latency"`
+	Details map[string]any `json:"details,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+type Health struct {
+	checks       []Check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration, checks ...Check) *Health {
+	return &Health{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// SetShuttingDown makes the application permanently not ready
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Ready runs all checks and reports whether the application can serve traffic
+func (h *Health) Ready(ctx context.Context) Report {
+	if h.shuttingDown.Load() {
+		return Report{
+			Status: StatusUnavailable,
+			Error:  ErrShuttingDown.Error(),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report := Report{
+		Status: StatusOk,
+		Checks: make(map[string]CheckResult, len(h.checks)),
+	}
+	for _, check := range h.checks {
+		start := time.Now()
+		details, err := check.Fn(ctx)
+		result := CheckResult{
+			Status:  StatusOk,
+			Latency: time.Since(start).String(),
+			Details: details,
+		}
+		if err != nil {
+			result.Status = StatusUnavailable
+			result.Error = err.Error()
+			report.Status = StatusUnavailable
+		}
+		report.Checks[check.Name] = result
+	}
+
+	return report
+}
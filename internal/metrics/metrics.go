@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "subscription_service"
+
+type Metrics struct {
+	registry      *prometheus.Registry
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	serviceErrors *prometheus.CounterVec
+	budgetAlerts  *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "requests_total",
+				Help:      "Total number of HTTP requests by route, method and status.",
+			},
+			[]string{"method", "route", "status"},
+		),
+		httpDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "request_duration_seconds",
+				Help:      "HTTP request latency by route, method and status.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"method", "route", "status"},
+		),
+		serviceErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "service",
+				Name:      "errors_total",
+				Help:      "Total number of errors returned by service methods.",
+			},
+			[]string{"method", "reason"},
+		),
+		budgetAlerts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "budget",
+				Name:      "alerts_total",
+				Help:      "Total number of budget alerts by the reached percent of the budget.",
+			},
+			[]string{"threshold"},
+		),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.serviceErrors,
+		m.budgetAlerts,
+	)
+
+	return m
+}
+
+// Handler serves the collected metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		//A failed business query must not hide the rest of the metrics
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+func (m *Metrics) IncServiceError(method, reason string) {
+	m.serviceErrors.WithLabelValues(method, reason).Inc()
+}
+
+func (m *Metrics) IncBudgetAlert(threshold int) {
+	m.budgetAlerts.WithLabelValues(strconv.Itoa(threshold)).Inc()
+}
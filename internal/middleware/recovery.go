@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery turns a panic in a handler into a response written by onPanic.
+// It must be registered after Logging so the panic is logged with the request ID.
+func Recovery(log *slog.Logger, onPanic gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				//The client is gone, nothing to respond
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				logger.FromContext(c.Request.Context(), log).Error(
+					"Panic recovered",
+					slog.String("panic", fmt.Sprint(r)),
+					slog.String("stack", string(debug.Stack())),
+				)
+				onPanic(c)
+				c.Abort()
+			}
+		}()
+
+		c.Next()
+	}
+}
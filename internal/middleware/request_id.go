@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIdHeader = "X-Request-ID"
+
+// Incoming IDs that do not match are replaced to keep logs and headers clean
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type requestIdKey struct{}
+
+// RequestId takes the request ID from the X-Request-ID header or generates a new one,
+// returns it to the client and stores it in the request context
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIdHeader)
+		if !requestIdPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(RequestIdHeader, id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIdKey{}, id))
+		c.Next()
+	}
+}
+
+// GetRequestId returns the request ID stored in ctx by the RequestId middleware
+func GetRequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
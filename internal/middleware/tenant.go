@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+const OrganizationIdHeader = "X-Organization-Id"
+
+var (
+	ErrNoOrganization        = errors.New("the X-Organization-Id header is required")
+	ErrIncorrectOrganization = errors.New("the X-Organization-Id header must be a positive integer")
+	ErrOtherOrganization     = errors.New("the organization differs from the X-Organization-Id header")
+)
+
+// Tenant scopes the request context to the organization in the X-Organization-Id header.
+// The header is required unless bypass is set, then the requests without it see the rows of all the organizations.
+// Bypass is meant for the administrative endpoints, the errors are responded by onError.
+func Tenant(bypass bool, onError func(c *gin.Context, err error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(OrganizationIdHeader)
+		if header == "" {
+			if !bypass {
+				onError(c, ErrNoOrganization)
+				c.Abort()
+				return
+			}
+			c.Request = c.Request.WithContext(tenant.Bypass(c.Request.Context()))
+			c.Next()
+			return
+		}
+
+		id, err := strconv.Atoi(header)
+		if err != nil || id <= 0 {
+			onError(c, ErrIncorrectOrganization)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithOrganization(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// TenantPath rejects the requests to an organization other than the one the request is scoped to by Tenant,
+// the ID of the organization is the path parameter param. The IDs that aren't numbers are left to the handler.
+func TenantPath(param string, onError func(c *gin.Context, err error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationId, ok := tenant.Organization(c.Request.Context())
+		id, err := strconv.Atoi(c.Param(param))
+		if err == nil && (!ok || id != organizationId) {
+			onError(c, ErrOtherOrganization)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package migrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const versionLayout = "20060102150405"
+
+var namePattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes empty up and down migration files named like the existing ones into dir
+func Create(dir, name string, now time.Time) (up, down string, err error) {
+	name = strings.Trim(namePattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", fmt.Errorf("migrator:Create - the migration name is empty")
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%s_%s", now.UTC().Format(versionLayout), name))
+	up = base + ".up.sql"
+	down = base + ".down.sql"
+
+	for _, file := range []string{up, down} {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("migrator:Create:OpenFile - %w", err)
+		}
+		f.Close()
+	}
+
+	return up, down, nil
+}
@@ -0,0 +1,203 @@
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/config"
+	"github.com/Estriper0/subscription_service/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+type Migrator struct {
+	m      *migrate.Migrate
+	source fs.FS
+}
+
+type Status struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+	Pending []uint
+}
+
+// New creates a migrator for the embedded migrations of the storage.
+// dsn is a postgres:// URL for Postgres and a file path for SQLite.
+func New(storage, dsn string, logger *slog.Logger) (*Migrator, error) {
+	fsys, err := Source(storage)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbURL string
+	switch storage {
+	case config.StoragePostgres:
+		//The pgx driver is registered under its own scheme
+		dbURL = strings.Replace(dsn, "postgres://", "pgx5://", 1)
+	case config.StorageSQLite:
+		//The database file is created on first use, but not its directory
+		err = os.MkdirAll(filepath.Dir(dsn), 0o755)
+		if err != nil {
+			return nil, fmt.Errorf("migrator:New:MkdirAll - %w", err)
+		}
+		dbURL = "sqlite://" + dsn
+	}
+
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrator:New:iofs.New - %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("migrator:New:NewWithSourceInstance - %w", err)
+	}
+	if logger != nil {
+		m.Log = &migrateLogger{logger: logger}
+	}
+
+	return &Migrator{
+		m:      m,
+		source: fsys,
+	}, nil
+}
+
+// Source returns the embedded migrations of the storage
+func Source(storage string) (fs.FS, error) {
+	switch storage {
+	case config.StoragePostgres:
+		return migrations.Postgres, nil
+	case config.StorageSQLite:
+		return migrations.SQLite, nil
+	}
+	return nil, fmt.Errorf("migrator:Source - storage %q has no migrations", storage)
+}
+
+// Up applies all pending migrations, it is not an error if there are none
+func (m *Migrator) Up() error {
+	err := m.m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator:Migrator.Up - %w", err)
+	}
+	return nil
+}
+
+// Down rolls back n applied migrations
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("migrator:Migrator.Down - the number of migrations must be positive, got %d", n)
+	}
+	err := m.m.Steps(-n)
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator:Migrator.Down - %w", err)
+	}
+	return nil
+}
+
+// Goto migrates up or down to the given version
+func (m *Migrator) Goto(version uint) error {
+	err := m.m.Migrate(version)
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator:Migrator.Goto - %w", err)
+	}
+	return nil
+}
+
+// Force sets the version without running migrations and clears the dirty flag, -1 means no version
+func (m *Migrator) Force(version int) error {
+	err := m.m.Force(version)
+	if err != nil {
+		return fmt.Errorf("migrator:Migrator.Force - %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) Status() (*Status, error) {
+	versions, err := Versions(m.source)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	if len(versions) > 0 {
+		status.Latest = versions[len(versions)-1]
+	}
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("migrator:Migrator.Status:Version - %w", err)
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	for _, v := range versions {
+		if v > version {
+			status.Pending = append(status.Pending, v)
+		}
+	}
+
+	return status, nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Versions returns the sorted versions of the migrations in fsys
+func Versions(fsys fs.FS) ([]uint, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrator:Versions:ReadDir - %w", err)
+	}
+
+	var versions []uint
+	for _, entry := range entries {
+		migration, err := source.DefaultParse(entry.Name())
+		if err != nil || migration.Direction != source.Up {
+			continue
+		}
+		versions = append(versions, migration.Version)
+	}
+	slices.Sort(versions)
+
+	return versions, nil
+}
+
+// LatestVersion returns the version of the newest embedded migration of the storage
+func LatestVersion(storage string) (uint, error) {
+	fsys, err := Source(storage)
+	if err != nil {
+		return 0, err
+	}
+	versions, err := Versions(fsys)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	return versions[len(versions)-1], nil
+}
+
+type migrateLogger struct {
+	logger *slog.Logger
+}
+
+func (l *migrateLogger) Printf(format string, v ...any) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l *migrateLogger) Verbose() bool {
+	return false
+}
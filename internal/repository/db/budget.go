@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *SubscriptionRepo) CreateBudget(ctx context.Context, b *models.BudgetCreate) (int, error) {
+	query := `
+		INSERT INTO budget (user_id, amount, category_id, service_id, organization_id)
+			VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	var id int
+
+	//The budget belongs to the tenant it is created in
+	err := r.db.QueryRow(ctx, query, b.UserId, b.Amount, b.CategoryId, b.ServiceId, organizationId(ctx)).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == repository.PgCodeForeignKeyError {
+			return 0, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateBudget:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetBudget(ctx context.Context, id int) (*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		SELECT id, user_id, amount, category_id, service_id, organization_id
+			FROM budget
+		WHERE id = $1` + condition
+	var budget models.Budget
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&budget.Id, &budget.UserId, &budget.Amount, &budget.CategoryId, &budget.ServiceId, &budget.OrganizationId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetBudget:QueryRow - %s", err.Error())
+	}
+
+	return &budget, nil
+}
+
+func (r *SubscriptionRepo) GetBudgets(ctx context.Context, userId uuid.UUID) ([]*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{userId}, "organization_id")
+	query := `
+		SELECT id, user_id, amount, category_id, service_id, organization_id
+			FROM budget
+		WHERE user_id = $1` + condition + `
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetBudgets:Query - %s", err.Error())
+	}
+
+	var budgets []*models.Budget
+	for rows.Next() {
+		var budget models.Budget
+		err := rows.Scan(&budget.Id, &budget.UserId, &budget.Amount, &budget.CategoryId, &budget.ServiceId, &budget.OrganizationId)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetBudgets:Scan - %s", err.Error())
+		}
+		budgets = append(budgets, &budget)
+	}
+
+	return budgets, nil
+}
+
+func (r *SubscriptionRepo) UpdateBudget(ctx context.Context, b *models.BudgetUpdate) (*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{b.Amount, b.Id}, "organization_id")
+	query := `
+		UPDATE budget
+		SET
+			amount = $1
+		WHERE
+			id = $2` + condition + `
+		RETURNING id, user_id, amount, category_id, service_id, organization_id
+	`
+	var budget models.Budget
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&budget.Id, &budget.UserId, &budget.Amount, &budget.CategoryId, &budget.ServiceId, &budget.OrganizationId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateBudget:QueryRow - %s", err.Error())
+	}
+
+	return &budget, nil
+}
+
+func (r *SubscriptionRepo) DeleteBudget(ctx context.Context, id int) (*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		DELETE FROM budget
+			WHERE id = $1` + condition + `
+		RETURNING id, user_id, amount, category_id, service_id, organization_id
+	`
+	var budget models.Budget
+
+	//The alerts are deleted with the budget
+	err := r.db.QueryRow(ctx, query, args...).Scan(&budget.Id, &budget.UserId, &budget.Amount, &budget.CategoryId, &budget.ServiceId, &budget.OrganizationId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteBudget:QueryRow - %s", err.Error())
+	}
+
+	return &budget, nil
+}
+
+func (r *SubscriptionRepo) CreateBudgetAlert(ctx context.Context, a *models.BudgetAlertCreate) (*models.BudgetAlert, error) {
+	query := `
+		INSERT INTO budget_alert (budget_id, month, threshold, spent, amount, alerted_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (budget_id, month, threshold) DO NOTHING
+		RETURNING id
+	`
+	alert := &models.BudgetAlert{
+		BudgetId:  a.BudgetId,
+		Month:     a.Month,
+		Threshold: a.Threshold,
+		Spent:     a.Spent,
+		Amount:    a.Amount,
+		AlertedAt: a.AlertedAt.UTC(),
+	}
+
+	err := r.db.QueryRow(ctx, query, a.BudgetId, a.Month, a.Threshold, a.Spent, a.Amount, alert.AlertedAt).Scan(&alert.Id)
+	if err != nil {
+		//Nothing is returned if the alert has already been raised
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrAlreadyExists
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == repository.PgCodeForeignKeyError {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.CreateBudgetAlert:QueryRow - %s", err.Error())
+	}
+
+	return alert, nil
+}
+
+func (r *SubscriptionRepo) GetBudgetAlerts(ctx context.Context, userId uuid.UUID) ([]*models.BudgetAlert, error) {
+	args, condition := tenantCondition(ctx, []any{userId}, "b.organization_id")
+	query := `
+		SELECT a.id, a.budget_id, a.month, a.threshold, a.spent, a.amount, a.alerted_at
+			FROM budget_alert a
+			JOIN budget b ON b.id = a.budget_id
+		WHERE b.user_id = $1` + condition + `
+		ORDER BY a.id
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetBudgetAlerts:Query - %s", err.Error())
+	}
+
+	var alerts []*models.BudgetAlert
+	for rows.Next() {
+		var alert models.BudgetAlert
+		err := rows.Scan(&alert.Id, &alert.BudgetId, &alert.Month, &alert.Threshold, &alert.Spent, &alert.Amount, &alert.AlertedAt)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetBudgetAlerts:Scan - %s", err.Error())
+		}
+		alert.AlertedAt = alert.AlertedAt.UTC()
+		alerts = append(alerts, &alert)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,390 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *SubscriptionRepo) CreateService(ctx context.Context, s *models.ServiceCreate) (int, error) {
+	query := `
+		INSERT INTO service (name, category_id, website, logo_url)
+			VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateService:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, query, s.Name, s.CategoryId, s.Website, s.LogoUrl).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateService:QueryRow - %s", err.Error())
+	}
+
+	err = insertAliases(ctx, tx, id, s.Aliases)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateService:insertAliases - %s", err.Error())
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateService:Commit - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetService(ctx context.Context, id int) (*models.Service, error) {
+	query := `
+		SELECT id, name, category_id, website, logo_url,
+			ARRAY(SELECT alias FROM service_alias WHERE service_id = service.id ORDER BY alias COLLATE "C")
+			FROM service
+		WHERE id = $1
+	`
+	var service models.Service
+
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&service.Id,
+		&service.Name,
+		&service.CategoryId,
+		&service.Website,
+		&service.LogoUrl,
+		&service.Aliases,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetService:QueryRow - %s", err.Error())
+	}
+
+	return &service, nil
+}
+
+func (r *SubscriptionRepo) FindService(ctx context.Context, name string) (*models.Service, error) {
+	query := `
+		SELECT id
+			FROM service
+		WHERE lower(name) = lower($1)
+			OR id IN (SELECT service_id FROM service_alias WHERE lower(alias) = lower($1))
+		ORDER BY id
+		LIMIT 1
+	`
+	var id int
+
+	err := r.db.QueryRow(ctx, query, name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.FindService:QueryRow - %s", err.Error())
+	}
+
+	return r.GetService(ctx, id)
+}
+
+func (r *SubscriptionRepo) GetServices(ctx context.Context, offset, limit int) ([]*models.Service, error) {
+	query := `
+		SELECT id, name, category_id, website, logo_url,
+			ARRAY(SELECT alias FROM service_alias WHERE service_id = service.id ORDER BY alias COLLATE "C")
+			FROM service
+		ORDER BY id
+			OFFSET $1
+			LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetServices:Query - %s", err.Error())
+	}
+
+	var services []*models.Service
+	for rows.Next() {
+		var service models.Service
+		err := rows.Scan(
+			&service.Id,
+			&service.Name,
+			&service.CategoryId,
+			&service.Website,
+			&service.LogoUrl,
+			&service.Aliases,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetServices:Scan - %s", err.Error())
+		}
+		services = append(services, &service)
+	}
+
+	return services, nil
+}
+
+func (r *SubscriptionRepo) UpdateService(ctx context.Context, s *models.ServiceUpdate) (*models.Service, error) {
+	query := `
+		UPDATE service
+		SET
+			name = COALESCE($1, name),
+			category_id = COALESCE($2, category_id),
+			website = COALESCE($3, website),
+			logo_url = COALESCE($4, logo_url)
+		WHERE
+			id = $5
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateService:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, s.Name, s.CategoryId, s.Website, s.LogoUrl, s.Id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateService:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	if s.Aliases != nil {
+		_, err = tx.Exec(ctx, "DELETE FROM service_alias WHERE service_id = $1", s.Id)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.UpdateService:Exec - %s", err.Error())
+		}
+		err = insertAliases(ctx, tx, s.Id, s.Aliases)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return nil, repository.ErrAlreadyExists
+			}
+			return nil, fmt.Errorf("db:SubscriptionRepo.UpdateService:insertAliases - %s", err.Error())
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateService:Commit - %s", err.Error())
+	}
+
+	return r.GetService(ctx, s.Id)
+}
+
+func (r *SubscriptionRepo) DeleteService(ctx context.Context, id int) (*models.Service, error) {
+	query := `
+		DELETE FROM service
+			WHERE id = $1
+		RETURNING id, name, category_id, website, logo_url
+	`
+	//The aliases are deleted with the service
+	service, err := r.GetService(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.QueryRow(ctx, query, id).Scan(
+		&service.Id,
+		&service.Name,
+		&service.CategoryId,
+		&service.Website,
+		&service.LogoUrl,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteService:QueryRow - %s", err.Error())
+	}
+
+	return service, nil
+}
+
+func (r *SubscriptionRepo) LinkSubscriptions(ctx context.Context, serviceId int, names []string) (int, error) {
+	lowered := make([]string, 0, len(names))
+	for _, name := range names {
+		lowered = append(lowered, strings.ToLower(name))
+	}
+	args, condition := tenantCondition(ctx, []any{serviceId, lowered}, "organization_id")
+	query := `
+		UPDATE subscription
+		SET
+			service_id = $1
+		WHERE
+			service_id IS NULL
+			AND lower(service_name) = ANY($2)` + condition
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("db:SubscriptionRepo.LinkSubscriptions:Exec - %s", err.Error())
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *SubscriptionRepo) CreatePlan(ctx context.Context, p *models.PlanCreate) (int, error) {
+	query := `
+		INSERT INTO plan (service_id, name, price, billing_period)
+			VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRow(ctx, query, p.ServiceId, p.Name, p.Price, p.BillingPeriod).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == repository.PgCodeForeignKeyError {
+			return 0, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreatePlan:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetPlan(ctx context.Context, id int) (*models.Plan, error) {
+	query := `
+		SELECT id, service_id, name, price, billing_period
+			FROM plan
+		WHERE id = $1
+	`
+	var plan models.Plan
+
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&plan.Id,
+		&plan.ServiceId,
+		&plan.Name,
+		&plan.Price,
+		&plan.BillingPeriod,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetPlan:QueryRow - %s", err.Error())
+	}
+
+	return &plan, nil
+}
+
+func (r *SubscriptionRepo) GetPlans(ctx context.Context, serviceIds []int) ([]*models.Plan, error) {
+	query := `
+		SELECT id, service_id, name, price, billing_period
+			FROM plan
+		WHERE service_id = ANY($1)
+		ORDER BY service_id, id
+	`
+	if len(serviceIds) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(ctx, query, serviceIds)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetPlans:Query - %s", err.Error())
+	}
+
+	var plans []*models.Plan
+	for rows.Next() {
+		var plan models.Plan
+		err := rows.Scan(
+			&plan.Id,
+			&plan.ServiceId,
+			&plan.Name,
+			&plan.Price,
+			&plan.BillingPeriod,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetPlans:Scan - %s", err.Error())
+		}
+		plans = append(plans, &plan)
+	}
+
+	return plans, nil
+}
+
+func (r *SubscriptionRepo) UpdatePlan(ctx context.Context, p *models.PlanUpdate) (*models.Plan, error) {
+	query := `
+		UPDATE plan
+		SET
+			name = COALESCE($1, name),
+			price = COALESCE($2, price),
+			billing_period = COALESCE($3, billing_period)
+		WHERE
+			id = $4
+		RETURNING id, service_id, name, price, billing_period
+	`
+	var plan models.Plan
+
+	err := r.db.QueryRow(ctx, query, p.Name, p.Price, p.BillingPeriod, p.Id).Scan(
+		&plan.Id,
+		&plan.ServiceId,
+		&plan.Name,
+		&plan.Price,
+		&plan.BillingPeriod,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdatePlan:QueryRow - %s", err.Error())
+	}
+
+	return &plan, nil
+}
+
+func (r *SubscriptionRepo) DeletePlan(ctx context.Context, id int) (*models.Plan, error) {
+	query := `
+		DELETE FROM plan
+			WHERE id = $1
+		RETURNING id, service_id, name, price, billing_period
+	`
+	var plan models.Plan
+
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&plan.Id,
+		&plan.ServiceId,
+		&plan.Name,
+		&plan.Price,
+		&plan.BillingPeriod,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeletePlan:QueryRow - %s", err.Error())
+	}
+
+	return &plan, nil
+}
+
+func insertAliases(ctx context.Context, tx pgx.Tx, serviceId int, aliases []string) error {
+	for _, alias := range aliases {
+		_, err := tx.Exec(ctx, "INSERT INTO service_alias (service_id, alias) VALUES ($1, $2)", serviceId, alias)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == repository.PgCodeUniqueError
+}
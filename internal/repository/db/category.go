@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *SubscriptionRepo) CreateCategory(ctx context.Context, c *models.CategoryCreate) (int, error) {
+	query := `
+		INSERT INTO category (name, parent_id, user_id)
+			VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRow(ctx, query, c.Name, c.ParentId, c.UserId).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == repository.PgCodeForeignKeyError {
+			return 0, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateCategory:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetCategory(ctx context.Context, id int) (*models.Category, error) {
+	query := `
+		SELECT id, name, parent_id, user_id
+			FROM category
+		WHERE id = $1
+	`
+	var category models.Category
+
+	err := r.db.QueryRow(ctx, query, id).Scan(&category.Id, &category.Name, &category.ParentId, &category.UserId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetCategory:QueryRow - %s", err.Error())
+	}
+
+	return &category, nil
+}
+
+func (r *SubscriptionRepo) GetCategories(ctx context.Context, userId *uuid.UUID) ([]*models.Category, error) {
+	query := `
+		SELECT id, name, parent_id, user_id
+			FROM category
+	`
+	var args []any
+	if userId != nil {
+		args = append(args, *userId)
+		query += " WHERE user_id IS NULL OR user_id = $1"
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetCategories:Query - %s", err.Error())
+	}
+
+	var categories []*models.Category
+	for rows.Next() {
+		var category models.Category
+		err := rows.Scan(&category.Id, &category.Name, &category.ParentId, &category.UserId)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetCategories:Scan - %s", err.Error())
+		}
+		categories = append(categories, &category)
+	}
+
+	return categories, nil
+}
+
+func (r *SubscriptionRepo) UpdateCategory(ctx context.Context, c *models.CategoryUpdate) (*models.Category, error) {
+	query := `
+		UPDATE category
+		SET
+			name = COALESCE($1, name),
+			parent_id = COALESCE($2, parent_id)
+		WHERE
+			id = $3
+		RETURNING id, name, parent_id, user_id
+	`
+	var category models.Category
+
+	err := r.db.QueryRow(ctx, query, c.Name, c.ParentId, c.Id).Scan(&category.Id, &category.Name, &category.ParentId, &category.UserId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == repository.PgCodeForeignKeyError {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateCategory:QueryRow - %s", err.Error())
+	}
+
+	return &category, nil
+}
+
+func (r *SubscriptionRepo) DeleteCategory(ctx context.Context, id int) (*models.Category, error) {
+	query := `
+		DELETE FROM category
+			WHERE id = $1
+		RETURNING id, name, parent_id, user_id
+	`
+	var category models.Category
+
+	//The subcategories are deleted with the category
+	err := r.db.QueryRow(ctx, query, id).Scan(&category.Id, &category.Name, &category.ParentId, &category.UserId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteCategory:QueryRow - %s", err.Error())
+	}
+
+	return &category, nil
+}
+
+func (r *SubscriptionRepo) GetServiceCategories(ctx context.Context, serviceIds []int) ([]*models.ServiceCategory, error) {
+	query := `
+		SELECT id, category_id
+			FROM service
+		WHERE id = ANY($1)
+			AND category_id IS NOT NULL
+		ORDER BY id
+	`
+	rows, err := r.db.Query(ctx, query, serviceIds)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetServiceCategories:Query - %s", err.Error())
+	}
+
+	var categories []*models.ServiceCategory
+	for rows.Next() {
+		var category models.ServiceCategory
+		err := rows.Scan(&category.ServiceId, &category.CategoryId)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetServiceCategories:Scan - %s", err.Error())
+		}
+		categories = append(categories, &category)
+	}
+
+	return categories, nil
+}
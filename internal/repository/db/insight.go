@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) DismissInsight(ctx context.Context, d *models.InsightDismissal) error {
+	query := `
+		INSERT INTO insight_dismissal (user_id, insight_id, dismissed_at, organization_id)
+			VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id, user_id, insight_id) DO NOTHING
+	`
+
+	//The first dismissal in the tenant is kept
+	_, err := r.db.Exec(ctx, query, d.UserId, d.InsightId, d.DismissedAt.UTC(), organizationId(ctx))
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.DismissInsight:Exec - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetInsightDismissals(ctx context.Context, userId uuid.UUID) ([]*models.InsightDismissal, error) {
+	args, condition := tenantCondition(ctx, []any{userId}, "organization_id")
+	query := `
+		SELECT user_id, insight_id, dismissed_at, organization_id
+			FROM insight_dismissal
+		WHERE user_id = $1` + condition + `
+		ORDER BY insight_id COLLATE "C"
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetInsightDismissals:Query - %s", err.Error())
+	}
+
+	var dismissals []*models.InsightDismissal
+	for rows.Next() {
+		var dismissal models.InsightDismissal
+		err := rows.Scan(&dismissal.UserId, &dismissal.InsightId, &dismissal.DismissedAt, &dismissal.OrganizationId)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetInsightDismissals:Scan - %s", err.Error())
+		}
+		dismissal.DismissedAt = dismissal.DismissedAt.UTC()
+		dismissals = append(dismissals, &dismissal)
+	}
+
+	return dismissals, nil
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+func (r *SubscriptionRepo) GetServiceNames(ctx context.Context) ([]*models.ServiceName, error) {
+	args, condition := tenantCondition(ctx, nil, "organization_id")
+	query := `
+		SELECT service_name, count(*)
+			FROM subscription
+		WHERE TRUE` + condition + `
+		GROUP BY service_name
+		ORDER BY service_name COLLATE "C"
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetServiceNames:Query - %s", err.Error())
+	}
+
+	var names []*models.ServiceName
+	for rows.Next() {
+		var name models.ServiceName
+		err := rows.Scan(&name.Name, &name.Subscriptions)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetServiceNames:Scan - %s", err.Error())
+		}
+		names = append(names, &name)
+	}
+
+	return names, nil
+}
+
+// SimilarNames compares the names with similarity() of pg_trgm
+func (r *SubscriptionRepo) SimilarNames(ctx context.Context, names []string, threshold float64) ([]*models.NamePair, error) {
+	query := `
+		WITH names AS (
+			SELECT DISTINCT unnest($1::text[]) AS name
+		)
+		SELECT a.name, b.name, similarity(a.name, b.name)::float8
+			FROM names a
+			JOIN names b ON a.name COLLATE "C" < b.name COLLATE "C"
+		WHERE similarity(a.name, b.name) >= $2
+		ORDER BY a.name COLLATE "C", b.name COLLATE "C"
+	`
+	rows, err := r.db.Query(ctx, query, names, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.SimilarNames:Query - %s", err.Error())
+	}
+
+	var pairs []*models.NamePair
+	for rows.Next() {
+		var pair models.NamePair
+		err := rows.Scan(&pair.A, &pair.B, &pair.Similarity)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.SimilarNames:Scan - %s", err.Error())
+		}
+		pairs = append(pairs, &pair)
+	}
+
+	return pairs, nil
+}
+
+func (r *SubscriptionRepo) MergeServiceNames(ctx context.Context, m *models.NameMerge) (*models.NameMergeAudit, error) {
+	updateArgs, condition := tenantCondition(ctx, []any{m.Canonical, m.ServiceId, m.Names}, "organization_id")
+	updateQuery := `
+		UPDATE subscription
+		SET
+			service_name = $1,
+			service_id = COALESCE($2, service_id),
+			plan_id = CASE WHEN $2::integer IS NULL OR service_id = $2 THEN plan_id END
+		WHERE service_name = ANY($3)` + condition
+	auditQuery := `
+		INSERT INTO service_name_merge (canonical_name, merged_names, subscription_count, merged_at, organization_id)
+			VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.MergeServiceNames:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, updateQuery, updateArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.MergeServiceNames:Exec - %s", err.Error())
+	}
+
+	audit := &models.NameMergeAudit{
+		Canonical:     m.Canonical,
+		Names:         m.Names,
+		Subscriptions: int(tag.RowsAffected()),
+		MergedAt:      m.MergedAt.UTC(),
+		//Outside of a tenant the merge renamed the subscriptions of all the organizations
+		OrganizationId: organizationId(ctx),
+	}
+	err = tx.QueryRow(ctx, auditQuery, audit.Canonical, audit.Names, audit.Subscriptions, audit.MergedAt, audit.OrganizationId).Scan(&audit.Id)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.MergeServiceNames:QueryRow - %s", err.Error())
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.MergeServiceNames:Commit - %s", err.Error())
+	}
+
+	return audit, nil
+}
+
+func (r *SubscriptionRepo) GetNameMerges(ctx context.Context, offset, limit int) ([]*models.NameMergeAudit, error) {
+	args, condition := tenantCondition(ctx, []any{offset, limit}, "organization_id")
+	query := `
+		SELECT id, canonical_name, merged_names, subscription_count, merged_at, organization_id
+			FROM service_name_merge
+		WHERE TRUE` + condition + `
+		ORDER BY id
+			OFFSET $1
+			LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetNameMerges:Query - %s", err.Error())
+	}
+
+	var merges []*models.NameMergeAudit
+	for rows.Next() {
+		var merge models.NameMergeAudit
+		err := rows.Scan(&merge.Id, &merge.Canonical, &merge.Names, &merge.Subscriptions, &merge.MergedAt, &merge.OrganizationId)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetNameMerges:Scan - %s", err.Error())
+		}
+		merge.MergedAt = merge.MergedAt.UTC()
+		merges = append(merges, &merge)
+	}
+
+	return merges, nil
+}
@@ -0,0 +1,296 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/Estriper0/subscription_service/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// BypassRole is the role the row level security policies let see the rows of all the organizations.
+// The policies check the current role, so the application role only sees them after switching to it.
+const BypassRole = "tenant_bypass"
+
+// TenantSetting sets the organization of the query context for the row level security policies,
+// without it the policies hide all the rows.
+// The policies are a second line of defense, every query is scoped by the repository as well.
+var TenantSetting = postgres.Setting{
+	Name: "app.organization_id",
+	Value: func(ctx context.Context) string {
+		id, ok := tenant.Organization(ctx)
+		if !ok {
+			return ""
+		}
+		return strconv.Itoa(id)
+	},
+}
+
+// BypassSetting switches to BypassRole for the contexts bypassing the tenant and back to the login role
+var BypassSetting = postgres.Setting{
+	Name: "role",
+	Value: func(ctx context.Context) string {
+		if tenant.Bypassed(ctx) {
+			return BypassRole
+		}
+		return "none"
+	},
+}
+
+func (r *SubscriptionRepo) CreateOrganization(ctx context.Context, o *models.OrganizationCreate) (int, error) {
+	query := `
+		INSERT INTO organization (name, created_at)
+			VALUES ($1, $2)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRow(ctx, query, o.Name, o.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateOrganization:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetOrganization(ctx context.Context, id int) (*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "id")
+	query := `
+		SELECT id, name, created_at
+			FROM organization
+		WHERE id = $1` + condition
+	var organization models.Organization
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&organization.Id, &organization.Name, &organization.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetOrganization:QueryRow - %s", err.Error())
+	}
+	organization.CreatedAt = organization.CreatedAt.UTC()
+
+	return &organization, nil
+}
+
+func (r *SubscriptionRepo) GetOrganizations(ctx context.Context, userId uuid.UUID) ([]*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{userId}, "o.id")
+	query := `
+		SELECT o.id, o.name, o.created_at
+			FROM organization o
+			JOIN organization_member m ON m.organization_id = o.id
+		WHERE m.user_id = $1` + condition + `
+		ORDER BY o.id
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetOrganizations:Query - %s", err.Error())
+	}
+
+	var organizations []*models.Organization
+	for rows.Next() {
+		var organization models.Organization
+		err := rows.Scan(&organization.Id, &organization.Name, &organization.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetOrganizations:Scan - %s", err.Error())
+		}
+		organization.CreatedAt = organization.CreatedAt.UTC()
+		organizations = append(organizations, &organization)
+	}
+
+	return organizations, nil
+}
+
+func (r *SubscriptionRepo) UpdateOrganization(ctx context.Context, o *models.OrganizationUpdate) (*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{o.Name, o.Id}, "id")
+	query := `
+		UPDATE organization
+		SET
+			name = $1
+		WHERE
+			id = $2` + condition + `
+		RETURNING id, name, created_at
+	`
+	var organization models.Organization
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&organization.Id, &organization.Name, &organization.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateOrganization:QueryRow - %s", err.Error())
+	}
+	organization.CreatedAt = organization.CreatedAt.UTC()
+
+	return &organization, nil
+}
+
+func (r *SubscriptionRepo) DeleteOrganization(ctx context.Context, id int) (*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "id")
+	query := `
+		DELETE FROM organization
+			WHERE id = $1` + condition + `
+		RETURNING id, name, created_at
+	`
+	var organization models.Organization
+
+	//The members are deleted with the organization, the subscriptions restrict the deletion
+	err := r.db.QueryRow(ctx, query, args...).Scan(&organization.Id, &organization.Name, &organization.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == repository.PgCodeForeignKeyError {
+			return nil, repository.ErrInUse
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteOrganization:QueryRow - %s", err.Error())
+	}
+	organization.CreatedAt = organization.CreatedAt.UTC()
+
+	return &organization, nil
+}
+
+func (r *SubscriptionRepo) AddMember(ctx context.Context, m *models.OrganizationMember) error {
+	args, condition := tenantCondition(ctx, []any{m.OrganizationId, m.UserId, m.JoinedAt.UTC()}, "id")
+	query := `
+		INSERT INTO organization_member (organization_id, user_id, joined_at)
+			SELECT id, $2, $3
+				FROM organization
+			WHERE id = $1` + condition + `
+		ON CONFLICT (organization_id, user_id) DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.AddMember:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	//Nothing is inserted for a missing organization or a user who is already a member
+	existsArgs, condition := tenantCondition(ctx, []any{m.OrganizationId}, "id")
+	existsQuery := `
+		SELECT EXISTS (SELECT 1 FROM organization WHERE id = $1` + condition + `)
+	`
+	var exists bool
+
+	err = r.db.QueryRow(ctx, existsQuery, existsArgs...).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.AddMember:QueryRow - %s", err.Error())
+	}
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) RemoveMember(ctx context.Context, organizationId int, userId uuid.UUID) error {
+	args, condition := tenantCondition(ctx, []any{organizationId, userId}, "organization_id")
+	query := `
+		DELETE FROM organization_member
+			WHERE organization_id = $1
+			AND user_id = $2` + condition
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.RemoveMember:Exec - %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetMembers(ctx context.Context, organizationId int) ([]*models.OrganizationMember, error) {
+	args, condition := tenantCondition(ctx, []any{organizationId}, "organization_id")
+	query := `
+		SELECT organization_id, user_id, joined_at
+			FROM organization_member
+		WHERE organization_id = $1` + condition + `
+		ORDER BY user_id
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetMembers:Query - %s", err.Error())
+	}
+
+	var members []*models.OrganizationMember
+	for rows.Next() {
+		var member models.OrganizationMember
+		err := rows.Scan(&member.OrganizationId, &member.UserId, &member.JoinedAt)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetMembers:Scan - %s", err.Error())
+		}
+		member.JoinedAt = member.JoinedAt.UTC()
+		members = append(members, &member)
+	}
+
+	return members, nil
+}
+
+func (r *SubscriptionRepo) IsMember(ctx context.Context, organizationId int, userId uuid.UUID) (bool, error) {
+	args, condition := tenantCondition(ctx, []any{organizationId, userId}, "organization_id")
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+				FROM organization_member
+			WHERE organization_id = $1
+				AND user_id = $2` + condition + `
+		)
+	`
+	var member bool
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&member)
+	if err != nil {
+		return false, fmt.Errorf("db:SubscriptionRepo.IsMember:QueryRow - %s", err.Error())
+	}
+
+	return member, nil
+}
+
+// organizationId returns the organization of ctx to store with a new subscription
+func organizationId(ctx context.Context) sql.NullInt32 {
+	id, ok := tenant.Organization(ctx)
+	return sql.NullInt32{Int32: int32(id), Valid: ok}
+}
+
+// tenantCondition appends the organization of ctx to the query arguments and returns the condition
+// matching its rows by the column. Outside of an organization no rows match unless ctx bypasses the tenant.
+func tenantCondition(ctx context.Context, args []any, column string) ([]any, string) {
+	id, ok := tenant.Organization(ctx)
+	if !ok {
+		return args, bypassCondition(ctx)
+	}
+	args = append(args, id)
+	return args, fmt.Sprintf(" AND %s = $%d", column, len(args))
+}
+
+// tenantSubscriptionCondition is tenantCondition for the tables referencing the subscription by the column
+func tenantSubscriptionCondition(ctx context.Context, args []any, column string) ([]any, string) {
+	id, ok := tenant.Organization(ctx)
+	if !ok {
+		return args, bypassCondition(ctx)
+	}
+	args = append(args, id)
+	return args, fmt.Sprintf(" AND %s IN (SELECT id FROM subscription WHERE organization_id = $%d)", column, len(args))
+}
+
+// bypassCondition is the condition outside of an organization
+func bypassCondition(ctx context.Context) string {
+	if tenant.Bypassed(ctx) {
+		return ""
+	}
+	return " AND FALSE"
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *SubscriptionRepo) CreatePause(ctx context.Context, p *models.PauseCreate) (int, error) {
+	args, condition := tenantCondition(ctx, []any{p.SubscriptionId, p.StartDate, p.EndDate}, "organization_id")
+	query := `
+		INSERT INTO subscription_pause (subscription_id, start_date, end_date)
+			SELECT $1, $2, $3
+			WHERE EXISTS (SELECT 1 FROM subscription WHERE id = $1` + condition + `)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == repository.PgCodeForeignKeyError {
+				return 0, repository.ErrNotFound
+			}
+			if pgErr.Code == repository.PgCodeConstrainError && pgErr.ConstraintName == repository.ConstraintPausePeriod {
+				return 0, repository.ErrIncorrectTime
+			}
+			if pgErr.Code == repository.PgCodeExclusionError && pgErr.ConstraintName == repository.ConstraintPauseOverlap {
+				return 0, repository.ErrOverlap
+			}
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreatePause:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) EndPause(ctx context.Context, id int, endDate time.Time) (*models.Pause, error) {
+	args, condition := tenantSubscriptionCondition(ctx, []any{endDate, id}, "subscription_id")
+	query := `
+		UPDATE subscription_pause
+		SET
+			end_date = $1
+		WHERE
+			id = $2` + condition + `
+		RETURNING id, subscription_id, start_date, end_date
+	`
+	var pause models.Pause
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(
+		&pause.Id,
+		&pause.SubscriptionId,
+		&pause.StartDate,
+		&pause.EndDate,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == repository.PgCodeConstrainError && pgErr.ConstraintName == repository.ConstraintPausePeriod {
+				return nil, repository.ErrIncorrectTime
+			}
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.EndPause:QueryRow - %s", err.Error())
+	}
+
+	return &pause, nil
+}
+
+func (r *SubscriptionRepo) GetPauses(ctx context.Context, subscriptionIds []int) ([]*models.Pause, error) {
+	args, condition := tenantSubscriptionCondition(ctx, []any{subscriptionIds}, "subscription_id")
+	query := `
+		SELECT id, subscription_id, start_date, end_date
+			FROM subscription_pause
+		WHERE subscription_id = ANY($1)` + condition + `
+		ORDER BY subscription_id, start_date, id
+	`
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetPauses:Query - %s", err.Error())
+	}
+
+	var pauses []*models.Pause
+	for rows.Next() {
+		var pause models.Pause
+		err := rows.Scan(
+			&pause.Id,
+			&pause.SubscriptionId,
+			&pause.StartDate,
+			&pause.EndDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetPauses:Scan - %s", err.Error())
+		}
+		pauses = append(pauses, &pause)
+	}
+
+	return pauses, nil
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *SubscriptionRepo) CreatePriceChange(ctx context.Context, p *models.PriceChangeCreate) (int, error) {
+	args, condition := tenantCondition(ctx, []any{p.SubscriptionId, p.EffectiveDate, p.Price}, "organization_id")
+	query := `
+		INSERT INTO subscription_price_change (subscription_id, effective_date, price)
+			SELECT $1, $2, $3
+			WHERE EXISTS (SELECT 1 FROM subscription WHERE id = $1` + condition + `)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == repository.PgCodeForeignKeyError {
+				return 0, repository.ErrNotFound
+			}
+			if pgErr.Code == repository.PgCodeUniqueError && pgErr.ConstraintName == repository.ConstraintPriceChangeDate {
+				return 0, repository.ErrAlreadyExists
+			}
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreatePriceChange:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetPriceChanges(ctx context.Context, subscriptionIds []int) ([]*models.PriceChange, error) {
+	args, condition := tenantSubscriptionCondition(ctx, []any{subscriptionIds}, "subscription_id")
+	query := `
+		SELECT id, subscription_id, effective_date, price
+			FROM subscription_price_change
+		WHERE subscription_id = ANY($1)` + condition + `
+		ORDER BY subscription_id, effective_date
+	`
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetPriceChanges:Query - %s", err.Error())
+	}
+
+	var changes []*models.PriceChange
+	for rows.Next() {
+		var change models.PriceChange
+		err := rows.Scan(
+			&change.Id,
+			&change.SubscriptionId,
+			&change.EffectiveDate,
+			&change.Price,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetPriceChanges:Scan - %s", err.Error())
+		}
+		changes = append(changes, &change)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *SubscriptionRepo) SetSplit(ctx context.Context, split *models.SubscriptionSplit) error {
+	lockArgs, condition := tenantCondition(ctx, []any{split.SubscriptionId}, "organization_id")
+	lockQuery := `
+		SELECT id
+			FROM subscription
+		WHERE id = $1` + condition + `
+		FOR UPDATE
+	`
+	deleteQuery := `
+		DELETE FROM subscription_split
+			WHERE subscription_id = $1
+	`
+	splitQuery := `
+		INSERT INTO subscription_split (subscription_id, rule)
+			VALUES ($1, $2)
+	`
+	memberQuery := `
+		INSERT INTO subscription_member (subscription_id, user_id, share)
+			VALUES ($1, $2, $3)
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.SetSplit:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	err = tx.QueryRow(ctx, lockQuery, lockArgs...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		return fmt.Errorf("db:SubscriptionRepo.SetSplit:QueryRow - %s", err.Error())
+	}
+
+	//The members are deleted with the split
+	_, err = tx.Exec(ctx, deleteQuery, split.SubscriptionId)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.SetSplit:Exec - %s", err.Error())
+	}
+	if len(split.Members) > 0 {
+		_, err = tx.Exec(ctx, splitQuery, split.SubscriptionId, split.Rule)
+		if err != nil {
+			return fmt.Errorf("db:SubscriptionRepo.SetSplit:Exec - %s", err.Error())
+		}
+		for _, m := range split.Members {
+			_, err = tx.Exec(ctx, memberQuery, split.SubscriptionId, m.UserId, m.Share)
+			if err != nil {
+				if isUniqueViolation(err) {
+					return repository.ErrAlreadyExists
+				}
+				return fmt.Errorf("db:SubscriptionRepo.SetSplit:Exec - %s", err.Error())
+			}
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.SetSplit:Commit - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetSplits(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionSplit, error) {
+	args, condition := tenantSubscriptionCondition(ctx, []any{subscriptionIds}, "s.subscription_id")
+	query := `
+		SELECT s.subscription_id, s.rule, m.user_id, m.share
+			FROM subscription_split s
+			JOIN subscription_member m ON m.subscription_id = s.subscription_id
+		WHERE s.subscription_id = ANY($1)` + condition + `
+		ORDER BY s.subscription_id, m.user_id
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetSplits:Query - %s", err.Error())
+	}
+
+	var splits []*models.SubscriptionSplit
+	for rows.Next() {
+		var (
+			subscriptionId int
+			rule           string
+			member         models.SubscriptionMember
+		)
+		err := rows.Scan(&subscriptionId, &rule, &member.UserId, &member.Share)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetSplits:Scan - %s", err.Error())
+		}
+		if len(splits) == 0 || splits[len(splits)-1].SubscriptionId != subscriptionId {
+			splits = append(splits, &models.SubscriptionSplit{SubscriptionId: subscriptionId, Rule: rule})
+		}
+		split := splits[len(splits)-1]
+		split.Members = append(split.Members, member)
+	}
+
+	return splits, nil
+}
+
+// memberCondition appends the user to the query arguments and returns the condition
+// matching the subscriptions of the user and the ones the user is a member of
+func memberCondition(args []any, userId any) ([]any, string) {
+	args = append(args, userId)
+	return args, fmt.Sprintf(" AND (user_id = $%d OR id IN (SELECT subscription_id FROM subscription_member WHERE user_id = $%d))", len(args), len(args))
+}
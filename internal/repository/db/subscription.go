@@ -26,31 +26,71 @@ func NewSubscriptionRepo(db *pgxpool.Pool) *SubscriptionRepo {
 
 func (r *SubscriptionRepo) Create(ctx context.Context, s *models.SubscriptionCreate) (int, error) {
 	query := `
-		INSERT INTO subscription (service_name, price, user_id, start_date, end_date) 
-			VALUES ($1, $2, $3, $4, $5) 
+		INSERT INTO subscription (service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, service_id, plan_id, category_id, notes, organization_id) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) 
 		RETURNING id
 	`
 	var id int
 
-	err := r.db.QueryRow(ctx, query, s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate).Scan(&id)
-	return id, err
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db:SubscriptionRepo.Create:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	//The subscription belongs to the tenant it is created in
+	err = tx.QueryRow(ctx, query, s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate, s.BillingDay, s.TrialMonths, s.TrialPrice, s.ServiceId, s.PlanId, s.CategoryId, s.Notes, organizationId(ctx)).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == repository.PgCodeConstrainError && pgErr.ConstraintName == repository.ConstraintPeriod {
+				return 0, repository.ErrIncorrectTime
+			}
+			if pgErr.Code == repository.PgCodeForeignKeyError && pgErr.ConstraintName == repository.ConstraintSubscriptionUser {
+				return 0, repository.ErrUnknownUser
+			}
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.Create:QueryRow - %s", err.Error())
+	}
+	if s.Tags != nil {
+		if err := setTags(ctx, tx, id, s.UserId, s.Tags); err != nil {
+			return 0, fmt.Errorf("db:SubscriptionRepo.Create:setTags - %s", err.Error())
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("db:SubscriptionRepo.Create:Commit - %s", err.Error())
+	}
+
+	return id, nil
 }
 
 func (r *SubscriptionRepo) GetById(ctx context.Context, id int) (*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date 
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
 			FROM subscription 
-		WHERE id = $1
-	`
+		WHERE id = $1` + condition
 	var subscription models.Subscription
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := r.db.QueryRow(ctx, query, args...).Scan(
 		&subscription.Id,
 		&subscription.ServiceName,
 		&subscription.Price,
 		&subscription.UserId,
 		&subscription.StartDate,
 		&subscription.EndDate,
+		&subscription.BillingDay,
+		&subscription.TrialMonths,
+		&subscription.TrialPrice,
+		&subscription.CancelledAt,
+		&subscription.CancelReason,
+		&subscription.ServiceId,
+		&subscription.PlanId,
+		&subscription.CategoryId,
+		&subscription.Notes,
+		&subscription.OrganizationId,
 	)
 
 	if err != nil {
@@ -64,14 +104,16 @@ func (r *SubscriptionRepo) GetById(ctx context.Context, id int) (*models.Subscri
 }
 
 func (r *SubscriptionRepo) GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int) ([]*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{userId, offset, limit}, "organization_id")
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date 
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
 			FROM subscription 
-		WHERE user_id = $1
+		WHERE user_id = $1` + condition + `
+		ORDER BY id
 			OFFSET $2
 			LIMIT $3
 	`
-	rows, err := r.db.Query(ctx, query, userId, offset, limit)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("db:SubscriptionRepo.GetByUser:Query - %s", err.Error())
 	}
@@ -86,6 +128,16 @@ func (r *SubscriptionRepo) GetByUser(ctx context.Context, userId uuid.UUID, offs
 			&subscription.UserId,
 			&subscription.StartDate,
 			&subscription.EndDate,
+			&subscription.BillingDay,
+			&subscription.TrialMonths,
+			&subscription.TrialPrice,
+			&subscription.CancelledAt,
+			&subscription.CancelReason,
+			&subscription.ServiceId,
+			&subscription.PlanId,
+			&subscription.CategoryId,
+			&subscription.Notes,
+			&subscription.OrganizationId,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("db:SubscriptionRepo.GetByUser:Scan - %s", err.Error())
@@ -97,20 +149,31 @@ func (r *SubscriptionRepo) GetByUser(ctx context.Context, userId uuid.UUID, offs
 }
 
 func (r *SubscriptionRepo) DeleteById(ctx context.Context, id int) (*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
 	query := `
 		DELETE FROM subscription 
-			WHERE id = $1
-		RETURNING id, service_name, price, user_id, start_date, end_date
+			WHERE id = $1` + condition + `
+		RETURNING id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
 	`
 	var subscription models.Subscription
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := r.db.QueryRow(ctx, query, args...).Scan(
 		&subscription.Id,
 		&subscription.ServiceName,
 		&subscription.Price,
 		&subscription.UserId,
 		&subscription.StartDate,
 		&subscription.EndDate,
+		&subscription.BillingDay,
+		&subscription.TrialMonths,
+		&subscription.TrialPrice,
+		&subscription.CancelledAt,
+		&subscription.CancelReason,
+		&subscription.ServiceId,
+		&subscription.PlanId,
+		&subscription.CategoryId,
+		&subscription.Notes,
+		&subscription.OrganizationId,
 	)
 
 	if err != nil {
@@ -124,26 +187,50 @@ func (r *SubscriptionRepo) DeleteById(ctx context.Context, id int) (*models.Subs
 }
 
 func (r *SubscriptionRepo) Update(ctx context.Context, s *models.SubscriptionUpdate) (*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{s.ServiceName, s.Price, s.StartDate, s.EndDate, s.BillingDay, s.TrialMonths, s.TrialPrice, s.ServiceId, s.PlanId, s.CategoryId, s.Notes, s.Id}, "organization_id")
 	query := `
 		UPDATE subscription 
 		SET
 			service_name = COALESCE($1, service_name),
 			price = COALESCE($2, price),
 			start_date = COALESCE($3, start_date),
-			end_date = COALESCE($4, end_date)
+			end_date = COALESCE($4, end_date),
+			billing_day = COALESCE($5, billing_day),
+			trial_months = COALESCE($6, trial_months),
+			trial_price = COALESCE($7, trial_price),
+			service_id = COALESCE($8, service_id),
+			plan_id = COALESCE($9, plan_id),
+			category_id = COALESCE($10, category_id),
+			notes = COALESCE($11, notes)
 		WHERE
-			id = $5
-		RETURNING id, service_name, price, user_id, start_date, end_date
+			id = $12` + condition + `
+		RETURNING id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
 	`
 	var subscription models.Subscription
 
-	err := r.db.QueryRow(ctx, query, s.ServiceName, s.Price, s.StartDate, s.EndDate, s.Id).Scan(
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.Update:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query, args...).Scan(
 		&subscription.Id,
 		&subscription.ServiceName,
 		&subscription.Price,
 		&subscription.UserId,
 		&subscription.StartDate,
 		&subscription.EndDate,
+		&subscription.BillingDay,
+		&subscription.TrialMonths,
+		&subscription.TrialPrice,
+		&subscription.CancelledAt,
+		&subscription.CancelReason,
+		&subscription.ServiceId,
+		&subscription.PlanId,
+		&subscription.CategoryId,
+		&subscription.Notes,
+		&subscription.OrganizationId,
 	)
 
 	if err != nil {
@@ -152,77 +239,115 @@ func (r *SubscriptionRepo) Update(ctx context.Context, s *models.SubscriptionUpd
 		}
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
-			if pgErr.Code == repository.PgCodeConstrainError {
+			if pgErr.Code == repository.PgCodeConstrainError && pgErr.ConstraintName == repository.ConstraintPeriod {
 				return nil, repository.ErrIncorrectTime
 			}
 		}
 		return nil, fmt.Errorf("db:SubscriptionRepo.Update:QueryRow - %s", err.Error())
 	}
+	//The updated row stays locked until the commit
+	if s.Tags != nil {
+		if err := setTags(ctx, tx, subscription.Id, subscription.UserId, s.Tags); err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.Update:setTags - %s", err.Error())
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.Update:Commit - %s", err.Error())
+	}
 
 	return &subscription, nil
 }
 
-func (r *SubscriptionRepo) GetPriceByFilter(ctx context.Context, user_id *uuid.UUID, service_name *string, start_date, end_date time.Time) (int, error) {
+func (r *SubscriptionRepo) GetByPeriod(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.Subscription, error) {
 	query := `
-        SELECT price,
-			CASE
-				WHEN GREATEST(start_date, $2) > LEAST(end_date, $1)
-					THEN 0
-				ELSE
-					(EXTRACT(YEAR FROM age(
-						LEAST(end_date, $1),
-						GREATEST(start_date, $2)
-					))::int * 12 +
-					EXTRACT(MONTH FROM age(
-						LEAST(end_date, $1),
-						GREATEST(start_date, $2)
-					))::int)
-			END AS count_months
-        	FROM subscription
-    `
-	c := 3
-	args := []interface{}{end_date, start_date}
-	if user_id != nil {
-		query += fmt.Sprintf("WHERE user_id = $%d", c)
-		c++
-		args = append(args, *user_id)
-	}
-	if service_name != nil {
-		if c == 3 {
-			query += fmt.Sprintf("WHERE service_name = $%d", c)
-		} else {
-			query += fmt.Sprintf(" AND service_name = $%d", c)
-		}
-		args = append(args, *service_name)
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+			FROM subscription
+		WHERE start_date <= $1
+			AND (end_date IS NULL OR end_date >= $2)
+	`
+	args, condition := tenantCondition(ctx, []any{filter.To, filter.From}, "organization_id")
+	query += condition
+	if filter.UserId != nil && filter.Members {
+		var condition string
+		args, condition = memberCondition(args, *filter.UserId)
+		query += condition
+	} else if filter.UserId != nil {
+		args = append(args, *filter.UserId)
+		query += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if filter.Shared {
+		query += " AND id IN (SELECT subscription_id FROM subscription_split)"
 	}
+	if filter.ServiceName != nil && filter.ServiceId != nil {
+		args = append(args, *filter.ServiceName, *filter.ServiceId)
+		query += fmt.Sprintf(" AND (service_name = $%d OR service_id = $%d)", len(args)-1, len(args))
+	} else if filter.ServiceName != nil {
+		args = append(args, *filter.ServiceName)
+		query += fmt.Sprintf(" AND service_name = $%d", len(args))
+	} else if filter.ServiceId != nil {
+		args = append(args, *filter.ServiceId)
+		query += fmt.Sprintf(" AND service_id = $%d", len(args))
+	}
+	if filter.Trial {
+		query += " AND trial_months > 0"
+	}
+	if filter.CategoryIds != nil {
+		args = append(args, filter.CategoryIds)
+		query += categoryCondition(len(args))
+	}
+	args, condition = tagCondition(args, filter.Tags, filter.ExcludedTags)
+	query += condition
+	query += " ORDER BY id"
 
-	var total int
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("db:SubscriptionRepo.GetPriceByTime:Query - %s", err.Error())
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetByPeriod:Query - %s", err.Error())
 	}
 
+	var subscriptions []*models.Subscription
 	for rows.Next() {
-		var price, countMonths int
-		err := rows.Scan(&price, &countMonths)
+		var subscription models.Subscription
+		err := rows.Scan(
+			&subscription.Id,
+			&subscription.ServiceName,
+			&subscription.Price,
+			&subscription.UserId,
+			&subscription.StartDate,
+			&subscription.EndDate,
+			&subscription.BillingDay,
+			&subscription.TrialMonths,
+			&subscription.TrialPrice,
+			&subscription.CancelledAt,
+			&subscription.CancelReason,
+			&subscription.ServiceId,
+			&subscription.PlanId,
+			&subscription.CategoryId,
+			&subscription.Notes,
+			&subscription.OrganizationId,
+		)
 		if err != nil {
-			return 0, fmt.Errorf("db:SubscriptionRepo.GetPriceByTime:Scan - %s", err.Error())
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetByPeriod:Scan - %s", err.Error())
 		}
-		total += price * countMonths
+		subscriptions = append(subscriptions, &subscription)
 	}
 
-	return total, nil
+	return subscriptions, nil
 }
 
 func (r *SubscriptionRepo) GetAll(ctx context.Context, offset, limit int) ([]*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{offset, limit}, "organization_id")
 	query := `
-		SELECT * 
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
 			FROM subscription
-		OFFSET $1
-		LIMIT $2
+		WHERE TRUE` + condition + `
+		ORDER BY id
+			OFFSET $1
+			LIMIT $2
 	`
 
-	rows, err := r.db.Query(ctx, query, offset, limit)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("db:SubscriptionRepo.GetAll:Query - %s", err.Error())
 	}
@@ -237,6 +362,16 @@ func (r *SubscriptionRepo) GetAll(ctx context.Context, offset, limit int) ([]*mo
 			&subscription.UserId,
 			&subscription.StartDate,
 			&subscription.EndDate,
+			&subscription.BillingDay,
+			&subscription.TrialMonths,
+			&subscription.TrialPrice,
+			&subscription.CancelledAt,
+			&subscription.CancelReason,
+			&subscription.ServiceId,
+			&subscription.PlanId,
+			&subscription.CategoryId,
+			&subscription.Notes,
+			&subscription.OrganizationId,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("db:SubscriptionRepo.GetAll:Scan - %s", err.Error())
@@ -246,3 +381,202 @@ func (r *SubscriptionRepo) GetAll(ctx context.Context, offset, limit int) ([]*mo
 
 	return subscriptions, nil
 }
+
+func (r *SubscriptionRepo) CountActive(ctx context.Context, at time.Time) (int, error) {
+	args, condition := tenantCondition(ctx, []any{at}, "organization_id")
+	query := `
+		SELECT count(*)
+			FROM subscription
+		WHERE start_date <= $1
+			AND (end_date IS NULL OR end_date >= $1)` + condition
+	var count int
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("db:SubscriptionRepo.CountActive:QueryRow - %s", err.Error())
+	}
+
+	return count, nil
+}
+
+func (r *SubscriptionRepo) Cancel(ctx context.Context, c *models.SubscriptionCancel) (*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{c.EndDate, c.CancelledAt, c.Reason, c.Id}, "organization_id")
+	query := `
+		UPDATE subscription 
+		SET
+			end_date = $1,
+			cancelled_at = $2,
+			cancel_reason = $3
+		WHERE
+			id = $4` + condition + `
+		RETURNING id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+	`
+	var subscription models.Subscription
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(
+		&subscription.Id,
+		&subscription.ServiceName,
+		&subscription.Price,
+		&subscription.UserId,
+		&subscription.StartDate,
+		&subscription.EndDate,
+		&subscription.BillingDay,
+		&subscription.TrialMonths,
+		&subscription.TrialPrice,
+		&subscription.CancelledAt,
+		&subscription.CancelReason,
+		&subscription.ServiceId,
+		&subscription.PlanId,
+		&subscription.CategoryId,
+		&subscription.Notes,
+		&subscription.OrganizationId,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			if pgErr.Code == repository.PgCodeConstrainError && pgErr.ConstraintName == repository.ConstraintPeriod {
+				return nil, repository.ErrIncorrectTime
+			}
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.Cancel:QueryRow - %s", err.Error())
+	}
+
+	return &subscription, nil
+}
+
+func (r *SubscriptionRepo) GetByState(ctx context.Context, filter *models.StateFilter) ([]*models.Subscription, error) {
+	query, args := stateQuery(ctx, filter)
+	query += " ORDER BY id"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetByState:Query - %s", err.Error())
+	}
+
+	return scanStateRows(rows, "GetByState")
+}
+
+func (r *SubscriptionRepo) GetPageByState(ctx context.Context, filter *models.StateFilter, offset, limit int) ([]*models.Subscription, error) {
+	query, args := stateQuery(ctx, filter)
+	args = append(args, offset, limit)
+	query += fmt.Sprintf(`
+		ORDER BY id
+			OFFSET $%d
+			LIMIT $%d
+	`, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetPageByState:Query - %s", err.Error())
+	}
+
+	return scanStateRows(rows, "GetPageByState")
+}
+
+// stateQuery selects the subscriptions matching the filter
+func stateQuery(ctx context.Context, filter *models.StateFilter) (string, []any) {
+	query := `
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+			FROM subscription
+		WHERE TRUE
+	`
+	args, condition := tenantCondition(ctx, nil, "organization_id")
+	query += condition
+	if filter.UserId != nil {
+		args = append(args, *filter.UserId)
+		query += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if filter.Cancelled != nil {
+		if *filter.Cancelled {
+			query += " AND cancelled_at IS NOT NULL"
+		} else {
+			query += " AND cancelled_at IS NULL"
+		}
+	}
+	if filter.EndedBefore != nil {
+		args = append(args, *filter.EndedBefore)
+		query += fmt.Sprintf(" AND end_date < $%d", len(args))
+	}
+	if filter.ActiveOn != nil {
+		args = append(args, *filter.ActiveOn)
+		query += fmt.Sprintf(" AND (end_date IS NULL OR end_date >= $%d)", len(args))
+	}
+	if filter.Paused != nil || filter.Trialing != nil {
+		args = append(args, filter.StatusDay)
+	}
+	day := fmt.Sprintf("GREATEST(subscription.start_date, $%d::date)", len(args))
+	if filter.Paused != nil {
+		condition := fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM subscription_pause
+			WHERE subscription_id = subscription.id AND start_date <= %[1]s AND (end_date IS NULL OR end_date >= %[1]s)
+		)`, day)
+		if !*filter.Paused {
+			condition = "NOT " + condition
+		}
+		query += " AND " + condition
+	}
+	if filter.Trialing != nil {
+		condition := fmt.Sprintf("(trial_months > 0 AND %s <= %s)", day, trialEndDate)
+		if !*filter.Trialing {
+			condition = "NOT " + condition
+		}
+		query += " AND " + condition
+	}
+	if filter.CategoryIds != nil {
+		args = append(args, filter.CategoryIds)
+		query += categoryCondition(len(args))
+	}
+	args, condition = tagCondition(args, filter.Tags, filter.ExcludedTags)
+	query += condition
+
+	return query, args
+}
+
+// trialMonth is the first day of the month the trial of the subscription ends in:
+// trial_months months after the month its first billing cycle starts in
+const trialMonth = `(date_trunc('month', start_date::timestamp) + make_interval(months => trial_months -
+	CASE WHEN LEAST(billing_day, EXTRACT(DAY FROM date_trunc('month', start_date::timestamp) + interval '1 month - 1 day')::integer) > EXTRACT(DAY FROM start_date)
+	THEN 1 ELSE 0 END))`
+
+// trialEndDate is the last day of the trial, the day before the billing day of trialMonth clamped to the month length
+const trialEndDate = `(` + trialMonth + ` + make_interval(days => LEAST(billing_day, EXTRACT(DAY FROM ` + trialMonth + ` + interval '1 month - 1 day')::integer) - 2))::date`
+
+func scanStateRows(rows pgx.Rows, method string) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for rows.Next() {
+		var subscription models.Subscription
+		err := rows.Scan(
+			&subscription.Id,
+			&subscription.ServiceName,
+			&subscription.Price,
+			&subscription.UserId,
+			&subscription.StartDate,
+			&subscription.EndDate,
+			&subscription.BillingDay,
+			&subscription.TrialMonths,
+			&subscription.TrialPrice,
+			&subscription.CancelledAt,
+			&subscription.CancelReason,
+			&subscription.ServiceId,
+			&subscription.PlanId,
+			&subscription.CategoryId,
+			&subscription.Notes,
+			&subscription.OrganizationId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo."+method+":Scan - %s", err.Error())
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// categoryCondition matches the subscriptions in the categories of the parameter directly or by their service
+func categoryCondition(param int) string {
+	return fmt.Sprintf(" AND (category_id = ANY($%[1]d) OR (category_id IS NULL AND service_id IN (SELECT id FROM service WHERE category_id = ANY($%[1]d))))", param)
+}
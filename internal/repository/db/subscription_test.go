@@ -0,0 +1,112 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/Estriper0/subscription_service/internal/pgtest"
+	"github.com/Estriper0/subscription_service/internal/repository/db"
+	"github.com/Estriper0/subscription_service/internal/repository/repotest"
+	"github.com/Estriper0/subscription_service/pkg/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestSubscriptionRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.SubscriptionRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestCatalogRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.CatalogRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestNameRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.NameRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestCategoryRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.CategoryRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestTagRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.TagRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestBudgetRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.BudgetRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestInsightRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.InsightRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+func TestSplitRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.SplitRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestOrganizationRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.OrganizationRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func TestUserRepo(t *testing.T) {
+	pool := newPool(t)
+
+	repotest.UserRepo(t, func(t *testing.T) repotest.Repo {
+		pgtest.Reset(t, pool)
+		return db.NewSubscriptionRepo(pool)
+	})
+}
+
+func newPool(t *testing.T) *pgxpool.Pool {
+	dbConfig := pgtest.Start(t)
+
+	pool, err := postgres.New(dbConfig.Url(), dbConfig.PoolSize)
+	if err != nil {
+		t.Fatalf("postgres.New: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
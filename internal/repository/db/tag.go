@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *SubscriptionRepo) CreateTag(ctx context.Context, t *models.TagCreate) (int, error) {
+	query := `
+		INSERT INTO tag (user_id, name, organization_id)
+			VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	var id int
+
+	//The tag belongs to the tenant it is created in
+	err := r.db.QueryRow(ctx, query, t.UserId, t.Name, organizationId(ctx)).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("db:SubscriptionRepo.CreateTag:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetTag(ctx context.Context, id int) (*models.Tag, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		SELECT id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+			FROM tag
+		WHERE id = $1` + condition
+	var tag models.Tag
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&tag.Id, &tag.UserId, &tag.Name, &tag.Subscriptions, &tag.OrganizationId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetTag:QueryRow - %s", err.Error())
+	}
+
+	return &tag, nil
+}
+
+func (r *SubscriptionRepo) GetTags(ctx context.Context, userId uuid.UUID) ([]*models.Tag, error) {
+	args, condition := tenantCondition(ctx, []any{userId}, "organization_id")
+	query := `
+		SELECT id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+			FROM tag
+		WHERE user_id = $1` + condition + `
+		ORDER BY lower(name) COLLATE "C"
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetTags:Query - %s", err.Error())
+	}
+
+	var tags []*models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		err := rows.Scan(&tag.Id, &tag.UserId, &tag.Name, &tag.Subscriptions, &tag.OrganizationId)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetTags:Scan - %s", err.Error())
+		}
+		tags = append(tags, &tag)
+	}
+
+	return tags, nil
+}
+
+func (r *SubscriptionRepo) UpdateTag(ctx context.Context, t *models.TagUpdate) (*models.Tag, error) {
+	args, condition := tenantCondition(ctx, []any{t.Name, t.Id}, "organization_id")
+	query := `
+		UPDATE tag
+		SET
+			name = $1
+		WHERE
+			id = $2` + condition + `
+		RETURNING id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+	`
+	var tag models.Tag
+
+	err := r.db.QueryRow(ctx, query, args...).Scan(&tag.Id, &tag.UserId, &tag.Name, &tag.Subscriptions, &tag.OrganizationId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateTag:QueryRow - %s", err.Error())
+	}
+
+	return &tag, nil
+}
+
+func (r *SubscriptionRepo) DeleteTag(ctx context.Context, id int) (*models.Tag, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		DELETE FROM tag
+			WHERE id = $1` + condition + `
+		RETURNING id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+	`
+	var tag models.Tag
+
+	//The tag is removed from the subscriptions with it
+	err := r.db.QueryRow(ctx, query, args...).Scan(&tag.Id, &tag.UserId, &tag.Name, &tag.Subscriptions, &tag.OrganizationId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteTag:QueryRow - %s", err.Error())
+	}
+
+	return &tag, nil
+}
+
+func (r *SubscriptionRepo) SetSubscriptionTags(ctx context.Context, subscriptionId int, names []string) error {
+	userArgs, condition := tenantCondition(ctx, []any{subscriptionId}, "organization_id")
+	userQuery := `
+		SELECT user_id
+			FROM subscription
+		WHERE id = $1` + condition + `
+		FOR UPDATE
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.SetSubscriptionTags:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var userId uuid.UUID
+	err = tx.QueryRow(ctx, userQuery, userArgs...).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		return fmt.Errorf("db:SubscriptionRepo.SetSubscriptionTags:QueryRow - %s", err.Error())
+	}
+
+	err = setTags(ctx, tx, subscriptionId, userId, names)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.SetSubscriptionTags:setTags - %s", err.Error())
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("db:SubscriptionRepo.SetSubscriptionTags:Commit - %s", err.Error())
+	}
+
+	return nil
+}
+
+// setTags replaces the tags of the user's subscription in the transaction,
+// creating the missing ones in the organization of the subscription
+func setTags(ctx context.Context, tx pgx.Tx, subscriptionId int, userId uuid.UUID, names []string) error {
+	createQuery := `
+		INSERT INTO tag (user_id, name, organization_id)
+			SELECT $1::uuid, name, (SELECT organization_id FROM subscription WHERE id = $3)
+				FROM unnest($2::text[]) AS name
+		ON CONFLICT (organization_id, user_id, lower(name)) DO NOTHING
+	`
+	deleteQuery := `
+		DELETE FROM subscription_tag
+			WHERE subscription_id = $1
+	`
+	linkQuery := `
+		INSERT INTO subscription_tag (subscription_id, tag_id)
+			SELECT $1, id
+				FROM tag
+			WHERE user_id = $2
+				AND organization_id = (SELECT organization_id FROM subscription WHERE id = $1)
+				AND lower(name) IN (SELECT lower(name) FROM unnest($3::text[]) AS name)
+	`
+	_, err := tx.Exec(ctx, createQuery, userId, names, subscriptionId)
+	if err != nil {
+		return fmt.Errorf("Exec - %s", err.Error())
+	}
+	_, err = tx.Exec(ctx, deleteQuery, subscriptionId)
+	if err != nil {
+		return fmt.Errorf("Exec - %s", err.Error())
+	}
+	_, err = tx.Exec(ctx, linkQuery, subscriptionId, userId, names)
+	if err != nil {
+		return fmt.Errorf("Exec - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetSubscriptionTags(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionTag, error) {
+	args, condition := tenantSubscriptionCondition(ctx, []any{subscriptionIds}, "st.subscription_id")
+	query := `
+		SELECT st.subscription_id, t.id, t.name
+			FROM subscription_tag st
+			JOIN tag t ON t.id = st.tag_id
+		WHERE st.subscription_id = ANY($1)` + condition + `
+		ORDER BY st.subscription_id, lower(t.name) COLLATE "C"
+	`
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetSubscriptionTags:Query - %s", err.Error())
+	}
+
+	var tags []*models.SubscriptionTag
+	for rows.Next() {
+		var tag models.SubscriptionTag
+		err := rows.Scan(&tag.SubscriptionId, &tag.TagId, &tag.Name)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetSubscriptionTags:Scan - %s", err.Error())
+		}
+		tags = append(tags, &tag)
+	}
+
+	return tags, nil
+}
+
+// tagCondition appends the tags to the query arguments and returns the condition
+// matching the subscriptions with all of the tags and none of the excluded ones
+func tagCondition(args []any, tags, excluded []string) ([]any, string) {
+	var condition string
+	for _, tag := range tags {
+		args = append(args, tag)
+		condition += fmt.Sprintf(" AND id IN (SELECT st.subscription_id FROM subscription_tag st JOIN tag t ON t.id = st.tag_id WHERE lower(t.name) = lower($%d))", len(args))
+	}
+	if len(excluded) > 0 {
+		args = append(args, excluded)
+		condition += fmt.Sprintf(" AND id NOT IN (SELECT st.subscription_id FROM subscription_tag st JOIN tag t ON t.id = st.tag_id WHERE lower(t.name) IN (SELECT lower(name) FROM unnest($%d::text[]) AS name))", len(args))
+	}
+	return args, condition
+}
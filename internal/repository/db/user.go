@@ -0,0 +1,232 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// personalDataQueries delete the rows of the user besides the subscriptions, $1 is the user ID.
+// The subscriptions lose the deleted categories and tags, the splits left without members stop sharing.
+var personalDataQueries = []string{
+	`DELETE FROM subscription_split s
+		WHERE EXISTS (SELECT 1 FROM subscription_member m WHERE m.subscription_id = s.subscription_id AND m.user_id = $1)
+			AND NOT EXISTS (SELECT 1 FROM subscription_member m WHERE m.subscription_id = s.subscription_id AND m.user_id <> $1)`,
+	`DELETE FROM subscription_member WHERE user_id = $1`,
+	`DELETE FROM organization_member WHERE user_id = $1`,
+	`DELETE FROM budget WHERE user_id = $1`,
+	`DELETE FROM tag WHERE user_id = $1`,
+	`DELETE FROM category WHERE user_id = $1`,
+	`DELETE FROM insight_dismissal WHERE user_id = $1`,
+}
+
+func (r *SubscriptionRepo) CreateUser(ctx context.Context, u *models.UserCreate) error {
+	query := `
+		INSERT INTO users (id, display_name, email, locale, currency, timezone, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.Exec(ctx, query, u.Id, u.DisplayName, u.Email, u.Locale, u.Currency, u.Timezone, u.CreatedAt.UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repository.ErrAlreadyExists
+		}
+		return fmt.Errorf("db:SubscriptionRepo.CreateUser:Exec - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `
+		SELECT id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+			FROM users
+		WHERE id = $1
+	`
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetUser:QueryRow - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func (r *SubscriptionRepo) GetUsers(ctx context.Context, offset, limit int) ([]*models.User, error) {
+	query := `
+		SELECT id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+			FROM users
+		ORDER BY id
+			OFFSET $1
+			LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.GetUsers:Query - %s", err.Error())
+	}
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("db:SubscriptionRepo.GetUsers:Scan - %s", err.Error())
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *SubscriptionRepo) UpdateUser(ctx context.Context, u *models.UserUpdate) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET
+			display_name = COALESCE($1, display_name),
+			email = CASE WHEN $7 THEN NULL ELSE COALESCE($2, email) END,
+			locale = COALESCE($3, locale),
+			currency = COALESCE($4, currency),
+			timezone = COALESCE($5, timezone)
+		WHERE
+			id = $6 AND anonymized_at IS NULL
+		RETURNING id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+	`
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, u.DisplayName, u.Email, u.Locale, u.Currency, u.Timezone, u.Id, u.ClearEmail))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.UpdateUser:QueryRow - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func (r *SubscriptionRepo) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	subscriptionsQuery := `
+		DELETE FROM subscription
+			WHERE user_id = $1
+	`
+	userQuery := `
+		DELETE FROM users
+			WHERE id = $1
+		RETURNING id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteUser:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	//The pauses, the tags and the splits are deleted with the subscriptions
+	_, err = tx.Exec(ctx, subscriptionsQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteUser:Exec - %s", err.Error())
+	}
+	err = deletePersonalData(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteUser:deletePersonalData - %s", err.Error())
+	}
+
+	user, err := scanUser(tx.QueryRow(ctx, userQuery, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteUser:QueryRow - %s", err.Error())
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.DeleteUser:Commit - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func (r *SubscriptionRepo) AnonymizeUser(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error) {
+	userQuery := `
+		UPDATE users
+		SET
+			display_name = '',
+			email = NULL,
+			anonymized_at = $2
+		WHERE
+			id = $1 AND anonymized_at IS NULL
+		RETURNING id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+	`
+	subscriptionsQuery := `
+		UPDATE subscription
+		SET
+			notes = NULL,
+			cancel_reason = NULL
+		WHERE user_id = $1
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.AnonymizeUser:Begin - %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := scanUser(tx.QueryRow(ctx, userQuery, id, at.UTC()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("db:SubscriptionRepo.AnonymizeUser:QueryRow - %s", err.Error())
+	}
+
+	//The free text of the subscriptions may hold personal data
+	_, err = tx.Exec(ctx, subscriptionsQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.AnonymizeUser:Exec - %s", err.Error())
+	}
+	err = deletePersonalData(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.AnonymizeUser:deletePersonalData - %s", err.Error())
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db:SubscriptionRepo.AnonymizeUser:Commit - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func deletePersonalData(ctx context.Context, tx pgx.Tx, userId uuid.UUID) error {
+	for _, query := range personalDataQueries {
+		_, err := tx.Exec(ctx, query, userId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanUser(row pgx.Row) (*models.User, error) {
+	var user models.User
+	err := row.Scan(&user.Id, &user.DisplayName, &user.Email, &user.Locale, &user.Currency, &user.Timezone, &user.CreatedAt, &user.AnonymizedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	user.CreatedAt = user.CreatedAt.UTC()
+	if user.AnonymizedAt.Valid {
+		user.AnonymizedAt.Time = user.AnonymizedAt.Time.UTC()
+	}
+
+	return &user, nil
+}
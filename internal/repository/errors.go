@@ -3,10 +3,31 @@ package repository
 import "errors"
 
 const (
-	PgCodeConstrainError = "23514"
+	PgCodeConstrainError  = "23514"
+	PgCodeForeignKeyError = "23503"
+	PgCodeUniqueError     = "23505"
+	PgCodeExclusionError  = "23P01"
+	//Check constraint of the subscription period
+	ConstraintPeriod = "end_date_not_before_start_date"
+	//Check constraint of the pause period
+	ConstraintPausePeriod = "pause_end_date_not_before_start_date"
+	//Exclusion constraint against overlapping pauses of a subscription
+	ConstraintPauseOverlap = "pause_no_overlap"
+	//Unique constraint of the price changes of a subscription
+	ConstraintPriceChangeDate = "price_change_date_key"
+	//Foreign key of the subscription's user
+	ConstraintSubscriptionUser = "subscription_user_id_fkey"
 )
 
 var (
 	ErrNotFound      = errors.New("not found")
-	ErrIncorrectTime = errors.New("the end date must be later than the start date")
+	ErrIncorrectTime = errors.New("the end date must not be earlier than the start date")
+	//A unique name or alias is already taken
+	ErrAlreadyExists = errors.New("already exists")
+	//The row is still referenced, e.g. an organization with subscriptions
+	ErrInUse = errors.New("in use")
+	//The period overlaps another one, e.g. two pauses of a subscription
+	ErrOverlap = errors.New("overlap")
+	//The user is not registered
+	ErrUnknownUser = errors.New("unknown user")
 )
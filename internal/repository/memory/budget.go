@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateBudget(ctx context.Context, b *models.BudgetCreate) (int, error) {
+	organization, err := organizationId(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("memory:SubscriptionRepo.CreateBudget - %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	budget := models.Budget{
+		UserId:     b.UserId,
+		Amount:     b.Amount,
+		CategoryId: b.CategoryId,
+		ServiceId:  b.ServiceId,
+		//The budget belongs to the tenant it is created in
+		OrganizationId: organization,
+	}
+	if _, ok := r.categories[int(b.CategoryId.Int32)]; b.CategoryId.Valid && !ok {
+		return 0, repository.ErrNotFound
+	}
+	if _, ok := r.services[int(b.ServiceId.Int32)]; b.ServiceId.Valid && !ok {
+		return 0, repository.ErrNotFound
+	}
+	//Mirrors the unique index on the organization, the user and the scope
+	for _, other := range r.budgets {
+		if other.OrganizationId == budget.OrganizationId && other.UserId == budget.UserId && other.CategoryId == budget.CategoryId && other.ServiceId == budget.ServiceId {
+			return 0, repository.ErrAlreadyExists
+		}
+	}
+	r.lastBudgetId++
+	budget.Id = r.lastBudgetId
+	r.budgets[budget.Id] = budget
+
+	return budget.Id, nil
+}
+
+func (r *SubscriptionRepo) GetBudget(ctx context.Context, id int) (*models.Budget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	budget, ok := r.budget(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &budget, nil
+}
+
+func (r *SubscriptionRepo) GetBudgets(ctx context.Context, userId uuid.UUID) ([]*models.Budget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var budgets []*models.Budget
+	for _, b := range r.budgets {
+		if b.UserId == userId && inTenant(ctx, b.OrganizationId) {
+			budgets = append(budgets, &b)
+		}
+	}
+	slices.SortFunc(budgets, func(a, b *models.Budget) int { return a.Id - b.Id })
+
+	return budgets, nil
+}
+
+func (r *SubscriptionRepo) UpdateBudget(ctx context.Context, b *models.BudgetUpdate) (*models.Budget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	budget, ok := r.budget(ctx, b.Id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	budget.Amount = b.Amount
+	r.budgets[b.Id] = budget
+
+	return &budget, nil
+}
+
+func (r *SubscriptionRepo) DeleteBudget(ctx context.Context, id int) (*models.Budget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	budget, ok := r.budget(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	r.deleteBudget(id)
+
+	return &budget, nil
+}
+
+func (r *SubscriptionRepo) CreateBudgetAlert(ctx context.Context, a *models.BudgetAlertCreate) (*models.BudgetAlert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.budgets[a.BudgetId]; !ok {
+		return nil, repository.ErrNotFound
+	}
+	//Mirrors the unique constraint on the budget, the month and the threshold
+	for _, other := range r.budgetAlerts {
+		if other.BudgetId == a.BudgetId && other.Month.Equal(a.Month) && other.Threshold == a.Threshold {
+			return nil, repository.ErrAlreadyExists
+		}
+	}
+	alert := models.BudgetAlert{
+		BudgetId:  a.BudgetId,
+		Month:     a.Month,
+		Threshold: a.Threshold,
+		Spent:     a.Spent,
+		Amount:    a.Amount,
+		AlertedAt: a.AlertedAt.UTC(),
+	}
+	r.lastBudgetAlertId++
+	alert.Id = r.lastBudgetAlertId
+	r.budgetAlerts = append(r.budgetAlerts, alert)
+
+	return &alert, nil
+}
+
+func (r *SubscriptionRepo) GetBudgetAlerts(ctx context.Context, userId uuid.UUID) ([]*models.BudgetAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var alerts []*models.BudgetAlert
+	for _, a := range r.budgetAlerts {
+		if budget := r.budgets[a.BudgetId]; budget.UserId == userId && inTenant(ctx, budget.OrganizationId) {
+			alerts = append(alerts, &a)
+		}
+	}
+
+	return alerts, nil
+}
+
+// budget returns the budget by ID if it is visible in the organization of ctx
+func (r *SubscriptionRepo) budget(ctx context.Context, id int) (models.Budget, bool) {
+	budget, ok := r.budgets[id]
+	if !ok || !inTenant(ctx, budget.OrganizationId) {
+		return models.Budget{}, false
+	}
+	return budget, true
+}
+
+// deleteBudget removes the budget with its alerts like ON DELETE CASCADE
+func (r *SubscriptionRepo) deleteBudget(id int) {
+	delete(r.budgets, id)
+	r.budgetAlerts = slices.DeleteFunc(r.budgetAlerts, func(a models.BudgetAlert) bool { return a.BudgetId == id })
+}
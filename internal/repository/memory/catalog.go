@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+func (r *SubscriptionRepo) CreateService(ctx context.Context, s *models.ServiceCreate) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	service := models.Service{
+		Name:       s.Name,
+		Aliases:    sortedAliases(s.Aliases),
+		CategoryId: s.CategoryId,
+		Website:    s.Website,
+		LogoUrl:    s.LogoUrl,
+	}
+	if !r.uniqueService(&service) {
+		return 0, repository.ErrAlreadyExists
+	}
+	r.lastServiceId++
+	service.Id = r.lastServiceId
+	r.services[service.Id] = service
+
+	return service.Id, nil
+}
+
+func (r *SubscriptionRepo) GetService(ctx context.Context, id int) (*models.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, ok := r.services[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return copyService(service), nil
+}
+
+func (r *SubscriptionRepo) FindService(ctx context.Context, name string) (*models.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *models.Service
+	for _, s := range r.services {
+		if found != nil && found.Id < s.Id {
+			continue
+		}
+		if strings.EqualFold(s.Name, name) || slices.ContainsFunc(s.Aliases, func(alias string) bool { return strings.EqualFold(alias, name) }) {
+			found = copyService(s)
+		}
+	}
+	if found == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	return found, nil
+}
+
+func (r *SubscriptionRepo) GetServices(ctx context.Context, offset, limit int) ([]*models.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("memory:SubscriptionRepo.GetServices - OFFSET and LIMIT must not be negative")
+	}
+	var services []*models.Service
+	for _, s := range r.services {
+		services = append(services, copyService(s))
+	}
+	slices.SortFunc(services, func(a, b *models.Service) int { return a.Id - b.Id })
+	if offset >= len(services) {
+		return nil, nil
+	}
+
+	return services[offset:min(offset+limit, len(services))], nil
+}
+
+func (r *SubscriptionRepo) UpdateService(ctx context.Context, s *models.ServiceUpdate) (*models.Service, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	service, ok := r.services[s.Id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	//Like COALESCE in the SQL query, unset fields keep their values
+	if s.Name.Valid {
+		service.Name = s.Name.String
+	}
+	if s.Aliases != nil {
+		service.Aliases = sortedAliases(s.Aliases)
+	}
+	if s.CategoryId.Valid {
+		service.CategoryId = s.CategoryId
+	}
+	if s.Website.Valid {
+		service.Website = s.Website
+	}
+	if s.LogoUrl.Valid {
+		service.LogoUrl = s.LogoUrl
+	}
+	if !r.uniqueService(&service) {
+		return nil, repository.ErrAlreadyExists
+	}
+	r.services[s.Id] = service
+
+	return copyService(service), nil
+}
+
+func (r *SubscriptionRepo) DeleteService(ctx context.Context, id int) (*models.Service, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	service, ok := r.services[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.services, id)
+	//Like ON DELETE CASCADE of the plans and the budgets and ON DELETE SET NULL of the subscriptions
+	for planId, p := range r.plans {
+		if p.ServiceId == id {
+			r.deletePlan(planId)
+		}
+	}
+	for budgetId, b := range r.budgets {
+		if b.ServiceId.Valid && int(b.ServiceId.Int32) == id {
+			r.deleteBudget(budgetId)
+		}
+	}
+	for subscriptionId, s := range r.subscriptions {
+		if s.ServiceId.Valid && int(s.ServiceId.Int32) == id {
+			s.ServiceId = sql.NullInt32{}
+			r.subscriptions[subscriptionId] = s
+		}
+	}
+
+	return copyService(service), nil
+}
+
+func (r *SubscriptionRepo) LinkSubscriptions(ctx context.Context, serviceId int, names []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var linked int
+	for id, s := range r.subscriptions {
+		if !r.visible(ctx, &s) || s.ServiceId.Valid || !slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, s.ServiceName) }) {
+			continue
+		}
+		s.ServiceId = sql.NullInt32{Int32: int32(serviceId), Valid: true}
+		r.subscriptions[id] = s
+		linked++
+	}
+
+	return linked, nil
+}
+
+func (r *SubscriptionRepo) CreatePlan(ctx context.Context, p *models.PlanCreate) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//Like the foreign key to the service
+	if _, ok := r.services[p.ServiceId]; !ok {
+		return 0, repository.ErrNotFound
+	}
+	plan := models.Plan{
+		ServiceId:     p.ServiceId,
+		Name:          p.Name,
+		Price:         p.Price,
+		BillingPeriod: p.BillingPeriod,
+	}
+	if !r.uniquePlan(&plan) {
+		return 0, repository.ErrAlreadyExists
+	}
+	r.lastPlanId++
+	plan.Id = r.lastPlanId
+	r.plans[plan.Id] = plan
+
+	return plan.Id, nil
+}
+
+func (r *SubscriptionRepo) GetPlan(ctx context.Context, id int) (*models.Plan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &plan, nil
+}
+
+func (r *SubscriptionRepo) GetPlans(ctx context.Context, serviceIds []int) ([]*models.Plan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var plans []*models.Plan
+	for _, p := range r.plans {
+		if slices.Contains(serviceIds, p.ServiceId) {
+			plans = append(plans, &p)
+		}
+	}
+	slices.SortFunc(plans, func(a, b *models.Plan) int {
+		if a.ServiceId != b.ServiceId {
+			return a.ServiceId - b.ServiceId
+		}
+		return a.Id - b.Id
+	})
+
+	return plans, nil
+}
+
+func (r *SubscriptionRepo) UpdatePlan(ctx context.Context, p *models.PlanUpdate) (*models.Plan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan, ok := r.plans[p.Id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	//Like COALESCE in the SQL query, unset fields keep their values
+	if p.Name.Valid {
+		plan.Name = p.Name.String
+	}
+	if p.Price.Valid {
+		plan.Price = int(p.Price.Int32)
+	}
+	if p.BillingPeriod.Valid {
+		plan.BillingPeriod = p.BillingPeriod.String
+	}
+	if !r.uniquePlan(&plan) {
+		return nil, repository.ErrAlreadyExists
+	}
+	r.plans[p.Id] = plan
+
+	return &plan, nil
+}
+
+func (r *SubscriptionRepo) DeletePlan(ctx context.Context, id int) (*models.Plan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plan, ok := r.plans[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	r.deletePlan(id)
+
+	return &plan, nil
+}
+
+// deletePlan removes the plan from the subscriptions like ON DELETE SET NULL
+func (r *SubscriptionRepo) deletePlan(id int) {
+	delete(r.plans, id)
+	for subscriptionId, s := range r.subscriptions {
+		if s.PlanId.Valid && int(s.PlanId.Int32) == id {
+			s.PlanId = sql.NullInt32{}
+			r.subscriptions[subscriptionId] = s
+		}
+	}
+}
+
+// uniqueService mirrors the unique indexes on the lowercased names and aliases
+func (r *SubscriptionRepo) uniqueService(service *models.Service) bool {
+	for i, alias := range service.Aliases {
+		if slices.ContainsFunc(service.Aliases[i+1:], func(other string) bool { return strings.EqualFold(alias, other) }) {
+			return false
+		}
+	}
+	for _, s := range r.services {
+		if s.Id == service.Id {
+			continue
+		}
+		if strings.EqualFold(s.Name, service.Name) {
+			return false
+		}
+		for _, alias := range s.Aliases {
+			if slices.ContainsFunc(service.Aliases, func(other string) bool { return strings.EqualFold(alias, other) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// uniquePlan mirrors the plan_name_key constraint
+func (r *SubscriptionRepo) uniquePlan(plan *models.Plan) bool {
+	for _, p := range r.plans {
+		if p.Id != plan.Id && p.ServiceId == plan.ServiceId && p.Name == plan.Name {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedAliases(aliases []string) []string {
+	res := append([]string{}, aliases...)
+	slices.Sort(res)
+	return res
+}
+
+func copyService(s models.Service) *models.Service {
+	s.Aliases = slices.Clone(s.Aliases)
+	if s.Aliases == nil {
+		s.Aliases = []string{}
+	}
+	return &s
+}
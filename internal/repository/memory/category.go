@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+// builtinCategories are the ones the category migration seeds, in the same order
+var builtinCategories = []models.Category{
+	{Name: "Streaming"},
+	{Name: "Software"},
+	{Name: "News"},
+	{Name: "Gaming"},
+	{Name: "Education"},
+	{Name: "Health"},
+	{Name: "Video", ParentId: sql.NullInt32{Int32: 1, Valid: true}},
+	{Name: "Music", ParentId: sql.NullInt32{Int32: 1, Valid: true}},
+	{Name: "Productivity", ParentId: sql.NullInt32{Int32: 2, Valid: true}},
+	{Name: "Cloud storage", ParentId: sql.NullInt32{Int32: 2, Valid: true}},
+}
+
+func (r *SubscriptionRepo) CreateCategory(ctx context.Context, c *models.CategoryCreate) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category := models.Category{
+		Name:     c.Name,
+		ParentId: c.ParentId,
+		UserId:   c.UserId,
+	}
+	if _, ok := r.categories[int(c.ParentId.Int32)]; c.ParentId.Valid && !ok {
+		return 0, repository.ErrNotFound
+	}
+	if !r.uniqueCategory(&category) {
+		return 0, repository.ErrAlreadyExists
+	}
+	r.lastCategoryId++
+	category.Id = r.lastCategoryId
+	r.categories[category.Id] = category
+
+	return category.Id, nil
+}
+
+func (r *SubscriptionRepo) GetCategory(ctx context.Context, id int) (*models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &category, nil
+}
+
+func (r *SubscriptionRepo) GetCategories(ctx context.Context, userId *uuid.UUID) ([]*models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var categories []*models.Category
+	for _, c := range r.categories {
+		if userId == nil || !c.UserId.Valid || c.UserId.UUID == *userId {
+			categories = append(categories, &c)
+		}
+	}
+	slices.SortFunc(categories, func(a, b *models.Category) int { return a.Id - b.Id })
+
+	return categories, nil
+}
+
+func (r *SubscriptionRepo) UpdateCategory(ctx context.Context, c *models.CategoryUpdate) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[c.Id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if c.Name.Valid {
+		category.Name = c.Name.String
+	}
+	if c.ParentId.Valid {
+		if _, ok := r.categories[int(c.ParentId.Int32)]; !ok {
+			return nil, repository.ErrNotFound
+		}
+		category.ParentId = c.ParentId
+	}
+	if !r.uniqueCategory(&category) {
+		return nil, repository.ErrAlreadyExists
+	}
+	r.categories[c.Id] = category
+
+	return &category, nil
+}
+
+func (r *SubscriptionRepo) DeleteCategory(ctx context.Context, id int) (*models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	r.deleteCategory(id)
+
+	return &category, nil
+}
+
+func (r *SubscriptionRepo) GetServiceCategories(ctx context.Context, serviceIds []int) ([]*models.ServiceCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var categories []*models.ServiceCategory
+	for _, s := range r.services {
+		if s.CategoryId.Valid && slices.Contains(serviceIds, s.Id) {
+			categories = append(categories, &models.ServiceCategory{ServiceId: s.Id, CategoryId: int(s.CategoryId.Int32)})
+		}
+	}
+	slices.SortFunc(categories, func(a, b *models.ServiceCategory) int { return a.ServiceId - b.ServiceId })
+
+	return categories, nil
+}
+
+// deleteCategory removes the category with its subcategories and budgets like ON DELETE CASCADE
+// and unsets it in the subscriptions and services like ON DELETE SET NULL
+func (r *SubscriptionRepo) deleteCategory(id int) {
+	delete(r.categories, id)
+	for childId, c := range r.categories {
+		if c.ParentId.Valid && int(c.ParentId.Int32) == id {
+			r.deleteCategory(childId)
+		}
+	}
+	for subscriptionId, s := range r.subscriptions {
+		if s.CategoryId.Valid && int(s.CategoryId.Int32) == id {
+			s.CategoryId = sql.NullInt32{}
+			r.subscriptions[subscriptionId] = s
+		}
+	}
+	for serviceId, s := range r.services {
+		if s.CategoryId.Valid && int(s.CategoryId.Int32) == id {
+			s.CategoryId = sql.NullInt32{}
+			r.services[serviceId] = s
+		}
+	}
+	for budgetId, b := range r.budgets {
+		if b.CategoryId.Valid && int(b.CategoryId.Int32) == id {
+			r.deleteBudget(budgetId)
+		}
+	}
+}
+
+// uniqueCategory mirrors the unique indexes on the lowercased names of the siblings
+func (r *SubscriptionRepo) uniqueCategory(category *models.Category) bool {
+	for _, c := range r.categories {
+		if c.Id != category.Id && c.UserId == category.UserId && c.ParentId == category.ParentId && strings.EqualFold(c.Name, category.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// inCategories reports whether the subscription or, without a category, its service is in any of the categories
+func (r *SubscriptionRepo) inCategories(s *models.Subscription, categoryIds []int) bool {
+	categoryId := s.CategoryId
+	if !categoryId.Valid && s.ServiceId.Valid {
+		categoryId = r.services[int(s.ServiceId.Int32)].CategoryId
+	}
+	return categoryId.Valid && slices.Contains(categoryIds, int(categoryId.Int32))
+}
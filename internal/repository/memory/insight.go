@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) DismissInsight(ctx context.Context, d *models.InsightDismissal) error {
+	organization, err := organizationId(ctx)
+	if err != nil {
+		return fmt.Errorf("memory:SubscriptionRepo.DismissInsight - %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//The first dismissal in the tenant is kept
+	for _, other := range r.dismissals {
+		if other.OrganizationId == organization && other.UserId == d.UserId && other.InsightId == d.InsightId {
+			return nil
+		}
+	}
+	dismissal := *d
+	dismissal.DismissedAt = d.DismissedAt.UTC()
+	dismissal.OrganizationId = organization
+	r.dismissals = append(r.dismissals, dismissal)
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetInsightDismissals(ctx context.Context, userId uuid.UUID) ([]*models.InsightDismissal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var dismissals []*models.InsightDismissal
+	for _, d := range r.dismissals {
+		if d.UserId == userId && inTenant(ctx, d.OrganizationId) {
+			dismissals = append(dismissals, &d)
+		}
+	}
+	slices.SortFunc(dismissals, func(a, b *models.InsightDismissal) int {
+		return strings.Compare(a.InsightId, b.InsightId)
+	})
+
+	return dismissals, nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+)
+
+func (r *SubscriptionRepo) GetServiceNames(ctx context.Context) ([]*models.ServiceName, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, s := range r.subscriptions {
+		if r.visible(ctx, &s) {
+			counts[s.ServiceName]++
+		}
+	}
+	var names []*models.ServiceName
+	for name, count := range counts {
+		names = append(names, &models.ServiceName{Name: name, Subscriptions: count})
+	}
+	slices.SortFunc(names, func(a, b *models.ServiceName) int { return strings.Compare(a.Name, b.Name) })
+
+	return names, nil
+}
+
+func (r *SubscriptionRepo) SimilarNames(ctx context.Context, names []string, threshold float64) ([]*models.NamePair, error) {
+	return repository.SimilarNames(names, threshold), nil
+}
+
+func (r *SubscriptionRepo) MergeServiceNames(ctx context.Context, m *models.NameMerge) (*models.NameMergeAudit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for id, s := range r.subscriptions {
+		if !r.visible(ctx, &s) || !slices.Contains(m.Names, s.ServiceName) {
+			continue
+		}
+		s.ServiceName = m.Canonical
+		//Like the SQL query, the plan of another service is unlinked
+		if m.ServiceId.Valid {
+			if s.ServiceId != m.ServiceId {
+				s.PlanId = sql.NullInt32{}
+			}
+			s.ServiceId = m.ServiceId
+		}
+		r.subscriptions[id] = s
+		count++
+	}
+	audit := models.NameMergeAudit{
+		Id:            len(r.merges) + 1,
+		Canonical:     m.Canonical,
+		Names:         slices.Clone(m.Names),
+		Subscriptions: count,
+		MergedAt:      m.MergedAt.UTC(),
+	}
+	//Outside of a tenant the merge renamed the subscriptions of all the organizations
+	if organization, ok := tenant.Organization(ctx); ok {
+		audit.OrganizationId = sql.NullInt32{Int32: int32(organization), Valid: true}
+	}
+	r.merges = append(r.merges, audit)
+
+	return &audit, nil
+}
+
+func (r *SubscriptionRepo) GetNameMerges(ctx context.Context, offset, limit int) ([]*models.NameMergeAudit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("memory:SubscriptionRepo.GetNameMerges - OFFSET and LIMIT must not be negative")
+	}
+	//The merges of all the organizations are only visible when ctx bypasses the tenant
+	visible := slices.DeleteFunc(slices.Clone(r.merges), func(m models.NameMergeAudit) bool {
+		return !inTenant(ctx, int(m.OrganizationId.Int32)) || !m.OrganizationId.Valid && !tenant.Bypassed(ctx)
+	})
+	var merges []*models.NameMergeAudit
+	for _, m := range visible[min(offset, len(visible)):min(offset+limit, len(visible))] {
+		m.Names = slices.Clone(m.Names)
+		merges = append(merges, &m)
+	}
+
+	return merges, nil
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateOrganization(ctx context.Context, o *models.OrganizationCreate) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastOrganizationId++
+	r.organizations[r.lastOrganizationId] = models.Organization{
+		Id:        r.lastOrganizationId,
+		Name:      o.Name,
+		CreatedAt: o.CreatedAt.UTC(),
+	}
+
+	return r.lastOrganizationId, nil
+}
+
+func (r *SubscriptionRepo) GetOrganization(ctx context.Context, id int) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	organization, ok := r.organization(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &organization, nil
+}
+
+func (r *SubscriptionRepo) GetOrganizations(ctx context.Context, userId uuid.UUID) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var organizations []*models.Organization
+	for id, members := range r.members {
+		organization, ok := r.organization(ctx, id)
+		if ok && slices.ContainsFunc(members, func(m models.OrganizationMember) bool { return m.UserId == userId }) {
+			organizations = append(organizations, &organization)
+		}
+	}
+	slices.SortFunc(organizations, func(a, b *models.Organization) int { return a.Id - b.Id })
+
+	return organizations, nil
+}
+
+func (r *SubscriptionRepo) UpdateOrganization(ctx context.Context, o *models.OrganizationUpdate) (*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	organization, ok := r.organization(ctx, o.Id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	organization.Name = o.Name
+	r.organizations[o.Id] = organization
+
+	return &organization, nil
+}
+
+func (r *SubscriptionRepo) DeleteOrganization(ctx context.Context, id int) (*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	organization, ok := r.organization(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	//Like the foreign key of the subscriptions, the tenant of ctx doesn't hide them
+	for _, s := range r.subscriptions {
+		if s.OrganizationId == id {
+			return nil, repository.ErrInUse
+		}
+	}
+	delete(r.organizations, id)
+	//Like ON DELETE CASCADE
+	delete(r.members, id)
+	for budgetId, b := range r.budgets {
+		if b.OrganizationId == id {
+			r.deleteBudget(budgetId)
+		}
+	}
+	for tagId, t := range r.tags {
+		if t.OrganizationId == id {
+			delete(r.tags, tagId)
+		}
+	}
+	r.dismissals = slices.DeleteFunc(r.dismissals, func(d models.InsightDismissal) bool { return d.OrganizationId == id })
+	r.merges = slices.DeleteFunc(r.merges, func(m models.NameMergeAudit) bool {
+		return m.OrganizationId.Valid && int(m.OrganizationId.Int32) == id
+	})
+
+	return &organization, nil
+}
+
+func (r *SubscriptionRepo) AddMember(ctx context.Context, m *models.OrganizationMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.organization(ctx, m.OrganizationId); !ok {
+		return repository.ErrNotFound
+	}
+	members := r.members[m.OrganizationId]
+	i, found := slices.BinarySearchFunc(members, m.UserId, compareMember)
+	if found {
+		return nil
+	}
+	member := *m
+	member.JoinedAt = member.JoinedAt.UTC()
+	r.members[m.OrganizationId] = slices.Insert(members, i, member)
+
+	return nil
+}
+
+func (r *SubscriptionRepo) RemoveMember(ctx context.Context, organizationId int, userId uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.organization(ctx, organizationId); !ok {
+		return repository.ErrNotFound
+	}
+	members := r.members[organizationId]
+	i, found := slices.BinarySearchFunc(members, userId, compareMember)
+	if !found {
+		return repository.ErrNotFound
+	}
+	r.members[organizationId] = slices.Delete(members, i, i+1)
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetMembers(ctx context.Context, organizationId int) ([]*models.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.organization(ctx, organizationId); !ok {
+		return nil, nil
+	}
+	var members []*models.OrganizationMember
+	for _, m := range r.members[organizationId] {
+		members = append(members, &m)
+	}
+
+	return members, nil
+}
+
+func (r *SubscriptionRepo) IsMember(ctx context.Context, organizationId int, userId uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.organization(ctx, organizationId); !ok {
+		return false, nil
+	}
+	_, found := slices.BinarySearchFunc(r.members[organizationId], userId, compareMember)
+
+	return found, nil
+}
+
+// organization returns the organization by ID if it is visible in the organization of ctx
+func (r *SubscriptionRepo) organization(ctx context.Context, id int) (models.Organization, bool) {
+	if !inTenant(ctx, id) {
+		return models.Organization{}, false
+	}
+	organization, ok := r.organizations[id]
+	return organization, ok
+}
+
+// get returns the subscription by ID if it is visible in the organization of ctx
+func (r *SubscriptionRepo) get(ctx context.Context, id int) (models.Subscription, bool) {
+	subscription, ok := r.subscriptions[id]
+	if !ok || !r.visible(ctx, &subscription) {
+		return models.Subscription{}, false
+	}
+	return subscription, true
+}
+
+// visible reports whether the subscription belongs to the organization of ctx
+func (r *SubscriptionRepo) visible(ctx context.Context, s *models.Subscription) bool {
+	return inTenant(ctx, s.OrganizationId)
+}
+
+// inTenant reports whether a row of the organization is visible in ctx like tenantCondition in the SQL queries:
+// outside of an organization none are unless ctx bypasses the tenant
+func inTenant(ctx context.Context, organizationId int) bool {
+	id, ok := tenant.Organization(ctx)
+	if !ok {
+		return tenant.Bypassed(ctx)
+	}
+	return organizationId == id
+}
+
+// organizationId returns the organization of ctx to store with a new row, like the NOT NULL organization_id columns it is required
+func organizationId(ctx context.Context) (int, error) {
+	id, ok := tenant.Organization(ctx)
+	if !ok {
+		return 0, errors.New("the row must be created in an organization")
+	}
+	return id, nil
+}
+
+// compareMember orders the members by user ID like the string form Postgres and SQLite sort by
+func compareMember(m models.OrganizationMember, userId uuid.UUID) int {
+	return strings.Compare(m.UserId.String(), userId.String())
+}
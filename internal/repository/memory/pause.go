@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+func (r *SubscriptionRepo) CreatePause(ctx context.Context, p *models.PauseCreate) (int, error) {
+	pause := models.Pause{
+		SubscriptionId: p.SubscriptionId,
+		StartDate:      p.StartDate,
+		EndDate:        p.EndDate,
+	}
+	if !validPausePeriod(&pause) {
+		return 0, repository.ErrIncorrectTime
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//Like the foreign key to the subscription
+	if _, ok := r.get(ctx, p.SubscriptionId); !ok {
+		return 0, repository.ErrNotFound
+	}
+	//Like the pause_no_overlap exclusion constraint
+	for _, other := range r.pauses {
+		if other.SubscriptionId == pause.SubscriptionId && pausesOverlap(&pause, &other) {
+			return 0, repository.ErrOverlap
+		}
+	}
+	r.lastPauseId++
+	pause.Id = r.lastPauseId
+	r.pauses[pause.Id] = pause
+
+	return pause.Id, nil
+}
+
+func (r *SubscriptionRepo) EndPause(ctx context.Context, id int, endDate time.Time) (*models.Pause, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pause, ok := r.pauses[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if _, ok := r.get(ctx, pause.SubscriptionId); !ok {
+		return nil, repository.ErrNotFound
+	}
+	pause.EndDate.Time, pause.EndDate.Valid = endDate, true
+	if !validPausePeriod(&pause) {
+		return nil, repository.ErrIncorrectTime
+	}
+	r.pauses[id] = pause
+
+	return &pause, nil
+}
+
+func (r *SubscriptionRepo) GetPauses(ctx context.Context, subscriptionIds []int) ([]*models.Pause, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var res []*models.Pause
+	for _, p := range r.pauses {
+		if _, ok := r.get(ctx, p.SubscriptionId); ok && slices.Contains(subscriptionIds, p.SubscriptionId) {
+			res = append(res, &p)
+		}
+	}
+	slices.SortFunc(res, func(a, b *models.Pause) int {
+		return cmp.Or(
+			cmp.Compare(a.SubscriptionId, b.SubscriptionId),
+			a.StartDate.Compare(b.StartDate),
+			cmp.Compare(a.Id, b.Id),
+		)
+	})
+
+	return res, nil
+}
+
+// validPausePeriod mirrors the pause_end_date_not_before_start_date check constraint
+func validPausePeriod(p *models.Pause) bool {
+	return !p.EndDate.Valid || !p.EndDate.Time.Before(p.StartDate)
+}
+
+// pausesOverlap mirrors the pause_no_overlap exclusion constraint, both ends included
+func pausesOverlap(a, b *models.Pause) bool {
+	return (!a.EndDate.Valid || !a.EndDate.Time.Before(b.StartDate)) &&
+		(!b.EndDate.Valid || !b.EndDate.Time.Before(a.StartDate))
+}
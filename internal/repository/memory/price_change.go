@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+func (r *SubscriptionRepo) CreatePriceChange(ctx context.Context, p *models.PriceChangeCreate) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//Like the foreign key to the subscription
+	if _, ok := r.get(ctx, p.SubscriptionId); !ok {
+		return 0, repository.ErrNotFound
+	}
+	//Like the price_change_date_key unique constraint
+	for _, other := range r.priceChanges {
+		if other.SubscriptionId == p.SubscriptionId && other.EffectiveDate.Equal(p.EffectiveDate) {
+			return 0, repository.ErrAlreadyExists
+		}
+	}
+	r.lastPriceChangeId++
+	change := models.PriceChange{
+		Id:             r.lastPriceChangeId,
+		SubscriptionId: p.SubscriptionId,
+		EffectiveDate:  p.EffectiveDate,
+		Price:          p.Price,
+	}
+	r.priceChanges[change.Id] = change
+
+	return change.Id, nil
+}
+
+func (r *SubscriptionRepo) GetPriceChanges(ctx context.Context, subscriptionIds []int) ([]*models.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var res []*models.PriceChange
+	for _, c := range r.priceChanges {
+		if _, ok := r.get(ctx, c.SubscriptionId); ok && slices.Contains(subscriptionIds, c.SubscriptionId) {
+			res = append(res, &c)
+		}
+	}
+	slices.SortFunc(res, func(a, b *models.PriceChange) int {
+		return cmp.Or(
+			cmp.Compare(a.SubscriptionId, b.SubscriptionId),
+			a.EffectiveDate.Compare(b.EffectiveDate),
+		)
+	})
+
+	return res, nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) SetSplit(ctx context.Context, split *models.SubscriptionSplit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.get(ctx, split.SubscriptionId); !ok {
+		return repository.ErrNotFound
+	}
+	if len(split.Members) == 0 {
+		delete(r.splits, split.SubscriptionId)
+		return nil
+	}
+	members := slices.Clone(split.Members)
+	slices.SortFunc(members, func(a, b models.SubscriptionMember) int {
+		return strings.Compare(a.UserId.String(), b.UserId.String())
+	})
+	//Mirrors the primary key of the members
+	if len(slices.CompactFunc(slices.Clone(members), func(a, b models.SubscriptionMember) bool { return a.UserId == b.UserId })) != len(members) {
+		return repository.ErrAlreadyExists
+	}
+	r.splits[split.SubscriptionId] = models.SubscriptionSplit{
+		SubscriptionId: split.SubscriptionId,
+		Rule:           split.Rule,
+		Members:        members,
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetSplits(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionSplit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var splits []*models.SubscriptionSplit
+	for _, id := range subscriptionIds {
+		if _, ok := r.get(ctx, id); !ok {
+			continue
+		}
+		if split, ok := r.splits[id]; ok {
+			split.Members = slices.Clone(split.Members)
+			splits = append(splits, &split)
+		}
+	}
+	slices.SortFunc(splits, func(a, b *models.SubscriptionSplit) int {
+		return a.SubscriptionId - b.SubscriptionId
+	})
+
+	return splits, nil
+}
+
+// isMember reports whether the user shares the subscription with its owner
+func (r *SubscriptionRepo) isMember(subscriptionId int, userId uuid.UUID) bool {
+	return slices.ContainsFunc(r.splits[subscriptionId].Members, func(m models.SubscriptionMember) bool {
+		return m.UserId == userId
+	})
+}
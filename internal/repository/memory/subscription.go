@@ -0,0 +1,398 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+// SubscriptionRepo keeps subscriptions in memory with the same semantics as db.SubscriptionRepo
+type SubscriptionRepo struct {
+	mu                sync.RWMutex
+	lastId            int
+	subscriptions     map[int]models.Subscription
+	users             map[uuid.UUID]models.User
+	lastPauseId       int
+	pauses            map[int]models.Pause
+	lastPriceChangeId int
+	priceChanges      map[int]models.PriceChange
+	lastServiceId     int
+	services          map[int]models.Service
+	lastPlanId        int
+	plans             map[int]models.Plan
+	//Seeded with the built-in categories
+	lastCategoryId int
+	categories     map[int]models.Category
+	lastTagId      int
+	tags           map[int]models.Tag
+	//Tag IDs of the subscriptions by subscription ID
+	subscriptionTags map[int][]int
+	//By subscription ID
+	splits map[int]models.SubscriptionSplit
+	//Seeded with the default organization
+	lastOrganizationId int
+	organizations      map[int]models.Organization
+	//Ordered by user ID, by organization ID
+	members      map[int][]models.OrganizationMember
+	lastBudgetId int
+	budgets      map[int]models.Budget
+	//Ordered by ID
+	lastBudgetAlertId int
+	budgetAlerts      []models.BudgetAlert
+	//Append-only, ordered by ID
+	merges     []models.NameMergeAudit
+	dismissals []models.InsightDismissal
+}
+
+func NewSubscriptionRepo() *SubscriptionRepo {
+	r := &SubscriptionRepo{
+		subscriptions:    make(map[int]models.Subscription),
+		users:            make(map[uuid.UUID]models.User),
+		pauses:           make(map[int]models.Pause),
+		priceChanges:     make(map[int]models.PriceChange),
+		services:         make(map[int]models.Service),
+		plans:            make(map[int]models.Plan),
+		categories:       make(map[int]models.Category),
+		tags:             make(map[int]models.Tag),
+		subscriptionTags: make(map[int][]int),
+		splits:           make(map[int]models.SubscriptionSplit),
+		organizations:    make(map[int]models.Organization),
+		members:          make(map[int][]models.OrganizationMember),
+		budgets:          make(map[int]models.Budget),
+	}
+	for _, c := range builtinCategories {
+		r.lastCategoryId++
+		c.Id = r.lastCategoryId
+		r.categories[c.Id] = c
+	}
+	//Like the migration moving the rows created before the organizations to the default one
+	r.lastOrganizationId++
+	r.organizations[r.lastOrganizationId] = models.Organization{Id: r.lastOrganizationId, Name: "Default", CreatedAt: time.Now().UTC()}
+	return r
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, s *models.SubscriptionCreate) (int, error) {
+	organization, err := organizationId(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("memory:SubscriptionRepo.Create - %w", err)
+	}
+	subscription := models.Subscription{
+		ServiceName: s.ServiceName,
+		Price:       s.Price,
+		UserId:      s.UserId,
+		StartDate:   s.StartDate,
+		EndDate:     s.EndDate,
+		BillingDay:  s.BillingDay,
+		TrialMonths: s.TrialMonths,
+		TrialPrice:  s.TrialPrice,
+		ServiceId:   s.ServiceId,
+		PlanId:      s.PlanId,
+		CategoryId:  s.CategoryId,
+		Notes:       s.Notes,
+		//The subscription belongs to the tenant it is created in
+		OrganizationId: organization,
+	}
+	if !validPeriod(&subscription) {
+		return 0, repository.ErrIncorrectTime
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//Like the foreign key of the user
+	if _, ok := r.users[s.UserId]; !ok {
+		return 0, repository.ErrUnknownUser
+	}
+	r.lastId++
+	subscription.Id = r.lastId
+	r.subscriptions[subscription.Id] = subscription
+	if s.Tags != nil {
+		r.setTags(subscription.Id, subscription.UserId, s.Tags)
+	}
+
+	return subscription.Id, nil
+}
+
+func (r *SubscriptionRepo) GetById(ctx context.Context, id int) (*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, ok := r.get(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &subscription, nil
+}
+
+func (r *SubscriptionRepo) GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int) ([]*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions, err := page(r.sorted(ctx, func(s *models.Subscription) bool { return s.UserId == userId }), offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("memory:SubscriptionRepo.GetByUser - %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepo) DeleteById(ctx context.Context, id int) (*models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, ok := r.get(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	delete(r.subscriptions, id)
+	//Like ON DELETE CASCADE
+	for pauseId, p := range r.pauses {
+		if p.SubscriptionId == id {
+			delete(r.pauses, pauseId)
+		}
+	}
+	for changeId, c := range r.priceChanges {
+		if c.SubscriptionId == id {
+			delete(r.priceChanges, changeId)
+		}
+	}
+	delete(r.subscriptionTags, id)
+	delete(r.splits, id)
+
+	return &subscription, nil
+}
+
+func (r *SubscriptionRepo) Update(ctx context.Context, s *models.SubscriptionUpdate) (*models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, ok := r.get(ctx, s.Id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	//Like COALESCE in the SQL query, unset fields keep their values
+	if s.ServiceName.Valid {
+		subscription.ServiceName = s.ServiceName.String
+	}
+	if s.Price.Valid {
+		subscription.Price = int(s.Price.Int32)
+	}
+	if s.StartDate.Valid {
+		subscription.StartDate = s.StartDate.Time
+	}
+	if s.EndDate.Valid {
+		subscription.EndDate = s.EndDate
+	}
+	if s.BillingDay.Valid {
+		subscription.BillingDay = int(s.BillingDay.Int32)
+	}
+	if s.TrialMonths.Valid {
+		subscription.TrialMonths = int(s.TrialMonths.Int32)
+	}
+	if s.TrialPrice.Valid {
+		subscription.TrialPrice = int(s.TrialPrice.Int32)
+	}
+	if s.ServiceId.Valid {
+		subscription.ServiceId = s.ServiceId
+	}
+	if s.PlanId.Valid {
+		subscription.PlanId = s.PlanId
+	}
+	if s.CategoryId.Valid {
+		subscription.CategoryId = s.CategoryId
+	}
+	if s.Notes.Valid {
+		subscription.Notes = s.Notes
+	}
+	if !validPeriod(&subscription) {
+		return nil, repository.ErrIncorrectTime
+	}
+	r.subscriptions[s.Id] = subscription
+	if s.Tags != nil {
+		r.setTags(s.Id, subscription.UserId, s.Tags)
+	}
+
+	return &subscription, nil
+}
+
+func (r *SubscriptionRepo) GetByPeriod(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted(ctx, func(s *models.Subscription) bool {
+		if filter.UserId != nil && s.UserId != *filter.UserId && !(filter.Members && r.isMember(s.Id, *filter.UserId)) {
+			return false
+		}
+		if _, ok := r.splits[s.Id]; filter.Shared && !ok {
+			return false
+		}
+		nameMatches := filter.ServiceName != nil && s.ServiceName == *filter.ServiceName
+		idMatches := filter.ServiceId != nil && s.ServiceId.Valid && int(s.ServiceId.Int32) == *filter.ServiceId
+		if (filter.ServiceName != nil || filter.ServiceId != nil) && !nameMatches && !idMatches {
+			return false
+		}
+		if filter.Trial && s.TrialMonths == 0 {
+			return false
+		}
+		if filter.CategoryIds != nil && !r.inCategories(s, filter.CategoryIds) {
+			return false
+		}
+		if !r.hasTags(s.Id, filter.Tags, filter.ExcludedTags) {
+			return false
+		}
+		return !s.StartDate.After(filter.To) && (!s.EndDate.Valid || !s.EndDate.Time.Before(filter.From))
+	}), nil
+}
+
+func (r *SubscriptionRepo) GetAll(ctx context.Context, offset, limit int) ([]*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions, err := page(r.sorted(ctx, nil), offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("memory:SubscriptionRepo.GetAll - %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepo) CountActive(ctx context.Context, at time.Time) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int
+	for _, s := range r.subscriptions {
+		if r.visible(ctx, &s) && !s.StartDate.After(at) && (!s.EndDate.Valid || !s.EndDate.Time.Before(at)) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *SubscriptionRepo) Cancel(ctx context.Context, c *models.SubscriptionCancel) (*models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, ok := r.get(ctx, c.Id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	subscription.EndDate = sql.NullTime{Time: c.EndDate, Valid: true}
+	subscription.CancelledAt = sql.NullTime{Time: c.CancelledAt, Valid: true}
+	subscription.CancelReason = c.Reason
+	if !validPeriod(&subscription) {
+		return nil, repository.ErrIncorrectTime
+	}
+	r.subscriptions[c.Id] = subscription
+
+	return &subscription, nil
+}
+
+func (r *SubscriptionRepo) GetByState(ctx context.Context, filter *models.StateFilter) ([]*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted(ctx, func(s *models.Subscription) bool { return r.inState(s, filter) }), nil
+}
+
+func (r *SubscriptionRepo) GetPageByState(ctx context.Context, filter *models.StateFilter, offset, limit int) ([]*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return page(r.sorted(ctx, func(s *models.Subscription) bool { return r.inState(s, filter) }), offset, limit)
+}
+
+// inState reports whether the subscription matches the filter
+func (r *SubscriptionRepo) inState(s *models.Subscription, filter *models.StateFilter) bool {
+	if filter.UserId != nil && s.UserId != *filter.UserId {
+		return false
+	}
+	if filter.Cancelled != nil && s.CancelledAt.Valid != *filter.Cancelled {
+		return false
+	}
+	if filter.EndedBefore != nil && (!s.EndDate.Valid || !s.EndDate.Time.Before(*filter.EndedBefore)) {
+		return false
+	}
+	if filter.ActiveOn != nil && s.EndDate.Valid && s.EndDate.Time.Before(*filter.ActiveOn) {
+		return false
+	}
+	day := filter.StatusDay
+	if s.StartDate.After(day) {
+		day = s.StartDate
+	}
+	if filter.Paused != nil && r.pausedOn(s.Id, day) != *filter.Paused {
+		return false
+	}
+	if filter.Trialing != nil && (s.TrialMonths > 0 && !day.After(trialEndDate(s))) != *filter.Trialing {
+		return false
+	}
+	if filter.CategoryIds != nil && !r.inCategories(s, filter.CategoryIds) {
+		return false
+	}
+	return r.hasTags(s.Id, filter.Tags, filter.ExcludedTags)
+}
+
+// pausedOn reports whether a pause of the subscription covers the day
+func (r *SubscriptionRepo) pausedOn(subscriptionId int, day time.Time) bool {
+	for _, p := range r.pauses {
+		if p.SubscriptionId == subscriptionId && !day.Before(p.StartDate) && (!p.EndDate.Valid || !day.After(p.EndDate.Time)) {
+			return true
+		}
+	}
+	return false
+}
+
+// trialEndDate mirrors the SQL of the other repositories: the day before the billing day,
+// clamped to the month length, trial_months months after the month the first billing cycle starts in
+func trialEndDate(s *models.Subscription) time.Time {
+	month := time.Date(s.StartDate.Year(), s.StartDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if min(s.BillingDay, month.AddDate(0, 1, -1).Day()) > s.StartDate.Day() {
+		month = month.AddDate(0, -1, 0)
+	}
+	month = month.AddDate(0, s.TrialMonths, 0)
+	return month.AddDate(0, 0, min(s.BillingDay, month.AddDate(0, 1, -1).Day())-2)
+}
+
+// sorted returns copies of the subscriptions visible in ctx matching filter ordered by ID
+func (r *SubscriptionRepo) sorted(ctx context.Context, filter func(s *models.Subscription) bool) []*models.Subscription {
+	var res []*models.Subscription
+	for _, s := range r.subscriptions {
+		if r.visible(ctx, &s) && (filter == nil || filter(&s)) {
+			res = append(res, &s)
+		}
+	}
+	slices.SortFunc(res, func(a, b *models.Subscription) int { return a.Id - b.Id })
+
+	return res
+}
+
+// page applies OFFSET and LIMIT, rejecting negative values like Postgres does
+func page(subscriptions []*models.Subscription, offset, limit int) ([]*models.Subscription, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("OFFSET must not be negative")
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("LIMIT must not be negative")
+	}
+	if offset >= len(subscriptions) {
+		return nil, nil
+	}
+
+	return subscriptions[offset:min(offset+limit, len(subscriptions))], nil
+}
+
+// validPeriod mirrors the end_date_not_before_start_date check constraint
+func validPeriod(s *models.Subscription) bool {
+	return !s.EndDate.Valid || !s.EndDate.Time.Before(s.StartDate)
+}
@@ -0,0 +1,68 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/Estriper0/subscription_service/internal/repository/memory"
+	"github.com/Estriper0/subscription_service/internal/repository/repotest"
+)
+
+func TestSubscriptionRepo(t *testing.T) {
+	repotest.SubscriptionRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestCatalogRepo(t *testing.T) {
+	repotest.CatalogRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestNameRepo(t *testing.T) {
+	repotest.NameRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestCategoryRepo(t *testing.T) {
+	repotest.CategoryRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestTagRepo(t *testing.T) {
+	repotest.TagRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestBudgetRepo(t *testing.T) {
+	repotest.BudgetRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestInsightRepo(t *testing.T) {
+	repotest.InsightRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestSplitRepo(t *testing.T) {
+	repotest.SplitRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestOrganizationRepo(t *testing.T) {
+	repotest.OrganizationRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
+
+func TestUserRepo(t *testing.T) {
+	repotest.UserRepo(t, func(t *testing.T) repotest.Repo {
+		return memory.NewSubscriptionRepo()
+	})
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateTag(ctx context.Context, t *models.TagCreate) (int, error) {
+	organization, err := organizationId(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("memory:SubscriptionRepo.CreateTag - %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.findTag(organization, t.UserId, t.Name); ok {
+		return 0, repository.ErrAlreadyExists
+	}
+
+	//The tag belongs to the tenant it is created in
+	return r.createTag(organization, t.UserId, t.Name), nil
+}
+
+func (r *SubscriptionRepo) GetTag(ctx context.Context, id int) (*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tag(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return r.withCount(tag), nil
+}
+
+func (r *SubscriptionRepo) GetTags(ctx context.Context, userId uuid.UUID) ([]*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tags []*models.Tag
+	for _, t := range r.tags {
+		if t.UserId == userId && inTenant(ctx, t.OrganizationId) {
+			tags = append(tags, r.withCount(t))
+		}
+	}
+	slices.SortFunc(tags, func(a, b *models.Tag) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return tags, nil
+}
+
+func (r *SubscriptionRepo) UpdateTag(ctx context.Context, t *models.TagUpdate) (*models.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tag, ok := r.tag(ctx, t.Id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if other, ok := r.findTag(tag.OrganizationId, tag.UserId, t.Name); ok && other.Id != tag.Id {
+		return nil, repository.ErrAlreadyExists
+	}
+	tag.Name = t.Name
+	r.tags[tag.Id] = tag
+
+	return r.withCount(tag), nil
+}
+
+func (r *SubscriptionRepo) DeleteTag(ctx context.Context, id int) (*models.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tag, ok := r.tag(ctx, id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	deleted := r.withCount(tag)
+	delete(r.tags, id)
+	//The tag is removed from the subscriptions with it
+	for subscriptionId, tagIds := range r.subscriptionTags {
+		r.subscriptionTags[subscriptionId] = slices.DeleteFunc(tagIds, func(tagId int) bool { return tagId == id })
+	}
+
+	return deleted, nil
+}
+
+func (r *SubscriptionRepo) SetSubscriptionTags(ctx context.Context, subscriptionId int, names []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, ok := r.get(ctx, subscriptionId)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	r.setTags(subscriptionId, subscription.UserId, names)
+
+	return nil
+}
+
+// setTags replaces the tags of the user's subscription, creating the missing ones in the organization of the subscription
+func (r *SubscriptionRepo) setTags(subscriptionId int, userId uuid.UUID, names []string) {
+	organizationId := r.subscriptions[subscriptionId].OrganizationId
+	var tagIds []int
+	for _, name := range names {
+		tagId := 0
+		if tag, ok := r.findTag(organizationId, userId, name); ok {
+			tagId = tag.Id
+		} else {
+			tagId = r.createTag(organizationId, userId, name)
+		}
+		if !slices.Contains(tagIds, tagId) {
+			tagIds = append(tagIds, tagId)
+		}
+	}
+	r.subscriptionTags[subscriptionId] = tagIds
+}
+
+func (r *SubscriptionRepo) GetSubscriptionTags(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionTag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tags []*models.SubscriptionTag
+	for _, subscriptionId := range subscriptionIds {
+		if _, ok := r.get(ctx, subscriptionId); !ok {
+			continue
+		}
+		for _, tagId := range r.subscriptionTags[subscriptionId] {
+			tags = append(tags, &models.SubscriptionTag{
+				SubscriptionId: subscriptionId,
+				TagId:          tagId,
+				Name:           r.tags[tagId].Name,
+			})
+		}
+	}
+	slices.SortFunc(tags, func(a, b *models.SubscriptionTag) int {
+		if a.SubscriptionId != b.SubscriptionId {
+			return a.SubscriptionId - b.SubscriptionId
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return tags, nil
+}
+
+func (r *SubscriptionRepo) createTag(organizationId int, userId uuid.UUID, name string) int {
+	r.lastTagId++
+	r.tags[r.lastTagId] = models.Tag{Id: r.lastTagId, UserId: userId, Name: name, OrganizationId: organizationId}
+	return r.lastTagId
+}
+
+// tag returns the tag by ID if it is visible in the organization of ctx
+func (r *SubscriptionRepo) tag(ctx context.Context, id int) (models.Tag, bool) {
+	tag, ok := r.tags[id]
+	if !ok || !inTenant(ctx, tag.OrganizationId) {
+		return models.Tag{}, false
+	}
+	return tag, true
+}
+
+// findTag mirrors the unique index on the lowercased names of the user's tags in the organization
+func (r *SubscriptionRepo) findTag(organizationId int, userId uuid.UUID, name string) (models.Tag, bool) {
+	for _, t := range r.tags {
+		if t.OrganizationId == organizationId && t.UserId == userId && strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return models.Tag{}, false
+}
+
+func (r *SubscriptionRepo) withCount(tag models.Tag) *models.Tag {
+	for _, tagIds := range r.subscriptionTags {
+		if slices.Contains(tagIds, tag.Id) {
+			tag.Subscriptions++
+		}
+	}
+	return &tag
+}
+
+// hasTags reports whether the subscription has all of the tags and none of the excluded ones
+func (r *SubscriptionRepo) hasTags(subscriptionId int, tags, excluded []string) bool {
+	has := func(name string) bool {
+		return slices.ContainsFunc(r.subscriptionTags[subscriptionId], func(tagId int) bool {
+			return strings.EqualFold(r.tags[tagId].Name, name)
+		})
+	}
+	for _, name := range tags {
+		if !has(name) {
+			return false
+		}
+	}
+	for _, name := range excluded {
+		if has(name) {
+			return false
+		}
+	}
+	return true
+}
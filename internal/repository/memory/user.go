@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateUser(ctx context.Context, u *models.UserCreate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[u.Id]; ok || r.emailTaken(u.Id, u.Email) {
+		return repository.ErrAlreadyExists
+	}
+	r.users[u.Id] = models.User{
+		Id:          u.Id,
+		DisplayName: u.DisplayName,
+		Email:       u.Email,
+		Locale:      u.Locale,
+		Currency:    u.Currency,
+		Timezone:    u.Timezone,
+		CreatedAt:   u.CreatedAt.UTC(),
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (r *SubscriptionRepo) GetUsers(ctx context.Context, offset, limit int) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("memory:SubscriptionRepo.GetUsers - OFFSET and LIMIT must not be negative")
+	}
+	var users []*models.User
+	for _, u := range r.users {
+		users = append(users, &u)
+	}
+	//Like ORDER BY on the UUID column
+	slices.SortFunc(users, func(a, b *models.User) int { return strings.Compare(a.Id.String(), b.Id.String()) })
+	if offset >= len(users) {
+		return nil, nil
+	}
+
+	return users[offset:min(offset+limit, len(users))], nil
+}
+
+func (r *SubscriptionRepo) UpdateUser(ctx context.Context, u *models.UserUpdate) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[u.Id]
+	if !ok || user.AnonymizedAt.Valid {
+		return nil, repository.ErrNotFound
+	}
+	if !u.ClearEmail && u.Email.Valid && r.emailTaken(u.Id, u.Email) {
+		return nil, repository.ErrAlreadyExists
+	}
+
+	//Like COALESCE in the SQL query, unset fields keep their values
+	if u.DisplayName.Valid {
+		user.DisplayName = u.DisplayName.String
+	}
+	if u.ClearEmail {
+		user.Email = sql.NullString{}
+	} else if u.Email.Valid {
+		user.Email = u.Email
+	}
+	if u.Locale.Valid {
+		user.Locale = u.Locale.String
+	}
+	if u.Currency.Valid {
+		user.Currency = u.Currency.String
+	}
+	if u.Timezone.Valid {
+		user.Timezone = u.Timezone.String
+	}
+	r.users[u.Id] = user
+
+	return &user, nil
+}
+
+func (r *SubscriptionRepo) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	for subscriptionId, s := range r.subscriptions {
+		if s.UserId != id {
+			continue
+		}
+		delete(r.subscriptions, subscriptionId)
+		//Like ON DELETE CASCADE
+		for pauseId, p := range r.pauses {
+			if p.SubscriptionId == subscriptionId {
+				delete(r.pauses, pauseId)
+			}
+		}
+		for changeId, c := range r.priceChanges {
+			if c.SubscriptionId == subscriptionId {
+				delete(r.priceChanges, changeId)
+			}
+		}
+		delete(r.subscriptionTags, subscriptionId)
+		delete(r.splits, subscriptionId)
+	}
+	r.deletePersonalData(id)
+	delete(r.users, id)
+
+	return &user, nil
+}
+
+func (r *SubscriptionRepo) AnonymizeUser(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.AnonymizedAt.Valid {
+		return nil, repository.ErrNotFound
+	}
+	user.DisplayName = ""
+	user.Email = sql.NullString{}
+	user.AnonymizedAt = sql.NullTime{Time: at.UTC(), Valid: true}
+	r.users[id] = user
+
+	//The free text of the subscriptions may hold personal data
+	for subscriptionId, s := range r.subscriptions {
+		if s.UserId == id {
+			s.Notes = sql.NullString{}
+			s.CancelReason = sql.NullString{}
+			r.subscriptions[subscriptionId] = s
+		}
+	}
+	r.deletePersonalData(id)
+
+	return &user, nil
+}
+
+// emailTaken mirrors the unique index on the lowercased email, a missing email is never taken
+func (r *SubscriptionRepo) emailTaken(id uuid.UUID, email sql.NullString) bool {
+	if !email.Valid {
+		return false
+	}
+	for _, u := range r.users {
+		if u.Id != id && u.Email.Valid && strings.EqualFold(u.Email.String, email.String) {
+			return true
+		}
+	}
+	return false
+}
+
+// deletePersonalData removes the rows of the user besides the subscriptions,
+// the splits left without members stop sharing
+func (r *SubscriptionRepo) deletePersonalData(id uuid.UUID) {
+	isUser := func(m models.SubscriptionMember) bool { return m.UserId == id }
+	for subscriptionId, split := range r.splits {
+		if !slices.ContainsFunc(split.Members, isUser) {
+			continue
+		}
+		split.Members = slices.DeleteFunc(split.Members, isUser)
+		if len(split.Members) == 0 {
+			delete(r.splits, subscriptionId)
+		} else {
+			r.splits[subscriptionId] = split
+		}
+	}
+	for organizationId, members := range r.members {
+		r.members[organizationId] = slices.DeleteFunc(members, func(m models.OrganizationMember) bool { return m.UserId == id })
+	}
+	for budgetId, b := range r.budgets {
+		if b.UserId == id {
+			r.deleteBudget(budgetId)
+		}
+	}
+	for tagId, t := range r.tags {
+		if t.UserId != id {
+			continue
+		}
+		delete(r.tags, tagId)
+		for subscriptionId, tagIds := range r.subscriptionTags {
+			r.subscriptionTags[subscriptionId] = slices.DeleteFunc(tagIds, func(other int) bool { return other == tagId })
+		}
+	}
+	for categoryId, c := range r.categories {
+		if c.UserId.Valid && c.UserId.UUID == id {
+			r.deleteCategory(categoryId)
+		}
+	}
+	r.dismissals = slices.DeleteFunc(r.dismissals, func(d models.InsightDismissal) bool { return d.UserId == id })
+}
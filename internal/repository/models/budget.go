@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget is a monthly spending limit of the user, overall without a category and a service
+type Budget struct {
+	Id         int
+	UserId     uuid.UUID
+	Amount     int
+	CategoryId sql.NullInt32
+	ServiceId  sql.NullInt32
+	//The tenant, taken from the context on creation
+	OrganizationId int
+}
+
+type BudgetCreate struct {
+	UserId     uuid.UUID
+	Amount     int
+	CategoryId sql.NullInt32
+	ServiceId  sql.NullInt32
+}
+
+type BudgetUpdate struct {
+	Id     int
+	Amount int
+}
+
+// BudgetAlert is a record of the budget reaching a threshold in a month
+type BudgetAlert struct {
+	Id       int
+	BudgetId int
+	//First day of the month
+	Month time.Time
+	//Percent of the amount
+	Threshold int
+	Spent     int
+	Amount    int
+	AlertedAt time.Time
+}
+
+type BudgetAlertCreate struct {
+	BudgetId  int
+	Month     time.Time
+	Threshold int
+	Spent     int
+	Amount    int
+	AlertedAt time.Time
+}
@@ -0,0 +1,55 @@
+package models
+
+import "database/sql"
+
+// Service is a catalog entry, subscriptions are linked to it by ID
+type Service struct {
+	Id   int
+	Name string
+	//Other names the service is known by, ordered
+	Aliases    []string
+	CategoryId sql.NullInt32
+	Website    sql.NullString
+	LogoUrl    sql.NullString
+}
+
+type ServiceCreate struct {
+	Name       string
+	Aliases    []string
+	CategoryId sql.NullInt32
+	Website    sql.NullString
+	LogoUrl    sql.NullString
+}
+
+type ServiceUpdate struct {
+	Id   int
+	Name sql.NullString
+	//Replace the aliases unless nil
+	Aliases    []string
+	CategoryId sql.NullInt32
+	Website    sql.NullString
+	LogoUrl    sql.NullString
+}
+
+type Plan struct {
+	Id        int
+	ServiceId int
+	Name      string
+	//Charged every billing period
+	Price         int
+	BillingPeriod string
+}
+
+type PlanCreate struct {
+	ServiceId     int
+	Name          string
+	Price         int
+	BillingPeriod string
+}
+
+type PlanUpdate struct {
+	Id            int
+	Name          sql.NullString
+	Price         sql.NullInt32
+	BillingPeriod sql.NullString
+}
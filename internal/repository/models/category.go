@@ -0,0 +1,34 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Category groups the subscriptions and services by what the money is spent on.
+// Built-in categories have no user and are shared by everyone.
+type Category struct {
+	Id       int
+	Name     string
+	ParentId sql.NullInt32
+	UserId   uuid.NullUUID
+}
+
+type CategoryCreate struct {
+	Name     string
+	ParentId sql.NullInt32
+	UserId   uuid.NullUUID
+}
+
+type CategoryUpdate struct {
+	Id       int
+	Name     sql.NullString
+	ParentId sql.NullInt32
+}
+
+// ServiceCategory is the category of a catalog service
+type ServiceCategory struct {
+	ServiceId  int
+	CategoryId int
+}
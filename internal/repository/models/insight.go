@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InsightDismissal is a record of the user dismissing an insight about their subscriptions
+type InsightDismissal struct {
+	UserId      uuid.UUID
+	InsightId   string
+	DismissedAt time.Time
+	//The tenant, taken from the context on creation
+	OrganizationId int
+}
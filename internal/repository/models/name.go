@@ -0,0 +1,39 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ServiceName is a spelling of a service name with the number of subscriptions using it
+type ServiceName struct {
+	Name          string
+	Subscriptions int
+}
+
+// NamePair is a pair of names with their trigram similarity, A < B
+type NamePair struct {
+	A          string
+	B          string
+	Similarity float64
+}
+
+type NameMerge struct {
+	Canonical string
+	//Spellings replaced by the canonical name
+	Names []string
+	//Replaces the service of the subscriptions, their plans of another service are unlinked
+	ServiceId sql.NullInt32
+	MergedAt  time.Time
+}
+
+// NameMergeAudit is a record of a merge
+type NameMergeAudit struct {
+	Id            int
+	Canonical     string
+	Names         []string
+	Subscriptions int
+	MergedAt      time.Time
+	//The tenant the merge was done in, none for the merges of all the organizations
+	OrganizationId sql.NullInt32
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a tenant grouping the users, e.g. a company or a household
+type Organization struct {
+	Id        int
+	Name      string
+	CreatedAt time.Time
+}
+
+type OrganizationCreate struct {
+	Name      string
+	CreatedAt time.Time
+}
+
+type OrganizationUpdate struct {
+	Id   int
+	Name string
+}
+
+type OrganizationMember struct {
+	OrganizationId int
+	UserId         uuid.UUID
+	JoinedAt       time.Time
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Pause is an interval of days, both included, the subscription is not billed for
+type Pause struct {
+	Id             int
+	SubscriptionId int
+	StartDate      time.Time
+	//Not set while the subscription is paused until further notice
+	EndDate sql.NullTime
+}
+
+type PauseCreate struct {
+	SubscriptionId int
+	StartDate      time.Time
+	EndDate        sql.NullTime
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PriceChange is a price the subscription is billed at from the effective date on
+type PriceChange struct {
+	Id             int
+	SubscriptionId int
+	EffectiveDate  time.Time
+	Price          int
+}
+
+type PriceChangeCreate struct {
+	SubscriptionId int
+	EffectiveDate  time.Time
+	Price          int
+}
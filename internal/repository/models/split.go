@@ -0,0 +1,22 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionSplit shares the cost of a subscription between its owner and the members
+type SubscriptionSplit struct {
+	SubscriptionId int
+	//equal, percentage or fixed
+	Rule string
+	//Ordered by user ID
+	Members []SubscriptionMember
+}
+
+type SubscriptionMember struct {
+	UserId uuid.UUID
+	//Percent or amount per charge by the rule, not set for the equal split
+	Share sql.NullInt32
+}
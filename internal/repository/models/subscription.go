@@ -14,6 +14,22 @@ type Subscription struct {
 	UserId      uuid.UUID
 	StartDate   time.Time
 	EndDate     sql.NullTime
+	//Day of the month the subscription renews on, 1-31
+	BillingDay int
+	//The first TrialMonths billing cycles are charged TrialPrice
+	TrialMonths int
+	TrialPrice  int
+	//Day the subscription was cancelled on, it ends on EndDate
+	CancelledAt  sql.NullTime
+	CancelReason sql.NullString
+	//Catalog entries, not set for services missing from the catalog
+	ServiceId sql.NullInt32
+	PlanId    sql.NullInt32
+	//Without it the subscription has the category of its service
+	CategoryId sql.NullInt32
+	Notes      sql.NullString
+	//The tenant, taken from the context on creation
+	OrganizationId int
 }
 
 type SubscriptionCreate struct {
@@ -22,6 +38,15 @@ type SubscriptionCreate struct {
 	UserId      uuid.UUID
 	StartDate   time.Time
 	EndDate     sql.NullTime
+	BillingDay  int
+	TrialMonths int
+	TrialPrice  int
+	ServiceId   sql.NullInt32
+	PlanId      sql.NullInt32
+	CategoryId  sql.NullInt32
+	Notes       sql.NullString
+	//Linked in the same transaction, the missing tags of the user are created
+	Tags []string
 }
 
 type SubscriptionUpdate struct {
@@ -30,4 +55,63 @@ type SubscriptionUpdate struct {
 	Price       sql.NullInt32
 	StartDate   sql.NullTime
 	EndDate     sql.NullTime
+	BillingDay  sql.NullInt32
+	TrialMonths sql.NullInt32
+	TrialPrice  sql.NullInt32
+	ServiceId   sql.NullInt32
+	PlanId      sql.NullInt32
+	CategoryId  sql.NullInt32
+	Notes       sql.NullString
+	//Replace the tags in the same transaction unless nil
+	Tags []string
+}
+
+// SubscriptionFilter selects the subscriptions active at any day between From and To inclusive.
+// With both ServiceName and ServiceId set a subscription matches either of them.
+type SubscriptionFilter struct {
+	UserId      *uuid.UUID
+	ServiceName *string
+	ServiceId   *int
+	From        time.Time
+	To          time.Time
+	//Only the subscriptions with a trial
+	Trial bool
+	//Only the subscriptions in any of the categories, directly or by their service
+	CategoryIds []int
+	//Only the subscriptions with all of the Tags and none of the ExcludedTags, regardless of case
+	Tags         []string
+	ExcludedTags []string
+	//With UserId, also the subscriptions the user is a member of
+	Members bool
+	//Only the subscriptions split between users
+	Shared bool
+}
+
+// SubscriptionCancel sets the end date of the subscription and records the cancellation
+type SubscriptionCancel struct {
+	Id          int
+	EndDate     time.Time
+	CancelledAt time.Time
+	Reason      sql.NullString
+}
+
+// StateFilter selects the subscriptions by the state their status is computed from
+type StateFilter struct {
+	UserId    *uuid.UUID
+	Cancelled *bool
+	//Only the subscriptions that ended before the day
+	EndedBefore *time.Time
+	//Only the subscriptions that have not ended before the day
+	ActiveOn *time.Time
+	//Only the subscriptions paused or not on StatusDay
+	Paused *bool
+	//Only the subscriptions in the trial or not on StatusDay
+	Trialing *bool
+	//The day Paused and Trialing are checked on, or the start date if the subscription starts later
+	StatusDay time.Time
+	//Only the subscriptions in any of the categories, directly or by their service
+	CategoryIds []int
+	//Only the subscriptions with all of the Tags and none of the ExcludedTags, regardless of case
+	Tags         []string
+	ExcludedTags []string
 }
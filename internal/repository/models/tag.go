@@ -0,0 +1,31 @@
+package models
+
+import "github.com/google/uuid"
+
+// Tag is a label of the user's subscriptions
+type Tag struct {
+	Id     int
+	UserId uuid.UUID
+	Name   string
+	//Number of the subscriptions with the tag
+	Subscriptions int
+	//The tenant, taken from the context on creation
+	OrganizationId int
+}
+
+type TagCreate struct {
+	UserId uuid.UUID
+	Name   string
+}
+
+type TagUpdate struct {
+	Id   int
+	Name string
+}
+
+// SubscriptionTag is a tag of a subscription
+type SubscriptionTag struct {
+	SubscriptionId int
+	TagId          int
+	Name           string
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is a registered user the subscriptions belong to
+type User struct {
+	Id          uuid.UUID
+	DisplayName string
+	Email       sql.NullString
+	Locale      string
+	Currency    string
+	Timezone    string
+	CreatedAt   time.Time
+	//Set when the personal data has been erased and the subscriptions are kept
+	AnonymizedAt sql.NullTime
+}
+
+type UserCreate struct {
+	Id          uuid.UUID
+	DisplayName string
+	Email       sql.NullString
+	Locale      string
+	Currency    string
+	Timezone    string
+	CreatedAt   time.Time
+}
+
+type UserUpdate struct {
+	Id          uuid.UUID
+	DisplayName sql.NullString
+	Email       sql.NullString
+	Locale      sql.NullString
+	Currency    sql.NullString
+	Timezone    sql.NullString
+	//Removes the email, Email is ignored
+	ClearEmail bool
+}
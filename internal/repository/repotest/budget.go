@@ -0,0 +1,196 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+// BudgetRepo checks the behaviour every service.IBudgetRepo implementation must have.
+// newRepo must return an empty repository on every call.
+func BudgetRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("CRUD", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix"})
+		overall := mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userA, Amount: 1000})
+		video := mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userA, Amount: 500, CategoryId: nullInt(videoCategory)})
+		mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userA, Amount: 300, ServiceId: nullInt(netflix)})
+		mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userB, Amount: 2000})
+
+		got, err := repo.GetBudget(ctx, video)
+		if err != nil {
+			t.Fatalf("GetBudget: %v", err)
+		}
+		if got.UserId != userA || got.Amount != 500 || got.CategoryId != nullInt(videoCategory) || got.ServiceId.Valid {
+			t.Fatalf("GetBudget = %+v", *got)
+		}
+
+		//A user has one budget per scope, the overall one included
+		for _, b := range []*models.BudgetCreate{
+			{UserId: userA, Amount: 1},
+			{UserId: userA, Amount: 1, CategoryId: nullInt(videoCategory)},
+			{UserId: userA, Amount: 1, ServiceId: nullInt(netflix)},
+		} {
+			_, err = repo.CreateBudget(ctx, b)
+			if !errors.Is(err, repository.ErrAlreadyExists) {
+				t.Fatalf("CreateBudget(%+v) for a taken scope: err = %v, want ErrAlreadyExists", *b, err)
+			}
+		}
+		_, err = repo.CreateBudget(ctx, &models.BudgetCreate{UserId: userA, Amount: 1, ServiceId: nullInt(1000)})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("CreateBudget for a missing service: err = %v, want ErrNotFound", err)
+		}
+
+		budgets, err := repo.GetBudgets(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetBudgets: %v", err)
+		}
+		if len(budgets) != 3 || budgets[0].Id != overall || budgets[1].Id != video {
+			t.Fatalf("GetBudgets returned %d budgets, want 3 ordered by id", len(budgets))
+		}
+
+		updated, err := repo.UpdateBudget(ctx, &models.BudgetUpdate{Id: overall, Amount: 1200})
+		if err != nil {
+			t.Fatalf("UpdateBudget: %v", err)
+		}
+		if updated.Amount != 1200 || updated.UserId != userA {
+			t.Fatalf("UpdateBudget = %+v", *updated)
+		}
+		_, err = repo.UpdateBudget(ctx, &models.BudgetUpdate{Id: 1000, Amount: 1})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateBudget of a missing budget: err = %v, want ErrNotFound", err)
+		}
+
+		deleted, err := repo.DeleteBudget(ctx, overall)
+		if err != nil {
+			t.Fatalf("DeleteBudget: %v", err)
+		}
+		if deleted.Id != overall || deleted.Amount != 1200 {
+			t.Fatalf("DeleteBudget = %+v", *deleted)
+		}
+		_, err = repo.GetBudget(ctx, overall)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetBudget of a deleted budget: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.DeleteBudget(ctx, overall)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteBudget of a deleted budget: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Alerts", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		overall := mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userA, Amount: 1000})
+		other := mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userB, Amount: 1000})
+		alertedAt := time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC)
+
+		alert, err := repo.CreateBudgetAlert(ctx, &models.BudgetAlertCreate{BudgetId: overall, Month: Month(2026, 10), Threshold: 80, Spent: 850, Amount: 1000, AlertedAt: alertedAt})
+		if err != nil {
+			t.Fatalf("CreateBudgetAlert: %v", err)
+		}
+		if alert.BudgetId != overall || !alert.Month.Equal(Month(2026, 10)) || alert.Threshold != 80 || alert.Spent != 850 || alert.Amount != 1000 || !alert.AlertedAt.Equal(alertedAt) {
+			t.Fatalf("CreateBudgetAlert = %+v", *alert)
+		}
+
+		//A threshold is alerted once a month
+		_, err = repo.CreateBudgetAlert(ctx, &models.BudgetAlertCreate{BudgetId: overall, Month: Month(2026, 10), Threshold: 80, Spent: 900, Amount: 1000, AlertedAt: alertedAt})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("CreateBudgetAlert of an alerted threshold: err = %v, want ErrAlreadyExists", err)
+		}
+		mustCreateBudgetAlert(t, repo, &models.BudgetAlertCreate{BudgetId: overall, Month: Month(2026, 10), Threshold: 100, Spent: 1100, Amount: 1000, AlertedAt: alertedAt})
+		mustCreateBudgetAlert(t, repo, &models.BudgetAlertCreate{BudgetId: overall, Month: Month(2026, 11), Threshold: 80, Spent: 850, Amount: 1000, AlertedAt: alertedAt})
+		mustCreateBudgetAlert(t, repo, &models.BudgetAlertCreate{BudgetId: other, Month: Month(2026, 10), Threshold: 80, Spent: 850, Amount: 1000, AlertedAt: alertedAt})
+
+		_, err = repo.CreateBudgetAlert(ctx, &models.BudgetAlertCreate{BudgetId: 1000, Month: Month(2026, 10), Threshold: 80, AlertedAt: alertedAt})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("CreateBudgetAlert of a missing budget: err = %v, want ErrNotFound", err)
+		}
+
+		alerts, err := repo.GetBudgetAlerts(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetBudgetAlerts: %v", err)
+		}
+		if len(alerts) != 3 || alerts[0].Id != alert.Id || alerts[1].Threshold != 100 || !alerts[2].Month.Equal(Month(2026, 11)) {
+			t.Fatalf("GetBudgetAlerts returned %d alerts, want 3 ordered by id", len(alerts))
+		}
+
+		//The alerts are deleted with the budget
+		mustDeleteBudget(t, repo, overall)
+		alerts, err = repo.GetBudgetAlerts(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetBudgetAlerts: %v", err)
+		}
+		if len(alerts) != 0 {
+			t.Fatalf("GetBudgetAlerts after the budget is deleted returned %d alerts", len(alerts))
+		}
+	})
+
+	t.Run("Cascade", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix"})
+		hobby := mustCreateCategory(t, repo, &models.CategoryCreate{UserId: nullUser(userA), Name: "Hobby"})
+		byService := mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userA, Amount: 300, ServiceId: nullInt(netflix)})
+		byCategory := mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userA, Amount: 300, CategoryId: nullInt(hobby)})
+		mustCreateBudgetAlert(t, repo, &models.BudgetAlertCreate{BudgetId: byService, Month: Month(2026, 10), Threshold: 80, AlertedAt: Month(2026, 10)})
+
+		//A budget is deleted with the service or category it limits
+		if _, err := repo.DeleteService(ctx, netflix); err != nil {
+			t.Fatalf("DeleteService: %v", err)
+		}
+		if _, err := repo.DeleteCategory(ctx, hobby); err != nil {
+			t.Fatalf("DeleteCategory: %v", err)
+		}
+		for _, id := range []int{byService, byCategory} {
+			_, err := repo.GetBudget(ctx, id)
+			if !errors.Is(err, repository.ErrNotFound) {
+				t.Fatalf("GetBudget(%d) after its scope is deleted: err = %v, want ErrNotFound", id, err)
+			}
+		}
+		alerts, err := repo.GetBudgetAlerts(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetBudgetAlerts: %v", err)
+		}
+		if len(alerts) != 0 {
+			t.Fatalf("GetBudgetAlerts after the budget is deleted returned %d alerts", len(alerts))
+		}
+	})
+}
+
+func mustCreateBudget(t *testing.T, repo Repo, b *models.BudgetCreate) int {
+	t.Helper()
+
+	id, err := repo.CreateBudget(tenantContext(), b)
+	if err != nil {
+		t.Fatalf("CreateBudget(%+v): %v", *b, err)
+	}
+	return id
+}
+
+func mustCreateBudgetAlert(t *testing.T, repo Repo, a *models.BudgetAlertCreate) {
+	t.Helper()
+
+	_, err := repo.CreateBudgetAlert(tenantContext(), a)
+	if err != nil {
+		t.Fatalf("CreateBudgetAlert(%+v): %v", *a, err)
+	}
+}
+
+func mustDeleteBudget(t *testing.T, repo Repo, id int) {
+	t.Helper()
+
+	_, err := repo.DeleteBudget(tenantContext(), id)
+	if err != nil {
+		t.Fatalf("DeleteBudget(%d): %v", id, err)
+	}
+}
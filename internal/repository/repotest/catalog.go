@@ -0,0 +1,336 @@
+package repotest
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service"
+)
+
+// Repo is a repository storing the subscriptions, the service catalog and the name merges
+type Repo interface {
+	service.ISubscriptionRepo
+	service.ICatalogRepo
+	service.ICategoryRepo
+	service.ITagRepo
+	service.INameRepo
+	service.IBudgetRepo
+	service.IInsightRepo
+	service.ISplitRepo
+	service.IOrganizationRepo
+	service.IUserRepo
+}
+
+// CatalogRepo checks the behaviour every service.ICatalogRepo implementation must have.
+// newRepo must return an empty repository on every call.
+func CatalogRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("Services", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{
+			Name:       "Netflix",
+			Aliases:    []string{"nflx", "Netflix Inc"},
+			CategoryId: sql.NullInt32{Int32: videoCategory, Valid: true},
+			Website:    sql.NullString{String: "https://netflix.com", Valid: true},
+		})
+		spotify := mustCreateService(t, repo, &models.ServiceCreate{Name: "Spotify"})
+
+		got, err := repo.GetService(ctx, netflix)
+		if err != nil {
+			t.Fatalf("GetService: %v", err)
+		}
+		if got.Name != "Netflix" || !slices.Equal(got.Aliases, []string{"Netflix Inc", "nflx"}) ||
+			got.CategoryId.Int32 != videoCategory || got.Website.String != "https://netflix.com" || got.LogoUrl.Valid {
+			t.Fatalf("GetService = %+v", *got)
+		}
+		got, err = repo.GetService(ctx, spotify)
+		if err != nil {
+			t.Fatalf("GetService: %v", err)
+		}
+		if got.Aliases == nil || len(got.Aliases) != 0 {
+			t.Fatalf("aliases of a service without them = %#v, want empty", got.Aliases)
+		}
+		_, err = repo.GetService(ctx, spotify+100)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetService of a missing service: got %v, want ErrNotFound", err)
+		}
+
+		for _, s := range []*models.ServiceCreate{
+			{Name: "NETFLIX"},
+			{Name: "Apple Music", Aliases: []string{"NFLX"}},
+			{Name: "YouTube", Aliases: []string{"yt", "YT"}},
+		} {
+			_, err = repo.CreateService(ctx, s)
+			if !errors.Is(err, repository.ErrAlreadyExists) {
+				t.Fatalf("CreateService(%+v): got %v, want ErrAlreadyExists", *s, err)
+			}
+		}
+
+		for name, want := range map[string]int{"netflix": netflix, "NFLX": netflix, "netflix inc": netflix, "SPOTIFY": spotify} {
+			found, err := repo.FindService(ctx, name)
+			if err != nil {
+				t.Fatalf("FindService(%q): %v", name, err)
+			}
+			if found.Id != want {
+				t.Fatalf("FindService(%q) = %d, want %d", name, found.Id, want)
+			}
+		}
+		_, err = repo.FindService(ctx, "Hulu")
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("FindService of a missing name: got %v, want ErrNotFound", err)
+		}
+
+		services, err := repo.GetServices(ctx, 0, 10)
+		if err != nil {
+			t.Fatalf("GetServices: %v", err)
+		}
+		assertServiceIds(t, services, []int{netflix, spotify})
+		services, err = repo.GetServices(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("GetServices: %v", err)
+		}
+		assertServiceIds(t, services, []int{spotify})
+	})
+
+	t.Run("UpdateService", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix", Aliases: []string{"nflx"}, CategoryId: sql.NullInt32{Int32: videoCategory, Valid: true}})
+		spotify := mustCreateService(t, repo, &models.ServiceCreate{Name: "Spotify", Aliases: []string{"spot"}})
+
+		got, err := repo.UpdateService(ctx, &models.ServiceUpdate{Id: netflix, Website: sql.NullString{String: "https://netflix.com", Valid: true}})
+		if err != nil {
+			t.Fatalf("UpdateService: %v", err)
+		}
+		if got.Name != "Netflix" || !slices.Equal(got.Aliases, []string{"nflx"}) || got.CategoryId.Int32 != videoCategory || got.Website.String != "https://netflix.com" {
+			t.Fatalf("UpdateService kept fields: %+v", *got)
+		}
+
+		got, err = repo.UpdateService(ctx, &models.ServiceUpdate{Id: netflix, Name: sql.NullString{String: "Netflix Premium", Valid: true}, Aliases: []string{}})
+		if err != nil {
+			t.Fatalf("UpdateService: %v", err)
+		}
+		if got.Name != "Netflix Premium" || len(got.Aliases) != 0 {
+			t.Fatalf("UpdateService = %+v", *got)
+		}
+
+		_, err = repo.UpdateService(ctx, &models.ServiceUpdate{Id: netflix, Aliases: []string{"Spot"}})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("UpdateService with a taken alias: got %v, want ErrAlreadyExists", err)
+		}
+		_, err = repo.UpdateService(ctx, &models.ServiceUpdate{Id: spotify, Name: sql.NullString{String: "netflix premium", Valid: true}})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("UpdateService with a taken name: got %v, want ErrAlreadyExists", err)
+		}
+		_, err = repo.UpdateService(ctx, &models.ServiceUpdate{Id: spotify + 100, Name: sql.NullString{String: "Hulu", Valid: true}})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateService of a missing service: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Plans", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix"})
+		spotify := mustCreateService(t, repo, &models.ServiceCreate{Name: "Spotify"})
+
+		premium := mustCreatePlan(t, repo, &models.PlanCreate{ServiceId: netflix, Name: "Premium", Price: 999, BillingPeriod: "month"})
+		family := mustCreatePlan(t, repo, &models.PlanCreate{ServiceId: spotify, Name: "Family", Price: 2400, BillingPeriod: "year"})
+		basic := mustCreatePlan(t, repo, &models.PlanCreate{ServiceId: netflix, Name: "Basic", Price: 499, BillingPeriod: "month"})
+
+		got, err := repo.GetPlan(ctx, family)
+		if err != nil {
+			t.Fatalf("GetPlan: %v", err)
+		}
+		want := models.Plan{Id: family, ServiceId: spotify, Name: "Family", Price: 2400, BillingPeriod: "year"}
+		if *got != want {
+			t.Fatalf("GetPlan = %+v, want %+v", *got, want)
+		}
+
+		_, err = repo.CreatePlan(ctx, &models.PlanCreate{ServiceId: netflix, Name: "Premium", Price: 1, BillingPeriod: "month"})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("CreatePlan with a taken name: got %v, want ErrAlreadyExists", err)
+		}
+		_, err = repo.CreatePlan(ctx, &models.PlanCreate{ServiceId: spotify + 100, Name: "Premium", Price: 1, BillingPeriod: "month"})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("CreatePlan of a missing service: got %v, want ErrNotFound", err)
+		}
+
+		plans, err := repo.GetPlans(ctx, []int{spotify, netflix})
+		if err != nil {
+			t.Fatalf("GetPlans: %v", err)
+		}
+		assertPlanIds(t, plans, []int{premium, basic, family})
+		plans, err = repo.GetPlans(ctx, nil)
+		if err != nil {
+			t.Fatalf("GetPlans: %v", err)
+		}
+		assertPlanIds(t, plans, nil)
+
+		got, err = repo.UpdatePlan(ctx, &models.PlanUpdate{Id: basic, Price: sql.NullInt32{Int32: 599, Valid: true}})
+		if err != nil {
+			t.Fatalf("UpdatePlan: %v", err)
+		}
+		want = models.Plan{Id: basic, ServiceId: netflix, Name: "Basic", Price: 599, BillingPeriod: "month"}
+		if *got != want {
+			t.Fatalf("UpdatePlan = %+v, want %+v", *got, want)
+		}
+		_, err = repo.UpdatePlan(ctx, &models.PlanUpdate{Id: basic, Name: sql.NullString{String: "Premium", Valid: true}})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("UpdatePlan with a taken name: got %v, want ErrAlreadyExists", err)
+		}
+
+		id := mustCreate(t, repo, &models.SubscriptionCreate{
+			ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1,
+			ServiceId: sql.NullInt32{Int32: int32(netflix), Valid: true},
+			PlanId:    sql.NullInt32{Int32: int32(basic), Valid: true},
+		})
+		deleted, err := repo.DeletePlan(ctx, basic)
+		if err != nil {
+			t.Fatalf("DeletePlan: %v", err)
+		}
+		if deleted.Id != basic {
+			t.Fatalf("DeletePlan returned plan %d, want %d", deleted.Id, basic)
+		}
+		s := mustGet(t, repo, id)
+		if s.PlanId.Valid || !s.ServiceId.Valid {
+			t.Fatalf("after DeletePlan the subscription has service %v and plan %v, want the plan unset", s.ServiceId, s.PlanId)
+		}
+		_, err = repo.GetPlan(ctx, basic)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetPlan after delete: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("DeleteService", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix", Aliases: []string{"nflx"}})
+		premium := mustCreatePlan(t, repo, &models.PlanCreate{ServiceId: netflix, Name: "Premium", Price: 999, BillingPeriod: "month"})
+		id := mustCreate(t, repo, &models.SubscriptionCreate{
+			ServiceName: "Netflix", Price: 999, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1,
+			ServiceId: sql.NullInt32{Int32: int32(netflix), Valid: true},
+			PlanId:    sql.NullInt32{Int32: int32(premium), Valid: true},
+		})
+
+		deleted, err := repo.DeleteService(ctx, netflix)
+		if err != nil {
+			t.Fatalf("DeleteService: %v", err)
+		}
+		if deleted.Id != netflix || !slices.Equal(deleted.Aliases, []string{"nflx"}) {
+			t.Fatalf("DeleteService = %+v", *deleted)
+		}
+		_, err = repo.GetPlan(ctx, premium)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetPlan after DeleteService: got %v, want ErrNotFound", err)
+		}
+		s := mustGet(t, repo, id)
+		if s.ServiceId.Valid || s.PlanId.Valid {
+			t.Fatalf("after DeleteService the subscription has service %v and plan %v, want both unset", s.ServiceId, s.PlanId)
+		}
+		_, err = repo.FindService(ctx, "nflx")
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("FindService after DeleteService: got %v, want ErrNotFound", err)
+		}
+		_, err = repo.DeleteService(ctx, netflix)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteService twice: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("LinkSubscriptions", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix", Aliases: []string{"nflx"}})
+		other := mustCreateService(t, repo, &models.ServiceCreate{Name: "Other"})
+
+		lower := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "netflix", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		alias := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "NFLX", Price: 100, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1})
+		linked := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, ServiceId: sql.NullInt32{Int32: int32(other), Valid: true}})
+		spotify := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		n, err := repo.LinkSubscriptions(ctx, netflix, []string{"Netflix", "nflx"})
+		if err != nil {
+			t.Fatalf("LinkSubscriptions: %v", err)
+		}
+		if n != 2 {
+			t.Fatalf("LinkSubscriptions linked %d subscriptions, want 2", n)
+		}
+		for id, want := range map[int]int{lower: netflix, alias: netflix, linked: other, spotify: 0} {
+			s := mustGet(t, repo, id)
+			if int(s.ServiceId.Int32) != want || s.ServiceId.Valid != (want != 0) {
+				t.Fatalf("subscription %d has service %v, want %d", id, s.ServiceId, want)
+			}
+		}
+
+		got, err := repo.GetByPeriod(ctx, &models.SubscriptionFilter{ServiceId: &netflix, From: Month(2026, 1), To: Month(2026, 6)})
+		if err != nil {
+			t.Fatalf("GetByPeriod: %v", err)
+		}
+		assertIds(t, got, []int{lower, alias})
+		//A subscription matches the name or the service
+		name := "Spotify"
+		got, err = repo.GetByPeriod(ctx, &models.SubscriptionFilter{ServiceName: &name, ServiceId: &netflix, From: Month(2026, 1), To: Month(2026, 6)})
+		if err != nil {
+			t.Fatalf("GetByPeriod: %v", err)
+		}
+		assertIds(t, got, []int{lower, alias, spotify})
+	})
+}
+
+func mustCreateService(t *testing.T, repo Repo, s *models.ServiceCreate) int {
+	t.Helper()
+
+	id, err := repo.CreateService(tenantContext(), s)
+	if err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+	return id
+}
+
+func mustCreatePlan(t *testing.T, repo Repo, p *models.PlanCreate) int {
+	t.Helper()
+
+	id, err := repo.CreatePlan(tenantContext(), p)
+	if err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	return id
+}
+
+func assertServiceIds(t *testing.T, got []*models.Service, want []int) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d services, want %v", len(got), want)
+	}
+	for i := range got {
+		if got[i].Id != want[i] {
+			t.Fatalf("service %d has ID %d, want %v", i, got[i].Id, want)
+		}
+	}
+}
+
+func assertPlanIds(t *testing.T, got []*models.Plan, want []int) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d plans, want %v", len(got), want)
+	}
+	for i := range got {
+		if got[i].Id != want[i] {
+			t.Fatalf("plan %d has ID %d, want %v", i, got[i].Id, want)
+		}
+	}
+}
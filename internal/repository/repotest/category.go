@@ -0,0 +1,264 @@
+package repotest
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+// IDs of the built-in categories seeded by the migration
+const (
+	streamingCategory = 1
+	softwareCategory  = 2
+	videoCategory     = 7
+	musicCategory     = 8
+	builtinCategories = 10
+)
+
+// CategoryRepo checks the behaviour every service.ICategoryRepo implementation must have.
+// newRepo must return an empty repository with only the built-in categories on every call.
+func CategoryRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("BuiltIn", func(t *testing.T) {
+		repo := newRepo(t)
+
+		got, err := repo.GetCategories(tenantContext(), nil)
+		if err != nil {
+			t.Fatalf("GetCategories: %v", err)
+		}
+		if len(got) != builtinCategories {
+			t.Fatalf("got %d built-in categories, want %d", len(got), builtinCategories)
+		}
+		for i, c := range got {
+			if c.Id != i+1 || c.UserId.Valid {
+				t.Fatalf("category %d = %+v, want a built-in one with ID %d", i, *c, i+1)
+			}
+		}
+		if got[0].Name != "Streaming" || got[0].ParentId.Valid {
+			t.Fatalf("category 1 = %+v, want top-level Streaming", *got[0])
+		}
+		if got[videoCategory-1].Name != "Video" || got[videoCategory-1].ParentId.Int32 != streamingCategory {
+			t.Fatalf("category %d = %+v, want Video under Streaming", videoCategory, *got[videoCategory-1])
+		}
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		podcasts := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Podcasts", ParentId: nullInt(musicCategory), UserId: nullUser(userA)})
+		got, err := repo.GetCategory(ctx, podcasts)
+		if err != nil {
+			t.Fatalf("GetCategory: %v", err)
+		}
+		if got.Name != "Podcasts" || got.ParentId.Int32 != musicCategory || got.UserId.UUID != userA {
+			t.Fatalf("GetCategory = %+v", *got)
+		}
+
+		//Names are unique per user and parent without regard to case
+		_, err = repo.CreateCategory(ctx, &models.CategoryCreate{Name: "podcasts", ParentId: nullInt(musicCategory), UserId: nullUser(userA)})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("CreateCategory with a taken name: err = %v, want ErrAlreadyExists", err)
+		}
+		mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Podcasts", ParentId: nullInt(musicCategory), UserId: nullUser(userB)})
+		mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Podcasts", UserId: nullUser(userA)})
+
+		_, err = repo.CreateCategory(ctx, &models.CategoryCreate{Name: "Orphan", ParentId: nullInt(1000), UserId: nullUser(userA)})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("CreateCategory with a missing parent: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.GetCategory(ctx, 1000)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetCategory of a missing category: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("GetCategories", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		a := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Work", UserId: nullUser(userA)})
+		b := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Family", UserId: nullUser(userB)})
+
+		got, err := repo.GetCategories(ctx, &userA)
+		if err != nil {
+			t.Fatalf("GetCategories: %v", err)
+		}
+		if len(got) != builtinCategories+1 || got[builtinCategories].Id != a {
+			t.Fatalf("got %d categories of user A, want the built-in ones and %d", len(got), a)
+		}
+
+		got, err = repo.GetCategories(ctx, nil)
+		if err != nil {
+			t.Fatalf("GetCategories: %v", err)
+		}
+		if len(got) != builtinCategories+2 || got[builtinCategories].Id != a || got[builtinCategories+1].Id != b {
+			t.Fatalf("got %d categories of all users, want the built-in ones, %d and %d", len(got), a, b)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		work := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Work", UserId: nullUser(userA)})
+		tools := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Tools", UserId: nullUser(userA)})
+
+		got, err := repo.UpdateCategory(ctx, &models.CategoryUpdate{Id: tools, ParentId: nullInt(work)})
+		if err != nil {
+			t.Fatalf("UpdateCategory: %v", err)
+		}
+		if got.Name != "Tools" || got.ParentId.Int32 != int32(work) || got.UserId.UUID != userA {
+			t.Fatalf("UpdateCategory moved: %+v", *got)
+		}
+
+		got, err = repo.UpdateCategory(ctx, &models.CategoryUpdate{Id: tools, Name: sql.NullString{String: "Dev tools", Valid: true}})
+		if err != nil {
+			t.Fatalf("UpdateCategory: %v", err)
+		}
+		if got.Name != "Dev tools" || got.ParentId.Int32 != int32(work) {
+			t.Fatalf("UpdateCategory renamed: %+v", *got)
+		}
+
+		mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Games", UserId: nullUser(userA)})
+		_, err = repo.UpdateCategory(ctx, &models.CategoryUpdate{Id: work, Name: sql.NullString{String: "games", Valid: true}})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("UpdateCategory with a taken name: err = %v, want ErrAlreadyExists", err)
+		}
+		_, err = repo.UpdateCategory(ctx, &models.CategoryUpdate{Id: tools, ParentId: nullInt(1000)})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateCategory with a missing parent: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.UpdateCategory(ctx, &models.CategoryUpdate{Id: 1000, Name: sql.NullString{String: "Missing", Valid: true}})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateCategory of a missing category: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		work := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Work", UserId: nullUser(userA)})
+		tools := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Tools", ParentId: nullInt(work), UserId: nullUser(userA)})
+		kept := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Slack", Price: 700, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, CategoryId: nullInt(tools)})
+
+		got, err := repo.DeleteCategory(ctx, work)
+		if err != nil {
+			t.Fatalf("DeleteCategory: %v", err)
+		}
+		if got.Id != work || got.Name != "Work" {
+			t.Fatalf("DeleteCategory = %+v", *got)
+		}
+		_, err = repo.GetCategory(ctx, tools)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetCategory of a subcategory of a deleted category: err = %v, want ErrNotFound", err)
+		}
+		if s := mustGet(t, repo, kept); s.CategoryId.Valid {
+			t.Fatalf("subscription kept the deleted category %d", s.CategoryId.Int32)
+		}
+
+		_, err = repo.DeleteCategory(ctx, work)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteCategory of a missing category: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ServiceCategories", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix", CategoryId: nullInt(videoCategory)})
+		mustCreateService(t, repo, &models.ServiceCreate{Name: "Notion"})
+		spotify := mustCreateService(t, repo, &models.ServiceCreate{Name: "Spotify", CategoryId: nullInt(musicCategory)})
+
+		got, err := repo.GetServiceCategories(ctx, []int{spotify, netflix, 1000})
+		if err != nil {
+			t.Fatalf("GetServiceCategories: %v", err)
+		}
+		want := []models.ServiceCategory{{ServiceId: netflix, CategoryId: videoCategory}, {ServiceId: spotify, CategoryId: musicCategory}}
+		if len(got) != len(want) {
+			t.Fatalf("got %d service categories, want %v", len(got), want)
+		}
+		for i := range got {
+			if *got[i] != want[i] {
+				t.Fatalf("service category %d = %+v, want %+v", i, *got[i], want[i])
+			}
+		}
+
+		got, err = repo.GetServiceCategories(ctx, nil)
+		if err != nil {
+			t.Fatalf("GetServiceCategories: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("GetServiceCategories without services = %v, want none", got)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix", CategoryId: nullInt(videoCategory)})
+		byService := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, ServiceId: nullInt(netflix)})
+		//The own category of a subscription takes precedence over the one of its service
+		overridden := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1, ServiceId: nullInt(netflix), CategoryId: nullInt(softwareCategory)})
+		direct := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 299, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, CategoryId: nullInt(musicCategory)})
+		mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Gym", Price: 2000, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		tests := []struct {
+			name string
+			ids  []int
+			want []int
+		}{
+			{"by service", []int{videoCategory}, []int{byService}},
+			{"own", []int{softwareCategory}, []int{overridden}},
+			{"several", []int{videoCategory, musicCategory}, []int{byService, direct}},
+			{"none", []int{}, nil},
+			{"no filter", nil, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				want := tt.want
+				if tt.ids == nil {
+					want = []int{byService, overridden, direct, direct + 1}
+				}
+
+				got, err := repo.GetByPeriod(ctx, &models.SubscriptionFilter{From: Month(2026, 1), To: Month(2026, 12), CategoryIds: tt.ids})
+				if err != nil {
+					t.Fatalf("GetByPeriod: %v", err)
+				}
+				assertIds(t, got, want)
+
+				got, err = repo.GetByState(ctx, &models.StateFilter{CategoryIds: tt.ids})
+				if err != nil {
+					t.Fatalf("GetByState: %v", err)
+				}
+				assertIds(t, got, want)
+			})
+		}
+	})
+}
+
+func mustCreateCategory(t *testing.T, repo Repo, c *models.CategoryCreate) int {
+	t.Helper()
+
+	id, err := repo.CreateCategory(tenantContext(), c)
+	if err != nil {
+		t.Fatalf("CreateCategory(%q): %v", c.Name, err)
+	}
+	return id
+}
+
+func nullInt(v int) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(v), Valid: true}
+}
+
+func nullUser(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: true}
+}
@@ -0,0 +1,46 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+// InsightRepo checks the behaviour every service.IInsightRepo implementation must have.
+// newRepo must return an empty repository on every call.
+func InsightRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("Dismiss", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		first := time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC)
+		for _, d := range []*models.InsightDismissal{
+			{UserId: userA, InsightId: "price_jump-1-2", DismissedAt: first},
+			{UserId: userA, InsightId: "duplicate-1-2", DismissedAt: first},
+			{UserId: userB, InsightId: "duplicate-1-2", DismissedAt: first},
+			//Dismissing again keeps the first dismissal
+			{UserId: userA, InsightId: "duplicate-1-2", DismissedAt: first.Add(time.Hour)},
+		} {
+			if err := repo.DismissInsight(ctx, d); err != nil {
+				t.Fatalf("DismissInsight(%+v): %v", *d, err)
+			}
+		}
+
+		got, err := repo.GetInsightDismissals(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetInsightDismissals: %v", err)
+		}
+		want := []string{"duplicate-1-2", "price_jump-1-2"}
+		if len(got) != len(want) {
+			t.Fatalf("got %d dismissals, want %d", len(got), len(want))
+		}
+		for i, d := range got {
+			if d.UserId != userA || d.InsightId != want[i] || !d.DismissedAt.Equal(first) {
+				t.Fatalf("dismissal %d = %+v, want %s dismissed at %s", i, *d, want[i], first)
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package repotest
+
+import (
+	"database/sql"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+// NameRepo checks the behaviour every service.INameRepo implementation must have.
+// newRepo must return an empty repository on every call.
+func NameRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("GetServiceNames", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, name := range []string{"netflix", "Netflix", "Netflix", "Spotify"} {
+			mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: name, Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		}
+
+		got, err := repo.GetServiceNames(tenantContext())
+		if err != nil {
+			t.Fatalf("GetServiceNames: %v", err)
+		}
+		want := []models.ServiceName{{Name: "Netflix", Subscriptions: 2}, {Name: "Spotify", Subscriptions: 1}, {Name: "netflix", Subscriptions: 1}}
+		if len(got) != len(want) {
+			t.Fatalf("got %d names, want %v", len(got), want)
+		}
+		for i := range got {
+			if *got[i] != want[i] {
+				t.Fatalf("name %d = %+v, want %+v", i, *got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("SimilarNames", func(t *testing.T) {
+		repo := newRepo(t)
+
+		//The values are the ones of similarity() in pg_trgm
+		tests := []struct {
+			name      string
+			names     []string
+			threshold float64
+			want      []models.NamePair
+		}{
+			{"typo", []string{"netflix", "netflx"}, 0.3, []models.NamePair{{A: "netflix", B: "netflx", Similarity: 0.5}}},
+			{"words", []string{"youtube premium", "youtube"}, 0.3, []models.NamePair{{A: "youtube", B: "youtube premium", Similarity: 0.5}}},
+			{"ordered pairs", []string{"spotify", "netflix", "netflix", "netflx", "netfix"}, 0.3, []models.NamePair{
+				{A: "netfix", B: "netflix", Similarity: 0.5},
+				{A: "netfix", B: "netflx", Similarity: 0.4},
+				{A: "netflix", B: "netflx", Similarity: 0.5},
+			}},
+			{"below threshold", []string{"netflix", "netflx"}, 0.6, nil},
+			{"unrelated", []string{"netflix", "spotify"}, 0.1, nil},
+			{"empty", nil, 0.3, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := repo.SimilarNames(tenantContext(), tt.names, tt.threshold)
+				if err != nil {
+					t.Fatalf("SimilarNames: %v", err)
+				}
+				if len(got) != len(tt.want) {
+					t.Fatalf("got %d pairs, want %v", len(got), tt.want)
+				}
+				for i, want := range tt.want {
+					if got[i].A != want.A || got[i].B != want.B || math.Abs(got[i].Similarity-want.Similarity) > 1e-6 {
+						t.Fatalf("pair %d = %+v, want %+v", i, *got[i], want)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("MergeServiceNames", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		netflix := mustCreateService(t, repo, &models.ServiceCreate{Name: "Netflix"})
+		other := mustCreateService(t, repo, &models.ServiceCreate{Name: "Other"})
+		otherPlan := mustCreatePlan(t, repo, &models.PlanCreate{ServiceId: other, Name: "Basic", Price: 100, BillingPeriod: "month"})
+		netflixPlan := mustCreatePlan(t, repo, &models.PlanCreate{ServiceId: netflix, Name: "Basic", Price: 100, BillingPeriod: "month"})
+		lower := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "netflix", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		typo := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflx", Price: 100, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1,
+			ServiceId: sql.NullInt32{Int32: int32(other), Valid: true}, PlanId: sql.NullInt32{Int32: int32(otherPlan), Valid: true}})
+		upper := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "NETFLIX", Price: 100, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1,
+			ServiceId: sql.NullInt32{Int32: int32(netflix), Valid: true}, PlanId: sql.NullInt32{Int32: int32(netflixPlan), Valid: true}})
+		spotify := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		mergedAt := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+		audit, err := repo.MergeServiceNames(ctx, &models.NameMerge{
+			Canonical: "Netflix",
+			Names:     []string{"netflix", "Netflx", "NETFLIX"},
+			ServiceId: sql.NullInt32{Int32: int32(netflix), Valid: true},
+			MergedAt:  mergedAt,
+		})
+		if err != nil {
+			t.Fatalf("MergeServiceNames: %v", err)
+		}
+		if audit.Id == 0 || audit.Canonical != "Netflix" || audit.Subscriptions != 3 || !audit.MergedAt.Equal(mergedAt) {
+			t.Fatalf("audit = %+v, want 3 subscriptions merged into Netflix", *audit)
+		}
+
+		//The service is replaced and the plan of another service is unlinked
+		for id, want := range map[int]struct {
+			name    string
+			service int
+			plan    int
+		}{lower: {"Netflix", netflix, 0}, typo: {"Netflix", netflix, 0}, upper: {"Netflix", netflix, netflixPlan}, spotify: {"Spotify", 0, 0}} {
+			s := mustGet(t, repo, id)
+			if s.ServiceName != want.name || int(s.ServiceId.Int32) != want.service || int(s.PlanId.Int32) != want.plan {
+				t.Fatalf("subscription %d is %s of the service %v and the plan %v, want %s of %d and %d", id, s.ServiceName, s.ServiceId, s.PlanId, want.name, want.service, want.plan)
+			}
+		}
+
+		second, err := repo.MergeServiceNames(ctx, &models.NameMerge{Canonical: "Spotify", Names: []string{"Spotfy"}, MergedAt: mergedAt.Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("MergeServiceNames: %v", err)
+		}
+		if second.Subscriptions != 0 {
+			t.Fatalf("merged %d subscriptions without the name, want 0", second.Subscriptions)
+		}
+
+		merges, err := repo.GetNameMerges(ctx, 0, 10)
+		if err != nil {
+			t.Fatalf("GetNameMerges: %v", err)
+		}
+		if len(merges) != 2 || merges[0].Id != audit.Id || merges[1].Id != second.Id {
+			t.Fatalf("got %d merges, want %d and %d", len(merges), audit.Id, second.Id)
+		}
+		got := merges[0]
+		if got.Canonical != "Netflix" || !slices.Equal(got.Names, []string{"netflix", "Netflx", "NETFLIX"}) || got.Subscriptions != 3 || !got.MergedAt.Equal(mergedAt) {
+			t.Fatalf("merge = %+v, want %+v", *got, *audit)
+		}
+		merges, err = repo.GetNameMerges(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("GetNameMerges: %v", err)
+		}
+		if len(merges) != 1 || merges[0].Id != second.Id {
+			t.Fatalf("second page has %d merges, want %d", len(merges), second.Id)
+		}
+	})
+}
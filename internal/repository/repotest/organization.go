@@ -0,0 +1,396 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// OrganizationRepo checks the behaviour every service.IOrganizationRepo implementation must have
+// and that the subscriptions are scoped by the organization of the context.
+// newRepo must return an empty repository on every call.
+func OrganizationRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("CRUD", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := bypassContext()
+
+		family := mustCreateOrganization(t, repo, "Family")
+		company := mustCreateOrganization(t, repo, "Company")
+
+		got, err := repo.GetOrganization(ctx, family)
+		if err != nil {
+			t.Fatalf("GetOrganization: %v", err)
+		}
+		if got.Id != family || got.Name != "Family" || !got.CreatedAt.Equal(Month(2026, 10)) {
+			t.Fatalf("organization = %+v, want Family created on %v", *got, Month(2026, 10))
+		}
+
+		updated, err := repo.UpdateOrganization(ctx, &models.OrganizationUpdate{Id: company, Name: "Acme"})
+		if err != nil {
+			t.Fatalf("UpdateOrganization: %v", err)
+		}
+		if updated.Name != "Acme" {
+			t.Fatalf("updated name = %q, want Acme", updated.Name)
+		}
+		_, err = repo.UpdateOrganization(ctx, &models.OrganizationUpdate{Id: company + 100, Name: "Acme"})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateOrganization of a missing organization: err = %v, want ErrNotFound", err)
+		}
+
+		//Another organization is invisible inside an organization
+		_, err = repo.GetOrganization(tenant.WithOrganization(ctx, family), company)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetOrganization of another organization: err = %v, want ErrNotFound", err)
+		}
+		//Without an organization and the bypass none is visible
+		_, err = repo.GetOrganization(context.Background(), family)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetOrganization without an organization: err = %v, want ErrNotFound", err)
+		}
+
+		mustAddMember(t, repo, family, userA)
+		mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		mustCreateIn(t, repo, family, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 300, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		_, err = repo.DeleteOrganization(ctx, family)
+		if !errors.Is(err, repository.ErrInUse) {
+			t.Fatalf("DeleteOrganization with subscriptions: err = %v, want ErrInUse", err)
+		}
+
+		deleted, err := repo.DeleteOrganization(ctx, company)
+		if err != nil {
+			t.Fatalf("DeleteOrganization: %v", err)
+		}
+		if deleted.Id != company {
+			t.Fatalf("deleted organization %d, want %d", deleted.Id, company)
+		}
+		_, err = repo.GetOrganization(ctx, company)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetOrganization after delete: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.DeleteOrganization(ctx, company)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteOrganization twice: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Members", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := bypassContext()
+
+		family := mustCreateOrganization(t, repo, "Family")
+		company := mustCreateOrganization(t, repo, "Company")
+
+		mustAddMember(t, repo, family, userC)
+		mustAddMember(t, repo, family, userA)
+		//Adding twice keeps the member
+		mustAddMember(t, repo, family, userA)
+		mustAddMember(t, repo, company, userA)
+
+		assertMembers(t, repo, family, []uuid.UUID{userA, userC})
+		assertMembers(t, repo, company, []uuid.UUID{userA})
+
+		err := repo.AddMember(ctx, &models.OrganizationMember{OrganizationId: company + 100, UserId: userB, JoinedAt: Month(2026, 10)})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("AddMember to a missing organization: err = %v, want ErrNotFound", err)
+		}
+
+		organizations, err := repo.GetOrganizations(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetOrganizations: %v", err)
+		}
+		if len(organizations) != 2 || organizations[0].Id != family || organizations[1].Id != company {
+			t.Fatalf("organizations of the user = %v, want [%d %d]", organizations, family, company)
+		}
+		organizations, err = repo.GetOrganizations(tenant.WithOrganization(ctx, company), userA)
+		if err != nil {
+			t.Fatalf("GetOrganizations in an organization: %v", err)
+		}
+		if len(organizations) != 1 || organizations[0].Id != company {
+			t.Fatalf("organizations of the user in %d = %v, want only it", company, organizations)
+		}
+
+		member, err := repo.IsMember(ctx, family, userC)
+		if err != nil || !member {
+			t.Fatalf("IsMember = %v, %v, want true", member, err)
+		}
+		member, err = repo.IsMember(ctx, company, userC)
+		if err != nil || member {
+			t.Fatalf("IsMember of another organization = %v, %v, want false", member, err)
+		}
+
+		if err := repo.RemoveMember(ctx, family, userC); err != nil {
+			t.Fatalf("RemoveMember: %v", err)
+		}
+		assertMembers(t, repo, family, []uuid.UUID{userA})
+		err = repo.RemoveMember(ctx, family, userC)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("RemoveMember twice: err = %v, want ErrNotFound", err)
+		}
+
+		//Deleting the organization deletes its members
+		if _, err := repo.DeleteOrganization(ctx, company); err != nil {
+			t.Fatalf("DeleteOrganization: %v", err)
+		}
+		assertMembers(t, repo, company, nil)
+	})
+
+	t.Run("Isolation", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		family := mustCreateOrganization(t, repo, "Family")
+		company := mustCreateOrganization(t, repo, "Company")
+		familyCtx := tenant.WithOrganization(ctx, family)
+
+		personal := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		shared := mustCreateIn(t, repo, family, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 300, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		work := mustCreateIn(t, repo, company, &models.SubscriptionCreate{ServiceName: "Slack", Price: 700, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		//Create stamps the organization of the context
+		got, err := repo.GetById(familyCtx, shared)
+		if err != nil {
+			t.Fatalf("GetById in an organization: %v", err)
+		}
+		if got.OrganizationId != family {
+			t.Fatalf("organization of the subscription = %d, want %d", got.OrganizationId, family)
+		}
+		if got := mustGet(t, repo, personal); got.OrganizationId != defaultOrganization {
+			t.Fatalf("organization of the personal subscription = %d, want %d", got.OrganizationId, defaultOrganization)
+		}
+
+		//With the bypass all the subscriptions are visible, without an organization none
+		all, err := repo.GetByUser(bypassContext(), userA, 0, 10)
+		if err != nil {
+			t.Fatalf("GetByUser with the bypass: %v", err)
+		}
+		assertIds(t, all, []int{personal, shared, work})
+		none, err := repo.GetByUser(ctx, userA, 0, 10)
+		if err != nil {
+			t.Fatalf("GetByUser without an organization: %v", err)
+		}
+		assertIds(t, none, nil)
+		_, err = repo.GetById(ctx, personal)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetById without an organization: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.Create(ctx, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		if err == nil {
+			t.Fatalf("Create without an organization succeeded")
+		}
+
+		visible, err := repo.GetByUser(familyCtx, userA, 0, 10)
+		if err != nil {
+			t.Fatalf("GetByUser in an organization: %v", err)
+		}
+		assertIds(t, visible, []int{shared})
+		visible, err = repo.GetAll(familyCtx, 0, 10)
+		if err != nil {
+			t.Fatalf("GetAll in an organization: %v", err)
+		}
+		assertIds(t, visible, []int{shared})
+		count, err := repo.CountActive(familyCtx, Month(2026, 3))
+		if err != nil {
+			t.Fatalf("CountActive in an organization: %v", err)
+		}
+		if count != 1 {
+			t.Fatalf("CountActive in an organization = %d, want 1", count)
+		}
+
+		_, err = repo.GetById(familyCtx, work)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetById of another organization's subscription: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.DeleteById(familyCtx, work)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteById of another organization's subscription: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.CreatePause(familyCtx, &models.PauseCreate{SubscriptionId: work, StartDate: Month(2026, 2)})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("CreatePause of another organization's subscription: err = %v, want ErrNotFound", err)
+		}
+
+		//The child rows of the other organizations are invisible as well
+		pause, err := repo.CreatePause(tenant.WithOrganization(ctx, company), &models.PauseCreate{SubscriptionId: work, StartDate: Month(2026, 2), EndDate: End(Month(2026, 2))})
+		if err != nil {
+			t.Fatalf("CreatePause: %v", err)
+		}
+		pauses, err := repo.GetPauses(familyCtx, []int{shared, work})
+		if err != nil {
+			t.Fatalf("GetPauses in an organization: %v", err)
+		}
+		assertPauseIds(t, pauses, nil)
+		pauses, err = repo.GetPauses(bypassContext(), []int{shared, work})
+		if err != nil {
+			t.Fatalf("GetPauses with the bypass: %v", err)
+		}
+		assertPauseIds(t, pauses, []int{pause})
+	})
+
+	t.Run("Tenancy", func(t *testing.T) {
+		repo := newRepo(t)
+
+		family := mustCreateOrganization(t, repo, "Family")
+		company := mustCreateOrganization(t, repo, "Company")
+		familyCtx := tenant.WithOrganization(context.Background(), family)
+		companyCtx := tenant.WithOrganization(context.Background(), company)
+
+		//The budgets and tags of a user are kept per organization, the same scope and name are allowed in each
+		familyBudget, err := repo.CreateBudget(familyCtx, &models.BudgetCreate{UserId: userA, Amount: 1000})
+		if err != nil {
+			t.Fatalf("CreateBudget: %v", err)
+		}
+		companyBudget, err := repo.CreateBudget(companyCtx, &models.BudgetCreate{UserId: userA, Amount: 2000})
+		if err != nil {
+			t.Fatalf("CreateBudget in another organization: %v", err)
+		}
+		budgets, err := repo.GetBudgets(familyCtx, userA)
+		if err != nil {
+			t.Fatalf("GetBudgets: %v", err)
+		}
+		if len(budgets) != 1 || budgets[0].Id != familyBudget || budgets[0].OrganizationId != family {
+			t.Fatalf("budgets in the organization = %v, want only %d", budgets, familyBudget)
+		}
+		_, err = repo.GetBudget(familyCtx, companyBudget)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetBudget of another organization: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.DeleteBudget(familyCtx, companyBudget)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteBudget of another organization: err = %v, want ErrNotFound", err)
+		}
+
+		familyTag, err := repo.CreateTag(familyCtx, &models.TagCreate{UserId: userA, Name: "Work"})
+		if err != nil {
+			t.Fatalf("CreateTag: %v", err)
+		}
+		companyTag, err := repo.CreateTag(companyCtx, &models.TagCreate{UserId: userA, Name: "Work"})
+		if err != nil {
+			t.Fatalf("CreateTag in another organization: %v", err)
+		}
+		tags, err := repo.GetTags(familyCtx, userA)
+		if err != nil {
+			t.Fatalf("GetTags: %v", err)
+		}
+		if len(tags) != 1 || tags[0].Id != familyTag {
+			t.Fatalf("tags in the organization = %v, want only %d", tags, familyTag)
+		}
+		_, err = repo.GetTag(familyCtx, companyTag)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetTag of another organization: err = %v, want ErrNotFound", err)
+		}
+
+		//A subscription is tagged with the tag of its organization
+		work := mustCreateIn(t, repo, company, &models.SubscriptionCreate{ServiceName: "netflix", Price: 700, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		if err := repo.SetSubscriptionTags(companyCtx, work, []string{"work"}); err != nil {
+			t.Fatalf("SetSubscriptionTags: %v", err)
+		}
+		linked, err := repo.GetSubscriptionTags(companyCtx, []int{work})
+		if err != nil {
+			t.Fatalf("GetSubscriptionTags: %v", err)
+		}
+		if len(linked) != 1 || linked[0].TagId != companyTag {
+			t.Fatalf("tags of the subscription = %v, want %d", linked, companyTag)
+		}
+
+		err = repo.DismissInsight(familyCtx, &models.InsightDismissal{UserId: userA, InsightId: "duplicate-1-2", DismissedAt: Month(2026, 10)})
+		if err != nil {
+			t.Fatalf("DismissInsight: %v", err)
+		}
+		dismissals, err := repo.GetInsightDismissals(companyCtx, userA)
+		if err != nil || len(dismissals) != 0 {
+			t.Fatalf("insight dismissals in another organization = %v, %v, want none", dismissals, err)
+		}
+
+		//A merge renames the subscriptions of its organization and is audited in it
+		home := mustCreateIn(t, repo, family, &models.SubscriptionCreate{ServiceName: "netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		audit, err := repo.MergeServiceNames(familyCtx, &models.NameMerge{Canonical: "Netflix", Names: []string{"netflix"}, MergedAt: Month(2026, 10)})
+		if err != nil {
+			t.Fatalf("MergeServiceNames: %v", err)
+		}
+		if audit.Subscriptions != 1 {
+			t.Fatalf("merged %d subscriptions, want only the one of the organization", audit.Subscriptions)
+		}
+		for id, want := range map[int]string{home: "Netflix", work: "netflix"} {
+			s, err := repo.GetById(bypassContext(), id)
+			if err != nil {
+				t.Fatalf("GetById: %v", err)
+			}
+			if s.ServiceName != want {
+				t.Fatalf("subscription %d is %s, want %s", id, s.ServiceName, want)
+			}
+		}
+		for _, tt := range []struct {
+			name string
+			ctx  context.Context
+			want int
+		}{
+			{"organization", familyCtx, 1},
+			{"another organization", companyCtx, 0},
+			{"bypass", bypassContext(), 1},
+		} {
+			merges, err := repo.GetNameMerges(tt.ctx, 0, 10)
+			if err != nil {
+				t.Fatalf("GetNameMerges with the %s: %v", tt.name, err)
+			}
+			if len(merges) != tt.want {
+				t.Fatalf("got %d merges with the %s, want %d", len(merges), tt.name, tt.want)
+			}
+		}
+	})
+}
+
+func mustCreateOrganization(t *testing.T, repo Repo, name string) int {
+	t.Helper()
+
+	id, err := repo.CreateOrganization(bypassContext(), &models.OrganizationCreate{Name: name, CreatedAt: Month(2026, 10)})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	return id
+}
+
+// mustCreateIn creates the subscription in the organization
+func mustCreateIn(t *testing.T, repo Repo, organizationId int, s *models.SubscriptionCreate) int {
+	t.Helper()
+
+	id, err := repo.Create(tenant.WithOrganization(context.Background(), organizationId), s)
+	if err != nil {
+		t.Fatalf("Create in organization %d: %v", organizationId, err)
+	}
+	return id
+}
+
+func mustAddMember(t *testing.T, repo Repo, organizationId int, userId uuid.UUID) {
+	t.Helper()
+
+	err := repo.AddMember(tenant.WithOrganization(context.Background(), organizationId), &models.OrganizationMember{OrganizationId: organizationId, UserId: userId, JoinedAt: Month(2026, 10).Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+}
+
+func assertMembers(t *testing.T, repo Repo, organizationId int, want []uuid.UUID) {
+	t.Helper()
+
+	got, err := repo.GetMembers(tenant.WithOrganization(context.Background(), organizationId), organizationId)
+	if err != nil {
+		t.Fatalf("GetMembers: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d members, want %v", len(got), want)
+	}
+	for i := range got {
+		if got[i].UserId != want[i] || got[i].OrganizationId != organizationId || !got[i].JoinedAt.Equal(Month(2026, 10).Add(time.Hour)) {
+			t.Fatalf("member %d = %+v, want %v of %d", i, *got[i], want[i], organizationId)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package repotest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+// SplitRepo checks the behaviour every service.ISplitRepo implementation must have.
+// newRepo must return an empty repository on every call.
+func SplitRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		family := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		music := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 300, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		own := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Gym", Price: 2000, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1})
+
+		mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: family, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userC}, {UserId: userB}}})
+		mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: music, Rule: "percentage", Members: []models.SubscriptionMember{{UserId: userB, Share: nullInt(40)}}})
+
+		want := []*models.SubscriptionSplit{
+			{SubscriptionId: family, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userB}, {UserId: userC}}},
+			{SubscriptionId: music, Rule: "percentage", Members: []models.SubscriptionMember{{UserId: userB, Share: nullInt(40)}}},
+		}
+		assertSplits(t, repo, []int{own, music, family}, want)
+
+		//Setting replaces the split, a member can't be listed twice
+		mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: family, Rule: "fixed", Members: []models.SubscriptionMember{{UserId: userC, Share: nullInt(300)}}})
+		err := repo.SetSplit(ctx, &models.SubscriptionSplit{SubscriptionId: music, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userC}, {UserId: userC}}})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("SetSplit with a repeated member: err = %v, want ErrAlreadyExists", err)
+		}
+		want[0] = &models.SubscriptionSplit{SubscriptionId: family, Rule: "fixed", Members: []models.SubscriptionMember{{UserId: userC, Share: nullInt(300)}}}
+		assertSplits(t, repo, []int{family, music}, want)
+
+		//No members stop sharing
+		mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: music, Rule: "equal"})
+		assertSplits(t, repo, []int{family, music}, want[:1])
+
+		err = repo.SetSplit(ctx, &models.SubscriptionSplit{SubscriptionId: own + 100, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userC}}})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("SetSplit of a missing subscription: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("GetByPeriod", func(t *testing.T) {
+		repo := newRepo(t)
+
+		shared := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 300, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		own := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Gym", Price: 2000, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1})
+		mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: shared, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userB}}})
+
+		tests := []struct {
+			name   string
+			filter models.SubscriptionFilter
+			want   []int
+		}{
+			{"owned", models.SubscriptionFilter{UserId: &userB}, []int{own}},
+			{"owned and shared with the user", models.SubscriptionFilter{UserId: &userB, Members: true}, []int{shared, own}},
+			{"shared of all users", models.SubscriptionFilter{Shared: true}, []int{shared}},
+			{"shared with the user", models.SubscriptionFilter{UserId: &userB, Members: true, Shared: true}, []int{shared}},
+			{"shared with nobody else", models.SubscriptionFilter{UserId: &userC, Members: true}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.filter.From, tt.filter.To = Month(2026, 3), Month(2026, 3).AddDate(0, 1, -1)
+				got, err := repo.GetByPeriod(tenantContext(), &tt.filter)
+				if err != nil {
+					t.Fatalf("GetByPeriod: %v", err)
+				}
+				var ids []int
+				for _, s := range got {
+					ids = append(ids, s.Id)
+				}
+				if !reflect.DeepEqual(ids, tt.want) {
+					t.Fatalf("GetByPeriod = %v, want %v", ids, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("Cascade", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		id := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: id, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userB}}})
+
+		//The split is deleted with the subscription
+		if _, err := repo.DeleteById(ctx, id); err != nil {
+			t.Fatalf("DeleteById: %v", err)
+		}
+		assertSplits(t, repo, []int{id}, nil)
+		got, err := repo.GetByPeriod(ctx, &models.SubscriptionFilter{Shared: true, From: Month(2026, 1), To: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatalf("GetByPeriod: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("GetByPeriod of shared subscriptions after the deletion returned %d subscriptions", len(got))
+		}
+	})
+}
+
+func mustSetSplit(t *testing.T, repo Repo, s *models.SubscriptionSplit) {
+	t.Helper()
+
+	if err := repo.SetSplit(tenantContext(), s); err != nil {
+		t.Fatalf("SetSplit(%+v): %v", *s, err)
+	}
+}
+
+func assertSplits(t *testing.T, repo Repo, ids []int, want []*models.SubscriptionSplit) {
+	t.Helper()
+
+	got, err := repo.GetSplits(tenantContext(), ids)
+	if err != nil {
+		t.Fatalf("GetSplits: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GetSplits returned %d splits, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("split %d = %+v, want %+v", i, *got[i], *want[i])
+		}
+	}
+}
@@ -0,0 +1,667 @@
+// Package repotest contains the conformance suite shared by all repository implementations
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+var (
+	userA = uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	userB = uuid.MustParse("6ba7b810-9dad-41d1-80b4-00c04fd430c8")
+	userC = uuid.MustParse("7c9e6679-7425-40de-944b-e07fc1f90ae7")
+)
+
+// defaultOrganization is the organization the migrations create, the suites work in it
+// unless they check the tenancy
+const defaultOrganization = 1
+
+// tenantContext returns a context scoped to the default organization
+func tenantContext() context.Context {
+	return tenant.WithOrganization(context.Background(), defaultOrganization)
+}
+
+// bypassContext returns a context seeing the rows of all the organizations, as the administrative requests do
+func bypassContext() context.Context {
+	return tenant.Bypass(context.Background())
+}
+
+// Month returns the first day of the month, the way the service stores MM-YYYY dates
+func Month(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End wraps the end date of a subscription
+func End(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+// SubscriptionRepo checks the behaviour every service.ISubscriptionRepo implementation must have.
+// newRepo must return an empty repository on every call.
+func SubscriptionRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("CreateAndGetById", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		open := &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1}
+		closed := &models.SubscriptionCreate{ServiceName: "Spotify", Price: 299, UserId: userB, StartDate: Month(2026, 2), BillingDay: 1, EndDate: End(Month(2026, 5)), TrialMonths: 1}
+
+		openId := mustCreate(t, repo, open)
+		closedId := mustCreate(t, repo, closed)
+		if openId == closedId {
+			t.Fatalf("Create returned the same ID %d twice", openId)
+		}
+
+		assertSubscription(t, mustGet(t, repo, openId), openId, open)
+		assertSubscription(t, mustGet(t, repo, closedId), closedId, closed)
+
+		_, err := repo.GetById(ctx, closedId+100)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetById of a missing subscription: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("CreateIncorrectTime", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Create(tenantContext(), &models.SubscriptionCreate{
+			ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 3), BillingDay: 1, EndDate: End(Month(2026, 2)),
+		})
+		if !errors.Is(err, repository.ErrIncorrectTime) {
+			t.Fatalf("Create with the end date before the start: got %v, want ErrIncorrectTime", err)
+		}
+
+		//The end date is inclusive, a subscription may end on the day it starts
+		mustCreate(t, repo, &models.SubscriptionCreate{
+			ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 3), BillingDay: 1, EndDate: End(Month(2026, 3)),
+		})
+	})
+
+	t.Run("GetByUser", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		var ids []int
+		for i := range 5 {
+			ids = append(ids, mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100 + i, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1}))
+			mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 200, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1})
+		}
+
+		page, err := repo.GetByUser(ctx, userA, 1, 3)
+		if err != nil {
+			t.Fatalf("GetByUser: %v", err)
+		}
+		assertIds(t, page, ids[1:4])
+
+		page, err = repo.GetByUser(ctx, userA, 4, 3)
+		if err != nil {
+			t.Fatalf("GetByUser: %v", err)
+		}
+		assertIds(t, page, ids[4:])
+
+		page, err = repo.GetByUser(ctx, userA, 10, 3)
+		if err != nil {
+			t.Fatalf("GetByUser: %v", err)
+		}
+		assertIds(t, page, nil)
+
+		page, err = repo.GetByUser(ctx, uuid.New(), 0, 10)
+		if err != nil {
+			t.Fatalf("GetByUser: %v", err)
+		}
+		assertIds(t, page, nil)
+
+		_, err = repo.GetByUser(ctx, userA, -1, 3)
+		if err == nil {
+			t.Fatalf("GetByUser with a negative offset: want an error")
+		}
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var ids []int
+		for _, user := range []uuid.UUID{userA, userB, userA, userB} {
+			ids = append(ids, mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100, UserId: user, StartDate: Month(2026, 1), BillingDay: 1}))
+		}
+
+		page, err := repo.GetAll(tenantContext(), 0, 3)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		assertIds(t, page, ids[:3])
+
+		page, err = repo.GetAll(tenantContext(), 3, 3)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		assertIds(t, page, ids[3:])
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		create := &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1}
+		id := mustCreate(t, repo, create)
+
+		updated, err := repo.Update(ctx, &models.SubscriptionUpdate{
+			Id:    id,
+			Price: sql.NullInt32{Int32: 699, Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		create.Price = 699
+		assertSubscription(t, updated, id, create)
+
+		updated, err = repo.Update(ctx, &models.SubscriptionUpdate{
+			Id:          id,
+			ServiceName: sql.NullString{String: "Netflix Premium", Valid: true},
+			StartDate:   sql.NullTime{Time: Month(2026, 2), Valid: true},
+			EndDate:     End(Month(2026, 8)),
+			BillingDay:  sql.NullInt32{Int32: 15, Valid: true},
+			TrialMonths: sql.NullInt32{Int32: 3, Valid: true},
+			TrialPrice:  sql.NullInt32{Int32: 99, Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		create.ServiceName = "Netflix Premium"
+		create.StartDate = Month(2026, 2)
+		create.EndDate = End(Month(2026, 8))
+		create.BillingDay = 15
+		create.TrialMonths = 3
+		create.TrialPrice = 99
+		assertSubscription(t, updated, id, create)
+		assertSubscription(t, mustGet(t, repo, id), id, create)
+
+		_, err = repo.Update(ctx, &models.SubscriptionUpdate{Id: id, EndDate: End(Month(2026, 1))})
+		if !errors.Is(err, repository.ErrIncorrectTime) {
+			t.Fatalf("Update with the end date before the start: got %v, want ErrIncorrectTime", err)
+		}
+		assertSubscription(t, mustGet(t, repo, id), id, create)
+
+		_, err = repo.Update(ctx, &models.SubscriptionUpdate{Id: id + 100, Price: sql.NullInt32{Int32: 1, Valid: true}})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("Update of a missing subscription: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("DeleteById", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		create := &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, EndDate: End(Month(2027, 1))}
+		id := mustCreate(t, repo, create)
+
+		deleted, err := repo.DeleteById(ctx, id)
+		if err != nil {
+			t.Fatalf("DeleteById: %v", err)
+		}
+		assertSubscription(t, deleted, id, create)
+
+		_, err = repo.GetById(ctx, id)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetById after delete: got %v, want ErrNotFound", err)
+		}
+		_, err = repo.DeleteById(ctx, id)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteById twice: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("GetByPeriod", func(t *testing.T) {
+		repo := newRepo(t)
+		netflix, spotify := "Netflix", "Spotify"
+
+		open := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: netflix, Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		closed := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: spotify, Price: 10, UserId: userA, StartDate: Month(2026, 2), BillingDay: 1, EndDate: End(Month(2026, 4)), TrialMonths: 2, TrialPrice: 5})
+		other := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: netflix, Price: 1000, UserId: userB, StartDate: Month(2025, 11), BillingDay: 1, EndDate: End(Month(2026, 2))})
+		sameDay := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: spotify, Price: 1, UserId: userB, StartDate: Month(2026, 6), BillingDay: 1, EndDate: End(Month(2026, 6))})
+
+		tests := []struct {
+			name   string
+			filter models.SubscriptionFilter
+			want   []int
+		}{
+			{"all", models.SubscriptionFilter{From: Month(2026, 1), To: Month(2026, 6)}, []int{open, closed, other, sameDay}},
+			{"user", models.SubscriptionFilter{UserId: &userA, From: Month(2026, 1), To: Month(2026, 6)}, []int{open, closed}},
+			{"service", models.SubscriptionFilter{ServiceName: &netflix, From: Month(2026, 1), To: Month(2026, 6)}, []int{open, other}},
+			{"user and service", models.SubscriptionFilter{UserId: &userA, ServiceName: &spotify, From: Month(2026, 1), To: Month(2026, 6)}, []int{closed}},
+			{"ends on the first day", models.SubscriptionFilter{From: Month(2026, 4), To: Month(2026, 5)}, []int{open, closed}},
+			{"starts on the last day", models.SubscriptionFilter{From: Month(2025, 1), To: Month(2025, 11)}, []int{other}},
+			{"single day", models.SubscriptionFilter{From: Month(2026, 6), To: Month(2026, 6)}, []int{open, sameDay}},
+			{"trial", models.SubscriptionFilter{From: Month(2026, 1), To: Month(2026, 6), Trial: true}, []int{closed}},
+			{"before all", models.SubscriptionFilter{From: Month(2024, 1), To: Month(2024, 12)}, nil},
+			{"no match", models.SubscriptionFilter{UserId: &userB, ServiceName: &spotify, From: Month(2026, 1), To: Month(2026, 5)}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := repo.GetByPeriod(tenantContext(), &tt.filter)
+				if err != nil {
+					t.Fatalf("GetByPeriod: %v", err)
+				}
+				assertIds(t, got, tt.want)
+			})
+		}
+	})
+
+	t.Run("CountActive", func(t *testing.T) {
+		repo := newRepo(t)
+
+		mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 10, UserId: userA, StartDate: Month(2026, 2), BillingDay: 1, EndDate: End(Month(2026, 4))})
+		mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Kinopoisk", Price: 10, UserId: userB, StartDate: Month(2026, 6), BillingDay: 1})
+
+		for at, want := range map[time.Time]int{
+			Month(2025, 12): 0,
+			Month(2026, 1):  1,
+			Month(2026, 4):  2,
+			Month(2026, 5):  1,
+			Month(2026, 6):  2,
+		} {
+			got, err := repo.CountActive(tenantContext(), at)
+			if err != nil {
+				t.Fatalf("CountActive: %v", err)
+			}
+			if got != want {
+				t.Fatalf("CountActive(%s) = %d, want %d", at.Format(time.DateOnly), got, want)
+			}
+		}
+	})
+
+	t.Run("Pauses", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		first := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		second := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 10, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		later := mustCreatePause(t, repo, &models.PauseCreate{SubscriptionId: first, StartDate: Month(2026, 6)})
+		earlier := mustCreatePause(t, repo, &models.PauseCreate{SubscriptionId: first, StartDate: Month(2026, 2), EndDate: End(Month(2026, 3))})
+		other := mustCreatePause(t, repo, &models.PauseCreate{SubscriptionId: second, StartDate: Month(2026, 4)})
+
+		pauses, err := repo.GetPauses(ctx, []int{second, first})
+		if err != nil {
+			t.Fatalf("GetPauses: %v", err)
+		}
+		assertPauseIds(t, pauses, []int{earlier, later, other})
+		if pauses[0].SubscriptionId != first || !pauses[0].StartDate.Equal(Month(2026, 2)) || !pauses[0].EndDate.Valid || !pauses[0].EndDate.Time.Equal(Month(2026, 3)) {
+			t.Fatalf("pause = %+v, want from 2026-02-01 to 2026-03-01 of subscription %d", *pauses[0], first)
+		}
+		if pauses[1].EndDate.Valid {
+			t.Fatalf("pause = %+v, want no end date", *pauses[1])
+		}
+
+		pauses, err = repo.GetPauses(ctx, nil)
+		if err != nil || len(pauses) != 0 {
+			t.Fatalf("GetPauses of no subscriptions = %v, %v, want none", pauses, err)
+		}
+
+		ended, err := repo.EndPause(ctx, later, Month(2026, 7))
+		if err != nil {
+			t.Fatalf("EndPause: %v", err)
+		}
+		if ended.Id != later || !ended.EndDate.Valid || !ended.EndDate.Time.Equal(Month(2026, 7)) {
+			t.Fatalf("pause = %+v, want ending on 2026-07-01", *ended)
+		}
+		_, err = repo.EndPause(ctx, later, Month(2026, 5))
+		if !errors.Is(err, repository.ErrIncorrectTime) {
+			t.Fatalf("EndPause before the start: got %v, want ErrIncorrectTime", err)
+		}
+		_, err = repo.EndPause(ctx, other+100, Month(2026, 7))
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("EndPause of a missing pause: got %v, want ErrNotFound", err)
+		}
+
+		_, err = repo.CreatePause(ctx, &models.PauseCreate{SubscriptionId: second + 100, StartDate: Month(2026, 1)})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("CreatePause of a missing subscription: got %v, want ErrNotFound", err)
+		}
+		_, err = repo.CreatePause(ctx, &models.PauseCreate{SubscriptionId: second, StartDate: Month(2026, 9), EndDate: End(Month(2026, 8))})
+		if !errors.Is(err, repository.ErrIncorrectTime) {
+			t.Fatalf("CreatePause with the end before the start: got %v, want ErrIncorrectTime", err)
+		}
+		_, err = repo.CreatePause(ctx, &models.PauseCreate{SubscriptionId: first, StartDate: Month(2026, 3), EndDate: End(Month(2026, 4))})
+		if !errors.Is(err, repository.ErrOverlap) {
+			t.Fatalf("CreatePause overlapping a pause: got %v, want ErrOverlap", err)
+		}
+		_, err = repo.CreatePause(ctx, &models.PauseCreate{SubscriptionId: first, StartDate: Month(2026, 5)})
+		if !errors.Is(err, repository.ErrOverlap) {
+			t.Fatalf("CreatePause overlapping an open pause: got %v, want ErrOverlap", err)
+		}
+		//Only the pauses of the same subscription are compared
+		beside := mustCreatePause(t, repo, &models.PauseCreate{SubscriptionId: second, StartDate: Month(2026, 2), EndDate: End(Month(2026, 3))})
+
+		//Pauses are deleted with their subscription
+		if _, err := repo.DeleteById(ctx, first); err != nil {
+			t.Fatalf("DeleteById: %v", err)
+		}
+		pauses, err = repo.GetPauses(ctx, []int{first, second})
+		if err != nil {
+			t.Fatalf("GetPauses: %v", err)
+		}
+		assertPauseIds(t, pauses, []int{beside, other})
+	})
+
+	t.Run("PriceChanges", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		first := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		second := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 10, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		later := mustCreatePriceChange(t, repo, &models.PriceChangeCreate{SubscriptionId: first, EffectiveDate: Month(2026, 9), Price: 150})
+		earlier := mustCreatePriceChange(t, repo, &models.PriceChangeCreate{SubscriptionId: first, EffectiveDate: Month(2026, 3), Price: 120})
+		other := mustCreatePriceChange(t, repo, &models.PriceChangeCreate{SubscriptionId: second, EffectiveDate: Month(2026, 2), Price: 0})
+
+		changes, err := repo.GetPriceChanges(ctx, []int{second, first})
+		if err != nil {
+			t.Fatalf("GetPriceChanges: %v", err)
+		}
+		assertPriceChangeIds(t, changes, []int{earlier, later, other})
+		if changes[0].SubscriptionId != first || !changes[0].EffectiveDate.Equal(Month(2026, 3)) || changes[0].Price != 120 {
+			t.Fatalf("price change = %+v, want 120 from 2026-03-01 for subscription %d", *changes[0], first)
+		}
+
+		changes, err = repo.GetPriceChanges(ctx, nil)
+		if err != nil || len(changes) != 0 {
+			t.Fatalf("GetPriceChanges of no subscriptions = %v, %v, want none", changes, err)
+		}
+
+		_, err = repo.CreatePriceChange(ctx, &models.PriceChangeCreate{SubscriptionId: second + 100, EffectiveDate: Month(2026, 3), Price: 1})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("CreatePriceChange of a missing subscription: got %v, want ErrNotFound", err)
+		}
+		_, err = repo.CreatePriceChange(ctx, &models.PriceChangeCreate{SubscriptionId: first, EffectiveDate: Month(2026, 3), Price: 130})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("CreatePriceChange on a taken date: got %v, want ErrAlreadyExists", err)
+		}
+
+		//Price changes are deleted with their subscription
+		if _, err := repo.DeleteById(ctx, first); err != nil {
+			t.Fatalf("DeleteById: %v", err)
+		}
+		changes, err = repo.GetPriceChanges(ctx, []int{first, second})
+		if err != nil {
+			t.Fatalf("GetPriceChanges: %v", err)
+		}
+		assertPriceChangeIds(t, changes, []int{other})
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		create := &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1}
+		id := mustCreate(t, repo, create)
+
+		cancelled, err := repo.Cancel(ctx, &models.SubscriptionCancel{
+			Id:          id,
+			EndDate:     Month(2026, 5).AddDate(0, 0, -1),
+			CancelledAt: Month(2026, 4).AddDate(0, 0, 9),
+			Reason:      sql.NullString{String: "too expensive", Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("Cancel: %v", err)
+		}
+		create.EndDate = End(Month(2026, 5).AddDate(0, 0, -1))
+		assertSubscription(t, cancelled, id, create)
+		got := mustGet(t, repo, id)
+		assertSubscription(t, got, id, create)
+		if !got.CancelledAt.Valid || !got.CancelledAt.Time.Equal(Month(2026, 4).AddDate(0, 0, 9)) || got.CancelReason != (sql.NullString{String: "too expensive", Valid: true}) {
+			t.Fatalf("subscription = %+v, want cancelled on 2026-04-10 as too expensive", *got)
+		}
+
+		//Without a reason
+		cancelled, err = repo.Cancel(ctx, &models.SubscriptionCancel{Id: id, EndDate: Month(2026, 3), CancelledAt: Month(2026, 3)})
+		if err != nil {
+			t.Fatalf("Cancel: %v", err)
+		}
+		if cancelled.CancelReason.Valid || !cancelled.EndDate.Time.Equal(Month(2026, 3)) {
+			t.Fatalf("subscription = %+v, want ending on 2026-03-01 without a reason", *cancelled)
+		}
+
+		_, err = repo.Cancel(ctx, &models.SubscriptionCancel{Id: id, EndDate: Month(2025, 12), CancelledAt: Month(2026, 3)})
+		if !errors.Is(err, repository.ErrIncorrectTime) {
+			t.Fatalf("Cancel before the start: got %v, want ErrIncorrectTime", err)
+		}
+		_, err = repo.Cancel(ctx, &models.SubscriptionCancel{Id: id + 100, EndDate: Month(2026, 3), CancelledAt: Month(2026, 3)})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("Cancel of a missing subscription: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("GetByState", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		open := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		ended := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 10, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, EndDate: End(Month(2026, 3))})
+		ending := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Kinopoisk", Price: 10, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1, EndDate: End(Month(2026, 6))})
+		cancelled := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Okko", Price: 10, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1})
+		if _, err := repo.Cancel(ctx, &models.SubscriptionCancel{Id: cancelled, EndDate: Month(2026, 2), CancelledAt: Month(2026, 2)}); err != nil {
+			t.Fatalf("Cancel: %v", err)
+		}
+
+		yes, no := true, false
+		day := Month(2026, 4)
+		tests := []struct {
+			name   string
+			filter models.StateFilter
+			want   []int
+		}{
+			{"all", models.StateFilter{}, []int{open, ended, ending, cancelled}},
+			{"user", models.StateFilter{UserId: &userB}, []int{ending, cancelled}},
+			{"cancelled", models.StateFilter{Cancelled: &yes}, []int{cancelled}},
+			{"not cancelled", models.StateFilter{Cancelled: &no}, []int{open, ended, ending}},
+			{"ended", models.StateFilter{Cancelled: &no, EndedBefore: &day}, []int{ended}},
+			{"not ended", models.StateFilter{Cancelled: &no, ActiveOn: &day}, []int{open, ending}},
+			{"ends on the day", models.StateFilter{ActiveOn: ptr(Month(2026, 6))}, []int{open, ending}},
+			{"no match", models.StateFilter{UserId: &userA, Cancelled: &yes}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := repo.GetByState(ctx, &tt.filter)
+				if err != nil {
+					t.Fatalf("GetByState: %v", err)
+				}
+				assertIds(t, got, tt.want)
+			})
+		}
+	})
+
+	t.Run("GetPageByState", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		date := func(day int) time.Time { return time.Date(2026, 2, day, 0, 0, 0, 0, time.UTC) }
+		//Billed on the 31st, the trial ends the day before February 28
+		clamped := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 100, UserId: userA, StartDate: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), BillingDay: 31, TrialMonths: 1})
+		//The first cycle starts on 2025-12-20, the trial ends on 2026-02-19
+		early := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 10, UserId: userA, StartDate: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), BillingDay: 20, TrialMonths: 2})
+		paused := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Okko", Price: 10, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		mustCreatePause(t, repo, &models.PauseCreate{SubscriptionId: paused, StartDate: date(10), EndDate: End(date(20))})
+		//The status of a subscription that has not started is checked on its start date
+		future := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Kion", Price: 10, UserId: userA, StartDate: Month(2026, 3), BillingDay: 1, TrialMonths: 1})
+
+		yes, no := true, false
+		tests := []struct {
+			name   string
+			filter models.StateFilter
+			want   []int
+		}{
+			{"trialing", models.StateFilter{Trialing: &yes, StatusDay: date(19)}, []int{clamped, early, future}},
+			{"not trialing", models.StateFilter{Trialing: &no, StatusDay: date(19)}, []int{paused}},
+			{"trial ended", models.StateFilter{Trialing: &yes, StatusDay: date(20)}, []int{clamped, future}},
+			{"clamped trial ended", models.StateFilter{Trialing: &yes, StatusDay: date(28)}, []int{future}},
+			{"paused", models.StateFilter{Paused: &yes, StatusDay: date(20)}, []int{paused}},
+			{"not paused", models.StateFilter{Paused: &no, StatusDay: date(20)}, []int{clamped, early, future}},
+			{"resumed", models.StateFilter{Paused: &yes, StatusDay: date(21)}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := repo.GetPageByState(ctx, &tt.filter, 0, 10)
+				if err != nil {
+					t.Fatalf("GetPageByState: %v", err)
+				}
+				assertIds(t, got, tt.want)
+			})
+		}
+
+		got, err := repo.GetPageByState(ctx, &models.StateFilter{Trialing: &yes, StatusDay: date(19)}, 1, 1)
+		if err != nil {
+			t.Fatalf("GetPageByState: %v", err)
+		}
+		assertIds(t, got, []int{early})
+		if _, err := repo.GetPageByState(ctx, &models.StateFilter{}, -1, 1); err == nil {
+			t.Fatal("GetPageByState with a negative offset: want an error")
+		}
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const n = 20
+		ids := make(chan int, n)
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				id, err := repo.Create(tenantContext(), &models.SubscriptionCreate{ServiceName: "Netflix", Price: 1, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+				if err != nil {
+					t.Errorf("Create: %v", err)
+					return
+				}
+				ids <- id
+			}()
+		}
+		wg.Wait()
+		close(ids)
+
+		seen := make(map[int]bool)
+		for id := range ids {
+			if seen[id] {
+				t.Fatalf("Create returned the same ID %d twice", id)
+			}
+			seen[id] = true
+		}
+		if len(seen) != n {
+			t.Fatalf("created %d subscriptions, want %d", len(seen), n)
+		}
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func mustCreate(t *testing.T, repo service.ISubscriptionRepo, s *models.SubscriptionCreate) int {
+	t.Helper()
+
+	id, err := repo.Create(tenantContext(), s)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return id
+}
+
+func mustGet(t *testing.T, repo service.ISubscriptionRepo, id int) *models.Subscription {
+	t.Helper()
+
+	s, err := repo.GetById(tenantContext(), id)
+	if err != nil {
+		t.Fatalf("GetById(%d): %v", id, err)
+	}
+	return s
+}
+
+func assertSubscription(t *testing.T, got *models.Subscription, id int, want *models.SubscriptionCreate) {
+	t.Helper()
+
+	if got.Id != id ||
+		got.ServiceName != want.ServiceName ||
+		got.Price != want.Price ||
+		got.UserId != want.UserId ||
+		got.BillingDay != want.BillingDay ||
+		got.TrialMonths != want.TrialMonths ||
+		got.TrialPrice != want.TrialPrice ||
+		!got.StartDate.Equal(want.StartDate) ||
+		got.EndDate.Valid != want.EndDate.Valid ||
+		(want.EndDate.Valid && !got.EndDate.Time.Equal(want.EndDate.Time)) {
+		t.Fatalf("subscription = %+v, want id=%d %+v", *got, id, *want)
+	}
+}
+
+func mustCreatePause(t *testing.T, repo service.ISubscriptionRepo, p *models.PauseCreate) int {
+	t.Helper()
+
+	id, err := repo.CreatePause(tenantContext(), p)
+	if err != nil {
+		t.Fatalf("CreatePause: %v", err)
+	}
+	return id
+}
+
+func assertPauseIds(t *testing.T, got []*models.Pause, want []int) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d pauses, want %v", len(got), want)
+	}
+	for i := range got {
+		if got[i].Id != want[i] {
+			t.Fatalf("pause %d has ID %d, want %v", i, got[i].Id, want)
+		}
+	}
+}
+
+func mustCreatePriceChange(t *testing.T, repo service.ISubscriptionRepo, p *models.PriceChangeCreate) int {
+	t.Helper()
+
+	id, err := repo.CreatePriceChange(tenantContext(), p)
+	if err != nil {
+		t.Fatalf("CreatePriceChange: %v", err)
+	}
+	return id
+}
+
+func assertPriceChangeIds(t *testing.T, got []*models.PriceChange, want []int) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d price changes, want %v", len(got), want)
+	}
+	for i := range got {
+		if got[i].Id != want[i] {
+			t.Fatalf("price change %d has ID %d, want %v", i, got[i].Id, want)
+		}
+	}
+}
+
+func assertIds(t *testing.T, got []*models.Subscription, want []int) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d subscriptions, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i].Id != want[i] {
+			t.Fatalf("subscription #%d has ID %d, want %d", i, got[i].Id, want[i])
+		}
+	}
+}
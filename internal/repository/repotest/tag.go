@@ -0,0 +1,318 @@
+package repotest
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+// TagRepo checks the behaviour every service.ITagRepo implementation must have,
+// including the notes and the tag filters of the subscriptions.
+// newRepo must return an empty repository on every call.
+func TagRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	newRepo = withUsers(newRepo)
+
+	t.Run("Create", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		work := mustCreateTag(t, repo, &models.TagCreate{UserId: userA, Name: "Work"})
+		got, err := repo.GetTag(ctx, work)
+		if err != nil {
+			t.Fatalf("GetTag: %v", err)
+		}
+		if got.Name != "Work" || got.UserId != userA || got.Subscriptions != 0 {
+			t.Fatalf("GetTag = %+v", *got)
+		}
+
+		//Names are unique per user without regard to case
+		_, err = repo.CreateTag(ctx, &models.TagCreate{UserId: userA, Name: "work"})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("CreateTag with a taken name: err = %v, want ErrAlreadyExists", err)
+		}
+		mustCreateTag(t, repo, &models.TagCreate{UserId: userB, Name: "work"})
+
+		_, err = repo.GetTag(ctx, 1000)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetTag of a missing tag: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("SetSubscriptionTags", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		work := mustCreateTag(t, repo, &models.TagCreate{UserId: userA, Name: "Work"})
+		mustCreateTag(t, repo, &models.TagCreate{UserId: userB, Name: "family"})
+		netflix := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		spotify := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 299, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+
+		//Existing tags are matched regardless of case, missing ones are created for the owner of the subscription
+		mustSetTags(t, repo, netflix, "work", "family", "FAMILY")
+		mustSetTags(t, repo, spotify, "Family")
+
+		got, err := repo.GetSubscriptionTags(ctx, []int{spotify, netflix})
+		if err != nil {
+			t.Fatalf("GetSubscriptionTags: %v", err)
+		}
+		want := []models.SubscriptionTag{
+			{SubscriptionId: netflix, Name: "family"},
+			{SubscriptionId: netflix, TagId: work, Name: "Work"},
+			{SubscriptionId: spotify, Name: "family"},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d subscription tags, want %d", len(got), len(want))
+		}
+		for i, tag := range got {
+			if tag.SubscriptionId != want[i].SubscriptionId || tag.Name != want[i].Name || (want[i].TagId != 0 && tag.TagId != want[i].TagId) {
+				t.Fatalf("subscription tag %d = %+v, want %+v", i, *tag, want[i])
+			}
+		}
+		if got[0].TagId != got[2].TagId {
+			t.Fatalf("the subscriptions got different tags %d and %d named family", got[0].TagId, got[2].TagId)
+		}
+
+		tags, err := repo.GetTags(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetTags: %v", err)
+		}
+		if len(tags) != 2 || tags[0].Name != "family" || tags[0].Subscriptions != 2 || tags[1].Id != work || tags[1].Subscriptions != 1 {
+			t.Fatalf("GetTags = %v, want family on 2 subscriptions and Work on 1", tags)
+		}
+
+		//An empty list removes the tags, the tags themselves are kept
+		mustSetTags(t, repo, netflix)
+		got, err = repo.GetSubscriptionTags(ctx, []int{netflix})
+		if err != nil {
+			t.Fatalf("GetSubscriptionTags: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("got %d tags after removing them, want 0", len(got))
+		}
+		if _, err := repo.GetTag(ctx, work); err != nil {
+			t.Fatalf("GetTag after removing it from the subscription: %v", err)
+		}
+
+		err = repo.SetSubscriptionTags(ctx, 1000, []string{"work"})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("SetSubscriptionTags of a missing subscription: err = %v, want ErrNotFound", err)
+		}
+		got, err = repo.GetSubscriptionTags(ctx, nil)
+		if err != nil || len(got) != 0 {
+			t.Fatalf("GetSubscriptionTags without IDs = %v, %v, want nothing", got, err)
+		}
+	})
+
+	t.Run("CreateUpdateWithTags", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		tagNames := func(id int) []string {
+			t.Helper()
+			tags, err := repo.GetSubscriptionTags(ctx, []int{id})
+			if err != nil {
+				t.Fatalf("GetSubscriptionTags: %v", err)
+			}
+			var names []string
+			for _, tag := range tags {
+				names = append(names, tag.Name)
+			}
+			return names
+		}
+
+		mustCreateTag(t, repo, &models.TagCreate{UserId: userA, Name: "Work"})
+		id := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, Tags: []string{"work", "Family"}})
+		if got := tagNames(id); !slices.Equal(got, []string{"Family", "Work"}) {
+			t.Fatalf("tags after Create = %v, want Family and Work", got)
+		}
+
+		//Nil keeps the tags
+		if _, err := repo.Update(ctx, &models.SubscriptionUpdate{Id: id, Price: sql.NullInt32{Int32: 699, Valid: true}}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got := tagNames(id); len(got) != 2 {
+			t.Fatalf("tags after Update without tags = %v, want 2", got)
+		}
+		if _, err := repo.Update(ctx, &models.SubscriptionUpdate{Id: id, Tags: []string{"Home"}}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got := tagNames(id); !slices.Equal(got, []string{"Home"}) {
+			t.Fatalf("tags after Update = %v, want Home", got)
+		}
+
+		//A failed update does not touch the tags
+		_, err := repo.Update(ctx, &models.SubscriptionUpdate{Id: id, EndDate: End(Month(2025, 12)), Tags: []string{"Other"}})
+		if !errors.Is(err, repository.ErrIncorrectTime) {
+			t.Fatalf("Update with the end before the start: got %v, want ErrIncorrectTime", err)
+		}
+		if got := tagNames(id); !slices.Equal(got, []string{"Home"}) {
+			t.Fatalf("tags after a failed Update = %v, want Home", got)
+		}
+		tags, err := repo.GetTags(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetTags: %v", err)
+		}
+		if len(tags) != 3 {
+			t.Fatalf("GetTags = %v, want Family, Home and Work without Other", tags)
+		}
+	})
+
+	t.Run("UpdateDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		work := mustCreateTag(t, repo, &models.TagCreate{UserId: userA, Name: "work"})
+		mustCreateTag(t, repo, &models.TagCreate{UserId: userA, Name: "family"})
+		netflix := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		mustSetTags(t, repo, netflix, "work", "family")
+
+		got, err := repo.UpdateTag(ctx, &models.TagUpdate{Id: work, Name: "Business"})
+		if err != nil {
+			t.Fatalf("UpdateTag: %v", err)
+		}
+		if got.Name != "Business" || got.Subscriptions != 1 {
+			t.Fatalf("UpdateTag = %+v", *got)
+		}
+		//Changing the case of the own name is allowed
+		if _, err := repo.UpdateTag(ctx, &models.TagUpdate{Id: work, Name: "business"}); err != nil {
+			t.Fatalf("UpdateTag changing the case: %v", err)
+		}
+		_, err = repo.UpdateTag(ctx, &models.TagUpdate{Id: work, Name: "Family"})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("UpdateTag to a taken name: err = %v, want ErrAlreadyExists", err)
+		}
+		_, err = repo.UpdateTag(ctx, &models.TagUpdate{Id: 1000, Name: "Other"})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateTag of a missing tag: err = %v, want ErrNotFound", err)
+		}
+
+		deleted, err := repo.DeleteTag(ctx, work)
+		if err != nil {
+			t.Fatalf("DeleteTag: %v", err)
+		}
+		if deleted.Id != work || deleted.Subscriptions != 1 {
+			t.Fatalf("DeleteTag = %+v", *deleted)
+		}
+		tags, err := repo.GetSubscriptionTags(ctx, []int{netflix})
+		if err != nil {
+			t.Fatalf("GetSubscriptionTags: %v", err)
+		}
+		if len(tags) != 1 || tags[0].Name != "family" {
+			t.Fatalf("got %v after deleting the tag, want only family", tags)
+		}
+		_, err = repo.DeleteTag(ctx, work)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteTag of a deleted tag: err = %v, want ErrNotFound", err)
+		}
+
+		//The tags of a deleted subscription are no longer counted
+		if _, err := repo.DeleteById(ctx, netflix); err != nil {
+			t.Fatalf("DeleteById: %v", err)
+		}
+		tagList, err := repo.GetTags(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetTags: %v", err)
+		}
+		if len(tagList) != 1 || tagList[0].Subscriptions != 0 {
+			t.Fatalf("GetTags = %v, want family without subscriptions", tagList)
+		}
+	})
+
+	t.Run("Notes", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		id := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, Notes: sql.NullString{String: "Family plan", Valid: true}})
+		got, err := repo.GetById(ctx, id)
+		if err != nil {
+			t.Fatalf("GetById: %v", err)
+		}
+		if got.Notes.String != "Family plan" {
+			t.Fatalf("notes = %+v, want Family plan", got.Notes)
+		}
+
+		//Updating other fields keeps the notes
+		got, err = repo.Update(ctx, &models.SubscriptionUpdate{Id: id, Price: sql.NullInt32{Int32: 699, Valid: true}})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got.Notes.String != "Family plan" {
+			t.Fatalf("notes after updating the price = %+v, want Family plan", got.Notes)
+		}
+		got, err = repo.Update(ctx, &models.SubscriptionUpdate{Id: id, Notes: sql.NullString{String: "Paid by card", Valid: true}})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got.Notes.String != "Paid by card" {
+			t.Fatalf("notes = %+v, want Paid by card", got.Notes)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := tenantContext()
+
+		both := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Slack", Price: 700, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		work := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Jira", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		family := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 599, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1})
+		untagged := mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Gym", Price: 2000, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		mustSetTags(t, repo, both, "work", "family")
+		mustSetTags(t, repo, work, "Work")
+		mustSetTags(t, repo, family, "family")
+
+		tests := []struct {
+			name     string
+			tags     []string
+			excluded []string
+			want     []int
+		}{
+			{"one", []string{"WORK"}, nil, []int{both, work}},
+			{"all of", []string{"work", "family"}, nil, []int{both}},
+			{"excluded", nil, []string{"family"}, []int{work, untagged}},
+			{"excluded several", nil, []string{"Family", "work"}, []int{untagged}},
+			{"both", []string{"work"}, []string{"family"}, []int{work}},
+			{"unknown", []string{"travel"}, nil, nil},
+			{"no filter", nil, nil, []int{both, work, family, untagged}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := repo.GetByPeriod(ctx, &models.SubscriptionFilter{From: Month(2026, 1), To: Month(2026, 12), Tags: tt.tags, ExcludedTags: tt.excluded})
+				if err != nil {
+					t.Fatalf("GetByPeriod: %v", err)
+				}
+				assertIds(t, got, tt.want)
+
+				got, err = repo.GetByState(ctx, &models.StateFilter{Tags: tt.tags, ExcludedTags: tt.excluded})
+				if err != nil {
+					t.Fatalf("GetByState: %v", err)
+				}
+				assertIds(t, got, tt.want)
+			})
+		}
+	})
+}
+
+func mustCreateTag(t *testing.T, repo Repo, c *models.TagCreate) int {
+	t.Helper()
+
+	id, err := repo.CreateTag(tenantContext(), c)
+	if err != nil {
+		t.Fatalf("CreateTag(%q): %v", c.Name, err)
+	}
+	return id
+}
+
+func mustSetTags(t *testing.T, repo Repo, subscriptionId int, names ...string) {
+	t.Helper()
+
+	if names == nil {
+		names = []string{}
+	}
+	if err := repo.SetSubscriptionTags(tenantContext(), subscriptionId, names); err != nil {
+		t.Fatalf("SetSubscriptionTags(%d, %v): %v", subscriptionId, names, err)
+	}
+}
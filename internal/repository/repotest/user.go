@@ -0,0 +1,282 @@
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// UserRepo checks the behaviour every service.IUserRepo implementation must have
+// and that deleting a user erases the user's data.
+// newRepo must return an empty repository on every call.
+func UserRepo(t *testing.T, newRepo func(t *testing.T) Repo) {
+	t.Run("CRUD", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		mustCreateUser(t, repo, &models.UserCreate{Id: userB, DisplayName: "Bob", Email: sql.NullString{String: "bob@example.com", Valid: true}, Locale: "en", Currency: "USD", Timezone: "UTC", CreatedAt: Month(2026, 10)})
+		mustCreateUser(t, repo, &models.UserCreate{Id: userA, DisplayName: "Alice", Locale: "ru-RU", Currency: "RUB", Timezone: "Europe/Moscow", CreatedAt: Month(2026, 10)})
+
+		got, err := repo.GetUser(ctx, userB)
+		if err != nil {
+			t.Fatalf("GetUser: %v", err)
+		}
+		if got.Id != userB || got.DisplayName != "Bob" || got.Email.String != "bob@example.com" || got.Currency != "USD" ||
+			!got.CreatedAt.Equal(Month(2026, 10)) || got.AnonymizedAt.Valid {
+			t.Fatalf("user = %+v, want Bob created on %v", *got, Month(2026, 10))
+		}
+		_, err = repo.GetUser(ctx, userC)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetUser of a missing user: err = %v, want ErrNotFound", err)
+		}
+
+		err = repo.CreateUser(ctx, &models.UserCreate{Id: userA, DisplayName: "Alice", Locale: "en", Currency: "RUB", Timezone: "UTC", CreatedAt: Month(2026, 10)})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("CreateUser with a taken ID: err = %v, want ErrAlreadyExists", err)
+		}
+		//The emails are compared regardless of case
+		err = repo.CreateUser(ctx, &models.UserCreate{Id: userC, DisplayName: "Carol", Email: sql.NullString{String: "Bob@Example.com", Valid: true}, Locale: "en", Currency: "RUB", Timezone: "UTC", CreatedAt: Month(2026, 10)})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("CreateUser with a taken email: err = %v, want ErrAlreadyExists", err)
+		}
+
+		users, err := repo.GetUsers(ctx, 0, 10)
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		if len(users) != 2 || users[0].Id != userA || users[1].Id != userB {
+			t.Fatalf("users = %v, want [%v %v]", users, userA, userB)
+		}
+		users, err = repo.GetUsers(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("GetUsers of the second page: %v", err)
+		}
+		if len(users) != 1 || users[0].Id != userB {
+			t.Fatalf("users after the first = %v, want [%v]", users, userB)
+		}
+
+		updated, err := repo.UpdateUser(ctx, &models.UserUpdate{Id: userA, Email: sql.NullString{String: "alice@example.com", Valid: true}, Timezone: sql.NullString{String: "Asia/Tokyo", Valid: true}})
+		if err != nil {
+			t.Fatalf("UpdateUser: %v", err)
+		}
+		if updated.DisplayName != "Alice" || updated.Email.String != "alice@example.com" || updated.Locale != "ru-RU" || updated.Timezone != "Asia/Tokyo" {
+			t.Fatalf("updated user = %+v, want Alice in Asia/Tokyo with the email", *updated)
+		}
+		_, err = repo.UpdateUser(ctx, &models.UserUpdate{Id: userA, Email: sql.NullString{String: "BOB@example.com", Valid: true}})
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			t.Fatalf("UpdateUser with a taken email: err = %v, want ErrAlreadyExists", err)
+		}
+		//The email is removed even when a new one is given
+		updated, err = repo.UpdateUser(ctx, &models.UserUpdate{Id: userA, Email: sql.NullString{String: "BOB@example.com", Valid: true}, ClearEmail: true})
+		if err != nil {
+			t.Fatalf("UpdateUser clearing the email: %v", err)
+		}
+		if updated.Email.Valid || updated.Timezone != "Asia/Tokyo" {
+			t.Fatalf("updated user = %+v, want no email and the rest kept", *updated)
+		}
+		_, err = repo.UpdateUser(ctx, &models.UserUpdate{Id: userC, DisplayName: sql.NullString{String: "Carol", Valid: true}})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateUser of a missing user: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Create(tenantContext(), &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1})
+		if !errors.Is(err, repository.ErrUnknownUser) {
+			t.Fatalf("Create for an unregistered user: err = %v, want ErrUnknownUser", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := withUsers(newRepo)(t)
+		ctx := bypassContext()
+		data := mustCreatePersonalData(t, repo)
+
+		deleted, err := repo.DeleteUser(ctx, userA)
+		if err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		if deleted.Id != userA {
+			t.Fatalf("deleted user %v, want %v", deleted.Id, userA)
+		}
+		_, err = repo.GetUser(ctx, userA)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetUser after delete: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.GetById(ctx, data.own)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("GetById of the user's subscription: err = %v, want ErrNotFound", err)
+		}
+		assertNoPersonalData(t, repo, data)
+
+		_, err = repo.DeleteUser(ctx, userA)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("DeleteUser twice: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Anonymize", func(t *testing.T) {
+		repo := withUsers(newRepo)(t)
+		ctx := bypassContext()
+		data := mustCreatePersonalData(t, repo)
+		at := Month(2026, 11).Add(time.Hour)
+
+		anonymized, err := repo.AnonymizeUser(ctx, userA, at)
+		if err != nil {
+			t.Fatalf("AnonymizeUser: %v", err)
+		}
+		got, err := repo.GetUser(ctx, userA)
+		if err != nil {
+			t.Fatalf("GetUser after anonymizing: %v", err)
+		}
+		for _, user := range []*models.User{anonymized, got} {
+			if user.Id != userA || user.DisplayName != "" || user.Email.Valid || user.Currency != "RUB" || !user.AnonymizedAt.Valid || !user.AnonymizedAt.Time.Equal(at) {
+				t.Fatalf("anonymized user = %+v, want no name and email, anonymized at %v", *user, at)
+			}
+		}
+
+		//The subscription is kept without the free text
+		own := mustGet(t, repo, data.own)
+		if own.UserId != userA || own.Notes.Valid || own.CancelReason.Valid {
+			t.Fatalf("subscription of the anonymized user = %+v, want it without the notes and the cancel reason", *own)
+		}
+		assertNoPersonalData(t, repo, data)
+
+		_, err = repo.UpdateUser(ctx, &models.UserUpdate{Id: userA, DisplayName: sql.NullString{String: "Alice", Valid: true}})
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("UpdateUser of an anonymized user: err = %v, want ErrNotFound", err)
+		}
+		_, err = repo.AnonymizeUser(ctx, userA, at)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("AnonymizeUser twice: err = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+// withUsers registers the users the suites create the subscriptions for in every new repository
+func withUsers(newRepo func(t *testing.T) Repo) func(t *testing.T) Repo {
+	return func(t *testing.T) Repo {
+		t.Helper()
+
+		repo := newRepo(t)
+		for _, id := range []uuid.UUID{userA, userB, userC} {
+			mustCreateUser(t, repo, &models.UserCreate{Id: id, DisplayName: "User " + id.String()[:8], Locale: "en", Currency: "RUB", Timezone: "UTC", CreatedAt: Month(2026, 10)})
+		}
+		return repo
+	}
+}
+
+func mustCreateUser(t *testing.T, repo Repo, u *models.UserCreate) {
+	t.Helper()
+
+	err := repo.CreateUser(context.Background(), u)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+}
+
+// personalData is the data of userA besides the user, owned by the user or shared with other users
+type personalData struct {
+	own          int
+	sharedAlone  int
+	sharedWithC  int
+	organization int
+}
+
+func mustCreatePersonalData(t *testing.T, repo Repo) personalData {
+	t.Helper()
+	ctx := tenantContext()
+
+	data := personalData{
+		own:          mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Netflix", Price: 900, UserId: userA, StartDate: Month(2026, 1), BillingDay: 1, Notes: sql.NullString{String: "Alice's card", Valid: true}}),
+		sharedAlone:  mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "Spotify", Price: 300, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1}),
+		sharedWithC:  mustCreate(t, repo, &models.SubscriptionCreate{ServiceName: "YouTube", Price: 400, UserId: userB, StartDate: Month(2026, 1), BillingDay: 1}),
+		organization: mustCreateOrganization(t, repo, "Family"),
+	}
+	_, err := repo.Cancel(ctx, &models.SubscriptionCancel{Id: data.own, EndDate: Month(2026, 12), CancelledAt: Month(2026, 10), Reason: sql.NullString{String: "Moving abroad", Valid: true}})
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	mustCreatePause(t, repo, &models.PauseCreate{SubscriptionId: data.own, StartDate: Month(2026, 2), EndDate: End(Month(2026, 2))})
+	mustSetTags(t, repo, data.own, "Family")
+	mustSetTags(t, repo, data.sharedAlone, "Music")
+	category := mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Hobby", UserId: nullUser(userA)})
+	mustCreateCategory(t, repo, &models.CategoryCreate{Name: "Games", ParentId: nullInt(category), UserId: nullUser(userA)})
+	mustCreateBudget(t, repo, &models.BudgetCreate{UserId: userA, Amount: 1000, CategoryId: nullInt(category)})
+	mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: data.sharedAlone, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userA}}})
+	mustSetSplit(t, repo, &models.SubscriptionSplit{SubscriptionId: data.sharedWithC, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userA}, {UserId: userC}}})
+	mustAddMember(t, repo, data.organization, userA)
+	mustAddMember(t, repo, data.organization, userC)
+	err = repo.DismissInsight(ctx, &models.InsightDismissal{UserId: userA, InsightId: "duplicate:1", DismissedAt: Month(2026, 10)})
+	if err != nil {
+		t.Fatalf("DismissInsight: %v", err)
+	}
+	//The data of the user in the other organizations is erased as well
+	err = repo.DismissInsight(tenant.WithOrganization(ctx, data.organization), &models.InsightDismissal{UserId: userA, InsightId: "duplicate:1", DismissedAt: Month(2026, 10)})
+	if err != nil {
+		t.Fatalf("DismissInsight in an organization: %v", err)
+	}
+
+	return data
+}
+
+// assertNoPersonalData checks that only the subscriptions of userA are left and the other users keep their data
+func assertNoPersonalData(t *testing.T, repo Repo, data personalData) {
+	t.Helper()
+	ctx := bypassContext()
+
+	categories, err := repo.GetCategories(ctx, &userA)
+	if err != nil {
+		t.Fatalf("GetCategories: %v", err)
+	}
+	if len(categories) != builtinCategories {
+		t.Fatalf("got %d categories, want only the %d built-in ones", len(categories), builtinCategories)
+	}
+	tags, err := repo.GetTags(ctx, userA)
+	if err != nil || len(tags) != 0 {
+		t.Fatalf("tags of the user = %v, %v, want none", tags, err)
+	}
+	budgets, err := repo.GetBudgets(ctx, userA)
+	if err != nil || len(budgets) != 0 {
+		t.Fatalf("budgets of the user = %v, %v, want none", budgets, err)
+	}
+	dismissals, err := repo.GetInsightDismissals(ctx, userA)
+	if err != nil || len(dismissals) != 0 {
+		t.Fatalf("insight dismissals of the user = %v, %v, want none", dismissals, err)
+	}
+	organizations, err := repo.GetOrganizations(ctx, userA)
+	if err != nil || len(organizations) != 0 {
+		t.Fatalf("organizations of the user = %v, %v, want none", organizations, err)
+	}
+	assertMembersOnly(t, repo, data.organization, userC)
+
+	//The split left without members stops sharing, the other one keeps userC
+	assertSplits(t, repo, []int{data.sharedAlone, data.sharedWithC}, []*models.SubscriptionSplit{
+		{SubscriptionId: data.sharedWithC, Rule: "equal", Members: []models.SubscriptionMember{{UserId: userC}}},
+	})
+	shared, err := repo.GetSubscriptionTags(ctx, []int{data.sharedAlone})
+	if err != nil || len(shared) != 1 || shared[0].Name != "Music" {
+		t.Fatalf("tags of the other user's subscription = %v, %v, want Music", shared, err)
+	}
+}
+
+func assertMembersOnly(t *testing.T, repo Repo, organizationId int, userId uuid.UUID) {
+	t.Helper()
+
+	members, err := repo.GetMembers(tenant.WithOrganization(context.Background(), organizationId), organizationId)
+	if err != nil {
+		t.Fatalf("GetMembers: %v", err)
+	}
+	if len(members) != 1 || members[0].UserId != userId {
+		t.Fatalf("members = %v, want only %v", members, userId)
+	}
+}
@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateBudget(ctx context.Context, b *models.BudgetCreate) (int, error) {
+	query := `
+		INSERT INTO budget (user_id, amount, category_id, service_id, organization_id)
+			VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`
+	var id int
+
+	//The budget belongs to the tenant it is created in
+	err := r.db.QueryRowContext(ctx, query, b.UserId.String(), b.Amount, b.CategoryId, b.ServiceId, organizationId(ctx)).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateBudget:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetBudget(ctx context.Context, id int) (*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		SELECT id, user_id, amount, category_id, service_id, organization_id
+			FROM budget
+		WHERE id = ?` + condition
+
+	budget, err := scanBudget(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudget:QueryRow - %s", err.Error())
+	}
+
+	return budget, nil
+}
+
+func (r *SubscriptionRepo) GetBudgets(ctx context.Context, userId uuid.UUID) ([]*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{userId.String()}, "organization_id")
+	query := `
+		SELECT id, user_id, amount, category_id, service_id, organization_id
+			FROM budget
+		WHERE user_id = ?` + condition + `
+		ORDER BY id
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgets:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var budgets []*models.Budget
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgets:Scan - %s", err.Error())
+		}
+		budgets = append(budgets, budget)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgets:Scan - %s", err.Error())
+	}
+
+	return budgets, nil
+}
+
+func (r *SubscriptionRepo) UpdateBudget(ctx context.Context, b *models.BudgetUpdate) (*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{b.Amount, b.Id}, "organization_id")
+	query := `
+		UPDATE budget
+		SET
+			amount = ?
+		WHERE
+			id = ?` + condition + `
+		RETURNING id, user_id, amount, category_id, service_id, organization_id
+	`
+
+	budget, err := scanBudget(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateBudget:QueryRow - %s", err.Error())
+	}
+
+	return budget, nil
+}
+
+func (r *SubscriptionRepo) DeleteBudget(ctx context.Context, id int) (*models.Budget, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		DELETE FROM budget
+			WHERE id = ?` + condition + `
+		RETURNING id, user_id, amount, category_id, service_id, organization_id
+	`
+
+	//The alerts are deleted with the budget
+	budget, err := scanBudget(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteBudget:QueryRow - %s", err.Error())
+	}
+
+	return budget, nil
+}
+
+func (r *SubscriptionRepo) CreateBudgetAlert(ctx context.Context, a *models.BudgetAlertCreate) (*models.BudgetAlert, error) {
+	query := `
+		INSERT INTO budget_alert (budget_id, month, threshold, spent, amount, alerted_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (budget_id, month, threshold) DO NOTHING
+		RETURNING id
+	`
+	alert := &models.BudgetAlert{
+		BudgetId:  a.BudgetId,
+		Month:     a.Month,
+		Threshold: a.Threshold,
+		Spent:     a.Spent,
+		Amount:    a.Amount,
+		AlertedAt: a.AlertedAt.UTC(),
+	}
+
+	err := r.db.QueryRowContext(ctx, query, a.BudgetId, formatDate(a.Month), a.Threshold, a.Spent, a.Amount, alert.AlertedAt.Format(time.RFC3339Nano)).Scan(&alert.Id)
+	if err != nil {
+		//Nothing is returned if the alert has already been raised
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrAlreadyExists
+		}
+		if isForeignKeyViolation(err) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.CreateBudgetAlert:QueryRow - %s", err.Error())
+	}
+
+	return alert, nil
+}
+
+func (r *SubscriptionRepo) GetBudgetAlerts(ctx context.Context, userId uuid.UUID) ([]*models.BudgetAlert, error) {
+	args, condition := tenantCondition(ctx, []any{userId.String()}, "b.organization_id")
+	query := `
+		SELECT a.id, a.budget_id, a.month, a.threshold, a.spent, a.amount, a.alerted_at
+			FROM budget_alert a
+			JOIN budget b ON b.id = a.budget_id
+		WHERE b.user_id = ?` + condition + `
+		ORDER BY a.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgetAlerts:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var alerts []*models.BudgetAlert
+	for rows.Next() {
+		var (
+			alert            models.BudgetAlert
+			month, alertedAt string
+		)
+		err := rows.Scan(&alert.Id, &alert.BudgetId, &month, &alert.Threshold, &alert.Spent, &alert.Amount, &alertedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgetAlerts:Scan - %s", err.Error())
+		}
+		alert.Month, err = time.Parse(dateLayout, month)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgetAlerts:Parse - %s", err.Error())
+		}
+		alert.AlertedAt, err = time.Parse(time.RFC3339Nano, alertedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgetAlerts:Parse - %s", err.Error())
+		}
+		alerts = append(alerts, &alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetBudgetAlerts:Scan - %s", err.Error())
+	}
+
+	return alerts, nil
+}
+
+func scanBudget(row scanner) (*models.Budget, error) {
+	var budget models.Budget
+	err := row.Scan(
+		&budget.Id,
+		&budget.UserId,
+		&budget.Amount,
+		&budget.CategoryId,
+		&budget.ServiceId,
+		&budget.OrganizationId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
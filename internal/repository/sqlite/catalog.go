@@ -0,0 +1,446 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func (r *SubscriptionRepo) CreateService(ctx context.Context, s *models.ServiceCreate) (int, error) {
+	query := `
+		INSERT INTO service (name, category_id, website, logo_url)
+			VALUES (?, ?, ?, ?)
+		RETURNING id
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateService:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, query, s.Name, s.CategoryId, s.Website, s.LogoUrl).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateService:QueryRow - %s", err.Error())
+	}
+
+	err = insertAliases(ctx, tx, id, s.Aliases)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateService:insertAliases - %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateService:Commit - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetService(ctx context.Context, id int) (*models.Service, error) {
+	query := `
+		SELECT id, name, category_id, website, logo_url
+			FROM service
+		WHERE id = ?
+	`
+
+	service, err := scanService(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetService:QueryRow - %s", err.Error())
+	}
+
+	err = r.loadAliases(ctx, []*models.Service{service})
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetService:loadAliases - %s", err.Error())
+	}
+
+	return service, nil
+}
+
+func (r *SubscriptionRepo) FindService(ctx context.Context, name string) (*models.Service, error) {
+	query := `
+		SELECT id
+			FROM service
+		WHERE lower(name) = lower(?1)
+			OR id IN (SELECT service_id FROM service_alias WHERE lower(alias) = lower(?1))
+		ORDER BY id
+		LIMIT 1
+	`
+	var id int
+
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.FindService:QueryRow - %s", err.Error())
+	}
+
+	return r.GetService(ctx, id)
+}
+
+func (r *SubscriptionRepo) GetServices(ctx context.Context, offset, limit int) ([]*models.Service, error) {
+	query := `
+		SELECT id, name, category_id, website, logo_url
+			FROM service
+		ORDER BY id
+			LIMIT ?
+			OFFSET ?
+	`
+	err := checkPage(offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServices - %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServices:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var services []*models.Service
+	for rows.Next() {
+		service, err := scanService(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServices:Scan - %s", err.Error())
+		}
+		services = append(services, service)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServices:Scan - %s", err.Error())
+	}
+
+	err = r.loadAliases(ctx, services)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServices:loadAliases - %s", err.Error())
+	}
+
+	return services, nil
+}
+
+func (r *SubscriptionRepo) UpdateService(ctx context.Context, s *models.ServiceUpdate) (*models.Service, error) {
+	query := `
+		UPDATE service
+		SET
+			name = COALESCE(?, name),
+			category_id = COALESCE(?, category_id),
+			website = COALESCE(?, website),
+			logo_url = COALESCE(?, logo_url)
+		WHERE
+			id = ?
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateService:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, s.Name, s.CategoryId, s.Website, s.LogoUrl, s.Id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateService:Exec - %s", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateService:RowsAffected - %s", err.Error())
+	}
+	if affected == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	if s.Aliases != nil {
+		_, err = tx.ExecContext(ctx, "DELETE FROM service_alias WHERE service_id = ?", s.Id)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateService:Exec - %s", err.Error())
+		}
+		err = insertAliases(ctx, tx, s.Id, s.Aliases)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return nil, repository.ErrAlreadyExists
+			}
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateService:insertAliases - %s", err.Error())
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateService:Commit - %s", err.Error())
+	}
+
+	return r.GetService(ctx, s.Id)
+}
+
+func (r *SubscriptionRepo) DeleteService(ctx context.Context, id int) (*models.Service, error) {
+	//The aliases are deleted with the service
+	service, err := r.GetService(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.db.ExecContext(ctx, "DELETE FROM service WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteService:Exec - %s", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteService:RowsAffected - %s", err.Error())
+	}
+	if affected == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	return service, nil
+}
+
+func (r *SubscriptionRepo) LinkSubscriptions(ctx context.Context, serviceId int, names []string) (int, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+	args := []any{serviceId}
+	for _, name := range names {
+		args = append(args, strings.ToLower(name))
+	}
+	args, condition := tenantCondition(ctx, args, "organization_id")
+	query := `
+		UPDATE subscription
+		SET
+			service_id = ?
+		WHERE
+			service_id IS NULL
+			AND lower(service_name) IN (?` + strings.Repeat(", ?", len(names)-1) + `)` + condition
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.LinkSubscriptions:Exec - %s", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.LinkSubscriptions:RowsAffected - %s", err.Error())
+	}
+
+	return int(affected), nil
+}
+
+func (r *SubscriptionRepo) CreatePlan(ctx context.Context, p *models.PlanCreate) (int, error) {
+	query := `
+		INSERT INTO plan (service_id, name, price, billing_period)
+			VALUES (?, ?, ?, ?)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRowContext(ctx, query, p.ServiceId, p.Name, p.Price, p.BillingPeriod).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreatePlan:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetPlan(ctx context.Context, id int) (*models.Plan, error) {
+	query := `
+		SELECT id, service_id, name, price, billing_period
+			FROM plan
+		WHERE id = ?
+	`
+
+	plan, err := scanPlan(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPlan:QueryRow - %s", err.Error())
+	}
+
+	return plan, nil
+}
+
+func (r *SubscriptionRepo) GetPlans(ctx context.Context, serviceIds []int) ([]*models.Plan, error) {
+	if len(serviceIds) == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT id, service_id, name, price, billing_period
+			FROM plan
+		WHERE service_id IN (?` + strings.Repeat(", ?", len(serviceIds)-1) + `)
+		ORDER BY service_id, id
+	`
+	args := make([]any, 0, len(serviceIds))
+	for _, id := range serviceIds {
+		args = append(args, id)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPlans:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var plans []*models.Plan
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPlans:Scan - %s", err.Error())
+		}
+		plans = append(plans, plan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPlans:Scan - %s", err.Error())
+	}
+
+	return plans, nil
+}
+
+func (r *SubscriptionRepo) UpdatePlan(ctx context.Context, p *models.PlanUpdate) (*models.Plan, error) {
+	query := `
+		UPDATE plan
+		SET
+			name = COALESCE(?, name),
+			price = COALESCE(?, price),
+			billing_period = COALESCE(?, billing_period)
+		WHERE
+			id = ?
+		RETURNING id, service_id, name, price, billing_period
+	`
+
+	plan, err := scanPlan(r.db.QueryRowContext(ctx, query, p.Name, p.Price, p.BillingPeriod, p.Id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdatePlan:QueryRow - %s", err.Error())
+	}
+
+	return plan, nil
+}
+
+func (r *SubscriptionRepo) DeletePlan(ctx context.Context, id int) (*models.Plan, error) {
+	query := `
+		DELETE FROM plan
+			WHERE id = ?
+		RETURNING id, service_id, name, price, billing_period
+	`
+
+	plan, err := scanPlan(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeletePlan:QueryRow - %s", err.Error())
+	}
+
+	return plan, nil
+}
+
+// loadAliases sets the ordered aliases of the services
+func (r *SubscriptionRepo) loadAliases(ctx context.Context, services []*models.Service) error {
+	if len(services) == 0 {
+		return nil
+	}
+	query := `
+		SELECT service_id, alias
+			FROM service_alias
+		WHERE service_id IN (?` + strings.Repeat(", ?", len(services)-1) + `)
+		ORDER BY service_id, alias
+	`
+	byId := make(map[int]*models.Service, len(services))
+	args := make([]any, 0, len(services))
+	for _, s := range services {
+		s.Aliases = []string{}
+		byId[s.Id] = s
+		args = append(args, s.Id)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			serviceId int
+			alias     string
+		)
+		if err := rows.Scan(&serviceId, &alias); err != nil {
+			return err
+		}
+		byId[serviceId].Aliases = append(byId[serviceId].Aliases, alias)
+	}
+
+	return rows.Err()
+}
+
+func insertAliases(ctx context.Context, tx *sql.Tx, serviceId int, aliases []string) error {
+	for _, alias := range aliases {
+		_, err := tx.ExecContext(ctx, "INSERT INTO service_alias (service_id, alias) VALUES (?, ?)", serviceId, alias)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanService(row scanner) (*models.Service, error) {
+	var service models.Service
+	err := row.Scan(
+		&service.Id,
+		&service.Name,
+		&service.CategoryId,
+		&service.Website,
+		&service.LogoUrl,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+func scanPlan(row scanner) (*models.Plan, error) {
+	var plan models.Plan
+	err := row.Scan(
+		&plan.Id,
+		&plan.ServiceId,
+		&plan.Name,
+		&plan.Price,
+		&plan.BillingPeriod,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// isUniqueViolation reports whether err is raised by a unique constraint or index
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	//A composite primary key is reported with its own code
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateCategory(ctx context.Context, c *models.CategoryCreate) (int, error) {
+	query := `
+		INSERT INTO category (name, parent_id, user_id)
+			VALUES (?, ?, ?)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRowContext(ctx, query, c.Name, c.ParentId, c.UserId).Scan(&id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateCategory:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetCategory(ctx context.Context, id int) (*models.Category, error) {
+	query := `
+		SELECT id, name, parent_id, user_id
+			FROM category
+		WHERE id = ?
+	`
+
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetCategory:QueryRow - %s", err.Error())
+	}
+
+	return category, nil
+}
+
+func (r *SubscriptionRepo) GetCategories(ctx context.Context, userId *uuid.UUID) ([]*models.Category, error) {
+	query := `
+		SELECT id, name, parent_id, user_id
+			FROM category
+	`
+	var args []any
+	if userId != nil {
+		query += " WHERE user_id IS NULL OR user_id = ?"
+		args = append(args, userId.String())
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetCategories:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var categories []*models.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetCategories:Scan - %s", err.Error())
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetCategories:Scan - %s", err.Error())
+	}
+
+	return categories, nil
+}
+
+func (r *SubscriptionRepo) UpdateCategory(ctx context.Context, c *models.CategoryUpdate) (*models.Category, error) {
+	query := `
+		UPDATE category
+		SET
+			name = COALESCE(?, name),
+			parent_id = COALESCE(?, parent_id)
+		WHERE
+			id = ?
+		RETURNING id, name, parent_id, user_id
+	`
+
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, c.Name, c.ParentId, c.Id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isForeignKeyViolation(err) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateCategory:QueryRow - %s", err.Error())
+	}
+
+	return category, nil
+}
+
+func (r *SubscriptionRepo) DeleteCategory(ctx context.Context, id int) (*models.Category, error) {
+	query := `
+		DELETE FROM category
+			WHERE id = ?
+		RETURNING id, name, parent_id, user_id
+	`
+
+	//The subcategories are deleted with the category
+	category, err := scanCategory(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteCategory:QueryRow - %s", err.Error())
+	}
+
+	return category, nil
+}
+
+func (r *SubscriptionRepo) GetServiceCategories(ctx context.Context, serviceIds []int) ([]*models.ServiceCategory, error) {
+	if len(serviceIds) == 0 {
+		return nil, nil
+	}
+	query := `
+		SELECT id, category_id
+			FROM service
+		WHERE id IN (?` + strings.Repeat(", ?", len(serviceIds)-1) + `)
+			AND category_id IS NOT NULL
+		ORDER BY id
+	`
+	args := make([]any, 0, len(serviceIds))
+	for _, id := range serviceIds {
+		args = append(args, id)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServiceCategories:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var categories []*models.ServiceCategory
+	for rows.Next() {
+		var category models.ServiceCategory
+		err := rows.Scan(&category.ServiceId, &category.CategoryId)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServiceCategories:Scan - %s", err.Error())
+		}
+		categories = append(categories, &category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServiceCategories:Scan - %s", err.Error())
+	}
+
+	return categories, nil
+}
+
+func scanCategory(row scanner) (*models.Category, error) {
+	var category models.Category
+	err := row.Scan(
+		&category.Id,
+		&category.Name,
+		&category.ParentId,
+		&category.UserId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) DismissInsight(ctx context.Context, d *models.InsightDismissal) error {
+	query := `
+		INSERT INTO insight_dismissal (user_id, insight_id, dismissed_at, organization_id)
+			VALUES (?, ?, ?, ?)
+		ON CONFLICT (organization_id, user_id, insight_id) DO NOTHING
+	`
+
+	//The first dismissal in the tenant is kept
+	_, err := r.db.ExecContext(ctx, query, d.UserId.String(), d.InsightId, d.DismissedAt.UTC().Format(time.RFC3339Nano), organizationId(ctx))
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.DismissInsight:Exec - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetInsightDismissals(ctx context.Context, userId uuid.UUID) ([]*models.InsightDismissal, error) {
+	args, condition := tenantCondition(ctx, []any{userId.String()}, "organization_id")
+	query := `
+		SELECT user_id, insight_id, dismissed_at, organization_id
+			FROM insight_dismissal
+		WHERE user_id = ?` + condition + `
+		ORDER BY insight_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetInsightDismissals:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var dismissals []*models.InsightDismissal
+	for rows.Next() {
+		var (
+			dismissal   models.InsightDismissal
+			dismissedAt string
+		)
+		err := rows.Scan(&dismissal.UserId, &dismissal.InsightId, &dismissedAt, &dismissal.OrganizationId)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetInsightDismissals:Scan - %s", err.Error())
+		}
+		dismissal.DismissedAt, err = time.Parse(time.RFC3339Nano, dismissedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetInsightDismissals:Parse - %s", err.Error())
+		}
+		dismissals = append(dismissals, &dismissal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetInsightDismissals:Scan - %s", err.Error())
+	}
+
+	return dismissals, nil
+}
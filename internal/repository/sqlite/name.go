@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+func (r *SubscriptionRepo) GetServiceNames(ctx context.Context) ([]*models.ServiceName, error) {
+	args, condition := tenantCondition(ctx, nil, "organization_id")
+	query := `
+		SELECT service_name, count(*)
+			FROM subscription
+		WHERE TRUE` + condition + `
+		GROUP BY service_name
+		ORDER BY service_name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServiceNames:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var names []*models.ServiceName
+	for rows.Next() {
+		var name models.ServiceName
+		err := rows.Scan(&name.Name, &name.Subscriptions)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServiceNames:Scan - %s", err.Error())
+		}
+		names = append(names, &name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetServiceNames:Scan - %s", err.Error())
+	}
+
+	return names, nil
+}
+
+// SimilarNames compares the names in Go, SQLite has no trigram functions
+func (r *SubscriptionRepo) SimilarNames(ctx context.Context, names []string, threshold float64) ([]*models.NamePair, error) {
+	return repository.SimilarNames(names, threshold), nil
+}
+
+func (r *SubscriptionRepo) MergeServiceNames(ctx context.Context, m *models.NameMerge) (*models.NameMergeAudit, error) {
+	if len(m.Names) == 0 {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.MergeServiceNames - no names to merge")
+	}
+	//The tenant argument goes after the names
+	tenantArgs, condition := tenantCondition(ctx, nil, "organization_id")
+	updateQuery := `
+		UPDATE subscription
+		SET
+			service_name = ?,
+			service_id = COALESCE(?, service_id),
+			plan_id = CASE WHEN ? IS NULL OR service_id = ? THEN plan_id END
+		WHERE service_name IN (?` + strings.Repeat(", ?", len(m.Names)-1) + `)` + condition
+	auditQuery := `
+		INSERT INTO service_name_merge (canonical_name, merged_names, subscription_count, merged_at, organization_id)
+			VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`
+	names, err := json.Marshal(m.Names)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.MergeServiceNames:Marshal - %s", err.Error())
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.MergeServiceNames:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	args := []any{m.Canonical, m.ServiceId, m.ServiceId, m.ServiceId}
+	for _, name := range m.Names {
+		args = append(args, name)
+	}
+	res, err := tx.ExecContext(ctx, updateQuery, append(args, tenantArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.MergeServiceNames:Exec - %s", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.MergeServiceNames:RowsAffected - %s", err.Error())
+	}
+
+	audit := &models.NameMergeAudit{
+		Canonical:     m.Canonical,
+		Names:         m.Names,
+		Subscriptions: int(affected),
+		MergedAt:      m.MergedAt.UTC(),
+		//Outside of a tenant the merge renamed the subscriptions of all the organizations
+		OrganizationId: organizationId(ctx),
+	}
+	err = tx.QueryRowContext(ctx, auditQuery, audit.Canonical, string(names), audit.Subscriptions, audit.MergedAt.Format(time.RFC3339Nano), audit.OrganizationId).Scan(&audit.Id)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.MergeServiceNames:QueryRow - %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.MergeServiceNames:Commit - %s", err.Error())
+	}
+
+	return audit, nil
+}
+
+func (r *SubscriptionRepo) GetNameMerges(ctx context.Context, offset, limit int) ([]*models.NameMergeAudit, error) {
+	args, condition := tenantCondition(ctx, nil, "organization_id")
+	query := `
+		SELECT id, canonical_name, merged_names, subscription_count, merged_at, organization_id
+			FROM service_name_merge
+		WHERE TRUE` + condition + `
+		ORDER BY id
+			LIMIT ?
+			OFFSET ?
+	`
+	err := checkPage(offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetNameMerges - %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetNameMerges:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var merges []*models.NameMergeAudit
+	for rows.Next() {
+		var (
+			merge         models.NameMergeAudit
+			names, merged string
+		)
+		err := rows.Scan(&merge.Id, &merge.Canonical, &names, &merge.Subscriptions, &merged, &merge.OrganizationId)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetNameMerges:Scan - %s", err.Error())
+		}
+		err = json.Unmarshal([]byte(names), &merge.Names)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetNameMerges:Unmarshal - %s", err.Error())
+		}
+		merge.MergedAt, err = time.Parse(time.RFC3339Nano, merged)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetNameMerges:Parse - %s", err.Error())
+		}
+		merges = append(merges, &merge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetNameMerges:Scan - %s", err.Error())
+	}
+
+	return merges, nil
+}
@@ -0,0 +1,293 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateOrganization(ctx context.Context, o *models.OrganizationCreate) (int, error) {
+	query := `
+		INSERT INTO organization (name, created_at)
+			VALUES (?, ?)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRowContext(ctx, query, o.Name, o.CreatedAt.UTC().Format(time.RFC3339Nano)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateOrganization:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetOrganization(ctx context.Context, id int) (*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "id")
+	query := `
+		SELECT id, name, created_at
+			FROM organization
+		WHERE id = ?` + condition
+
+	organization, err := scanOrganization(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetOrganization:QueryRow - %s", err.Error())
+	}
+
+	return organization, nil
+}
+
+func (r *SubscriptionRepo) GetOrganizations(ctx context.Context, userId uuid.UUID) ([]*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{userId.String()}, "o.id")
+	query := `
+		SELECT o.id, o.name, o.created_at
+			FROM organization o
+			JOIN organization_member m ON m.organization_id = o.id
+		WHERE m.user_id = ?` + condition + `
+		ORDER BY o.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetOrganizations:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var organizations []*models.Organization
+	for rows.Next() {
+		organization, err := scanOrganization(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetOrganizations:Scan - %s", err.Error())
+		}
+		organizations = append(organizations, organization)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetOrganizations:Scan - %s", err.Error())
+	}
+
+	return organizations, nil
+}
+
+func (r *SubscriptionRepo) UpdateOrganization(ctx context.Context, o *models.OrganizationUpdate) (*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{o.Name, o.Id}, "id")
+	query := `
+		UPDATE organization
+		SET
+			name = ?
+		WHERE
+			id = ?` + condition + `
+		RETURNING id, name, created_at
+	`
+
+	organization, err := scanOrganization(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateOrganization:QueryRow - %s", err.Error())
+	}
+
+	return organization, nil
+}
+
+func (r *SubscriptionRepo) DeleteOrganization(ctx context.Context, id int) (*models.Organization, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "id")
+	query := `
+		DELETE FROM organization
+			WHERE id = ?` + condition + `
+		RETURNING id, name, created_at
+	`
+
+	//The members are deleted with the organization, the subscriptions restrict the deletion
+	organization, err := scanOrganization(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isForeignKeyViolation(err) {
+			return nil, repository.ErrInUse
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteOrganization:QueryRow - %s", err.Error())
+	}
+
+	return organization, nil
+}
+
+func (r *SubscriptionRepo) AddMember(ctx context.Context, m *models.OrganizationMember) error {
+	args, condition := tenantCondition(ctx, []any{m.UserId.String(), m.JoinedAt.UTC().Format(time.RFC3339Nano), m.OrganizationId}, "id")
+	query := `
+		INSERT INTO organization_member (organization_id, user_id, joined_at)
+			SELECT id, ?, ?
+				FROM organization
+			WHERE id = ?` + condition + `
+		ON CONFLICT (organization_id, user_id) DO NOTHING
+	`
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.AddMember:Exec - %s", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.AddMember:RowsAffected - %s", err.Error())
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	//Nothing is inserted for a missing organization or a user who is already a member
+	_, err = r.GetOrganization(ctx, m.OrganizationId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("sqlite:SubscriptionRepo.AddMember:GetOrganization - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) RemoveMember(ctx context.Context, organizationId int, userId uuid.UUID) error {
+	args, condition := tenantCondition(ctx, []any{organizationId, userId.String()}, "organization_id")
+	query := `
+		DELETE FROM organization_member
+			WHERE organization_id = ?
+			AND user_id = ?` + condition
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.RemoveMember:Exec - %s", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.RemoveMember:RowsAffected - %s", err.Error())
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetMembers(ctx context.Context, organizationId int) ([]*models.OrganizationMember, error) {
+	args, condition := tenantCondition(ctx, []any{organizationId}, "organization_id")
+	query := `
+		SELECT organization_id, user_id, joined_at
+			FROM organization_member
+		WHERE organization_id = ?` + condition + `
+		ORDER BY user_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetMembers:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var members []*models.OrganizationMember
+	for rows.Next() {
+		var (
+			member   models.OrganizationMember
+			userId   string
+			joinedAt string
+		)
+		err := rows.Scan(&member.OrganizationId, &userId, &joinedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetMembers:Scan - %s", err.Error())
+		}
+		member.UserId, err = uuid.Parse(userId)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetMembers:Parse - %s", err.Error())
+		}
+		member.JoinedAt, err = time.Parse(time.RFC3339Nano, joinedAt)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetMembers:Parse - %s", err.Error())
+		}
+		members = append(members, &member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetMembers:Scan - %s", err.Error())
+	}
+
+	return members, nil
+}
+
+func (r *SubscriptionRepo) IsMember(ctx context.Context, organizationId int, userId uuid.UUID) (bool, error) {
+	args, condition := tenantCondition(ctx, []any{organizationId, userId.String()}, "organization_id")
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+				FROM organization_member
+			WHERE organization_id = ?
+				AND user_id = ?` + condition + `
+		)
+	`
+	var member bool
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&member)
+	if err != nil {
+		return false, fmt.Errorf("sqlite:SubscriptionRepo.IsMember:QueryRow - %s", err.Error())
+	}
+
+	return member, nil
+}
+
+func scanOrganization(row scanner) (*models.Organization, error) {
+	var (
+		organization models.Organization
+		createdAt    string
+	)
+	err := row.Scan(&organization.Id, &organization.Name, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	organization.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+// organizationId returns the organization of ctx to store with a new subscription
+func organizationId(ctx context.Context) sql.NullInt32 {
+	id, ok := tenant.Organization(ctx)
+	return sql.NullInt32{Int32: int32(id), Valid: ok}
+}
+
+// tenantCondition appends the organization of ctx to the query arguments and returns the condition
+// matching its rows by the column. Outside of an organization no rows match unless ctx bypasses the tenant.
+// SQLite has no row level security, the condition is the only isolation.
+func tenantCondition(ctx context.Context, args []any, column string) ([]any, string) {
+	id, ok := tenant.Organization(ctx)
+	if !ok {
+		return args, bypassCondition(ctx)
+	}
+	return append(args, id), " AND " + column + " = ?"
+}
+
+// tenantSubscriptionCondition is tenantCondition for the tables referencing the subscription by the column
+func tenantSubscriptionCondition(ctx context.Context, args []any, column string) ([]any, string) {
+	id, ok := tenant.Organization(ctx)
+	if !ok {
+		return args, bypassCondition(ctx)
+	}
+	return append(args, id), " AND " + column + " IN (SELECT id FROM subscription WHERE organization_id = ?)"
+}
+
+// bypassCondition is the condition outside of an organization
+func bypassCondition(ctx context.Context) string {
+	if tenant.Bypassed(ctx) {
+		return ""
+	}
+	return " AND FALSE"
+}
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func (r *SubscriptionRepo) CreatePause(ctx context.Context, p *models.PauseCreate) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreatePause:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	startDate, endDate := formatDate(p.StartDate), formatNullDate(p.EndDate)
+	args, condition := tenantCondition(ctx, []any{p.SubscriptionId, startDate, endDate, p.SubscriptionId}, "organization_id")
+	query := `
+		INSERT INTO subscription_pause (subscription_id, start_date, end_date)
+			SELECT ?, ?, ?
+			WHERE EXISTS (SELECT 1 FROM subscription WHERE id = ?` + condition + `)
+		RETURNING id
+	`
+	var id int
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, repository.ErrNotFound
+		}
+		if isForeignKeyViolation(err) {
+			return 0, repository.ErrNotFound
+		}
+		if isCheckViolation(err, repository.ConstraintPausePeriod) {
+			return 0, repository.ErrIncorrectTime
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreatePause:QueryRow - %s", err.Error())
+	}
+
+	//The insert holds the write lock until the commit, so no other pause
+	//can be inserted between it and this check of the pause_no_overlap rule
+	var overlap bool
+	query = `
+		SELECT EXISTS (
+			SELECT 1 FROM subscription_pause
+			WHERE
+				subscription_id = ?
+				AND id <> ?
+				AND (end_date IS NULL OR end_date >= ?)
+				AND (? IS NULL OR start_date <= ?)
+		)
+	`
+	err = tx.QueryRowContext(ctx, query, p.SubscriptionId, id, startDate, endDate, endDate).Scan(&overlap)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreatePause:QueryRow - %s", err.Error())
+	}
+	if overlap {
+		return 0, repository.ErrOverlap
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreatePause:Commit - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) EndPause(ctx context.Context, id int, endDate time.Time) (*models.Pause, error) {
+	args, condition := tenantSubscriptionCondition(ctx, []any{formatDate(endDate), id}, "subscription_id")
+	query := `
+		UPDATE subscription_pause
+		SET
+			end_date = ?
+		WHERE
+			id = ?` + condition + `
+		RETURNING id, subscription_id, start_date, end_date
+	`
+
+	pause, err := scanPause(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isCheckViolation(err, repository.ConstraintPausePeriod) {
+			return nil, repository.ErrIncorrectTime
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.EndPause:QueryRow - %s", err.Error())
+	}
+
+	return pause, nil
+}
+
+func (r *SubscriptionRepo) GetPauses(ctx context.Context, subscriptionIds []int) ([]*models.Pause, error) {
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(subscriptionIds))
+	for i, id := range subscriptionIds {
+		args[i] = id
+	}
+	args, condition := tenantSubscriptionCondition(ctx, args, "subscription_id")
+	query := `
+		SELECT id, subscription_id, start_date, end_date
+			FROM subscription_pause
+		WHERE subscription_id IN (?` + strings.Repeat(", ?", len(subscriptionIds)-1) + `)` + condition + `
+		ORDER BY subscription_id, start_date, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPauses:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var pauses []*models.Pause
+	for rows.Next() {
+		pause, err := scanPause(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPauses:Scan - %s", err.Error())
+		}
+		pauses = append(pauses, pause)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPauses:Rows - %s", err.Error())
+	}
+
+	return pauses, nil
+}
+
+func scanPause(row scanner) (*models.Pause, error) {
+	var (
+		pause     models.Pause
+		startDate string
+		endDate   sql.NullString
+	)
+	err := row.Scan(&pause.Id, &pause.SubscriptionId, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	pause.StartDate, err = time.Parse(dateLayout, startDate)
+	if err != nil {
+		return nil, err
+	}
+	if endDate.Valid {
+		pause.EndDate.Time, err = time.Parse(dateLayout, endDate.String)
+		if err != nil {
+			return nil, err
+		}
+		pause.EndDate.Valid = true
+	}
+
+	return &pause, nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+func (r *SubscriptionRepo) CreatePriceChange(ctx context.Context, p *models.PriceChangeCreate) (int, error) {
+	args, condition := tenantCondition(ctx, []any{p.SubscriptionId, formatDate(p.EffectiveDate), p.Price, p.SubscriptionId}, "organization_id")
+	query := `
+		INSERT INTO subscription_price_change (subscription_id, effective_date, price)
+			SELECT ?, ?, ?
+			WHERE EXISTS (SELECT 1 FROM subscription WHERE id = ?` + condition + `)
+		RETURNING id
+	`
+	var id int
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, repository.ErrNotFound
+		}
+		if isForeignKeyViolation(err) {
+			return 0, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreatePriceChange:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetPriceChanges(ctx context.Context, subscriptionIds []int) ([]*models.PriceChange, error) {
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(subscriptionIds))
+	for i, id := range subscriptionIds {
+		args[i] = id
+	}
+	args, condition := tenantSubscriptionCondition(ctx, args, "subscription_id")
+	query := `
+		SELECT id, subscription_id, effective_date, price
+			FROM subscription_price_change
+		WHERE subscription_id IN (?` + strings.Repeat(", ?", len(subscriptionIds)-1) + `)` + condition + `
+		ORDER BY subscription_id, effective_date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPriceChanges:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var changes []*models.PriceChange
+	for rows.Next() {
+		var (
+			change        models.PriceChange
+			effectiveDate string
+		)
+		err := rows.Scan(&change.Id, &change.SubscriptionId, &effectiveDate, &change.Price)
+		if err == nil {
+			change.EffectiveDate, err = time.Parse(dateLayout, effectiveDate)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPriceChanges:Scan - %s", err.Error())
+		}
+		changes = append(changes, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPriceChanges:Rows - %s", err.Error())
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+func (r *SubscriptionRepo) SetSplit(ctx context.Context, split *models.SubscriptionSplit) error {
+	existsArgs, condition := tenantCondition(ctx, []any{split.SubscriptionId}, "organization_id")
+	existsQuery := `
+		SELECT id
+			FROM subscription
+		WHERE id = ?` + condition
+	deleteQuery := `
+		DELETE FROM subscription_split
+			WHERE subscription_id = ?
+	`
+	splitQuery := `
+		INSERT INTO subscription_split (subscription_id, rule)
+			VALUES (?, ?)
+	`
+	memberQuery := `
+		INSERT INTO subscription_member (subscription_id, user_id, share)
+			VALUES (?, ?, ?)
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSplit:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, existsQuery, existsArgs...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSplit:QueryRow - %s", err.Error())
+	}
+
+	//The members are deleted with the split
+	_, err = tx.ExecContext(ctx, deleteQuery, split.SubscriptionId)
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSplit:Exec - %s", err.Error())
+	}
+	if len(split.Members) > 0 {
+		_, err = tx.ExecContext(ctx, splitQuery, split.SubscriptionId, split.Rule)
+		if err != nil {
+			return fmt.Errorf("sqlite:SubscriptionRepo.SetSplit:Exec - %s", err.Error())
+		}
+		for _, m := range split.Members {
+			_, err = tx.ExecContext(ctx, memberQuery, split.SubscriptionId, m.UserId.String(), m.Share)
+			if err != nil {
+				if isUniqueViolation(err) {
+					return repository.ErrAlreadyExists
+				}
+				return fmt.Errorf("sqlite:SubscriptionRepo.SetSplit:Exec - %s", err.Error())
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSplit:Commit - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetSplits(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionSplit, error) {
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, len(subscriptionIds))
+	for _, id := range subscriptionIds {
+		args = append(args, id)
+	}
+	args, condition := tenantSubscriptionCondition(ctx, args, "s.subscription_id")
+	query := `
+		SELECT s.subscription_id, s.rule, m.user_id, m.share
+			FROM subscription_split s
+			JOIN subscription_member m ON m.subscription_id = s.subscription_id
+		WHERE s.subscription_id IN (?` + strings.Repeat(", ?", len(subscriptionIds)-1) + `)` + condition + `
+		ORDER BY s.subscription_id, m.user_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetSplits:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var splits []*models.SubscriptionSplit
+	for rows.Next() {
+		var (
+			subscriptionId int
+			rule           string
+			member         models.SubscriptionMember
+		)
+		err := rows.Scan(&subscriptionId, &rule, &member.UserId, &member.Share)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetSplits:Scan - %s", err.Error())
+		}
+		if len(splits) == 0 || splits[len(splits)-1].SubscriptionId != subscriptionId {
+			splits = append(splits, &models.SubscriptionSplit{SubscriptionId: subscriptionId, Rule: rule})
+		}
+		split := splits[len(splits)-1]
+		split.Members = append(split.Members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetSplits:Scan - %s", err.Error())
+	}
+
+	return splits, nil
+}
+
+// memberCondition appends the user to the query arguments and returns the condition
+// matching the subscriptions of the user and the ones the user is a member of
+func memberCondition(args []any, userId string) ([]any, string) {
+	args = append(args, userId, userId)
+	return args, " AND (user_id = ? OR id IN (SELECT subscription_id FROM subscription_member WHERE user_id = ?))"
+}
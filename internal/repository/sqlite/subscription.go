@@ -0,0 +1,557 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Dates are stored as ISO 8601 text, so they compare correctly as strings
+const dateLayout = "2006-01-02"
+
+// SubscriptionRepo keeps subscriptions in a SQLite database with the same semantics as db.SubscriptionRepo
+type SubscriptionRepo struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepo(db *sql.DB) *SubscriptionRepo {
+	return &SubscriptionRepo{
+		db: db,
+	}
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, s *models.SubscriptionCreate) (int, error) {
+	query := `
+		INSERT INTO subscription (service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, service_id, plan_id, category_id, notes, organization_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+	var id int
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.Create:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	//The subscription belongs to the tenant it is created in
+	err = tx.QueryRowContext(ctx, query, s.ServiceName, s.Price, s.UserId.String(), formatDate(s.StartDate), formatNullDate(s.EndDate), s.BillingDay, s.TrialMonths, s.TrialPrice, s.ServiceId, s.PlanId, s.CategoryId, s.Notes, organizationId(ctx)).Scan(&id)
+	if err != nil {
+		if isCheckViolation(err, repository.ConstraintPeriod) {
+			return 0, repository.ErrIncorrectTime
+		}
+		//SQLite doesn't name the violated foreign key, the catalog, the category and the organization are checked by the service
+		if isForeignKeyViolation(err) {
+			return 0, repository.ErrUnknownUser
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.Create:QueryRow - %s", err.Error())
+	}
+	if s.Tags != nil {
+		if err := setTags(ctx, tx, id, s.UserId.String(), s.Tags); err != nil {
+			return 0, fmt.Errorf("sqlite:SubscriptionRepo.Create:setTags - %s", err.Error())
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.Create:Commit - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetById(ctx context.Context, id int) (*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+			FROM subscription
+		WHERE id = ?` + condition
+
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetById:QueryRow - %s", err.Error())
+	}
+
+	return subscription, nil
+}
+
+func (r *SubscriptionRepo) GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int) ([]*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{userId.String()}, "organization_id")
+	query := `
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+			FROM subscription
+		WHERE user_id = ?` + condition + `
+		ORDER BY id
+			LIMIT ?
+			OFFSET ?
+	`
+	err := checkPage(offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetByUser - %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetByUser:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetByUser:Scan - %s", err.Error())
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepo) DeleteById(ctx context.Context, id int) (*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		DELETE FROM subscription
+			WHERE id = ?` + condition + `
+		RETURNING id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+	`
+
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteById:QueryRow - %s", err.Error())
+	}
+
+	return subscription, nil
+}
+
+func (r *SubscriptionRepo) Update(ctx context.Context, s *models.SubscriptionUpdate) (*models.Subscription, error) {
+	var startDate sql.NullString
+	if s.StartDate.Valid {
+		startDate = sql.NullString{String: formatDate(s.StartDate.Time), Valid: true}
+	}
+	args, condition := tenantCondition(ctx, []any{s.ServiceName, s.Price, startDate, formatNullDate(s.EndDate), s.BillingDay, s.TrialMonths, s.TrialPrice, s.ServiceId, s.PlanId, s.CategoryId, s.Notes, s.Id}, "organization_id")
+	query := `
+		UPDATE subscription
+		SET
+			service_name = COALESCE(?, service_name),
+			price = COALESCE(?, price),
+			start_date = COALESCE(?, start_date),
+			end_date = COALESCE(?, end_date),
+			billing_day = COALESCE(?, billing_day),
+			trial_months = COALESCE(?, trial_months),
+			trial_price = COALESCE(?, trial_price),
+			service_id = COALESCE(?, service_id),
+			plan_id = COALESCE(?, plan_id),
+			category_id = COALESCE(?, category_id),
+			notes = COALESCE(?, notes)
+		WHERE
+			id = ?` + condition + `
+		RETURNING id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.Update:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	subscription, err := scanSubscription(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isCheckViolation(err, repository.ConstraintPeriod) {
+			return nil, repository.ErrIncorrectTime
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.Update:QueryRow - %s", err.Error())
+	}
+	if s.Tags != nil {
+		if err := setTags(ctx, tx, subscription.Id, subscription.UserId.String(), s.Tags); err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.Update:setTags - %s", err.Error())
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.Update:Commit - %s", err.Error())
+	}
+
+	return subscription, nil
+}
+
+func (r *SubscriptionRepo) GetByPeriod(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.Subscription, error) {
+	query := `
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+			FROM subscription
+		WHERE start_date <= ?
+			AND (end_date IS NULL OR end_date >= ?)
+	`
+	args, condition := tenantCondition(ctx, []any{formatDate(filter.To), formatDate(filter.From)}, "organization_id")
+	query += condition
+	if filter.UserId != nil && filter.Members {
+		args, condition = memberCondition(args, filter.UserId.String())
+		query += condition
+	} else if filter.UserId != nil {
+		query += " AND user_id = ?"
+		args = append(args, filter.UserId.String())
+	}
+	if filter.Shared {
+		query += " AND id IN (SELECT subscription_id FROM subscription_split)"
+	}
+	if filter.ServiceName != nil && filter.ServiceId != nil {
+		query += " AND (service_name = ? OR service_id = ?)"
+		args = append(args, *filter.ServiceName, *filter.ServiceId)
+	} else if filter.ServiceName != nil {
+		query += " AND service_name = ?"
+		args = append(args, *filter.ServiceName)
+	} else if filter.ServiceId != nil {
+		query += " AND service_id = ?"
+		args = append(args, *filter.ServiceId)
+	}
+	if filter.Trial {
+		query += " AND trial_months > 0"
+	}
+	if filter.CategoryIds != nil {
+		query += categoryCondition(len(filter.CategoryIds))
+		args = appendCategoryIds(args, filter.CategoryIds)
+	}
+	args, condition = tagCondition(args, filter.Tags, filter.ExcludedTags)
+	query += condition
+	query += " ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetByPeriod:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetByPeriod:Scan - %s", err.Error())
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepo) GetAll(ctx context.Context, offset, limit int) ([]*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, nil, "organization_id")
+	query := `
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+			FROM subscription
+		WHERE TRUE` + condition + `
+		ORDER BY id
+			LIMIT ?
+			OFFSET ?
+	`
+	err := checkPage(offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetAll - %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetAll:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetAll:Scan - %s", err.Error())
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepo) CountActive(ctx context.Context, at time.Time) (int, error) {
+	args, condition := tenantCondition(ctx, []any{formatDate(at), formatDate(at)}, "organization_id")
+	query := `
+		SELECT count(*)
+			FROM subscription
+		WHERE start_date <= ?
+			AND (end_date IS NULL OR end_date >= ?)` + condition
+	var count int
+
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CountActive:QueryRow - %s", err.Error())
+	}
+
+	return count, nil
+}
+
+func (r *SubscriptionRepo) Cancel(ctx context.Context, c *models.SubscriptionCancel) (*models.Subscription, error) {
+	args, condition := tenantCondition(ctx, []any{formatDate(c.EndDate), formatDate(c.CancelledAt), c.Reason, c.Id}, "organization_id")
+	query := `
+		UPDATE subscription
+		SET
+			end_date = ?,
+			cancelled_at = ?,
+			cancel_reason = ?
+		WHERE
+			id = ?` + condition + `
+		RETURNING id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+	`
+
+	subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isCheckViolation(err, repository.ConstraintPeriod) {
+			return nil, repository.ErrIncorrectTime
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.Cancel:QueryRow - %s", err.Error())
+	}
+
+	return subscription, nil
+}
+
+func (r *SubscriptionRepo) GetByState(ctx context.Context, filter *models.StateFilter) ([]*models.Subscription, error) {
+	query, args := stateQuery(ctx, filter)
+	query += " ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetByState:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetByState:Scan - %s", err.Error())
+	}
+
+	return subscriptions, nil
+}
+
+func (r *SubscriptionRepo) GetPageByState(ctx context.Context, filter *models.StateFilter, offset, limit int) ([]*models.Subscription, error) {
+	if err := checkPage(offset, limit); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPageByState - %w", err)
+	}
+
+	query, args := stateQuery(ctx, filter)
+	query += `
+		ORDER BY id
+			LIMIT ?
+			OFFSET ?
+	`
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPageByState:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetPageByState:Scan - %s", err.Error())
+	}
+
+	return subscriptions, nil
+}
+
+// stateQuery selects the subscriptions matching the filter
+func stateQuery(ctx context.Context, filter *models.StateFilter) (string, []any) {
+	query := `
+		SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price, cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+			FROM subscription
+		WHERE TRUE
+	`
+	args, condition := tenantCondition(ctx, nil, "organization_id")
+	query += condition
+	if filter.UserId != nil {
+		query += " AND user_id = ?"
+		args = append(args, filter.UserId.String())
+	}
+	if filter.Cancelled != nil {
+		if *filter.Cancelled {
+			query += " AND cancelled_at IS NOT NULL"
+		} else {
+			query += " AND cancelled_at IS NULL"
+		}
+	}
+	if filter.EndedBefore != nil {
+		query += " AND end_date < ?"
+		args = append(args, formatDate(*filter.EndedBefore))
+	}
+	if filter.ActiveOn != nil {
+		query += " AND (end_date IS NULL OR end_date >= ?)"
+		args = append(args, formatDate(*filter.ActiveOn))
+	}
+	//The dates are compared as text, the day is bound once per use
+	day := "MAX(subscription.start_date, ?)"
+	if filter.Paused != nil {
+		condition := `EXISTS (
+			SELECT 1 FROM subscription_pause
+			WHERE subscription_id = subscription.id AND start_date <= ` + day + ` AND (end_date IS NULL OR end_date >= ` + day + `)
+		)`
+		if !*filter.Paused {
+			condition = "NOT " + condition
+		}
+		query += " AND " + condition
+		args = append(args, formatDate(filter.StatusDay), formatDate(filter.StatusDay))
+	}
+	if filter.Trialing != nil {
+		condition := "(trial_months > 0 AND " + day + " <= " + trialEndDate + ")"
+		if !*filter.Trialing {
+			condition = "NOT " + condition
+		}
+		query += " AND " + condition
+		args = append(args, formatDate(filter.StatusDay))
+	}
+	if filter.CategoryIds != nil {
+		query += categoryCondition(len(filter.CategoryIds))
+		args = appendCategoryIds(args, filter.CategoryIds)
+	}
+	args, condition = tagCondition(args, filter.Tags, filter.ExcludedTags)
+	query += condition
+
+	return query, args
+}
+
+// trialMonth is the first day of the month the trial of the subscription ends in:
+// trial_months months after the month its first billing cycle starts in
+const trialMonth = `date(start_date, 'start of month', printf('%+d months', trial_months -
+	CASE WHEN MIN(billing_day, CAST(strftime('%d', start_date, 'start of month', '+1 month', '-1 day') AS INTEGER)) > CAST(strftime('%d', start_date) AS INTEGER)
+	THEN 1 ELSE 0 END))`
+
+// trialEndDate is the last day of the trial, the day before the billing day of trialMonth clamped to the month length
+const trialEndDate = `date(` + trialMonth + `, printf('%+d days', MIN(billing_day, CAST(strftime('%d', ` + trialMonth + `, '+1 month', '-1 day') AS INTEGER)) - 2))`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row scanner) (*models.Subscription, error) {
+	var (
+		subscription models.Subscription
+		userId       string
+		startDate    string
+		endDate      sql.NullString
+		cancelledAt  sql.NullString
+	)
+	err := row.Scan(
+		&subscription.Id,
+		&subscription.ServiceName,
+		&subscription.Price,
+		&userId,
+		&startDate,
+		&endDate,
+		&subscription.BillingDay,
+		&subscription.TrialMonths,
+		&subscription.TrialPrice,
+		&cancelledAt,
+		&subscription.CancelReason,
+		&subscription.ServiceId,
+		&subscription.PlanId,
+		&subscription.CategoryId,
+		&subscription.Notes,
+		&subscription.OrganizationId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.UserId, err = uuid.Parse(userId)
+	if err != nil {
+		return nil, err
+	}
+	subscription.StartDate, err = time.Parse(dateLayout, startDate)
+	if err != nil {
+		return nil, err
+	}
+	if endDate.Valid {
+		subscription.EndDate.Time, err = time.Parse(dateLayout, endDate.String)
+		if err != nil {
+			return nil, err
+		}
+		subscription.EndDate.Valid = true
+	}
+	if cancelledAt.Valid {
+		subscription.CancelledAt.Time, err = time.Parse(dateLayout, cancelledAt.String)
+		if err != nil {
+			return nil, err
+		}
+		subscription.CancelledAt.Valid = true
+	}
+
+	return &subscription, nil
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]*models.Subscription, error) {
+	var subscriptions []*models.Subscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// checkPage rejects negative values like Postgres does, SQLite treats them as "no limit"
+func checkPage(offset, limit int) error {
+	if offset < 0 {
+		return fmt.Errorf("OFFSET must not be negative")
+	}
+	if limit < 0 {
+		return fmt.Errorf("LIMIT must not be negative")
+	}
+	return nil
+}
+
+// isCheckViolation reports whether err is raised by the named check constraint,
+// SQLite gives the constraint name only in the message
+func isCheckViolation(err error, constraint string) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) &&
+		sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_CHECK &&
+		strings.Contains(sqliteErr.Error(), constraint)
+}
+
+func formatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+func formatNullDate(t sql.NullTime) sql.NullString {
+	if !t.Valid {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatDate(t.Time), Valid: true}
+}
+
+// categoryCondition matches the subscriptions in n categories directly or by their service,
+// the IDs are passed twice by appendCategoryIds
+func categoryCondition(n int) string {
+	if n == 0 {
+		return " AND FALSE"
+	}
+	list := "(?" + strings.Repeat(", ?", n-1) + ")"
+	return " AND (category_id IN " + list + " OR (category_id IS NULL AND service_id IN (SELECT id FROM service WHERE category_id IN " + list + ")))"
+}
+
+func appendCategoryIds(args []any, ids []int) []any {
+	if len(ids) == 0 {
+		return args
+	}
+	for range 2 {
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	return args
+}
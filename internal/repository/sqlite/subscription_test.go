@@ -0,0 +1,95 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Estriper0/subscription_service/internal/config"
+	"github.com/Estriper0/subscription_service/internal/migrator"
+	"github.com/Estriper0/subscription_service/internal/repository/repotest"
+	sqliteRepo "github.com/Estriper0/subscription_service/internal/repository/sqlite"
+	"github.com/Estriper0/subscription_service/pkg/sqlite"
+)
+
+func TestSubscriptionRepo(t *testing.T) {
+	repotest.SubscriptionRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestCatalogRepo(t *testing.T) {
+	repotest.CatalogRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestNameRepo(t *testing.T) {
+	repotest.NameRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestCategoryRepo(t *testing.T) {
+	repotest.CategoryRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestTagRepo(t *testing.T) {
+	repotest.TagRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestBudgetRepo(t *testing.T) {
+	repotest.BudgetRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestInsightRepo(t *testing.T) {
+	repotest.InsightRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestSplitRepo(t *testing.T) {
+	repotest.SplitRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestOrganizationRepo(t *testing.T) {
+	repotest.OrganizationRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+func TestUserRepo(t *testing.T) {
+	repotest.UserRepo(t, func(t *testing.T) repotest.Repo {
+		return newRepo(t)
+	})
+}
+
+// newRepo returns a repository on a new migrated database
+func newRepo(t *testing.T) *sqliteRepo.SubscriptionRepo {
+	path := filepath.Join(t.TempDir(), "subscriptions.db")
+
+	m, err := migrator.New(config.StorageSQLite, path, nil)
+	if err != nil {
+		t.Fatalf("migrator.New: %v", err)
+	}
+	err = m.Up()
+	m.Close()
+	if err != nil {
+		t.Fatalf("migrator.Up: %v", err)
+	}
+
+	db, err := sqlite.New(path)
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return sqliteRepo.NewSubscriptionRepo(db)
+}
@@ -0,0 +1,287 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+func (r *SubscriptionRepo) CreateTag(ctx context.Context, t *models.TagCreate) (int, error) {
+	query := `
+		INSERT INTO tag (user_id, name, organization_id)
+			VALUES (?, ?, ?)
+		RETURNING id
+	`
+	var id int
+
+	//The tag belongs to the tenant it is created in
+	err := r.db.QueryRowContext(ctx, query, t.UserId.String(), t.Name, organizationId(ctx)).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, repository.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("sqlite:SubscriptionRepo.CreateTag:QueryRow - %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *SubscriptionRepo) GetTag(ctx context.Context, id int) (*models.Tag, error) {
+	args, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	query := `
+		SELECT id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+			FROM tag
+		WHERE id = ?` + condition
+
+	tag, err := scanTag(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetTag:QueryRow - %s", err.Error())
+	}
+
+	return tag, nil
+}
+
+func (r *SubscriptionRepo) GetTags(ctx context.Context, userId uuid.UUID) ([]*models.Tag, error) {
+	args, condition := tenantCondition(ctx, []any{userId.String()}, "organization_id")
+	query := `
+		SELECT id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+			FROM tag
+		WHERE user_id = ?` + condition + `
+		ORDER BY lower(name)
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetTags:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var tags []*models.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetTags:Scan - %s", err.Error())
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetTags:Scan - %s", err.Error())
+	}
+
+	return tags, nil
+}
+
+func (r *SubscriptionRepo) UpdateTag(ctx context.Context, t *models.TagUpdate) (*models.Tag, error) {
+	args, condition := tenantCondition(ctx, []any{t.Name, t.Id}, "organization_id")
+	query := `
+		UPDATE tag
+		SET
+			name = ?
+		WHERE
+			id = ?` + condition + `
+		RETURNING id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+	`
+
+	tag, err := scanTag(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateTag:QueryRow - %s", err.Error())
+	}
+
+	return tag, nil
+}
+
+func (r *SubscriptionRepo) DeleteTag(ctx context.Context, id int) (*models.Tag, error) {
+	selectArgs, condition := tenantCondition(ctx, []any{id}, "organization_id")
+	selectQuery := `
+		SELECT id, user_id, name, (SELECT count(*) FROM subscription_tag WHERE tag_id = tag.id), organization_id
+			FROM tag
+		WHERE id = ?` + condition
+	deleteQuery := `
+		DELETE FROM tag
+			WHERE id = ?
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteTag:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	//The count is taken before the tag is removed from the subscriptions
+	tag, err := scanTag(tx.QueryRowContext(ctx, selectQuery, selectArgs...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteTag:QueryRow - %s", err.Error())
+	}
+	_, err = tx.ExecContext(ctx, deleteQuery, id)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteTag:Exec - %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteTag:Commit - %s", err.Error())
+	}
+
+	return tag, nil
+}
+
+func (r *SubscriptionRepo) SetSubscriptionTags(ctx context.Context, subscriptionId int, names []string) error {
+	userArgs, condition := tenantCondition(ctx, []any{subscriptionId}, "organization_id")
+	userQuery := `
+		SELECT user_id
+			FROM subscription
+		WHERE id = ?` + condition
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSubscriptionTags:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	var userId string
+	err = tx.QueryRowContext(ctx, userQuery, userArgs...).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSubscriptionTags:QueryRow - %s", err.Error())
+	}
+
+	err = setTags(ctx, tx, subscriptionId, userId, names)
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSubscriptionTags:setTags - %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sqlite:SubscriptionRepo.SetSubscriptionTags:Commit - %s", err.Error())
+	}
+
+	return nil
+}
+
+// setTags replaces the tags of the user's subscription in the transaction,
+// creating the missing ones in the organization of the subscription
+func setTags(ctx context.Context, tx *sql.Tx, subscriptionId int, userId string, names []string) error {
+	createQuery := `
+		INSERT INTO tag (user_id, name, organization_id)
+			SELECT ?, ?, organization_id
+				FROM subscription
+			WHERE id = ?
+		ON CONFLICT DO NOTHING
+	`
+	deleteQuery := `
+		DELETE FROM subscription_tag
+			WHERE subscription_id = ?
+	`
+	linkQuery := `
+		INSERT OR IGNORE INTO subscription_tag (subscription_id, tag_id)
+			SELECT ?, id
+				FROM tag
+			WHERE user_id = ?
+				AND organization_id = (SELECT organization_id FROM subscription WHERE id = ?)
+				AND lower(name) = lower(?)
+	`
+	_, err := tx.ExecContext(ctx, deleteQuery, subscriptionId)
+	if err != nil {
+		return fmt.Errorf("Exec - %s", err.Error())
+	}
+	for _, name := range names {
+		_, err = tx.ExecContext(ctx, createQuery, userId, name, subscriptionId)
+		if err != nil {
+			return fmt.Errorf("Exec - %s", err.Error())
+		}
+		_, err = tx.ExecContext(ctx, linkQuery, subscriptionId, userId, subscriptionId, name)
+		if err != nil {
+			return fmt.Errorf("Exec - %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetSubscriptionTags(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionTag, error) {
+	if len(subscriptionIds) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, len(subscriptionIds))
+	for _, id := range subscriptionIds {
+		args = append(args, id)
+	}
+	args, condition := tenantSubscriptionCondition(ctx, args, "st.subscription_id")
+	query := `
+		SELECT st.subscription_id, t.id, t.name
+			FROM subscription_tag st
+			JOIN tag t ON t.id = st.tag_id
+		WHERE st.subscription_id IN (?` + strings.Repeat(", ?", len(subscriptionIds)-1) + `)` + condition + `
+		ORDER BY st.subscription_id, lower(t.name)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetSubscriptionTags:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var tags []*models.SubscriptionTag
+	for rows.Next() {
+		var tag models.SubscriptionTag
+		err := rows.Scan(&tag.SubscriptionId, &tag.TagId, &tag.Name)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetSubscriptionTags:Scan - %s", err.Error())
+		}
+		tags = append(tags, &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetSubscriptionTags:Scan - %s", err.Error())
+	}
+
+	return tags, nil
+}
+
+// tagCondition appends the tags to the query arguments and returns the condition
+// matching the subscriptions with all of the tags and none of the excluded ones
+func tagCondition(args []any, tags, excluded []string) ([]any, string) {
+	var condition string
+	for _, tag := range tags {
+		args = append(args, tag)
+		condition += " AND id IN (SELECT st.subscription_id FROM subscription_tag st JOIN tag t ON t.id = st.tag_id WHERE lower(t.name) = lower(?))"
+	}
+	if len(excluded) > 0 {
+		for _, tag := range excluded {
+			args = append(args, tag)
+		}
+		condition += " AND id NOT IN (SELECT st.subscription_id FROM subscription_tag st JOIN tag t ON t.id = st.tag_id WHERE lower(t.name) IN (lower(?)" + strings.Repeat(", lower(?)", len(excluded)-1) + "))"
+	}
+	return args, condition
+}
+
+func scanTag(row scanner) (*models.Tag, error) {
+	var tag models.Tag
+	err := row.Scan(
+		&tag.Id,
+		&tag.UserId,
+		&tag.Name,
+		&tag.Subscriptions,
+		&tag.OrganizationId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
@@ -0,0 +1,262 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/google/uuid"
+)
+
+// personalDataQueries delete the rows of the user besides the subscriptions, every ? is the user ID.
+// The subscriptions lose the deleted categories and tags, the splits left without members stop sharing.
+var personalDataQueries = []string{
+	`DELETE FROM subscription_split
+		WHERE EXISTS (SELECT 1 FROM subscription_member m WHERE m.subscription_id = subscription_split.subscription_id AND m.user_id = ?)
+			AND NOT EXISTS (SELECT 1 FROM subscription_member m WHERE m.subscription_id = subscription_split.subscription_id AND m.user_id <> ?)`,
+	`DELETE FROM subscription_member WHERE user_id = ?`,
+	`DELETE FROM organization_member WHERE user_id = ?`,
+	`DELETE FROM budget WHERE user_id = ?`,
+	`DELETE FROM tag WHERE user_id = ?`,
+	`DELETE FROM category WHERE user_id = ?`,
+	`DELETE FROM insight_dismissal WHERE user_id = ?`,
+}
+
+func (r *SubscriptionRepo) CreateUser(ctx context.Context, u *models.UserCreate) error {
+	query := `
+		INSERT INTO users (id, display_name, email, locale, currency, timezone, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, u.Id.String(), u.DisplayName, u.Email, u.Locale, u.Currency, u.Timezone, u.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repository.ErrAlreadyExists
+		}
+		return fmt.Errorf("sqlite:SubscriptionRepo.CreateUser:Exec - %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepo) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `
+		SELECT id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+			FROM users
+		WHERE id = ?
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetUser:QueryRow - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func (r *SubscriptionRepo) GetUsers(ctx context.Context, offset, limit int) ([]*models.User, error) {
+	query := `
+		SELECT id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+			FROM users
+		ORDER BY id
+			LIMIT ?
+			OFFSET ?
+	`
+	err := checkPage(offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetUsers - %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetUsers:Query - %s", err.Error())
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetUsers:Scan - %s", err.Error())
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.GetUsers:Scan - %s", err.Error())
+	}
+
+	return users, nil
+}
+
+func (r *SubscriptionRepo) UpdateUser(ctx context.Context, u *models.UserUpdate) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET
+			display_name = COALESCE(?, display_name),
+			email = CASE WHEN ? THEN NULL ELSE COALESCE(?, email) END,
+			locale = COALESCE(?, locale),
+			currency = COALESCE(?, currency),
+			timezone = COALESCE(?, timezone)
+		WHERE
+			id = ? AND anonymized_at IS NULL
+		RETURNING id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, u.DisplayName, u.ClearEmail, u.Email, u.Locale, u.Currency, u.Timezone, u.Id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.UpdateUser:QueryRow - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func (r *SubscriptionRepo) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	subscriptionsQuery := `
+		DELETE FROM subscription
+			WHERE user_id = ?
+	`
+	userQuery := `
+		DELETE FROM users
+			WHERE id = ?
+		RETURNING id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteUser:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	//The pauses, the tags and the splits are deleted with the subscriptions
+	_, err = tx.ExecContext(ctx, subscriptionsQuery, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteUser:Exec - %s", err.Error())
+	}
+	err = deletePersonalData(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteUser:deletePersonalData - %s", err.Error())
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx, userQuery, id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteUser:QueryRow - %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.DeleteUser:Commit - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func (r *SubscriptionRepo) AnonymizeUser(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error) {
+	userQuery := `
+		UPDATE users
+		SET
+			display_name = '',
+			email = NULL,
+			anonymized_at = ?
+		WHERE
+			id = ? AND anonymized_at IS NULL
+		RETURNING id, display_name, email, locale, currency, timezone, created_at, anonymized_at
+	`
+	subscriptionsQuery := `
+		UPDATE subscription
+		SET
+			notes = NULL,
+			cancel_reason = NULL
+		WHERE user_id = ?
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.AnonymizeUser:BeginTx - %s", err.Error())
+	}
+	defer tx.Rollback()
+
+	user, err := scanUser(tx.QueryRowContext(ctx, userQuery, at.UTC().Format(time.RFC3339Nano), id.String()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.AnonymizeUser:QueryRow - %s", err.Error())
+	}
+
+	//The free text of the subscriptions may hold personal data
+	_, err = tx.ExecContext(ctx, subscriptionsQuery, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.AnonymizeUser:Exec - %s", err.Error())
+	}
+	err = deletePersonalData(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.AnonymizeUser:deletePersonalData - %s", err.Error())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:SubscriptionRepo.AnonymizeUser:Commit - %s", err.Error())
+	}
+
+	return user, nil
+}
+
+func deletePersonalData(ctx context.Context, tx *sql.Tx, userId uuid.UUID) error {
+	for _, query := range personalDataQueries {
+		args := make([]any, strings.Count(query, "?"))
+		for i := range args {
+			args[i] = userId.String()
+		}
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanUser(row scanner) (*models.User, error) {
+	var (
+		user         models.User
+		id           string
+		createdAt    string
+		anonymizedAt sql.NullString
+	)
+	err := row.Scan(&id, &user.DisplayName, &user.Email, &user.Locale, &user.Currency, &user.Timezone, &createdAt, &anonymizedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Id, err = uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	user.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if anonymizedAt.Valid {
+		user.AnonymizedAt.Time, err = time.Parse(time.RFC3339Nano, anonymizedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		user.AnonymizedAt.Valid = true
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+)
+
+// Similarity returns the trigram similarity of the strings the way similarity() of pg_trgm does:
+// the words are lowercased and padded with two spaces in front and one after,
+// the result is the number of common trigrams divided by the number of distinct ones
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	var common int
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// SimilarNames returns the pairs of distinct names with the similarity of at least threshold
+// ordered by the first and the second name
+func SimilarNames(names []string, threshold float64) []*models.NamePair {
+	unique := slices.Clone(names)
+	slices.Sort(unique)
+	unique = slices.Compact(unique)
+
+	var pairs []*models.NamePair
+	for i, a := range unique {
+		for _, b := range unique[i+1:] {
+			if similarity := Similarity(a, b); similarity >= threshold {
+				pairs = append(pairs, &models.NamePair{A: a, B: b, Similarity: similarity})
+			}
+		}
+	}
+	return pairs
+}
+
+func trigrams(s string) map[string]struct{} {
+	res := make(map[string]struct{})
+	//Words are runs of letters and digits like in pg_trgm
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			res[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return res
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Estriper0/subscription_service/internal/config"
 )
@@ -12,11 +13,12 @@ type Server struct {
 	httpServer *http.Server
 	config     *config.Config
 	err        chan error
+	beforeStop []func()
 }
 
-func New(handler http.Handler, config *config.Config) *Server {
+func New(handler http.Handler, port int, config *config.Config) *Server {
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", config.Server.Port),
+		Addr:         fmt.Sprintf(":%d", port),
 		Handler:      handler,
 		ReadTimeout:  config.Server.ReadTimeout,
 		WriteTimeout: config.Server.WriteTimeout,
@@ -29,6 +31,11 @@ func New(handler http.Handler, config *config.Config) *Server {
 	}
 }
 
+// Handler returns the handler serving the requests, e.g. for httptest
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
 func (s *Server) Err() <-chan error {
 	return s.err
 }
@@ -38,7 +45,19 @@ func (s *Server) Run() {
 	close(s.err)
 }
 
+// BeforeStop registers f to be called when Stop starts, before connections are drained
+func (s *Server) BeforeStop(f func()) {
+	s.beforeStop = append(s.beforeStop, f)
+}
+
 func (s *Server) Stop() error {
+	for _, f := range s.beforeStop {
+		f()
+	}
+
+	//Giving load balancers time to notice that the application is not ready
+	time.Sleep(s.config.Server.ShutdownDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
 	defer cancel()
 
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+)
+
+// Billing rules:
+//   - a subscription is active from its start date to its end date, both days included;
+//     an open-ended subscription is active until the end of the requested period
+//   - it is billed in monthly cycles starting on its billing day (clamped to the month length),
+//     the first charge is made on the start date for the cycle containing it
+//   - with monthly proration every charge made within the period costs the full price,
+//     so a subscription that starts and ends in the same cycle costs one cycle
+//   - with daily proration every cycle is billed for the share of its days the subscription is active in the period,
+//     the cost of a subscription is rounded to a whole number once
+//   - every cycle is charged the price in effect on its charge date, the scheduled price changes included;
+//     the first trial months cycles, starting with the one of the start date, are charged the trial price
+//   - paused days are not billed: with monthly proration a charge falling on a paused day is skipped,
+//     with daily proration paused days are not counted
+//
+// With the billing day 1 the cycles are calendar months.
+
+// bill returns the cost of the subscription with the pauses and the price changes in the period from..to (both days included).
+// The price of the item is the one of its last charge, without charges the one in effect at the end of the period.
+func bill(s *models.Subscription, pauses []*models.Pause, changes []*models.PriceChange, from, to time.Time, proration domain.Proration) domain.PriceItem {
+	item := domain.PriceItem{
+		SubscriptionId: s.Id,
+		ServiceName:    s.ServiceName,
+		Price:          priceOn(s, changes, to),
+	}
+	trialEnd := trialEndDate(s)
+	if trialEnd != nil {
+		item.TrialPrice = s.TrialPrice
+	}
+
+	first := s.StartDate
+	if from.After(first) {
+		first = from
+	}
+	last := to
+	if s.EndDate.Valid && s.EndDate.Time.Before(last) {
+		last = s.EndDate.Time
+	}
+	if first.After(last) {
+		return item
+	}
+	item.From = formatDate(first)
+	item.To = formatDate(last)
+	item.PausedDays = pausedDays(pauses, first, last)
+
+	var (
+		cost float64
+		//A regular charge has been billed at item.Price
+		billed bool
+	)
+	for start := cycleStart(first, s.BillingDay); !start.After(last); {
+		next := nextCycle(start, s.BillingDay)
+		charged := start
+		if s.StartDate.After(charged) {
+			charged = s.StartDate
+		}
+		regular := priceOn(s, changes, charged)
+		price := regular
+		trial := trialEnd != nil && !charged.After(*trialEnd)
+		if trial {
+			price = s.TrialPrice
+		}
+
+		if proration == domain.ProrationDaily {
+			activeFrom, activeTo := start, next.AddDate(0, 0, -1)
+			if first.After(activeFrom) {
+				activeFrom = first
+			}
+			if last.Before(activeTo) {
+				activeTo = last
+			}
+			days := daysBetween(activeFrom, activeTo) + 1 - pausedDays(pauses, activeFrom, activeTo)
+			if days <= 0 {
+				start = next
+				continue
+			}
+			item.Days += days
+			cost += float64(price) * float64(days) / float64(daysBetween(start, next))
+		} else if !charged.Before(first) && !paused(pauses, charged) {
+			cost += float64(price)
+		} else {
+			start = next
+			continue
+		}
+
+		item.Months++
+		if trial {
+			item.TrialMonths++
+		} else {
+			item.PriceChanged = item.PriceChanged || billed && item.Price != regular
+			billed = true
+		}
+		item.Price = regular
+		start = next
+	}
+	item.Cost = int(math.Round(cost))
+
+	return item
+}
+
+// trialEndDate returns the last day of the trial, nil if the subscription has no trial
+func trialEndDate(s *models.Subscription) *time.Time {
+	if s.TrialMonths <= 0 {
+		return nil
+	}
+
+	next := cycleStart(s.StartDate, s.BillingDay)
+	for range s.TrialMonths {
+		next = nextCycle(next, s.BillingDay)
+	}
+	end := next.AddDate(0, 0, -1)
+	return &end
+}
+
+// nextBillingDate returns the first charge of the subscription on or after today skipping paused days,
+// nil if there are none
+func nextBillingDate(s *models.Subscription, pauses []*models.Pause, today time.Time) *time.Time {
+	next := s.StartDate
+	if next.Before(today) {
+		next = cycleStart(today, s.BillingDay)
+		if next.Before(today) {
+			next = nextCycle(next, s.BillingDay)
+		}
+	}
+
+	for {
+		if s.EndDate.Valid && next.After(s.EndDate.Time) {
+			return nil
+		}
+		pause := pauseAt(pauses, next)
+		if pause == nil {
+			return &next
+		}
+		//Paused until resumed
+		if !pause.EndDate.Valid {
+			return nil
+		}
+		next = nextCycle(cycleStart(pause.EndDate.Time, s.BillingDay), s.BillingDay)
+	}
+}
+
+// pauseAt returns the pause the day falls into, nil if it is not paused
+func pauseAt(pauses []*models.Pause, day time.Time) *models.Pause {
+	for _, p := range pauses {
+		if !day.Before(p.StartDate) && (!p.EndDate.Valid || !day.After(p.EndDate.Time)) {
+			return p
+		}
+	}
+	return nil
+}
+
+func paused(pauses []*models.Pause, day time.Time) bool {
+	return pauseAt(pauses, day) != nil
+}
+
+// pausedDays returns the number of paused days from..to, both included; pauses do not overlap
+func pausedDays(pauses []*models.Pause, from, to time.Time) int {
+	var days int
+	for _, p := range pauses {
+		start, end := p.StartDate, to
+		if p.EndDate.Valid && p.EndDate.Time.Before(end) {
+			end = p.EndDate.Time
+		}
+		if from.After(start) {
+			start = from
+		}
+		if !start.After(end) {
+			days += daysBetween(start, end) + 1
+		}
+	}
+	return days
+}
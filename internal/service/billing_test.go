@@ -0,0 +1,739 @@
+package service
+
+import (
+	"database/sql"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+)
+
+func TestBill(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	end := func(t time.Time) sql.NullTime {
+		return sql.NullTime{Time: t, Valid: true}
+	}
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		s         models.Subscription
+		proration domain.Proration
+		want      domain.PriceItem
+	}{
+		{
+			"open-ended until the period end",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2026, 1, 1)},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-01-01", To: "2026-06-30", Months: 6, Cost: 600},
+		},
+		{
+			"same month is one month",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2026, 3, 1), EndDate: end(day(2026, 3, 31))},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-03-01", To: "2026-03-31", Months: 1, Cost: 100},
+		},
+		{
+			"crossing the billing day charges twice",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2026, 3, 31), EndDate: end(day(2026, 4, 1))},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-03-31", To: "2026-04-01", Months: 2, Cost: 200},
+		},
+		{
+			"clipped by the period start",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2025, 11, 1), EndDate: end(day(2026, 2, 28))},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-01-01", To: "2026-02-28", Months: 2, Cost: 200},
+		},
+		{
+			"outside the period",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2025, 1, 1), EndDate: end(day(2025, 12, 31))},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100},
+		},
+		{
+			"daily whole months",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2026, 1, 1)},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 100, From: "2026-01-01", To: "2026-06-30", Months: 6, Days: 181, Cost: 600},
+		},
+		{
+			"daily partial months",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 310, BillingDay: 1, StartDate: day(2026, 1, 17), EndDate: end(day(2026, 3, 14))},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 310, From: "2026-01-17", To: "2026-03-14", Months: 3, Days: 15 + 28 + 14, Cost: 150 + 310 + 140},
+		},
+		{
+			"daily leap february",
+			day(2024, 1, 1), day(2024, 12, 31),
+			models.Subscription{Price: 290, BillingDay: 1, StartDate: day(2024, 2, 15), EndDate: end(day(2024, 2, 29))},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 290, From: "2024-02-15", To: "2024-02-29", Months: 1, Days: 15, Cost: 150},
+		},
+		{
+			"billing day in the middle of the month",
+			day(2026, 1, 1), day(2026, 3, 31),
+			models.Subscription{Price: 100, BillingDay: 17, StartDate: day(2026, 1, 17)},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-01-17", To: "2026-03-31", Months: 3, Cost: 300},
+		},
+		{
+			"no charge before the billing day",
+			day(2026, 1, 1), day(2026, 3, 16),
+			models.Subscription{Price: 100, BillingDay: 17, StartDate: day(2026, 1, 17)},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-01-17", To: "2026-03-16", Months: 2, Cost: 200},
+		},
+		{
+			"billing day clamped to short months",
+			day(2026, 2, 1), day(2026, 4, 29),
+			models.Subscription{Price: 100, BillingDay: 31, StartDate: day(2026, 1, 31)},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-02-01", To: "2026-04-29", Months: 2, Cost: 200},
+		},
+		{
+			"start date charged besides the billing day",
+			day(2026, 1, 1), day(2026, 2, 28),
+			models.Subscription{Price: 100, BillingDay: 5, StartDate: day(2026, 1, 20)},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-01-20", To: "2026-02-28", Months: 2, Cost: 200},
+		},
+		{
+			"daily whole cycles",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 17, StartDate: day(2026, 1, 17), EndDate: end(day(2026, 3, 16))},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 100, From: "2026-01-17", To: "2026-03-16", Months: 2, Days: 31 + 28, Cost: 200},
+		},
+		{
+			"daily cycles clipped by the period",
+			day(2026, 2, 1), day(2026, 2, 28),
+			models.Subscription{Price: 310, BillingDay: 17, StartDate: day(2026, 1, 17)},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 310, From: "2026-02-01", To: "2026-02-28", Months: 2, Days: 16 + 12, Cost: 293},
+		},
+		{
+			"free trial",
+			day(2026, 1, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2026, 1, 1), TrialMonths: 2},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-01-01", To: "2026-06-30", Months: 6, TrialMonths: 2, Cost: 400},
+		},
+		{
+			"promo price clipped by the period start",
+			day(2026, 2, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2026, 1, 1), TrialMonths: 2, TrialPrice: 50},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-02-01", To: "2026-06-30", Months: 5, TrialMonths: 1, TrialPrice: 50, Cost: 50 + 400},
+		},
+		{
+			"trial over before the period",
+			day(2026, 6, 1), day(2026, 6, 30),
+			models.Subscription{Price: 100, BillingDay: 1, StartDate: day(2026, 1, 1), TrialMonths: 2, TrialPrice: 50},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 100, From: "2026-06-01", To: "2026-06-30", Months: 1, TrialPrice: 50, Cost: 100},
+		},
+		{
+			"daily free trial cycle",
+			day(2026, 2, 1), day(2026, 2, 28),
+			models.Subscription{Price: 310, BillingDay: 17, StartDate: day(2026, 1, 17), TrialMonths: 1},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 310, From: "2026-02-01", To: "2026-02-28", Months: 2, Days: 16 + 12, TrialMonths: 1, Cost: 133},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bill(&tt.s, nil, nil, tt.from, tt.to, tt.proration)
+			if got != tt.want {
+				t.Fatalf("bill = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBillingDate(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	end := func(t time.Time) sql.NullTime {
+		return sql.NullTime{Time: t, Valid: true}
+	}
+
+	tests := []struct {
+		name  string
+		s     models.Subscription
+		today time.Time
+		want  string
+	}{
+		{
+			"not started yet",
+			models.Subscription{BillingDay: 17, StartDate: day(2026, 3, 17)},
+			day(2026, 2, 1),
+			"2026-03-17",
+		},
+		{
+			"later this month",
+			models.Subscription{BillingDay: 17, StartDate: day(2026, 1, 17)},
+			day(2026, 2, 10),
+			"2026-02-17",
+		},
+		{
+			"today",
+			models.Subscription{BillingDay: 17, StartDate: day(2026, 1, 17)},
+			day(2026, 2, 17),
+			"2026-02-17",
+		},
+		{
+			"next month",
+			models.Subscription{BillingDay: 17, StartDate: day(2026, 1, 17)},
+			day(2026, 2, 18),
+			"2026-03-17",
+		},
+		{
+			"clamped to the month length",
+			models.Subscription{BillingDay: 31, StartDate: day(2026, 1, 31)},
+			day(2026, 2, 1),
+			"2026-02-28",
+		},
+		{
+			"ends before the next charge",
+			models.Subscription{BillingDay: 17, StartDate: day(2026, 1, 17), EndDate: end(day(2026, 2, 10))},
+			day(2026, 2, 1),
+			"",
+		},
+		{
+			"ended",
+			models.Subscription{BillingDay: 1, StartDate: day(2025, 1, 1), EndDate: end(day(2025, 12, 31))},
+			day(2026, 2, 1),
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if next := nextBillingDate(&tt.s, nil, tt.today); next != nil {
+				got = formatDate(*next)
+			}
+			if got != tt.want {
+				t.Fatalf("nextBillingDate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrialEndDate(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		s    models.Subscription
+		want string
+	}{
+		{"no trial", models.Subscription{BillingDay: 17, StartDate: day(2026, 1, 17)}, ""},
+		{"one month", models.Subscription{BillingDay: 17, StartDate: day(2026, 1, 17), TrialMonths: 1}, "2026-02-16"},
+		{"three months", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1), TrialMonths: 3}, "2026-03-31"},
+		{"clamped billing day", models.Subscription{BillingDay: 31, StartDate: day(2026, 1, 31), TrialMonths: 1}, "2026-02-27"},
+		{"started between billing days", models.Subscription{BillingDay: 5, StartDate: day(2026, 1, 20), TrialMonths: 1}, "2026-02-04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if end := trialEndDate(&tt.s); end != nil {
+				got = formatDate(*end)
+			}
+			if got != tt.want {
+				t.Fatalf("trialEndDate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBillPaused(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	pause := func(start time.Time, end ...time.Time) *models.Pause {
+		p := &models.Pause{StartDate: start}
+		if len(end) > 0 {
+			p.EndDate = sql.NullTime{Time: end[0], Valid: true}
+		}
+		return p
+	}
+
+	s := models.Subscription{Price: 310, BillingDay: 1, StartDate: day(2026, 1, 1)}
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		pauses    []*models.Pause
+		proration domain.Proration
+		want      domain.PriceItem
+	}{
+		{
+			"paused charges are skipped",
+			day(2026, 1, 1), day(2026, 6, 30),
+			[]*models.Pause{pause(day(2026, 3, 1), day(2026, 4, 30))},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 310, From: "2026-01-01", To: "2026-06-30", Months: 4, PausedDays: 61, Cost: 4 * 310},
+		},
+		{
+			"a pause between charges",
+			day(2026, 1, 1), day(2026, 6, 30),
+			[]*models.Pause{pause(day(2026, 3, 5), day(2026, 3, 20))},
+			domain.ProrationMonthly,
+			domain.PriceItem{Price: 310, From: "2026-01-01", To: "2026-06-30", Months: 6, PausedDays: 16, Cost: 6 * 310},
+		},
+		{
+			"daily paused days",
+			day(2026, 1, 1), day(2026, 1, 31),
+			[]*models.Pause{pause(day(2026, 1, 11), day(2026, 1, 20))},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 310, From: "2026-01-01", To: "2026-01-31", Months: 1, Days: 21, PausedDays: 10, Cost: 210},
+		},
+		{
+			"daily paused until resumed",
+			day(2026, 1, 1), day(2026, 2, 28),
+			[]*models.Pause{pause(day(2026, 2, 1))},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 310, From: "2026-01-01", To: "2026-02-28", Months: 1, Days: 31, PausedDays: 28, Cost: 310},
+		},
+		{
+			"a pause outside the period",
+			day(2026, 1, 1), day(2026, 1, 31),
+			[]*models.Pause{pause(day(2026, 3, 1), day(2026, 3, 31))},
+			domain.ProrationDaily,
+			domain.PriceItem{Price: 310, From: "2026-01-01", To: "2026-01-31", Months: 1, Days: 31, Cost: 310},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bill(&s, tt.pauses, nil, tt.from, tt.to, tt.proration)
+			if got != tt.want {
+				t.Fatalf("bill = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBillingDatePaused(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	s := models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1)}
+	tests := []struct {
+		name  string
+		pause models.Pause
+		want  string
+	}{
+		{"charges after the pause", models.Pause{StartDate: day(2026, 2, 1), EndDate: sql.NullTime{Time: day(2026, 3, 15), Valid: true}}, "2026-04-01"},
+		{"a pause between charges", models.Pause{StartDate: day(2026, 2, 5), EndDate: sql.NullTime{Time: day(2026, 2, 20), Valid: true}}, "2026-03-01"},
+		{"paused until resumed", models.Pause{StartDate: day(2026, 2, 1)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if next := nextBillingDate(&s, []*models.Pause{&tt.pause}, day(2026, 2, 10)); next != nil {
+				got = formatDate(*next)
+			}
+			if got != tt.want {
+				t.Fatalf("nextBillingDate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForecast(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	subs := []*models.Subscription{
+		{Id: 1, ServiceName: "Netflix", Price: 100, BillingDay: 1, StartDate: day(2026, 1, 1)},
+		//A free trial until November 24, then ends with November
+		{Id: 2, ServiceName: "Spotify", Price: 200, BillingDay: 25, StartDate: day(2026, 9, 25), EndDate: sql.NullTime{Time: day(2026, 11, 30), Valid: true}, TrialMonths: 2},
+		//Paused until resumed from November
+		{Id: 3, ServiceName: "Gym", Price: 50, BillingDay: 1, StartDate: day(2026, 1, 1)},
+	}
+	pauses := map[int][]*models.Pause{3: {{SubscriptionId: 3, StartDate: day(2026, 11, 1)}}}
+
+	got := forecast(subs, pauses, nil, nil, uuid.Nil, day(2026, 10, 19), 3)
+	want := []struct {
+		month string
+		total int
+		ids   []int
+	}{
+		//The charges of October 1 have been made
+		{"2026-10-01", 0, []int{2}},
+		{"2026-11-01", 300, []int{1, 2}},
+		{"2026-12-01", 100, []int{1}},
+	}
+	if got.Total != 400 || len(got.Months) != len(want) {
+		t.Fatalf("forecast total = %d over %d months, want 400 over %d", got.Total, len(got.Months), len(want))
+	}
+	for i, w := range want {
+		month := got.Months[i]
+		var ids []int
+		for _, item := range month.Items {
+			ids = append(ids, item.SubscriptionId)
+		}
+		if month.Month != w.month || month.Total != w.total || !slices.Equal(ids, w.ids) {
+			t.Errorf("month %d = %s %d %v, want %s %d %v", i, month.Month, month.Total, ids, w.month, w.total, w.ids)
+		}
+	}
+	if item := got.Months[0].Items[0]; item.TrialMonths != 1 || item.Cost != 0 {
+		t.Errorf("October charge of the trial = %+v, want a free trial month", item)
+	}
+}
+
+func TestForecastPriceChange(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	subs := []*models.Subscription{
+		{Id: 1, ServiceName: "Netflix", Price: 100, BillingDay: 15, StartDate: day(2026, 1, 15)},
+		//A free trial until November 14, the new price applies after it
+		{Id: 2, ServiceName: "Spotify", Price: 200, BillingDay: 15, StartDate: day(2026, 9, 15), TrialMonths: 2},
+	}
+	changes := map[int][]*models.PriceChange{
+		//Effective after the November charge, before the December one
+		1: {
+			{SubscriptionId: 1, EffectiveDate: day(2026, 11, 16), Price: 150},
+			{SubscriptionId: 1, EffectiveDate: day(2027, 1, 15), Price: 120},
+		},
+		2: {{SubscriptionId: 2, EffectiveDate: day(2026, 10, 20), Price: 250}},
+	}
+
+	got := forecast(subs, nil, changes, nil, uuid.Nil, day(2026, 10, 10), 4)
+	want := []int{100, 100 + 250, 150 + 250, 120 + 250}
+	if len(got.Months) != len(want) {
+		t.Fatalf("forecast over %d months, want %d", len(got.Months), len(want))
+	}
+	for i, w := range want {
+		if got.Months[i].Total != w {
+			t.Errorf("month %s total = %d, want %d", got.Months[i].Month, got.Months[i].Total, w)
+		}
+	}
+	if got.Total != 1220 {
+		t.Errorf("forecast total = %d, want 1220", got.Total)
+	}
+	if item := got.Months[1].Items[0]; item.Price != 100 {
+		t.Errorf("November charge = %+v, want the price before the change", item)
+	}
+}
+
+func TestBillPriceChange(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	sub := &models.Subscription{Id: 1, ServiceName: "Netflix", Price: 100, BillingDay: 15, StartDate: day(2026, 1, 15)}
+	//Effective after the November charge, the December one is billed at the new price
+	changes := []*models.PriceChange{{SubscriptionId: 1, EffectiveDate: day(2026, 11, 20), Price: 150}}
+
+	item := bill(sub, nil, changes, day(2026, 10, 1), day(2026, 12, 31), domain.ProrationMonthly)
+	if item.Cost != 100+100+150 || item.Price != 150 || !item.PriceChanged {
+		t.Fatalf("bill over the change = %+v, want 100 + 100 + 150 at the price of 150", item)
+	}
+	//Daily proration bills every cycle at the price of its charge
+	item = bill(sub, nil, changes, day(2026, 11, 15), day(2026, 12, 14), domain.ProrationDaily)
+	if item.Cost != 100 || item.Price != 100 || item.PriceChanged {
+		t.Fatalf("daily bill of the cycle started before the change = %+v, want 100", item)
+	}
+
+	//The price report and the forecast agree on every month
+	got := forecast([]*models.Subscription{sub}, nil, map[int][]*models.PriceChange{1: changes}, nil, uuid.Nil, day(2026, 10, 1), 3)
+	for i, month := range got.Months {
+		start := day(2026, time.October+time.Month(i), 1)
+		report := bill(sub, nil, changes, start, endOfMonth(start), domain.ProrationMonthly)
+		if month.Total != report.Cost {
+			t.Errorf("forecast of %s = %d, the price report %d", month.Month, month.Total, report.Cost)
+		}
+	}
+}
+
+func TestSplitCost(t *testing.T) {
+	owner, a, b := uuid.New(), uuid.New(), uuid.New()
+	sub := &models.Subscription{UserId: owner, Price: 300}
+	share := func(i int32) sql.NullInt32 {
+		return sql.NullInt32{Int32: i, Valid: true}
+	}
+
+	tests := []struct {
+		name  string
+		split *models.SubscriptionSplit
+		cost  int
+		want  map[uuid.UUID]int
+	}{
+		{"not shared", nil, 900, map[uuid.UUID]int{owner: 900}},
+		{
+			"equal with the owner, the owner pays the remainder",
+			&models.SubscriptionSplit{Rule: "equal", Members: []models.SubscriptionMember{{UserId: a}, {UserId: b}}},
+			1000,
+			map[uuid.UUID]int{owner: 334, a: 333, b: 333},
+		},
+		{
+			"percentage of the cost",
+			&models.SubscriptionSplit{Rule: "percentage", Members: []models.SubscriptionMember{{UserId: a, Share: share(25)}, {UserId: b, Share: share(50)}}},
+			900,
+			map[uuid.UUID]int{owner: 225, a: 225, b: 450},
+		},
+		{
+			"fixed amount of the monthly price over three months",
+			&models.SubscriptionSplit{Rule: "fixed", Members: []models.SubscriptionMember{{UserId: a, Share: share(100)}}},
+			900,
+			map[uuid.UUID]int{owner: 600, a: 300},
+		},
+		{
+			"fixed amounts above the price are reduced",
+			&models.SubscriptionSplit{Rule: "fixed", Members: []models.SubscriptionMember{{UserId: a, Share: share(200)}, {UserId: b, Share: share(400)}}},
+			900,
+			map[uuid.UUID]int{owner: 0, a: 300, b: 600},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitCost(sub, tt.split, tt.cost); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitCost = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwed(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	a, b, c := uuid.MustParse("00000000-0000-4000-8000-00000000000a"), uuid.MustParse("00000000-0000-4000-8000-00000000000b"), uuid.MustParse("00000000-0000-4000-8000-00000000000c")
+
+	subs := []*models.Subscription{
+		//b and c owe a 100 each for March
+		{Id: 1, UserId: a, Price: 300, BillingDay: 1, StartDate: day(2026, 1, 1)},
+		//a owes b 40, netted against the 100 above
+		{Id: 2, UserId: b, Price: 80, BillingDay: 1, StartDate: day(2026, 1, 1)},
+		//Not shared
+		{Id: 3, UserId: c, Price: 500, BillingDay: 1, StartDate: day(2026, 1, 1)},
+	}
+	splits := map[int]*models.SubscriptionSplit{
+		1: {SubscriptionId: 1, Rule: "equal", Members: []models.SubscriptionMember{{UserId: b}, {UserId: c}}},
+		2: {SubscriptionId: 2, Rule: "percentage", Members: []models.SubscriptionMember{{UserId: a, Share: sql.NullInt32{Int32: 50, Valid: true}}}},
+	}
+
+	got := owed(subs, nil, nil, splits, day(2026, 3, 1), day(2026, 3, 31))
+	want := []domain.Debt{{From: b, To: a, Amount: 60}, {From: c, To: a, Amount: 100}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("owed = %+v, want %+v", got, want)
+	}
+}
+
+func TestUserCosts(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	a, b := uuid.MustParse("00000000-0000-4000-8000-00000000000a"), uuid.MustParse("00000000-0000-4000-8000-00000000000b")
+
+	subs := []*models.Subscription{
+		//Shared equally, 300 a month for two months
+		{Id: 1, UserId: a, Price: 300, BillingDay: 1, StartDate: day(2026, 1, 1)},
+		//Paused for the whole of March
+		{Id: 2, UserId: b, Price: 100, BillingDay: 1, StartDate: day(2026, 1, 1)},
+	}
+	pauses := map[int][]*models.Pause{
+		2: {{SubscriptionId: 2, StartDate: day(2026, 3, 1), EndDate: sql.NullTime{Time: day(2026, 3, 31), Valid: true}}},
+	}
+	splits := map[int]*models.SubscriptionSplit{
+		1: {SubscriptionId: 1, Rule: "equal", Members: []models.SubscriptionMember{{UserId: b}}},
+	}
+
+	total, users := userCosts(subs, pauses, nil, splits, day(2026, 3, 1), day(2026, 4, 30))
+	want := []domain.UserCost{{UserId: a, Cost: 300}, {UserId: b, Cost: 400}}
+	if total != 700 || !reflect.DeepEqual(users, want) {
+		t.Fatalf("userCosts = %d, %+v, want 700, %+v", total, users, want)
+	}
+}
+
+func TestInsights(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	end := func(t time.Time) sql.NullTime {
+		return sql.NullTime{Time: t, Valid: true}
+	}
+	service := sql.NullInt32{Int32: 5, Valid: true}
+
+	subs := []*models.Subscription{
+		//Linked to the same catalog service under different names, overlapping in March
+		{Id: 1, ServiceName: "Netflix", Price: 500, StartDate: day(2026, 1, 1), EndDate: end(day(2026, 3, 31)), ServiceId: service},
+		{Id: 2, ServiceName: "Netflix via App Store", Price: 700, StartDate: day(2026, 3, 1), ServiceId: service},
+		//Spelled differently, one after another with a price jump
+		{Id: 3, ServiceName: "Spotify", Price: 200, StartDate: day(2023, 1, 1), EndDate: end(day(2025, 12, 31))},
+		{Id: 4, ServiceName: "spotify ", Price: 300, StartDate: day(2026, 1, 1), EndDate: end(day(2026, 12, 31))},
+		//Open-ended for 24 and 23 months
+		{Id: 5, ServiceName: "Gym", Price: 1000, StartDate: day(2024, 10, 19)},
+		{Id: 6, ServiceName: "Cloud", Price: 100, StartDate: day(2024, 10, 20)},
+	}
+
+	got := insights(subs, nil, day(2026, 10, 19))
+	want := []domain.Insight{
+		{Id: "duplicate-1-2", Kind: domain.InsightDuplicate, ServiceName: "Netflix", SubscriptionIds: []int{1, 2}, From: "2026-03-01", To: "2026-03-31"},
+		{Id: "price_jump-1-2", Kind: domain.InsightPriceJump, ServiceName: "Netflix", SubscriptionIds: []int{1, 2}, OldPrice: 500, NewPrice: 700, Increase: 40},
+		{Id: "price_jump-3-4", Kind: domain.InsightPriceJump, ServiceName: "Spotify", SubscriptionIds: []int{3, 4}, OldPrice: 200, NewPrice: 300, Increase: 50},
+		{Id: "long_open_ended-5", Kind: domain.InsightLongOpenEnded, ServiceName: "Gym", SubscriptionIds: []int{5}, Months: 24},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d insights, want %d", len(got), len(want))
+	}
+	for i, insight := range got {
+		if !reflect.DeepEqual(*insight, want[i]) {
+			t.Errorf("insight %d = %+v, want %+v", i, *insight, want[i])
+		}
+	}
+}
+
+func TestInsightsPriceChange(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	end := func(t time.Time) sql.NullTime {
+		return sql.NullTime{Time: t, Valid: true}
+	}
+
+	subs := []*models.Subscription{
+		{Id: 1, ServiceName: "Spotify", Price: 200, StartDate: day(2025, 1, 1), EndDate: end(day(2025, 12, 31))},
+		{Id: 2, ServiceName: "Spotify", Price: 300, StartDate: day(2026, 1, 1)},
+		{Id: 3, ServiceName: "Netflix", Price: 500, StartDate: day(2025, 1, 1), EndDate: end(day(2025, 12, 31))},
+		{Id: 4, ServiceName: "Netflix", Price: 550, StartDate: day(2026, 1, 1)},
+	}
+	changes := map[int][]*models.PriceChange{
+		//The old subscription already cost 250 when it was replaced, no jump
+		1: {{SubscriptionId: 1, EffectiveDate: day(2025, 6, 1), Price: 250}},
+		//The new one got more expensive from its first charge
+		4: {{SubscriptionId: 4, EffectiveDate: day(2026, 1, 1), Price: 700}},
+	}
+
+	got := insights(subs, changes, day(2026, 10, 19))
+	if len(got) != 1 || got[0].Id != "price_jump-3-4" || got[0].OldPrice != 500 || got[0].NewPrice != 700 {
+		t.Fatalf("insights = %+v, want only the price jump from 500 to 700", got)
+	}
+}
+
+func TestSubscriptionStatus(t *testing.T) {
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	end := func(t time.Time) sql.NullTime {
+		return sql.NullTime{Time: t, Valid: true}
+	}
+
+	today := day(2026, 3, 10)
+	tests := []struct {
+		name   string
+		s      models.Subscription
+		pauses []*models.Pause
+		want   domain.Status
+	}{
+		{"active", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1)}, nil, domain.StatusActive},
+		{"ends today", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1), EndDate: end(today)}, nil, domain.StatusActive},
+		{"expired", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1), EndDate: end(day(2026, 3, 9))}, nil, domain.StatusExpired},
+		{"trialing", models.Subscription{BillingDay: 1, StartDate: day(2026, 2, 1), TrialMonths: 2}, nil, domain.StatusTrialing},
+		{"trial ended", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1), TrialMonths: 2}, nil, domain.StatusActive},
+		{"paused", models.Subscription{BillingDay: 1, StartDate: day(2026, 2, 1), TrialMonths: 2}, []*models.Pause{{StartDate: day(2026, 3, 1)}}, domain.StatusPaused},
+		{"pause ended", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1)}, []*models.Pause{{StartDate: day(2026, 2, 1), EndDate: end(day(2026, 3, 9))}}, domain.StatusActive},
+		{"cancelled at period end", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1), EndDate: end(day(2026, 3, 31)), CancelledAt: end(day(2026, 3, 5))}, nil, domain.StatusCancelled},
+		{"cancelled and ended", models.Subscription{BillingDay: 1, StartDate: day(2026, 1, 1), EndDate: end(day(2026, 3, 5)), CancelledAt: end(day(2026, 3, 5))}, nil, domain.StatusCancelled},
+		{"starts with a trial", models.Subscription{BillingDay: 1, StartDate: day(2026, 6, 1), TrialMonths: 1}, nil, domain.StatusTrialing},
+		{"starts paused", models.Subscription{BillingDay: 1, StartDate: day(2026, 6, 1)}, []*models.Pause{{StartDate: day(2026, 6, 1)}}, domain.StatusPaused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subscriptionStatus(&tt.s, tt.pauses, today); got != tt.want {
+				t.Fatalf("subscriptionStatus = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthlyPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		plan models.Plan
+		want int
+	}{
+		{"monthly", models.Plan{Price: 599, BillingPeriod: "month"}, 599},
+		{"yearly", models.Plan{Price: 2400, BillingPeriod: "year"}, 200},
+		{"yearly rounded down", models.Plan{Price: 1000, BillingPeriod: "year"}, 83},
+		{"yearly rounded up", models.Plan{Price: 1030, BillingPeriod: "year"}, 86},
+		{"free", models.Plan{Price: 0, BillingPeriod: "year"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := monthlyPrice(&tt.plan); got != tt.want {
+				t.Fatalf("monthlyPrice = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Netflix", "netflix"},
+		{"  NETFLIX  ", "netflix"},
+		{"YouTube\t Premium", "youtube premium"},
+		{"Déezer", "deezer"},
+		{"Crème  Brûlée", "creme brulee"},
+		{"Яндекс Плюс", "яндекс плюс"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeName(tt.name); got != tt.want {
+			t.Errorf("normalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSubcategories(t *testing.T) {
+	parent := func(id int32) sql.NullInt32 {
+		return sql.NullInt32{Int32: id, Valid: true}
+	}
+	categories := []*models.Category{
+		{Id: 1, Name: "Streaming"},
+		{Id: 2, Name: "Software"},
+		{Id: 3, Name: "Video", ParentId: parent(1)},
+		{Id: 4, Name: "Music", ParentId: parent(1)},
+		{Id: 5, Name: "Podcasts", ParentId: parent(4)},
+		{Id: 6, Name: "Audiobooks", ParentId: parent(5)},
+	}
+
+	tests := []struct {
+		id   int
+		want []int
+	}{
+		{1, []int{1, 3, 4, 5, 6}},
+		{4, []int{4, 5, 6}},
+		{2, []int{2}},
+		{6, []int{6}},
+	}
+	for _, tt := range tests {
+		if got := subcategories(categories, tt.id); !slices.Equal(got, tt.want) {
+			t.Errorf("subcategories(%d) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Percents of the budget that raise an alert, ascending
+var budgetThresholds = []int{80, 100}
+
+type IBudgetMetrics interface {
+	IMetrics
+	IncBudgetAlert(threshold int)
+}
+
+// IPriceCalculator counts the spending of a budget
+type IPriceCalculator interface {
+	GetPriceByFilter(ctx context.Context, filter *domain.PriceFilter) (*domain.PriceReport, error)
+}
+
+// BudgetService manages the users' budgets and raises the alerts when the spending of the month reaches a threshold
+type BudgetService struct {
+	budgetRepo   IBudgetRepo
+	catalogRepo  ICatalogRepo
+	categoryRepo ICategoryRepo
+	prices       IPriceCalculator
+	metrics      IBudgetMetrics
+	tracer       trace.Tracer
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+func NewBudgetService(budgetRepo IBudgetRepo, catalogRepo ICatalogRepo, categoryRepo ICategoryRepo, prices IPriceCalculator, metrics IBudgetMetrics, logger *slog.Logger) *BudgetService {
+	return &BudgetService{
+		budgetRepo:   budgetRepo,
+		catalogRepo:  catalogRepo,
+		categoryRepo: categoryRepo,
+		prices:       prices,
+		metrics:      metrics,
+		tracer:       otel.Tracer(tracerName),
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *BudgetService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *BudgetService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+func (s *BudgetService) CreateBudget(ctx context.Context, data *domain.BudgetCreate) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.CreateBudget")
+	defer span.End()
+
+	if err := s.checkScope(ctx, "CreateBudget", data); err != nil {
+		return 0, err
+	}
+
+	id, err := s.budgetRepo.CreateBudget(ctx, &models.BudgetCreate{
+		UserId:     data.UserId,
+		Amount:     data.Amount,
+		CategoryId: nullInt(data.CategoryId),
+		ServiceId:  nullInt(data.ServiceId),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return 0, s.fail(ctx, "CreateBudget", ErrBudgetExists)
+		} else if errors.Is(err, repository.ErrNotFound) {
+			return 0, s.fail(ctx, "CreateBudget", ErrUnknownCategory)
+		}
+		s.log(ctx).Error("BudgetService.CreateBudget:budgetRepo.CreateBudget - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CreateBudget", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("The budget id=%d has been created", id))
+	s.CheckBudgets(ctx, data.UserId)
+	return id, nil
+}
+
+func (s *BudgetService) GetBudget(ctx context.Context, id int) (*domain.Budget, error) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.GetBudget")
+	defer span.End()
+
+	model, err := s.budgetRepo.GetBudget(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetBudget", ErrNotFound)
+		}
+		s.log(ctx).Error("BudgetService.GetBudget:budgetRepo.GetBudget - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetBudget", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Budget id=%d received successfully", id))
+
+	return toBudgetDomain(model), nil
+}
+
+func (s *BudgetService) GetBudgets(ctx context.Context, userId uuid.UUID) ([]*domain.Budget, error) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.GetBudgets")
+	defer span.End()
+
+	list, err := s.getBudgets(ctx, "GetBudgets", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	budgets := make([]*domain.Budget, 0, len(list))
+	for _, m := range list {
+		budgets = append(budgets, toBudgetDomain(m))
+	}
+	s.log(ctx).Info(fmt.Sprintf("%d budgets of the user userId=%s were received successfully", len(budgets), userId.String()))
+
+	return budgets, nil
+}
+
+// UpdateBudget changes the amount, a lower amount may raise the alerts at once
+func (s *BudgetService) UpdateBudget(ctx context.Context, data *domain.BudgetUpdate) (*domain.Budget, error) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.UpdateBudget")
+	defer span.End()
+
+	model, err := s.budgetRepo.UpdateBudget(ctx, &models.BudgetUpdate{Id: data.Id, Amount: data.Amount})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "UpdateBudget", ErrNotFound)
+		}
+		s.log(ctx).Error("BudgetService.UpdateBudget:budgetRepo.UpdateBudget - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "UpdateBudget", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Budget id=%d update successfully", data.Id))
+	s.CheckBudgets(ctx, model.UserId)
+
+	return toBudgetDomain(model), nil
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, id int) (*domain.Budget, error) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.DeleteBudget")
+	defer span.End()
+
+	model, err := s.budgetRepo.DeleteBudget(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "DeleteBudget", ErrNotFound)
+		}
+		s.log(ctx).Error("BudgetService.DeleteBudget:budgetRepo.DeleteBudget - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteBudget", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Budget id=%d deleted successfully", id))
+
+	return toBudgetDomain(model), nil
+}
+
+// GetBudgetStatus returns the spending of the month against every budget of the user, the current month without one.
+// The month is validated by the handler.
+func (s *BudgetService) GetBudgetStatus(ctx context.Context, userId uuid.UUID, month *string) ([]*domain.BudgetStatus, error) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.GetBudgetStatus")
+	defer span.End()
+
+	start := startOfMonth(s.now().UTC())
+	if month != nil {
+		start, _ = parseDate(*month, false)
+	}
+	statuses, err := s.statuses(ctx, "GetBudgetStatus", userId, start)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info(fmt.Sprintf("Status of %d budgets of the user userId=%s for %s", len(statuses), userId.String(), formatDate(start)))
+
+	return statuses, nil
+}
+
+func (s *BudgetService) GetBudgetAlerts(ctx context.Context, userId uuid.UUID) ([]*domain.BudgetAlert, error) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.GetBudgetAlerts")
+	defer span.End()
+
+	list, err := s.budgetRepo.GetBudgetAlerts(ctx, userId)
+	if err != nil {
+		s.log(ctx).Error("BudgetService.GetBudgetAlerts:budgetRepo.GetBudgetAlerts - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetBudgetAlerts", ErrInternal)
+	}
+
+	alerts := make([]*domain.BudgetAlert, 0, len(list))
+	for _, m := range list {
+		alerts = append(alerts, toBudgetAlertDomain(m))
+	}
+	s.log(ctx).Info(fmt.Sprintf("%d budget alerts of the user userId=%s were received successfully", len(alerts), userId.String()))
+
+	return alerts, nil
+}
+
+// CheckBudgets raises the alerts of the thresholds the user's budgets have reached this month.
+// Every alert is raised once per month, the errors are only logged since the change that triggered the check has been made.
+func (s *BudgetService) CheckBudgets(ctx context.Context, userId uuid.UUID) {
+	ctx, span := s.tracer.Start(ctx, "BudgetService.CheckBudgets")
+	defer span.End()
+
+	now := s.now().UTC()
+	month := startOfMonth(now)
+	statuses, err := s.statuses(ctx, "CheckBudgets", userId, month)
+	if err != nil {
+		return
+	}
+
+	for _, status := range statuses {
+		for _, threshold := range budgetThresholds {
+			if !reached(status.Spent, status.Budget.Amount, threshold) {
+				break
+			}
+			alert, err := s.budgetRepo.CreateBudgetAlert(ctx, &models.BudgetAlertCreate{
+				BudgetId:  status.Budget.Id,
+				Month:     month,
+				Threshold: threshold,
+				Spent:     status.Spent,
+				Amount:    status.Budget.Amount,
+				AlertedAt: now,
+			})
+			if errors.Is(err, repository.ErrAlreadyExists) || errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				s.log(ctx).Error("BudgetService.CheckBudgets:budgetRepo.CreateBudgetAlert - Internal error", slog.String("error", err.Error()))
+				s.fail(ctx, "CheckBudgets", ErrInternal)
+				return
+			}
+			s.metrics.IncBudgetAlert(threshold)
+			s.log(ctx).Warn(
+				fmt.Sprintf("The budget id=%d of the user userId=%s reached %d%%", alert.BudgetId, userId.String(), threshold),
+				slog.Int("spent", alert.Spent),
+				slog.Int("amount", alert.Amount),
+				slog.String("month", formatDate(month)),
+			)
+		}
+	}
+}
+
+// statuses counts the spending of the month for every budget of the user
+func (s *BudgetService) statuses(ctx context.Context, method string, userId uuid.UUID, month time.Time) ([]*domain.BudgetStatus, error) {
+	budgets, err := s.getBudgets(ctx, method, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*domain.BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		report, err := s.prices.GetPriceByFilter(ctx, &domain.PriceFilter{
+			UserId:     &userId,
+			ServiceId:  intPtr(b.ServiceId),
+			CategoryId: intPtr(b.CategoryId),
+			StartDate:  formatDate(month),
+			EndDate:    formatDate(endOfMonth(month)),
+			Proration:  domain.ProrationMonthly,
+		})
+		if err != nil {
+			//Already recorded by the subscription service
+			return nil, err
+		}
+
+		status := &domain.BudgetStatus{
+			Budget:  *toBudgetDomain(b),
+			Month:   formatDate(month),
+			Spent:   report.Total,
+			Percent: report.Total * 100 / b.Amount,
+			Level:   domain.BudgetOk,
+		}
+		if reached(status.Spent, b.Amount, budgetThresholds[len(budgetThresholds)-1]) {
+			status.Level = domain.BudgetExceeded
+		} else if reached(status.Spent, b.Amount, budgetThresholds[0]) {
+			status.Level = domain.BudgetWarning
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (s *BudgetService) getBudgets(ctx context.Context, method string, userId uuid.UUID) ([]*models.Budget, error) {
+	budgets, err := s.budgetRepo.GetBudgets(ctx, userId)
+	if err != nil {
+		s.log(ctx).Error("BudgetService."+method+":budgetRepo.GetBudgets - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	return budgets, nil
+}
+
+// checkScope fails unless the category is built-in or the user's and the service is in the catalog
+func (s *BudgetService) checkScope(ctx context.Context, method string, data *domain.BudgetCreate) error {
+	if data.CategoryId != nil {
+		category, err := s.categoryRepo.GetCategory(ctx, *data.CategoryId)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			s.log(ctx).Error("BudgetService."+method+":categoryRepo.GetCategory - Internal error", slog.String("error", err.Error()))
+			return s.fail(ctx, method, ErrInternal)
+		}
+		if err != nil || !availableTo(category, data.UserId) {
+			return s.fail(ctx, method, ErrUnknownCategory)
+		}
+	}
+	if data.ServiceId != nil {
+		_, err := s.catalogRepo.GetService(ctx, *data.ServiceId)
+		if errors.Is(err, repository.ErrNotFound) {
+			return s.fail(ctx, method, ErrUnknownService)
+		}
+		if err != nil {
+			s.log(ctx).Error("BudgetService."+method+":catalogRepo.GetService - Internal error", slog.String("error", err.Error()))
+			return s.fail(ctx, method, ErrInternal)
+		}
+	}
+	return nil
+}
+
+// reached reports whether the spending is at least threshold percent of the amount
+func reached(spent, amount, threshold int) bool {
+	return spent*100 >= amount*threshold
+}
+
+func toBudgetDomain(m *models.Budget) *domain.Budget {
+	return &domain.Budget{
+		Id:         m.Id,
+		UserId:     m.UserId,
+		Amount:     m.Amount,
+		CategoryId: intPtr(m.CategoryId),
+		ServiceId:  intPtr(m.ServiceId),
+	}
+}
+
+func toBudgetAlertDomain(m *models.BudgetAlert) *domain.BudgetAlert {
+	return &domain.BudgetAlert{
+		Id:        m.Id,
+		BudgetId:  m.BudgetId,
+		Month:     formatDate(m.Month),
+		Threshold: m.Threshold,
+		Spent:     m.Spent,
+		Amount:    m.Amount,
+		AlertedAt: m.AlertedAt,
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+)
+
+func (s *SubscriptionService) Cancel(ctx context.Context, data *domain.Cancel) (*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.Cancel")
+	defer span.End()
+
+	model, pauses, err := s.getWithPauses(ctx, "Cancel", data.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	today := s.today()
+	if model.EndDate.Valid && model.EndDate.Time.Before(today) {
+		return nil, s.fail(ctx, "Cancel", ErrAlreadyEnded)
+	}
+
+	//The date is validated by the handler
+	endDate := today
+	if data.AtPeriodEnd {
+		day := today
+		if model.StartDate.After(day) {
+			day = model.StartDate
+		}
+		endDate = nextCycle(cycleStart(day, model.BillingDay), model.BillingDay).AddDate(0, 0, -1)
+	} else if data.Date != nil {
+		endDate, _ = parseDate(*data.Date, true)
+	}
+	if endDate.Before(model.StartDate) {
+		return nil, s.fail(ctx, "Cancel", ErrIncorrectTime)
+	}
+	//Cancelling never extends the subscription
+	if model.EndDate.Valid && model.EndDate.Time.Before(endDate) {
+		endDate = model.EndDate.Time
+	}
+	for _, p := range pauses {
+		if !pauseInPeriod(p.StartDate, p.EndDate, model.StartDate, sql.NullTime{Time: endDate, Valid: true}) {
+			return nil, s.fail(ctx, "Cancel", ErrPauseOutsidePeriod)
+		}
+	}
+
+	cancel := &models.SubscriptionCancel{Id: data.SubscriptionId, EndDate: endDate, CancelledAt: today}
+	if data.Reason != nil {
+		cancel.Reason = sql.NullString{String: *data.Reason, Valid: true}
+	}
+	split, err := s.getSplit(ctx, "Cancel", data.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := s.subscriptionRepo.Cancel(ctx, cancel)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "Cancel", ErrNotFound)
+		}
+		if errors.Is(err, repository.ErrIncorrectTime) {
+			return nil, s.fail(ctx, "Cancel", ErrIncorrectTime)
+		}
+		s.log(ctx).Error("SubscriptionService.Cancel:subscriptionRepo.Cancel - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "Cancel", ErrInternal)
+	}
+	s.changed(ctx, splitUsers(cancelled.UserId, split)...)
+
+	s.log(ctx).Info(fmt.Sprintf("Subscription id=%d cancelled, it ends on %s", data.SubscriptionId, formatDate(endDate)))
+
+	subscription := s.toDomain(cancelled, pauses)
+	if err := s.loadTags(ctx, "Cancel", []*domain.Subscription{subscription}); err != nil {
+		return nil, err
+	}
+	if err := s.loadSplits(ctx, "Cancel", []*domain.Subscription{subscription}); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// getFiltered returns a page of the subscriptions matching the filter ordered by ID.
+// The status is mapped to the state it is computed from, see subscriptionStatus.
+func (s *SubscriptionService) getFiltered(ctx context.Context, method string, userId *uuid.UUID, listFilter domain.ListFilter, offset, limit int) ([]*domain.Subscription, error) {
+	today := s.today()
+	filter := &models.StateFilter{UserId: userId, Tags: listFilter.Tags, ExcludedTags: listFilter.ExcludedTags, StatusDay: today}
+	yes, no := true, false
+	switch listFilter.Status {
+	case "":
+	case domain.StatusCancelled:
+		filter.Cancelled = &yes
+	case domain.StatusExpired:
+		filter.Cancelled, filter.EndedBefore = &no, &today
+	case domain.StatusPaused:
+		filter.Cancelled, filter.ActiveOn, filter.Paused = &no, &today, &yes
+	case domain.StatusTrialing:
+		filter.Cancelled, filter.ActiveOn, filter.Paused, filter.Trialing = &no, &today, &no, &yes
+	default:
+		filter.Cancelled, filter.ActiveOn, filter.Paused, filter.Trialing = &no, &today, &no, &no
+	}
+	if listFilter.CategoryId != nil {
+		ids, err := s.categoryIds(ctx, method, *listFilter.CategoryId)
+		if err != nil {
+			return nil, err
+		}
+		filter.CategoryIds = ids
+	}
+	subs, err := s.subscriptionRepo.GetPageByState(ctx, filter, offset, limit)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":subscriptionRepo.GetPageByState - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	return s.withDetails(ctx, method, subs)
+}
+
+// subscriptionStatus returns the status of the subscription today, or on its start date if it has not started yet.
+// A cancelled subscription stays cancelled while it is active until its end date.
+func subscriptionStatus(m *models.Subscription, pauses []*models.Pause, today time.Time) domain.Status {
+	if m.CancelledAt.Valid {
+		return domain.StatusCancelled
+	}
+	day := today
+	if m.StartDate.After(day) {
+		day = m.StartDate
+	}
+	if m.EndDate.Valid && m.EndDate.Time.Before(day) {
+		return domain.StatusExpired
+	}
+	if paused(pauses, day) {
+		return domain.StatusPaused
+	}
+	if trialEnd := trialEndDate(m); trialEnd != nil && !day.After(*trialEnd) {
+		return domain.StatusTrialing
+	}
+	return domain.StatusActive
+}
@@ -0,0 +1,518 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CatalogService manages the canonical services and their plans
+type CatalogService struct {
+	catalogRepo  ICatalogRepo
+	categoryRepo ICategoryRepo
+	metrics      IMetrics
+	tracer       trace.Tracer
+	logger       *slog.Logger
+}
+
+func NewCatalogService(catalogRepo ICatalogRepo, categoryRepo ICategoryRepo, metrics IMetrics, logger *slog.Logger) *CatalogService {
+	return &CatalogService{
+		catalogRepo:  catalogRepo,
+		categoryRepo: categoryRepo,
+		metrics:      metrics,
+		tracer:       otel.Tracer(tracerName),
+		logger:       logger,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *CatalogService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *CatalogService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+// CreateService adds the service to the catalog and links the subscriptions with its name or aliases
+func (s *CatalogService) CreateService(ctx context.Context, data *domain.ServiceCreate) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.CreateService")
+	defer span.End()
+
+	names := append([]string{data.Name}, data.Aliases...)
+	if err := s.checkNames(ctx, "CreateService", 0, names); err != nil {
+		return 0, err
+	}
+	if err := s.checkCategory(ctx, "CreateService", data.CategoryId); err != nil {
+		return 0, err
+	}
+
+	id, err := s.catalogRepo.CreateService(ctx, &models.ServiceCreate{
+		Name:       data.Name,
+		Aliases:    data.Aliases,
+		CategoryId: nullInt(data.CategoryId),
+		Website:    nullString(data.Website),
+		LogoUrl:    nullString(data.LogoUrl),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return 0, s.fail(ctx, "CreateService", ErrAlreadyExists)
+		}
+		s.log(ctx).Error("CatalogService.CreateService:catalogRepo.CreateService - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CreateService", ErrInternal)
+	}
+
+	linked, err := s.catalogRepo.LinkSubscriptions(ctx, id, names)
+	if err != nil {
+		s.log(ctx).Error("CatalogService.CreateService:catalogRepo.LinkSubscriptions - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CreateService", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("The service id=%d has been created, %d subscriptions linked", id, linked))
+	return id, nil
+}
+
+func (s *CatalogService) GetService(ctx context.Context, id int) (*domain.Service, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.GetService")
+	defer span.End()
+
+	model, err := s.catalogRepo.GetService(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetService", ErrNotFound)
+		}
+		s.log(ctx).Error("CatalogService.GetService:catalogRepo.GetService - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetService", ErrInternal)
+	}
+
+	services, err := s.withPlans(ctx, "GetService", []*models.Service{model})
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info(fmt.Sprintf("Service id=%d received successfully", id))
+
+	return services[0], nil
+}
+
+func (s *CatalogService) GetServices(ctx context.Context, offset, limit int) ([]*domain.Service, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.GetServices")
+	defer span.End()
+
+	models, err := s.catalogRepo.GetServices(ctx, offset, limit)
+	if err != nil {
+		s.log(ctx).Error("CatalogService.GetServices:catalogRepo.GetServices - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetServices", ErrInternal)
+	}
+
+	services, err := s.withPlans(ctx, "GetServices", models)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info("All services were received successfully", slog.Int("offset", offset), slog.Int("limit", limit))
+
+	return services, nil
+}
+
+// UpdateService changes the service and links the subscriptions with its new name or aliases
+func (s *CatalogService) UpdateService(ctx context.Context, data *domain.ServiceUpdate) (*domain.Service, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.UpdateService")
+	defer span.End()
+
+	m := &models.ServiceUpdate{
+		Id:         data.Id,
+		Aliases:    data.Aliases,
+		CategoryId: nullInt(data.CategoryId),
+		Website:    nullString(data.Website),
+		LogoUrl:    nullString(data.LogoUrl),
+	}
+	names := data.Aliases
+	if data.Name != nil {
+		m.Name = sql.NullString{String: *data.Name, Valid: true}
+		names = append([]string{*data.Name}, names...)
+	}
+	if err := s.checkNames(ctx, "UpdateService", data.Id, names); err != nil {
+		return nil, err
+	}
+	if err := s.checkCategory(ctx, "UpdateService", data.CategoryId); err != nil {
+		return nil, err
+	}
+
+	model, err := s.catalogRepo.UpdateService(ctx, m)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "UpdateService", ErrNotFound)
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "UpdateService", ErrAlreadyExists)
+		}
+		s.log(ctx).Error("CatalogService.UpdateService:catalogRepo.UpdateService - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "UpdateService", ErrInternal)
+	}
+
+	if len(names) > 0 {
+		_, err = s.catalogRepo.LinkSubscriptions(ctx, data.Id, names)
+		if err != nil {
+			s.log(ctx).Error("CatalogService.UpdateService:catalogRepo.LinkSubscriptions - Internal error", slog.String("error", err.Error()))
+			return nil, s.fail(ctx, "UpdateService", ErrInternal)
+		}
+	}
+
+	services, err := s.withPlans(ctx, "UpdateService", []*models.Service{model})
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info(fmt.Sprintf("Service id=%d update successfully", data.Id))
+
+	return services[0], nil
+}
+
+// DeleteService removes the service with its plans, the subscriptions are unlinked but kept
+func (s *CatalogService) DeleteService(ctx context.Context, id int) (*domain.Service, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.DeleteService")
+	defer span.End()
+
+	//The plans are deleted with the service
+	plans, err := s.catalogRepo.GetPlans(ctx, []int{id})
+	if err != nil {
+		s.log(ctx).Error("CatalogService.DeleteService:catalogRepo.GetPlans - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteService", ErrInternal)
+	}
+
+	model, err := s.catalogRepo.DeleteService(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "DeleteService", ErrNotFound)
+		}
+		s.log(ctx).Error("CatalogService.DeleteService:catalogRepo.DeleteService - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteService", ErrInternal)
+	}
+
+	service := toServiceDomain(model, plans)
+	s.log(ctx).Info(fmt.Sprintf("Service id=%d deleted successfully", id))
+
+	return service, nil
+}
+
+func (s *CatalogService) CreatePlan(ctx context.Context, data *domain.PlanCreate) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.CreatePlan")
+	defer span.End()
+
+	id, err := s.catalogRepo.CreatePlan(ctx, &models.PlanCreate{
+		ServiceId:     data.ServiceId,
+		Name:          data.Name,
+		Price:         data.Price,
+		BillingPeriod: string(data.BillingPeriod),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, s.fail(ctx, "CreatePlan", ErrNotFound)
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return 0, s.fail(ctx, "CreatePlan", ErrAlreadyExists)
+		}
+		s.log(ctx).Error("CatalogService.CreatePlan:catalogRepo.CreatePlan - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CreatePlan", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("The plan id=%d of the service id=%d has been created", id, data.ServiceId))
+	return id, nil
+}
+
+func (s *CatalogService) GetPlan(ctx context.Context, id int) (*domain.Plan, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.GetPlan")
+	defer span.End()
+
+	model, err := s.catalogRepo.GetPlan(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetPlan", ErrNotFound)
+		}
+		s.log(ctx).Error("CatalogService.GetPlan:catalogRepo.GetPlan - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetPlan", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("Plan id=%d received successfully", id))
+	return toPlanDomain(model), nil
+}
+
+func (s *CatalogService) UpdatePlan(ctx context.Context, data *domain.PlanUpdate) (*domain.Plan, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.UpdatePlan")
+	defer span.End()
+
+	m := &models.PlanUpdate{Id: data.Id}
+	if data.Name != nil {
+		m.Name = sql.NullString{String: *data.Name, Valid: true}
+	}
+	if data.Price != nil {
+		m.Price = sql.NullInt32{Int32: int32(*data.Price), Valid: true}
+	}
+	if data.BillingPeriod != nil {
+		m.BillingPeriod = sql.NullString{String: string(*data.BillingPeriod), Valid: true}
+	}
+
+	model, err := s.catalogRepo.UpdatePlan(ctx, m)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "UpdatePlan", ErrNotFound)
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "UpdatePlan", ErrAlreadyExists)
+		}
+		s.log(ctx).Error("CatalogService.UpdatePlan:catalogRepo.UpdatePlan - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "UpdatePlan", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("Plan id=%d update successfully", data.Id))
+	return toPlanDomain(model), nil
+}
+
+// DeletePlan removes the plan, the subscriptions on it keep their price
+func (s *CatalogService) DeletePlan(ctx context.Context, id int) (*domain.Plan, error) {
+	ctx, span := s.tracer.Start(ctx, "CatalogService.DeletePlan")
+	defer span.End()
+
+	model, err := s.catalogRepo.DeletePlan(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "DeletePlan", ErrNotFound)
+		}
+		s.log(ctx).Error("CatalogService.DeletePlan:catalogRepo.DeletePlan - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeletePlan", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("Plan id=%d deleted successfully", id))
+	return toPlanDomain(model), nil
+}
+
+// checkNames fails if a name or alias is taken by a service other than id.
+// The unique indexes only compare names with names and aliases with aliases.
+func (s *CatalogService) checkNames(ctx context.Context, method string, id int, names []string) error {
+	for _, name := range names {
+		found, err := s.catalogRepo.FindService(ctx, name)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			s.log(ctx).Error("CatalogService."+method+":catalogRepo.FindService - Internal error", slog.String("error", err.Error()))
+			return s.fail(ctx, method, ErrInternal)
+		}
+		if found.Id != id {
+			return s.fail(ctx, method, ErrAlreadyExists)
+		}
+	}
+	return nil
+}
+
+// withPlans converts the services to the domain model loading their plans
+func (s *CatalogService) withPlans(ctx context.Context, method string, list []*models.Service) ([]*domain.Service, error) {
+	ids := make([]int, 0, len(list))
+	for _, m := range list {
+		ids = append(ids, m.Id)
+	}
+
+	plans, err := s.catalogRepo.GetPlans(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("CatalogService."+method+":catalogRepo.GetPlans - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	var services []*domain.Service
+	for _, m := range list {
+		var own []*models.Plan
+		for _, p := range plans {
+			if p.ServiceId == m.Id {
+				own = append(own, p)
+			}
+		}
+		services = append(services, toServiceDomain(m, own))
+	}
+	return services, nil
+}
+
+// checkCategory fails unless the category of a service is built-in, the services are shared by all users
+func (s *CatalogService) checkCategory(ctx context.Context, method string, id *int) error {
+	if id == nil {
+		return nil
+	}
+	category, err := s.categoryRepo.GetCategory(ctx, *id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return s.fail(ctx, method, ErrUnknownCategory)
+		}
+		s.log(ctx).Error("CatalogService."+method+":categoryRepo.GetCategory - Internal error", slog.String("error", err.Error()))
+		return s.fail(ctx, method, ErrInternal)
+	}
+	if category.UserId.Valid {
+		return s.fail(ctx, method, ErrUnknownCategory)
+	}
+	return nil
+}
+
+// monthlyPrice is the price of the plan per month, yearly prices are spread over 12 months
+func monthlyPrice(p *models.Plan) int {
+	if domain.BillingPeriod(p.BillingPeriod) == domain.BillingYear {
+		return (p.Price + 6) / 12
+	}
+	return p.Price
+}
+
+func toServiceDomain(m *models.Service, plans []*models.Plan) *domain.Service {
+	service := &domain.Service{
+		Id:         m.Id,
+		Name:       m.Name,
+		Aliases:    m.Aliases,
+		CategoryId: intPtr(m.CategoryId),
+		Website:    stringPtr(m.Website),
+		LogoUrl:    stringPtr(m.LogoUrl),
+	}
+	for _, p := range plans {
+		service.Plans = append(service.Plans, *toPlanDomain(p))
+	}
+	return service
+}
+
+func toPlanDomain(m *models.Plan) *domain.Plan {
+	return &domain.Plan{
+		Id:            m.Id,
+		ServiceId:     m.ServiceId,
+		Name:          m.Name,
+		Price:         m.Price,
+		BillingPeriod: domain.BillingPeriod(m.BillingPeriod),
+	}
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullInt(i *int) sql.NullInt32 {
+	if i == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*i), Valid: true}
+}
+
+func intPtr(i sql.NullInt32) *int {
+	if !i.Valid {
+		return nil
+	}
+	res := int(i.Int32)
+	return &res
+}
+
+// linkCatalog links the new subscription to the plan and its service, to the service with the ID
+// or to the one found by the subscription's name. It returns the plan if there is one.
+func (s *SubscriptionService) linkCatalog(ctx context.Context, method string, model *models.SubscriptionCreate, serviceId, planId *int) (*models.Plan, error) {
+	var plan *models.Plan
+	if planId != nil {
+		var err error
+		plan, err = s.getPlan(ctx, method, *planId)
+		if err != nil {
+			return nil, err
+		}
+		if serviceId != nil && *serviceId != plan.ServiceId {
+			return nil, s.fail(ctx, method, ErrPlanMismatch)
+		}
+		serviceId = &plan.ServiceId
+		model.PlanId = sql.NullInt32{Int32: int32(plan.Id), Valid: true}
+	}
+
+	var service *models.Service
+	if serviceId != nil {
+		var err error
+		service, err = s.getService(ctx, method, *serviceId)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		found, err := s.catalogRepo.FindService(ctx, model.ServiceName)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			s.log(ctx).Error("SubscriptionService."+method+":catalogRepo.FindService - Internal error", slog.String("error", err.Error()))
+			return nil, s.fail(ctx, method, ErrInternal)
+		}
+		service = found
+	}
+
+	if service != nil {
+		model.ServiceId = sql.NullInt32{Int32: int32(service.Id), Valid: true}
+		if model.ServiceName == "" {
+			model.ServiceName = service.Name
+		}
+	}
+	return plan, nil
+}
+
+// relinkCatalog checks the new service and plan of the subscription, the plan must belong to the service
+func (s *SubscriptionService) relinkCatalog(ctx context.Context, method string, m *models.SubscriptionUpdate, serviceId, planId *int) error {
+	if planId != nil {
+		plan, err := s.getPlan(ctx, method, *planId)
+		if err != nil {
+			return err
+		}
+		if serviceId != nil && *serviceId != plan.ServiceId {
+			return s.fail(ctx, method, ErrPlanMismatch)
+		}
+		serviceId = &plan.ServiceId
+		m.PlanId = sql.NullInt32{Int32: int32(plan.Id), Valid: true}
+	} else {
+		//The current plan must stay within the service
+		current, err := s.subscriptionRepo.GetById(ctx, m.Id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return s.fail(ctx, method, ErrNotFound)
+			}
+			s.log(ctx).Error("SubscriptionService."+method+":subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
+			return s.fail(ctx, method, ErrInternal)
+		}
+		if current.PlanId.Valid && int(current.ServiceId.Int32) != *serviceId {
+			return s.fail(ctx, method, ErrPlanMismatch)
+		}
+	}
+
+	if _, err := s.getService(ctx, method, *serviceId); err != nil {
+		return err
+	}
+	m.ServiceId = sql.NullInt32{Int32: int32(*serviceId), Valid: true}
+	return nil
+}
+
+func (s *SubscriptionService) getService(ctx context.Context, method string, id int) (*models.Service, error) {
+	service, err := s.catalogRepo.GetService(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, method, ErrUnknownService)
+		}
+		s.log(ctx).Error("SubscriptionService."+method+":catalogRepo.GetService - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	return service, nil
+}
+
+func (s *SubscriptionService) getPlan(ctx context.Context, method string, id int) (*models.Plan, error) {
+	plan, err := s.catalogRepo.GetPlan(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, method, ErrUnknownPlan)
+		}
+		s.log(ctx).Error("SubscriptionService."+method+":catalogRepo.GetPlan - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	return plan, nil
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CategoryService manages the user-defined categories, the built-in ones come with the migrations
+type CategoryService struct {
+	categoryRepo ICategoryRepo
+	metrics      IMetrics
+	tracer       trace.Tracer
+	logger       *slog.Logger
+}
+
+func NewCategoryService(categoryRepo ICategoryRepo, metrics IMetrics, logger *slog.Logger) *CategoryService {
+	return &CategoryService{
+		categoryRepo: categoryRepo,
+		metrics:      metrics,
+		tracer:       otel.Tracer(tracerName),
+		logger:       logger,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *CategoryService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *CategoryService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, data *domain.CategoryCreate) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "CategoryService.CreateCategory")
+	defer span.End()
+
+	m := &models.CategoryCreate{
+		Name:   data.Name,
+		UserId: uuid.NullUUID{UUID: data.UserId, Valid: true},
+	}
+	if data.ParentId != nil {
+		if _, err := s.getParent(ctx, "CreateCategory", *data.ParentId, data.UserId); err != nil {
+			return 0, err
+		}
+		m.ParentId = sql.NullInt32{Int32: int32(*data.ParentId), Valid: true}
+	}
+
+	id, err := s.categoryRepo.CreateCategory(ctx, m)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return 0, s.fail(ctx, "CreateCategory", ErrAlreadyExists)
+		} else if errors.Is(err, repository.ErrNotFound) {
+			return 0, s.fail(ctx, "CreateCategory", ErrUnknownCategory)
+		}
+		s.log(ctx).Error("CategoryService.CreateCategory:categoryRepo.CreateCategory - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CreateCategory", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("The category id=%d has been created", id))
+	return id, nil
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, id int) (*domain.Category, error) {
+	ctx, span := s.tracer.Start(ctx, "CategoryService.GetCategory")
+	defer span.End()
+
+	model, err := s.categoryRepo.GetCategory(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetCategory", ErrNotFound)
+		}
+		s.log(ctx).Error("CategoryService.GetCategory:categoryRepo.GetCategory - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetCategory", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Category id=%d received successfully", id))
+
+	return toCategoryDomain(model), nil
+}
+
+// GetCategories returns the built-in categories and the user's ones, the categories of all users without one
+func (s *CategoryService) GetCategories(ctx context.Context, userId *uuid.UUID) ([]*domain.Category, error) {
+	ctx, span := s.tracer.Start(ctx, "CategoryService.GetCategories")
+	defer span.End()
+
+	list, err := s.categoryRepo.GetCategories(ctx, userId)
+	if err != nil {
+		s.log(ctx).Error("CategoryService.GetCategories:categoryRepo.GetCategories - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetCategories", ErrInternal)
+	}
+
+	categories := make([]*domain.Category, 0, len(list))
+	for _, m := range list {
+		categories = append(categories, toCategoryDomain(m))
+	}
+	s.log(ctx).Info(fmt.Sprintf("%d categories were received successfully", len(categories)))
+
+	return categories, nil
+}
+
+// UpdateCategory renames or moves the user's category
+func (s *CategoryService) UpdateCategory(ctx context.Context, data *domain.CategoryUpdate) (*domain.Category, error) {
+	ctx, span := s.tracer.Start(ctx, "CategoryService.UpdateCategory")
+	defer span.End()
+
+	current, err := s.getOwn(ctx, "UpdateCategory", data.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.CategoryUpdate{Id: data.Id}
+	if data.Name != nil {
+		m.Name = sql.NullString{String: *data.Name, Valid: true}
+	}
+	if data.ParentId != nil {
+		parent, err := s.getParent(ctx, "UpdateCategory", *data.ParentId, current.UserId.UUID)
+		if err != nil {
+			return nil, err
+		}
+		//Built-in parents are never below a user's category
+		for parent != nil && parent.UserId.Valid {
+			if parent.Id == data.Id {
+				return nil, s.fail(ctx, "UpdateCategory", ErrCategoryCycle)
+			}
+			if !parent.ParentId.Valid {
+				break
+			}
+			parent, err = s.getParent(ctx, "UpdateCategory", int(parent.ParentId.Int32), current.UserId.UUID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		m.ParentId = sql.NullInt32{Int32: int32(*data.ParentId), Valid: true}
+	}
+
+	model, err := s.categoryRepo.UpdateCategory(ctx, m)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "UpdateCategory", ErrNotFound)
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "UpdateCategory", ErrAlreadyExists)
+		}
+		s.log(ctx).Error("CategoryService.UpdateCategory:categoryRepo.UpdateCategory - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "UpdateCategory", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Category id=%d update successfully", data.Id))
+
+	return toCategoryDomain(model), nil
+}
+
+// DeleteCategory removes the user's category with its subcategories, their subscriptions are kept without a category
+func (s *CategoryService) DeleteCategory(ctx context.Context, id int) (*domain.Category, error) {
+	ctx, span := s.tracer.Start(ctx, "CategoryService.DeleteCategory")
+	defer span.End()
+
+	if _, err := s.getOwn(ctx, "DeleteCategory", id); err != nil {
+		return nil, err
+	}
+
+	model, err := s.categoryRepo.DeleteCategory(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "DeleteCategory", ErrNotFound)
+		}
+		s.log(ctx).Error("CategoryService.DeleteCategory:categoryRepo.DeleteCategory - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteCategory", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Category id=%d deleted successfully", id))
+
+	return toCategoryDomain(model), nil
+}
+
+// getOwn returns the category if it is a user's one
+func (s *CategoryService) getOwn(ctx context.Context, method string, id int) (*models.Category, error) {
+	category, err := s.categoryRepo.GetCategory(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, method, ErrNotFound)
+		}
+		s.log(ctx).Error("CategoryService."+method+":categoryRepo.GetCategory - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	if !category.UserId.Valid {
+		return nil, s.fail(ctx, method, ErrBuiltInCategory)
+	}
+	return category, nil
+}
+
+// getParent returns the category if the user's categories may be put into it
+func (s *CategoryService) getParent(ctx context.Context, method string, id int, userId uuid.UUID) (*models.Category, error) {
+	category, err := s.categoryRepo.GetCategory(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, method, ErrUnknownCategory)
+		}
+		s.log(ctx).Error("CategoryService."+method+":categoryRepo.GetCategory - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	if !availableTo(category, userId) {
+		return nil, s.fail(ctx, method, ErrUnknownCategory)
+	}
+	return category, nil
+}
+
+// availableTo reports whether the category is built-in or the user's
+func availableTo(c *models.Category, userId uuid.UUID) bool {
+	return !c.UserId.Valid || c.UserId.UUID == userId
+}
+
+// subcategories returns the ID of the category followed by the IDs of all its subcategories
+func subcategories(categories []*models.Category, id int) []int {
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range categories {
+			if c.ParentId.Valid && int(c.ParentId.Int32) == ids[i] {
+				ids = append(ids, c.Id)
+			}
+		}
+	}
+	return ids
+}
+
+func toCategoryDomain(m *models.Category) *domain.Category {
+	category := &domain.Category{
+		Id:   m.Id,
+		Name: m.Name,
+	}
+	if m.ParentId.Valid {
+		category.ParentId = new(int)
+		*category.ParentId = int(m.ParentId.Int32)
+	}
+	if m.UserId.Valid {
+		category.UserId = &m.UserId.UUID
+	}
+	return category
+}
+
+// checkCategory fails unless the category is built-in or the user's
+func (s *SubscriptionService) checkCategory(ctx context.Context, method string, id int, userId uuid.UUID) error {
+	category, err := s.categoryRepo.GetCategory(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return s.fail(ctx, method, ErrUnknownCategory)
+		}
+		s.log(ctx).Error("SubscriptionService."+method+":categoryRepo.GetCategory - Internal error", slog.String("error", err.Error()))
+		return s.fail(ctx, method, ErrInternal)
+	}
+	if !availableTo(category, userId) {
+		return s.fail(ctx, method, ErrUnknownCategory)
+	}
+	return nil
+}
+
+// categoryIds returns the IDs of the category and its subcategories for a repository filter
+func (s *SubscriptionService) categoryIds(ctx context.Context, method string, id int) ([]int, error) {
+	categories, err := s.categoryRepo.GetCategories(ctx, nil)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":categoryRepo.GetCategories - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	for _, c := range categories {
+		if c.Id == id {
+			return subcategories(categories, id), nil
+		}
+	}
+	return nil, s.fail(ctx, method, ErrUnknownCategory)
+}
+
+// costByCategory sums up the costs of the subscriptions by their categories. The cost of a category
+// includes its subcategories, the subscriptions without a category are summed up last.
+func (s *SubscriptionService) costByCategory(ctx context.Context, method string, userId *uuid.UUID, subs []*models.Subscription, items []domain.PriceItem) ([]domain.CategoryCost, error) {
+	list, err := s.categoryRepo.GetCategories(ctx, userId)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":categoryRepo.GetCategories - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	var serviceIds []int
+	for _, m := range subs {
+		if !m.CategoryId.Valid && m.ServiceId.Valid {
+			serviceIds = append(serviceIds, int(m.ServiceId.Int32))
+		}
+	}
+	serviceCategories, err := s.categoryRepo.GetServiceCategories(ctx, serviceIds)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":categoryRepo.GetServiceCategories - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	categories := make(map[int]*models.Category, len(list))
+	for _, c := range list {
+		categories[c.Id] = c
+	}
+	byService := make(map[int]int, len(serviceCategories))
+	for _, c := range serviceCategories {
+		byService[c.ServiceId] = c.CategoryId
+	}
+
+	costs := make(map[int]int)
+	uncategorized, hasUncategorized := 0, false
+	for i, m := range subs {
+		var category *models.Category
+		if m.CategoryId.Valid {
+			category = categories[int(m.CategoryId.Int32)]
+		} else if id, ok := byService[int(m.ServiceId.Int32)]; ok && m.ServiceId.Valid {
+			category = categories[id]
+		}
+		if category == nil {
+			uncategorized += items[i].Share
+			hasUncategorized = true
+			continue
+		}
+		for category != nil {
+			costs[category.Id] += items[i].Share
+			if !category.ParentId.Valid {
+				break
+			}
+			category = categories[int(category.ParentId.Int32)]
+		}
+	}
+
+	res := make([]domain.CategoryCost, 0, len(costs)+1)
+	for _, c := range list {
+		cost, ok := costs[c.Id]
+		if !ok {
+			continue
+		}
+		res = append(res, domain.CategoryCost{CategoryId: &c.Id, Name: c.Name, ParentId: intPtr(c.ParentId), Cost: cost})
+	}
+	if hasUncategorized {
+		res = append(res, domain.CategoryCost{Cost: uncategorized})
+	}
+	return res, nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"time"
+)
+
+const (
+	// Dates are returned with day precision
+	dayLayout = "2006-01-02"
+	// A whole month is accepted for compatibility with the first API version
+	monthLayout = "01-2006"
+)
+
+// parseDate parses a YYYY-MM-DD or MM-YYYY date.
+// A month stands for its first day, or for its last day if end is set, so that both ends of a period are inclusive.
+func parseDate(value string, end bool) (time.Time, error) {
+	date, err := time.Parse(dayLayout, value)
+	if err == nil {
+		return date, nil
+	}
+	date, err = time.Parse(monthLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		return endOfMonth(date), nil
+	}
+	return date, nil
+}
+
+func formatDate(t time.Time) string {
+	return t.Format(dayLayout)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func endOfMonth(t time.Time) time.Time {
+	return startOfMonth(t).AddDate(0, 1, -1)
+}
+
+// anchorDate returns the billing day in the given month, clamped to the month length:
+// a subscription billed on the 31st renews on February 28 (29)
+func anchorDate(year int, month time.Month, billingDay int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, 0, min(billingDay, endOfMonth(first).Day())-1)
+}
+
+// cycleStart returns the start of the billing cycle containing t
+func cycleStart(t time.Time, billingDay int) time.Time {
+	anchor := anchorDate(t.Year(), t.Month(), billingDay)
+	if anchor.After(t) {
+		previous := startOfMonth(t).AddDate(0, -1, 0)
+		return anchorDate(previous.Year(), previous.Month(), billingDay)
+	}
+	return anchor
+}
+
+// nextCycle returns the start of the billing cycle following the one starting at start
+func nextCycle(start time.Time, billingDay int) time.Time {
+	next := startOfMonth(start).AddDate(0, 1, 0)
+	return anchorDate(next.Year(), next.Month(), billingDay)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
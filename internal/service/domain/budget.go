@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget is a monthly spending limit of the user.
+// Without a category and a service it limits all the user's subscriptions.
+type Budget struct {
+	Id     int
+	UserId uuid.UUID
+	Amount int
+	// Includes the subcategories, a subscription without a category is in the category of its service
+	CategoryId *int
+	// Catalog service, at most one of CategoryId and ServiceId is set
+	ServiceId *int
+}
+
+type BudgetCreate struct {
+	UserId     uuid.UUID
+	Amount     int
+	CategoryId *int
+	ServiceId  *int
+}
+
+type BudgetUpdate struct {
+	Id     int
+	Amount int
+}
+
+type BudgetLevel string
+
+const (
+	BudgetOk       BudgetLevel = "ok"
+	BudgetWarning  BudgetLevel = "warning"
+	BudgetExceeded BudgetLevel = "exceeded"
+)
+
+// BudgetStatus is the spending of a month against the budget, counted like PriceReport.Total of the month
+type BudgetStatus struct {
+	Budget Budget
+	// First day of the month
+	Month   string
+	Spent   int
+	Percent int
+	Level   BudgetLevel
+}
+
+// BudgetAlert is raised once per month when the spending reaches a threshold of the budget
+type BudgetAlert struct {
+	Id       int
+	BudgetId int
+	// First day of the month
+	Month string
+	// Percent of the amount
+	Threshold int
+	Spent     int
+	Amount    int
+	AlertedAt time.Time
+}
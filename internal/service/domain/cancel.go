@@ -0,0 +1,23 @@
+package domain
+
+// Status of a subscription on the current day, or on its start date if it has not started yet
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusTrialing Status = "trialing"
+	StatusPaused   Status = "paused"
+	// Cancelled by the user, the subscription is active until its end date
+	StatusCancelled Status = "cancelled"
+	// Ended on its end date without being cancelled
+	StatusExpired Status = "expired"
+)
+
+// Cancel ends a subscription at the end of its current billing cycle if AtPeriodEnd is set,
+// on Date otherwise (today if nil)
+type Cancel struct {
+	SubscriptionId int
+	AtPeriodEnd    bool
+	Date           *string
+	Reason         *string
+}
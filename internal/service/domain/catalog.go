@@ -0,0 +1,64 @@
+package domain
+
+// BillingPeriod is how often the price of a plan is charged
+type BillingPeriod string
+
+const (
+	BillingMonth BillingPeriod = "month"
+	BillingYear  BillingPeriod = "year"
+)
+
+// Service is a catalog entry, subscriptions with its name or alias are linked to it
+type Service struct {
+	Id   int
+	Name string
+	// Other names the service is known by, ordered
+	Aliases    []string
+	CategoryId *int
+	Website    *string
+	LogoUrl    *string
+	// Ordered by ID
+	Plans []Plan
+}
+
+type ServiceCreate struct {
+	Name    string
+	Aliases []string
+	// A built-in category
+	CategoryId *int
+	Website    *string
+	LogoUrl    *string
+}
+
+type ServiceUpdate struct {
+	Id   int
+	Name *string
+	// Replace the aliases unless nil
+	Aliases    []string
+	CategoryId *int
+	Website    *string
+	LogoUrl    *string
+}
+
+// Plan is a tier of a service with its default price
+type Plan struct {
+	Id            int
+	ServiceId     int
+	Name          string
+	Price         int
+	BillingPeriod BillingPeriod
+}
+
+type PlanCreate struct {
+	ServiceId     int
+	Name          string
+	Price         int
+	BillingPeriod BillingPeriod
+}
+
+type PlanUpdate struct {
+	Id            int
+	Name          *string
+	Price         *int
+	BillingPeriod *BillingPeriod
+}
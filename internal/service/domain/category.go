@@ -0,0 +1,35 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Category groups the subscriptions by what the money is spent on.
+// Built-in categories have no user, they can't be changed and are shared by everyone.
+type Category struct {
+	Id       int
+	Name     string
+	ParentId *int
+	UserId   *uuid.UUID
+}
+
+type CategoryCreate struct {
+	Name string
+	// A built-in category or one of the user's
+	ParentId *int
+	UserId   uuid.UUID
+}
+
+type CategoryUpdate struct {
+	Id   int
+	Name *string
+	// Must not be the category itself or its subcategory
+	ParentId *int
+}
+
+// CategoryCost is the cost of the subscriptions in the category and its subcategories
+type CategoryCost struct {
+	// Nil for the subscriptions without a category
+	CategoryId *int
+	Name       string
+	ParentId   *int
+	Cost       int
+}
@@ -0,0 +1,15 @@
+package domain
+
+// Forecast is the projected spending of the user month by month
+type Forecast struct {
+	Total  int
+	Months []ForecastMonth
+}
+
+// ForecastMonth is the projected spending of a month and the subscriptions charged in it
+type ForecastMonth struct {
+	// First day of the month, YYYY-MM-DD
+	Month string
+	Total int
+	Items []PriceItem
+}
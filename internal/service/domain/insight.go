@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// InsightKind is what an insight flags about the user's subscriptions
+type InsightKind string
+
+const (
+	// InsightDuplicate flags two subscriptions to the same service active at the same time
+	InsightDuplicate InsightKind = "duplicate"
+	// InsightPriceJump flags a subscription much more expensive than the previous one to the same service
+	InsightPriceJump InsightKind = "price_jump"
+	// InsightLongOpenEnded flags a subscription without an end date started long ago
+	InsightLongOpenEnded InsightKind = "long_open_ended"
+)
+
+// Insight is a possible problem with the user's subscriptions.
+// The fields after SubscriptionIds are set depending on the kind.
+type Insight struct {
+	// The kind and the subscription IDs, stable as long as the subscriptions are
+	Id   string
+	Kind InsightKind
+	// Of the earliest subscription
+	ServiceName string
+	// Ordered by the start date
+	SubscriptionIds []int
+	// Days both duplicates are active, YYYY-MM-DD; To is empty while they both are
+	From string
+	To   string
+	// Prices of the previous and the next subscription and the increase in percent
+	OldPrice int
+	NewPrice int
+	Increase int
+	// Full months the subscription has been active without an end date
+	Months      int
+	DismissedAt *time.Time
+}
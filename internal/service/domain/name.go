@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// ServiceName is a spelling of a service name used by the subscriptions
+type ServiceName struct {
+	Name          string
+	Subscriptions int
+}
+
+// NameCluster is a group of spellings that likely name the same service
+type NameCluster struct {
+	// Name of the catalog service or the most used spelling
+	Canonical string
+	// The catalog service matching the canonical name
+	ServiceId *int
+	// Ordered by the number of subscriptions, then by name
+	Names         []ServiceName
+	Subscriptions int
+}
+
+type NameMerge struct {
+	Canonical string
+	Names     []string
+}
+
+// NameMergeAudit is a record of a performed merge
+type NameMergeAudit struct {
+	Id            int
+	Canonical     string
+	Names         []string
+	Subscriptions int
+	MergedAt      time.Time
+}
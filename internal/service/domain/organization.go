@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a tenant grouping the users, e.g. a company or a household.
+// A subscription created in an organization is only visible in it.
+type Organization struct {
+	Id        int
+	Name      string
+	CreatedAt time.Time
+}
+
+type OrganizationCreate struct {
+	Name string
+	// Becomes the first member if set
+	UserId *uuid.UUID
+}
+
+type OrganizationUpdate struct {
+	Id   int
+	Name string
+}
+
+type OrganizationMember struct {
+	UserId   uuid.UUID
+	JoinedAt time.Time
+}
+
+type OrganizationCostFilter struct {
+	OrganizationId int
+	StartDate      string
+	EndDate        string
+}
+
+// OrganizationCost is the cost of the organization's subscriptions in a period with monthly proration
+type OrganizationCost struct {
+	OrganizationId int
+	Total          int
+	// What every user pays after the splits, ordered by user ID
+	Users []UserCost
+}
+
+type UserCost struct {
+	UserId uuid.UUID
+	Cost   int
+}
@@ -0,0 +1,22 @@
+package domain
+
+// Pause is an interval of days, both included, the subscription is not billed for
+type Pause struct {
+	Id        int
+	StartDate string
+	// Nil while the subscription is paused until it is resumed
+	EndDate *string
+}
+
+// PauseCreate pauses a subscription from StartDate (today if nil) to EndDate (until resumed if nil)
+type PauseCreate struct {
+	SubscriptionId int
+	StartDate      *string
+	EndDate        *string
+}
+
+// Resume ends the pause of a subscription the day before Date (today if nil)
+type Resume struct {
+	SubscriptionId int
+	Date           *string
+}
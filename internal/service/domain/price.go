@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// Proration defines how a partially active month is billed
+type Proration string
+
+const (
+	// ProrationMonthly bills every month the subscription is active in at the full price
+	ProrationMonthly Proration = "monthly"
+	// ProrationDaily bills the share of the month's days the subscription is active
+	ProrationDaily Proration = "daily"
+)
+
+type PriceFilter struct {
+	// Also matches the subscriptions shared with the user, only the user's shares are counted
+	UserId *uuid.UUID
+	// Also matches the subscriptions linked to the catalog service with the name or alias
+	ServiceName *string
+	// Takes precedence over ServiceName
+	ServiceId *int
+	// Includes the subcategories, a subscription without a category is in the category of its service
+	CategoryId *int
+	// The subscriptions must have all of Tags and none of ExcludedTags, regardless of case
+	Tags         []string
+	ExcludedTags []string
+	StartDate    string
+	EndDate      string
+	Proration    Proration
+	// Break the total down by category
+	ByCategory bool
+}
+
+// PriceReport is the total cost of a period and how every subscription contributes to it
+type PriceReport struct {
+	Total int
+	Items []PriceItem
+	// Only with ByCategory in the filter
+	Categories []CategoryCost
+}
+
+// PriceItem explains the cost of one subscription in the period
+type PriceItem struct {
+	SubscriptionId int
+	ServiceName    string
+	Price          int
+	// First and last billed days, YYYY-MM-DD
+	From   string
+	To     string
+	Months int
+	// Billed days, only for the daily proration
+	Days int
+	// Months out of Months billed at TrialPrice
+	TrialMonths int
+	TrialPrice  int
+	// The regular charges were billed at different prices, Price is the last one
+	PriceChanged bool
+	// Paused days between From and To, they are not billed
+	PausedDays int
+	Cost       int
+	// Part of Cost paid by the user of the filter, the whole Cost without a user
+	Share int
+}
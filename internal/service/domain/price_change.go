@@ -0,0 +1,15 @@
+package domain
+
+// PriceChange is the price the subscription is charged from EffectiveDate on
+type PriceChange struct {
+	Id            int
+	EffectiveDate string
+	Price         int
+}
+
+// PriceChangeCreate schedules a new price of a subscription from EffectiveDate on
+type PriceChangeCreate struct {
+	SubscriptionId int
+	EffectiveDate  string
+	Price          int
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// SplitRule defines how the cost of a shared subscription is divided between its owner and members
+type SplitRule string
+
+const (
+	// SplitEqual divides the cost equally between the owner and the members
+	SplitEqual SplitRule = "equal"
+	// SplitPercentage charges every member the percentage of the cost
+	SplitPercentage SplitRule = "percentage"
+	// SplitFixed charges every member a fixed amount of the monthly price
+	SplitFixed SplitRule = "fixed"
+)
+
+// Split is how a subscription is shared, the owner pays what the members don't
+type Split struct {
+	Rule SplitRule
+	// Ordered by user ID
+	Members []Member
+}
+
+type Member struct {
+	UserId uuid.UUID
+	// Percentage or monthly amount, nil for the equal split
+	Share *int
+}
+
+type SplitUpdate struct {
+	SubscriptionId int
+	Rule           SplitRule
+	// Replace the members, none stops sharing the subscription
+	Members []Member
+}
+
+type DebtFilter struct {
+	// Only the debts of and to the user if set
+	UserId    *uuid.UUID
+	StartDate string
+	EndDate   string
+}
+
+// Debt is what one user owes another for the shared subscriptions in a period, net of what is owed back
+type Debt struct {
+	From   uuid.UUID
+	To     uuid.UUID
+	Amount int
+}
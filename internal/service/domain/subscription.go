@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 )
 
+// Dates are YYYY-MM-DD, on input MM-YYYY is accepted as well
 type Subscription struct {
 	Id          int
 	ServiceName string
@@ -11,14 +12,54 @@ type Subscription struct {
 	UserId      uuid.UUID
 	StartDate   string
 	EndDate     *string
+	BillingDay  int
+	// Next charge on or after today, nil if the subscription has ended
+	NextBillingDate *string
+	// The first TrialMonths billing cycles are charged TrialPrice
+	TrialMonths int
+	TrialPrice  int
+	// Last day of the trial, nil without a trial
+	TrialEndsAt *string
+	// Ordered by the start date
+	Pauses []Pause
+	Status Status
+	// Day the subscription was cancelled on, it ends on EndDate
+	CancelledAt  *string
+	CancelReason *string
+	// Catalog service and plan, nil if not linked
+	ServiceId *int
+	PlanId    *int
+	// Nil if the subscription has the category of its service
+	CategoryId *int
+	// Ordered by name regardless of case
+	Tags  []string
+	Notes *string
+	// Nil if the subscription is not shared
+	Split *Split
+	// The organization the subscription was created in
+	OrganizationId int
 }
 
 type SubscriptionCreate struct {
+	// The catalog name of the service if empty
 	ServiceName string
-	Price       int
-	UserId      uuid.UUID
-	StartDate   string
-	EndDate     *string
+	// The monthly price of the plan if not set
+	Price     *int
+	UserId    uuid.UUID
+	StartDate string
+	EndDate   *string
+	// The day of the start date if not set
+	BillingDay  *int
+	TrialMonths *int
+	TrialPrice  *int
+	// The service of the plan or the one found by ServiceName if not set
+	ServiceId *int
+	PlanId    *int
+	// A built-in category or one of the user's
+	CategoryId *int
+	// The user's tags, missing ones are created
+	Tags  []string
+	Notes *string
 }
 
 type SubscriptionUpdate struct {
@@ -27,4 +68,28 @@ type SubscriptionUpdate struct {
 	Price       *int
 	StartDate   *string
 	EndDate     *string
+	BillingDay  *int
+	TrialMonths *int
+	TrialPrice  *int
+	ServiceId   *int
+	PlanId      *int
+	CategoryId  *int
+	// Replace the tags of the subscription, an empty list removes them
+	Tags  []string
+	Notes *string
+}
+
+// ListFilter narrows a list of subscriptions, empty fields match everything
+type ListFilter struct {
+	Status Status
+	// Includes the subcategories, a subscription without a category is in the category of its service
+	CategoryId *int
+	// The subscription must have all of Tags and none of ExcludedTags, regardless of case
+	Tags         []string
+	ExcludedTags []string
+}
+
+// Empty reports whether the filter matches every subscription
+func (f *ListFilter) Empty() bool {
+	return f.Status == "" && f.CategoryId == nil && f.Tags == nil && f.ExcludedTags == nil
 }
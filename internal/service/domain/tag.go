@@ -0,0 +1,22 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Tag is a free-form label of the user's subscriptions, unique per user regardless of case
+type Tag struct {
+	Id     int
+	UserId uuid.UUID
+	Name   string
+	// Number of the subscriptions with the tag
+	Subscriptions int
+}
+
+type TagCreate struct {
+	UserId uuid.UUID
+	Name   string
+}
+
+type TagUpdate struct {
+	Id   int
+	Name string
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is a registered owner of subscriptions.
+// An anonymized user keeps the ID and the settings, the name and the email are erased.
+type User struct {
+	Id          uuid.UUID
+	DisplayName string
+	Email       *string
+	// BCP 47 language tag, e.g. en or ru-RU
+	Locale string
+	// ISO 4217 code
+	Currency string
+	// IANA time zone, e.g. Europe/Moscow
+	Timezone     string
+	CreatedAt    time.Time
+	AnonymizedAt *time.Time
+}
+
+type UserCreate struct {
+	// A new ID is generated if not set
+	Id          *uuid.UUID
+	DisplayName string
+	Email       *string
+	// en, RUB and UTC if not set
+	Locale   *string
+	Currency *string
+	Timezone *string
+}
+
+type UserUpdate struct {
+	Id          uuid.UUID
+	DisplayName *string
+	Email       *string
+	Locale      *string
+	Currency    *string
+	Timezone    *string
+	// Removes the email, Email is ignored
+	ClearEmail bool
+}
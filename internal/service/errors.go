@@ -5,5 +5,32 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrInternal      = errors.New("internal error")
-	ErrIncorrectTime = errors.New("the end date must be later than the start date")
+	ErrIncorrectTime = errors.New("the end date must not be earlier than the start date")
+
+	ErrPauseOutsidePeriod = errors.New("the pause must be within the subscription period")
+	ErrPauseOverlap       = errors.New("the pause overlaps another pause of the subscription")
+	ErrNotPaused          = errors.New("the subscription is not paused on the resume date")
+	ErrAlreadyEnded       = errors.New("the subscription has already ended")
+
+	ErrPriceChangeOutsidePeriod = errors.New("the price change must be within the subscription period and not in the past")
+	ErrPriceChangeExists        = errors.New("the subscription already has a price change on the date")
+
+	ErrAlreadyExists  = errors.New("the name is already taken")
+	ErrUnknownService = errors.New("the service is not in the catalog")
+	ErrUnknownPlan    = errors.New("the plan is not in the catalog")
+	ErrPlanMismatch   = errors.New("the plan belongs to another service")
+
+	ErrUnknownCategory = errors.New("the category is neither built-in nor the user's")
+	ErrBuiltInCategory = errors.New("built-in categories can't be changed")
+	ErrCategoryCycle   = errors.New("the category can't be moved into itself or its subcategory")
+
+	ErrBudgetExists = errors.New("the user already has a budget for the category or service")
+
+	ErrIncorrectSplit = errors.New("the members and their shares don't match the split rule")
+
+	ErrNotMember            = errors.New("the user is not a member of the organization")
+	ErrOrganizationNotEmpty = errors.New("the organization still has subscriptions")
+
+	ErrUnknownUser = errors.New("the user is not registered")
+	ErrUserExists  = errors.New("a user with the ID or email already exists")
 )
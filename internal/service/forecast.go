@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+)
+
+// GetForecast projects the charges of the user's subscriptions for the number of months starting with the current one
+func (s *SubscriptionService) GetForecast(ctx context.Context, userId uuid.UUID, months int) (*domain.Forecast, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetForecast")
+	defer span.End()
+
+	today := s.today()
+	until := endOfMonth(startOfMonth(today).AddDate(0, months-1, 0))
+	subs, err := s.subscriptionRepo.GetByPeriod(ctx, &models.SubscriptionFilter{
+		UserId:  &userId,
+		Members: true,
+		From:    today,
+		To:      until,
+	})
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.GetForecast:subscriptionRepo.GetByPeriod - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetForecast", ErrInternal)
+	}
+
+	pauses, err := s.getPauses(ctx, "GetForecast", subs)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.getPriceChanges(ctx, "GetForecast", subs)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.getSplits(ctx, "GetForecast", subs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := forecast(subs, pauses, changes, splits, userId, today, months)
+	s.log(ctx).Info(fmt.Sprintf("Forecast of %d months for the user userId=%s is %d", months, userId.String(), res.Total))
+
+	return res, nil
+}
+
+// forecast bills the subscriptions month by month by the billing rules with monthly proration.
+// The charges of the current month made before today are not counted, only the user's shares of the shared subscriptions are.
+// Every charge is billed at the price in effect on its date.
+func forecast(subs []*models.Subscription, pauses map[int][]*models.Pause, changes map[int][]*models.PriceChange, splits map[int]*models.SubscriptionSplit, userId uuid.UUID, today time.Time, months int) *domain.Forecast {
+	res := &domain.Forecast{}
+	for i := range months {
+		start := startOfMonth(today).AddDate(0, i, 0)
+		from := start
+		if i == 0 {
+			from = today
+		}
+
+		month := domain.ForecastMonth{Month: formatDate(start)}
+		for _, sub := range subs {
+			//A month has a single charge at most, billed at the price in effect on its date
+			item := bill(sub, pauses[sub.Id], changes[sub.Id], from, endOfMonth(start), domain.ProrationMonthly)
+			//Trial charges are listed even when free
+			if item.Months == 0 {
+				continue
+			}
+			item.Share = shareOf(sub, splits[sub.Id], &userId, item.Cost)
+			month.Total += item.Share
+			month.Items = append(month.Items, item)
+		}
+		res.Total += month.Total
+		res.Months = append(res.Months, month)
+	}
+	return res
+}
+
+// priceOn returns the price of the subscription on the day, changes must be ordered by effective date
+func priceOn(sub *models.Subscription, changes []*models.PriceChange, day time.Time) int {
+	price := sub.Price
+	for _, c := range changes {
+		if c.EffectiveDate.After(day) {
+			break
+		}
+		price = c.Price
+	}
+	return price
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// A subscription at least this much more expensive than the previous one to the service is a price jump
+	priceJumpPercent = 30
+	// An open-ended subscription active for this many months is flagged
+	longOpenEndedMonths = 24
+)
+
+// InsightService finds possible problems with the user's subscriptions
+type InsightService struct {
+	subscriptionRepo ISubscriptionRepo
+	insightRepo      IInsightRepo
+	metrics          IMetrics
+	tracer           trace.Tracer
+	logger           *slog.Logger
+	now              func() time.Time
+}
+
+func NewInsightService(subscriptionRepo ISubscriptionRepo, insightRepo IInsightRepo, metrics IMetrics, logger *slog.Logger) *InsightService {
+	return &InsightService{
+		subscriptionRepo: subscriptionRepo,
+		insightRepo:      insightRepo,
+		metrics:          metrics,
+		tracer:           otel.Tracer(tracerName),
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *InsightService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *InsightService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+// GetInsights returns the insights about the user's subscriptions, the dismissed ones only if dismissed is set
+func (s *InsightService) GetInsights(ctx context.Context, userId uuid.UUID, dismissed bool) ([]*domain.Insight, error) {
+	ctx, span := s.tracer.Start(ctx, "InsightService.GetInsights")
+	defer span.End()
+
+	all, err := s.getInsights(ctx, "GetInsights", userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*domain.Insight
+	for _, insight := range all {
+		if insight.DismissedAt == nil || dismissed {
+			res = append(res, insight)
+		}
+	}
+	s.log(ctx).Info(fmt.Sprintf("Found %d insights about the subscriptions of the user userId=%s", len(res), userId.String()))
+
+	return res, nil
+}
+
+// DismissInsight hides the insight from the user, dismissing it again changes nothing
+func (s *InsightService) DismissInsight(ctx context.Context, userId uuid.UUID, id string) (*domain.Insight, error) {
+	ctx, span := s.tracer.Start(ctx, "InsightService.DismissInsight")
+	defer span.End()
+
+	all, err := s.getInsights(ctx, "DismissInsight", userId)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(all, func(insight *domain.Insight) bool { return insight.Id == id })
+	if i < 0 {
+		return nil, s.fail(ctx, "DismissInsight", ErrNotFound)
+	}
+	insight := all[i]
+	if insight.DismissedAt != nil {
+		return insight, nil
+	}
+
+	now := s.now().UTC()
+	err = s.insightRepo.DismissInsight(ctx, &models.InsightDismissal{UserId: userId, InsightId: id, DismissedAt: now})
+	if err != nil {
+		s.log(ctx).Error("InsightService.DismissInsight:insightRepo.DismissInsight - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DismissInsight", ErrInternal)
+	}
+	insight.DismissedAt = &now
+	s.log(ctx).Info(fmt.Sprintf("The insight %s of the user userId=%s has been dismissed", id, userId.String()))
+
+	return insight, nil
+}
+
+// getInsights finds the insights about all subscriptions of the user and marks the dismissed ones
+func (s *InsightService) getInsights(ctx context.Context, method string, userId uuid.UUID) ([]*domain.Insight, error) {
+	subs, err := s.subscriptionRepo.GetByState(ctx, &models.StateFilter{UserId: &userId})
+	if err != nil {
+		s.log(ctx).Error("InsightService."+method+":subscriptionRepo.GetByState - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	ids := make([]int, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.Id)
+	}
+	list, err := s.subscriptionRepo.GetPriceChanges(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("InsightService."+method+":subscriptionRepo.GetPriceChanges - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	changes := make(map[int][]*models.PriceChange, len(subs))
+	for _, c := range list {
+		changes[c.SubscriptionId] = append(changes[c.SubscriptionId], c)
+	}
+	dismissals, err := s.insightRepo.GetInsightDismissals(ctx, userId)
+	if err != nil {
+		s.log(ctx).Error("InsightService."+method+":insightRepo.GetInsightDismissals - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	now := s.now().UTC()
+	all := insights(subs, changes, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	for _, insight := range all {
+		for _, d := range dismissals {
+			if d.InsightId == insight.Id {
+				insight.DismissedAt = &d.DismissedAt
+			}
+		}
+	}
+	return all, nil
+}
+
+// insights finds the duplicates, the price jumps and the long open-ended subscriptions, in this order.
+// Subscriptions are to the same service if they are linked to the same catalog service
+// or, without one, their names are equal after normalization.
+// A price jump compares the last price of a subscription with the first price of the next one, the price changes included.
+func insights(subs []*models.Subscription, changes map[int][]*models.PriceChange, today time.Time) []*domain.Insight {
+	var (
+		keys   []string
+		groups = make(map[string][]*models.Subscription)
+	)
+	for _, sub := range subs {
+		key := "name:" + normalizeName(sub.ServiceName)
+		if sub.ServiceId.Valid {
+			key = "service:" + strconv.Itoa(int(sub.ServiceId.Int32))
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], sub)
+	}
+	for _, key := range keys {
+		slices.SortStableFunc(groups[key], func(a, b *models.Subscription) int {
+			return cmp.Or(a.StartDate.Compare(b.StartDate), a.Id-b.Id)
+		})
+	}
+
+	var res []*domain.Insight
+	for _, key := range keys {
+		group := groups[key]
+		for i, a := range group {
+			for _, b := range group[i+1:] {
+				//Sorted by the start date, so b starts when both are active if it starts before a ends
+				if a.EndDate.Valid && b.StartDate.After(a.EndDate.Time) {
+					continue
+				}
+				insight := &domain.Insight{
+					Id:              insightId(domain.InsightDuplicate, a.Id, b.Id),
+					Kind:            domain.InsightDuplicate,
+					ServiceName:     a.ServiceName,
+					SubscriptionIds: []int{a.Id, b.Id},
+					From:            formatDate(b.StartDate),
+				}
+				if a.EndDate.Valid && (!b.EndDate.Valid || a.EndDate.Time.Before(b.EndDate.Time)) {
+					insight.To = formatDate(a.EndDate.Time)
+				} else if b.EndDate.Valid {
+					insight.To = formatDate(b.EndDate.Time)
+				}
+				res = append(res, insight)
+			}
+		}
+	}
+	for _, key := range keys {
+		group := groups[key]
+		for i := 1; i < len(group); i++ {
+			prev, next := group[i-1], group[i]
+			//The previous subscription is replaced when the next one starts unless it ends before
+			replaced := next.StartDate
+			if prev.EndDate.Valid && prev.EndDate.Time.Before(replaced) {
+				replaced = prev.EndDate.Time
+			}
+			oldPrice, newPrice := priceOn(prev, changes[prev.Id], replaced), priceOn(next, changes[next.Id], next.StartDate)
+			if oldPrice <= 0 || (newPrice-oldPrice)*100 < oldPrice*priceJumpPercent {
+				continue
+			}
+			res = append(res, &domain.Insight{
+				Id:              insightId(domain.InsightPriceJump, prev.Id, next.Id),
+				Kind:            domain.InsightPriceJump,
+				ServiceName:     prev.ServiceName,
+				SubscriptionIds: []int{prev.Id, next.Id},
+				OldPrice:        oldPrice,
+				NewPrice:        newPrice,
+				Increase:        (newPrice - oldPrice) * 100 / oldPrice,
+			})
+		}
+	}
+	for _, sub := range subs {
+		months := fullMonths(sub.StartDate, today)
+		if sub.EndDate.Valid || months < longOpenEndedMonths {
+			continue
+		}
+		res = append(res, &domain.Insight{
+			Id:              insightId(domain.InsightLongOpenEnded, sub.Id),
+			Kind:            domain.InsightLongOpenEnded,
+			ServiceName:     sub.ServiceName,
+			SubscriptionIds: []int{sub.Id},
+			Months:          months,
+		})
+	}
+	return res
+}
+
+// insightId joins the kind and the subscription IDs, e.g. duplicate-3-7
+func insightId(kind domain.InsightKind, ids ...int) string {
+	id := string(kind)
+	for _, subscriptionId := range ids {
+		id += "-" + strconv.Itoa(subscriptionId)
+	}
+	return id
+}
+
+// fullMonths returns the number of whole months from..to, 0 if to is before from
+func fullMonths(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+	if to.Day() < from.Day() {
+		months--
+	}
+	return max(months, 0)
+}
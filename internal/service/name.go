@@ -0,0 +1,227 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NameService finds the differently spelled service names and merges them
+type NameService struct {
+	nameRepo    INameRepo
+	catalogRepo ICatalogRepo
+	metrics     IMetrics
+	tracer      trace.Tracer
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+func NewNameService(nameRepo INameRepo, catalogRepo ICatalogRepo, metrics IMetrics, logger *slog.Logger) *NameService {
+	return &NameService{
+		nameRepo:    nameRepo,
+		catalogRepo: catalogRepo,
+		metrics:     metrics,
+		tracer:      otel.Tracer(tracerName),
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *NameService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *NameService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+// GetClusters groups the service names that are equal after normalization
+// or whose normalized forms have the trigram similarity of at least threshold.
+// Only the groups with more than one spelling are returned, the largest first.
+func (s *NameService) GetClusters(ctx context.Context, threshold float64) ([]*domain.NameCluster, error) {
+	ctx, span := s.tracer.Start(ctx, "NameService.GetClusters")
+	defer span.End()
+
+	names, err := s.nameRepo.GetServiceNames(ctx)
+	if err != nil {
+		s.log(ctx).Error("NameService.GetClusters:nameRepo.GetServiceNames - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetClusters", ErrInternal)
+	}
+
+	//Spellings with the same normalized form are always together
+	groups := make(map[string][]*models.ServiceName)
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		key := normalizeName(name.Name)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], name)
+	}
+
+	pairs, err := s.nameRepo.SimilarNames(ctx, keys, threshold)
+	if err != nil {
+		s.log(ctx).Error("NameService.GetClusters:nameRepo.SimilarNames - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetClusters", ErrInternal)
+	}
+
+	parent := make(map[string]string, len(keys))
+	var find func(key string) string
+	find = func(key string) string {
+		p, ok := parent[key]
+		if !ok || p == key {
+			return key
+		}
+		root := find(p)
+		parent[key] = root
+		return root
+	}
+	for _, pair := range pairs {
+		a, b := find(pair.A), find(pair.B)
+		if a != b {
+			parent[max(a, b)] = min(a, b)
+		}
+	}
+
+	members := make(map[string][]*models.ServiceName)
+	for _, key := range keys {
+		root := find(key)
+		members[root] = append(members[root], groups[key]...)
+	}
+
+	clusters := make([]*domain.NameCluster, 0)
+	for _, list := range members {
+		if len(list) < 2 {
+			continue
+		}
+		cluster, err := s.toCluster(ctx, list)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, cluster)
+	}
+	slices.SortFunc(clusters, func(a, b *domain.NameCluster) int {
+		return cmp.Or(cmp.Compare(b.Subscriptions, a.Subscriptions), strings.Compare(a.Canonical, b.Canonical))
+	})
+
+	s.log(ctx).Info(fmt.Sprintf("%d service name clusters found", len(clusters)), slog.Float64("threshold", threshold))
+	return clusters, nil
+}
+
+// toCluster suggests the canonical name: the catalog service one of the spellings matches
+// or the most used spelling
+func (s *NameService) toCluster(ctx context.Context, list []*models.ServiceName) (*domain.NameCluster, error) {
+	slices.SortFunc(list, func(a, b *models.ServiceName) int {
+		return cmp.Or(cmp.Compare(b.Subscriptions, a.Subscriptions), strings.Compare(a.Name, b.Name))
+	})
+
+	cluster := &domain.NameCluster{Canonical: list[0].Name, Names: make([]domain.ServiceName, 0, len(list))}
+	for _, name := range list {
+		cluster.Names = append(cluster.Names, domain.ServiceName{Name: name.Name, Subscriptions: name.Subscriptions})
+		cluster.Subscriptions += name.Subscriptions
+
+		if cluster.ServiceId != nil {
+			continue
+		}
+		service, err := s.catalogRepo.FindService(ctx, name.Name)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			s.log(ctx).Error("NameService.GetClusters:catalogRepo.FindService - Internal error", slog.String("error", err.Error()))
+			return nil, s.fail(ctx, "GetClusters", ErrInternal)
+		}
+		cluster.Canonical = service.Name
+		cluster.ServiceId = &service.Id
+	}
+
+	return cluster, nil
+}
+
+// Merge renames the subscriptions with any of the names to the canonical one
+// and links them to the catalog service with that name. The merge is recorded.
+func (s *NameService) Merge(ctx context.Context, data *domain.NameMerge) (*domain.NameMergeAudit, error) {
+	ctx, span := s.tracer.Start(ctx, "NameService.Merge")
+	defer span.End()
+
+	merge := &models.NameMerge{
+		Canonical: data.Canonical,
+		Names:     data.Names,
+		MergedAt:  s.now().UTC().Truncate(time.Second),
+	}
+	service, err := s.catalogRepo.FindService(ctx, data.Canonical)
+	switch {
+	case err == nil:
+		merge.ServiceId = sql.NullInt32{Int32: int32(service.Id), Valid: true}
+	case !errors.Is(err, repository.ErrNotFound):
+		s.log(ctx).Error("NameService.Merge:catalogRepo.FindService - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "Merge", ErrInternal)
+	}
+
+	audit, err := s.nameRepo.MergeServiceNames(ctx, merge)
+	if err != nil {
+		s.log(ctx).Error("NameService.Merge:nameRepo.MergeServiceNames - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "Merge", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("%d subscriptions renamed to %q, merge id=%d", audit.Subscriptions, audit.Canonical, audit.Id))
+	return toMergeDomain(audit), nil
+}
+
+func (s *NameService) GetMerges(ctx context.Context, offset, limit int) ([]*domain.NameMergeAudit, error) {
+	ctx, span := s.tracer.Start(ctx, "NameService.GetMerges")
+	defer span.End()
+
+	list, err := s.nameRepo.GetNameMerges(ctx, offset, limit)
+	if err != nil {
+		s.log(ctx).Error("NameService.GetMerges:nameRepo.GetNameMerges - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetMerges", ErrInternal)
+	}
+
+	merges := make([]*domain.NameMergeAudit, 0, len(list))
+	for _, m := range list {
+		merges = append(merges, toMergeDomain(m))
+	}
+	s.log(ctx).Info("All name merges were received successfully", slog.Int("offset", offset), slog.Int("limit", limit))
+
+	return merges, nil
+}
+
+func toMergeDomain(m *models.NameMergeAudit) *domain.NameMergeAudit {
+	return &domain.NameMergeAudit{
+		Id:            m.Id,
+		Canonical:     m.Canonical,
+		Names:         m.Names,
+		Subscriptions: m.Subscriptions,
+		MergedAt:      m.MergedAt,
+	}
+}
+
+// normalizeName folds the case, strips the diacritics and collapses the whitespace
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ICostCalculator counts the cost of the subscriptions visible in the context by user
+type ICostCalculator interface {
+	GetUserCosts(ctx context.Context, startDate, endDate string) (int, []domain.UserCost, error)
+}
+
+// OrganizationService manages the organizations, the tenants the subscriptions are scoped to
+type OrganizationService struct {
+	organizationRepo IOrganizationRepo
+	costs            ICostCalculator
+	metrics          IMetrics
+	tracer           trace.Tracer
+	logger           *slog.Logger
+	now              func() time.Time
+}
+
+func NewOrganizationService(organizationRepo IOrganizationRepo, costs ICostCalculator, metrics IMetrics, logger *slog.Logger) *OrganizationService {
+	return &OrganizationService{
+		organizationRepo: organizationRepo,
+		costs:            costs,
+		metrics:          metrics,
+		tracer:           otel.Tracer(tracerName),
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *OrganizationService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *OrganizationService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+func (s *OrganizationService) CreateOrganization(ctx context.Context, data *domain.OrganizationCreate) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.CreateOrganization")
+	defer span.End()
+
+	//A new organization is not created in the tenant of the request
+	ctx = tenant.Bypass(ctx)
+	now := s.now()
+	id, err := s.organizationRepo.CreateOrganization(ctx, &models.OrganizationCreate{Name: data.Name, CreatedAt: now})
+	if err != nil {
+		s.log(ctx).Error("OrganizationService.CreateOrganization:organizationRepo.CreateOrganization - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CreateOrganization", ErrInternal)
+	}
+	if data.UserId != nil {
+		err = s.organizationRepo.AddMember(ctx, &models.OrganizationMember{OrganizationId: id, UserId: *data.UserId, JoinedAt: now})
+		if err != nil {
+			s.log(ctx).Error("OrganizationService.CreateOrganization:organizationRepo.AddMember - Internal error", slog.String("error", err.Error()))
+			return 0, s.fail(ctx, "CreateOrganization", ErrInternal)
+		}
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("The organization id=%d has been created", id))
+	return id, nil
+}
+
+func (s *OrganizationService) GetOrganization(ctx context.Context, id int) (*domain.Organization, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.GetOrganization")
+	defer span.End()
+
+	model, err := s.organizationRepo.GetOrganization(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetOrganization", ErrNotFound)
+		}
+		s.log(ctx).Error("OrganizationService.GetOrganization:organizationRepo.GetOrganization - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetOrganization", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Organization id=%d received successfully", id))
+
+	return toOrganizationDomain(model), nil
+}
+
+func (s *OrganizationService) GetOrganizations(ctx context.Context, userId uuid.UUID) ([]*domain.Organization, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.GetOrganizations")
+	defer span.End()
+
+	//The organizations of the user are looked up across the tenants
+	list, err := s.organizationRepo.GetOrganizations(tenant.Bypass(ctx), userId)
+	if err != nil {
+		s.log(ctx).Error("OrganizationService.GetOrganizations:organizationRepo.GetOrganizations - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetOrganizations", ErrInternal)
+	}
+	organizations := make([]*domain.Organization, 0, len(list))
+	for _, m := range list {
+		organizations = append(organizations, toOrganizationDomain(m))
+	}
+	s.log(ctx).Info(fmt.Sprintf("All user userId=%s organizations were received successfully", userId.String()))
+
+	return organizations, nil
+}
+
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, data *domain.OrganizationUpdate) (*domain.Organization, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.UpdateOrganization")
+	defer span.End()
+
+	model, err := s.organizationRepo.UpdateOrganization(ctx, &models.OrganizationUpdate{Id: data.Id, Name: data.Name})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "UpdateOrganization", ErrNotFound)
+		}
+		s.log(ctx).Error("OrganizationService.UpdateOrganization:organizationRepo.UpdateOrganization - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "UpdateOrganization", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("The organization id=%d has been updated", data.Id))
+
+	return toOrganizationDomain(model), nil
+}
+
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id int) (*domain.Organization, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.DeleteOrganization")
+	defer span.End()
+
+	model, err := s.organizationRepo.DeleteOrganization(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "DeleteOrganization", ErrNotFound)
+		} else if errors.Is(err, repository.ErrInUse) {
+			return nil, s.fail(ctx, "DeleteOrganization", ErrOrganizationNotEmpty)
+		}
+		s.log(ctx).Error("OrganizationService.DeleteOrganization:organizationRepo.DeleteOrganization - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteOrganization", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("The organization id=%d has been deleted", id))
+
+	return toOrganizationDomain(model), nil
+}
+
+func (s *OrganizationService) AddMember(ctx context.Context, organizationId int, userId uuid.UUID) ([]domain.OrganizationMember, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.AddMember")
+	defer span.End()
+
+	err := s.organizationRepo.AddMember(ctx, &models.OrganizationMember{OrganizationId: organizationId, UserId: userId, JoinedAt: s.now()})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "AddMember", ErrNotFound)
+		}
+		s.log(ctx).Error("OrganizationService.AddMember:organizationRepo.AddMember - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "AddMember", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("The user userId=%s has joined the organization id=%d", userId.String(), organizationId))
+
+	return s.getMembers(ctx, "AddMember", organizationId)
+}
+
+func (s *OrganizationService) RemoveMember(ctx context.Context, organizationId int, userId uuid.UUID) ([]domain.OrganizationMember, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.RemoveMember")
+	defer span.End()
+
+	//The subscriptions of the user stay in the organization
+	err := s.organizationRepo.RemoveMember(ctx, organizationId, userId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "RemoveMember", ErrNotFound)
+		}
+		s.log(ctx).Error("OrganizationService.RemoveMember:organizationRepo.RemoveMember - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "RemoveMember", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("The user userId=%s has left the organization id=%d", userId.String(), organizationId))
+
+	return s.getMembers(ctx, "RemoveMember", organizationId)
+}
+
+func (s *OrganizationService) GetMembers(ctx context.Context, organizationId int) ([]domain.OrganizationMember, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.GetMembers")
+	defer span.End()
+
+	_, err := s.organizationRepo.GetOrganization(ctx, organizationId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetMembers", ErrNotFound)
+		}
+		s.log(ctx).Error("OrganizationService.GetMembers:organizationRepo.GetOrganization - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetMembers", ErrInternal)
+	}
+
+	return s.getMembers(ctx, "GetMembers", organizationId)
+}
+
+// GetOrganizationCost counts the cost of the organization's subscriptions in the period by the members paying it
+func (s *OrganizationService) GetOrganizationCost(ctx context.Context, filter *domain.OrganizationCostFilter) (*domain.OrganizationCost, error) {
+	ctx, span := s.tracer.Start(ctx, "OrganizationService.GetOrganizationCost")
+	defer span.End()
+
+	_, err := s.organizationRepo.GetOrganization(ctx, filter.OrganizationId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetOrganizationCost", ErrNotFound)
+		}
+		s.log(ctx).Error("OrganizationService.GetOrganizationCost:organizationRepo.GetOrganization - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetOrganizationCost", ErrInternal)
+	}
+
+	//Only the subscriptions of the organization are visible in its tenant
+	total, users, err := s.costs.GetUserCosts(tenant.WithOrganization(ctx, filter.OrganizationId), filter.StartDate, filter.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info(fmt.Sprintf("The cost of the organization id=%d has been counted", filter.OrganizationId))
+
+	return &domain.OrganizationCost{OrganizationId: filter.OrganizationId, Total: total, Users: users}, nil
+}
+
+func (s *OrganizationService) getMembers(ctx context.Context, method string, organizationId int) ([]domain.OrganizationMember, error) {
+	list, err := s.organizationRepo.GetMembers(ctx, organizationId)
+	if err != nil {
+		s.log(ctx).Error("OrganizationService."+method+":organizationRepo.GetMembers - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+	members := make([]domain.OrganizationMember, 0, len(list))
+	for _, m := range list {
+		members = append(members, domain.OrganizationMember{UserId: m.UserId, JoinedAt: m.JoinedAt})
+	}
+
+	return members, nil
+}
+
+// GetUserCosts counts the cost of the subscriptions visible in ctx in the period with monthly proration
+// and divides it between the users paying it
+func (s *SubscriptionService) GetUserCosts(ctx context.Context, startDate, endDate string) (int, []domain.UserCost, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetUserCosts")
+	defer span.End()
+
+	//Both ends are included, months cover all their days
+	from, _ := parseDate(startDate, false)
+	to, _ := parseDate(endDate, true)
+	if to.Before(from) {
+		return 0, nil, s.fail(ctx, "GetUserCosts", ErrIncorrectTime)
+	}
+
+	subs, err := s.subscriptionRepo.GetByPeriod(ctx, &models.SubscriptionFilter{From: from, To: to})
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.GetUserCosts:subscriptionRepo.GetByPeriod - Internal error", slog.String("error", err.Error()))
+		return 0, nil, s.fail(ctx, "GetUserCosts", ErrInternal)
+	}
+	pauses, err := s.getPauses(ctx, "GetUserCosts", subs)
+	if err != nil {
+		return 0, nil, err
+	}
+	changes, err := s.getPriceChanges(ctx, "GetUserCosts", subs)
+	if err != nil {
+		return 0, nil, err
+	}
+	splits, err := s.getSplits(ctx, "GetUserCosts", subs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	total, users := userCosts(subs, pauses, changes, splits, from, to)
+	return total, users, nil
+}
+
+// userCosts sums what every user pays for the subscriptions billed with monthly proration after the splits
+func userCosts(subs []*models.Subscription, pauses map[int][]*models.Pause, changes map[int][]*models.PriceChange, splits map[int]*models.SubscriptionSplit, from, to time.Time) (int, []domain.UserCost) {
+	var total int
+	costs := make(map[uuid.UUID]int)
+	for _, sub := range subs {
+		item := bill(sub, pauses[sub.Id], changes[sub.Id], from, to, domain.ProrationMonthly)
+		total += item.Cost
+		for userId, cost := range splitCost(sub, splits[sub.Id], item.Cost) {
+			costs[userId] += cost
+		}
+	}
+
+	users := make([]domain.UserCost, 0, len(costs))
+	for userId, cost := range costs {
+		users = append(users, domain.UserCost{UserId: userId, Cost: cost})
+	}
+	slices.SortFunc(users, func(a, b domain.UserCost) int { return strings.Compare(a.UserId.String(), b.UserId.String()) })
+	return total, users
+}
+
+// checkMember rejects a subscription of a user who is not a member of the organization of ctx
+func (s *SubscriptionService) checkMember(ctx context.Context, method string, userId uuid.UUID) error {
+	organizationId, ok := tenant.Organization(ctx)
+	if !ok {
+		return nil
+	}
+	member, err := s.organizationRepo.IsMember(ctx, organizationId, userId)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":organizationRepo.IsMember - Internal error", slog.String("error", err.Error()))
+		return s.fail(ctx, method, ErrInternal)
+	}
+	if !member {
+		return s.fail(ctx, method, ErrNotMember)
+	}
+	return nil
+}
+
+func toOrganizationDomain(m *models.Organization) *domain.Organization {
+	return &domain.Organization{
+		Id:        m.Id,
+		Name:      m.Name,
+		CreatedAt: m.CreatedAt,
+	}
+}
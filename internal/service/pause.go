@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+)
+
+func (s *SubscriptionService) Pause(ctx context.Context, data *domain.PauseCreate) (*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.Pause")
+	defer span.End()
+
+	//The dates are validated by the handler
+	pause := &models.PauseCreate{SubscriptionId: data.SubscriptionId, StartDate: s.today()}
+	if data.StartDate != nil {
+		pause.StartDate, _ = parseDate(*data.StartDate, false)
+	}
+	if data.EndDate != nil {
+		endDate, _ := parseDate(*data.EndDate, true)
+		pause.EndDate = sql.NullTime{Time: endDate, Valid: true}
+	}
+	if pause.EndDate.Valid && pause.EndDate.Time.Before(pause.StartDate) {
+		return nil, s.fail(ctx, "Pause", ErrIncorrectTime)
+	}
+
+	model, pauses, err := s.getWithPauses(ctx, "Pause", data.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	if !pauseInPeriod(pause.StartDate, pause.EndDate, model.StartDate, model.EndDate) {
+		return nil, s.fail(ctx, "Pause", ErrPauseOutsidePeriod)
+	}
+	for _, p := range pauses {
+		if pausesOverlap(pause.StartDate, pause.EndDate, p.StartDate, p.EndDate) {
+			return nil, s.fail(ctx, "Pause", ErrPauseOverlap)
+		}
+	}
+
+	split, err := s.getSplit(ctx, "Pause", data.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.subscriptionRepo.CreatePause(ctx, pause)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "Pause", ErrNotFound)
+		}
+		//A concurrent pause was created after the check above
+		if errors.Is(err, repository.ErrOverlap) {
+			return nil, s.fail(ctx, "Pause", ErrPauseOverlap)
+		}
+		s.log(ctx).Error("SubscriptionService.Pause:subscriptionRepo.CreatePause - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "Pause", ErrInternal)
+	}
+	s.changed(ctx, splitUsers(model.UserId, split)...)
+	pauses = append(pauses, &models.Pause{Id: id, SubscriptionId: pause.SubscriptionId, StartDate: pause.StartDate, EndDate: pause.EndDate})
+	sortPauses(pauses)
+
+	s.log(ctx).Info(fmt.Sprintf("Subscription id=%d paused from %s", data.SubscriptionId, formatDate(pause.StartDate)))
+
+	subscription := s.toDomain(model, pauses)
+	if err := s.loadTags(ctx, "Pause", []*domain.Subscription{subscription}); err != nil {
+		return nil, err
+	}
+	if err := s.loadSplits(ctx, "Pause", []*domain.Subscription{subscription}); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *SubscriptionService) Resume(ctx context.Context, data *domain.Resume) (*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.Resume")
+	defer span.End()
+
+	//The date is validated by the handler
+	date := s.today()
+	if data.Date != nil {
+		date, _ = parseDate(*data.Date, false)
+	}
+
+	model, pauses, err := s.getWithPauses(ctx, "Resume", data.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	pause := pauseAt(pauses, date)
+	if pause == nil {
+		return nil, s.fail(ctx, "Resume", ErrNotPaused)
+	}
+	//The subscription is billed again from the resume date
+	endDate := date.AddDate(0, 0, -1)
+	if endDate.Before(pause.StartDate) {
+		return nil, s.fail(ctx, "Resume", ErrIncorrectTime)
+	}
+	if !pauseInPeriod(pause.StartDate, sql.NullTime{Time: endDate, Valid: true}, model.StartDate, model.EndDate) {
+		return nil, s.fail(ctx, "Resume", ErrPauseOutsidePeriod)
+	}
+
+	split, err := s.getSplit(ctx, "Resume", data.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	ended, err := s.subscriptionRepo.EndPause(ctx, pause.Id, endDate)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "Resume", ErrNotPaused)
+		}
+		if errors.Is(err, repository.ErrIncorrectTime) {
+			return nil, s.fail(ctx, "Resume", ErrIncorrectTime)
+		}
+		s.log(ctx).Error("SubscriptionService.Resume:subscriptionRepo.EndPause - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "Resume", ErrInternal)
+	}
+	*pause = *ended
+	s.changed(ctx, splitUsers(model.UserId, split)...)
+
+	s.log(ctx).Info(fmt.Sprintf("Subscription id=%d resumed on %s", data.SubscriptionId, formatDate(date)))
+
+	subscription := s.toDomain(model, pauses)
+	if err := s.loadTags(ctx, "Resume", []*domain.Subscription{subscription}); err != nil {
+		return nil, err
+	}
+	if err := s.loadSplits(ctx, "Resume", []*domain.Subscription{subscription}); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// getWithPauses returns the subscription and its pauses
+func (s *SubscriptionService) getWithPauses(ctx context.Context, method string, id int) (*models.Subscription, []*models.Pause, error) {
+	model, err := s.subscriptionRepo.GetById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, s.fail(ctx, method, ErrNotFound)
+		}
+		s.log(ctx).Error("SubscriptionService."+method+":subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	pauses, err := s.subscriptionRepo.GetPauses(ctx, []int{id})
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":subscriptionRepo.GetPauses - Internal error", slog.String("error", err.Error()))
+		return nil, nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	return model, pauses, nil
+}
+
+// pauseInPeriod reports whether the pause starts and ends within the subscription period.
+// A pause until resumed may outlast the subscription, it ends with it.
+func pauseInPeriod(start time.Time, end sql.NullTime, periodStart time.Time, periodEnd sql.NullTime) bool {
+	if start.Before(periodStart) {
+		return false
+	}
+	if !periodEnd.Valid {
+		return true
+	}
+	return !start.After(periodEnd.Time) && (!end.Valid || !end.Time.After(periodEnd.Time))
+}
+
+// pausesOverlap reports whether two pauses share a day, a pause without the end date lasts forever
+func pausesOverlap(aStart time.Time, aEnd sql.NullTime, bStart time.Time, bEnd sql.NullTime) bool {
+	return (!aEnd.Valid || !bStart.After(aEnd.Time)) && (!bEnd.Valid || !aStart.After(bEnd.Time))
+}
+
+func sortPauses(pauses []*models.Pause) {
+	slices.SortFunc(pauses, func(a, b *models.Pause) int {
+		return a.StartDate.Compare(b.StartDate)
+	})
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+)
+
+// SchedulePriceChange sets the price the subscription is charged from the effective date on
+func (s *SubscriptionService) SchedulePriceChange(ctx context.Context, data *domain.PriceChangeCreate) (*domain.PriceChange, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.SchedulePriceChange")
+	defer span.End()
+
+	//The date is validated by the handler
+	effectiveDate, _ := parseDate(data.EffectiveDate, false)
+
+	model, err := s.subscriptionRepo.GetById(ctx, data.SubscriptionId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "SchedulePriceChange", ErrNotFound)
+		}
+		s.log(ctx).Error("SubscriptionService.SchedulePriceChange:subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "SchedulePriceChange", ErrInternal)
+	}
+	if effectiveDate.Before(s.today()) || effectiveDate.Before(model.StartDate) ||
+		(model.EndDate.Valid && effectiveDate.After(model.EndDate.Time)) {
+		return nil, s.fail(ctx, "SchedulePriceChange", ErrPriceChangeOutsidePeriod)
+	}
+
+	split, err := s.getSplit(ctx, "SchedulePriceChange", data.SubscriptionId)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.subscriptionRepo.CreatePriceChange(ctx, &models.PriceChangeCreate{
+		SubscriptionId: data.SubscriptionId,
+		EffectiveDate:  effectiveDate,
+		Price:          data.Price,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "SchedulePriceChange", ErrNotFound)
+		}
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "SchedulePriceChange", ErrPriceChangeExists)
+		}
+		s.log(ctx).Error("SubscriptionService.SchedulePriceChange:subscriptionRepo.CreatePriceChange - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "SchedulePriceChange", ErrInternal)
+	}
+	s.changed(ctx, splitUsers(model.UserId, split)...)
+
+	s.log(ctx).Info(fmt.Sprintf("Price of the subscription id=%d changes to %d on %s", data.SubscriptionId, data.Price, formatDate(effectiveDate)))
+
+	return &domain.PriceChange{
+		Id:            id,
+		EffectiveDate: formatDate(effectiveDate),
+		Price:         data.Price,
+	}, nil
+}
+
+// GetPriceChanges returns the price changes of the subscription by effective date
+func (s *SubscriptionService) GetPriceChanges(ctx context.Context, subscriptionId int) ([]*domain.PriceChange, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetPriceChanges")
+	defer span.End()
+
+	model, err := s.subscriptionRepo.GetById(ctx, subscriptionId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetPriceChanges", ErrNotFound)
+		}
+		s.log(ctx).Error("SubscriptionService.GetPriceChanges:subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetPriceChanges", ErrInternal)
+	}
+
+	changes, err := s.getPriceChanges(ctx, "GetPriceChanges", []*models.Subscription{model})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*domain.PriceChange, 0, len(changes[model.Id]))
+	for _, c := range changes[model.Id] {
+		res = append(res, &domain.PriceChange{
+			Id:            c.Id,
+			EffectiveDate: formatDate(c.EffectiveDate),
+			Price:         c.Price,
+		})
+	}
+	return res, nil
+}
+
+// getPriceChanges returns the price changes of the subscriptions by subscription ID, ordered by effective date
+func (s *SubscriptionService) getPriceChanges(ctx context.Context, method string, subs []*models.Subscription) (map[int][]*models.PriceChange, error) {
+	ids := make([]int, 0, len(subs))
+	for _, m := range subs {
+		ids = append(ids, m.Id)
+	}
+
+	changes, err := s.subscriptionRepo.GetPriceChanges(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":subscriptionRepo.GetPriceChanges - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	res := make(map[int][]*models.PriceChange, len(subs))
+	for _, c := range changes {
+		res[c.SubscriptionId] = append(res[c.SubscriptionId], c)
+	}
+	return res, nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+)
+
+// SetSplit shares the subscription between its owner and the members or stops sharing it without members
+func (s *SubscriptionService) SetSplit(ctx context.Context, data *domain.SplitUpdate) (*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.SetSplit")
+	defer span.End()
+
+	model, err := s.subscriptionRepo.GetById(ctx, data.SubscriptionId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "SetSplit", ErrNotFound)
+		}
+		s.log(ctx).Error("SubscriptionService.SetSplit:subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "SetSplit", ErrInternal)
+	}
+	if !validSplit(data.Rule, data.Members, model.UserId, model.Price) {
+		return nil, s.fail(ctx, "SetSplit", ErrIncorrectSplit)
+	}
+	//The members pay for the subscription like its owner, so they must be able to own one
+	for _, m := range data.Members {
+		if err := s.checkUser(ctx, "SetSplit", m.UserId); err != nil {
+			return nil, err
+		}
+		if err := s.checkMember(ctx, "SetSplit", m.UserId); err != nil {
+			return nil, err
+		}
+	}
+
+	//The former members are notified as well, their costs change
+	previous, err := s.getSplits(ctx, "SetSplit", []*models.Subscription{model})
+	if err != nil {
+		return nil, err
+	}
+
+	split := &models.SubscriptionSplit{SubscriptionId: data.SubscriptionId, Rule: string(data.Rule)}
+	for _, m := range data.Members {
+		split.Members = append(split.Members, models.SubscriptionMember{UserId: m.UserId, Share: nullInt(m.Share)})
+	}
+	err = s.splitRepo.SetSplit(ctx, split)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "SetSplit", ErrNotFound)
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "SetSplit", ErrIncorrectSplit)
+		}
+		s.log(ctx).Error("SubscriptionService.SetSplit:splitRepo.SetSplit - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "SetSplit", ErrInternal)
+	}
+
+	s.changed(ctx, splitUsers(model.UserId, previous[model.Id], split)...)
+
+	s.log(ctx).Info(fmt.Sprintf("Subscription id=%d is shared with %d members", data.SubscriptionId, len(split.Members)))
+
+	subscriptions, err := s.withDetails(ctx, "SetSplit", []*models.Subscription{model})
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions[0], nil
+}
+
+// GetDebts returns what the members of the shared subscriptions owe their owners in the period.
+// Debts between two users in both directions are netted, the pairs are ordered by the debtor and the creditor.
+func (s *SubscriptionService) GetDebts(ctx context.Context, filter *domain.DebtFilter) ([]domain.Debt, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetDebts")
+	defer span.End()
+
+	//Both ends are included, months cover all their days
+	from, _ := parseDate(filter.StartDate, false)
+	to, _ := parseDate(filter.EndDate, true)
+	if to.Before(from) {
+		return nil, s.fail(ctx, "GetDebts", ErrIncorrectTime)
+	}
+
+	subs, err := s.subscriptionRepo.GetByPeriod(ctx, &models.SubscriptionFilter{
+		UserId:  filter.UserId,
+		Members: true,
+		Shared:  true,
+		From:    from,
+		To:      to,
+	})
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.GetDebts:subscriptionRepo.GetByPeriod - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetDebts", ErrInternal)
+	}
+	pauses, err := s.getPauses(ctx, "GetDebts", subs)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.getPriceChanges(ctx, "GetDebts", subs)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.getSplits(ctx, "GetDebts", subs)
+	if err != nil {
+		return nil, err
+	}
+
+	var debts []domain.Debt
+	for _, d := range owed(subs, pauses, changes, splits, from, to) {
+		if filter.UserId == nil || d.From == *filter.UserId || d.To == *filter.UserId {
+			debts = append(debts, d)
+		}
+	}
+	s.log(ctx).Info(fmt.Sprintf("Found %d debts for the period from %s to %s", len(debts), filter.StartDate, filter.EndDate))
+
+	return debts, nil
+}
+
+// owed nets what the members owe the owners for the subscriptions billed with monthly proration
+func owed(subs []*models.Subscription, pauses map[int][]*models.Pause, changes map[int][]*models.PriceChange, splits map[int]*models.SubscriptionSplit, from, to time.Time) []domain.Debt {
+	type pair struct{ from, to uuid.UUID }
+	amounts := make(map[pair]int)
+	for _, sub := range subs {
+		split := splits[sub.Id]
+		if split == nil {
+			continue
+		}
+		item := bill(sub, pauses[sub.Id], changes[sub.Id], from, to, domain.ProrationMonthly)
+		for userId, cost := range splitCost(sub, split, item.Cost) {
+			if userId != sub.UserId {
+				amounts[pair{userId, sub.UserId}] += cost
+			}
+		}
+	}
+
+	var debts []domain.Debt
+	for p, amount := range amounts {
+		net := amount - amounts[pair{p.to, p.from}]
+		if net > 0 {
+			debts = append(debts, domain.Debt{From: p.from, To: p.to, Amount: net})
+		}
+	}
+	slices.SortFunc(debts, func(a, b domain.Debt) int {
+		if c := strings.Compare(a.From.String(), b.From.String()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.To.String(), b.To.String())
+	})
+	return debts
+}
+
+// splitCost divides the cost of the subscription between its owner and the members of the split.
+// The members pay their shares rounded down and the owner pays the rest. A percentage is a share of the cost,
+// a fixed amount is a share of the monthly price. If the shares exceed the cost they are reduced in proportion.
+func splitCost(sub *models.Subscription, split *models.SubscriptionSplit, cost int) map[uuid.UUID]int {
+	res := map[uuid.UUID]int{sub.UserId: cost}
+	if split == nil || len(split.Members) == 0 {
+		return res
+	}
+
+	//Every member pays cost*share/whole
+	whole := 0
+	for _, m := range split.Members {
+		switch domain.SplitRule(split.Rule) {
+		case domain.SplitEqual:
+			whole++
+		default:
+			whole += int(m.Share.Int32)
+		}
+	}
+	switch domain.SplitRule(split.Rule) {
+	case domain.SplitEqual:
+		//The owner has a share as well
+		whole++
+	case domain.SplitPercentage:
+		whole = max(whole, 100)
+	case domain.SplitFixed:
+		whole = max(whole, sub.Price)
+	}
+	if whole == 0 {
+		return res
+	}
+
+	for _, m := range split.Members {
+		share := 1
+		if domain.SplitRule(split.Rule) != domain.SplitEqual {
+			share = int(m.Share.Int32)
+		}
+		res[m.UserId] = cost * share / whole
+		res[sub.UserId] -= res[m.UserId]
+	}
+	return res
+}
+
+// shareOf returns the part of the subscription's cost paid by the user
+func shareOf(sub *models.Subscription, split *models.SubscriptionSplit, userId *uuid.UUID, cost int) int {
+	if userId == nil {
+		return cost
+	}
+	return splitCost(sub, split, cost)[*userId]
+}
+
+// validSplit checks the members and their shares against the rule. The owner can't be a member,
+// the percentages can't add up to more than 100 and the fixed amounts to more than the price.
+func validSplit(rule domain.SplitRule, members []domain.Member, owner uuid.UUID, price int) bool {
+	total := 0
+	for _, m := range members {
+		if m.UserId == owner {
+			return false
+		}
+		switch rule {
+		case domain.SplitEqual:
+			if m.Share != nil {
+				return false
+			}
+		case domain.SplitPercentage:
+			if m.Share == nil || *m.Share < 1 {
+				return false
+			}
+			total += *m.Share
+		case domain.SplitFixed:
+			if m.Share == nil || *m.Share < 0 {
+				return false
+			}
+			total += *m.Share
+		default:
+			return false
+		}
+	}
+	switch rule {
+	case domain.SplitPercentage:
+		return total <= 100
+	case domain.SplitFixed:
+		return total <= price
+	}
+	return true
+}
+
+// toDomainSplit converts the split of a shared subscription, nil if it is not shared
+func toDomainSplit(split *models.SubscriptionSplit) *domain.Split {
+	if split == nil {
+		return nil
+	}
+	res := &domain.Split{Rule: domain.SplitRule(split.Rule)}
+	for _, m := range split.Members {
+		res.Members = append(res.Members, domain.Member{UserId: m.UserId, Share: intPtr(m.Share)})
+	}
+	return res
+}
+
+// splitUsers returns the owner and the members of the splits without duplicates, their costs change with the subscription
+func splitUsers(owner uuid.UUID, splits ...*models.SubscriptionSplit) []uuid.UUID {
+	users := []uuid.UUID{owner}
+	for _, split := range splits {
+		if split == nil {
+			continue
+		}
+		for _, m := range split.Members {
+			users = append(users, m.UserId)
+		}
+	}
+	slices.SortFunc(users, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	return slices.Compact(users)
+}
+
+// getSplits returns the splits of the shared subscriptions by subscription ID
+func (s *SubscriptionService) getSplits(ctx context.Context, method string, subs []*models.Subscription) (map[int]*models.SubscriptionSplit, error) {
+	ids := make([]int, 0, len(subs))
+	for _, m := range subs {
+		ids = append(ids, m.Id)
+	}
+
+	splits, err := s.splitRepo.GetSplits(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":splitRepo.GetSplits - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	res := make(map[int]*models.SubscriptionSplit, len(splits))
+	for _, split := range splits {
+		res[split.SubscriptionId] = split
+	}
+	return res, nil
+}
+
+// loadSplits sets the splits of the shared subscriptions
+func (s *SubscriptionService) loadSplits(ctx context.Context, method string, subscriptions []*domain.Subscription) error {
+	ids := make([]int, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.Id)
+	}
+
+	splits, err := s.splitRepo.GetSplits(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":splitRepo.GetSplits - Internal error", slog.String("error", err.Error()))
+		return s.fail(ctx, method, ErrInternal)
+	}
+
+	byId := make(map[int]*domain.Split, len(splits))
+	for _, split := range splits {
+		byId[split.SubscriptionId] = toDomainSplit(split)
+	}
+	for _, subscription := range subscriptions {
+		subscription.Split = byId[subscription.Id]
+	}
+	return nil
+}
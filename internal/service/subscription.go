@@ -8,145 +8,450 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/Estriper0/subscription_service/internal/logger"
 	"github.com/Estriper0/subscription_service/internal/repository"
 	"github.com/Estriper0/subscription_service/internal/repository/models"
 	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/Estriper0/subscription_service/internal/tenant"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "github.com/Estriper0/subscription_service/internal/service"
+
 type ISubscriptionRepo interface {
 	Create(ctx context.Context, s *models.SubscriptionCreate) (int, error)
 	GetById(ctx context.Context, id int) (*models.Subscription, error)
 	GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int) ([]*models.Subscription, error)
 	DeleteById(ctx context.Context, id int) (*models.Subscription, error)
 	Update(ctx context.Context, s *models.SubscriptionUpdate) (*models.Subscription, error)
-	GetPriceByFilter(ctx context.Context, user_id *uuid.UUID, service_name *string, start_date, end_date time.Time) (int, error)
+	GetByPeriod(ctx context.Context, filter *models.SubscriptionFilter) ([]*models.Subscription, error)
 	GetAll(ctx context.Context, offset, limit int) ([]*models.Subscription, error)
+	CountActive(ctx context.Context, at time.Time) (int, error)
+	CreatePause(ctx context.Context, p *models.PauseCreate) (int, error)
+	EndPause(ctx context.Context, id int, endDate time.Time) (*models.Pause, error)
+	//Pauses of the subscriptions ordered by subscription ID and start date
+	GetPauses(ctx context.Context, subscriptionIds []int) ([]*models.Pause, error)
+	CreatePriceChange(ctx context.Context, p *models.PriceChangeCreate) (int, error)
+	//Price changes of the subscriptions ordered by subscription ID and effective date
+	GetPriceChanges(ctx context.Context, subscriptionIds []int) ([]*models.PriceChange, error)
+	Cancel(ctx context.Context, c *models.SubscriptionCancel) (*models.Subscription, error)
+	//Subscriptions matching the filter ordered by ID
+	GetByState(ctx context.Context, filter *models.StateFilter) ([]*models.Subscription, error)
+	GetPageByState(ctx context.Context, filter *models.StateFilter, offset, limit int) ([]*models.Subscription, error)
+}
+
+type ICatalogRepo interface {
+	CreateService(ctx context.Context, s *models.ServiceCreate) (int, error)
+	GetService(ctx context.Context, id int) (*models.Service, error)
+	//The service with the name or alias regardless of case
+	FindService(ctx context.Context, name string) (*models.Service, error)
+	GetServices(ctx context.Context, offset, limit int) ([]*models.Service, error)
+	UpdateService(ctx context.Context, s *models.ServiceUpdate) (*models.Service, error)
+	DeleteService(ctx context.Context, id int) (*models.Service, error)
+	//Links the subscriptions without a service whose name is one of names regardless of case
+	LinkSubscriptions(ctx context.Context, serviceId int, names []string) (int, error)
+	CreatePlan(ctx context.Context, p *models.PlanCreate) (int, error)
+	GetPlan(ctx context.Context, id int) (*models.Plan, error)
+	//Plans of the services ordered by service ID and plan ID
+	GetPlans(ctx context.Context, serviceIds []int) ([]*models.Plan, error)
+	UpdatePlan(ctx context.Context, p *models.PlanUpdate) (*models.Plan, error)
+	DeletePlan(ctx context.Context, id int) (*models.Plan, error)
+}
+
+type ICategoryRepo interface {
+	CreateCategory(ctx context.Context, c *models.CategoryCreate) (int, error)
+	GetCategory(ctx context.Context, id int) (*models.Category, error)
+	//The built-in categories and the user's ones ordered by ID, all categories without a user
+	GetCategories(ctx context.Context, userId *uuid.UUID) ([]*models.Category, error)
+	UpdateCategory(ctx context.Context, c *models.CategoryUpdate) (*models.Category, error)
+	//Deletes the subcategories as well, the subscriptions and services lose the category
+	DeleteCategory(ctx context.Context, id int) (*models.Category, error)
+	//Categories of the services that have one ordered by service ID
+	GetServiceCategories(ctx context.Context, serviceIds []int) ([]*models.ServiceCategory, error)
+}
+
+type ITagRepo interface {
+	CreateTag(ctx context.Context, t *models.TagCreate) (int, error)
+	GetTag(ctx context.Context, id int) (*models.Tag, error)
+	//Tags of the user ordered by name regardless of case
+	GetTags(ctx context.Context, userId uuid.UUID) ([]*models.Tag, error)
+	UpdateTag(ctx context.Context, t *models.TagUpdate) (*models.Tag, error)
+	//Removes the tag from the subscriptions as well
+	DeleteTag(ctx context.Context, id int) (*models.Tag, error)
+	//Replaces the tags of the subscription, the missing tags of its user are created
+	SetSubscriptionTags(ctx context.Context, subscriptionId int, names []string) error
+	//Tags of the subscriptions ordered by subscription ID and name regardless of case
+	GetSubscriptionTags(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionTag, error)
+}
+
+type IBudgetRepo interface {
+	CreateBudget(ctx context.Context, b *models.BudgetCreate) (int, error)
+	GetBudget(ctx context.Context, id int) (*models.Budget, error)
+	//Budgets of the user ordered by ID
+	GetBudgets(ctx context.Context, userId uuid.UUID) ([]*models.Budget, error)
+	UpdateBudget(ctx context.Context, b *models.BudgetUpdate) (*models.Budget, error)
+	//Deletes the alerts of the budget as well
+	DeleteBudget(ctx context.Context, id int) (*models.Budget, error)
+	//Fails with repository.ErrAlreadyExists if the alert of the month and threshold has been raised
+	CreateBudgetAlert(ctx context.Context, a *models.BudgetAlertCreate) (*models.BudgetAlert, error)
+	//Alerts of the user's budgets ordered by ID
+	GetBudgetAlerts(ctx context.Context, userId uuid.UUID) ([]*models.BudgetAlert, error)
+}
+
+type IInsightRepo interface {
+	//Keeps the first dismissal of the insight
+	DismissInsight(ctx context.Context, d *models.InsightDismissal) error
+	//Dismissals of the user ordered by insight ID
+	GetInsightDismissals(ctx context.Context, userId uuid.UUID) ([]*models.InsightDismissal, error)
+}
+
+type INameRepo interface {
+	//Distinct service names of the subscriptions ordered by name
+	GetServiceNames(ctx context.Context) ([]*models.ServiceName, error)
+	//Pairs of the names with the trigram similarity of at least threshold
+	SimilarNames(ctx context.Context, names []string, threshold float64) ([]*models.NamePair, error)
+	//Renames the subscriptions and records the merge in one transaction
+	MergeServiceNames(ctx context.Context, m *models.NameMerge) (*models.NameMergeAudit, error)
+	GetNameMerges(ctx context.Context, offset, limit int) ([]*models.NameMergeAudit, error)
+}
+
+type ISplitRepo interface {
+	//Replaces the split of the subscription, no members delete it
+	SetSplit(ctx context.Context, s *models.SubscriptionSplit) error
+	//Splits of the shared subscriptions ordered by subscription ID with the members ordered by user ID
+	GetSplits(ctx context.Context, subscriptionIds []int) ([]*models.SubscriptionSplit, error)
+}
+
+type IOrganizationRepo interface {
+	CreateOrganization(ctx context.Context, o *models.OrganizationCreate) (int, error)
+	GetOrganization(ctx context.Context, id int) (*models.Organization, error)
+	//Organizations of the member ordered by ID
+	GetOrganizations(ctx context.Context, userId uuid.UUID) ([]*models.Organization, error)
+	UpdateOrganization(ctx context.Context, o *models.OrganizationUpdate) (*models.Organization, error)
+	//Deletes the members as well, fails with repository.ErrInUse while the organization has subscriptions
+	DeleteOrganization(ctx context.Context, id int) (*models.Organization, error)
+	//Keeps the member if the user has already joined
+	AddMember(ctx context.Context, m *models.OrganizationMember) error
+	RemoveMember(ctx context.Context, organizationId int, userId uuid.UUID) error
+	//Members ordered by user ID
+	GetMembers(ctx context.Context, organizationId int) ([]*models.OrganizationMember, error)
+	IsMember(ctx context.Context, organizationId int, userId uuid.UUID) (bool, error)
+}
+
+type IUserRepo interface {
+	//Fails with repository.ErrAlreadyExists if the ID or the email is taken
+	CreateUser(ctx context.Context, u *models.UserCreate) error
+	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	//Users ordered by ID
+	GetUsers(ctx context.Context, offset, limit int) ([]*models.User, error)
+	//Fails with repository.ErrNotFound for an anonymized user
+	UpdateUser(ctx context.Context, u *models.UserUpdate) (*models.User, error)
+	//Deletes the user with the subscriptions and the rest of the user's data
+	DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	//Erases the name, the email and the notes of the subscriptions, deletes the rest of the user's data
+	//but the subscriptions. Fails with repository.ErrNotFound for an anonymized user
+	AnonymizeUser(ctx context.Context, id uuid.UUID, at time.Time) (*models.User, error)
+}
+
+type IMetrics interface {
+	IncServiceError(method, reason string)
 }
 
 type SubscriptionService struct {
 	subscriptionRepo ISubscriptionRepo
+	catalogRepo      ICatalogRepo
+	categoryRepo     ICategoryRepo
+	tagRepo          ITagRepo
+	splitRepo        ISplitRepo
+	organizationRepo IOrganizationRepo
+	userRepo         IUserRepo
+	metrics          IMetrics
+	tracer           trace.Tracer
 	logger           *slog.Logger
+	now              func() time.Time
+	//Called after a subscription of the user is created or updated
+	onChange []func(ctx context.Context, userId uuid.UUID)
 }
 
-func NewSubscriptionService(subscriptionRepo ISubscriptionRepo, logger *slog.Logger) *SubscriptionService {
+func NewSubscriptionService(subscriptionRepo ISubscriptionRepo, catalogRepo ICatalogRepo, categoryRepo ICategoryRepo, tagRepo ITagRepo, splitRepo ISplitRepo, organizationRepo IOrganizationRepo, userRepo IUserRepo, metrics IMetrics, logger *slog.Logger) *SubscriptionService {
 	return &SubscriptionService{
 		subscriptionRepo: subscriptionRepo,
+		catalogRepo:      catalogRepo,
+		categoryRepo:     categoryRepo,
+		tagRepo:          tagRepo,
+		splitRepo:        splitRepo,
+		organizationRepo: organizationRepo,
+		userRepo:         userRepo,
+		metrics:          metrics,
+		tracer:           otel.Tracer(tracerName),
 		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// OnChange registers a function called after a subscription owned by or shared with the user is created, updated, paused, resumed, cancelled or deleted
+func (s *SubscriptionService) OnChange(fn func(ctx context.Context, userId uuid.UUID)) {
+	s.onChange = append(s.onChange, fn)
+}
+
+// changed calls the functions registered by OnChange for every user
+func (s *SubscriptionService) changed(ctx context.Context, userIds ...uuid.UUID) {
+	for _, userId := range userIds {
+		for _, fn := range s.onChange {
+			fn(ctx, userId)
+		}
+	}
+}
+
+// getSplit returns the split of the subscription before it changes, nil if it is not shared
+func (s *SubscriptionService) getSplit(ctx context.Context, method string, id int) (*models.SubscriptionSplit, error) {
+	splits, err := s.getSplits(ctx, method, []*models.Subscription{{Id: id}})
+	if err != nil {
+		return nil, err
 	}
+	return splits[id], nil
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *SubscriptionService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *SubscriptionService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+// recordError counts the error by its reason and marks the span failed if the error is internal
+func recordError(ctx context.Context, metrics IMetrics, method string, err error) error {
+	reason := "internal"
+	switch {
+	case errors.Is(err, ErrNotFound):
+		reason = "not_found"
+	case errors.Is(err, ErrIncorrectTime):
+		reason = "incorrect_time"
+	case errors.Is(err, ErrPauseOutsidePeriod):
+		reason = "pause_outside_period"
+	case errors.Is(err, ErrPauseOverlap):
+		reason = "pause_overlap"
+	case errors.Is(err, ErrNotPaused):
+		reason = "not_paused"
+	case errors.Is(err, ErrAlreadyEnded):
+		reason = "already_ended"
+	case errors.Is(err, ErrPriceChangeOutsidePeriod):
+		reason = "price_change_outside_period"
+	case errors.Is(err, ErrPriceChangeExists):
+		reason = "price_change_exists"
+	case errors.Is(err, ErrAlreadyExists):
+		reason = "already_exists"
+	case errors.Is(err, ErrUnknownService):
+		reason = "unknown_service"
+	case errors.Is(err, ErrUnknownPlan):
+		reason = "unknown_plan"
+	case errors.Is(err, ErrPlanMismatch):
+		reason = "plan_mismatch"
+	case errors.Is(err, ErrUnknownCategory):
+		reason = "unknown_category"
+	case errors.Is(err, ErrBuiltInCategory):
+		reason = "builtin_category"
+	case errors.Is(err, ErrCategoryCycle):
+		reason = "category_cycle"
+	case errors.Is(err, ErrBudgetExists):
+		reason = "budget_exists"
+	case errors.Is(err, ErrIncorrectSplit):
+		reason = "incorrect_split"
+	case errors.Is(err, ErrNotMember):
+		reason = "not_member"
+	case errors.Is(err, ErrOrganizationNotEmpty):
+		reason = "organization_not_empty"
+	case errors.Is(err, ErrUnknownUser):
+		reason = "unknown_user"
+	case errors.Is(err, ErrUserExists):
+		reason = "user_exists"
+	}
+	metrics.IncServiceError(method, reason)
+
+	if reason == "internal" {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
 }
 
 func (s *SubscriptionService) Create(ctx context.Context, subscription *domain.SubscriptionCreate) (int, error) {
-	startDate, _ := time.Parse("01-2006", subscription.StartDate)
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.Create")
+	defer span.End()
+
+	if err := s.checkUser(ctx, "Create", subscription.UserId); err != nil {
+		return 0, err
+	}
+	if err := s.checkMember(ctx, "Create", subscription.UserId); err != nil {
+		return 0, err
+	}
+
+	//The dates are validated by the handler
+	startDate, _ := parseDate(subscription.StartDate, false)
 	model := &models.SubscriptionCreate{
 		ServiceName: subscription.ServiceName,
-		Price:       subscription.Price,
 		UserId:      subscription.UserId,
 		StartDate:   startDate,
+		BillingDay:  startDate.Day(),
+	}
+	plan, err := s.linkCatalog(ctx, "Create", model, subscription.ServiceId, subscription.PlanId)
+	if err != nil {
+		return 0, err
+	}
+	//The handler requires the price without a plan
+	if subscription.Price != nil {
+		model.Price = *subscription.Price
+	} else if plan != nil {
+		model.Price = monthlyPrice(plan)
+	}
+	if subscription.BillingDay != nil {
+		model.BillingDay = *subscription.BillingDay
+	}
+	if subscription.CategoryId != nil {
+		if err := s.checkCategory(ctx, "Create", *subscription.CategoryId, subscription.UserId); err != nil {
+			return 0, err
+		}
+		model.CategoryId = sql.NullInt32{Int32: int32(*subscription.CategoryId), Valid: true}
+	}
+	if subscription.Notes != nil {
+		model.Notes = sql.NullString{String: *subscription.Notes, Valid: true}
+	}
+	if subscription.TrialMonths != nil {
+		model.TrialMonths = *subscription.TrialMonths
+	}
+	if subscription.TrialPrice != nil {
+		model.TrialPrice = *subscription.TrialPrice
 	}
 	if subscription.EndDate != nil {
-		endDate, _ := time.Parse("01-2006", *subscription.EndDate)
+		//A month means the subscription is active until its last day
+		endDate, _ := parseDate(*subscription.EndDate, true)
 		if endDate.Before(startDate) {
-			return 0, ErrIncorrectTime
+			return 0, s.fail(ctx, "Create", ErrIncorrectTime)
 		}
 		model.EndDate = sql.NullTime{Time: endDate, Valid: true}
 	}
 
+	//The tags are linked in the same transaction
+	model.Tags = subscription.Tags
+
 	id, err := s.subscriptionRepo.Create(ctx, model)
 	if err != nil {
-		s.logger.Error("SubscriptionService.Add:subscriptionRepo.Create - Internal error", slog.String("error", err.Error()))
-		return 0, ErrInternal
+		if errors.Is(err, repository.ErrIncorrectTime) {
+			return 0, s.fail(ctx, "Create", ErrIncorrectTime)
+		} else if errors.Is(err, repository.ErrUnknownUser) {
+			//The user has been deleted after the check
+			return 0, s.fail(ctx, "Create", ErrUnknownUser)
+		}
+		s.log(ctx).Error("SubscriptionService.Add:subscriptionRepo.Create - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "Create", ErrInternal)
 	}
+	s.changed(ctx, subscription.UserId)
 
-	s.logger.Info(fmt.Sprintf("The subscription id=%d has been created", id))
+	s.log(ctx).Info(fmt.Sprintf("The subscription id=%d has been created", id))
 	return id, err
 }
 
-func (s *SubscriptionService) GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int) ([]*domain.Subscription, error) {
+// GetByUser returns a page of the user's subscriptions matching the filter
+func (s *SubscriptionService) GetByUser(ctx context.Context, userId uuid.UUID, offset, limit int, filter domain.ListFilter) ([]*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetByUser")
+	defer span.End()
+
+	if !filter.Empty() {
+		return s.getFiltered(ctx, "GetByUser", &userId, filter, offset, limit)
+	}
+
 	models, err := s.subscriptionRepo.GetByUser(ctx, userId, offset, limit)
 	if err != nil {
-		s.logger.Error("SubscriptionService.GetByUser:subscriptionRepo.GetByUser - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+		s.log(ctx).Error("SubscriptionService.GetByUser:subscriptionRepo.GetByUser - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetByUser", ErrInternal)
 	}
 
-	var subscriptions []*domain.Subscription
-	for _, m := range models {
-		subscription := &domain.Subscription{
-			Id:          m.Id,
-			ServiceName: m.ServiceName,
-			Price:       m.Price,
-			UserId:      m.UserId,
-			StartDate:   m.StartDate.Format("01-2006"),
-		}
-
-		if m.EndDate.Valid {
-			subscription.EndDate = new(string)
-			*subscription.EndDate = m.EndDate.Time.Format("01-2006")
-		}
-		subscriptions = append(subscriptions, subscription)
+	subscriptions, err := s.withDetails(ctx, "GetByUser", models)
+	if err != nil {
+		return nil, err
 	}
-	s.logger.Info(fmt.Sprintf("All user userId=%s subscriptions were received successfully", userId.String()))
+	s.log(ctx).Info(fmt.Sprintf("All user userId=%s subscriptions were received successfully", userId.String()))
 
 	return subscriptions, err
 }
 
 func (s *SubscriptionService) GetById(ctx context.Context, id int) (*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetById")
+	defer span.End()
+
 	model, err := s.subscriptionRepo.GetById(ctx, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+			return nil, s.fail(ctx, "GetById", ErrNotFound)
 		}
-		s.logger.Error("SubscriptionService.GetById:subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
-	}
-
-	subscription := &domain.Subscription{
-		Id:          model.Id,
-		ServiceName: model.ServiceName,
-		Price:       model.Price,
-		UserId:      model.UserId,
-		StartDate:   model.StartDate.Format("01-2006"),
+		s.log(ctx).Error("SubscriptionService.GetById:subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetById", ErrInternal)
 	}
 
-	if model.EndDate.Valid {
-		subscription.EndDate = new(string)
-		*subscription.EndDate = model.EndDate.Time.Format("01-2006")
+	subscriptions, err := s.withDetails(ctx, "GetById", []*models.Subscription{model})
+	if err != nil {
+		return nil, err
 	}
+	subscription := subscriptions[0]
 
-	s.logger.Info(fmt.Sprintf("Subscription id=%d received successfully", id))
+	s.log(ctx).Info(fmt.Sprintf("Subscription id=%d received successfully", id))
 
 	return subscription, err
 }
 
 func (s *SubscriptionService) DeleteById(ctx context.Context, id int) (*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.DeleteById")
+	defer span.End()
+
+	//The pauses, the tags and the split are deleted with the subscription
+	pauses, err := s.subscriptionRepo.GetPauses(ctx, []int{id})
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.DeleteById:subscriptionRepo.GetPauses - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteById", ErrInternal)
+	}
+	tags, err := s.tagRepo.GetSubscriptionTags(ctx, []int{id})
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.DeleteById:tagRepo.GetSubscriptionTags - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteById", ErrInternal)
+	}
+	split, err := s.getSplit(ctx, "DeleteById", id)
+	if err != nil {
+		return nil, err
+	}
+
 	model, err := s.subscriptionRepo.DeleteById(ctx, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+			return nil, s.fail(ctx, "DeleteById", ErrNotFound)
 		}
-		s.logger.Error("SubscriptionService.DeleteById:subscriptionRepo.DeleteById - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+		s.log(ctx).Error("SubscriptionService.DeleteById:subscriptionRepo.DeleteById - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteById", ErrInternal)
 	}
+	s.changed(ctx, splitUsers(model.UserId, split)...)
 
-	subscription := &domain.Subscription{
-		Id:          model.Id,
-		ServiceName: model.ServiceName,
-		Price:       model.Price,
-		UserId:      model.UserId,
-		StartDate:   model.StartDate.Format("01-2006"),
+	subscription := s.toDomain(model, pauses)
+	for _, t := range tags {
+		subscription.Tags = append(subscription.Tags, t.Name)
 	}
+	subscription.Split = toDomainSplit(split)
 
-	if model.EndDate.Valid {
-		subscription.EndDate = new(string)
-		*subscription.EndDate = model.EndDate.Time.Format("01-2006")
-	}
-
-	s.logger.Info(fmt.Sprintf("Subscription id=%d deleted successfully", id))
+	s.log(ctx).Info(fmt.Sprintf("Subscription id=%d deleted successfully", id))
 
 	return subscription, err
 }
 
 func (s *SubscriptionService) Update(ctx context.Context, data *domain.SubscriptionUpdate) (*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.Update")
+	defer span.End()
+
 	m := &models.SubscriptionUpdate{Id: data.Id}
 	if data.ServiceName != nil {
 		m.ServiceName = sql.NullString{String: *data.ServiceName, Valid: true}
@@ -155,84 +460,351 @@ func (s *SubscriptionService) Update(ctx context.Context, data *domain.Subscript
 		m.Price = sql.NullInt32{Int32: int32(*data.Price), Valid: true}
 	}
 	if data.StartDate != nil {
-		startDate, _ := time.Parse("01-2006", *data.StartDate)
+		startDate, _ := parseDate(*data.StartDate, false)
 		m.StartDate = sql.NullTime{Time: startDate, Valid: true}
 	}
 	if data.EndDate != nil {
-		endDate, _ := time.Parse("01-2006", *data.EndDate)
+		endDate, _ := parseDate(*data.EndDate, true)
 		m.EndDate = sql.NullTime{Time: endDate, Valid: true}
 	}
+	if data.BillingDay != nil {
+		m.BillingDay = sql.NullInt32{Int32: int32(*data.BillingDay), Valid: true}
+	}
+	if data.TrialMonths != nil {
+		m.TrialMonths = sql.NullInt32{Int32: int32(*data.TrialMonths), Valid: true}
+	}
+	if data.TrialPrice != nil {
+		m.TrialPrice = sql.NullInt32{Int32: int32(*data.TrialPrice), Valid: true}
+	}
+	if data.Notes != nil {
+		m.Notes = sql.NullString{String: *data.Notes, Valid: true}
+	}
+	if data.ServiceId != nil || data.PlanId != nil {
+		if err := s.relinkCatalog(ctx, "Update", m, data.ServiceId, data.PlanId); err != nil {
+			return nil, err
+		}
+	}
+	if data.CategoryId != nil {
+		//The category must be available to the owner of the subscription
+		current, err := s.subscriptionRepo.GetById(ctx, data.Id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, s.fail(ctx, "Update", ErrNotFound)
+			}
+			s.log(ctx).Error("SubscriptionService.Update:subscriptionRepo.GetById - Internal error", slog.String("error", err.Error()))
+			return nil, s.fail(ctx, "Update", ErrInternal)
+		}
+		if err := s.checkCategory(ctx, "Update", *data.CategoryId, current.UserId); err != nil {
+			return nil, err
+		}
+		m.CategoryId = sql.NullInt32{Int32: int32(*data.CategoryId), Valid: true}
+	}
+
+	//The new period must still hold every pause
+	if m.StartDate.Valid || m.EndDate.Valid {
+		current, pauses, err := s.getWithPauses(ctx, "Update", data.Id)
+		if err != nil {
+			return nil, err
+		}
+		startDate, endDate := current.StartDate, current.EndDate
+		if m.StartDate.Valid {
+			startDate = m.StartDate.Time
+		}
+		if m.EndDate.Valid {
+			endDate = m.EndDate
+		}
+		for _, p := range pauses {
+			if !pauseInPeriod(p.StartDate, p.EndDate, startDate, endDate) {
+				return nil, s.fail(ctx, "Update", ErrIncorrectTime)
+			}
+		}
+	}
+
+	m.Tags = data.Tags
+
+	//The members share the new price
+	split, err := s.getSplit(ctx, "Update", data.Id)
+	if err != nil {
+		return nil, err
+	}
 
 	model, err := s.subscriptionRepo.Update(ctx, m)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return nil, ErrNotFound
+			return nil, s.fail(ctx, "Update", ErrNotFound)
 		} else if errors.Is(err, repository.ErrIncorrectTime) {
-			return nil, ErrIncorrectTime
+			return nil, s.fail(ctx, "Update", ErrIncorrectTime)
 		}
-		s.logger.Error("SubscriptionService.Update:subscriptionRepo.Update - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+		s.log(ctx).Error("SubscriptionService.Update:subscriptionRepo.Update - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "Update", ErrInternal)
 	}
+	s.changed(ctx, splitUsers(model.UserId, split)...)
 
-	subscription := &domain.Subscription{
-		Id:          model.Id,
-		ServiceName: model.ServiceName,
-		Price:       model.Price,
-		UserId:      model.UserId,
-		StartDate:   model.StartDate.Format("01-2006"),
-	}
-
-	if model.EndDate.Valid {
-		subscription.EndDate = new(string)
-		*subscription.EndDate = model.EndDate.Time.Format("01-2006")
+	subscriptions, err := s.withDetails(ctx, "Update", []*models.Subscription{model})
+	if err != nil {
+		return nil, err
 	}
+	subscription := subscriptions[0]
 
-	s.logger.Info(fmt.Sprintf("Subscription id=%d update successfully", data.Id))
+	s.log(ctx).Info(fmt.Sprintf("Subscription id=%d update successfully", data.Id))
 
 	return subscription, err
 }
 
-func (s *SubscriptionService) GetPriceByFilter(ctx context.Context, userId *uuid.UUID, serviceName *string, startDate, endDate string) (int, error) {
-	parsedStart, _ := time.Parse("01-2006", startDate)
-	parsedEnd, _ := time.Parse("01-2006", endDate)
+func (s *SubscriptionService) GetPriceByFilter(ctx context.Context, filter *domain.PriceFilter) (*domain.PriceReport, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetPriceByFilter")
+	defer span.End()
+
+	//Both ends are included, months cover all their days
+	parsedStart, _ := parseDate(filter.StartDate, false)
+	parsedEnd, _ := parseDate(filter.EndDate, true)
 	if parsedEnd.Before(parsedStart) {
-		return 0, ErrIncorrectTime
+		return nil, s.fail(ctx, "GetPriceByFilter", ErrIncorrectTime)
+	}
+
+	periodFilter := &models.SubscriptionFilter{
+		UserId:       filter.UserId,
+		Members:      true,
+		From:         parsedStart,
+		To:           parsedEnd,
+		Tags:         filter.Tags,
+		ExcludedTags: filter.ExcludedTags,
+	}
+	if filter.ServiceId != nil {
+		periodFilter.ServiceId = filter.ServiceId
+	} else if filter.ServiceName != nil {
+		periodFilter.ServiceName = filter.ServiceName
+		//Subscriptions named differently are found by the catalog service with the name or alias
+		service, err := s.catalogRepo.FindService(ctx, *filter.ServiceName)
+		if err == nil {
+			periodFilter.ServiceId = &service.Id
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			s.log(ctx).Error("SubscriptionService.GetPriceByFilter:catalogRepo.FindService - Internal error", slog.String("error", err.Error()))
+			return nil, s.fail(ctx, "GetPriceByFilter", ErrInternal)
+		}
+	}
+	if filter.CategoryId != nil {
+		ids, err := s.categoryIds(ctx, "GetPriceByFilter", *filter.CategoryId)
+		if err != nil {
+			return nil, err
+		}
+		periodFilter.CategoryIds = ids
+	}
+
+	subs, err := s.subscriptionRepo.GetByPeriod(ctx, periodFilter)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.GetPriceByFilter:subscriptionRepo.GetByPeriod - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetPriceByFilter", ErrInternal)
 	}
 
-	total, err := s.subscriptionRepo.GetPriceByFilter(ctx, userId, serviceName, parsedStart, parsedEnd)
+	pauses, err := s.getPauses(ctx, "GetPriceByFilter", subs)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.getPriceChanges(ctx, "GetPriceByFilter", subs)
 	if err != nil {
-		s.logger.Error("SubscriptionService.GetPriceByFilter:subscriptionRepo.GetPriceByFilter - Internal error", slog.String("error", err.Error()))
-		return 0, ErrInternal
+		return nil, err
+	}
+	splits, err := s.getSplits(ctx, "GetPriceByFilter", subs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.PriceReport{}
+	for _, sub := range subs {
+		item := bill(sub, pauses[sub.Id], changes[sub.Id], parsedStart, parsedEnd, filter.Proration)
+		item.Share = shareOf(sub, splits[sub.Id], filter.UserId, item.Cost)
+		report.Total += item.Share
+		report.Items = append(report.Items, item)
+	}
+	if filter.ByCategory {
+		report.Categories, err = s.costByCategory(ctx, "GetPriceByFilter", filter.UserId, subs, report.Items)
+		if err != nil {
+			return nil, err
+		}
 	}
-	s.logger.Info(fmt.Sprintf("Total cost for the period from %s to %s is %d", startDate, endDate, total))
+	s.log(ctx).Info(fmt.Sprintf("Total cost for the period from %s to %s is %d", filter.StartDate, filter.EndDate, report.Total))
 
-	return total, err
+	return report, nil
 }
 
-func (s *SubscriptionService) GetAll(ctx context.Context, offset, limit int) ([]*domain.Subscription, error) {
+// GetEndingTrials returns the subscriptions whose trial ends within days from today, today included.
+// Subscriptions that end with their trial are skipped, there is nothing to cancel.
+func (s *SubscriptionService) GetEndingTrials(ctx context.Context, userId *uuid.UUID, days int) ([]*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetEndingTrials")
+	defer span.End()
+
+	today := s.today()
+	until := today.AddDate(0, 0, days)
+	subs, err := s.subscriptionRepo.GetByPeriod(ctx, &models.SubscriptionFilter{
+		UserId: userId,
+		From:   today,
+		To:     until,
+		Trial:  true,
+	})
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.GetEndingTrials:subscriptionRepo.GetByPeriod - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetEndingTrials", ErrInternal)
+	}
+
+	var ending []*models.Subscription
+	for _, m := range subs {
+		trialEnd := trialEndDate(m)
+		if trialEnd.Before(today) || trialEnd.After(until) || (m.EndDate.Valid && !m.EndDate.Time.After(*trialEnd)) {
+			continue
+		}
+		ending = append(ending, m)
+	}
+	subscriptions, err := s.withDetails(ctx, "GetEndingTrials", ending)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info(fmt.Sprintf("Found %d subscriptions with the trial ending within %d days", len(subscriptions), days))
+
+	return subscriptions, nil
+}
+
+// GetAll returns a page of all subscriptions matching the filter
+func (s *SubscriptionService) GetAll(ctx context.Context, offset, limit int, filter domain.ListFilter) ([]*domain.Subscription, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.GetAll")
+	defer span.End()
+
+	if !filter.Empty() {
+		return s.getFiltered(ctx, "GetAll", nil, filter, offset, limit)
+	}
+
 	subs, err := s.subscriptionRepo.GetAll(ctx, offset, limit)
 	if err != nil {
-		s.logger.Error("SubscriptionService.GetAll:subscriptionRepo.GetAll - Internal error", slog.String("error", err.Error()))
-		return nil, ErrInternal
+		s.log(ctx).Error("SubscriptionService.GetAll:subscriptionRepo.GetAll - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetAll", ErrInternal)
+	}
+
+	subscriptions, err := s.withDetails(ctx, "GetAll", subs)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info("All subscriptions were received successfully", slog.Int("offset", offset), slog.Int("limit", limit))
+
+	return subscriptions, err
+}
+
+func (s *SubscriptionService) CountActive(ctx context.Context) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "SubscriptionService.CountActive")
+	defer span.End()
+
+	//The metric counts the subscriptions of all the organizations
+	count, err := s.subscriptionRepo.CountActive(tenant.Bypass(ctx), s.now())
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService.CountActive:subscriptionRepo.CountActive - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CountActive", ErrInternal)
+	}
+
+	return count, nil
+}
+
+// withDetails converts the subscriptions to the domain model loading their pauses, tags and splits
+func (s *SubscriptionService) withDetails(ctx context.Context, method string, subs []*models.Subscription) ([]*domain.Subscription, error) {
+	pauses, err := s.getPauses(ctx, method, subs)
+	if err != nil {
+		return nil, err
 	}
 
 	var subscriptions []*domain.Subscription
-	for _, s := range subs {
-		subscription := &domain.Subscription{
-			Id:          s.Id,
-			ServiceName: s.ServiceName,
-			Price:       s.Price,
-			UserId:      s.UserId,
-			StartDate:   s.StartDate.Format("01-2006"),
-		}
+	for _, m := range subs {
+		subscriptions = append(subscriptions, s.toDomain(m, pauses[m.Id]))
+	}
+	if err := s.loadTags(ctx, method, subscriptions); err != nil {
+		return nil, err
+	}
+	if err := s.loadSplits(ctx, method, subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// getPauses returns the pauses of the subscriptions by subscription ID
+func (s *SubscriptionService) getPauses(ctx context.Context, method string, subs []*models.Subscription) (map[int][]*models.Pause, error) {
+	ids := make([]int, 0, len(subs))
+	for _, m := range subs {
+		ids = append(ids, m.Id)
+	}
+
+	pauses, err := s.subscriptionRepo.GetPauses(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":subscriptionRepo.GetPauses - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, method, ErrInternal)
+	}
+
+	res := make(map[int][]*models.Pause, len(subs))
+	for _, p := range pauses {
+		res[p.SubscriptionId] = append(res[p.SubscriptionId], p)
+	}
+	return res, nil
+}
+
+func (s *SubscriptionService) toDomain(m *models.Subscription, pauses []*models.Pause) *domain.Subscription {
+	subscription := &domain.Subscription{
+		Id:             m.Id,
+		ServiceName:    m.ServiceName,
+		Price:          m.Price,
+		UserId:         m.UserId,
+		StartDate:      formatDate(m.StartDate),
+		BillingDay:     m.BillingDay,
+		TrialMonths:    m.TrialMonths,
+		TrialPrice:     m.TrialPrice,
+		OrganizationId: m.OrganizationId,
+	}
+	today := s.today()
+	subscription.Status = subscriptionStatus(m, pauses, today)
 
-		if s.EndDate.Valid {
-			subscription.EndDate = new(string)
-			*subscription.EndDate = s.EndDate.Time.Format("01-2006")
+	if m.EndDate.Valid {
+		subscription.EndDate = new(string)
+		*subscription.EndDate = formatDate(m.EndDate.Time)
+	}
+	if next := nextBillingDate(m, pauses, today); next != nil {
+		subscription.NextBillingDate = new(string)
+		*subscription.NextBillingDate = formatDate(*next)
+	}
+	if trialEnd := trialEndDate(m); trialEnd != nil {
+		subscription.TrialEndsAt = new(string)
+		*subscription.TrialEndsAt = formatDate(*trialEnd)
+	}
+	if m.CancelledAt.Valid {
+		subscription.CancelledAt = new(string)
+		*subscription.CancelledAt = formatDate(m.CancelledAt.Time)
+	}
+	if m.CancelReason.Valid {
+		subscription.CancelReason = &m.CancelReason.String
+	}
+	if m.ServiceId.Valid {
+		subscription.ServiceId = new(int)
+		*subscription.ServiceId = int(m.ServiceId.Int32)
+	}
+	if m.PlanId.Valid {
+		subscription.PlanId = new(int)
+		*subscription.PlanId = int(m.PlanId.Int32)
+	}
+	if m.CategoryId.Valid {
+		subscription.CategoryId = new(int)
+		*subscription.CategoryId = int(m.CategoryId.Int32)
+	}
+	if m.Notes.Valid {
+		subscription.Notes = &m.Notes.String
+	}
+	for _, p := range pauses {
+		pause := domain.Pause{Id: p.Id, StartDate: formatDate(p.StartDate)}
+		if p.EndDate.Valid {
+			pause.EndDate = new(string)
+			*pause.EndDate = formatDate(p.EndDate.Time)
 		}
-		subscriptions = append(subscriptions, subscription)
+		subscription.Pauses = append(subscription.Pauses, pause)
 	}
-	s.logger.Info("All subscriptions were received successfully", slog.Int("offset", offset), slog.Int("limit", limit))
 
-	return subscriptions, err
+	return subscription
+}
+
+// today returns the current date in UTC, the dates of subscriptions have no time zone
+func (s *SubscriptionService) today() time.Time {
+	now := s.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TagService manages the user's tags, the subscriptions get theirs through SubscriptionService
+type TagService struct {
+	tagRepo ITagRepo
+	metrics IMetrics
+	tracer  trace.Tracer
+	logger  *slog.Logger
+}
+
+func NewTagService(tagRepo ITagRepo, metrics IMetrics, logger *slog.Logger) *TagService {
+	return &TagService{
+		tagRepo: tagRepo,
+		metrics: metrics,
+		tracer:  otel.Tracer(tracerName),
+		logger:  logger,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *TagService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *TagService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+func (s *TagService) CreateTag(ctx context.Context, data *domain.TagCreate) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.CreateTag")
+	defer span.End()
+
+	id, err := s.tagRepo.CreateTag(ctx, &models.TagCreate{UserId: data.UserId, Name: data.Name})
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return 0, s.fail(ctx, "CreateTag", ErrAlreadyExists)
+		}
+		s.log(ctx).Error("TagService.CreateTag:tagRepo.CreateTag - Internal error", slog.String("error", err.Error()))
+		return 0, s.fail(ctx, "CreateTag", ErrInternal)
+	}
+
+	s.log(ctx).Info(fmt.Sprintf("The tag id=%d has been created", id))
+	return id, nil
+}
+
+func (s *TagService) GetTag(ctx context.Context, id int) (*domain.Tag, error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.GetTag")
+	defer span.End()
+
+	model, err := s.tagRepo.GetTag(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetTag", ErrNotFound)
+		}
+		s.log(ctx).Error("TagService.GetTag:tagRepo.GetTag - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetTag", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Tag id=%d received successfully", id))
+
+	return toTagDomain(model), nil
+}
+
+// GetTags returns the user's tags ordered by name regardless of case
+func (s *TagService) GetTags(ctx context.Context, userId uuid.UUID) ([]*domain.Tag, error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.GetTags")
+	defer span.End()
+
+	list, err := s.tagRepo.GetTags(ctx, userId)
+	if err != nil {
+		s.log(ctx).Error("TagService.GetTags:tagRepo.GetTags - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetTags", ErrInternal)
+	}
+
+	tags := make([]*domain.Tag, 0, len(list))
+	for _, m := range list {
+		tags = append(tags, toTagDomain(m))
+	}
+	s.log(ctx).Info(fmt.Sprintf("%d tags of the user userId=%s were received successfully", len(tags), userId.String()))
+
+	return tags, nil
+}
+
+// UpdateTag renames the tag on all the subscriptions with it
+func (s *TagService) UpdateTag(ctx context.Context, data *domain.TagUpdate) (*domain.Tag, error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.UpdateTag")
+	defer span.End()
+
+	model, err := s.tagRepo.UpdateTag(ctx, &models.TagUpdate{Id: data.Id, Name: data.Name})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "UpdateTag", ErrNotFound)
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "UpdateTag", ErrAlreadyExists)
+		}
+		s.log(ctx).Error("TagService.UpdateTag:tagRepo.UpdateTag - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "UpdateTag", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Tag id=%d update successfully", data.Id))
+
+	return toTagDomain(model), nil
+}
+
+// DeleteTag removes the tag from the user's subscriptions, the subscriptions are kept
+func (s *TagService) DeleteTag(ctx context.Context, id int) (*domain.Tag, error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.DeleteTag")
+	defer span.End()
+
+	model, err := s.tagRepo.DeleteTag(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "DeleteTag", ErrNotFound)
+		}
+		s.log(ctx).Error("TagService.DeleteTag:tagRepo.DeleteTag - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteTag", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("Tag id=%d deleted successfully", id))
+
+	return toTagDomain(model), nil
+}
+
+func toTagDomain(m *models.Tag) *domain.Tag {
+	return &domain.Tag{
+		Id:            m.Id,
+		UserId:        m.UserId,
+		Name:          m.Name,
+		Subscriptions: m.Subscriptions,
+	}
+}
+
+// loadTags fills in the tags of the subscriptions
+func (s *SubscriptionService) loadTags(ctx context.Context, method string, subscriptions []*domain.Subscription) error {
+	ids := make([]int, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.Id)
+	}
+
+	tags, err := s.tagRepo.GetSubscriptionTags(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("SubscriptionService."+method+":tagRepo.GetSubscriptionTags - Internal error", slog.String("error", err.Error()))
+		return s.fail(ctx, method, ErrInternal)
+	}
+
+	byId := make(map[int][]string, len(subscriptions))
+	for _, t := range tags {
+		byId[t.SubscriptionId] = append(byId[t.SubscriptionId], t.Name)
+	}
+	for _, subscription := range subscriptions {
+		subscription.Tags = byId[subscription.Id]
+	}
+	return nil
+}
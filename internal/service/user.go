@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Estriper0/subscription_service/internal/logger"
+	"github.com/Estriper0/subscription_service/internal/repository"
+	"github.com/Estriper0/subscription_service/internal/repository/models"
+	"github.com/Estriper0/subscription_service/internal/service/domain"
+	"github.com/Estriper0/subscription_service/internal/tenant"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultLocale   = "en"
+	defaultCurrency = "RUB"
+	defaultTimezone = "UTC"
+)
+
+// UserService manages the registry of the users owning the subscriptions
+type UserService struct {
+	userRepo IUserRepo
+	//Delete the subscriptions of a deleted user instead of anonymizing the user
+	cascade bool
+	metrics IMetrics
+	tracer  trace.Tracer
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func NewUserService(userRepo IUserRepo, cascade bool, metrics IMetrics, logger *slog.Logger) *UserService {
+	return &UserService{
+		userRepo: userRepo,
+		cascade:  cascade,
+		metrics:  metrics,
+		tracer:   otel.Tracer(tracerName),
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// log returns the request-scoped logger if the context carries one
+func (s *UserService) log(ctx context.Context) *slog.Logger {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// fail records the error returned by the service method and passes it through
+func (s *UserService) fail(ctx context.Context, method string, err error) error {
+	return recordError(ctx, s.metrics, method, err)
+}
+
+func (s *UserService) CreateUser(ctx context.Context, data *domain.UserCreate) (*domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	model := &models.UserCreate{
+		Id:          uuid.New(),
+		DisplayName: data.DisplayName,
+		Email:       nullString(data.Email),
+		Locale:      valueOr(data.Locale, defaultLocale),
+		Currency:    valueOr(data.Currency, defaultCurrency),
+		Timezone:    valueOr(data.Timezone, defaultTimezone),
+		CreatedAt:   s.now(),
+	}
+	if data.Id != nil {
+		model.Id = *data.Id
+	}
+	err := s.userRepo.CreateUser(ctx, model)
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "CreateUser", ErrUserExists)
+		}
+		s.log(ctx).Error("UserService.CreateUser:userRepo.CreateUser - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "CreateUser", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("The user userId=%s has been created", model.Id.String()))
+
+	return &domain.User{
+		Id:          model.Id,
+		DisplayName: model.DisplayName,
+		Email:       data.Email,
+		Locale:      model.Locale,
+		Currency:    model.Currency,
+		Timezone:    model.Timezone,
+		CreatedAt:   model.CreatedAt.UTC(),
+	}, nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetUser")
+	defer span.End()
+
+	model, err := s.userRepo.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "GetUser", ErrNotFound)
+		}
+		s.log(ctx).Error("UserService.GetUser:userRepo.GetUser - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetUser", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("User userId=%s received successfully", id.String()))
+
+	return toUserDomain(model), nil
+}
+
+func (s *UserService) GetUsers(ctx context.Context, offset, limit int) ([]*domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.GetUsers")
+	defer span.End()
+
+	list, err := s.userRepo.GetUsers(ctx, offset, limit)
+	if err != nil {
+		s.log(ctx).Error("UserService.GetUsers:userRepo.GetUsers - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "GetUsers", ErrInternal)
+	}
+	users := make([]*domain.User, 0, len(list))
+	for _, m := range list {
+		users = append(users, toUserDomain(m))
+	}
+	s.log(ctx).Info("All users were received successfully")
+
+	return users, nil
+}
+
+// UpdateUser changes the set fields, an anonymized user can't be changed
+func (s *UserService) UpdateUser(ctx context.Context, data *domain.UserUpdate) (*domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	model, err := s.userRepo.UpdateUser(ctx, &models.UserUpdate{
+		Id:          data.Id,
+		DisplayName: nullString(data.DisplayName),
+		Email:       nullString(data.Email),
+		ClearEmail:  data.ClearEmail,
+		Locale:      nullString(data.Locale),
+		Currency:    nullString(data.Currency),
+		Timezone:    nullString(data.Timezone),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "UpdateUser", ErrNotFound)
+		} else if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, s.fail(ctx, "UpdateUser", ErrUserExists)
+		}
+		s.log(ctx).Error("UserService.UpdateUser:userRepo.UpdateUser - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "UpdateUser", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("The user userId=%s has been updated", data.Id.String()))
+
+	return toUserDomain(model), nil
+}
+
+// DeleteUser erases the personal data of the user. By the configuration the user is deleted
+// with the subscriptions or anonymized, keeping the subscriptions for the statistics.
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	ctx, span := s.tracer.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	//The data of the user is erased in all the organizations
+	ctx = tenant.Bypass(ctx)
+	var (
+		model *models.User
+		err   error
+		call  string
+	)
+	if s.cascade {
+		call = "userRepo.DeleteUser"
+		model, err = s.userRepo.DeleteUser(ctx, id)
+	} else {
+		call = "userRepo.AnonymizeUser"
+		model, err = s.userRepo.AnonymizeUser(ctx, id, s.now())
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, s.fail(ctx, "DeleteUser", ErrNotFound)
+		}
+		s.log(ctx).Error("UserService.DeleteUser:"+call+" - Internal error", slog.String("error", err.Error()))
+		return nil, s.fail(ctx, "DeleteUser", ErrInternal)
+	}
+	s.log(ctx).Info(fmt.Sprintf("The user userId=%s has been deleted", id.String()))
+
+	return toUserDomain(model), nil
+}
+
+// checkUser rejects a subscription of a user who is not registered or has been anonymized
+func (s *SubscriptionService) checkUser(ctx context.Context, method string, userId uuid.UUID) error {
+	user, err := s.userRepo.GetUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return s.fail(ctx, method, ErrUnknownUser)
+		}
+		s.log(ctx).Error("SubscriptionService."+method+":userRepo.GetUser - Internal error", slog.String("error", err.Error()))
+		return s.fail(ctx, method, ErrInternal)
+	}
+	if user.AnonymizedAt.Valid {
+		return s.fail(ctx, method, ErrUnknownUser)
+	}
+	return nil
+}
+
+func toUserDomain(m *models.User) *domain.User {
+	user := &domain.User{
+		Id:          m.Id,
+		DisplayName: m.DisplayName,
+		Locale:      m.Locale,
+		Currency:    m.Currency,
+		Timezone:    m.Timezone,
+		Email:       stringPtr(m.Email),
+		CreatedAt:   m.CreatedAt,
+	}
+	if m.AnonymizedAt.Valid {
+		user.AnonymizedAt = &m.AnonymizedAt.Time
+	}
+	return user
+}
+
+func valueOr(s *string, value string) string {
+	if s == nil {
+		return value
+	}
+	return *s
+}
@@ -0,0 +1,36 @@
+// Package tenant carries the organization a request is scoped to.
+// The repositories only see the rows of the organization in the context, without an organization
+// they see nothing unless the context explicitly bypasses the tenant for administrative work.
+package tenant
+
+import "context"
+
+type (
+	organizationKey struct{}
+	bypassKey       struct{}
+)
+
+// WithOrganization returns a copy of ctx scoped to the organization
+func WithOrganization(ctx context.Context, organizationId int) context.Context {
+	ctx = context.WithValue(ctx, bypassKey{}, false)
+	return context.WithValue(ctx, organizationKey{}, organizationId)
+}
+
+// Bypass returns a copy of ctx that sees the rows of all the organizations,
+// it is meant for the administrative work that is not done on behalf of a tenant
+func Bypass(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, organizationKey{}, nil)
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Organization returns the organization ctx is scoped to
+func Organization(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(organizationKey{}).(int)
+	return id, ok
+}
+
+// Bypassed reports whether ctx sees the rows of all the organizations
+func Bypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
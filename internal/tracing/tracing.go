@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Estriper0/subscription_service/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// New installs the global tracer provider and the W3C trace-context propagator.
+// The returned function flushes and stops the exporter.
+func New(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	//Incoming trace context is propagated even when spans are not exported
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing:New:resource.Merge - %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("tracing:newExporter:stdouttrace.New - %w", err)
+		}
+		return exporter, nil
+	case ExporterOTLP:
+		switch cfg.Protocol {
+		case ProtocolGRPC:
+			opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+			if cfg.Insecure {
+				opts = append(opts, otlptracegrpc.WithInsecure())
+			}
+			exporter, err := otlptracegrpc.New(ctx, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("tracing:newExporter:otlptracegrpc.New - %w", err)
+			}
+			return exporter, nil
+		case ProtocolHTTP:
+			opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+			if cfg.Insecure {
+				opts = append(opts, otlptracehttp.WithInsecure())
+			}
+			exporter, err := otlptracehttp.New(ctx, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("tracing:newExporter:otlptracehttp.New - %w", err)
+			}
+			return exporter, nil
+		}
+		return nil, fmt.Errorf("tracing:newExporter - unknown OTLP protocol %q", cfg.Protocol)
+	}
+
+	return nil, fmt.Errorf("tracing:newExporter - unknown exporter %q", cfg.Exporter)
+}
@@ -0,0 +1,13 @@
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS end_date_not_before_start_date;
+
+UPDATE subscription
+    SET end_date = date_trunc('month', end_date)::date
+WHERE end_date IS NOT NULL;
+
+-- Subscriptions within a single month had no valid exclusive end date
+UPDATE subscription
+    SET end_date = (end_date + interval '1 month')::date
+WHERE end_date <= start_date;
+
+ALTER TABLE subscription ADD CONSTRAINT end_date_after_start_date
+    CHECK (end_date IS NULL OR end_date > start_date);
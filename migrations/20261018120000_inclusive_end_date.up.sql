@@ -0,0 +1,9 @@
+-- The end date is the last active day: a subscription ending in May is billed for May
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS end_date_after_start_date;
+
+UPDATE subscription
+    SET end_date = (date_trunc('month', end_date) + interval '1 month - 1 day')::date
+WHERE end_date IS NOT NULL;
+
+ALTER TABLE subscription ADD CONSTRAINT end_date_not_before_start_date
+    CHECK (end_date IS NULL OR end_date >= start_date);
@@ -0,0 +1,8 @@
+-- Day of the month the subscription renews on, existing subscriptions renew on their start day
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS billing_day SMALLINT;
+
+UPDATE subscription SET billing_day = EXTRACT(DAY FROM start_date);
+
+ALTER TABLE subscription ALTER COLUMN billing_day SET NOT NULL;
+ALTER TABLE subscription ADD CONSTRAINT billing_day_in_month
+    CHECK (billing_day BETWEEN 1 AND 31);
@@ -0,0 +1,2 @@
+ALTER TABLE subscription DROP COLUMN IF EXISTS trial_price;
+ALTER TABLE subscription DROP COLUMN IF EXISTS trial_months;
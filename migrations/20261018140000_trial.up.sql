@@ -0,0 +1,6 @@
+-- The first trial_months billing cycles of a subscription are charged trial_price instead of its price,
+-- a free trial has the trial price 0
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS trial_months SMALLINT NOT NULL DEFAULT 0
+    CONSTRAINT trial_months_not_negative CHECK (trial_months >= 0);
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS trial_price INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT trial_price_not_negative CHECK (trial_price >= 0);
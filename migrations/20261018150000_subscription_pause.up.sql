@@ -0,0 +1,17 @@
+-- Days from start_date to end_date, both included, the subscription is not billed for.
+-- A pause without the end date lasts until the subscription is resumed.
+-- btree_gist lets the exclusion constraint compare subscription_id with =.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+CREATE TABLE IF NOT EXISTS subscription_pause (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE
+    CONSTRAINT pause_end_date_not_before_start_date
+        CHECK (end_date IS NULL OR end_date >= start_date),
+    CONSTRAINT pause_no_overlap
+        EXCLUDE USING gist (subscription_id WITH =, daterange(start_date, end_date, '[]') WITH &&)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_pause_subscription_id ON subscription_pause(subscription_id);
@@ -0,0 +1,2 @@
+ALTER TABLE subscription DROP COLUMN IF EXISTS cancel_reason;
+ALTER TABLE subscription DROP COLUMN IF EXISTS cancelled_at;
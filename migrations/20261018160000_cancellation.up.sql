@@ -0,0 +1,3 @@
+-- A cancelled subscription ends on its end_date, cancelled_at is the day it was cancelled
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS cancelled_at DATE;
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS cancel_reason VARCHAR(500);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_subscription_service_id;
+ALTER TABLE subscription DROP COLUMN IF EXISTS plan_id;
+ALTER TABLE subscription DROP COLUMN IF EXISTS service_id;
+DROP TABLE IF EXISTS plan;
+DROP TABLE IF EXISTS service_alias;
+DROP TABLE IF EXISTS service;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS service_name_merge;
+
+-- pg_trgm is left installed: other objects of the database may use it
@@ -0,0 +1,11 @@
+-- Trigram similarity of service names
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Audit log of merged service name spellings
+CREATE TABLE IF NOT EXISTS service_name_merge (
+    id SERIAL PRIMARY KEY,
+    canonical_name VARCHAR(100) NOT NULL,
+    merged_names JSONB NOT NULL,
+    subscription_count INTEGER NOT NULL,
+    merged_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_subscription_category_id;
+ALTER TABLE subscription DROP COLUMN IF EXISTS category_id;
+
+ALTER TABLE service ADD COLUMN IF NOT EXISTS category VARCHAR(100);
+
+UPDATE service
+SET
+    category = (SELECT name FROM category WHERE id = service.category_id);
+
+ALTER TABLE service DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS category;
@@ -0,0 +1,19 @@
+-- Free-form notes, e.g. login hints or the phone number to cancel by
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS notes VARCHAR(2000);
+
+-- Labels of the user's subscriptions, names are unique per user regardless of case
+CREATE TABLE IF NOT EXISTS tag (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    name VARCHAR(50) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tag_user_name_key ON tag(user_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS subscription_tag (
+    subscription_id INTEGER NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+    PRIMARY KEY (subscription_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tag_tag_id ON subscription_tag(tag_id);
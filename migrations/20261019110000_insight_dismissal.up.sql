@@ -0,0 +1,7 @@
+-- Insights the users have dismissed, an insight ID is stable as long as its subscriptions are
+CREATE TABLE IF NOT EXISTS insight_dismissal (
+    user_id UUID NOT NULL,
+    insight_id TEXT NOT NULL,
+    dismissed_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, insight_id)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscription_member_user_id;
+DROP TABLE IF EXISTS subscription_member;
+DROP TABLE IF EXISTS subscription_split;
@@ -0,0 +1,96 @@
+DROP POLICY IF EXISTS budget_alert_tenant ON budget_alert;
+ALTER TABLE budget_alert NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE budget_alert DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS subscription_member_tenant ON subscription_member;
+ALTER TABLE subscription_member NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_member DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS subscription_split_tenant ON subscription_split;
+ALTER TABLE subscription_split NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_split DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS subscription_tag_tenant ON subscription_tag;
+ALTER TABLE subscription_tag NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_tag DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS subscription_pause_tenant ON subscription_pause;
+ALTER TABLE subscription_pause NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_pause DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS service_name_merge_bypass ON service_name_merge;
+DROP POLICY IF EXISTS service_name_merge_tenant ON service_name_merge;
+ALTER TABLE service_name_merge NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE service_name_merge DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS insight_dismissal_bypass ON insight_dismissal;
+DROP POLICY IF EXISTS insight_dismissal_tenant ON insight_dismissal;
+ALTER TABLE insight_dismissal NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE insight_dismissal DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tag_bypass ON tag;
+DROP POLICY IF EXISTS tag_tenant ON tag;
+ALTER TABLE tag NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tag DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS budget_bypass ON budget;
+DROP POLICY IF EXISTS budget_tenant ON budget;
+ALTER TABLE budget NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE budget DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS subscription_bypass ON subscription;
+DROP POLICY IF EXISTS subscription_tenant ON subscription;
+ALTER TABLE subscription NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS organization_member_bypass ON organization_member;
+DROP POLICY IF EXISTS organization_member_tenant ON organization_member;
+ALTER TABLE organization_member NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization_member DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS organization_bypass ON organization;
+DROP POLICY IF EXISTS organization_tenant ON organization;
+ALTER TABLE organization NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organization DISABLE ROW LEVEL SECURITY;
+
+-- The role is shared by the databases of the cluster, only its privileges in this one are revoked
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE USAGE, SELECT ON SEQUENCES FROM tenant_bypass;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM tenant_bypass;
+REVOKE USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public FROM tenant_bypass;
+REVOKE SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public FROM tenant_bypass;
+
+ALTER TABLE service_name_merge DROP COLUMN IF EXISTS organization_id;
+
+-- Of the rows the user has in several organizations the first one is kept
+DELETE FROM insight_dismissal d
+    USING insight_dismissal o
+WHERE o.user_id = d.user_id
+    AND o.insight_id = d.insight_id
+    AND o.ctid < d.ctid;
+ALTER TABLE insight_dismissal DROP CONSTRAINT insight_dismissal_pkey, ADD PRIMARY KEY (user_id, insight_id);
+ALTER TABLE insight_dismissal DROP COLUMN IF EXISTS organization_id;
+
+DELETE FROM tag t
+    USING tag o
+WHERE o.user_id = t.user_id
+    AND lower(o.name) = lower(t.name)
+    AND o.id < t.id;
+DROP INDEX IF EXISTS tag_user_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS tag_user_name_key ON tag(user_id, lower(name));
+ALTER TABLE tag DROP COLUMN IF EXISTS organization_id;
+
+DELETE FROM budget b
+    USING budget o
+WHERE o.user_id = b.user_id
+    AND COALESCE(o.category_id, 0) = COALESCE(b.category_id, 0)
+    AND COALESCE(o.service_id, 0) = COALESCE(b.service_id, 0)
+    AND o.id < b.id;
+DROP INDEX IF EXISTS budget_user_scope_key;
+CREATE UNIQUE INDEX IF NOT EXISTS budget_user_scope_key ON budget(user_id, COALESCE(category_id, 0), COALESCE(service_id, 0));
+ALTER TABLE budget DROP COLUMN IF EXISTS organization_id;
+
+DROP INDEX IF EXISTS idx_subscription_organization_id;
+ALTER TABLE subscription DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organization_member;
+DROP TABLE IF EXISTS organization;
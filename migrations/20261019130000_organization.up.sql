@@ -0,0 +1,153 @@
+-- Organizations (tenants) grouping the users, e.g. a company or a household
+CREATE TABLE IF NOT EXISTS organization (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organization_member (
+    organization_id INTEGER NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    joined_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_member_user_id ON organization_member(user_id);
+
+-- The existing rows are moved to the default organization, its members are the users they belong to
+INSERT INTO organization (id, name, created_at)
+    VALUES (1, 'Default', now());
+SELECT setval(pg_get_serial_sequence('organization', 'id'), 1);
+
+INSERT INTO organization_member (organization_id, user_id, joined_at)
+    SELECT 1, user_id, now()
+        FROM (
+            SELECT user_id FROM subscription
+            UNION SELECT user_id FROM subscription_member
+            UNION SELECT user_id FROM budget
+            UNION SELECT user_id FROM tag
+            UNION SELECT user_id FROM insight_dismissal
+        ) referenced;
+
+-- Every subscription belongs to a tenant, the default only backfills the existing rows.
+-- An organization can't be deleted while it has subscriptions.
+ALTER TABLE subscription ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organization(id);
+ALTER TABLE subscription ALTER COLUMN organization_id DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_organization_id ON subscription(organization_id);
+
+-- The budgets, tags and dismissed insights of a user are kept per tenant
+ALTER TABLE budget ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organization(id) ON DELETE CASCADE;
+ALTER TABLE budget ALTER COLUMN organization_id DROP DEFAULT;
+
+DROP INDEX IF EXISTS budget_user_scope_key;
+CREATE UNIQUE INDEX IF NOT EXISTS budget_user_scope_key ON budget(organization_id, user_id, COALESCE(category_id, 0), COALESCE(service_id, 0));
+
+ALTER TABLE tag ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organization(id) ON DELETE CASCADE;
+ALTER TABLE tag ALTER COLUMN organization_id DROP DEFAULT;
+
+DROP INDEX IF EXISTS tag_user_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS tag_user_name_key ON tag(organization_id, user_id, lower(name));
+
+ALTER TABLE insight_dismissal ADD COLUMN IF NOT EXISTS organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organization(id) ON DELETE CASCADE;
+ALTER TABLE insight_dismissal ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE insight_dismissal DROP CONSTRAINT insight_dismissal_pkey, ADD PRIMARY KEY (organization_id, user_id, insight_id);
+
+-- The merges of a tenant renamed its subscriptions only, the merges done before, or outside a tenant, have none
+ALTER TABLE service_name_merge ADD COLUMN IF NOT EXISTS organization_id INTEGER REFERENCES organization(id) ON DELETE CASCADE;
+
+-- Administrative work that isn't done on behalf of a tenant, e.g. counting the subscriptions of all the organizations
+-- or deleting a user, switches to this role with SET ROLE; so does the data work of the migrations after this one.
+-- The role can't log in, it is granted to the role running the migrations.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'tenant_bypass') THEN
+        CREATE ROLE tenant_bypass NOLOGIN;
+    END IF;
+END $$;
+
+GRANT tenant_bypass TO CURRENT_USER;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO tenant_bypass;
+GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO tenant_bypass;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO tenant_bypass;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO tenant_bypass;
+
+-- The repository scopes its queries to the tenant in app.organization_id on its own,
+-- row-level security enforces the same for roles that are neither superusers nor have BYPASSRLS.
+-- Without the setting no rows are visible, only the tenant_bypass role sees all of them.
+-- The bypass policies check current_user rather than being granted TO the role:
+-- a policy for a role applies to all its members, even those that haven't switched to it.
+ALTER TABLE organization ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization FORCE ROW LEVEL SECURITY;
+CREATE POLICY organization_tenant ON organization
+    USING (id = NULLIF(current_setting('app.organization_id', true), '')::integer);
+CREATE POLICY organization_bypass ON organization
+    USING (current_user = 'tenant_bypass');
+
+ALTER TABLE organization_member ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organization_member FORCE ROW LEVEL SECURITY;
+CREATE POLICY organization_member_tenant ON organization_member
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::integer);
+CREATE POLICY organization_member_bypass ON organization_member
+    USING (current_user = 'tenant_bypass');
+
+ALTER TABLE subscription ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_tenant ON subscription
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::integer);
+CREATE POLICY subscription_bypass ON subscription
+    USING (current_user = 'tenant_bypass');
+
+ALTER TABLE budget ENABLE ROW LEVEL SECURITY;
+ALTER TABLE budget FORCE ROW LEVEL SECURITY;
+CREATE POLICY budget_tenant ON budget
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::integer);
+CREATE POLICY budget_bypass ON budget
+    USING (current_user = 'tenant_bypass');
+
+ALTER TABLE tag ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tag FORCE ROW LEVEL SECURITY;
+CREATE POLICY tag_tenant ON tag
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::integer);
+CREATE POLICY tag_bypass ON tag
+    USING (current_user = 'tenant_bypass');
+
+ALTER TABLE insight_dismissal ENABLE ROW LEVEL SECURITY;
+ALTER TABLE insight_dismissal FORCE ROW LEVEL SECURITY;
+CREATE POLICY insight_dismissal_tenant ON insight_dismissal
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::integer);
+CREATE POLICY insight_dismissal_bypass ON insight_dismissal
+    USING (current_user = 'tenant_bypass');
+
+ALTER TABLE service_name_merge ENABLE ROW LEVEL SECURITY;
+ALTER TABLE service_name_merge FORCE ROW LEVEL SECURITY;
+CREATE POLICY service_name_merge_tenant ON service_name_merge
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::integer);
+CREATE POLICY service_name_merge_bypass ON service_name_merge
+    USING (current_user = 'tenant_bypass');
+
+-- The rows of a subscription or a budget are visible with it
+ALTER TABLE subscription_pause ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_pause FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_pause_tenant ON subscription_pause
+    USING (EXISTS (SELECT 1 FROM subscription WHERE id = subscription_id));
+
+ALTER TABLE subscription_tag ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_tag FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_tag_tenant ON subscription_tag
+    USING (EXISTS (SELECT 1 FROM subscription WHERE id = subscription_id));
+
+ALTER TABLE subscription_split ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_split FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_split_tenant ON subscription_split
+    USING (EXISTS (SELECT 1 FROM subscription WHERE id = subscription_id));
+
+ALTER TABLE subscription_member ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_member FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_member_tenant ON subscription_member
+    USING (EXISTS (SELECT 1 FROM subscription WHERE id = subscription_id));
+
+ALTER TABLE budget_alert ENABLE ROW LEVEL SECURITY;
+ALTER TABLE budget_alert FORCE ROW LEVEL SECURITY;
+CREATE POLICY budget_alert_tenant ON budget_alert
+    USING (EXISTS (SELECT 1 FROM budget WHERE id = budget_id));
@@ -0,0 +1,3 @@
+ALTER TABLE subscription DROP CONSTRAINT IF EXISTS subscription_user_id_fkey;
+
+DROP TABLE IF EXISTS users;
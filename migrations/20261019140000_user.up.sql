@@ -0,0 +1,39 @@
+-- Registered users the subscriptions belong to, "user" is a reserved word in Postgres
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    display_name VARCHAR(100) NOT NULL,
+    email VARCHAR(254),
+    -- BCP 47 language tag
+    locale VARCHAR(35) NOT NULL DEFAULT 'en',
+    -- ISO 4217 code
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    -- IANA time zone
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMPTZ NOT NULL,
+    -- Set when the personal data has been erased and the subscriptions are kept
+    anonymized_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users(lower(email));
+
+-- Placeholders for the users referenced before the registry, named after the start of their ID.
+-- The rows of all the tenants are read as the bypass role.
+SET ROLE tenant_bypass;
+
+INSERT INTO users (id, display_name, created_at)
+    SELECT user_id, 'User ' || left(user_id::text, 8), now()
+        FROM (
+            SELECT user_id FROM subscription
+            UNION SELECT user_id FROM subscription_member
+            UNION SELECT user_id FROM category WHERE user_id IS NOT NULL
+            UNION SELECT user_id FROM tag
+            UNION SELECT user_id FROM budget
+            UNION SELECT user_id FROM insight_dismissal
+            UNION SELECT user_id FROM organization_member
+        ) referenced
+ON CONFLICT (id) DO NOTHING;
+
+RESET ROLE;
+
+ALTER TABLE subscription ADD CONSTRAINT subscription_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id);
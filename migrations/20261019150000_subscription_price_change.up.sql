@@ -0,0 +1,16 @@
+-- A price the subscription is billed at from the effective date on, e.g. an announced increase
+CREATE TABLE IF NOT EXISTS subscription_price_change (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    effective_date DATE NOT NULL,
+    price INTEGER NOT NULL
+    CONSTRAINT price_change_price_not_negative
+        CHECK (price >= 0),
+    CONSTRAINT price_change_date_key UNIQUE (subscription_id, effective_date)
+);
+
+-- The changes are visible with their subscription, like the other rows of a subscription
+ALTER TABLE subscription_price_change ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_price_change FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_price_change_tenant ON subscription_price_change
+    USING (EXISTS (SELECT 1 FROM subscription WHERE id = subscription_id));
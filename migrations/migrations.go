@@ -0,0 +1,26 @@
+// Package migrations embeds the SQL migrations so that binaries do not depend on the working directory
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Postgres contains the migrations of the Postgres storage
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// SQLite contains the migrations of the SQLite storage
+var SQLite = mustSub(sqlite, "sqlite")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
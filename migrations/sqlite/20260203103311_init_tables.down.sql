@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscription_users_id;
+
+DROP TABLE IF EXISTS subscription;
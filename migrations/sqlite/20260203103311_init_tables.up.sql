@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS subscription (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_name TEXT NOT NULL CHECK (length(service_name) <= 100),
+    price INTEGER NOT NULL,
+    user_id TEXT NOT NULL,
+    start_date TEXT NOT NULL,
+    end_date TEXT
+    CONSTRAINT end_date_after_start_date
+        CHECK (end_date IS NULL OR end_date > start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_users_id ON subscription(user_id);
@@ -0,0 +1,24 @@
+CREATE TABLE subscription_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_name TEXT NOT NULL CHECK (length(service_name) <= 100),
+    price INTEGER NOT NULL,
+    user_id TEXT NOT NULL,
+    start_date TEXT NOT NULL,
+    end_date TEXT
+    CONSTRAINT end_date_after_start_date
+        CHECK (end_date IS NULL OR end_date > start_date)
+);
+
+-- Subscriptions within a single month had no valid exclusive end date
+INSERT INTO subscription_old (id, service_name, price, user_id, start_date, end_date)
+    SELECT id, service_name, price, user_id, start_date,
+        CASE
+            WHEN date(end_date, 'start of month') <= start_date THEN date(end_date, 'start of month', '+1 month')
+            ELSE date(end_date, 'start of month')
+        END
+        FROM subscription;
+
+DROP TABLE subscription;
+ALTER TABLE subscription_old RENAME TO subscription;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_users_id ON subscription(user_id);
@@ -0,0 +1,21 @@
+-- The end date is the last active day: a subscription ending in May is billed for May.
+-- SQLite cannot alter a check constraint, so the table is rebuilt.
+CREATE TABLE subscription_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_name TEXT NOT NULL CHECK (length(service_name) <= 100),
+    price INTEGER NOT NULL,
+    user_id TEXT NOT NULL,
+    start_date TEXT NOT NULL,
+    end_date TEXT
+    CONSTRAINT end_date_not_before_start_date
+        CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+INSERT INTO subscription_new (id, service_name, price, user_id, start_date, end_date)
+    SELECT id, service_name, price, user_id, start_date, date(end_date, 'start of month', '+1 month', '-1 day')
+        FROM subscription;
+
+DROP TABLE subscription;
+ALTER TABLE subscription_new RENAME TO subscription;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_users_id ON subscription(user_id);
@@ -0,0 +1,5 @@
+-- Day of the month the subscription renews on, existing subscriptions renew on their start day
+ALTER TABLE subscription ADD COLUMN billing_day INTEGER NOT NULL DEFAULT 1
+    CONSTRAINT billing_day_in_month CHECK (billing_day BETWEEN 1 AND 31);
+
+UPDATE subscription SET billing_day = CAST(strftime('%d', start_date) AS INTEGER);
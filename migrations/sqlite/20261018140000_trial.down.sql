@@ -0,0 +1,2 @@
+ALTER TABLE subscription DROP COLUMN trial_price;
+ALTER TABLE subscription DROP COLUMN trial_months;
@@ -0,0 +1,12 @@
+-- Days from start_date to end_date, both included, the subscription is not billed for.
+-- A pause without the end date lasts until the subscription is resumed.
+CREATE TABLE IF NOT EXISTS subscription_pause (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    start_date TEXT NOT NULL,
+    end_date TEXT
+    CONSTRAINT pause_end_date_not_before_start_date
+        CHECK (end_date IS NULL OR end_date >= start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_pause_subscription_id ON subscription_pause(subscription_id);
@@ -0,0 +1,2 @@
+ALTER TABLE subscription DROP COLUMN cancel_reason;
+ALTER TABLE subscription DROP COLUMN cancelled_at;
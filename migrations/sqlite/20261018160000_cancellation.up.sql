@@ -0,0 +1,3 @@
+-- A cancelled subscription ends on its end_date, cancelled_at is the day it was cancelled
+ALTER TABLE subscription ADD COLUMN cancelled_at TEXT;
+ALTER TABLE subscription ADD COLUMN cancel_reason TEXT CHECK (length(cancel_reason) <= 500);
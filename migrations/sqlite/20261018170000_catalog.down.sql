@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_subscription_service_id;
+ALTER TABLE subscription DROP COLUMN plan_id;
+ALTER TABLE subscription DROP COLUMN service_id;
+DROP TABLE IF EXISTS plan;
+DROP TABLE IF EXISTS service_alias;
+DROP TABLE IF EXISTS service;
@@ -0,0 +1,38 @@
+-- Catalog of known services, names and aliases are unique regardless of case
+CREATE TABLE IF NOT EXISTS service (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL CHECK (length(name) <= 100),
+    category TEXT CHECK (length(category) <= 100),
+    website TEXT CHECK (length(website) <= 255),
+    logo_url TEXT CHECK (length(logo_url) <= 255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS service_name_key ON service(lower(name));
+
+CREATE TABLE IF NOT EXISTS service_alias (
+    service_id INTEGER NOT NULL REFERENCES service(id) ON DELETE CASCADE,
+    alias TEXT NOT NULL CHECK (length(alias) <= 100)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS service_alias_key ON service_alias(lower(alias));
+CREATE INDEX IF NOT EXISTS idx_service_alias_service_id ON service_alias(service_id);
+
+-- Plans of a service, the price is charged every billing period
+CREATE TABLE IF NOT EXISTS plan (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_id INTEGER NOT NULL REFERENCES service(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (length(name) <= 100),
+    price INTEGER NOT NULL
+    CONSTRAINT plan_price_not_negative
+        CHECK (price >= 0),
+    billing_period TEXT NOT NULL DEFAULT 'month'
+    CONSTRAINT plan_billing_period
+        CHECK (billing_period IN ('month', 'year')),
+    CONSTRAINT plan_name_key UNIQUE (service_id, name)
+);
+
+-- Subscriptions of services missing from the catalog keep only the free-text service_name
+ALTER TABLE subscription ADD COLUMN service_id INTEGER REFERENCES service(id) ON DELETE SET NULL;
+ALTER TABLE subscription ADD COLUMN plan_id INTEGER REFERENCES plan(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_service_id ON subscription(service_id);
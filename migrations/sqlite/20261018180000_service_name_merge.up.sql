@@ -0,0 +1,8 @@
+-- Audit log of merged service name spellings, merged_names is a JSON array
+CREATE TABLE IF NOT EXISTS service_name_merge (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    canonical_name TEXT NOT NULL CHECK (length(canonical_name) <= 100),
+    merged_names TEXT NOT NULL,
+    subscription_count INTEGER NOT NULL,
+    merged_at TEXT NOT NULL
+);
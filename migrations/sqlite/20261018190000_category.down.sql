@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_subscription_category_id;
+ALTER TABLE subscription DROP COLUMN category_id;
+
+ALTER TABLE service ADD COLUMN category TEXT CHECK (length(category) <= 100);
+
+UPDATE service
+SET
+    category = (SELECT name FROM category WHERE id = service.category_id);
+
+ALTER TABLE service DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS category;
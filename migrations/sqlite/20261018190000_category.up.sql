@@ -0,0 +1,45 @@
+-- Spending categories, built-in ones have no user
+CREATE TABLE IF NOT EXISTS category (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL CHECK (length(name) <= 100),
+    parent_id INTEGER REFERENCES category(id) ON DELETE CASCADE,
+    user_id TEXT
+);
+
+-- Names of the siblings are unique regardless of case, built-in and every user's categories separately
+CREATE UNIQUE INDEX IF NOT EXISTS category_builtin_name_key ON category(COALESCE(parent_id, 0), lower(name)) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS category_user_name_key ON category(user_id, COALESCE(parent_id, 0), lower(name)) WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_category_parent_id ON category(parent_id);
+
+INSERT INTO category (name)
+    VALUES ('Streaming'), ('Software'), ('News'), ('Gaming'), ('Education'), ('Health');
+
+INSERT INTO category (name, parent_id)
+    VALUES
+        ('Video', (SELECT id FROM category WHERE name = 'Streaming' AND user_id IS NULL)),
+        ('Music', (SELECT id FROM category WHERE name = 'Streaming' AND user_id IS NULL)),
+        ('Productivity', (SELECT id FROM category WHERE name = 'Software' AND user_id IS NULL)),
+        ('Cloud storage', (SELECT id FROM category WHERE name = 'Software' AND user_id IS NULL));
+
+-- The free-text category of the catalog services becomes a built-in category
+INSERT INTO category (name)
+    SELECT min(category)
+        FROM service
+    WHERE category IS NOT NULL
+        AND lower(category) NOT IN (SELECT lower(name) FROM category)
+    GROUP BY lower(category);
+
+ALTER TABLE service ADD COLUMN category_id INTEGER REFERENCES category(id) ON DELETE SET NULL;
+
+UPDATE service
+SET
+    category_id = (SELECT min(id) FROM category WHERE user_id IS NULL AND lower(name) = lower(service.category))
+WHERE
+    category IS NOT NULL;
+
+ALTER TABLE service DROP COLUMN category;
+
+-- A subscription without a category has the category of its catalog service
+ALTER TABLE subscription ADD COLUMN category_id INTEGER REFERENCES category(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_category_id ON subscription(category_id);
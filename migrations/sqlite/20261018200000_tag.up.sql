@@ -0,0 +1,19 @@
+-- Free-form notes, e.g. login hints or the phone number to cancel by
+ALTER TABLE subscription ADD COLUMN notes TEXT CHECK (length(notes) <= 2000);
+
+-- Labels of the user's subscriptions, names are unique per user regardless of case
+CREATE TABLE IF NOT EXISTS tag (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL CHECK (length(name) <= 50)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tag_user_name_key ON tag(user_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS subscription_tag (
+    subscription_id INTEGER NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+    PRIMARY KEY (subscription_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tag_tag_id ON subscription_tag(tag_id);
@@ -0,0 +1,24 @@
+-- Monthly spending limits of the users, overall or for a category or a catalog service
+CREATE TABLE IF NOT EXISTS budget (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    category_id INTEGER REFERENCES category(id) ON DELETE CASCADE,
+    service_id INTEGER REFERENCES service(id) ON DELETE CASCADE,
+    CHECK (category_id IS NULL OR service_id IS NULL)
+);
+
+-- One budget per user and scope
+CREATE UNIQUE INDEX IF NOT EXISTS budget_user_scope_key ON budget(user_id, COALESCE(category_id, 0), COALESCE(service_id, 0));
+
+-- Alerts of the budgets reaching a threshold, at most one per budget, month and threshold
+CREATE TABLE IF NOT EXISTS budget_alert (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    budget_id INTEGER NOT NULL REFERENCES budget(id) ON DELETE CASCADE,
+    month TEXT NOT NULL,
+    threshold INTEGER NOT NULL,
+    spent INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    alerted_at TEXT NOT NULL,
+    UNIQUE (budget_id, month, threshold)
+);
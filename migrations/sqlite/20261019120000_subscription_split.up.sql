@@ -0,0 +1,15 @@
+-- How the cost of a shared subscription is split between its owner and the members
+CREATE TABLE IF NOT EXISTS subscription_split (
+    subscription_id INTEGER PRIMARY KEY REFERENCES subscription(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL CHECK (rule IN ('equal', 'percentage', 'fixed'))
+);
+
+-- Users sharing a subscription with its owner, the share is a percent or an amount per charge by the rule
+CREATE TABLE IF NOT EXISTS subscription_member (
+    subscription_id INTEGER NOT NULL REFERENCES subscription_split(subscription_id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    share INTEGER CHECK (share >= 0),
+    PRIMARY KEY (subscription_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_member_user_id ON subscription_member(user_id);
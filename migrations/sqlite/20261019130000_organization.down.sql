@@ -0,0 +1,65 @@
+ALTER TABLE service_name_merge DROP COLUMN organization_id;
+
+-- Of the rows the user has in several organizations the first one is kept
+CREATE TABLE insight_dismissal_new (
+    user_id TEXT NOT NULL,
+    insight_id TEXT NOT NULL,
+    dismissed_at TEXT NOT NULL,
+    PRIMARY KEY (user_id, insight_id)
+);
+
+INSERT OR IGNORE INTO insight_dismissal_new
+    SELECT user_id, insight_id, dismissed_at
+        FROM insight_dismissal
+    ORDER BY organization_id;
+
+DROP TABLE insight_dismissal;
+ALTER TABLE insight_dismissal_new RENAME TO insight_dismissal;
+
+CREATE TABLE tag_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL CHECK (length(name) <= 50)
+);
+
+INSERT INTO tag_new
+    SELECT id, user_id, name
+        FROM tag t
+    WHERE NOT EXISTS (SELECT 1 FROM tag o WHERE o.user_id = t.user_id AND lower(o.name) = lower(t.name) AND o.id < t.id);
+
+DROP TABLE tag;
+ALTER TABLE tag_new RENAME TO tag;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tag_user_name_key ON tag(user_id, lower(name));
+
+CREATE TABLE budget_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    category_id INTEGER REFERENCES category(id) ON DELETE CASCADE,
+    service_id INTEGER REFERENCES service(id) ON DELETE CASCADE,
+    CHECK (category_id IS NULL OR service_id IS NULL)
+);
+
+INSERT INTO budget_new
+    SELECT id, user_id, amount, category_id, service_id
+        FROM budget b
+    WHERE NOT EXISTS (
+        SELECT 1
+            FROM budget o
+        WHERE o.user_id = b.user_id
+            AND COALESCE(o.category_id, 0) = COALESCE(b.category_id, 0)
+            AND COALESCE(o.service_id, 0) = COALESCE(b.service_id, 0)
+            AND o.id < b.id
+    );
+
+DROP TABLE budget;
+ALTER TABLE budget_new RENAME TO budget;
+
+CREATE UNIQUE INDEX IF NOT EXISTS budget_user_scope_key ON budget(user_id, COALESCE(category_id, 0), COALESCE(service_id, 0));
+
+DROP INDEX IF EXISTS idx_subscription_organization_id;
+ALTER TABLE subscription DROP COLUMN organization_id;
+
+DROP TABLE IF EXISTS organization_member;
+DROP TABLE IF EXISTS organization;
@@ -0,0 +1,92 @@
+-- Organizations (tenants) grouping the users, e.g. a company or a household
+CREATE TABLE IF NOT EXISTS organization (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL CHECK (length(name) <= 100),
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organization_member (
+    organization_id INTEGER NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    joined_at TEXT NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_member_user_id ON organization_member(user_id);
+
+-- The existing rows are moved to the default organization, its members are the users they belong to
+INSERT INTO organization (id, name, created_at)
+    VALUES (1, 'Default', strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+
+INSERT INTO organization_member (organization_id, user_id, joined_at)
+    SELECT 1, user_id, strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+        FROM (
+            SELECT user_id FROM subscription
+            UNION SELECT user_id FROM subscription_member
+            UNION SELECT user_id FROM budget
+            UNION SELECT user_id FROM tag
+            UNION SELECT user_id FROM insight_dismissal
+        );
+
+-- Every subscription belongs to a tenant, the default only backfills the existing rows
+-- and is dropped when the table is rebuilt with the foreign key of the user.
+-- An organization can't be deleted while it has subscriptions.
+ALTER TABLE subscription ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1 REFERENCES organization(id);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_organization_id ON subscription(organization_id);
+
+-- The budgets, tags and dismissed insights of a user are kept per tenant.
+-- SQLite cannot change the keys of a table, so the tables are rebuilt.
+-- The migrations run without foreign key enforcement, dropping a table keeps the rows referencing it.
+CREATE TABLE budget_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    organization_id INTEGER NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    category_id INTEGER REFERENCES category(id) ON DELETE CASCADE,
+    service_id INTEGER REFERENCES service(id) ON DELETE CASCADE,
+    CHECK (category_id IS NULL OR service_id IS NULL)
+);
+
+INSERT INTO budget_new
+    SELECT id, 1, user_id, amount, category_id, service_id
+        FROM budget;
+
+DROP TABLE budget;
+ALTER TABLE budget_new RENAME TO budget;
+
+CREATE UNIQUE INDEX IF NOT EXISTS budget_user_scope_key ON budget(organization_id, user_id, COALESCE(category_id, 0), COALESCE(service_id, 0));
+
+CREATE TABLE tag_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    organization_id INTEGER NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL CHECK (length(name) <= 50)
+);
+
+INSERT INTO tag_new
+    SELECT id, 1, user_id, name
+        FROM tag;
+
+DROP TABLE tag;
+ALTER TABLE tag_new RENAME TO tag;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tag_user_name_key ON tag(organization_id, user_id, lower(name));
+
+CREATE TABLE insight_dismissal_new (
+    organization_id INTEGER NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    insight_id TEXT NOT NULL,
+    dismissed_at TEXT NOT NULL,
+    PRIMARY KEY (organization_id, user_id, insight_id)
+);
+
+INSERT INTO insight_dismissal_new
+    SELECT 1, user_id, insight_id, dismissed_at
+        FROM insight_dismissal;
+
+DROP TABLE insight_dismissal;
+ALTER TABLE insight_dismissal_new RENAME TO insight_dismissal;
+
+-- The merges of a tenant renamed its subscriptions only, the merges done before, or outside a tenant, have none
+ALTER TABLE service_name_merge ADD COLUMN organization_id INTEGER REFERENCES organization(id) ON DELETE CASCADE;
@@ -0,0 +1,40 @@
+-- The table is rebuilt without the foreign key of the user.
+-- The migrations run without foreign key enforcement, dropping the table keeps the rows referencing it.
+CREATE TABLE subscription_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_name TEXT NOT NULL CHECK (length(service_name) <= 100),
+    price INTEGER NOT NULL,
+    user_id TEXT NOT NULL,
+    start_date TEXT NOT NULL,
+    end_date TEXT
+    CONSTRAINT end_date_not_before_start_date
+        CHECK (end_date IS NULL OR end_date >= start_date),
+    billing_day INTEGER NOT NULL DEFAULT 1
+    CONSTRAINT billing_day_in_month CHECK (billing_day BETWEEN 1 AND 31),
+    trial_months INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT trial_months_not_negative CHECK (trial_months >= 0),
+    trial_price INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT trial_price_not_negative CHECK (trial_price >= 0),
+    cancelled_at TEXT,
+    cancel_reason TEXT CHECK (length(cancel_reason) <= 500),
+    service_id INTEGER REFERENCES service(id) ON DELETE SET NULL,
+    plan_id INTEGER REFERENCES plan(id) ON DELETE SET NULL,
+    category_id INTEGER REFERENCES category(id) ON DELETE SET NULL,
+    notes TEXT CHECK (length(notes) <= 2000),
+    organization_id INTEGER NOT NULL REFERENCES organization(id)
+);
+
+INSERT INTO subscription_new
+    SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price,
+        cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+        FROM subscription;
+
+DROP TABLE subscription;
+ALTER TABLE subscription_new RENAME TO subscription;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_users_id ON subscription(user_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_service_id ON subscription(service_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_category_id ON subscription(category_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_organization_id ON subscription(organization_id);
+
+DROP TABLE IF EXISTS users;
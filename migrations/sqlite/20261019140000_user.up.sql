@@ -0,0 +1,70 @@
+-- Registered users the subscriptions belong to
+CREATE TABLE IF NOT EXISTS users (
+    id TEXT PRIMARY KEY,
+    display_name TEXT NOT NULL CHECK (length(display_name) <= 100),
+    email TEXT CHECK (length(email) <= 254),
+    -- BCP 47 language tag
+    locale TEXT NOT NULL DEFAULT 'en',
+    -- ISO 4217 code
+    currency TEXT NOT NULL DEFAULT 'RUB',
+    -- IANA time zone
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    created_at TEXT NOT NULL,
+    -- Set when the personal data has been erased and the subscriptions are kept
+    anonymized_at TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users(lower(email));
+
+-- Placeholders for the users referenced before the registry, named after the start of their ID
+INSERT OR IGNORE INTO users (id, display_name, created_at)
+    SELECT user_id, 'User ' || substr(user_id, 1, 8), strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+        FROM (
+            SELECT user_id FROM subscription
+            UNION SELECT user_id FROM subscription_member
+            UNION SELECT user_id FROM category WHERE user_id IS NOT NULL
+            UNION SELECT user_id FROM tag
+            UNION SELECT user_id FROM budget
+            UNION SELECT user_id FROM insight_dismissal
+            UNION SELECT user_id FROM organization_member
+        );
+
+-- SQLite cannot add a foreign key to a column, so the table is rebuilt.
+-- The migrations run without foreign key enforcement, dropping the table keeps the rows referencing it.
+CREATE TABLE subscription_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_name TEXT NOT NULL CHECK (length(service_name) <= 100),
+    price INTEGER NOT NULL,
+    user_id TEXT NOT NULL
+    CONSTRAINT subscription_user_id_fkey REFERENCES users(id),
+    start_date TEXT NOT NULL,
+    end_date TEXT
+    CONSTRAINT end_date_not_before_start_date
+        CHECK (end_date IS NULL OR end_date >= start_date),
+    billing_day INTEGER NOT NULL DEFAULT 1
+    CONSTRAINT billing_day_in_month CHECK (billing_day BETWEEN 1 AND 31),
+    trial_months INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT trial_months_not_negative CHECK (trial_months >= 0),
+    trial_price INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT trial_price_not_negative CHECK (trial_price >= 0),
+    cancelled_at TEXT,
+    cancel_reason TEXT CHECK (length(cancel_reason) <= 500),
+    service_id INTEGER REFERENCES service(id) ON DELETE SET NULL,
+    plan_id INTEGER REFERENCES plan(id) ON DELETE SET NULL,
+    category_id INTEGER REFERENCES category(id) ON DELETE SET NULL,
+    notes TEXT CHECK (length(notes) <= 2000),
+    organization_id INTEGER NOT NULL REFERENCES organization(id)
+);
+
+INSERT INTO subscription_new
+    SELECT id, service_name, price, user_id, start_date, end_date, billing_day, trial_months, trial_price,
+        cancelled_at, cancel_reason, service_id, plan_id, category_id, notes, organization_id
+        FROM subscription;
+
+DROP TABLE subscription;
+ALTER TABLE subscription_new RENAME TO subscription;
+
+CREATE INDEX IF NOT EXISTS idx_subscription_users_id ON subscription(user_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_service_id ON subscription(service_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_category_id ON subscription(category_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_organization_id ON subscription(organization_id);
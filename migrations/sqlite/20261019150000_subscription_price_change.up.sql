@@ -0,0 +1,10 @@
+-- A price the subscription is billed at from the effective date on, e.g. an announced increase
+CREATE TABLE IF NOT EXISTS subscription_price_change (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscription(id) ON DELETE CASCADE,
+    effective_date TEXT NOT NULL,
+    price INTEGER NOT NULL
+    CONSTRAINT price_change_price_not_negative
+        CHECK (price >= 0),
+    CONSTRAINT price_change_date_key UNIQUE (subscription_id, effective_date)
+);
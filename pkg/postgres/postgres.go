@@ -4,16 +4,29 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func New(url string, poolSize int) (*pgxpool.Pool, error) {
+// Setting is a session parameter set on a connection before it is handed to a query,
+// e.g. the tenant read by row level security policies
+type Setting struct {
+	Name string
+	//Value returns the parameter for the context of the query, an empty string resets it
+	Value func(ctx context.Context) string
+}
+
+func New(url string, poolSize int, settings ...Setting) (*pgxpool.Pool, error) {
 	poolConfig, err := pgxpool.ParseConfig(url)
 	if err != nil {
 		return nil, fmt.Errorf("postgres:New:ParseConfig - %w", err)
 	}
 
 	poolConfig.MaxConns = int32(poolSize)
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
+	if len(settings) > 0 {
+		poolConfig.PrepareConn = prepareConn(settings)
+	}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
@@ -22,3 +35,24 @@ func New(url string, poolSize int) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// prepareConn applies the settings to an acquired connection.
+// The values already set on the connection are cached to skip a round trip when they do not change.
+func prepareConn(settings []Setting) func(context.Context, *pgx.Conn) (bool, error) {
+	return func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+		data := conn.PgConn().CustomData()
+		for _, setting := range settings {
+			value := setting.Value(ctx)
+			if current, ok := data[setting.Name].(string); ok && current == value {
+				continue
+			}
+			_, err := conn.Exec(ctx, "SELECT set_config($1, $2, false)", setting.Name, value)
+			if err != nil {
+				//The connection is in an unknown state
+				return false, fmt.Errorf("postgres:prepareConn:Exec - %w", err)
+			}
+			data[setting.Name] = value
+		}
+		return true, nil
+	}
+}
@@ -0,0 +1,44 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// New opens the SQLite database file at path, creating it and its directory if needed
+func New(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		err := os.MkdirAll(dir, 0o755)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite:New:MkdirAll - %w", err)
+		}
+	}
+
+	pragmas := url.Values{
+		"_pragma": {
+			"foreign_keys(1)",
+			"journal_mode(WAL)",
+			"busy_timeout(5000)",
+		},
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite:New:Open - %w", err)
+	}
+
+	//SQLite has a single writer, one connection avoids "database is locked" errors
+	db.SetMaxOpenConns(1)
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite:New:Ping - %w", err)
+	}
+
+	return db, nil
+}